@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/comparator"
+	"docs-parser/internal/core/revisions"
+	"docs-parser/internal/templates"
+	"docs-parser/internal/utils"
+	pkgcomparator "docs-parser/pkg/comparator"
+
+	"github.com/spf13/cobra"
+)
+
+var compareBatchCmd = &cobra.Command{
+	Use:   "compare-batch [目录或通配符] [模板路径]",
+	Short: "批量对比文档与Word文档模板",
+	Long: `将目录（递归）或通配符匹配的所有文档与同一模板对比。
+模板只解析一次，文档按 --workers 并发解析；DOCX文档的标注副本按输入目录结构写入输出目录，
+汇总报告（各文档问题数和得分、高频问题、未通过文档排名）写入输出目录下的 batch_report.json。
+模板为 template export 导出的YAML/JSON规则文件时按段落角色对比。
+也可以用 --template-id 引用注册表中的模板，此时不再给出模板路径。
+按 Ctrl+C 中断时保存已完成部分的报告。`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// 解析器的进度信息写到标准输出；输出JSON时改写到标准错误，使标准输出只有JSON报告
+		stdout := os.Stdout
+		asJSON, _ := cmd.Flags().GetBool("json")
+		if asJSON {
+			os.Stdout = os.Stderr
+		}
+
+		pattern := args[0]
+		templatePath, err := templateArgument(cmd, args, 1)
+		if err != nil {
+			fmt.Printf("批量对比失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		config := loadConfig()
+		options := comparator.BatchOptions{
+			Workers:         config.PerformanceOptions.MaxWorkers,
+			OutputDirectory: config.OutputOptions.OutputDirectory,
+			Progress:        os.Stdout,
+		}
+		if cmd.Flags().Changed("workers") {
+			options.Workers, _ = cmd.Flags().GetInt("workers")
+		}
+		if output, _ := cmd.Flags().GetString("output"); output != "" {
+			options.OutputDirectory = output
+		}
+		options.DocumentTimeout, _ = cmd.Flags().GetDuration("timeout")
+		options.TopViolations, _ = cmd.Flags().GetInt("top")
+		if templates.IsRuleFile(templatePath) {
+			resolved, err := resolveRuleFile(cmd, templatePath)
+			if err != nil {
+				fmt.Printf("批量对比失败: %v\n", err)
+				os.Exit(1)
+			}
+			options.TemplateRules = &resolved.Template.FormatRules
+		}
+
+		docComparator := pkgcomparator.NewComparator()
+		revisionPolicy, _ := cmd.Flags().GetString("revisions")
+		if err := docComparator.SetRevisionPolicy(revisionPolicy); err != nil {
+			fmt.Printf("批量对比失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		monitor := utils.NewPerformanceMonitor()
+		if parseCache := cache.NewParseCacheFromConfig(config); parseCache != nil {
+			docComparator.SetParseCache(parseCache)
+			defer func() {
+				parseCache.RecordTo(monitor)
+				monitor.PrintReport()
+			}()
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("正在批量对比: %s 与Word模板: %s（%d 个工作协程）\n", pattern, templatePath, options.Workers)
+		report, err := docComparator.CompareBatchContext(ctx, pattern, templatePath, options)
+		if report == nil {
+			fmt.Printf("批量对比失败: %v\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("警告: 批量对比未完成: %v\n", err)
+		}
+
+		if asJSON {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Fprintln(stdout, string(data))
+			return
+		}
+		printBatchReport(report)
+	},
+}
+
+// printBatchReport 输出批量对比汇总
+func printBatchReport(report *comparator.BatchReport) {
+	fmt.Printf("\n=== 批量对比汇总 ===\n")
+	fmt.Printf("文档: %d，通过: %d，未通过: %d，出错: %d\n", report.TotalFiles, report.PassedFiles, report.FailedFiles, report.ErrorFiles)
+	fmt.Printf("问题总数: %d，平均得分: %.1f，耗时: %v\n", report.TotalIssues, report.AverageScore, report.Duration)
+
+	if len(report.TopViolations) > 0 {
+		fmt.Println("\n高频问题:")
+		for i, violation := range report.TopViolations {
+			fmt.Printf("  %d. %s: %d 次，涉及 %d 个文档（如: %s）\n", i+1, violation.Rule, violation.Count, violation.Files, violation.Description)
+		}
+	}
+
+	fmt.Println("\n未通过的文档:")
+	failing := 0
+	for _, file := range report.Files {
+		if file.Passed {
+			continue
+		}
+		failing++
+		if file.Error != "" {
+			fmt.Printf("  %d. %s: 出错: %s\n", file.Rank, file.RelativePath, file.Error)
+			continue
+		}
+		fmt.Printf("  %d. %s: %d 个问题，得分 %.1f\n", file.Rank, file.RelativePath, file.Issues, file.Score)
+	}
+	if failing == 0 {
+		fmt.Println("  无")
+	}
+
+	if report.OutputDirectory != "" {
+		fmt.Printf("\n标注文档和报告已写入: %s\n", report.OutputDirectory)
+	}
+}
+
+func init() {
+	compareBatchCmd.Flags().Int("workers", 4, "并发解析的工作协程数，默认使用配置中的 max_workers")
+	compareBatchCmd.Flags().StringP("output", "o", "", "输出目录，默认使用配置中的 output_directory")
+	compareBatchCmd.Flags().Duration("timeout", 0, "单个文档的解析时限，如 30s，0 表示不限制")
+	compareBatchCmd.Flags().Int("top", 10, "汇总中列出的高频问题数")
+	compareBatchCmd.Flags().String("revisions", string(revisions.PolicyAccepted), "修订处理策略: accepted 或 original")
+	compareBatchCmd.Flags().Bool("json", false, "以JSON格式输出汇总报告，进度和警告信息改为输出到标准错误")
+	addTemplateIDFlags(compareBatchCmd)
+	rootCmd.AddCommand(compareBatchCmd)
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"docs-parser/internal/core/annotator"
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/comparator"
+	"docs-parser/internal/core/graphics"
+	"docs-parser/internal/core/revisions"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
+	"docs-parser/internal/templates"
 	"docs-parser/internal/utils"
 	pkgcomparator "docs-parser/pkg/comparator"
 
@@ -23,17 +33,48 @@ var compareCmd = &cobra.Command{
 	Use:   "compare [文档路径] [模板路径]",
 	Short: "对比文档与Word文档模板",
 	Long: `对比文档与Word文档模板，支持.docx、.doc、.dot、.dotx格式的模板文件。
-模板应该是Word文档，包含所需的格式规则和样式。`,
-	Args: cobra.ExactArgs(2),
+模板应该是Word文档，包含所需的格式规则和样式；也可以是 template export 导出的YAML/JSON规则文件，此时按段落角色对比。
+也可以用 --template-id 引用注册表中的模板（如 gov-notice@2），此时不再给出模板路径。
+文档含有未处理的修订时，--revisions 决定按接受全部修订（accepted，默认）还是按原始文档（original）评估。`,
+	Args: cobra.RangeArgs(1, 2),
 	Run: func(cmd *cobra.Command, args []string) {
 		docPath := args[0]
-		templatePath := args[1]
+		templatePath, err := templateArgument(cmd, args, 1)
+		if err != nil {
+			fmt.Printf("对比失败: %v\n", err)
+			os.Exit(1)
+		}
 
 		fmt.Printf("正在对比文档: %s 与Word模板: %s\n", docPath, templatePath)
 
 		// 使用对比包
 		docComparator := pkgcomparator.NewComparator()
-		report, err := docComparator.CompareWithTemplate(docPath, templatePath)
+		revisionPolicy, _ := cmd.Flags().GetString("revisions")
+		if err := docComparator.SetRevisionPolicy(revisionPolicy); err != nil {
+			fmt.Printf("对比失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 解析缓存：内容相同的模板和文档只解析一次，启用磁盘缓存时跨运行复用
+		monitor := utils.NewPerformanceMonitor()
+		if parseCache := loadParseCache(); parseCache != nil {
+			docComparator.SetParseCache(parseCache)
+			defer func() {
+				parseCache.RecordTo(monitor)
+				monitor.PrintReport()
+			}()
+		}
+		var report *comparator.ComparisonReport
+		if templates.IsRuleFile(templatePath) {
+			// 规则文件没有可逐段对照的模板文档，按段落角色对比
+			var resolved *templates.ResolvedTemplate
+			resolved, err = resolveRuleFile(cmd, templatePath)
+			if err == nil {
+				report, err = docComparator.CompareWithRulesContext(context.Background(), docPath, templatePath, &resolved.Template.FormatRules)
+			}
+		} else {
+			report, err = docComparator.CompareWithTemplate(docPath, templatePath)
+		}
 		if err != nil {
 			fmt.Printf("对比失败: %v\n", err)
 			os.Exit(1)
@@ -95,6 +136,232 @@ var annotateCmd = &cobra.Command{
 	},
 }
 
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "提取文档资源",
+	Long:  `从Word文档中提取图片等嵌入资源。`,
+}
+
+var extractImagesCmd = &cobra.Command{
+	Use:   "images [文档路径] [输出目录]",
+	Short: "提取文档中的图片",
+	Long: `将文档中的所有图片写入输出目录，按图片顺序和题注命名，内容相同的图片只保存一份。
+同时生成manifest.json清单，记录每张图片的位置、题注、替代文字、显示尺寸和来源关系。
+未指定输出目录时使用 "<文档名>_images"。`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		docPath := args[0]
+		ext := filepath.Ext(docPath)
+		outputDir := docPath[:len(docPath)-len(ext)] + "_images"
+		if len(args) > 1 {
+			outputDir = args[1]
+		}
+
+		fmt.Printf("正在提取图片: %s -> %s\n", docPath, outputDir)
+
+		manifest, err := graphics.NewDOCXGraphicsParser().ExtractImages(docPath, outputDir)
+		if err != nil {
+			fmt.Printf("提取失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, image := range manifest.Images {
+			caption := image.Caption
+			if caption == "" {
+				caption = "(无题注)"
+			}
+			if image.DuplicateOf > 0 {
+				fmt.Printf("  [%d] %s 与图%d内容相同 -> %s\n", image.Figure, caption, image.DuplicateOf, image.File)
+				continue
+			}
+			fmt.Printf("  [%d] %s -> %s\n", image.Figure, caption, image.File)
+		}
+
+		fmt.Printf("提取完成: 共 %d 处图片引用，写出 %d 个文件，清单: %s\n",
+			manifest.TotalImages, manifest.UniqueFiles, filepath.Join(outputDir, graphics.ManifestFileName))
+	},
+}
+
+var revisionsCmd = &cobra.Command{
+	Use:   "revisions [文档路径]",
+	Short: "列出文档中未处理的修订",
+	Long: `列出文档中所有未处理的修订（插入、删除、移动以及字符和段落格式修改），
+包括修订类型、作者、日期、位置和修改前的原始格式。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		docPath := args[0]
+
+		doc, err := parseWordDocument(docPath)
+		if err != nil {
+			fmt.Printf("解析失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		report := revisions.BuildReport(doc)
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				fmt.Printf("生成报告失败: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if report.Total == 0 {
+			fmt.Println("文档中没有未处理的修订")
+			return
+		}
+
+		fmt.Printf("发现 %d 处未处理的修订:\n", report.Total)
+		for _, entry := range report.Entries {
+			date := ""
+			if !entry.Date.IsZero() {
+				date = entry.Date.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  [%s] %s %s %s: %q\n", entry.Type, entry.Location, entry.Author, date, entry.Text)
+			if len(entry.Original) > 0 {
+				fmt.Printf("      原始格式: %v\n", entry.Original)
+			}
+		}
+	},
+}
+
+var revisionsAcceptCmd = &cobra.Command{
+	Use:   "accept [文档路径] [输出路径]",
+	Short: "接受修订并生成清理后的文档",
+	Long: `接受文档中的修订（插入、删除、移动和格式修改）并写出新文档，批注范围和书签保持不变。
+未指定输出路径时使用 "<文档名>_accepted.docx"。`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRevisionAction(cmd, args, revisions.ActionAccept)
+	},
+}
+
+var revisionsRejectCmd = &cobra.Command{
+	Use:   "reject [文档路径] [输出路径]",
+	Short: "拒绝修订并生成清理后的文档",
+	Long: `拒绝文档中的修订（插入、删除、移动和格式修改）并写出新文档，批注范围和书签保持不变。
+未指定输出路径时使用 "<文档名>_rejected.docx"。`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRevisionAction(cmd, args, revisions.ActionReject)
+	},
+}
+
+// loadConfig 读取配置文件，没有配置文件或读取失败时使用默认配置
+func loadConfig() *utils.Config {
+	config := utils.DefaultConfig()
+	configPath := utils.GetConfigPath()
+	if _, err := os.Stat(configPath); err == nil {
+		loaded, err := utils.LoadConfig(configPath)
+		if err != nil {
+			fmt.Printf("警告: 读取配置失败，使用默认配置: %v\n", err)
+		} else {
+			config = loaded
+		}
+	}
+	return config
+}
+
+// loadParseCache 按配置文件中的性能选项创建解析缓存
+func loadParseCache() *cache.ParseCache {
+	return cache.NewParseCacheFromConfig(loadConfig())
+}
+
+// parseWordDocument 使用OOXML文档层解析.docx文档，不输出解析过程信息
+func parseWordDocument(path string) (*types.Document, error) {
+	wordDoc := documents.NewWordprocessingDocument(path)
+	defer wordDoc.Close()
+
+	if err := wordDoc.Open(); err != nil {
+		return nil, err
+	}
+	return wordDoc.Parse()
+}
+
+// runRevisionAction 执行接受或拒绝修订命令
+func runRevisionAction(cmd *cobra.Command, args []string, action revisions.Action) {
+	docPath := args[0]
+	ext := filepath.Ext(docPath)
+	outputPath := docPath[:len(docPath)-len(ext)] + "_" + string(action) + "ed" + ext
+	if len(args) > 1 {
+		outputPath = args[1]
+	}
+	author, _ := cmd.Flags().GetString("author")
+
+	result, err := revisions.Process(docPath, outputPath, action, revisions.Options{Author: author})
+	if err != nil {
+		fmt.Printf("处理修订失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	verb := "接受"
+	if action == revisions.ActionReject {
+		verb = "拒绝"
+	}
+	if result.Total == 0 {
+		fmt.Println("没有需要处理的修订")
+	} else {
+		fmt.Printf("已%s %d 处修订:\n", verb, result.Total)
+		for revisionType, count := range result.ByType {
+			fmt.Printf("  %s: %d\n", revisionType, count)
+		}
+	}
+	fmt.Printf("已生成文档: %s\n", result.OutputPath)
+}
+
+var commentsCmd = &cobra.Command{
+	Use:   "comments [文档路径]",
+	Short: "导出文档中的批注",
+	Long: `列出文档中已有的批注，包括作者、日期、批注内容、锚定的正文段落和文本、
+回复关系以及是否已解决。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		docPath := args[0]
+
+		doc, err := parseWordDocument(docPath)
+		if err != nil {
+			fmt.Printf("解析失败: %v\n", err)
+			os.Exit(1)
+		}
+		comments := doc.Content.Comments
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			data, err := json.MarshalIndent(comments, "", "  ")
+			if err != nil {
+				fmt.Printf("生成报告失败: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		if len(comments) == 0 {
+			fmt.Println("文档中没有批注")
+			return
+		}
+
+		fmt.Printf("共 %d 条批注:\n", len(comments))
+		for _, comment := range comments {
+			indent := "  "
+			if comment.ParentID != "" {
+				indent = "      ↳ "
+			}
+			status := ""
+			if comment.Done {
+				status = " [已解决]"
+			}
+			fmt.Printf("%s#%s %s %s%s: %s\n", indent, comment.ID, comment.Author,
+				comment.Date.Format("2006-01-02 15:04"), status, strings.ReplaceAll(comment.Text, "\n", " / "))
+			if comment.ParentID == "" && comment.Anchor.Paragraph > 0 {
+				fmt.Printf("      第%d段: %q\n", comment.Anchor.Paragraph, comment.Anchor.Text)
+			}
+		}
+	},
+}
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "配置管理",
@@ -121,6 +388,7 @@ var configShowCmd = &cobra.Command{
 		fmt.Printf("  忽略大小写: %v\n", config.CompareOptions.IgnoreCase)
 		fmt.Printf("  缓存启用: %v\n", config.PerformanceOptions.EnableCaching)
 		fmt.Printf("  缓存大小: %d\n", config.PerformanceOptions.CacheSize)
+		fmt.Printf("  磁盘缓存: %v\n", config.PerformanceOptions.EnableDiskCache)
 	},
 }
 
@@ -150,10 +418,28 @@ var configPathCmd = &cobra.Command{
 }
 
 func init() {
+	compareCmd.Flags().String("revisions", string(revisions.PolicyAccepted), "修订处理策略: accepted 或 original")
+	addTemplateIDFlags(compareCmd)
 	rootCmd.AddCommand(compareCmd)
 	rootCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(annotateCmd)
 
+	// 提取命令
+	extractCmd.AddCommand(extractImagesCmd)
+	rootCmd.AddCommand(extractCmd)
+
+	// 修订命令
+	revisionsCmd.Flags().Bool("json", false, "以JSON格式输出修订报告")
+	revisionsAcceptCmd.Flags().String("author", "", "只处理指定作者的修订")
+	revisionsRejectCmd.Flags().String("author", "", "只处理指定作者的修订")
+	revisionsCmd.AddCommand(revisionsAcceptCmd)
+	revisionsCmd.AddCommand(revisionsRejectCmd)
+	rootCmd.AddCommand(revisionsCmd)
+
+	// 批注命令
+	commentsCmd.Flags().Bool("json", false, "以JSON格式输出批注")
+	rootCmd.AddCommand(commentsCmd)
+
 	// 配置命令
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configResetCmd)
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/server"
+	"docs-parser/internal/templates"
+	"docs-parser/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "以HTTP服务方式提供解析、对比、验证和标注",
+	Long: `启动HTTP服务，通过multipart上传文档（document）和模板（template，或已注册模板的 template_id）：
+  POST /api/v1/parse|compare|validate|annotate  提交任务，默认返回202和任务状态地址，?wait=true 时同步返回结果
+  GET  /api/v1/jobs/{id}                        查询任务状态和JSON报告
+  GET  /api/v1/jobs/{id}/annotated              下载标注后的DOCX文档
+  GET  /api/v1/templates                        列出已注册的模板
+  GET  /healthz, /metrics                       健康检查和运行指标
+任务队列已满时返回503，上传超过 --max-upload 时返回413。`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		options := server.DefaultOptions()
+		options.Workers = config.PerformanceOptions.MaxWorkers
+		options.DataDir = filepath.Join(config.OutputOptions.OutputDirectory, "serve")
+		options.Cache = cache.NewParseCacheFromConfig(config)
+
+		options.Addr, _ = cmd.Flags().GetString("addr")
+		if cmd.Flags().Changed("workers") {
+			options.Workers, _ = cmd.Flags().GetInt("workers")
+		}
+		options.QueueSize, _ = cmd.Flags().GetInt("queue")
+		maxUploadMB, _ := cmd.Flags().GetInt64("max-upload")
+		options.MaxUploadBytes = maxUploadMB << 20
+		options.JobTimeout, _ = cmd.Flags().GetDuration("job-timeout")
+		if dataDir, _ := cmd.Flags().GetString("data"); dataDir != "" {
+			options.DataDir = dataDir
+		}
+
+		registryDir, _ := cmd.Flags().GetString("registry")
+		if registryDir == "" {
+			registryDir = utils.GetTemplateRegistryPath()
+		}
+		registry, err := templates.OpenRegistry(registryDir)
+		if err != nil {
+			fmt.Printf("打开模板注册表失败: %v\n", err)
+			os.Exit(1)
+		}
+		options.Registry = registry
+
+		if templateDir, _ := cmd.Flags().GetString("templates"); templateDir != "" {
+			options.Templates = templates.NewTemplateManager(templateDir)
+			if err := options.Templates.LoadTemplatesFromDirectory(templateDir); err != nil {
+				fmt.Printf("加载模板失败: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("已注册 %d 个模板\n", len(options.Templates.ListTemplates()))
+		}
+
+		service, err := server.New(options)
+		if err != nil {
+			fmt.Printf("启动服务失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("服务已启动: %s（%d 个工作协程，队列 %d，数据目录 %s）\n", options.Addr, options.Workers, options.QueueSize, options.DataDir)
+		if err := service.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("服务异常退出: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("服务已停止")
+	},
+}
+
+func init() {
+	defaults := server.DefaultOptions()
+	serveCmd.Flags().String("addr", defaults.Addr, "监听地址")
+	serveCmd.Flags().Int("workers", defaults.Workers, "并发执行任务的工作协程数，默认使用配置中的 max_workers")
+	serveCmd.Flags().Int("queue", defaults.QueueSize, "等待执行的任务上限")
+	serveCmd.Flags().Int64("max-upload", defaults.MaxUploadBytes>>20, "单个请求的上传大小上限（MB）")
+	serveCmd.Flags().Duration("job-timeout", defaults.JobTimeout, "单个任务的执行时限，0 表示不限制")
+	serveCmd.Flags().String("registry", "", "模板注册表目录，默认为配置目录下的 templates，其中的模板可通过 template_id（如 gov-notice@2）引用")
+	serveCmd.Flags().String("templates", "", "模板目录，其中的Word模板可通过 template_id 引用")
+	serveCmd.Flags().String("data", "", "上传文件和标注文档的存放目录，默认为输出目录下的 serve")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docs-parser/internal/core/comparator"
+	"docs-parser/internal/templates"
+	"docs-parser/internal/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "模板注册表管理",
+	Long: `管理磁盘上的模板注册表（默认位于配置目录下的 templates）。
+每个模板有稳定的ID和语义化版本，注册时保存源Word文档副本、内容哈希和提取的格式规则快照。
+compare 和 compare-batch 可以用 --template-id id@版本 引用已注册的模板，如 gov-notice@2 表示最高的2.x.x版本。
+export 将格式规则导出为可手工编辑的YAML/JSON规则文件，规则文件可以直接作为模板使用，也可以用 add 注册；
+learn 从一组符合规范的样本文档学习规则文件；
+规则文件可以用 extends、includes 和 remove 继承和组合其他模板，explain 说明每条规则的来源。`,
+}
+
+var templateAddCmd = &cobra.Command{
+	Use:   "add [Word模板或规则文件路径]",
+	Short: "注册Word模板，已有同ID模板时注册为新版本",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		registry := openRegistry(cmd)
+
+		var options templates.RegisterOptions
+		options.ID, _ = cmd.Flags().GetString("id")
+		options.Version, _ = cmd.Flags().GetString("version")
+		options.Name, _ = cmd.Flags().GetString("name")
+		options.Description, _ = cmd.Flags().GetString("description")
+		options.Author, _ = cmd.Flags().GetString("author")
+		options.Category, _ = cmd.Flags().GetString("category")
+		options.Tags, _ = cmd.Flags().GetStringSlice("tags")
+
+		entry, err := registry.Add(args[0], options)
+		if err != nil {
+			fmt.Printf("注册模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已注册模板: %s（%s）\n", entry.Ref(), entry.Name)
+	},
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出已注册的模板",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries := openRegistry(cmd).List()
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			data, _ := json.MarshalIndent(entries, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("注册表中没有模板")
+			return
+		}
+		for _, entry := range entries {
+			fmt.Printf("%-30s %-20s %-16s %s\n", entry.Ref(), entry.Metadata.Category, strings.Join(entry.Metadata.Tags, ","), entry.Name)
+		}
+	},
+}
+
+var templateShowCmd = &cobra.Command{
+	Use:   "show [模板ID[@版本]]",
+	Short: "显示模板的元数据和格式规则",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		registry := openRegistry(cmd)
+		entry, err := registry.Resolve(args[0])
+		if err != nil {
+			fmt.Printf("查找模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		template, err := registry.Load(entry.Ref())
+		if err != nil {
+			fmt.Printf("读取模板失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			data, _ := json.MarshalIndent(template, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("模板: %s\n", entry.Ref())
+		fmt.Printf("  名称: %s\n", template.Name)
+		fmt.Printf("  描述: %s\n", template.Description)
+		fmt.Printf("  作者: %s\n", template.Metadata.Author)
+		fmt.Printf("  分类: %s\n", template.Metadata.Category)
+		fmt.Printf("  标签: %s\n", strings.Join(template.Metadata.Tags, ", "))
+		fmt.Printf("  源文档: %s\n", template.SourcePath)
+		fmt.Printf("  SHA-256: %s\n", entry.SourceHash)
+		fmt.Printf("  注册时间: %s\n", entry.AddedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("  格式规则: 字体 %d，段落 %d，表格 %d，页面 %d\n",
+			len(template.FormatRules.FontRules), len(template.FormatRules.ParagraphRules),
+			len(template.FormatRules.TableRules), len(template.FormatRules.PageRules))
+	},
+}
+
+var templateRemoveCmd = &cobra.Command{
+	Use:   "remove [模板ID[@版本]]",
+	Short: "删除模板，不指定版本时删除所有版本",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := openRegistry(cmd).Remove(args[0])
+		if err != nil {
+			fmt.Printf("删除模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		for _, entry := range removed {
+			fmt.Printf("已删除模板: %s\n", entry.Ref())
+		}
+	},
+}
+
+var templateExportCmd = &cobra.Command{
+	Use:   "export [模板ID[@版本]、Word模板或规则文件路径] [输出路径]",
+	Short: "将模板的格式规则导出为YAML或JSON规则文件，不指定输出路径时输出到标准输出",
+	Long: `导出模板的格式规则（段落角色、字体、段落设置、页面设置和表格样式）。
+规则文件可以手工编辑后作为 compare 的模板或用 template add 注册，无需Word文档；
+读取时报告未知字段、类型错误和无效取值所在的行号。
+--format 默认由输出路径的扩展名决定，输出到标准输出时为YAML。`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var template *templates.Template
+		var err error
+		resolve, _ := cmd.Flags().GetBool("resolve")
+		_, statErr := os.Stat(args[0])
+		switch {
+		case resolve && (statErr != nil || templates.IsRuleFile(args[0])):
+			var resolved *templates.ResolvedTemplate
+			if resolved, err = resolveTemplateArgument(cmd, args[0]); err == nil {
+				template = resolved.Template
+			}
+		case statErr == nil && templates.IsRuleFile(args[0]):
+			template, err = templates.ReadRuleFile(args[0])
+		case statErr == nil:
+			template, err = templates.NewTemplateManager("").LoadTemplate(args[0])
+		default:
+			template, err = openRegistry(cmd).Load(args[0])
+		}
+		if err != nil {
+			fmt.Printf("读取模板失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = templates.RuleFormatYAML
+			if len(args) == 2 {
+				format = templates.RuleFormatOf(args[1])
+			}
+		}
+		data, err := templates.MarshalRuleFile(template, format)
+		if err != nil {
+			fmt.Printf("导出模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		if len(args) < 2 {
+			fmt.Print(string(data))
+			return
+		}
+		if err := os.WriteFile(args[1], data, 0644); err != nil {
+			fmt.Printf("导出模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已导出模板: %s\n", args[1])
+	},
+}
+
+var templateLearnCmd = &cobra.Command{
+	Use:   "learn [目录或通配符] [输出规则文件]",
+	Short: "从一组符合规范的样本文档学习模板规则",
+	Long: `解析目录（递归）或通配符匹配的所有样本文档，按段落角色和格式聚类：
+每个角色段落最多的格式作为规则，其段落比例为置信度（confidence），
+段落比例达到 --variance 的其他字体、字号、对齐方式和行距作为允许的偏差（variance）写入规则文件。
+出现在少于 --min-support 比例文档中的角色不生成规则。
+不符合规则的段落比例超过 --outlier 的文档报告为离群文档，并在排除后重新学习。`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern, outputPath := args[0], args[1]
+		_, files, err := comparator.CollectBatchFiles(pattern, "")
+		if err != nil {
+			fmt.Printf("学习模板失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		config := loadConfig()
+		options := templates.LearnOptions{Workers: config.PerformanceOptions.MaxWorkers}
+		if cmd.Flags().Changed("workers") {
+			options.Workers, _ = cmd.Flags().GetInt("workers")
+		}
+		options.DocumentTimeout, _ = cmd.Flags().GetDuration("timeout")
+		options.MinSupport, _ = cmd.Flags().GetFloat64("min-support")
+		options.VarianceShare, _ = cmd.Flags().GetFloat64("variance")
+		options.OutlierThreshold, _ = cmd.Flags().GetFloat64("outlier")
+
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = filepath.Base(strings.TrimSuffix(filepath.Clean(pattern), string(filepath.Separator)))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("正在从 %d 个样本文档学习模板（%d 个工作协程）\n", len(files), options.Workers)
+		report, err := templates.NewTemplateManager("").LearnTemplateContext(ctx, name, files, options)
+		if err != nil {
+			fmt.Printf("学习模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		if id, _ := cmd.Flags().GetString("id"); id != "" {
+			report.Template.ID = id
+		}
+
+		format, _ := cmd.Flags().GetString("format")
+		if format == "" {
+			format = templates.RuleFormatOf(outputPath)
+		}
+		data, err := templates.MarshalRuleFile(report.Template, format)
+		if err == nil {
+			err = os.WriteFile(outputPath, data, 0644)
+		}
+		if err != nil {
+			fmt.Printf("写入规则文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		if reportPath, _ := cmd.Flags().GetString("report"); reportPath != "" {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			if err := os.WriteFile(reportPath, append(data, '\n'), 0644); err != nil {
+				fmt.Printf("写入学习报告失败: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		printLearnReport(report)
+		fmt.Printf("已生成规则文件: %s\n", outputPath)
+	},
+}
+
+var templateExplainCmd = &cobra.Command{
+	Use:   "explain [规则文件或模板ID[@版本]]",
+	Short: "解析模板的继承和组合，说明每条规则的来源",
+	Long: `模板可以用 extends 继承另一个模板，用 includes 组合规则片段（如标准表格样式、题注样式），
+用 remove 删除继承来的角色或规则，自身的规则按角色或规则ID覆盖继承的规则。
+引用的模板先在规则文件所在目录的其他规则文件中查找，再在注册表中查找。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		resolved, err := resolveTemplateArgument(cmd, args[0])
+		if err != nil {
+			fmt.Printf("解析模板失败: %v\n", err)
+			os.Exit(1)
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			data, _ := json.MarshalIndent(resolved, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("模板: %s\n", resolved.Template.ID)
+		fmt.Printf("应用顺序: %s\n", strings.Join(resolved.Chain, " -> "))
+		for _, origin := range resolved.Origins {
+			note := ""
+			if origin.Overrides != "" {
+				note = fmt.Sprintf("（覆盖 %s）", origin.Overrides)
+			}
+			fmt.Printf("  %-10s %-20s %s%s\n", origin.Kind, origin.Key, origin.Source, note)
+		}
+		for _, origin := range resolved.Removed {
+			fmt.Printf("  已删除: %s %s（%s 定义，%s 删除）\n", origin.Kind, origin.Key, origin.Source, origin.RemovedBy)
+		}
+	},
+}
+
+// resolveTemplateArgument 读取规则文件或注册表中的模板，并解析其继承和组合
+func resolveTemplateArgument(cmd *cobra.Command, argument string) (*templates.ResolvedTemplate, error) {
+	if _, err := os.Stat(argument); err == nil {
+		if !templates.IsRuleFile(argument) {
+			return nil, fmt.Errorf("Word模板没有继承关系，只能解析规则文件或注册表中的模板: %s", argument)
+		}
+		return resolveRuleFile(cmd, argument)
+	}
+
+	registry := openRegistry(cmd)
+	template, err := registry.Load(argument)
+	if err != nil {
+		return nil, err
+	}
+	manager := templates.NewTemplateManager("")
+	manager.SetLookup(registry.Load)
+	return manager.ResolveTemplateRules(template)
+}
+
+// resolveRuleFile 读取规则文件并解析其继承和组合，引用的模板在同目录的规则文件和注册表中查找
+func resolveRuleFile(cmd *cobra.Command, path string) (*templates.ResolvedTemplate, error) {
+	template, err := templates.ReadRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manager := templates.NewTemplateManager(filepath.Dir(path))
+	manager.SetLookup(templates.DirectoryLookup(filepath.Dir(path), func(templateID string) (*templates.Template, error) {
+		return openRegistry(cmd).Load(templateID)
+	}))
+	return manager.ResolveTemplateRules(template)
+}
+
+// printLearnReport 输出各角色的学习结果、解析失败和离群的文档
+func printLearnReport(report *templates.LearnReport) {
+	fmt.Printf("\n%-12s %6s %6s %6s  %s\n", "角色", "文档", "段落", "置信度", "主导格式")
+	for _, role := range report.Roles {
+		format := role.Clusters[0].Format
+		summary := fmt.Sprintf("%s %.1f 粗体=%v 对齐=%s 段前/段后=%.1f/%.1f 行距=%.2f",
+			format.Font.Name, format.Font.Size, format.Font.Bold, format.Alignment,
+			format.Spacing.Before, format.Spacing.After, format.Spacing.Line)
+		if !role.Learned {
+			summary = "（出现的文档过少，未生成规则）"
+		}
+		fmt.Printf("%-12s %6d %6d %5.0f%%  %s\n", role.Role, role.Documents, role.Paragraphs, role.Confidence*100, summary)
+	}
+
+	for _, document := range report.Documents {
+		if document.Error != "" {
+			fmt.Printf("解析失败: %s: %s\n", document.Path, document.Error)
+		}
+	}
+	if len(report.Outliers) == 0 {
+		fmt.Println("\n没有离群文档")
+		return
+	}
+	fmt.Printf("\n离群文档 %d 个（未参与学习）:\n", len(report.Outliers))
+	for _, document := range report.Outliers {
+		deviations := make([]string, 0, len(document.Deviations))
+		for deviation, count := range document.Deviations {
+			deviations = append(deviations, fmt.Sprintf("%s×%d", deviation, count))
+		}
+		sort.Strings(deviations)
+		fmt.Printf("  %s: %d/%d 段不符合（%.0f%%）%s\n", document.Path, document.Deviating, document.Paragraphs,
+			document.DeviationRate*100, strings.Join(deviations, ", "))
+	}
+}
+
+// openRegistry 打开 --registry 指定的注册表，默认使用配置目录下的注册表
+func openRegistry(cmd *cobra.Command) *templates.Registry {
+	dir, _ := cmd.Flags().GetString("registry")
+	if dir == "" {
+		dir = utils.GetTemplateRegistryPath()
+	}
+	registry, err := templates.OpenRegistry(dir)
+	if err != nil {
+		fmt.Printf("打开模板注册表失败: %v\n", err)
+		os.Exit(1)
+	}
+	return registry
+}
+
+// templateArgument 返回命令的模板路径：指定 --template-id 时使用注册表中的模板，
+// 否则使用第documentArgs+1个位置参数
+func templateArgument(cmd *cobra.Command, args []string, documentArgs int) (string, error) {
+	ref, _ := cmd.Flags().GetString("template-id")
+	if ref == "" {
+		if len(args) != documentArgs+1 {
+			return "", fmt.Errorf("需要指定模板路径或 --template-id")
+		}
+		return args[documentArgs], nil
+	}
+
+	if len(args) != documentArgs {
+		return "", fmt.Errorf("指定 --template-id 时不能再给出模板路径")
+	}
+	registry := openRegistry(cmd)
+	entry, err := registry.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	return registry.SourcePath(entry), nil
+}
+
+// addTemplateIDFlags 为使用模板的命令添加 --template-id 和 --registry 参数
+func addTemplateIDFlags(cmd *cobra.Command) {
+	cmd.Flags().String("template-id", "", "使用注册表中的模板，如 gov-notice 或 gov-notice@2")
+	cmd.Flags().String("registry", "", "模板注册表目录，默认为配置目录下的 templates")
+}
+
+func init() {
+	templateCmd.PersistentFlags().String("registry", "", "模板注册表目录，默认为配置目录下的 templates")
+
+	templateAddCmd.Flags().String("id", "", "模板ID，默认由文件名生成")
+	templateAddCmd.Flags().String("version", "", "语义化版本号，默认在已有最高版本上递增主版本号")
+	templateAddCmd.Flags().String("name", "", "模板名称")
+	templateAddCmd.Flags().String("description", "", "模板描述")
+	templateAddCmd.Flags().String("author", "", "模板作者，默认使用文档属性中的作者")
+	templateAddCmd.Flags().String("category", "", "模板分类")
+	templateAddCmd.Flags().StringSlice("tags", nil, "模板标签，以逗号分隔")
+	templateListCmd.Flags().Bool("json", false, "以JSON格式输出")
+	templateShowCmd.Flags().Bool("json", false, "以JSON格式输出完整模板")
+	templateExportCmd.Flags().String("format", "", "规则文件格式：yaml 或 json")
+	templateExportCmd.Flags().Bool("resolve", false, "导出解析继承和组合后的完整规则")
+	templateExplainCmd.Flags().Bool("json", false, "以JSON格式输出合并后的模板和规则来源")
+	templateLearnCmd.Flags().Int("workers", 4, "并发解析的工作协程数，默认使用配置中的 max_workers")
+	templateLearnCmd.Flags().Duration("timeout", 0, "单个文档的解析时限，0 表示不限制")
+	templateLearnCmd.Flags().Float64("min-support", 0.5, "角色至少出现在该比例的文档中才生成规则")
+	templateLearnCmd.Flags().Float64("variance", 0.1, "其他格式的段落比例达到该值时作为允许的偏差")
+	templateLearnCmd.Flags().Float64("outlier", 0.3, "不符合规则的段落比例超过该值的文档为离群文档")
+	templateLearnCmd.Flags().String("id", "", "模板ID，默认由目录名生成")
+	templateLearnCmd.Flags().String("name", "", "模板名称，默认为目录名")
+	templateLearnCmd.Flags().String("format", "", "规则文件格式：yaml 或 json，默认由扩展名决定")
+	templateLearnCmd.Flags().String("report", "", "将完整的学习报告（格式聚类、各文档偏差）写入JSON文件")
+
+	templateCmd.AddCommand(templateAddCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateShowCmd)
+	templateCmd.AddCommand(templateRemoveCmd)
+	templateCmd.AddCommand(templateExportCmd)
+	templateCmd.AddCommand(templateLearnCmd)
+	templateCmd.AddCommand(templateExplainCmd)
+	rootCmd.AddCommand(templateCmd)
+}
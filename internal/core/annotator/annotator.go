@@ -2,15 +2,22 @@ package annotator
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
+	"docs-parser/internal/formats"
 )
 
+// annotatorAuthor 标注器添加的批注作者，用于识别先前运行添加的批注
+const annotatorAuthor = "Docs Parser"
+
 // Annotator 文档标注器，负责在Word文档中添加格式问题的批注
 // 
 // 主要功能:
@@ -20,6 +27,7 @@ import (
 //   - 创建Word兼容的批注XML结构
 type Annotator struct {
 	// 可以添加配置选项，如批注样式、作者信息等
+	output io.Writer // 进度和警告信息的输出位置
 }
 
 // NewAnnotator 创建新的文档标注器
@@ -30,7 +38,20 @@ type Annotator struct {
 // 示例:
 //   annotator := NewAnnotator()
 func NewAnnotator() *Annotator {
-	return &Annotator{}
+	return &Annotator{output: os.Stdout}
+}
+
+// SetOutput 设置进度和警告信息的输出位置，nil表示不输出
+func (docAnnotator *Annotator) SetOutput(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	docAnnotator.output = w
+}
+
+// printf 输出进度和警告信息
+func (docAnnotator *Annotator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(docAnnotator.output, format, args...)
 }
 
 // AnnotateDocument 标注文档，在指定文档中添加格式问题的批注
@@ -52,7 +73,22 @@ func NewAnnotator() *Annotator {
 //   issues := []types.FormatIssue{...}
 //   err := annotator.AnnotateDocument("source.docx", "output.docx", issues)
 func (docAnnotator *Annotator) AnnotateDocument(sourcePath, outputPath string, issues []types.FormatIssue) error {
-	fmt.Printf("开始标注文档: %s -> %s\n", sourcePath, outputPath)
+	return docAnnotator.AnnotateDocumentContext(context.Background(), sourcePath, outputPath, issues)
+}
+
+// AnnotateDocumentContext 受ctx控制的AnnotateDocument
+//
+// ctx结束时停止写入并删除未完成的输出文件，返回*types.TimeoutError。
+func (docAnnotator *Annotator) AnnotateDocumentContext(ctx context.Context, sourcePath, outputPath string, issues []types.FormatIssue) error {
+	if err := types.CheckContext(ctx, "annotate document", sourcePath); err != nil {
+		return err
+	}
+	docAnnotator.printf("开始标注文档: %s -> %s\n", sourcePath, outputPath)
+
+	// 旧格式文档不能直接添加批注，解析后写为带批注的RTF
+	if isRTFOutput(sourcePath, outputPath) {
+		return docAnnotator.annotateRTF(ctx, sourcePath, outputPath, issues)
+	}
 
 	// 步骤1: 复制原文档
 	if err := docAnnotator.copyDocument(sourcePath, outputPath); err != nil {
@@ -61,13 +97,84 @@ func (docAnnotator *Annotator) AnnotateDocument(sourcePath, outputPath string, i
 
 	// 步骤2: 如果有格式问题，添加批注
 	if len(issues) > 0 {
-		if err := docAnnotator.addAnnotations(outputPath, issues); err != nil {
+		if err := docAnnotator.addAnnotationsContext(ctx, outputPath, issues); err != nil {
+			if timeoutErr, ok := types.AsTimeoutError(err); ok {
+				os.Remove(outputPath + ".tmp")
+				os.Remove(outputPath)
+				timeoutErr.Path = sourcePath
+				return timeoutErr
+			}
 			return fmt.Errorf("添加批注失败: %w", err)
 		}
-		fmt.Printf("已添加 %d 个批注\n", len(issues))
+		docAnnotator.printf("已添加 %d 个批注\n", len(issues))
+	}
+
+	docAnnotator.printf("标注文档已生成: %s\n", outputPath)
+	return nil
+}
+
+// rtfSourceExtensions 标注结果写为RTF的源文档格式
+var rtfSourceExtensions = map[string]bool{".doc": true, ".dot": true, ".rtf": true, ".wpd": true, ".xml": true, ".odt": true, ".ott": true,
+	".html": true, ".htm": true, ".mht": true, ".mhtml": true}
+
+// OutputExtension 返回源文档标注结果的扩展名：DOCX保持不变，旧格式、XML、ODF文档和网页写为RTF
+func OutputExtension(sourcePath string) string {
+	ext := filepath.Ext(sourcePath)
+	if rtfSourceExtensions[strings.ToLower(ext)] {
+		return ".rtf"
+	}
+	return ext
+}
+
+// isRTFOutput 判断标注结果是否写为RTF：源文档为旧格式，或输出路径的扩展名为.rtf
+func isRTFOutput(sourcePath, outputPath string) bool {
+	return OutputExtension(sourcePath) == ".rtf" || strings.EqualFold(filepath.Ext(outputPath), ".rtf")
+}
+
+// annotateRTF 解析源文档，将格式问题作为批注锚定到对应段落，写出带批注的RTF
+//
+// 与DOCX相同，先前运行已添加的相同批注会被跳过。
+func (docAnnotator *Annotator) annotateRTF(ctx context.Context, sourcePath, outputPath string, issues []types.FormatIssue) error {
+	doc, err := formats.NewWordParser().ParseDocument(sourcePath)
+	if err != nil {
+		return fmt.Errorf("解析文档失败: %w", err)
+	}
+	if err := types.CheckContext(ctx, "annotate document", sourcePath); err != nil {
+		return err
 	}
 
-	fmt.Printf("标注文档已生成: %s\n", outputPath)
+	comments := docAnnotator.planComments(issues, doc.Content.Comments)
+	for _, commentData := range comments {
+		n := commentData.paragraphIndex + 1
+		doc.Content.Comments = append(doc.Content.Comments, types.Comment{
+			ID:     strconv.Itoa(commentData.id),
+			Author: annotatorAuthor,
+			Text:   strings.Join(commentData.lines(), "\n"),
+			Anchor: types.CommentAnchor{Paragraph: n, EndParagraph: n},
+		})
+	}
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("无法创建目标文件: %w", err)
+	}
+	warnings, err := formats.WriteRTF(outputFile, doc)
+	if err != nil {
+		outputFile.Close()
+		os.Remove(outputPath)
+		return fmt.Errorf("写出RTF失败: %w", err)
+	}
+	for _, warning := range warnings {
+		docAnnotator.printf("警告: 标注文档 %s: %s\n", outputPath, warning)
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("写出RTF失败: %w", err)
+	}
+
+	if len(comments) > 0 {
+		docAnnotator.printf("已添加 %d 个批注\n", len(comments))
+	}
+	docAnnotator.printf("标注文档已生成: %s\n", outputPath)
 	return nil
 }
 
@@ -125,8 +232,11 @@ func (docAnnotator *Annotator) copyDocument(sourcePath, outputPath string) error
 //   5. 添加批注关系文件
 //   6. 替换原文件
 func (docAnnotator *Annotator) addAnnotations(docPath string, issues []types.FormatIssue) error {
-	fmt.Printf("DEBUG: 开始添加批注，共 %d 个问题\n", len(issues))
+	return docAnnotator.addAnnotationsContext(context.Background(), docPath, issues)
+}
 
+// addAnnotationsContext 受ctx控制的addAnnotations，每复制一个压缩包条目前检查ctx
+func (docAnnotator *Annotator) addAnnotationsContext(ctx context.Context, docPath string, issues []types.FormatIssue) error {
 	// 打开DOCX文件作为ZIP归档
 	reader, err := zip.OpenReader(docPath)
 	if err != nil {
@@ -145,9 +255,37 @@ func (docAnnotator *Annotator) addAnnotations(docPath string, issues []types.For
 	zipWriter := zip.NewWriter(tempFile)
 	defer zipWriter.Close()
 
+	// 读取已有批注，新批注追加在其后
+	existingParts := make(map[string][]byte)
+	for _, file := range reader.File {
+		switch file.Name {
+		case documents.CommentsPart, documents.CommentsExtendedPart, documents.CommentsIdsPart:
+			rc, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("无法读取 %s: %w", file.Name, err)
+			}
+			existingParts[file.Name], err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("无法读取 %s: %w", file.Name, err)
+			}
+		}
+	}
+	var existing []types.Comment
+	if content := existingParts[documents.CommentsPart]; len(content) > 0 {
+		existing, err = documents.ParseComments(content, existingParts[documents.CommentsExtendedPart], existingParts[documents.CommentsIdsPart])
+		if err != nil {
+			return fmt.Errorf("解析已有批注失败: %w", err)
+		}
+	}
+	comments := docAnnotator.planComments(issues, existing)
+
 	var relsContent []byte
 	// 复制所有文件，跳过 comments.xml 和 rels
 	for _, file := range reader.File {
+		if err := types.CheckContext(ctx, "annotate document", docPath); err != nil {
+			return err
+		}
 		if file.Name == "word/comments.xml" {
 			continue // 跳过，后面生成
 		}
@@ -158,13 +296,13 @@ func (docAnnotator *Annotator) addAnnotations(docPath string, issues []types.For
 			rc.Close()
 			continue
 		}
-		if err := docAnnotator.processFile(file, zipWriter, issues); err != nil {
+		if err := docAnnotator.processFile(file, zipWriter, comments); err != nil {
 			return fmt.Errorf("处理文件 %s 失败: %w", file.Name, err)
 		}
 	}
 	
 	// 生成批注内容文件
-	if err := docAnnotator.addCommentsFile(zipWriter, issues); err != nil {
+	if err := docAnnotator.addCommentsFile(zipWriter, comments, existingParts[documents.CommentsPart]); err != nil {
 		return fmt.Errorf("添加批注内容文件失败: %w", err)
 	}
 	
@@ -189,12 +327,11 @@ func (docAnnotator *Annotator) addAnnotations(docPath string, issues []types.For
 		return fmt.Errorf("重命名临时文件失败: %w", err)
 	}
 	
-	fmt.Printf("DEBUG: 批注添加完成\n")
 	return nil
 }
 
 // processFile 处理单个文件
-func (docAnnotator *Annotator) processFile(file *zip.File, zipWriter *zip.Writer, issues []types.FormatIssue) error {
+func (docAnnotator *Annotator) processFile(file *zip.File, zipWriter *zip.Writer, comments []CommentData) error {
 	// 打开源文件
 	rc, err := file.Open()
 	if err != nil {
@@ -210,7 +347,7 @@ func (docAnnotator *Annotator) processFile(file *zip.File, zipWriter *zip.Writer
 
 	// 如果是document.xml，添加批注
 	if file.Name == "word/document.xml" {
-		content, err = docAnnotator.addDocumentAnnotations(content, issues)
+		content, err = docAnnotator.addDocumentAnnotations(content, comments)
 		if err != nil {
 			return fmt.Errorf("添加文档批注失败: %w", err)
 		}
@@ -227,32 +364,57 @@ func (docAnnotator *Annotator) processFile(file *zip.File, zipWriter *zip.Writer
 	return err
 }
 
-// addDocumentAnnotations 在document.xml中根据问题类型插入批注引用
-func (docAnnotator *Annotator) addDocumentAnnotations(content []byte, issues []types.FormatIssue) ([]byte, error) {
-	fmt.Printf("DEBUG: addDocumentAnnotations called with %d issues\n", len(issues))
-	if len(issues) == 0 {
+// addDocumentAnnotations 在document.xml中为每个批注在对应段落插入引用
+func (docAnnotator *Annotator) addDocumentAnnotations(content []byte, comments []CommentData) ([]byte, error) {
+	if len(comments) == 0 {
 		return content, nil
 	}
 	
 	contentStr := string(content)
-	commentID := 0
 	
-	// 为每个问题生成具体的批注
-	for _, issue := range issues {
-		commentIDs := docAnnotator.generateSpecificComments(issue, commentID)
-		
-		// 为每个具体批注在对应段落插入引用
-		for _, commentData := range commentIDs {
-			contentStr = docAnnotator.insertCommentInSpecificParagraphByIndex(contentStr, commentData.id, commentData.paragraphIndex)
-		}
-		
-		commentID += len(commentIDs)
+	// 为每个具体批注在对应段落插入引用
+	for _, commentData := range comments {
+		contentStr = docAnnotator.insertCommentInSpecificParagraphByIndex(contentStr, commentData.id, commentData.paragraphIndex)
 	}
 	
-	fmt.Printf("DEBUG: Successfully inserted %d comment references\n", commentID)
 	return []byte(contentStr), nil
 }
 
+// planComments 为问题生成批注并分配ID
+//
+// 新批注的ID接在已有批注之后；先前运行已添加的相同批注（同一作者、相同内容）会被跳过。
+func (docAnnotator *Annotator) planComments(issues []types.FormatIssue, existing []types.Comment) []CommentData {
+	nextID := 0
+	added := make(map[string]bool)
+	for _, comment := range existing {
+		if id, err := strconv.Atoi(comment.ID); err == nil && id >= nextID {
+			nextID = id + 1
+		}
+		if comment.Author == annotatorAuthor {
+			added[comment.Text] = true
+		}
+	}
+
+	var comments []CommentData
+	skipped := 0
+	for _, issue := range issues {
+		for _, commentData := range docAnnotator.generateSpecificComments(issue, 0) {
+			if added[strings.Join(commentData.lines(), "\n")] {
+				skipped++
+				continue
+			}
+			commentData.id = nextID
+			nextID++
+			comments = append(comments, commentData)
+		}
+	}
+
+	if skipped > 0 {
+		docAnnotator.printf("跳过 %d 个已存在的批注\n", skipped)
+	}
+	return comments
+}
+
 // insertCommentInSpecificParagraphByIndex 在指定索引的段落插入批注
 func (docAnnotator *Annotator) insertCommentInSpecificParagraphByIndex(contentStr string, id int, paragraphIndex int) string {
 	paragraphs := docAnnotator.findAllParagraphs(contentStr)
@@ -277,7 +439,6 @@ func (docAnnotator *Annotator) insertCommentAtDocumentEnd(contentStr string, id
 	// 找到 </w:body> 位置
 	bodyEnd := strings.Index(contentStr, "</w:body>")
 	if bodyEnd == -1 {
-		fmt.Printf("DEBUG: No body end found in document\n")
 		return contentStr
 	}
 	
@@ -512,14 +673,12 @@ func (docAnnotator *Annotator) insertCommentInFirstParagraph(contentStr string,
 	// 找到第一个 <w:p>
 	pStart := strings.Index(contentStr, "<w:p")
 	if pStart == -1 {
-		fmt.Printf("DEBUG: No paragraph found in document\n")
 		return contentStr
 	}
 	
 	// 找到第一个段落的结束位置
 	pEnd := strings.Index(contentStr[pStart:], "</w:p>")
 	if pEnd == -1 {
-		fmt.Printf("DEBUG: No paragraph end found in document\n")
 		return contentStr
 	}
 	pEnd += pStart + len("</w:p>")
@@ -530,7 +689,6 @@ func (docAnnotator *Annotator) insertCommentInFirstParagraph(contentStr string,
 	// 找到第一个 <w:r>
 	rStart := strings.Index(para, "<w:r")
 	if rStart == -1 {
-		fmt.Printf("DEBUG: No run found in paragraph\n")
 		return contentStr
 	}
 	
@@ -540,7 +698,6 @@ func (docAnnotator *Annotator) insertCommentInFirstParagraph(contentStr string,
 	// 找到最后一个 </w:r>
 	rEnd := strings.LastIndex(para, "</w:r>")
 	if rEnd == -1 {
-		fmt.Printf("DEBUG: No run end found in paragraph\n")
 		return contentStr
 	}
 	
@@ -559,14 +716,12 @@ func (docAnnotator *Annotator) insertCommentInFirstRun(contentStr string, id int
 	// 找到第一个 <w:r>
 	rStart := strings.Index(contentStr, "<w:r")
 	if rStart == -1 {
-		fmt.Printf("DEBUG: No run found in document\n")
 		return contentStr
 	}
 	
 	// 找到第一个 </w:r>
 	rEnd := strings.Index(contentStr[rStart:], "</w:r>")
 	if rEnd == -1 {
-		fmt.Printf("DEBUG: No run end found in document\n")
 		return contentStr
 	}
 	rEnd += rStart + len("</w:r>")
@@ -588,14 +743,12 @@ func (docAnnotator *Annotator) insertCommentInFirstTable(contentStr string, id i
 	// 找到第一个 <w:tbl>
 	tblStart := strings.Index(contentStr, "<w:tbl")
 	if tblStart == -1 {
-		fmt.Printf("DEBUG: No table found in document\n")
 		return contentStr
 	}
 	
 	// 找到第一个 </w:tbl>
 	tblEnd := strings.Index(contentStr[tblStart:], "</w:tbl>")
 	if tblEnd == -1 {
-		fmt.Printf("DEBUG: No table end found in document\n")
 		return contentStr
 	}
 	tblEnd += tblStart + len("</w:tbl>")
@@ -617,7 +770,6 @@ func (docAnnotator *Annotator) insertCommentAtDocumentStart(contentStr string, i
 	// 找到 <w:body> 开始位置
 	bodyStart := strings.Index(contentStr, "<w:body>")
 	if bodyStart == -1 {
-		fmt.Printf("DEBUG: No body found in document\n")
 		return contentStr
 	}
 	
@@ -652,13 +804,18 @@ func (docAnnotator *Annotator) createAnnotation(issue types.FormatIssue, index i
 
 // AnnotateDocumentWithIssues 使用格式问题标注文档
 func (docAnnotator *Annotator) AnnotateDocumentWithIssues(sourcePath string, issues []types.FormatIssue) (string, error) {
+	return docAnnotator.AnnotateDocumentWithIssuesContext(context.Background(), sourcePath, issues)
+}
+
+// AnnotateDocumentWithIssuesContext 受ctx控制的AnnotateDocumentWithIssues
+func (docAnnotator *Annotator) AnnotateDocumentWithIssuesContext(ctx context.Context, sourcePath string, issues []types.FormatIssue) (string, error) {
 	// 生成输出路径
 	ext := filepath.Ext(sourcePath)
 	baseName := sourcePath[:len(sourcePath)-len(ext)]
-	outputPath := baseName + "_annotated" + ext
+	outputPath := baseName + "_annotated" + OutputExtension(sourcePath)
 
 	// 执行标注
-	err := docAnnotator.AnnotateDocument(sourcePath, outputPath, issues)
+	err := docAnnotator.AnnotateDocumentContext(ctx, sourcePath, outputPath, issues)
 	if err != nil {
 		return "", err
 	}
@@ -666,76 +823,42 @@ func (docAnnotator *Annotator) AnnotateDocumentWithIssues(sourcePath string, iss
 	return outputPath, nil
 }
 
-// addCommentsFile 添加批注内容文件
-func (docAnnotator *Annotator) addCommentsFile(zipWriter *zip.Writer, issues []types.FormatIssue) error {
-	commentsXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
-<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`
-	
-	commentID := 0
-	for _, issue := range issues {
-		// 为每个问题生成多个具体的批注
-		commentIDs := docAnnotator.generateSpecificComments(issue, commentID)
+// addCommentsFile 添加批注内容文件，existing为已有的comments.xml内容，新批注追加在其末尾
+func (docAnnotator *Annotator) addCommentsFile(zipWriter *zip.Writer, comments []CommentData, existing []byte) error {
+	var newComments strings.Builder
+	for _, commentData := range comments {
+		// 构建正确的XML结构
+		commentXML := fmt.Sprintf(`
+	<w:comment w:id="%d" w:author="%s" w:date="2024-01-01T00:00:00Z">`, commentData.id, annotatorAuthor)
 		
-		for _, commentData := range commentIDs {
-			// 构建具体的批注内容
-			commentContent := ""
-			
-			// 具体位置描述
-			commentContent += fmt.Sprintf("段落 %d 格式问题", commentData.paragraphIndex+1)
-			
-			// 具体问题描述
-			commentContent += fmt.Sprintf("\\n问题: %s", commentData.problem)
-			
-			// 当前格式
-			if commentData.currentFormat != "" {
-				commentContent += fmt.Sprintf("\\n当前: %s", commentData.currentFormat)
-			}
-			
-			// 期望格式
-			if commentData.expectedFormat != "" {
-				commentContent += fmt.Sprintf("\\n期望: %s", commentData.expectedFormat)
-			}
-			
-			// 修复建议
-			if commentData.suggestion != "" {
-				commentContent += fmt.Sprintf("\\n建议: %s", commentData.suggestion)
-			}
-			
+		for _, line := range commentData.lines() {
 			// 转义XML特殊字符
-			commentContent = strings.ReplaceAll(commentContent, "&", "&amp;")
-			commentContent = strings.ReplaceAll(commentContent, "<", "&lt;")
-			commentContent = strings.ReplaceAll(commentContent, ">", "&gt;")
-			commentContent = strings.ReplaceAll(commentContent, "\"", "&quot;")
-			commentContent = strings.ReplaceAll(commentContent, "'", "&apos;")
+			line = strings.ReplaceAll(line, "&", "&amp;")
+			line = strings.ReplaceAll(line, "<", "&lt;")
+			line = strings.ReplaceAll(line, ">", "&gt;")
+			line = strings.ReplaceAll(line, "\"", "&quot;")
+			line = strings.ReplaceAll(line, "'", "&apos;")
 			
-			// 将换行符分割成多个段落
-			lines := strings.Split(commentContent, "\\n")
-			
-			// 构建正确的XML结构
-			commentXML := fmt.Sprintf(`
-	<w:comment w:id="%d" w:author="Docs Parser" w:date="2024-01-01T00:00:00Z">`, commentData.id)
-			
-			for _, line := range lines {
-				if line != "" {
-					commentXML += fmt.Sprintf(`
+			commentXML += fmt.Sprintf(`
 		<w:p>
 			<w:r>
 				<w:t xml:space="preserve">%s</w:t>
 			</w:r>
 		</w:p>`, line)
-				}
-			}
-			
-			commentXML += `
-	</w:comment>`
-			commentsXML += commentXML
 		}
 		
-		commentID += len(commentIDs)
+		commentXML += `
+	</w:comment>`
+		newComments.WriteString(commentXML)
 	}
 	
-	commentsXML += `
+	commentsXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` + newComments.String() + `
 </w:comments>`
+	if end := strings.LastIndex(string(existing), "</w:comments>"); end != -1 {
+		commentsXML = string(existing[:end]) + newComments.String() + string(existing[end:])
+	}
+	
 	commentsFile, err := zipWriter.Create("word/comments.xml")
 	if err != nil {
 		return err
@@ -754,6 +877,33 @@ type CommentData struct {
 	suggestion     string
 }
 
+// lines 返回批注内容的各行（未转义），每行对应批注中的一个段落
+func (commentData CommentData) lines() []string {
+	lines := []string{
+		// 具体位置描述
+		fmt.Sprintf("段落 %d 格式问题", commentData.paragraphIndex+1),
+		// 具体问题描述
+		fmt.Sprintf("问题: %s", commentData.problem),
+	}
+	
+	// 当前格式
+	if commentData.currentFormat != "" {
+		lines = append(lines, fmt.Sprintf("当前: %s", commentData.currentFormat))
+	}
+	
+	// 期望格式
+	if commentData.expectedFormat != "" {
+		lines = append(lines, fmt.Sprintf("期望: %s", commentData.expectedFormat))
+	}
+	
+	// 修复建议
+	if commentData.suggestion != "" {
+		lines = append(lines, fmt.Sprintf("建议: %s", commentData.suggestion))
+	}
+	
+	return lines
+}
+
 // generateSpecificComments 为每个问题生成具体的批注
 func (docAnnotator *Annotator) generateSpecificComments(issue types.FormatIssue, startID int) []CommentData {
 	var comments []CommentData
@@ -1,8 +1,14 @@
 package annotator
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
+	"docs-parser/internal/formats"
+	"docs-parser/internal/testutil"
 )
 
 // TestNewAnnotator 测试创建新的标注器
@@ -152,4 +158,153 @@ func TestAnnotator_ExtractExpectedFormat(t *testing.T) {
 	if format == "" {
 		t.Error("期望提取到格式信息")
 	}
-} 
\ No newline at end of file
+} 
+// writeCommentedDocx 创建带有审阅者批注（含回复和解决状态）的测试文档
+func writeCommentedDocx(t *testing.T) string {
+	t.Helper()
+
+	parts := testutil.DocxParts(`
+<w:p><w:r><w:t>标题</w:t></w:r></w:p>
+<w:p><w:commentRangeStart w:id="3"/><w:r><w:t>需要修改</w:t></w:r><w:r><w:t>的句子</w:t></w:r><w:commentRangeEnd w:id="3"/><w:r><w:commentReference w:id="3"/></w:r><w:r><w:commentReference w:id="4"/></w:r></w:p>
+`)
+	parts["word/_rels/document.xml.rels"] = testutil.RelationshipsXML
+	parts["word/comments.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:w14="http://schemas.microsoft.com/office/word/2010/wordml">
+<w:comment w:id="3" w:author="王老师" w:initials="WLS" w:date="2024-05-01T08:00:00Z"><w:p w14:paraId="0A000001"><w:r><w:t>请改写</w:t></w:r></w:p></w:comment>
+<w:comment w:id="4" w:author="学生" w:date="2024-05-02T08:00:00Z"><w:p w14:paraId="0A000002"><w:r><w:t>已修改</w:t></w:r></w:p></w:comment>
+</w:comments>`
+	parts["word/commentsExtended.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<w15:commentsEx xmlns:w15="http://schemas.microsoft.com/office/word/2012/wordml">
+<w15:commentEx w15:paraId="0A000001" w15:done="1"/>
+<w15:commentEx w15:paraId="0A000002" w15:paraIdParent="0A000001" w15:done="0"/>
+</w15:commentsEx>`
+	parts["word/commentsIds.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<w16cid:commentsIds xmlns:w16cid="http://schemas.microsoft.com/office/word/2016/wordml/cid">
+<w16cid:commentId w16cid:paraId="0A000001" w16cid:durableId="1234ABCD"/>
+</w16cid:commentsIds>`
+	return testutil.WriteZip(t, filepath.Join(t.TempDir(), "commented.docx"), parts)
+}
+
+// parseTestComments 解析文档中的批注
+func parseTestComments(t *testing.T, path string) []types.Comment {
+	t.Helper()
+
+	wd := documents.NewWordprocessingDocument(path)
+	if err := wd.Open(); err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	defer wd.Close()
+
+	doc, err := wd.Parse()
+	if err != nil {
+		t.Fatalf("解析文档失败: %v", err)
+	}
+	return doc.Content.Comments
+}
+
+// TestParseExistingComments 测试解析已有批注、回复和解决状态
+func TestParseExistingComments(t *testing.T) {
+	comments := parseTestComments(t, writeCommentedDocx(t))
+	if len(comments) != 2 {
+		t.Fatalf("期望2条批注，实际 %d", len(comments))
+	}
+
+	first := comments[0]
+	if first.Author != "王老师" || first.Initials != "WLS" || first.Text != "请改写" || !first.Done || first.DurableID != "1234ABCD" {
+		t.Errorf("批注解析不正确: %+v", first)
+	}
+	if first.Anchor.Paragraph != 2 || first.Anchor.Text != "需要修改的句子" {
+		t.Errorf("批注锚定范围不正确: %+v", first.Anchor)
+	}
+	if len(first.Replies) != 1 || first.Replies[0] != "4" {
+		t.Errorf("批注回复不正确: %v", first.Replies)
+	}
+
+	reply := comments[1]
+	if reply.ParentID != "3" || reply.Done || reply.Anchor.Paragraph != 2 {
+		t.Errorf("回复解析不正确: %+v", reply)
+	}
+}
+
+// TestAnnotator_SkipsExistingComments 测试重复标注时保留已有批注且不重复添加
+func TestAnnotator_SkipsExistingComments(t *testing.T) {
+	annotator := NewAnnotator()
+	issues := []types.FormatIssue{
+		{
+			ID:          "paragraph_format_2",
+			Type:        "paragraph",
+			Location:    "第2段",
+			Rule:        "paragraph_format",
+			Current:     map[string]interface{}{"alignment": "left"},
+			Expected:    map[string]interface{}{"alignment": "center"},
+			Suggestions: []string{"调整对齐方式"},
+		},
+	}
+
+	source := writeCommentedDocx(t)
+	first := filepath.Join(t.TempDir(), "first.docx")
+	if err := annotator.AnnotateDocument(source, first, issues); err != nil {
+		t.Fatalf("第一次标注失败: %v", err)
+	}
+	second := filepath.Join(t.TempDir(), "second.docx")
+	if err := annotator.AnnotateDocument(first, second, issues); err != nil {
+		t.Fatalf("第二次标注失败: %v", err)
+	}
+
+	comments := parseTestComments(t, second)
+	if len(comments) != 3 {
+		t.Fatalf("期望保留2条审阅批注并只添加1条标注批注，实际 %d", len(comments))
+	}
+	added := comments[2]
+	if added.Author != annotatorAuthor || added.ID != "5" || added.Anchor.Paragraph != 2 {
+		t.Errorf("新增批注应接在已有批注ID之后并锚定到第2段: %+v", added)
+	}
+}
+
+// TestAnnotator_AnnotateRTF 测试为RTF文档添加批注，结果写为带\annotation的RTF
+func TestAnnotator_AnnotateRTF(t *testing.T) {
+	annotator := NewAnnotator()
+	issues := []types.FormatIssue{
+		{
+			ID:          "paragraph_format_2",
+			Type:        "paragraph",
+			Location:    "第2段",
+			Rule:        "paragraph_format",
+			Current:     map[string]interface{}{"alignment": "left"},
+			Expected:    map[string]interface{}{"alignment": "center"},
+			Suggestions: []string{"调整对齐方式"},
+		},
+	}
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.rtf")
+	content := `{\rtf1\ansi{\fonttbl{\f0 Arial;}}\pard First\par\pard Second\par}`
+	if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	first, err := annotator.AnnotateDocumentWithIssues(source, issues)
+	if err != nil {
+		t.Fatalf("第一次标注失败: %v", err)
+	}
+	if filepath.Ext(first) != ".rtf" {
+		t.Fatalf("RTF文档的标注结果应为.rtf，实际 %s", first)
+	}
+	second := filepath.Join(dir, "second.rtf")
+	if err := annotator.AnnotateDocument(first, second, issues); err != nil {
+		t.Fatalf("第二次标注失败: %v", err)
+	}
+
+	doc, err := formats.NewRtfParser().ParseDocument(second)
+	if err != nil {
+		t.Fatalf("解析标注结果失败: %v", err)
+	}
+	if len(doc.Content.Paragraphs) != 2 || doc.Content.Paragraphs[1].Text != "Second" {
+		t.Fatalf("标注不应改变正文: %+v", doc.Content.Paragraphs)
+	}
+	if len(doc.Content.Comments) != 1 {
+		t.Fatalf("重复标注不应重复添加批注，实际 %+v", doc.Content.Comments)
+	}
+	if comment := doc.Content.Comments[0]; comment.Author != annotatorAuthor || comment.Anchor.Paragraph != 2 {
+		t.Errorf("批注应由标注器添加并锚定到第2段: %+v", comment)
+	}
+}
@@ -0,0 +1,265 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/utils"
+)
+
+// ParserVersion 解析器版本，解析结果的结构或内容变化时递增，使旧的缓存条目失效
+const ParserVersion = "12"
+
+// Stats 缓存统计
+type Stats struct {
+	Hits      int64 `json:"hits"`      // 内存命中
+	DiskHits  int64 `json:"disk_hits"` // 磁盘命中
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Entries   int   `json:"entries"`
+	Capacity  int   `json:"capacity"`
+}
+
+// HitRate 命中率（内存和磁盘命中合计）
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.DiskHits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.DiskHits) / float64(total)
+}
+
+// entry LRU链表中的缓存条目
+type entry struct {
+	key string
+	doc *types.Document
+}
+
+// ParseCache 解析结果缓存，键由文件内容哈希、解析器版本和解析选项组成
+//
+// 内存中按LRU保留最多capacity个文档；dir非空时同时把解析结果以JSON持久化到该目录。
+// 缓存返回的文档在多次调用间共享，调用方不应修改。nil缓存等同于不缓存。
+type ParseCache struct {
+	mu       sync.Mutex
+	capacity int
+	dir      string
+	entries  map[string]*list.Element
+	order    *list.List // 链表头部为最近使用
+	stats    Stats
+}
+
+// NewParseCache 创建解析缓存，capacity为0时不使用内存缓存，dir为空时不使用磁盘缓存
+func NewParseCache(capacity int, dir string) *ParseCache {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &ParseCache{
+		capacity: capacity,
+		dir:      dir,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		stats:    Stats{Capacity: capacity},
+	}
+}
+
+// NewParseCacheFromConfig 按性能选项创建解析缓存，未启用缓存时返回nil
+//
+// 磁盘缓存位于输出目录下的.cache/parse。
+func NewParseCacheFromConfig(config *utils.Config) *ParseCache {
+	if config == nil || !config.PerformanceOptions.EnableCaching {
+		return nil
+	}
+
+	dir := ""
+	if config.PerformanceOptions.EnableDiskCache {
+		dir = filepath.Join(config.OutputOptions.OutputDirectory, ".cache", "parse")
+	}
+	return NewParseCache(config.PerformanceOptions.CacheSize, dir)
+}
+
+// Key 计算缓存键：文件内容的SHA-256、解析器版本和解析选项共同决定
+func Key(filePath, options string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	content := sha256.New()
+	if _, err := io.Copy(content, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	key := sha256.New()
+	fmt.Fprintf(key, "%x\x00%s\x00%s", content.Sum(nil), ParserVersion, options)
+	return hex.EncodeToString(key.Sum(nil)), nil
+}
+
+// Parse 返回文件的解析结果，缓存未命中时调用parse解析并写入缓存
+//
+// options为影响解析结果的选项（如解析器类型），不同选项的结果分别缓存。
+func (pc *ParseCache) Parse(filePath, options string, parse func(string) (*types.Document, error)) (*types.Document, error) {
+	if pc == nil {
+		return parse(filePath)
+	}
+
+	key, err := Key(filePath, options)
+	if err != nil {
+		// 无法计算哈希时不使用缓存，由解析器报告文件错误
+		return parse(filePath)
+	}
+
+	if doc, ok := pc.Get(key); ok {
+		return doc, nil
+	}
+
+	doc, err := parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	pc.Put(key, doc)
+	return doc, nil
+}
+
+// Get 按键读取缓存，先查内存再查磁盘，磁盘命中的文档会放入内存
+func (pc *ParseCache) Get(key string) (*types.Document, bool) {
+	pc.mu.Lock()
+	if element, exists := pc.entries[key]; exists {
+		pc.order.MoveToFront(element)
+		pc.stats.Hits++
+		pc.mu.Unlock()
+		return element.Value.(*entry).doc, true
+	}
+	pc.mu.Unlock()
+
+	doc, ok := pc.readDisk(key)
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if !ok {
+		pc.stats.Misses++
+		return nil, false
+	}
+	pc.stats.DiskHits++
+	pc.add(key, doc)
+	return doc, true
+}
+
+// Put 写入缓存，磁盘写入失败时只保留内存缓存
+func (pc *ParseCache) Put(key string, doc *types.Document) {
+	pc.mu.Lock()
+	pc.add(key, doc)
+	pc.mu.Unlock()
+
+	pc.writeDisk(key, doc)
+}
+
+// add 将文档放入内存LRU，超出容量时淘汰最久未使用的条目，调用方需持有锁
+func (pc *ParseCache) add(key string, doc *types.Document) {
+	if pc.capacity == 0 {
+		return
+	}
+
+	if element, exists := pc.entries[key]; exists {
+		element.Value.(*entry).doc = doc
+		pc.order.MoveToFront(element)
+		return
+	}
+
+	pc.entries[key] = pc.order.PushFront(&entry{key: key, doc: doc})
+	for pc.order.Len() > pc.capacity {
+		oldest := pc.order.Back()
+		pc.order.Remove(oldest)
+		delete(pc.entries, oldest.Value.(*entry).key)
+		pc.stats.Evictions++
+	}
+}
+
+// diskPath 返回缓存条目的磁盘路径
+func (pc *ParseCache) diskPath(key string) string {
+	return filepath.Join(pc.dir, key+".json")
+}
+
+// readDisk 从磁盘读取缓存条目，未启用磁盘缓存或条目损坏时返回false
+func (pc *ParseCache) readDisk(key string) (*types.Document, bool) {
+	if pc.dir == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(pc.diskPath(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var doc types.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false
+	}
+	return &doc, true
+}
+
+// writeDisk 将文档写入磁盘缓存，先写临时文件再重命名，避免并发读取到不完整的条目
+func (pc *ParseCache) writeDisk(key string, doc *types.Document) {
+	if pc.dir == "" {
+		return
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(pc.dir, 0755); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(pc.dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), pc.diskPath(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Stats 返回缓存统计
+func (pc *ParseCache) Stats() Stats {
+	if pc == nil {
+		return Stats{}
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	stats := pc.stats
+	stats.Entries = pc.order.Len()
+	return stats
+}
+
+// RecordTo 将缓存统计写入性能监控器，随性能报告输出
+func (pc *ParseCache) RecordTo(monitor *utils.PerformanceMonitor) {
+	if pc == nil || monitor == nil {
+		return
+	}
+
+	stats := pc.Stats()
+	monitor.SetCounter("解析缓存命中", stats.Hits)
+	monitor.SetCounter("解析缓存磁盘命中", stats.DiskHits)
+	monitor.SetCounter("解析缓存未命中", stats.Misses)
+	monitor.SetCounter("解析缓存淘汰", stats.Evictions)
+	monitor.SetCounter("解析缓存条目", int64(stats.Entries))
+}
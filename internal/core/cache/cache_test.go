@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/utils"
+)
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+// countingParser 返回记录解析次数的解析函数
+func countingParser(count *int) func(string) (*types.Document, error) {
+	return func(path string) (*types.Document, error) {
+		*count++
+		return &types.Document{Metadata: types.DocumentMetadata{Title: filepath.Base(path)}}, nil
+	}
+}
+
+// TestParseCache_LRU 测试内容哈希键和LRU淘汰
+func TestParseCache_LRU(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, dir, "a.docx", "内容A")
+	b := writeFile(t, dir, "b.docx", "内容B")
+	sameAsA := writeFile(t, dir, "copy.docx", "内容A")
+
+	pc := NewParseCache(1, "")
+	parses := 0
+	parse := countingParser(&parses)
+
+	pc.Parse(a, ".docx", parse)
+	pc.Parse(sameAsA, ".docx", parse) // 内容相同，命中
+	if parses != 1 {
+		t.Errorf("内容相同的文件应只解析一次，实际 %d 次", parses)
+	}
+
+	pc.Parse(a, ".doc", parse) // 解析选项不同，未命中
+	pc.Parse(b, ".docx", parse)
+	pc.Parse(a, ".docx", parse) // 容量为1，已被淘汰
+	if parses != 4 {
+		t.Errorf("期望解析4次，实际 %d 次", parses)
+	}
+
+	stats := pc.Stats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Evictions != 3 || stats.Entries != 1 {
+		t.Errorf("缓存统计不正确: %+v", stats)
+	}
+
+	monitor := utils.NewPerformanceMonitor()
+	pc.RecordTo(monitor)
+	if monitor.GetCounter("解析缓存命中") != 1 || monitor.GetCounter("解析缓存未命中") != 4 {
+		t.Error("缓存统计应写入性能监控器")
+	}
+}
+
+// TestParseCache_Disk 测试磁盘缓存跨实例复用
+func TestParseCache_Disk(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "template.docx", "模板")
+	cacheDir := filepath.Join(dir, "cache")
+
+	parses := 0
+	parse := countingParser(&parses)
+	if _, err := NewParseCache(10, cacheDir).Parse(path, ".docx", parse); err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	// 新实例的内存缓存为空，从磁盘读取
+	pc := NewParseCache(10, cacheDir)
+	doc, err := pc.Parse(path, ".docx", parse)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if parses != 1 || doc.Metadata.Title != "template.docx" {
+		t.Errorf("应从磁盘缓存读取解析结果: 解析 %d 次, 标题 %q", parses, doc.Metadata.Title)
+	}
+	if stats := pc.Stats(); stats.DiskHits != 1 || stats.Entries != 1 {
+		t.Errorf("磁盘命中统计不正确: %+v", stats)
+	}
+
+	// 文件内容变化后缓存失效
+	writeFile(t, dir, "template.docx", "修改后的模板")
+	pc.Parse(path, ".docx", parse)
+	if parses != 2 {
+		t.Errorf("文件内容变化后应重新解析，实际解析 %d 次", parses)
+	}
+}
+
+// TestNewParseCacheFromConfig 测试按配置创建缓存
+func TestNewParseCacheFromConfig(t *testing.T) {
+	config := utils.DefaultConfig()
+	config.PerformanceOptions.EnableCaching = false
+	if NewParseCacheFromConfig(config) != nil {
+		t.Error("未启用缓存时应返回nil")
+	}
+
+	var pc *ParseCache
+	parses := 0
+	pc.Parse("不存在.docx", ".docx", countingParser(&parses))
+	if parses != 1 {
+		t.Error("nil缓存应直接解析")
+	}
+
+	config.PerformanceOptions.EnableCaching = true
+	config.PerformanceOptions.CacheSize = 5
+	config.PerformanceOptions.EnableDiskCache = true
+	config.OutputOptions.OutputDirectory = "out"
+	pc = NewParseCacheFromConfig(config)
+	if pc == nil || pc.capacity != 5 || pc.dir != filepath.Join("out", ".cache", "parse") {
+		t.Errorf("按配置创建的缓存不正确: %+v", pc)
+	}
+}
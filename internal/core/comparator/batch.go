@@ -0,0 +1,409 @@
+package comparator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docs-parser/internal/core/annotator"
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/revisions"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/sniff"
+)
+
+// BatchReportFile 批量对比报告在输出目录中的文件名
+const BatchReportFile = "batch_report.json"
+
+// batchExtensions 批量对比收集的文档扩展名，.xml文件还要求内容为Word XML文档，见isBatchInput
+var batchExtensions = map[string]bool{
+	".docx":  true,
+	".doc":   true,
+	".rtf":   true,
+	".wpd":   true,
+	".dot":   true,
+	".dotx":  true,
+	".docm":  true,
+	".dotm":  true,
+	".odt":   true,
+	".ott":   true,
+	".xml":   true,
+	".html":  true,
+	".htm":   true,
+	".mht":   true,
+	".mhtml": true,
+}
+
+// BatchOptions 批量对比选项
+type BatchOptions struct {
+	Workers         int           // 并发解析的工作协程数，小于1时为1
+	OutputDirectory string        // 标注文档和汇总报告的输出目录，按输入目录结构存放标注文档
+	DocumentTimeout time.Duration // 单个文档的解析时限，0表示不限制
+	TopViolations   int           // 汇总报告中列出的高频问题数，0表示10
+	Progress        io.Writer     // 标注进度和警告信息的输出位置，需支持并发写入，nil表示不输出
+
+	// TemplateRules 不为nil时按其中的角色规则对比，templatePath只用于报告，不再解析模板文档
+	TemplateRules *types.FormatRules
+}
+
+// BatchFileResult 单个文档的对比结果
+type BatchFileResult struct {
+	Rank          int            `json:"rank"` // 按得分从低到高的排名，1为最差
+	Path          string         `json:"path"`
+	RelativePath  string         `json:"relative_path"` // 相对输入根目录的路径
+	AnnotatedPath string         `json:"annotated_path,omitempty"`
+	Issues        int            `json:"issues"`
+	Severities    map[string]int `json:"severities,omitempty"` // 各严重程度的问题数
+	Score         float64        `json:"score"`
+	Passed        bool           `json:"passed"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// RuleViolation 语料中某类问题的出现情况
+type RuleViolation struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"` // 该类问题的一个示例描述
+	Count       int    `json:"count"`       // 出现次数
+	Files       int    `json:"files"`       // 涉及的文档数
+}
+
+// BatchReport 批量对比汇总报告
+type BatchReport struct {
+	TemplatePath    string            `json:"template_path"`
+	InputRoot       string            `json:"input_root"`
+	OutputDirectory string            `json:"output_directory"`
+	TotalFiles      int               `json:"total_files"`
+	PassedFiles     int               `json:"passed_files"`
+	FailedFiles     int               `json:"failed_files"` // 存在格式问题的文档数
+	ErrorFiles      int               `json:"error_files"`  // 无法解析或对比的文档数
+	TotalIssues     int               `json:"total_issues"`
+	AverageScore    float64           `json:"average_score"`
+	TopViolations   []RuleViolation   `json:"top_violations"`
+	Files           []BatchFileResult `json:"files"` // 按排名排序，未通过的文档在前
+	Duration        time.Duration     `json:"duration"`
+}
+
+// CollectBatchFiles 收集批量对比的输入文档，pattern为目录或通配符
+//
+// 目录会被递归遍历；通配符按filepath.Glob匹配，输入根目录为通配符之前的目录部分。
+// exclude目录（通常是输出目录）、已标注的文档（文件名以_annotated结尾）和Word锁文件（~$开头）会被跳过。
+func CollectBatchFiles(pattern, exclude string) (root string, files []string, err error) {
+	excluded := func(path string) bool {
+		return exclude != "" && (path == filepath.Clean(exclude) || strings.HasPrefix(path, filepath.Clean(exclude)+string(filepath.Separator)))
+	}
+
+	if info, statErr := os.Stat(pattern); statErr == nil && info.IsDir() {
+		root = pattern
+		err = filepath.WalkDir(pattern, func(path string, entry os.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if excluded(filepath.Clean(path)) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.IsDir() && isBatchInput(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to walk directory: %w", err)
+		}
+	} else {
+		matches, globErr := filepath.Glob(pattern)
+		if globErr != nil {
+			return "", nil, fmt.Errorf("invalid pattern: %w", globErr)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() && isBatchInput(match) && !excluded(filepath.Clean(match)) {
+				files = append(files, match)
+			}
+		}
+		root = globRoot(pattern)
+	}
+
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no documents found: %s", pattern)
+	}
+	sort.Strings(files)
+	return root, files, nil
+}
+
+// isBatchInput 是否为需要对比的文档
+func isBatchInput(path string) bool {
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(name))
+	if !batchExtensions[ext] || strings.HasPrefix(name, "~$") {
+		return false
+	}
+	if strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), "_annotated") {
+		return false
+	}
+	// 目录中的.xml文件多为配置等其他XML，只收集Flat OPC和Word 2003 XML文档
+	if ext == ".xml" {
+		result, err := sniff.File(path)
+		return err == nil && (result.Format == sniff.FlatOPC || result.Format == sniff.WordML)
+	}
+	return true
+}
+
+// globRoot 返回通配符中第一个通配字符之前的目录
+func globRoot(pattern string) string {
+	index := strings.IndexAny(pattern, "*?[")
+	if index == -1 {
+		return filepath.Dir(pattern)
+	}
+	return filepath.Dir(pattern[:index+1])
+}
+
+// CompareBatchContext 将多个文档与同一模板对比，生成标注文档和汇总报告
+//
+// 模板只解析一次；文档由parser.BatchProcessor按options.Workers并发解析，
+// DOCX文档的标注副本按相对root的路径写入输出目录，汇总报告写入输出目录下的batch_report.json。
+// 单个文档解析失败或超时只记录在该文档的结果中；ctx结束时返回已完成部分的报告和*types.TimeoutError。
+func (dc *DocumentComparator) CompareBatchContext(ctx context.Context, root string, files []string, templatePath string, options BatchOptions) (*BatchReport, error) {
+	start := time.Now()
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.TopViolations <= 0 {
+		options.TopViolations = 10
+	}
+
+	var template *types.Document
+	if options.TemplateRules == nil {
+		var err error
+		template, err = dc.wordParser.ParseDocumentContext(ctx, templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		template = revisions.Apply(template, dc.revisionPolicy)
+	}
+
+	results := make([]BatchFileResult, len(files))
+	issues := make([][]types.FormatIssue, len(files))
+	for i, file := range files {
+		results[i] = BatchFileResult{Path: file, RelativePath: relativePath(root, file)}
+	}
+
+	// 标注在独立的协程中进行，与后续文档的解析并行，并发数与解析相同
+	var annotating sync.WaitGroup
+	slots := make(chan struct{}, options.Workers)
+	batchAnnotator := annotator.NewAnnotator()
+	batchAnnotator.SetOutput(options.Progress)
+
+	batch := parser.NewBatchProcessor(options.Workers)
+	batch.SetParser(dc.wordParser)
+	batch.SetDocumentTimeout(options.DocumentTimeout)
+	batchErr := batch.ProcessFilesWithCallbackContext(ctx, files, func(index int, doc *types.Document, err error) {
+		result := &results[index]
+		if err != nil {
+			result.Error = err.Error()
+			return
+		}
+
+		var report *ComparisonReport
+		if options.TemplateRules != nil {
+			report = dc.compareWithRules(doc, options.TemplateRules, files[index], templatePath)
+		} else {
+			var err error
+			report, err = dc.compareWithParsedTemplate(doc, template, files[index], templatePath)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+		}
+		issues[index] = report.Issues
+
+		outputExt := annotator.OutputExtension(files[index])
+		if options.OutputDirectory == "" || (strings.ToLower(outputExt) != ".docx" && outputExt != ".rtf") {
+			return
+		}
+		annotating.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer annotating.Done()
+			defer func() { <-slots }()
+
+			outputPath := filepath.Join(options.OutputDirectory, result.RelativePath)
+			outputPath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + outputExt
+			if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+				result.Error = fmt.Sprintf("failed to create output directory: %v", err)
+				return
+			}
+			if err := batchAnnotator.AnnotateDocumentContext(ctx, files[index], outputPath, report.Issues); err != nil {
+				result.Error = err.Error()
+				return
+			}
+			result.AnnotatedPath = outputPath
+		}()
+	})
+	annotating.Wait()
+
+	report := summarizeBatch(results, issues, options.TopViolations)
+	report.TemplatePath = templatePath
+	report.InputRoot = root
+	report.OutputDirectory = options.OutputDirectory
+	report.Duration = time.Since(start)
+
+	if options.OutputDirectory != "" {
+		if err := writeBatchReport(report, filepath.Join(options.OutputDirectory, BatchReportFile)); err != nil {
+			return report, err
+		}
+	}
+
+	return report, batchErr
+}
+
+// relativePath 返回file相对root的路径，无法计算时使用文件名
+func relativePath(root, file string) string {
+	rel, err := filepath.Rel(root, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(file)
+	}
+	return rel
+}
+
+// ScoreIssues 按问题严重程度计算0-100的得分，权重与验证器的合规率一致
+func ScoreIssues(issues []types.FormatIssue) float64 {
+	penalty := 0.0
+	for _, issue := range issues {
+		switch issue.Severity {
+		case string(SeverityCritical):
+			penalty += 4
+		case string(SeverityHigh):
+			penalty += 3
+		case string(SeverityMedium):
+			penalty += 2
+		default:
+			penalty += 1
+		}
+	}
+	if penalty >= 100 {
+		return 0
+	}
+	return 100 - penalty
+}
+
+// issueIndexSuffix 问题ID末尾的段落、文本序号
+var issueIndexSuffix = regexp.MustCompile(`(_\d+)+$`)
+
+// violationKey 问题的分类键：去掉序号的问题ID，如font_format_3_1归为font_format
+func violationKey(issue types.FormatIssue) string {
+	if key := issueIndexSuffix.ReplaceAllString(issue.ID, ""); key != "" {
+		return key
+	}
+	return issue.Rule
+}
+
+// summarizeBatch 汇总各文档的对比结果，计算得分、排名和高频问题
+func summarizeBatch(results []BatchFileResult, issues [][]types.FormatIssue, top int) *BatchReport {
+	report := &BatchReport{TotalFiles: len(results)}
+	violations := make(map[string]*RuleViolation)
+	scored := 0
+
+	for i := range results {
+		result := &results[i]
+		if result.Error != "" && issues[i] == nil {
+			report.ErrorFiles++
+			continue
+		}
+
+		result.Issues = len(issues[i])
+		result.Score = ScoreIssues(issues[i])
+		result.Passed = result.Issues == 0 && result.Error == ""
+		report.TotalIssues += result.Issues
+		report.AverageScore += result.Score
+		scored++
+		if result.Passed {
+			report.PassedFiles++
+		} else {
+			report.FailedFiles++
+		}
+
+		seen := make(map[string]bool)
+		for _, issue := range issues[i] {
+			if result.Severities == nil {
+				result.Severities = make(map[string]int)
+			}
+			result.Severities[issue.Severity]++
+
+			key := violationKey(issue)
+			violation, exists := violations[key]
+			if !exists {
+				violation = &RuleViolation{Rule: key, Description: issue.Description}
+				violations[key] = violation
+			}
+			violation.Count++
+			if !seen[key] {
+				seen[key] = true
+				violation.Files++
+			}
+		}
+	}
+	if scored > 0 {
+		report.AverageScore /= float64(scored)
+	}
+
+	for _, violation := range violations {
+		report.TopViolations = append(report.TopViolations, *violation)
+	}
+	sort.Slice(report.TopViolations, func(i, j int) bool {
+		a, b := report.TopViolations[i], report.TopViolations[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Rule < b.Rule
+	})
+	if len(report.TopViolations) > top {
+		report.TopViolations = report.TopViolations[:top]
+	}
+
+	// 排名：出错的文档在前，其余按得分从低到高、问题数从多到少
+	report.Files = append(report.Files, results...)
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if (a.Error != "") != (b.Error != "") {
+			return a.Error != ""
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.Issues != b.Issues {
+			return a.Issues > b.Issues
+		}
+		return a.RelativePath < b.RelativePath
+	})
+	for i := range report.Files {
+		report.Files[i].Rank = i + 1
+	}
+
+	return report
+}
+
+// writeBatchReport 将汇总报告以JSON写入path
+func writeBatchReport(report *BatchReport, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write batch report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/testutil"
+)
+
+// TestCollectBatchFiles 测试目录和通配符输入的收集
+func TestCollectBatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.docx", "sub/b.doc", "sub/a_annotated.docx", "~$lock.docx", "notes.txt", "out/c.docx"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	root, files, err := CollectBatchFiles(dir, filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatalf("收集目录失败: %v", err)
+	}
+	if root != dir || len(files) != 2 || files[0] != filepath.Join(dir, "a.docx") || files[1] != filepath.Join(dir, "sub", "b.doc") {
+		t.Errorf("目录收集结果不正确: root=%s files=%v", root, files)
+	}
+
+	root, files, err = CollectBatchFiles(filepath.Join(dir, "sub", "*.doc*"), "")
+	if err != nil {
+		t.Fatalf("收集通配符失败: %v", err)
+	}
+	if root != filepath.Join(dir, "sub") || len(files) != 1 || relativePath(root, files[0]) != "b.doc" {
+		t.Errorf("通配符收集结果不正确: root=%s files=%v", root, files)
+	}
+
+	if _, _, err := CollectBatchFiles(filepath.Join(dir, "*.rtf"), ""); err == nil {
+		t.Error("没有匹配的文档时应返回错误")
+	}
+
+	// 网页和Word XML文档参与对比，其他XML文件不收集
+	webDir := t.TempDir()
+	for name, content := range map[string]string{
+		"page.html":   "<html><body>x</body></html>",
+		"page.htm":    "<html><body>x</body></html>",
+		"word.xml":    `<?xml version="1.0"?><w:wordDocument xmlns:w="http://schemas.microsoft.com/office/word/2003/wordml"/>`,
+		"config.xml":  `<?xml version="1.0"?><configuration/>`,
+		"package.xml": `<?xml version="1.0"?><pkg:package xmlns:pkg="http://schemas.microsoft.com/office/2006/xmlPackage"/>`,
+	} {
+		if err := os.WriteFile(filepath.Join(webDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+	_, files, err = CollectBatchFiles(webDir, "")
+	if err != nil {
+		t.Fatalf("收集目录失败: %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	if strings.Join(names, " ") != "package.xml page.htm page.html word.xml" {
+		t.Errorf("应收集网页和Word XML文档，实际 %v", names)
+	}
+}
+
+// TestSummarizeBatch 测试得分、排名和高频问题统计
+func TestSummarizeBatch(t *testing.T) {
+	results := []BatchFileResult{
+		{RelativePath: "pass.docx"},
+		{RelativePath: "minor.docx"},
+		{RelativePath: "major.docx"},
+		{RelativePath: "broken.docx", Error: "解析失败"},
+	}
+	issues := [][]types.FormatIssue{
+		{},
+		{{ID: "paragraph_spacing_2", Severity: "low"}},
+		{
+			{ID: "font_format_0_1", Severity: "medium", Description: "字体不符"},
+			{ID: "font_format_3_0", Severity: "medium"},
+			{ID: "paragraph_spacing_1", Severity: "low"},
+		},
+		nil,
+	}
+
+	report := summarizeBatch(results, issues, 1)
+	if report.PassedFiles != 1 || report.FailedFiles != 2 || report.ErrorFiles != 1 || report.TotalIssues != 4 {
+		t.Errorf("汇总统计不正确: %+v", report)
+	}
+
+	order := []string{"broken.docx", "major.docx", "minor.docx", "pass.docx"}
+	for i, file := range report.Files {
+		if file.RelativePath != order[i] || file.Rank != i+1 {
+			t.Errorf("第 %d 名应为 %s，实际 %s", i+1, order[i], file.RelativePath)
+		}
+	}
+	if report.Files[1].Score != 95 || report.Files[1].Severities["medium"] != 2 {
+		t.Errorf("得分或严重程度统计不正确: %+v", report.Files[1])
+	}
+
+	// font_format和paragraph_spacing各2次，按名称排序后只保留1个
+	if len(report.TopViolations) != 1 || report.TopViolations[0].Rule != "font_format" || report.TopViolations[0].Files != 1 {
+		t.Errorf("高频问题不正确: %+v", report.TopViolations)
+	}
+}
+
+// TestDocumentComparator_CompareBatchProgress 测试标注进度写到options.Progress
+func TestDocumentComparator_CompareBatchProgress(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in")
+	os.MkdirAll(input, 0755)
+	templatePath := testutil.WriteDocx(t, filepath.Join(dir, "template.docx"), `<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:t>正文</w:t></w:r></w:p>`)
+	testutil.WriteDocx(t, filepath.Join(input, "a.docx"), `<w:p><w:pPr><w:jc w:val="left"/></w:pPr><w:r><w:t>正文</w:t></w:r></w:p>`)
+
+	root, files, err := CollectBatchFiles(input, "")
+	if err != nil {
+		t.Fatalf("收集目录失败: %v", err)
+	}
+	var progress bytes.Buffer
+	report, err := NewDocumentComparator().CompareBatchContext(context.Background(), root, files, templatePath, BatchOptions{
+		Workers:         1,
+		OutputDirectory: filepath.Join(dir, "out"),
+		Progress:        &progress,
+	})
+	if err != nil {
+		t.Fatalf("批量对比失败: %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].Issues == 0 || report.Files[0].AnnotatedPath == "" {
+		t.Fatalf("应对比并标注文档: %+v", report.Files)
+	}
+	if !strings.Contains(progress.String(), "标注文档已生成: "+report.Files[0].AnnotatedPath) {
+		t.Errorf("标注进度应写到Progress，实际 %q", progress.String())
+	}
+}
@@ -1,11 +1,15 @@
 package comparator
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"docs-parser/internal/core/annotator"
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/revisions"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
 	"docs-parser/internal/formats"
 	"docs-parser/internal/templates"
 )
@@ -15,6 +19,7 @@ type DocumentComparator struct {
 	annotator       *annotator.Annotator
 	wordParser      *formats.WordParser
 	templateManager *templates.TemplateManager
+	revisionPolicy  revisions.Policy
 }
 
 // NewDocumentComparator 创建新的文档对比器
@@ -23,22 +28,193 @@ func NewDocumentComparator() *DocumentComparator {
 		annotator:       annotator.NewAnnotator(),
 		wordParser:      formats.NewWordParser(),
 		templateManager: templates.NewTemplateManager(""),
+		revisionPolicy:  revisions.PolicyAccepted,
 	}
 }
 
+// SetRevisionPolicy 设置修订处理策略，对比前按该策略处理文档和模板中的修订
+func (dc *DocumentComparator) SetRevisionPolicy(policy revisions.Policy) {
+	dc.revisionPolicy = policy
+}
+
+// RevisionPolicy 返回当前的修订处理策略
+func (dc *DocumentComparator) RevisionPolicy() revisions.Policy {
+	return dc.revisionPolicy
+}
+
+// SetParseCache 设置解析结果缓存，同一模板在多次对比中只解析一次
+func (dc *DocumentComparator) SetParseCache(c *cache.ParseCache) {
+	dc.wordParser.SetCache(c)
+}
+
+// ParseCache 返回解析结果缓存
+func (dc *DocumentComparator) ParseCache() *cache.ParseCache {
+	return dc.wordParser.Cache()
+}
+
 // CompareWithTemplate 与模板进行对比
 func (dc *DocumentComparator) CompareWithTemplate(docPath, templatePath string) (*ComparisonReport, error) {
-	// 解析文档
-	doc, err := dc.wordParser.ParseDocument(docPath)
+	return dc.CompareWithTemplateContext(context.Background(), docPath, templatePath)
+}
+
+// CompareWithTemplateContext 受ctx控制的CompareWithTemplate
+//
+// 解析阶段超时或取消时返回*types.TimeoutError；对比已完成而标注被中断时，
+// 返回不含标注文档路径的报告和*types.TimeoutError。
+//
+// 模板完整解析；文档流式解析，逐段按修订策略处理后与模板对照，不保留整个正文。
+func (dc *DocumentComparator) CompareWithTemplateContext(ctx context.Context, docPath, templatePath string) (*ComparisonReport, error) {
+	// 解析模板
+	template, err := dc.wordParser.ParseDocumentContext(ctx, templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	template = revisions.Apply(template, dc.revisionPolicy)
+
+	// 流式解析文档
+	comparison := dc.newStreamComparison(template)
+	_, err = dc.wordParser.StreamDocumentContext(ctx, docPath, func(event *documents.StreamEvent) error {
+		if event.Type == documents.EventParagraph {
+			comparison.add(*event.Paragraph)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse document: %w", err)
 	}
 
-	// 解析模板
-	template, err := dc.wordParser.ParseDocument(templatePath)
+	report := comparison.report(docPath, templatePath)
+	return report, dc.annotateReport(ctx, report, docPath)
+}
+
+// streamComparison 文档流式解析时逐段与模板对照，问题的顺序与CompareFormatRules一致：先段落格式，后字体
+type streamComparison struct {
+	dc              *DocumentComparator
+	template        *types.Document
+	paragraphs      *revisions.ParagraphStream
+	index           int // 已对照的段落数
+	paragraphIssues []types.FormatIssue
+	fontIssues      []types.FormatIssue
+}
+
+// newStreamComparison 创建与模板对照的流式对比，模板应已按修订策略处理
+func (dc *DocumentComparator) newStreamComparison(template *types.Document) *streamComparison {
+	return &streamComparison{
+		dc:         dc,
+		template:   template,
+		paragraphs: revisions.NewParagraphStream(dc.revisionPolicy),
+	}
+}
+
+// add 按修订策略处理文档的下一个段落，处理完成的段落与模板中的同序号段落对照
+func (sc *streamComparison) add(paragraph types.Paragraph) {
+	if merged, ok := sc.paragraphs.Add(paragraph); ok {
+		sc.compare(merged)
+	}
+}
+
+// compare 对照文档的第sc.index个段落，模板中没有对应段落时不检查
+func (sc *streamComparison) compare(paragraph types.Paragraph) {
+	i := sc.index
+	sc.index++
+
+	if templateRules := sc.template.FormatRules.ParagraphRules; i < len(templateRules) {
+		docRule := types.ParagraphRule{Alignment: paragraph.Alignment, Spacing: paragraph.Spacing}
+		sc.dc.compareParagraphFormat(i, docRule, templateRules[i], &sc.paragraphIssues)
+	}
+	if templateParagraphs := sc.template.Content.Paragraphs; i < len(templateParagraphs) {
+		sc.dc.compareParagraphFonts(i, &paragraph, &templateParagraphs[i], &sc.fontIssues)
+	}
+}
+
+// report 对照暂存的最后一个段落，生成不含标注文档的对比报告
+func (sc *streamComparison) report(docPath, templatePath string) *ComparisonReport {
+	if last, ok := sc.paragraphs.Flush(); ok {
+		sc.compare(last)
+	}
+
+	formatComparison := &FormatComparison{
+		FontRules:      []RuleComparison{},
+		ParagraphRules: []RuleComparison{},
+		TableRules:     []RuleComparison{},
+		PageRules:      []RuleComparison{},
+		StyleRules:     []RuleComparison{},
+		Issues:         []types.FormatIssue{},
+	}
+	formatComparison.Issues = append(formatComparison.Issues, sc.paragraphIssues...)
+	formatComparison.Issues = append(formatComparison.Issues, sc.fontIssues...)
+
+	return &ComparisonReport{
+		DocumentPath:      docPath,
+		TemplatePath:      templatePath,
+		Issues:            formatComparison.Issues,
+		FormatComparison:  formatComparison,
+		ContentComparison: &ContentComparison{Issues: []types.FormatIssue{}},
+		StyleComparison:   &StyleComparison{Issues: []types.FormatIssue{}},
+	}
+}
+
+// CompareWithRulesContext 对比文档与不含Word文档的模板规则，如从规则文件导入的模板
+//
+// 没有模板文档可以逐段对照，因此按段落角色检查rules.RoleRules；rulesPath只用于报告。
+func (dc *DocumentComparator) CompareWithRulesContext(ctx context.Context, docPath, rulesPath string, rules *types.FormatRules) (*ComparisonReport, error) {
+	doc, err := dc.wordParser.ParseDocumentContext(ctx, docPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %w", err)
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	report := dc.compareWithRules(doc, rules, docPath, rulesPath)
+	return report, dc.annotateReport(ctx, report, docPath)
+}
+
+// compareWithRules 按段落角色对比已解析的文档和模板规则，不生成标注文档
+func (dc *DocumentComparator) compareWithRules(doc *types.Document, rules *types.FormatRules, docPath, rulesPath string) *ComparisonReport {
+	doc = revisions.Apply(doc, dc.revisionPolicy)
+
+	formatComparison := &FormatComparison{
+		FontRules:      []RuleComparison{},
+		ParagraphRules: []RuleComparison{},
+		TableRules:     []RuleComparison{},
+		PageRules:      []RuleComparison{},
+		StyleRules:     []RuleComparison{},
+		Issues:         []types.FormatIssue{},
 	}
+	dc.compareRoleFormats(&doc.Content, rules.RoleRules, &formatComparison.Issues)
+
+	return &ComparisonReport{
+		DocumentPath:      docPath,
+		TemplatePath:      rulesPath,
+		Issues:            formatComparison.Issues,
+		FormatComparison:  formatComparison,
+		ContentComparison: &ContentComparison{Issues: []types.FormatIssue{}},
+		StyleComparison:   &StyleComparison{Issues: []types.FormatIssue{}},
+	}
+}
+
+// annotateReport 报告中有格式问题时生成标注文档
+//
+// 标注被中断时返回*types.TimeoutError，其他标注错误只输出警告。
+func (dc *DocumentComparator) annotateReport(ctx context.Context, report *ComparisonReport, docPath string) error {
+	if len(report.Issues) == 0 {
+		return nil
+	}
+
+	annotatedPath, err := dc.annotator.AnnotateDocumentWithIssuesContext(ctx, docPath, report.Issues)
+	if _, ok := types.AsTimeoutError(err); ok {
+		return err
+	}
+	if err != nil {
+		fmt.Printf("警告: 生成标注文档失败: %v\n", err)
+		return nil
+	}
+	report.AnnotatedDocumentPath = annotatedPath
+	fmt.Printf("已生成标注文档: %s\n", annotatedPath)
+	return nil
+}
+
+// compareWithParsedTemplate 对比已解析的文档和模板，模板应已按修订策略处理，不生成标注文档
+func (dc *DocumentComparator) compareWithParsedTemplate(doc, template *types.Document, docPath, templatePath string) (*ComparisonReport, error) {
+	doc = revisions.Apply(doc, dc.revisionPolicy)
 
 	// 只对比格式规则（文本运行级别的字体比较）
 	formatComparison, err := dc.CompareFormatRules(&doc.FormatRules, &template.FormatRules, doc, template)
@@ -47,43 +223,35 @@ func (dc *DocumentComparator) CompareWithTemplate(docPath, templatePath string)
 	}
 
 	// 创建对比报告
-	report := &ComparisonReport{
+	return &ComparisonReport{
 		DocumentPath:     docPath,
 		TemplatePath:     templatePath,
 		Issues:           formatComparison.Issues,
 		FormatComparison: formatComparison,
 		ContentComparison: &ContentComparison{Issues: []types.FormatIssue{}},
 		StyleComparison:  &StyleComparison{Issues: []types.FormatIssue{}},
-	}
-
-	// 如果有格式问题，自动生成标注文档
-	fmt.Printf("DEBUG: 发现 %d 个问题，准备生成标注文档\n", len(formatComparison.Issues))
-	if len(formatComparison.Issues) > 0 {
-		fmt.Printf("DEBUG: 开始生成标注文档...\n")
-		annotatedPath, err := dc.annotator.AnnotateDocumentWithIssues(docPath, formatComparison.Issues)
-		if err != nil {
-			fmt.Printf("警告: 生成标注文档失败: %v\n", err)
-		} else {
-			report.AnnotatedDocumentPath = annotatedPath
-			fmt.Printf("已生成标注文档: %s\n", annotatedPath)
-		}
-	}
-
-	return report, nil
+	}, nil
 }
 
 // CompareDocuments 对比两个文档
 func (dc *DocumentComparator) CompareDocuments(doc1Path, doc2Path string) (*ComparisonReport, error) {
+	return dc.CompareDocumentsContext(context.Background(), doc1Path, doc2Path)
+}
+
+// CompareDocumentsContext 受ctx控制的CompareDocuments，中断时的返回值同CompareWithTemplateContext
+func (dc *DocumentComparator) CompareDocumentsContext(ctx context.Context, doc1Path, doc2Path string) (*ComparisonReport, error) {
 	// 解析两个文档
-	doc1, err := dc.wordParser.ParseDocument(doc1Path)
+	doc1, err := dc.wordParser.ParseDocumentContext(ctx, doc1Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse first document: %w", err)
 	}
 
-	doc2, err := dc.wordParser.ParseDocument(doc2Path)
+	doc2, err := dc.wordParser.ParseDocumentContext(ctx, doc2Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse second document: %w", err)
 	}
+	doc1 = revisions.Apply(doc1, dc.revisionPolicy)
+	doc2 = revisions.Apply(doc2, dc.revisionPolicy)
 
 	// 比较格式规则
 	formatComparison, err := dc.CompareFormatRules(&doc1.FormatRules, &doc2.FormatRules, doc1, doc2)
@@ -91,6 +259,10 @@ func (dc *DocumentComparator) CompareDocuments(doc1Path, doc2Path string) (*Comp
 		return nil, fmt.Errorf("format comparison failed: %w", err)
 	}
 
+	if err := types.CheckContext(ctx, "compare documents", doc1Path); err != nil {
+		return nil, err
+	}
+
 	// 比较内容
 	contentComparison, err := dc.CompareContent(&doc1.Content, &doc2.Content)
 	if err != nil {
@@ -121,7 +293,10 @@ func (dc *DocumentComparator) CompareDocuments(doc1Path, doc2Path string) (*Comp
 
 	// 如果有格式问题，自动生成标注文档
 	if len(allIssues) > 0 {
-		annotatedPath, err := dc.annotator.AnnotateDocumentWithIssues(doc1Path, allIssues)
+		annotatedPath, err := dc.annotator.AnnotateDocumentWithIssuesContext(ctx, doc1Path, allIssues)
+		if _, ok := types.AsTimeoutError(err); ok {
+			return report, err
+		}
 		if err != nil {
 			fmt.Printf("警告: 生成标注文档失败: %v\n", err)
 		} else {
@@ -146,84 +321,76 @@ func (dc *DocumentComparator) CompareFormatRules(docRules, templateRules *types.
 	}
 
 	// 对比段落格式（对齐、缩进、间距等）
-	fmt.Printf("DEBUG: 开始对比段落格式，文档段落数: %d, 模板段落数: %d\n", len(docRules.ParagraphRules), len(templateRules.ParagraphRules))
 	dc.compareParagraphFormats(docRules.ParagraphRules, templateRules.ParagraphRules, &comparison.Issues)
 
 	// 对比文本运行级别的字体信息（合并同一文本的多个问题）
 	if doc != nil && template != nil {
-		fmt.Printf("DEBUG: 开始对比内容字体，文档段落数: %d, 模板段落数: %d\n", len(doc.Content.Paragraphs), len(template.Content.Paragraphs))
 		dc.compareContentFonts(&doc.Content, &template.Content, &comparison.Issues)
 	}
 
-	fmt.Printf("DEBUG: 格式对比问题数量: %d\n", len(comparison.Issues))
 	return comparison, nil
 }
 
 // compareParagraphFormats 对比段落格式
 func (dc *DocumentComparator) compareParagraphFormats(docRules, templateRules []types.ParagraphRule, issues *[]types.FormatIssue) {
-	fmt.Printf("DEBUG: 开始对比段落格式，文档段落数: %d, 模板段落数: %d\n", len(docRules), len(templateRules))
-	
 	// 为每个段落生成具体的格式对比
 	for i, templateRule := range templateRules {
 		if i < len(docRules) {
-			docRule := docRules[i]
-			
-			fmt.Printf("DEBUG: 对比段落 %d: 文档对齐=%s, 模板对齐=%s\n", i+1, docRule.Alignment, templateRule.Alignment)
-			
-			// 检查对齐方式
-			if docRule.Alignment != templateRule.Alignment {
-				currentFormat := map[string]interface{}{
-					"alignment": docRule.Alignment,
-					"spacing":   docRule.Spacing,
-				}
-				expectedFormat := map[string]interface{}{
-					"alignment": templateRule.Alignment,
-					"spacing":   templateRule.Spacing,
-				}
-				
-				*issues = append(*issues, types.FormatIssue{
-					ID:          fmt.Sprintf("paragraph_format_%d", i),
-					Type:        "paragraph",
-					Severity:    "medium",
-					Location:    fmt.Sprintf("第%d段", i+1),
-					Description: fmt.Sprintf("第%d段对齐方式不符合模板要求", i+1),
-					Current:     currentFormat,
-					Expected:    expectedFormat,
-					Rule:        "paragraph_format",
-					Suggestions: []string{"调整段落对齐方式以匹配模板"},
-				})
-				fmt.Printf("DEBUG: 发现段落对齐问题\n")
-			}
-			
-			// 检查间距
-			if docRule.Spacing.Before != templateRule.Spacing.Before || 
-			   docRule.Spacing.After != templateRule.Spacing.After {
-				currentFormat := map[string]interface{}{
-					"spacingBefore": docRule.Spacing.Before,
-					"spacingAfter":  docRule.Spacing.After,
-				}
-				expectedFormat := map[string]interface{}{
-					"spacingBefore": templateRule.Spacing.Before,
-					"spacingAfter":  templateRule.Spacing.After,
-				}
-				
-				*issues = append(*issues, types.FormatIssue{
-					ID:          fmt.Sprintf("paragraph_spacing_%d", i),
-					Type:        "paragraph",
-					Severity:    "low",
-					Location:    fmt.Sprintf("第%d段", i+1),
-					Description: fmt.Sprintf("第%d段间距不符合模板要求", i+1),
-					Current:     currentFormat,
-					Expected:    expectedFormat,
-					Rule:        "paragraph_format",
-					Suggestions: []string{"调整段落间距以匹配模板"},
-				})
-				fmt.Printf("DEBUG: 发现段落间距问题\n")
-			}
+			dc.compareParagraphFormat(i, docRules[i], templateRule, issues)
 		}
 	}
+}
+
+// compareParagraphFormat 对比第i个段落的对齐方式和间距
+func (dc *DocumentComparator) compareParagraphFormat(i int, docRule, templateRule types.ParagraphRule, issues *[]types.FormatIssue) {
+	// 检查对齐方式
+	if docRule.Alignment != templateRule.Alignment {
+		currentFormat := map[string]interface{}{
+			"alignment": docRule.Alignment,
+			"spacing":   docRule.Spacing,
+		}
+		expectedFormat := map[string]interface{}{
+			"alignment": templateRule.Alignment,
+			"spacing":   templateRule.Spacing,
+		}
+		
+		*issues = append(*issues, types.FormatIssue{
+			ID:          fmt.Sprintf("paragraph_format_%d", i),
+			Type:        "paragraph",
+			Severity:    "medium",
+			Location:    fmt.Sprintf("第%d段", i+1),
+			Description: fmt.Sprintf("第%d段对齐方式不符合模板要求", i+1),
+			Current:     currentFormat,
+			Expected:    expectedFormat,
+			Rule:        "paragraph_format",
+			Suggestions: []string{"调整段落对齐方式以匹配模板"},
+		})
+	}
 	
-	fmt.Printf("DEBUG: 段落格式对比完成，发现问题数: %d\n", len(*issues))
+	// 检查间距
+	if docRule.Spacing.Before != templateRule.Spacing.Before || 
+	   docRule.Spacing.After != templateRule.Spacing.After {
+		currentFormat := map[string]interface{}{
+			"spacingBefore": docRule.Spacing.Before,
+			"spacingAfter":  docRule.Spacing.After,
+		}
+		expectedFormat := map[string]interface{}{
+			"spacingBefore": templateRule.Spacing.Before,
+			"spacingAfter":  templateRule.Spacing.After,
+		}
+		
+		*issues = append(*issues, types.FormatIssue{
+			ID:          fmt.Sprintf("paragraph_spacing_%d", i),
+			Type:        "paragraph",
+			Severity:    "low",
+			Location:    fmt.Sprintf("第%d段", i+1),
+			Description: fmt.Sprintf("第%d段间距不符合模板要求", i+1),
+			Current:     currentFormat,
+			Expected:    expectedFormat,
+			Rule:        "paragraph_format",
+			Suggestions: []string{"调整段落间距以匹配模板"},
+		})
+	}
 }
 
 // compareFontFormats 对比字体格式
@@ -482,85 +649,159 @@ func (dc *DocumentComparator) compareCharacterStyles(docStyles, templateStyles [
 
 // compareContentFonts 对比文档内容中的实际字体信息
 func (dc *DocumentComparator) compareContentFonts(docContent, templateContent *types.DocumentContent, issues *[]types.FormatIssue) {
-	fmt.Printf("DEBUG: 开始对比内容字体，文档段落数: %d, 模板段落数: %d\n", len(docContent.Paragraphs), len(templateContent.Paragraphs))
-	
 	// 为每个段落比较字体信息
-	for i, templatePara := range templateContent.Paragraphs {
+	for i := range templateContent.Paragraphs {
 		if i < len(docContent.Paragraphs) {
-			docPara := docContent.Paragraphs[i]
+			dc.compareParagraphFonts(i, &docContent.Paragraphs[i], &templateContent.Paragraphs[i], issues)
+		}
+	}
+}
+
+// compareParagraphFonts 对比第i个段落中各文本运行的字体，同一文本运行的多个问题合并为一个
+func (dc *DocumentComparator) compareParagraphFonts(i int, docPara, templatePara *types.Paragraph, issues *[]types.FormatIssue) {
+	// 比较段落中的文本运行
+	for j, templateRun := range templatePara.Runs {
+		if j < len(docPara.Runs) {
+			docRun := docPara.Runs[j]
+			
+			// 收集这个文本运行的所有字体问题
+			var fontIssues []string
+			var currentFormat map[string]interface{}
+			var expectedFormat map[string]interface{}
+			
+			// 检查字体名称
+			if docRun.Font.Name != templateRun.Font.Name {
+				fontIssues = append(fontIssues, fmt.Sprintf("字体名称: 文档=%s, 模板=%s", docRun.Font.Name, templateRun.Font.Name))
+			}
+			
+			// 检查字体大小
+			if docRun.Font.Size != templateRun.Font.Size {
+				fontIssues = append(fontIssues, fmt.Sprintf("字体大小: 文档=%.1f, 模板=%.1f", docRun.Font.Size, templateRun.Font.Size))
+			}
+			
+			// 检查字体颜色
+			if docRun.Font.Color.RGB != templateRun.Font.Color.RGB {
+				fontIssues = append(fontIssues, fmt.Sprintf("字体颜色: 文档=%s, 模板=%s", docRun.Font.Color.RGB, templateRun.Font.Color.RGB))
+			}
+			
+			// 检查粗体
+			if docRun.Font.Bold != templateRun.Font.Bold {
+				fontIssues = append(fontIssues, fmt.Sprintf("粗体: 文档=%v, 模板=%v", docRun.Font.Bold, templateRun.Font.Bold))
+			}
 			
-			// 比较段落中的文本运行
-			for j, templateRun := range templatePara.Runs {
-				if j < len(docPara.Runs) {
-					docRun := docPara.Runs[j]
-					
-					// 收集这个文本运行的所有字体问题
-					var fontIssues []string
-					var currentFormat map[string]interface{}
-					var expectedFormat map[string]interface{}
-					
-					// 检查字体名称
-					if docRun.Font.Name != templateRun.Font.Name {
-						fontIssues = append(fontIssues, fmt.Sprintf("字体名称: 文档=%s, 模板=%s", docRun.Font.Name, templateRun.Font.Name))
-						fmt.Printf("DEBUG: 发现字体名称问题: 文档=%s, 模板=%s\n", docRun.Font.Name, templateRun.Font.Name)
-					}
-					
-					// 检查字体大小
-					if docRun.Font.Size != templateRun.Font.Size {
-						fontIssues = append(fontIssues, fmt.Sprintf("字体大小: 文档=%.1f, 模板=%.1f", docRun.Font.Size, templateRun.Font.Size))
-						fmt.Printf("DEBUG: 发现字体大小问题: 文档=%.1f, 模板=%.1f\n", docRun.Font.Size, templateRun.Font.Size)
-					}
-					
-					// 检查字体颜色
-					if docRun.Font.Color.RGB != templateRun.Font.Color.RGB {
-						fontIssues = append(fontIssues, fmt.Sprintf("字体颜色: 文档=%s, 模板=%s", docRun.Font.Color.RGB, templateRun.Font.Color.RGB))
-					}
-					
-					// 检查粗体
-					if docRun.Font.Bold != templateRun.Font.Bold {
-						fontIssues = append(fontIssues, fmt.Sprintf("粗体: 文档=%v, 模板=%v", docRun.Font.Bold, templateRun.Font.Bold))
-					}
-					
-					// 检查斜体
-					if docRun.Font.Italic != templateRun.Font.Italic {
-						fontIssues = append(fontIssues, fmt.Sprintf("斜体: 文档=%v, 模板=%v", docRun.Font.Italic, templateRun.Font.Italic))
-					}
-					
-					// 如果有字体问题，创建一个合并的问题
-					if len(fontIssues) > 0 {
-						currentFormat = map[string]interface{}{
-							"fontName":  docRun.Font.Name,
-							"fontSize":  docRun.Font.Size,
-							"fontColor": docRun.Font.Color.RGB,
-							"bold":      docRun.Font.Bold,
-							"italic":    docRun.Font.Italic,
-						}
-						expectedFormat = map[string]interface{}{
-							"fontName":  templateRun.Font.Name,
-							"fontSize":  templateRun.Font.Size,
-							"fontColor": templateRun.Font.Color.RGB,
-							"bold":      templateRun.Font.Bold,
-							"italic":    templateRun.Font.Italic,
-						}
-						
-						*issues = append(*issues, types.FormatIssue{
-							ID:          fmt.Sprintf("font_format_%d_%d", i, j),
-							Type:        "font",
-							Severity:    "medium",
-							Location:    fmt.Sprintf("第%d段第%d个文本", i+1, j+1),
-							Description: fmt.Sprintf("第%d段第%d个文本的字体格式不符合模板要求", i+1, j+1),
-							Current:     currentFormat,
-							Expected:    expectedFormat,
-							Rule:        "font_format",
-							Suggestions: []string{fmt.Sprintf("调整字体格式: %s", strings.Join(fontIssues, "; "))},
-						})
-					}
+			// 检查斜体
+			if docRun.Font.Italic != templateRun.Font.Italic {
+				fontIssues = append(fontIssues, fmt.Sprintf("斜体: 文档=%v, 模板=%v", docRun.Font.Italic, templateRun.Font.Italic))
+			}
+			
+			// 如果有字体问题，创建一个合并的问题
+			if len(fontIssues) > 0 {
+				currentFormat = map[string]interface{}{
+					"fontName":  docRun.Font.Name,
+					"fontSize":  docRun.Font.Size,
+					"fontColor": docRun.Font.Color.RGB,
+					"bold":      docRun.Font.Bold,
+					"italic":    docRun.Font.Italic,
+				}
+				expectedFormat = map[string]interface{}{
+					"fontName":  templateRun.Font.Name,
+					"fontSize":  templateRun.Font.Size,
+					"fontColor": templateRun.Font.Color.RGB,
+					"bold":      templateRun.Font.Bold,
+					"italic":    templateRun.Font.Italic,
 				}
+				
+				*issues = append(*issues, types.FormatIssue{
+					ID:          fmt.Sprintf("font_format_%d_%d", i, j),
+					Type:        "font",
+					Severity:    "medium",
+					Location:    fmt.Sprintf("第%d段第%d个文本", i+1, j+1),
+					Description: fmt.Sprintf("第%d段第%d个文本的字体格式不符合模板要求", i+1, j+1),
+					Current:     currentFormat,
+					Expected:    expectedFormat,
+					Rule:        "font_format",
+					Suggestions: []string{fmt.Sprintf("调整字体格式: %s", strings.Join(fontIssues, "; "))},
+				})
 			}
 		}
 	}
 }
 
+// compareRoleFormats 按段落角色检查字体和段落格式，没有对应角色规则的段落不检查
+//
+// 是否符合由types.RoleRule.Deviations判断，规则的Variance中允许的取值不报告为问题。
+func (dc *DocumentComparator) compareRoleFormats(docContent *types.DocumentContent, roleRules []types.RoleRule, issues *[]types.FormatIssue) {
+	rules := make(map[string]types.RoleRule, len(roleRules))
+	for _, rule := range roleRules {
+		rules[rule.Role] = rule
+	}
+
+	for i := range docContent.Paragraphs {
+		paragraph := &docContent.Paragraphs[i]
+		if strings.TrimSpace(paragraph.Text) == "" {
+			continue
+		}
+		actual := types.RoleRuleOf(paragraph)
+		expected, exists := rules[actual.Role]
+		if !exists {
+			continue
+		}
+
+		var fontIssues, paragraphIssues []string
+		spacingReported := false
+		for _, deviation := range expected.Deviations(actual) {
+			switch deviation {
+			case types.DeviationFontName:
+				fontIssues = append(fontIssues, fmt.Sprintf("字体名称: 文档=%s, 模板=%s", actual.Font.Name, expected.Font.Name))
+			case types.DeviationFontSize:
+				fontIssues = append(fontIssues, fmt.Sprintf("字体大小: 文档=%.1f, 模板=%.1f", actual.Font.Size, expected.Font.Size))
+			case types.DeviationBold:
+				fontIssues = append(fontIssues, fmt.Sprintf("粗体: 文档=%v, 模板=%v", actual.Font.Bold, expected.Font.Bold))
+			case types.DeviationItalic:
+				fontIssues = append(fontIssues, fmt.Sprintf("斜体: 文档=%v, 模板=%v", actual.Font.Italic, expected.Font.Italic))
+			case types.DeviationAlignment:
+				paragraphIssues = append(paragraphIssues, fmt.Sprintf("对齐方式: 文档=%s, 模板=%s", actual.Alignment, expected.Alignment))
+			case types.DeviationSpacingBefore, types.DeviationSpacingAfter:
+				if !spacingReported {
+					spacingReported = true
+					paragraphIssues = append(paragraphIssues, fmt.Sprintf("段前/段后间距: 文档=%.1f/%.1f, 模板=%.1f/%.1f",
+						actual.Spacing.Before, actual.Spacing.After, expected.Spacing.Before, expected.Spacing.After))
+				}
+			case types.DeviationLineSpacing:
+				paragraphIssues = append(paragraphIssues, fmt.Sprintf("行距: 文档=%.2f, 模板=%.2f", actual.Spacing.Line, expected.Spacing.Line))
+			}
+		}
+
+		if len(fontIssues) > 0 {
+			*issues = append(*issues, types.FormatIssue{
+				ID:          fmt.Sprintf("role_font_%d", i),
+				Type:        "font",
+				Severity:    "medium",
+				Location:    fmt.Sprintf("第%d段", i+1),
+				Description: fmt.Sprintf("第%d段（%s）的字体格式不符合模板要求", i+1, actual.Role),
+				Current:     actual.Font,
+				Expected:    expected.Font,
+				Rule:        "role_format",
+				Suggestions: []string{fmt.Sprintf("调整字体格式: %s", strings.Join(fontIssues, "; "))},
+			})
+		}
+
+		if len(paragraphIssues) > 0 {
+			*issues = append(*issues, types.FormatIssue{
+				ID:          fmt.Sprintf("role_paragraph_%d", i),
+				Type:        "paragraph",
+				Severity:    "low",
+				Location:    fmt.Sprintf("第%d段", i+1),
+				Description: fmt.Sprintf("第%d段（%s）的段落格式不符合模板要求", i+1, actual.Role),
+				Current:     map[string]interface{}{"alignment": actual.Alignment, "spacing": actual.Spacing},
+				Expected:    map[string]interface{}{"alignment": expected.Alignment, "spacing": expected.Spacing},
+				Rule:        "role_format",
+				Suggestions: []string{fmt.Sprintf("调整段落格式: %s", strings.Join(paragraphIssues, "; "))},
+			})
+		}
+	}
+}
+
 // compareTableStyles 对比表格样式
 func (dc *DocumentComparator) compareTableStyles(docStyles, templateStyles []types.TableStyle, issues *[]types.FormatIssue) {
 	// 创建样式名称映射
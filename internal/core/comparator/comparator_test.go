@@ -1,8 +1,13 @@
 package comparator
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"docs-parser/internal/core/revisions"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/testutil"
 )
 
 // TestNewDocumentComparator 测试创建新的文档比较器
@@ -297,4 +302,47 @@ func TestComparisonReport_Validation(t *testing.T) {
 	if validReport.StyleComparison == nil {
 		t.Error("比较报告应该有样式比较结果")
 	}
-} 
\ No newline at end of file
+} 
+// TestDocumentComparator_CompareWithTemplateStream 测试流式对比与完整解析后对比的问题一致，包括段落标记修订的合并
+func TestDocumentComparator_CompareWithTemplateStream(t *testing.T) {
+	dir := t.TempDir()
+	docPath := testutil.WriteDocx(t, filepath.Join(dir, "doc.docx"), `<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:t>标题</w:t></w:r></w:p>
+<w:p><w:pPr><w:rPr><w:del w:id="1" w:author="审阅者"/></w:rPr></w:pPr><w:r><w:t>第一</w:t></w:r></w:p>
+<w:p><w:pPr><w:jc w:val="right"/></w:pPr><w:r><w:rPr><w:rFonts w:val="宋体"/></w:rPr><w:t>段</w:t></w:r></w:p>`)
+	templatePath := testutil.WriteDocx(t, filepath.Join(dir, "template.docx"), `<w:p><w:r><w:t>标题</w:t></w:r></w:p>
+<w:p><w:pPr><w:jc w:val="right"/></w:pPr><w:r><w:rPr><w:rFonts w:val="黑体"/></w:rPr><w:t>第一段</w:t></w:r></w:p>`)
+
+	comparator := NewDocumentComparator()
+	report, err := comparator.CompareWithTemplate(docPath, templatePath)
+	if err != nil {
+		t.Fatalf("对比失败: %v", err)
+	}
+
+	doc, err := comparator.wordParser.ParseDocument(docPath)
+	if err != nil {
+		t.Fatalf("解析文档失败: %v", err)
+	}
+	template, err := comparator.wordParser.ParseDocument(templatePath)
+	if err != nil {
+		t.Fatalf("解析模板失败: %v", err)
+	}
+	expected, err := comparator.compareWithParsedTemplate(doc, revisions.Apply(template, comparator.RevisionPolicy()), docPath, templatePath)
+	if err != nil {
+		t.Fatalf("对比失败: %v", err)
+	}
+
+	if got, want := issueIDs(report.Issues), issueIDs(expected.Issues); !reflect.DeepEqual(got, want) {
+		t.Errorf("流式对比的问题与完整解析不一致:\n期望 %v\n实际 %v", want, got)
+	}
+	if len(report.Issues) != 2 {
+		t.Errorf("应发现第1段对齐和合并后第2段字体的问题，实际: %v", issueIDs(report.Issues))
+	}
+}
+
+func issueIDs(issues []types.FormatIssue) []string {
+	ids := make([]string, len(issues))
+	for i, issue := range issues {
+		ids[i] = issue.ID
+	}
+	return ids
+}
@@ -0,0 +1,564 @@
+package graphics
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"docs-parser/internal/utils"
+)
+
+// ManifestFileName 图片清单文件名
+const ManifestFileName = "manifest.json"
+
+// 关系类型与命名空间
+const (
+	relationshipTypeImage = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	wordprocessingMLNS    = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	emuPerPoint           = 12700.0
+	captionSlugMaxRunes   = 40
+)
+
+// captionPattern 题注文本的常见开头，如 "图1"、"图 2-3"、"Figure 4"、"Fig. 5"
+var captionPattern = regexp.MustCompile(`^\s*(图|圖|Figure|Fig\.?)\s*[0-9一二三四五六七八九十]`)
+
+// ImageManifest 图片提取清单
+type ImageManifest struct {
+	Document    string               `json:"document"`
+	OutputDir   string               `json:"output_dir"`
+	Generated   time.Time            `json:"generated"`
+	TotalImages int                  `json:"total_images"` // 文档中引用的图片数量（含重复）
+	UniqueFiles int                  `json:"unique_files"` // 去重后写出的文件数量
+	Images      []ImageManifestEntry `json:"images"`
+}
+
+// ImageManifestEntry 清单中的单个图片
+type ImageManifestEntry struct {
+	Figure         int     `json:"figure"`          // 图片在文档中的顺序，未引用的媒体为0
+	File           string  `json:"file"`            // 输出目录中的文件名
+	MediaPart      string  `json:"media_part"`      // 包内媒体部件，如 word/media/image1.png
+	RelationshipID string  `json:"relationship_id"` // 来源关系ID，如 rId5
+	Part           string  `json:"part"`            // 引用所在部件，如 word/document.xml
+	ParagraphIndex int     `json:"paragraph_index"` // 所在段落序号（从1开始）
+	Location       string  `json:"location"`
+	Caption        string  `json:"caption"`
+	AltText        string  `json:"alt_text"`
+	Title          string  `json:"title"`
+	DisplayWidth   float64 `json:"display_width"`  // 显示宽度（磅）
+	DisplayHeight  float64 `json:"display_height"` // 显示高度（磅）
+	Format         string  `json:"format"`
+	Size           int64   `json:"size"`
+	SHA256         string  `json:"sha256"`
+	Source         string  `json:"source"`       // drawingml 或 vml
+	DuplicateOf    int     `json:"duplicate_of"` // 内容与之相同的首个图片序号
+	External       bool    `json:"external"`     // 链接到外部文件的图片
+}
+
+// imageReference 正文中对图片的一次引用
+type imageReference struct {
+	part           string
+	relID          string
+	external       bool
+	target         string
+	paragraphIndex int
+	altText        string
+	title          string
+	width          float64
+	height         float64
+	source         string
+}
+
+// imageParagraph 正文段落的样式、文本和其中的图片引用
+type imageParagraph struct {
+	style string
+	text  strings.Builder
+	refs  []*imageReference
+}
+
+// ExtractImages 将DOCX中的所有媒体图片写入输出目录，并生成JSON清单
+func (dgp *DOCXGraphicsParser) ExtractImages(docxPath, outputDir string) (*ImageManifest, error) {
+	reader, err := zip.OpenReader(docxPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DOCX file: %w", err)
+	}
+	defer reader.Close()
+
+	if err := utils.EnsureDirectoryExists(outputDir); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	var mediaParts []string
+	for _, file := range reader.File {
+		files[file.Name] = file
+		if strings.HasPrefix(file.Name, "word/media/") {
+			mediaParts = append(mediaParts, file.Name)
+		}
+	}
+	sort.Strings(mediaParts)
+
+	// 按文档顺序收集图片引用：先正文，再页眉页脚
+	var bodyParts []string
+	for name := range files {
+		if isBodyPart(name) && name != "word/document.xml" {
+			bodyParts = append(bodyParts, name)
+		}
+	}
+	sort.Strings(bodyParts)
+	if _, exists := files["word/document.xml"]; exists {
+		bodyParts = append([]string{"word/document.xml"}, bodyParts...)
+	}
+
+	styleNames, err := readStyleNames(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var references []*imageReference
+	captions := make(map[*imageReference]string)
+	for _, part := range bodyParts {
+		refs, partCaptions, err := dgp.collectImageReferences(files, part, styleNames)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, refs...)
+		for ref, caption := range partCaptions {
+			captions[ref] = caption
+		}
+	}
+
+	manifest := &ImageManifest{
+		Document:  docxPath,
+		OutputDir: outputDir,
+		Generated: time.Now(),
+		Images:    []ImageManifestEntry{},
+	}
+
+	written := make(map[string]string)  // 内容哈希 -> 输出文件名
+	firstFigure := make(map[string]int) // 内容哈希 -> 首个图片序号
+	referenced := make(map[string]bool)
+
+	for i, ref := range references {
+		figure := i + 1
+		entry := ImageManifestEntry{
+			Figure:         figure,
+			MediaPart:      ref.target,
+			RelationshipID: ref.relID,
+			Part:           ref.part,
+			ParagraphIndex: ref.paragraphIndex,
+			Location:       fmt.Sprintf("%s 第%d段", ref.part, ref.paragraphIndex),
+			Caption:        captions[ref],
+			AltText:        ref.altText,
+			Title:          ref.title,
+			DisplayWidth:   ref.width,
+			DisplayHeight:  ref.height,
+			Format:         dgp.getImageFormat(ref.target),
+			Source:         ref.source,
+			External:       ref.external,
+		}
+
+		file, exists := files[ref.target]
+		if ref.external || !exists {
+			manifest.Images = append(manifest.Images, entry)
+			continue
+		}
+		referenced[ref.target] = true
+
+		name := fmt.Sprintf("figure_%03d", figure)
+		if slug := captionSlug(entry.Caption); slug != "" {
+			name += "_" + slug
+		}
+		name += strings.ToLower(path.Ext(ref.target))
+
+		if err := dgp.writeMediaEntry(file, outputDir, name, &entry, written, firstFigure); err != nil {
+			return nil, err
+		}
+		manifest.Images = append(manifest.Images, entry)
+	}
+
+	// 未被正文引用的媒体部件同样导出，便于完整归档
+	for _, mediaPart := range mediaParts {
+		if referenced[mediaPart] {
+			continue
+		}
+		entry := ImageManifestEntry{
+			MediaPart: mediaPart,
+			Location:  "unreferenced",
+			Format:    dgp.getImageFormat(mediaPart),
+		}
+		name := "unreferenced_" + path.Base(mediaPart)
+		if err := dgp.writeMediaEntry(files[mediaPart], outputDir, name, &entry, written, firstFigure); err != nil {
+			return nil, err
+		}
+		manifest.Images = append(manifest.Images, entry)
+	}
+
+	manifest.TotalImages = len(references)
+	manifest.UniqueFiles = len(written)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, ManifestFileName), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// writeMediaEntry 写出媒体文件；内容相同的图片只写一次，后续条目指向已写出的文件
+func (dgp *DOCXGraphicsParser) writeMediaEntry(file *zip.File, outputDir, name string, entry *ImageManifestEntry, written map[string]string, firstFigure map[string]int) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open media %s: %w", file.Name, err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read media %s: %w", file.Name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	entry.SHA256 = hash
+	entry.Size = int64(len(data))
+
+	if existing, exists := written[hash]; exists {
+		entry.File = existing
+		entry.DuplicateOf = firstFigure[hash]
+		return nil
+	}
+
+	if err := os.WriteFile(filepath.Join(outputDir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write image %s: %w", name, err)
+	}
+	written[hash] = name
+	firstFigure[hash] = entry.Figure
+	entry.File = name
+	return nil
+}
+
+// collectImageReferences 按顺序收集部件中的图片引用，并为每个引用查找题注
+func (dgp *DOCXGraphicsParser) collectImageReferences(files map[string]*zip.File, part string, styleNames map[string]string) ([]*imageReference, map[*imageReference]string, error) {
+	relationships, err := readPartRelationships(files, part)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file := files[part]
+	rc, err := file.Open()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", part, err)
+	}
+	defer rc.Close()
+
+	var (
+		paragraphs []*imageParagraph
+		stack      []*imageParagraph
+		references []*imageReference
+		drawing    *imageReference // 当前DrawingML图片的尺寸和描述
+		vmlWidth   float64
+		vmlHeight  float64
+		altStarts  []int // 每层mc:AlternateContent开始时的引用数量
+	)
+
+	current := func() *imageParagraph {
+		if len(stack) == 0 {
+			return nil
+		}
+		return stack[len(stack)-1]
+	}
+	addReference := func(ref *imageReference) {
+		if rel, exists := relationships[ref.relID]; exists {
+			ref.target = rel.target
+			ref.external = rel.external
+		}
+		ref.part = part
+		ref.paragraphIndex = len(paragraphs)
+		if p := current(); p != nil {
+			p.refs = append(p.refs, ref)
+		}
+		references = append(references, ref)
+	}
+
+	decoder := xml.NewDecoder(rc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", part, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				if t.Name.Space == wordprocessingMLNS {
+					p := &imageParagraph{}
+					paragraphs = append(paragraphs, p)
+					stack = append(stack, p)
+				}
+			case "pStyle":
+				if p := current(); p != nil {
+					p.style = attrValue(t, "val")
+					if name, exists := styleNames[p.style]; exists {
+						p.style = name
+					}
+				}
+			case "t":
+				if t.Name.Space == wordprocessingMLNS {
+					var text string
+					if err := decoder.DecodeElement(&text, &t); err == nil {
+						if p := current(); p != nil {
+							p.text.WriteString(text)
+						}
+					}
+				}
+			case "AlternateContent":
+				altStarts = append(altStarts, len(references))
+			case "Fallback":
+				// mc:Choice已经给出图片时，跳过旧版表示，避免同一图片计数两次
+				if n := len(altStarts); n > 0 && len(references) > altStarts[n-1] {
+					decoder.Skip()
+				}
+			case "drawing":
+				drawing = &imageReference{source: "drawingml"}
+			case "extent":
+				if drawing != nil {
+					drawing.width = emuAttr(t, "cx")
+					drawing.height = emuAttr(t, "cy")
+				}
+			case "docPr":
+				if drawing != nil {
+					drawing.altText = attrValue(t, "descr")
+					drawing.title = attrValue(t, "title")
+					if drawing.title == "" {
+						drawing.title = attrValue(t, "name")
+					}
+				}
+			case "blip":
+				if drawing != nil {
+					ref := *drawing
+					ref.relID = attrValue(t, "embed")
+					if ref.relID == "" {
+						ref.relID = attrValue(t, "link")
+					}
+					addReference(&ref)
+				}
+			case "shape", "rect", "image":
+				vmlWidth, vmlHeight = 0, 0
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "style" {
+						props := parseVMLStyle(attr.Value)
+						vmlWidth, _ = parseVMLLength(props["width"])
+						vmlHeight, _ = parseVMLLength(props["height"])
+					}
+				}
+			case "imagedata":
+				ref := &imageReference{
+					relID:  attrValue(t, "id"),
+					title:  attrValue(t, "title"),
+					width:  vmlWidth,
+					height: vmlHeight,
+					source: "vml",
+				}
+				ref.altText = ref.title
+				addReference(ref)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				if t.Name.Space == wordprocessingMLNS && len(stack) > 0 {
+					stack = stack[:len(stack)-1]
+				}
+			case "drawing":
+				drawing = nil
+			case "AlternateContent":
+				if n := len(altStarts); n > 0 {
+					altStarts = altStarts[:n-1]
+				}
+			}
+		}
+	}
+
+	captions := make(map[*imageReference]string)
+	for i, p := range paragraphs {
+		for _, ref := range p.refs {
+			captions[ref] = findCaption(paragraphs, i)
+		}
+	}
+
+	return references, captions, nil
+}
+
+// findCaption 查找图片题注：依次检查图片段落本身、下一段和上一段
+func findCaption(paragraphs []*imageParagraph, index int) string {
+	candidates := []int{index, index + 1, index - 1}
+	for _, i := range candidates {
+		if i < 0 || i >= len(paragraphs) {
+			continue
+		}
+		p := paragraphs[i]
+		text := strings.TrimSpace(p.text.String())
+		if text == "" {
+			continue
+		}
+		// 相邻段落本身含有图片时，其文字属于那张图片
+		if i != index && len(p.refs) > 0 {
+			continue
+		}
+		style := strings.ToLower(p.style)
+		if style == "caption" || strings.Contains(p.style, "题注") || captionPattern.MatchString(text) {
+			return text
+		}
+	}
+	return ""
+}
+
+// captionSlug 将题注转换为文件名片段，保留字母、数字（含中文），其余字符替换为连字符
+func captionSlug(caption string) string {
+	var builder strings.Builder
+	lastDash := true
+	count := 0
+	for _, r := range strings.ToLower(caption) {
+		if count >= captionSlugMaxRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			lastDash = false
+			count++
+			continue
+		}
+		if !lastDash {
+			builder.WriteRune('-')
+			lastDash = true
+			count++
+		}
+	}
+	return strings.Trim(builder.String(), "-")
+}
+
+// partRelationship 部件关系
+type partRelationship struct {
+	target   string
+	external bool
+}
+
+// readPartRelationships 读取部件的关系文件，如 word/_rels/document.xml.rels
+func readPartRelationships(files map[string]*zip.File, part string) (map[string]partRelationship, error) {
+	relationships := make(map[string]partRelationship)
+
+	relsName := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	file, exists := files[relsName]
+	if !exists {
+		return relationships, nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", relsName, err)
+	}
+	defer rc.Close()
+
+	var rels struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Type       string `xml:"Type,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&rels); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", relsName, err)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.Type != relationshipTypeImage {
+			continue
+		}
+		if rel.TargetMode == "External" {
+			relationships[rel.ID] = partRelationship{target: rel.Target, external: true}
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(path.Dir(part), target)
+		}
+		relationships[rel.ID] = partRelationship{target: target}
+	}
+
+	return relationships, nil
+}
+
+// readStyleNames 读取styles.xml中样式ID到样式名称的映射
+func readStyleNames(files map[string]*zip.File) (map[string]string, error) {
+	names := make(map[string]string)
+
+	file, exists := files["word/styles.xml"]
+	if !exists {
+		return names, nil
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open styles.xml: %w", err)
+	}
+	defer rc.Close()
+
+	var styles struct {
+		Styles []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+		} `xml:"style"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&styles); err != nil {
+		return nil, fmt.Errorf("failed to parse styles.xml: %w", err)
+	}
+
+	for _, style := range styles.Styles {
+		if style.Name.Val != "" {
+			names[style.ID] = style.Name.Val
+		}
+	}
+	return names, nil
+}
+
+// attrValue 按本地名称获取属性值
+func attrValue(element xml.StartElement, local string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// emuAttr 读取EMU属性并转换为磅
+func emuAttr(element xml.StartElement, local string) float64 {
+	value, err := strconv.ParseFloat(attrValue(element, local), 64)
+	if err != nil {
+		return 0
+	}
+	return value / emuPerPoint
+}
@@ -0,0 +1,92 @@
+package graphics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/testutil"
+)
+
+// TestExtractImages 测试图片提取的命名、去重和清单
+func TestExtractImages(t *testing.T) {
+	drawing := func(id, rel, descr string) string {
+		return `<w:r><w:drawing><wp:inline><wp:extent cx="1270000" cy="635000"/><wp:docPr id="` + id + `" name="图片 ` + id + `" descr="` + descr + `"/>
+<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><a:blip r:embed="` + rel + `"/></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`
+	}
+
+	path := testutil.WriteZip(t, filepath.Join(t.TempDir(), "test.docx"), map[string]string{
+		"word/document.xml": testDocumentXML(`<w:p>` + drawing("1", "rId1", "系统架构") + `</w:p>
+<w:p><w:pPr><w:pStyle w:val="a5"/></w:pPr><w:r><w:t>图1 系统架构</w:t></w:r></w:p>
+<w:p><w:r><w:t>正文内容</w:t></w:r></w:p>
+<w:p>` + drawing("2", "rId2", "") + `</w:p>
+<w:p><w:r><mc:AlternateContent><mc:Choice Requires="wps">` + drawing("3", "rId3", "流程") + `</mc:Choice>
+<mc:Fallback><w:pict><v:shape style="width:100pt;height:50pt"><v:imagedata r:id="rId3" o:title="流程"/></v:shape></w:pict></mc:Fallback></mc:AlternateContent></w:r></w:p>
+<w:p><w:r><w:t>Figure 3: Process Flow</w:t></w:r></w:p>`),
+		"word/_rels/document.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.png"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image2.png"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image3.jpeg"/>
+</Relationships>`,
+		"word/styles.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:styleId="a5"><w:name w:val="题注"/></w:style>
+</w:styles>`,
+		"word/media/image1.png":  "PNG-A",
+		"word/media/image2.png":  "PNG-A",
+		"word/media/image3.jpeg": "JPEG-B",
+		"word/media/image4.gif":  "GIF-C",
+	})
+
+	outputDir := filepath.Join(t.TempDir(), "images")
+	manifest, err := NewDOCXGraphicsParser().ExtractImages(path, outputDir)
+	if err != nil {
+		t.Fatalf("提取图片失败: %v", err)
+	}
+
+	if manifest.TotalImages != 3 {
+		t.Errorf("期望3处图片引用（AlternateContent只计一次），实际 %d", manifest.TotalImages)
+	}
+	if manifest.UniqueFiles != 3 {
+		t.Errorf("期望去重后写出3个文件，实际 %d", manifest.UniqueFiles)
+	}
+	if len(manifest.Images) != 4 {
+		t.Fatalf("期望清单包含4项（含未引用媒体），实际 %d", len(manifest.Images))
+	}
+
+	first := manifest.Images[0]
+	if first.File != "figure_001_图1-系统架构.png" || first.Caption != "图1 系统架构" {
+		t.Errorf("第1张图片命名或题注不正确: %s / %s", first.File, first.Caption)
+	}
+	if first.AltText != "系统架构" || first.RelationshipID != "rId1" || first.DisplayWidth != 100 || first.DisplayHeight != 50 {
+		t.Errorf("第1张图片信息不正确: %+v", first)
+	}
+
+	second := manifest.Images[1]
+	if second.DuplicateOf != 1 || second.File != first.File {
+		t.Errorf("期望第2张图片与第1张去重，实际 %+v", second)
+	}
+
+	third := manifest.Images[2]
+	if third.Caption != "Figure 3: Process Flow" || third.File != "figure_003_figure-3-process-flow.jpeg" {
+		t.Errorf("第3张图片命名或题注不正确: %s / %s", third.File, third.Caption)
+	}
+
+	if manifest.Images[3].File != "unreferenced_image4.gif" {
+		t.Errorf("未引用媒体命名不正确: %s", manifest.Images[3].File)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, ManifestFileName))
+	if err != nil {
+		t.Fatalf("读取清单失败: %v", err)
+	}
+	var saved ImageManifest
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved.Images) != 4 {
+		t.Errorf("清单文件内容不正确: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "figure_002.png")); err == nil {
+		t.Error("重复内容的图片不应再次写出")
+	}
+}
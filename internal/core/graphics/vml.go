@@ -0,0 +1,608 @@
+package graphics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// VML形状类型引用
+const (
+	vmlShapeTypeTextbox = "#_x0000_t202" // 文本框
+	vmlShapeTypeWordArt = "#_x0000_t136" // 艺术字
+)
+
+// vmlNamedColors VML中常见的命名颜色
+var vmlNamedColors = map[string]string{
+	"black":   "000000",
+	"white":   "FFFFFF",
+	"red":     "FF0000",
+	"green":   "008000",
+	"lime":    "00FF00",
+	"blue":    "0000FF",
+	"yellow":  "FFFF00",
+	"gray":    "808080",
+	"silver":  "C0C0C0",
+	"maroon":  "800000",
+	"navy":    "000080",
+	"purple":  "800080",
+	"teal":    "008080",
+	"olive":   "808000",
+	"aqua":    "00FFFF",
+	"fuchsia": "FF00FF",
+}
+
+// parseVMLPicture 解析w:pict（或w:object）中的VML图形
+func (dgp *DOCXGraphicsParser) parseVMLPicture(decoder *xml.Decoder, startElement *xml.StartElement, part string, result *bodyGraphics) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "shapetype":
+				// 形状类型定义，不是实际图形
+				decoder.Skip()
+			case "group":
+				dgp.parseVMLGroup(decoder, &t, part, result)
+			case "shape", "rect", "roundrect", "oval", "line", "polyline", "arc", "curve", "image":
+				element := dgp.parseVMLShape(decoder, &t, part)
+				result.add(element)
+			}
+		case xml.EndElement:
+			if t.Name.Local == startElement.Name.Local {
+				return
+			}
+		}
+	}
+}
+
+// parseVMLGroup 解析v:group图形组
+func (dgp *DOCXGraphicsParser) parseVMLGroup(decoder *xml.Decoder, startElement *xml.StartElement, part string, result *bodyGraphics) {
+	element := newVMLElement(startElement, part, decoder.InputOffset())
+	element.Type = types.GraphicTypeGroup
+	dgp.applyVMLAttributes(element, startElement)
+
+	group := types.GraphicGroup{
+		ID:       element.ID,
+		Name:     element.Metadata.Name,
+		Elements: []string{},
+		Visible:  element.Visible,
+		Locked:   element.Locked,
+	}
+
+	children := &bodyGraphics{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		done := false
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "group":
+				dgp.parseVMLGroup(decoder, &t, part, children)
+			case "shape", "rect", "roundrect", "oval", "line", "polyline", "arc", "curve", "image":
+				children.add(dgp.parseVMLShape(decoder, &t, part))
+			default:
+				decoder.Skip()
+			}
+		case xml.EndElement:
+			done = t.Name.Local == "group"
+		}
+		if done {
+			break
+		}
+	}
+
+	// 只记录直接子元素，嵌套组的成员由嵌套组自己记录
+	for _, child := range children.elements {
+		if child.Anchor.ID == "" {
+			child.Anchor.ID = element.ID
+			group.Elements = append(group.Elements, child.ID)
+		}
+	}
+
+	result.add(element)
+	result.elements = append(result.elements, children.elements...)
+	result.groups = append(result.groups, group)
+	result.groups = append(result.groups, children.groups...)
+}
+
+// parseVMLShape 解析单个VML形状（v:shape、v:rect、v:oval等）
+func (dgp *DOCXGraphicsParser) parseVMLShape(decoder *xml.Decoder, startElement *xml.StartElement, part string) *types.GraphicElement {
+	element := newVMLElement(startElement, part, decoder.InputOffset())
+	element.Metadata.Tags = append(element.Metadata.Tags, startElement.Name.Local)
+	dgp.applyVMLAttributes(element, startElement)
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return element
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "fill":
+				dgp.parseVMLFill(&t, element)
+			case "stroke":
+				dgp.parseVMLStroke(&t, element)
+			case "shadow":
+				dgp.parseVMLShadow(&t, element)
+			case "textpath":
+				dgp.parseVMLTextPath(&t, element)
+			case "imagedata":
+				dgp.parseVMLImageData(&t, element)
+			case "txbxContent":
+				// v:textbox > w:txbxContent
+				paragraphs := dgp.parseTxbxContent(decoder, &t)
+				element.Type = types.GraphicTypeTextbox
+				element.Content.Paragraphs = append(element.Content.Paragraphs, paragraphs...)
+				element.Content.Text = joinParagraphText(element.Content.Paragraphs)
+			}
+		case xml.EndElement:
+			if t.Name.Local == startElement.Name.Local {
+				return element
+			}
+		}
+	}
+}
+
+// newVMLElement 创建带默认值的VML图形元素
+func newVMLElement(startElement *xml.StartElement, part string, offset int64) *types.GraphicElement {
+	return &types.GraphicElement{
+		ID:   fmt.Sprintf("vml_%s_%d", strings.TrimSuffix(filepath.Base(part), ".xml"), offset),
+		Type: types.GraphicTypeShape,
+		Position: types.GraphicPosition{
+			Unit: "pt",
+		},
+		Size: types.Size{
+			ScaleX: 1.0,
+			ScaleY: 1.0,
+			Unit:   "pt",
+		},
+		Style: types.GraphicStyle{
+			Opacity: 1.0,
+			Border: types.GraphicBorder{
+				Width:   0.75,
+				Style:   "solid",
+				Color:   types.GraphicColor{Type: "rgb", Value: "000000", Alpha: 1.0},
+				Visible: true,
+			},
+			Fill: types.Fill{
+				Type:  "solid",
+				Color: types.GraphicColor{Type: "rgb", Value: "FFFFFF", Alpha: 1.0},
+			},
+		},
+		Metadata: types.GraphicMetadata{
+			Source: "vml",
+			Part:   part,
+		},
+		Anchor: types.Anchor{
+			Type: "character",
+		},
+		Visible: true,
+	}
+}
+
+// applyVMLAttributes 应用VML形状元素上的属性
+func (dgp *DOCXGraphicsParser) applyVMLAttributes(element *types.GraphicElement, startElement *xml.StartElement) {
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "id":
+			if attr.Value != "" {
+				element.ID = "vml_" + attr.Value
+			}
+		case "style":
+			applyVMLStyle(element, parseVMLStyle(attr.Value))
+		case "type":
+			element.Metadata.Tags = append(element.Metadata.Tags, attr.Value)
+			switch attr.Value {
+			case vmlShapeTypeTextbox:
+				element.Type = types.GraphicTypeTextbox
+			case vmlShapeTypeWordArt:
+				element.Type = types.GraphicTypeWordArt
+				element.Content.WordArt.Preset = attr.Value
+			}
+		case "alt":
+			element.Content.Image.AltText = attr.Value
+			element.Metadata.Comments = attr.Value
+		case "title":
+			element.Metadata.Name = attr.Value
+		case "fillcolor":
+			element.Style.Fill.Color = parseVMLColor(attr.Value)
+		case "filled":
+			if !parseVMLBool(attr.Value) {
+				element.Style.Fill.Type = "none"
+			}
+		case "strokecolor":
+			element.Style.Border.Color = parseVMLColor(attr.Value)
+		case "strokeweight":
+			if width, ok := parseVMLLength(attr.Value); ok {
+				element.Style.Border.Width = width
+			}
+		case "stroked":
+			element.Style.Border.Visible = parseVMLBool(attr.Value)
+		case "from":
+			// v:line 起点
+			if x, y, ok := parseVMLPoint(attr.Value); ok {
+				element.Position.X, element.Position.Y = x, y
+			}
+		case "to":
+			// v:line 终点，尺寸取两点差值
+			if x, y, ok := parseVMLPoint(attr.Value); ok {
+				element.Size.Width = math.Abs(x - element.Position.X)
+				element.Size.Height = math.Abs(y - element.Position.Y)
+			}
+		}
+	}
+}
+
+// parseVMLStyle 解析VML的CSS样式属性，如 "position:absolute;margin-left:10pt"
+func parseVMLStyle(style string) map[string]string {
+	props := make(map[string]string)
+	for _, decl := range strings.Split(style, ";") {
+		name, value, found := strings.Cut(decl, ":")
+		if !found {
+			continue
+		}
+		props[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return props
+}
+
+// applyVMLStyle 将样式属性应用到图形元素的位置、尺寸和可见性
+func applyVMLStyle(element *types.GraphicElement, props map[string]string) {
+	lookupLength := func(names ...string) (float64, bool) {
+		for _, name := range names {
+			if value, exists := props[name]; exists {
+				if length, ok := parseVMLLength(value); ok {
+					return length, true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	if x, ok := lookupLength("margin-left", "left"); ok {
+		element.Position.X = x
+		element.Anchor.OffsetX = x
+	}
+	if y, ok := lookupLength("margin-top", "top"); ok {
+		element.Position.Y = y
+		element.Anchor.OffsetY = y
+	}
+	if width, ok := lookupLength("width"); ok {
+		element.Size.Width = width
+	}
+	if height, ok := lookupLength("height"); ok {
+		element.Size.Height = height
+	}
+
+	// 浮动形状默认相对于段落定位，嵌入形状随字符定位
+	if props["position"] == "absolute" {
+		element.Anchor.Type = "paragraph"
+		if relative := props["mso-position-horizontal-relative"]; relative == "page" || relative == "margin" {
+			element.Anchor.Type = relative
+		} else if relative := props["mso-position-vertical-relative"]; relative == "page" || relative == "margin" {
+			element.Anchor.Type = relative
+		}
+	}
+	if horizontal := props["mso-position-horizontal"]; horizontal != "" && horizontal != "absolute" {
+		element.Anchor.Position = horizontal
+	}
+
+	if zIndex, exists := props["z-index"]; exists {
+		if z, err := strconv.Atoi(zIndex); err == nil {
+			element.ZIndex = z
+		}
+	}
+	if rotation, exists := props["rotation"]; exists {
+		element.Style.Rotation = parseVMLFixed(rotation)
+	}
+	if flip, exists := props["flip"]; exists {
+		element.Style.FlipH = strings.Contains(flip, "x")
+		element.Style.FlipV = strings.Contains(flip, "y")
+	}
+	if props["visibility"] == "hidden" {
+		element.Visible = false
+	}
+}
+
+// parseVMLFill 解析v:fill填充
+func (dgp *DOCXGraphicsParser) parseVMLFill(startElement *xml.StartElement, element *types.GraphicElement) {
+	fill := &element.Style.Fill
+	color2 := ""
+	colors := ""
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "on":
+			if !parseVMLBool(attr.Value) {
+				fill.Type = "none"
+				return
+			}
+		case "type":
+			switch attr.Value {
+			case "gradient":
+				fill.Type = "gradient"
+				fill.Gradient.Type = "linear"
+			case "gradientRadial":
+				fill.Type = "gradient"
+				fill.Gradient.Type = "radial"
+			case "tile", "frame":
+				fill.Type = "picture"
+			case "pattern":
+				fill.Type = "pattern"
+			default:
+				fill.Type = "solid"
+			}
+		case "color":
+			fill.Color = parseVMLColor(attr.Value)
+		case "color2":
+			color2 = attr.Value
+		case "colors":
+			colors = attr.Value
+		case "opacity":
+			fill.Color.Alpha = parseVMLFraction(attr.Value)
+		case "angle":
+			fill.Gradient.Angle = parseVMLFixed(attr.Value)
+		case "id", "relid", "src":
+			fill.Picture.Source = attr.Value
+		}
+	}
+
+	switch fill.Type {
+	case "gradient":
+		fill.Gradient.Stops = parseVMLGradientStops(fill.Color, color2, colors)
+	case "pattern":
+		fill.Pattern.ForeColor = fill.Color
+		if color2 != "" {
+			fill.Pattern.BackColor = parseVMLColor(color2)
+		}
+	}
+}
+
+// parseVMLGradientStops 解析渐变停止点，colors属性形如 "0 #fff;.5 red;1 black"
+func parseVMLGradientStops(color types.GraphicColor, color2, colors string) []types.GradientStop {
+	var stops []types.GradientStop
+	for _, entry := range strings.Split(colors, ";") {
+		position, value, found := strings.Cut(strings.TrimSpace(entry), " ")
+		if !found {
+			continue
+		}
+		stops = append(stops, types.GradientStop{
+			Position: parseVMLFraction(position),
+			Color:    parseVMLColor(value),
+		})
+	}
+	if len(stops) > 0 {
+		return stops
+	}
+
+	stops = append(stops, types.GradientStop{Position: 0, Color: color})
+	if color2 != "" {
+		stops = append(stops, types.GradientStop{Position: 1, Color: parseVMLColor(color2)})
+	}
+	return stops
+}
+
+// parseVMLStroke 解析v:stroke边框
+func (dgp *DOCXGraphicsParser) parseVMLStroke(startElement *xml.StartElement, element *types.GraphicElement) {
+	border := &element.Style.Border
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "on":
+			border.Visible = parseVMLBool(attr.Value)
+		case "weight":
+			if width, ok := parseVMLLength(attr.Value); ok {
+				border.Width = width
+			}
+		case "color":
+			border.Color = parseVMLColor(attr.Value)
+		case "opacity":
+			border.Color.Alpha = parseVMLFraction(attr.Value)
+		case "dashstyle":
+			switch {
+			case attr.Value == "solid":
+				border.Style = "solid"
+			case strings.Contains(strings.ToLower(attr.Value), "dash"):
+				border.Style = "dashed"
+			case strings.Contains(strings.ToLower(attr.Value), "dot"):
+				border.Style = "dotted"
+			}
+		}
+	}
+}
+
+// parseVMLShadow 解析v:shadow阴影
+func (dgp *DOCXGraphicsParser) parseVMLShadow(startElement *xml.StartElement, element *types.GraphicElement) {
+	shadow := &element.Style.Shadow
+	shadow.Color = types.GraphicColor{Type: "rgb", Value: "808080", Alpha: 1.0}
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "on":
+			shadow.Enabled = parseVMLBool(attr.Value)
+		case "color":
+			shadow.Color = parseVMLColor(attr.Value)
+		case "opacity":
+			shadow.Transparency = 1 - parseVMLFraction(attr.Value)
+		case "offset":
+			if dx, dy, ok := parseVMLPoint(attr.Value); ok {
+				shadow.Distance = math.Hypot(dx, dy)
+				shadow.Angle = math.Atan2(dy, dx) * 180 / math.Pi
+			}
+		}
+	}
+}
+
+// parseVMLTextPath 解析v:textpath艺术字路径
+func (dgp *DOCXGraphicsParser) parseVMLTextPath(startElement *xml.StartElement, element *types.GraphicElement) {
+	wordArt := &element.Content.WordArt
+	on := true
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "on":
+			on = parseVMLBool(attr.Value)
+		case "string":
+			wordArt.Text = attr.Value
+		case "fitshape":
+			wordArt.FitShape = parseVMLBool(attr.Value)
+		case "style":
+			props := parseVMLStyle(attr.Value)
+			wordArt.FontFamily = strings.Trim(props["font-family"], "\"'")
+			if size, ok := parseVMLLength(props["font-size"]); ok {
+				wordArt.FontSize = size
+			}
+			wordArt.Bold = props["font-weight"] == "bold"
+			wordArt.Italic = props["font-style"] == "italic"
+		}
+	}
+
+	if on && wordArt.Text != "" {
+		element.Type = types.GraphicTypeWordArt
+		element.Content.Text = wordArt.Text
+	}
+}
+
+// parseVMLImageData 解析v:imagedata图片引用
+func (dgp *DOCXGraphicsParser) parseVMLImageData(startElement *xml.StartElement, element *types.GraphicElement) {
+	element.Type = types.GraphicTypeImage
+	for _, attr := range startElement.Attr {
+		switch attr.Name.Local {
+		case "id", "relid":
+			element.Content.Image.Source = attr.Value
+		case "title":
+			if element.Content.Image.AltText == "" {
+				element.Content.Image.AltText = attr.Value
+			}
+		}
+	}
+}
+
+// parseVMLLength 解析VML/CSS长度并转换为磅，无单位的数值按坐标单位原样返回
+func parseVMLLength(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return 0, false
+	}
+
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"pt", 1},
+		{"in", 72},
+		{"cm", 72 / 2.54},
+		{"mm", 72 / 25.4},
+		{"pc", 12},
+		{"px", 0.75},
+		{"emu", 1.0 / 12700},
+	}
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+			if err != nil {
+				return 0, false
+			}
+			return number * unit.factor, true
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// parseVMLPoint 解析 "x,y" 形式的坐标
+func parseVMLPoint(value string) (float64, float64, bool) {
+	xs, ys, found := strings.Cut(value, ",")
+	if !found {
+		return 0, 0, false
+	}
+	x, okX := parseVMLLength(xs)
+	y, okY := parseVMLLength(ys)
+	return x, y, okX && okY
+}
+
+// parseVMLColor 解析VML颜色，支持 "#rrggbb"、"#rgb"、命名颜色以及 "#4f81bd [3204]" 形式
+func parseVMLColor(value string) types.GraphicColor {
+	color := types.GraphicColor{Type: "rgb", Alpha: 1.0}
+
+	value = strings.TrimSpace(value)
+	if idx := strings.Index(value, "["); idx >= 0 {
+		// 方括号中是系统/方案颜色索引
+		color.Scheme = strings.Trim(value[idx:], "[] ")
+		value = strings.TrimSpace(value[:idx])
+	}
+
+	if strings.HasPrefix(value, "#") {
+		hex := strings.TrimPrefix(value, "#")
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		color.Value = strings.ToUpper(hex)
+		return color
+	}
+
+	if rgb, exists := vmlNamedColors[strings.ToLower(value)]; exists {
+		color.Value = rgb
+		return color
+	}
+
+	color.Type = "scheme"
+	color.Value = value
+	return color
+}
+
+// parseVMLBool 解析VML布尔值（t/true/on 与 f/false/off）
+func parseVMLBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "f", "false", "off", "0":
+		return false
+	default:
+		return true
+	}
+}
+
+// parseVMLFraction 解析分数值，支持 "50%"、"0.5" 与 "32768f"（1/65536）
+func parseVMLFraction(value string) float64 {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		if number, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64); err == nil {
+			return number / 100
+		}
+		return 0
+	}
+	return parseVMLFixed(value)
+}
+
+// parseVMLFixed 解析可能带 "fd"/"f" 后缀（1/65536）的数值
+func parseVMLFixed(value string) float64 {
+	value = strings.TrimSpace(value)
+	divisor := 1.0
+	if strings.HasSuffix(value, "fd") {
+		value = strings.TrimSuffix(value, "fd")
+		divisor = 65536
+	} else if strings.HasSuffix(value, "f") {
+		value = strings.TrimSuffix(value, "f")
+		divisor = 65536
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return number / divisor
+}
@@ -0,0 +1,182 @@
+package graphics
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/testutil"
+)
+
+// testDocumentXML 用常用命名空间包装正文
+func testDocumentXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+ xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+ xmlns:v="urn:schemas-microsoft-com:vml"
+ xmlns:o="urn:schemas-microsoft-com:office:office"
+ xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"
+ xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+ xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"
+ xmlns:wps="http://schemas.microsoft.com/office/word/2010/wordprocessingShape">
+<w:body>` + body + `</w:body></w:document>`
+}
+
+// writeTestDocx 创建只包含指定正文的测试DOCX文件
+func writeTestDocx(t *testing.T, body string) string {
+	t.Helper()
+	return testutil.WriteZip(t, filepath.Join(t.TempDir(), "test.docx"), map[string]string{"word/document.xml": testDocumentXML(body)})
+}
+
+// findElements 按类型筛选图形元素
+func findElements(graphics *types.DocumentGraphics, graphicType types.GraphicType) []*types.GraphicElement {
+	var result []*types.GraphicElement
+	for _, element := range graphics.Elements {
+		if element.Type == graphicType {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
+// TestParseGraphics_VMLShape 测试VML形状的位置、填充和边框
+func TestParseGraphics_VMLShape(t *testing.T) {
+	path := writeTestDocx(t, `<w:p><w:r><w:pict>
+<v:shapetype id="_x0000_t202" coordsize="21600,21600" o:spt="202"/>
+<v:rect id="_x0000_s1026" style="position:absolute;margin-left:36pt;margin-top:1in;width:2cm;height:50pt;z-index:3;rotation:45;mso-position-horizontal-relative:page"
+ fillcolor="#f00" strokecolor="blue [3204]" strokeweight="2pt">
+<v:stroke dashstyle="dash"/>
+<v:shadow on="t" offset="3pt,4pt"/>
+</v:rect>
+</w:pict></w:r></w:p>`)
+
+	graphics, err := NewDOCXGraphicsParser().ParseGraphics(path)
+	if err != nil {
+		t.Fatalf("解析图形失败: %v", err)
+	}
+
+	shapes := findElements(graphics, types.GraphicTypeShape)
+	if len(shapes) != 1 {
+		t.Fatalf("期望1个形状，实际 %d", len(shapes))
+	}
+	shape := shapes[0]
+
+	if shape.ID != "vml__x0000_s1026" {
+		t.Errorf("形状ID不正确: %s", shape.ID)
+	}
+	if shape.Position.X != 36 || shape.Position.Y != 72 {
+		t.Errorf("位置不正确: x=%v, y=%v", shape.Position.X, shape.Position.Y)
+	}
+	if shape.Size.Width < 56.6 || shape.Size.Width > 56.8 || shape.Size.Height != 50 {
+		t.Errorf("尺寸不正确: width=%v, height=%v", shape.Size.Width, shape.Size.Height)
+	}
+	if shape.ZIndex != 3 || shape.Style.Rotation != 45 {
+		t.Errorf("层级或旋转不正确: z=%d, rotation=%v", shape.ZIndex, shape.Style.Rotation)
+	}
+	if shape.Anchor.Type != "page" {
+		t.Errorf("期望锚点相对页面，实际 %s", shape.Anchor.Type)
+	}
+	if shape.Style.Fill.Color.Value != "FF0000" {
+		t.Errorf("填充颜色不正确: %s", shape.Style.Fill.Color.Value)
+	}
+	if shape.Style.Border.Color.Value != "0000FF" || shape.Style.Border.Width != 2 || shape.Style.Border.Style != "dashed" {
+		t.Errorf("边框不正确: %+v", shape.Style.Border)
+	}
+	if !shape.Style.Shadow.Enabled || shape.Style.Shadow.Distance != 5 {
+		t.Errorf("阴影不正确: %+v", shape.Style.Shadow)
+	}
+}
+
+// TestParseGraphics_VMLTextboxAndWordArt 测试VML文本框内容和艺术字
+func TestParseGraphics_VMLTextboxAndWordArt(t *testing.T) {
+	path := writeTestDocx(t, `<w:p><w:r><w:pict>
+<v:shape id="tb" type="#_x0000_t202" style="width:100pt;height:40pt">
+<v:textbox><w:txbxContent>
+<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:b/><w:sz w:val="28"/></w:rPr><w:t>第一行</w:t></w:r></w:p>
+<w:p><w:r><w:t>第二行</w:t></w:r></w:p>
+</w:txbxContent></v:textbox>
+</v:shape>
+<v:shape id="wa" type="#_x0000_t136" style="width:200pt;height:60pt">
+<v:textpath style="font-family:&quot;Arial Black&quot;;font-size:36pt;font-weight:bold" string="标题艺术字" fitshape="t"/>
+</v:shape>
+</w:pict></w:r></w:p>`)
+
+	graphics, err := NewDOCXGraphicsParser().ParseGraphics(path)
+	if err != nil {
+		t.Fatalf("解析图形失败: %v", err)
+	}
+
+	textboxes := findElements(graphics, types.GraphicTypeTextbox)
+	if len(textboxes) != 1 {
+		t.Fatalf("期望1个文本框，实际 %d", len(textboxes))
+	}
+	paragraphs := textboxes[0].Content.Paragraphs
+	if len(paragraphs) != 2 {
+		t.Fatalf("期望文本框包含2个段落，实际 %d", len(paragraphs))
+	}
+	if paragraphs[0].Text != "第一行" || paragraphs[0].Alignment != types.AlignCenter {
+		t.Errorf("第一段解析不正确: %+v", paragraphs[0])
+	}
+	if !paragraphs[0].Runs[0].Bold || paragraphs[0].Runs[0].Size != 14 {
+		t.Errorf("文本运行格式不正确: %+v", paragraphs[0].Runs[0])
+	}
+
+	wordArts := findElements(graphics, types.GraphicTypeWordArt)
+	if len(wordArts) != 1 {
+		t.Fatalf("期望1个艺术字，实际 %d", len(wordArts))
+	}
+	wordArt := wordArts[0].Content.WordArt
+	if wordArt.Text != "标题艺术字" || wordArt.FontFamily != "Arial Black" || wordArt.FontSize != 36 || !wordArt.Bold || !wordArt.FitShape {
+		t.Errorf("艺术字解析不正确: %+v", wordArt)
+	}
+}
+
+// TestParseGraphics_AlternateContent 测试mc:AlternateContent只计入一种表示
+func TestParseGraphics_AlternateContent(t *testing.T) {
+	path := writeTestDocx(t, `<w:p><w:r><mc:AlternateContent>
+<mc:Choice Requires="wps"><w:drawing><wp:anchor><wp:extent cx="1270000" cy="635000"/><wp:docPr id="7" name="文本框 7"/>
+<a:graphic><a:graphicData uri="http://schemas.microsoft.com/office/word/2010/wordprocessingShape"><wps:wsp><wps:txbx><w:txbxContent>
+<w:p><w:r><w:t>新版文本框</w:t></w:r></w:p>
+</w:txbxContent></wps:txbx></wps:wsp></a:graphicData></a:graphic></wp:anchor></w:drawing></mc:Choice>
+<mc:Fallback><w:pict><v:shape id="fb" type="#_x0000_t202"><v:textbox><w:txbxContent>
+<w:p><w:r><w:t>旧版文本框</w:t></w:r></w:p>
+</w:txbxContent></v:textbox></v:shape></w:pict></mc:Fallback>
+</mc:AlternateContent></w:r></w:p>
+<w:p><w:r><mc:AlternateContent>
+<mc:Choice Requires="w14"><w:t>不支持的内容</w:t></mc:Choice>
+<mc:Fallback><w:pict><v:oval id="ov" style="width:10pt;height:10pt"/></w:pict></mc:Fallback>
+</mc:AlternateContent></w:r></w:p>
+<w:p><w:r><mc:AlternateContent>
+<mc:Choice Requires="v"><w:pict><v:group id="choiceGroup" style="width:50pt;height:50pt"></v:group></w:pict></mc:Choice>
+<mc:Fallback><w:pict><v:group id="fallbackGroup" style="width:50pt;height:50pt"></v:group></w:pict></mc:Fallback>
+</mc:AlternateContent></w:r></w:p>`)
+
+	graphics, err := NewDOCXGraphicsParser().ParseGraphics(path)
+	if err != nil {
+		t.Fatalf("解析图形失败: %v", err)
+	}
+
+	textboxes := findElements(graphics, types.GraphicTypeTextbox)
+	if len(textboxes) != 1 {
+		t.Fatalf("期望只计入1个文本框，实际 %d", len(textboxes))
+	}
+	if textboxes[0].Content.Text != "新版文本框" || textboxes[0].Metadata.Source != "drawingml" {
+		t.Errorf("期望采用mc:Choice表示，实际 %q (%s)", textboxes[0].Content.Text, textboxes[0].Metadata.Source)
+	}
+
+	shapes := findElements(graphics, types.GraphicTypeShape)
+	if len(shapes) != 1 || shapes[0].ID != "vml_ov" {
+		t.Errorf("期望Choice无图形时采用mc:Fallback中的椭圆，实际 %d 个形状", len(shapes))
+	}
+
+	var groups []string
+	for _, group := range graphics.Groups {
+		if strings.HasPrefix(group.ID, "vml_") {
+			groups = append(groups, group.ID)
+		}
+	}
+	if len(groups) != 1 || groups[0] != "vml_choiceGroup" {
+		t.Errorf("期望只计入mc:Choice中的图形组，实际 %v", groups)
+	}
+}
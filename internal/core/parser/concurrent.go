@@ -1,400 +1,607 @@
-package parser
-
-import (
-	"context"
-	"fmt"
-	"sync"
-	"time"
-
-	"docs-parser/internal/core/types"
-)
-
-// Job 处理任务
-type Job struct {
-	ID       string
-	FilePath string
-	Priority int
-}
-
-// Result 处理结果
-type Result struct {
-	JobID     string
-	Document  *types.Document
-	Error     error
-	Duration  time.Duration
-	StartTime time.Time
-	EndTime   time.Time
-}
-
-// ConcurrentProcessor 并发处理器
-type ConcurrentProcessor struct {
-	workers    int
-	jobQueue   chan Job
-	resultChan chan Result
-	ctx        context.Context
-	cancel     context.CancelFunc
-	wg         sync.WaitGroup
-	stats      ProcessorStats
-	mu         sync.RWMutex
-}
-
-// ProcessorStats 处理器统计信息
-type ProcessorStats struct {
-	JobsProcessed int64
-	JobsFailed    int64
-	TotalDuration time.Duration
-	AverageTime   time.Duration
-	LastJobTime   time.Time
-}
-
-// NewConcurrentProcessor 创建新的并发处理器
-func NewConcurrentProcessor(workers int) *ConcurrentProcessor {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	return &ConcurrentProcessor{
-		workers:    workers,
-		jobQueue:   make(chan Job, workers*2),
-		resultChan: make(chan Result, workers*2),
-		ctx:        ctx,
-		cancel:     cancel,
-		stats:      ProcessorStats{LastJobTime: time.Now()},
-	}
-}
-
-// Start 启动并发处理器
-func (cp *ConcurrentProcessor) Start() {
-	for i := 0; i < cp.workers; i++ {
-		cp.wg.Add(1)
-		go cp.worker(i)
-	}
-}
-
-// Stop 停止并发处理器
-func (cp *ConcurrentProcessor) Stop() {
-	cp.cancel()
-	close(cp.jobQueue)
-	cp.wg.Wait()
-	close(cp.resultChan)
-}
-
-// SubmitJob 提交任务
-func (cp *ConcurrentProcessor) SubmitJob(job Job) error {
-	select {
-	case cp.jobQueue <- job:
-		return nil
-	case <-cp.ctx.Done():
-		return fmt.Errorf("processor is stopped")
-	default:
-		return fmt.Errorf("job queue is full")
-	}
-}
-
-// GetResults 获取结果通道
-func (cp *ConcurrentProcessor) GetResults() <-chan Result {
-	return cp.resultChan
-}
-
-// ProcessBatch 批量处理文件
-func (cp *ConcurrentProcessor) ProcessBatch(files []string) []Result {
-	// 启动处理器
-	cp.Start()
-	defer cp.Stop()
-
-	// 提交所有任务
-	for i, file := range files {
-		job := Job{
-			ID:       fmt.Sprintf("job_%d", i),
-			FilePath: file,
-			Priority: 1,
-		}
-		cp.SubmitJob(job)
-	}
-
-	// 收集结果
-	var results []Result
-	for range files {
-		select {
-		case result := <-cp.resultChan:
-			results = append(results, result)
-		case <-time.After(30 * time.Second):
-			// 超时处理
-			break
-		}
-	}
-
-	return results
-}
-
-// worker 工作协程
-func (cp *ConcurrentProcessor) worker(id int) {
-	defer cp.wg.Done()
-
-	// 获取全局池管理器
-	poolManager := GetGlobalPoolManager()
-
-	for {
-		select {
-		case job := <-cp.jobQueue:
-			cp.processJob(job, poolManager)
-		case <-cp.ctx.Done():
-			return
-		}
-	}
-}
-
-// processJob 处理单个任务
-func (cp *ConcurrentProcessor) processJob(job Job, poolManager *GlobalPoolManager) {
-	startTime := time.Now()
-
-	// 从池中获取解析器
-	parser := poolManager.GetParser()
-	defer poolManager.PutParser(parser)
-
-	// 从池中获取文档对象
-	doc := poolManager.GetDocument()
-	defer poolManager.PutDocument(doc)
-
-	// 解析文档
-	err := cp.parseDocument(parser, job.FilePath, doc)
-
-	endTime := time.Now()
-	duration := endTime.Sub(startTime)
-
-	// 更新统计信息
-	cp.updateStats(duration, err)
-
-	// 发送结果
-	result := Result{
-		JobID:     job.ID,
-		Document:  doc,
-		Error:     err,
-		Duration:  duration,
-		StartTime: startTime,
-		EndTime:   endTime,
-	}
-
-	select {
-	case cp.resultChan <- result:
-	case <-cp.ctx.Done():
-		return
-	}
-}
-
-// parseDocument 解析文档
-func (cp *ConcurrentProcessor) parseDocument(parser Parser, filePath string, doc *types.Document) error {
-	// 使用解析器解析文档
-	parsedDoc, err := parser.ParseDocument(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to parse document: %w", err)
-	}
-
-	// 复制解析结果到池中的文档对象
-	*doc = *parsedDoc
-
-	return nil
-}
-
-// updateStats 更新统计信息
-func (cp *ConcurrentProcessor) updateStats(duration time.Duration, err error) {
-	cp.mu.Lock()
-	defer cp.mu.Unlock()
-
-	cp.stats.JobsProcessed++
-	cp.stats.TotalDuration += duration
-	cp.stats.AverageTime = cp.stats.TotalDuration / time.Duration(cp.stats.JobsProcessed)
-	cp.stats.LastJobTime = time.Now()
-
-	if err != nil {
-		cp.stats.JobsFailed++
-	}
-}
-
-// GetStats 获取统计信息
-func (cp *ConcurrentProcessor) GetStats() ProcessorStats {
-	cp.mu.RLock()
-	defer cp.mu.RUnlock()
-	return cp.stats
-}
-
-// Reset 重置统计信息
-func (cp *ConcurrentProcessor) Reset() {
-	cp.mu.Lock()
-	cp.stats = ProcessorStats{LastJobTime: time.Now()}
-	cp.mu.Unlock()
-}
-
-// BatchProcessor 批量处理器
-type BatchProcessor struct {
-	processor *ConcurrentProcessor
-	pool      *GlobalPoolManager
-}
-
-// NewBatchProcessor 创建新的批量处理器
-func NewBatchProcessor(workers int) *BatchProcessor {
-	return &BatchProcessor{
-		processor: NewConcurrentProcessor(workers),
-		pool:      GetGlobalPoolManager(),
-	}
-}
-
-// ProcessFiles 批量处理文件
-func (bp *BatchProcessor) ProcessFiles(files []string) ([]*types.Document, []error) {
-	// 启动处理器
-	bp.processor.Start()
-	defer bp.processor.Stop()
-
-	// 提交所有任务
-	for i, file := range files {
-		job := Job{
-			ID:       fmt.Sprintf("batch_%d", i),
-			FilePath: file,
-			Priority: 1,
-		}
-		bp.processor.SubmitJob(job)
-	}
-
-	// 收集结果
-	var documents []*types.Document
-	var errors []error
-
-	for range files {
-		select {
-		case result := <-bp.processor.GetResults():
-			if result.Error != nil {
-				errors = append(errors, result.Error)
-				documents = append(documents, nil)
-			} else {
-				documents = append(documents, result.Document)
-				errors = append(errors, nil)
-			}
-		case <-time.After(60 * time.Second):
-			// 超时处理
-			break
-		}
-	}
-
-	return documents, errors
-}
-
-// ProcessFilesWithCallback 带回调的批量处理
-func (bp *BatchProcessor) ProcessFilesWithCallback(files []string, callback func(int, *types.Document, error)) {
-	// 启动处理器
-	bp.processor.Start()
-	defer bp.processor.Stop()
-
-	// 提交所有任务
-	for i, file := range files {
-		job := Job{
-			ID:       fmt.Sprintf("callback_%d", i),
-			FilePath: file,
-			Priority: 1,
-		}
-		bp.processor.SubmitJob(job)
-	}
-
-	// 处理结果
-	for i := range files {
-		select {
-		case result := <-bp.processor.GetResults():
-			callback(i, result.Document, result.Error)
-		case <-time.After(60 * time.Second):
-			// 超时处理
-			callback(i, nil, fmt.Errorf("processing timeout"))
-		}
-	}
-}
-
-// GetStats 获取统计信息
-func (bp *BatchProcessor) GetStats() ProcessorStats {
-	return bp.processor.GetStats()
-}
-
-// GetPoolStats 获取池统计信息
-func (bp *BatchProcessor) GetPoolStats() map[string]PoolStats {
-	return bp.pool.GetStats()
-}
-
-// Reset 重置统计信息
-func (bp *BatchProcessor) Reset() {
-	bp.processor.Reset()
-	bp.pool.Reset()
-}
-
-// Close 关闭处理器
-func (bp *BatchProcessor) Close() {
-	bp.processor.Stop()
-	bp.pool.Close()
-}
-
-// StreamingBatchProcessor 流式批量处理器
-type StreamingBatchProcessor struct {
-	processor *ConcurrentProcessor
-	pool      *GlobalPoolManager
-}
-
-// NewStreamingBatchProcessor 创建新的流式批量处理器
-func NewStreamingBatchProcessor(workers int) *StreamingBatchProcessor {
-	return &StreamingBatchProcessor{
-		processor: NewConcurrentProcessor(workers),
-		pool:      GetGlobalPoolManager(),
-	}
-}
-
-// ProcessFilesStream 流式批量处理文件
-func (sbp *StreamingBatchProcessor) ProcessFilesStream(files []string) (<-chan Result, error) {
-	resultChan := make(chan Result, len(files))
-
-	// 启动处理器
-	sbp.processor.Start()
-
-	// 在后台处理结果
-	go func() {
-		defer close(resultChan)
-		defer sbp.processor.Stop()
-
-		// 提交所有任务
-		for i, file := range files {
-			job := Job{
-				ID:       fmt.Sprintf("stream_%d", i),
-				FilePath: file,
-				Priority: 1,
-			}
-			sbp.processor.SubmitJob(job)
-		}
-
-		// 流式返回结果
-		for range files {
-			select {
-			case result := <-sbp.processor.GetResults():
-				resultChan <- result
-			case <-time.After(60 * time.Second):
-				// 超时处理
-				break
-			}
-		}
-	}()
-
-	return resultChan, nil
-}
-
-// GetStats 获取统计信息
-func (sbp *StreamingBatchProcessor) GetStats() ProcessorStats {
-	return sbp.processor.GetStats()
-}
-
-// GetPoolStats 获取池统计信息
-func (sbp *StreamingBatchProcessor) GetPoolStats() map[string]PoolStats {
-	return sbp.pool.GetStats()
-}
-
-// Reset 重置统计信息
-func (sbp *StreamingBatchProcessor) Reset() {
-	sbp.processor.Reset()
-	sbp.pool.Reset()
-}
-
-// Close 关闭处理器
-func (sbp *StreamingBatchProcessor) Close() {
-	sbp.processor.Stop()
-	sbp.pool.Close()
-}
+package parser
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"docs-parser/internal/core/types"
+)
+
+// DefaultDocumentTimeout 单个文档的默认解析时限
+const DefaultDocumentTimeout = 30 * time.Second
+
+// ErrQueueFull 任务队列已满
+var ErrQueueFull = fmt.Errorf("job queue is full")
+
+// ErrProcessorStopped 处理器已停止，不再接受任务
+var ErrProcessorStopped = fmt.Errorf("processor is stopped")
+
+// Job 处理任务
+type Job struct {
+	ID       string
+	FilePath string
+	Priority int
+
+	ctx  context.Context             // 任务所属批次的ctx，为nil时使用处理器的ctx
+	task func(context.Context) error // 自定义任务，非nil时代替文档解析执行
+}
+
+// Result 处理结果
+type Result struct {
+	JobID     string
+	Document  *types.Document
+	Error     error
+	Duration  time.Duration
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// ConcurrentProcessor 并发处理器
+type ConcurrentProcessor struct {
+	workers    int
+	jobQueue   chan Job
+	resultChan chan Result
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	stats      ProcessorStats
+	mu         sync.RWMutex
+
+	// stopped表示任务队列已关闭；提交任务时持有读锁，Stop关闭队列时持有写锁，避免向已关闭的队列发送
+	queueMu sync.RWMutex
+	stopped bool
+
+	documentTimeout time.Duration // 单个文档的解析时限，0表示不限制
+	parser          Parser        // 指定的解析器，nil时从全局池获取
+}
+
+// ProcessorStats 处理器统计信息
+type ProcessorStats struct {
+	JobsProcessed int64
+	JobsFailed    int64
+	TotalDuration time.Duration
+	AverageTime   time.Duration
+	LastJobTime   time.Time
+}
+
+// NewConcurrentProcessor 创建新的并发处理器
+func NewConcurrentProcessor(workers int) *ConcurrentProcessor {
+	return NewConcurrentProcessorWithQueue(workers, workers*2)
+}
+
+// NewConcurrentProcessorWithQueue 创建任务队列容量为queueSize的并发处理器
+//
+// 队列已满时SubmitJob和SubmitTask立即返回ErrQueueFull，调用方可据此拒绝新的请求。
+func NewConcurrentProcessorWithQueue(workers, queueSize int) *ConcurrentProcessor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ConcurrentProcessor{
+		workers:    workers,
+		jobQueue:   make(chan Job, queueSize),
+		resultChan: make(chan Result, workers*2),
+		ctx:        ctx,
+		cancel:     cancel,
+		stats:      ProcessorStats{LastJobTime: time.Now()},
+
+		documentTimeout: DefaultDocumentTimeout,
+	}
+}
+
+// SetParser 指定工作协程使用的解析器，nil表示从全局池获取
+//
+// 指定的解析器由所有工作协程共享，必须支持并发调用。
+func (cp *ConcurrentProcessor) SetParser(parser Parser) {
+	cp.parser = parser
+}
+
+// SetDocumentTimeout 设置单个文档的解析时限，0表示不限制
+//
+// 超时的文档在结果中返回*types.TimeoutError和已解析的部分文档，不影响批次中的其他文档。
+func (cp *ConcurrentProcessor) SetDocumentTimeout(timeout time.Duration) {
+	cp.documentTimeout = timeout
+}
+
+// Start 启动并发处理器
+func (cp *ConcurrentProcessor) Start() {
+	for i := 0; i < cp.workers; i++ {
+		cp.wg.Add(1)
+		go cp.worker(i)
+	}
+}
+
+// Stop 停止并发处理器，重复调用无效果；停止后提交任务返回ErrProcessorStopped
+func (cp *ConcurrentProcessor) Stop() {
+	// 先取消ctx，使等待入队的SubmitJobContext释放读锁
+	cp.cancel()
+
+	cp.queueMu.Lock()
+	if cp.stopped {
+		cp.queueMu.Unlock()
+		return
+	}
+	cp.stopped = true
+	close(cp.jobQueue)
+	cp.queueMu.Unlock()
+
+	cp.wg.Wait()
+	close(cp.resultChan)
+}
+
+// SubmitJob 提交任务
+func (cp *ConcurrentProcessor) SubmitJob(job Job) error {
+	cp.queueMu.RLock()
+	defer cp.queueMu.RUnlock()
+	if cp.stopped {
+		return ErrProcessorStopped
+	}
+
+	select {
+	case cp.jobQueue <- job:
+		return nil
+	case <-cp.ctx.Done():
+		return ErrProcessorStopped
+	default:
+		return ErrQueueFull
+	}
+}
+
+// SubmitTask 提交自定义任务，队列已满时返回ErrQueueFull，处理器已停止时返回ErrProcessorStopped
+//
+// 任务在工作协程中以ctx执行，设置了单个文档时限时同样生效；执行结果以Result发送到结果通道，
+// Result.Document为nil，调用方需持续读取GetResults。
+func (cp *ConcurrentProcessor) SubmitTask(ctx context.Context, id string, task func(context.Context) error) error {
+	return cp.SubmitJob(Job{ID: id, Priority: 1, ctx: ctx, task: task})
+}
+
+// QueueLength 返回等待执行的任务数
+func (cp *ConcurrentProcessor) QueueLength() int {
+	return len(cp.jobQueue)
+}
+
+// QueueCapacity 返回任务队列容量
+func (cp *ConcurrentProcessor) QueueCapacity() int {
+	return cap(cp.jobQueue)
+}
+
+// SubmitJobContext 提交任务，队列已满时等待，直到任务入队、ctx结束或处理器停止
+//
+// 任务的解析受ctx控制。
+func (cp *ConcurrentProcessor) SubmitJobContext(ctx context.Context, job Job) error {
+	cp.queueMu.RLock()
+	defer cp.queueMu.RUnlock()
+	if cp.stopped {
+		return ErrProcessorStopped
+	}
+
+	job.ctx = ctx
+	select {
+	case cp.jobQueue <- job:
+		return nil
+	case <-ctx.Done():
+		return types.CheckContext(ctx, "submit job", job.FilePath)
+	case <-cp.ctx.Done():
+		return ErrProcessorStopped
+	}
+}
+
+// GetResults 获取结果通道
+func (cp *ConcurrentProcessor) GetResults() <-chan Result {
+	return cp.resultChan
+}
+
+// ProcessBatch 批量处理文件
+func (cp *ConcurrentProcessor) ProcessBatch(files []string) []Result {
+	results, _ := cp.ProcessBatchContext(context.Background(), files)
+	return results
+}
+
+// ProcessBatchContext 受ctx控制的批量处理，结果与files一一对应
+//
+// ctx结束时立即返回*types.TimeoutError：已完成的文档保留各自的结果，
+// 未完成的文档结果中的Error为*types.TimeoutError。批次结束时处理器随之停止，
+// 再次调用返回ErrProcessorStopped，每个文档结果中的Error同为ErrProcessorStopped。
+func (cp *ConcurrentProcessor) ProcessBatchContext(ctx context.Context, files []string) ([]Result, error) {
+	results := make([]Result, len(files))
+	done := make([]bool, len(files))
+
+	err := cp.runBatch(ctx, "job", files, func(index int, result Result) {
+		results[index] = result
+		done[index] = true
+	})
+
+	if err != nil {
+		for i := range results {
+			if !done[i] {
+				results[i] = Result{
+					JobID: fmt.Sprintf("job_%d", i),
+					Error: pendingError(ctx, err, files[i]),
+				}
+			}
+		}
+	}
+
+	return results, err
+}
+
+// runBatch 启动处理器并处理一批文件，每完成一个文档调用一次handle，index为文档在files中的位置
+//
+// 所有文档完成时返回nil，ctx先结束时返回*types.TimeoutError。返回时处理器已停止，
+// 处理器已停止时不处理任何文档，返回ErrProcessorStopped；提交任务失败时返回提交的错误。
+func (cp *ConcurrentProcessor) runBatch(ctx context.Context, prefix string, files []string, handle func(index int, result Result)) error {
+	if err := cp.checkStopped(); err != nil {
+		return err
+	}
+
+	// 启动处理器
+	cp.Start()
+	defer cp.Stop()
+
+	// 在后台提交任务，队列满时等待工作协程消费，返回前确保提交协程已退出
+	submitCtx, cancelSubmit := context.WithCancel(ctx)
+	submitted := make(chan struct{})
+	submitErr := make(chan error, 1)
+	indexes := make(map[string]int, len(files))
+	jobs := make([]Job, len(files))
+	for i, file := range files {
+		jobs[i] = Job{
+			ID:       fmt.Sprintf("%s_%d", prefix, i),
+			FilePath: file,
+			Priority: 1,
+		}
+		indexes[jobs[i].ID] = i
+	}
+	go func() {
+		defer close(submitted)
+		for _, job := range jobs {
+			if err := cp.SubmitJobContext(submitCtx, job); err != nil {
+				submitErr <- err
+				return
+			}
+		}
+	}()
+	defer func() {
+		cancelSubmit()
+		<-submitted
+	}()
+
+	// 收集结果
+	for range files {
+		select {
+		case result, ok := <-cp.resultChan:
+			if !ok {
+				return ErrProcessorStopped
+			}
+			handle(indexes[result.JobID], result)
+		case err := <-submitErr:
+			return err
+		case <-ctx.Done():
+			return types.CheckContext(ctx, "process batch", "")
+		}
+	}
+
+	return nil
+}
+
+// checkStopped 处理器已停止时返回ErrProcessorStopped
+func (cp *ConcurrentProcessor) checkStopped() error {
+	cp.queueMu.RLock()
+	defer cp.queueMu.RUnlock()
+	if cp.stopped {
+		return ErrProcessorStopped
+	}
+	return nil
+}
+
+// pendingError 批次返回err时未完成的文档的错误：ctx结束时为该文档的*types.TimeoutError，否则为err
+func pendingError(ctx context.Context, err error, filePath string) error {
+	if _, ok := types.AsTimeoutError(err); ok {
+		return &types.TimeoutError{Op: "process batch", Path: filePath, Err: ctx.Err()}
+	}
+	return err
+}
+
+// worker 工作协程
+func (cp *ConcurrentProcessor) worker(id int) {
+	defer cp.wg.Done()
+
+	// 获取全局池管理器
+	poolManager := GetGlobalPoolManager()
+
+	for {
+		select {
+		case job, ok := <-cp.jobQueue:
+			if !ok {
+				return
+			}
+			cp.processJob(job, poolManager)
+		case <-cp.ctx.Done():
+			return
+		}
+	}
+}
+
+// processJob 处理单个任务
+func (cp *ConcurrentProcessor) processJob(job Job, poolManager *GlobalPoolManager) {
+	startTime := time.Now()
+
+	// 单个任务的时限从任务开始时计算
+	ctx := job.ctx
+	if ctx == nil {
+		ctx = cp.ctx
+	}
+	if cp.documentTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cp.documentTimeout)
+		defer cancel()
+	}
+
+	var doc *types.Document
+	if job.task == nil {
+		// 从池中获取文档对象，结果送达后文档归接收方所有，只有未送达时才归还
+		doc = poolManager.GetDocument()
+	}
+	err := cp.runJob(ctx, job, doc, poolManager)
+
+	endTime := time.Now()
+	duration := endTime.Sub(startTime)
+
+	// 更新统计信息
+	cp.updateStats(duration, err)
+
+	// 发送结果
+	result := Result{
+		JobID:     job.ID,
+		Document:  doc,
+		Error:     err,
+		Duration:  duration,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+
+	select {
+	case cp.resultChan <- result:
+	case <-cp.ctx.Done():
+		if doc != nil {
+			poolManager.PutDocument(doc)
+		}
+		return
+	}
+}
+
+// runJob 执行自定义任务或将文档解析到doc中，任务或解析器panic时转换为错误，工作协程继续处理后续任务
+func (cp *ConcurrentProcessor) runJob(ctx context.Context, job Job, doc *types.Document, poolManager *GlobalPoolManager) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job %s panicked: %v", job.ID, r)
+		}
+	}()
+
+	if job.task != nil {
+		return job.task(ctx)
+	}
+
+	// 从池中获取解析器
+	parser := cp.parser
+	if parser == nil {
+		parser = poolManager.GetParser()
+		defer poolManager.PutParser(parser)
+	}
+	return cp.parseDocument(ctx, parser, job.FilePath, doc)
+}
+
+// parseDocument 解析文档
+func (cp *ConcurrentProcessor) parseDocument(ctx context.Context, parser Parser, filePath string, doc *types.Document) error {
+	// 使用解析器解析文档
+	parsedDoc, err := WithContext(parser).ParseDocumentContext(ctx, filePath)
+
+	// 复制解析结果到池中的文档对象，超时时保留部分结果
+	if parsedDoc != nil {
+		*doc = *parsedDoc
+	}
+
+	if err != nil {
+		if _, ok := types.AsTimeoutError(err); ok {
+			return err
+		}
+		return fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	return nil
+}
+
+// updateStats 更新统计信息
+func (cp *ConcurrentProcessor) updateStats(duration time.Duration, err error) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	cp.stats.JobsProcessed++
+	cp.stats.TotalDuration += duration
+	cp.stats.AverageTime = cp.stats.TotalDuration / time.Duration(cp.stats.JobsProcessed)
+	cp.stats.LastJobTime = time.Now()
+
+	if err != nil {
+		cp.stats.JobsFailed++
+	}
+}
+
+// GetStats 获取统计信息
+func (cp *ConcurrentProcessor) GetStats() ProcessorStats {
+	cp.mu.RLock()
+	defer cp.mu.RUnlock()
+	return cp.stats
+}
+
+// Reset 重置统计信息
+func (cp *ConcurrentProcessor) Reset() {
+	cp.mu.Lock()
+	cp.stats = ProcessorStats{LastJobTime: time.Now()}
+	cp.mu.Unlock()
+}
+
+// BatchProcessor 批量处理器
+type BatchProcessor struct {
+	processor *ConcurrentProcessor
+	pool      *GlobalPoolManager
+}
+
+// NewBatchProcessor 创建新的批量处理器
+func NewBatchProcessor(workers int) *BatchProcessor {
+	processor := NewConcurrentProcessor(workers)
+	processor.SetDocumentTimeout(60 * time.Second)
+
+	return &BatchProcessor{
+		processor: processor,
+		pool:      GetGlobalPoolManager(),
+	}
+}
+
+// SetParser 指定解析器，nil表示使用全局池中的解析器
+func (bp *BatchProcessor) SetParser(parser Parser) {
+	bp.processor.SetParser(parser)
+}
+
+// SetDocumentTimeout 设置单个文档的解析时限，0表示不限制
+func (bp *BatchProcessor) SetDocumentTimeout(timeout time.Duration) {
+	bp.processor.SetDocumentTimeout(timeout)
+}
+
+// ProcessFiles 批量处理文件
+func (bp *BatchProcessor) ProcessFiles(files []string) ([]*types.Document, []error) {
+	return bp.ProcessFilesContext(context.Background(), files)
+}
+
+// ProcessFilesContext 受ctx控制的批量处理，结果与files一一对应
+//
+// 解析失败的文档对应的文档为nil；超时或取消的文档返回部分结果和*types.TimeoutError。
+// 每个BatchProcessor只能处理一个批次，再次调用时每个文档的错误为ErrProcessorStopped。
+func (bp *BatchProcessor) ProcessFilesContext(ctx context.Context, files []string) ([]*types.Document, []error) {
+	documents := make([]*types.Document, len(files))
+	errors := make([]error, len(files))
+
+	results, _ := bp.processor.ProcessBatchContext(ctx, files)
+	for i, result := range results {
+		errors[i] = result.Error
+		if result.Error == nil {
+			documents[i] = result.Document
+		} else if _, ok := types.AsTimeoutError(result.Error); ok {
+			documents[i] = result.Document
+		}
+	}
+
+	return documents, errors
+}
+
+// ProcessFilesWithCallback 带回调的批量处理
+func (bp *BatchProcessor) ProcessFilesWithCallback(files []string, callback func(int, *types.Document, error)) {
+	bp.ProcessFilesWithCallbackContext(context.Background(), files, callback)
+}
+
+// ProcessFilesWithCallbackContext 受ctx控制的带回调批量处理，callback的index为文档在files中的位置
+//
+// ctx结束时对尚未完成的文档以*types.TimeoutError调用callback，并返回该错误；
+// 处理器已停止等其他错误同样对尚未完成的文档调用callback。
+func (bp *BatchProcessor) ProcessFilesWithCallbackContext(ctx context.Context, files []string, callback func(int, *types.Document, error)) error {
+	done := make([]bool, len(files))
+	err := bp.processor.runBatch(ctx, "callback", files, func(index int, result Result) {
+		done[index] = true
+		callback(index, result.Document, result.Error)
+	})
+
+	if err != nil {
+		for i, file := range files {
+			if !done[i] {
+				callback(i, nil, pendingError(ctx, err, file))
+			}
+		}
+	}
+
+	return err
+}
+
+// GetStats 获取统计信息
+func (bp *BatchProcessor) GetStats() ProcessorStats {
+	return bp.processor.GetStats()
+}
+
+// GetPoolStats 获取池统计信息
+func (bp *BatchProcessor) GetPoolStats() map[string]PoolStats {
+	return bp.pool.GetStats()
+}
+
+// Reset 重置统计信息
+func (bp *BatchProcessor) Reset() {
+	bp.processor.Reset()
+	bp.pool.Reset()
+}
+
+// Close 关闭处理器
+func (bp *BatchProcessor) Close() {
+	bp.processor.Stop()
+	bp.pool.Close()
+}
+
+// StreamingBatchProcessor 流式批量处理器
+type StreamingBatchProcessor struct {
+	processor *ConcurrentProcessor
+	pool      *GlobalPoolManager
+}
+
+// NewStreamingBatchProcessor 创建新的流式批量处理器
+func NewStreamingBatchProcessor(workers int) *StreamingBatchProcessor {
+	return &StreamingBatchProcessor{
+		processor: NewConcurrentProcessor(workers),
+		pool:      GetGlobalPoolManager(),
+	}
+}
+
+// ProcessFilesStream 流式批量处理文件
+func (sbp *StreamingBatchProcessor) ProcessFilesStream(files []string) (<-chan Result, error) {
+	return sbp.ProcessFilesStreamContext(context.Background(), files)
+}
+
+// ProcessFilesStreamContext 受ctx控制的流式批量处理
+//
+// 结果按完成顺序发送，ctx结束时停止处理并关闭通道，未完成的文档不再发送结果。
+// 处理器已停止（已处理过一个批次或已关闭）时返回ErrProcessorStopped。
+func (sbp *StreamingBatchProcessor) ProcessFilesStreamContext(ctx context.Context, files []string) (<-chan Result, error) {
+	if err := sbp.processor.checkStopped(); err != nil {
+		return nil, err
+	}
+	resultChan := make(chan Result, len(files))
+
+	// 在后台处理结果
+	go func() {
+		defer close(resultChan)
+
+		// 通道容量等于文件数，发送不会阻塞
+		sbp.processor.runBatch(ctx, "stream", files, func(index int, result Result) {
+			resultChan <- result
+		})
+	}()
+
+	return resultChan, nil
+}
+
+// GetStats 获取统计信息
+func (sbp *StreamingBatchProcessor) GetStats() ProcessorStats {
+	return sbp.processor.GetStats()
+}
+
+// GetPoolStats 获取池统计信息
+func (sbp *StreamingBatchProcessor) GetPoolStats() map[string]PoolStats {
+	return sbp.pool.GetStats()
+}
+
+// Reset 重置统计信息
+func (sbp *StreamingBatchProcessor) Reset() {
+	sbp.processor.Reset()
+	sbp.pool.Reset()
+}
+
+// Close 关闭处理器
+func (sbp *StreamingBatchProcessor) Close() {
+	sbp.processor.Stop()
+	sbp.pool.Close()
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"docs-parser/internal/core/types"
+)
+
+// blockingParser 解析时一直阻塞到release关闭的解析器
+type blockingParser struct {
+	Parser
+	release chan struct{}
+}
+
+// ParseDocument 阻塞直到release关闭
+func (bp *blockingParser) ParseDocument(filePath string) (*types.Document, error) {
+	<-bp.release
+	return &types.Document{}, nil
+}
+
+// TestWithContext 测试不支持context的解析器在截止时间到达时立即返回
+func TestWithContext(t *testing.T) {
+	blocking := &blockingParser{Parser: &DefaultParser{}, release: make(chan struct{})}
+	defer close(blocking.release)
+
+	if _, ok := WithContext(&DefaultParser{}).(*DefaultParser); !ok {
+		t.Error("已实现ContextParser的解析器应直接返回")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := WithContext(blocking).ParseDocumentContext(ctx, "slow.docx")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("超时后应立即返回，实际耗时 %v", elapsed)
+	}
+
+	timeoutErr, ok := types.AsTimeoutError(err)
+	if !ok || !timeoutErr.Timeout() || timeoutErr.Path != "slow.docx" {
+		t.Errorf("应返回带文件路径的超时错误，实际: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("超时错误应能用errors.Is判断")
+	}
+}
+
+// TestConcurrentProcessor_ProcessBatchContext 测试已取消的ctx使批处理立即返回，结果与文件一一对应
+func TestConcurrentProcessor_ProcessBatchContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	files := []string{"a.docx", "b.docx", "c.docx"}
+	results, err := NewConcurrentProcessor(2).ProcessBatchContext(ctx, files)
+	if _, ok := types.AsTimeoutError(err); !ok {
+		t.Fatalf("ctx已取消时应返回TimeoutError，实际: %v", err)
+	}
+	if len(results) != len(files) {
+		t.Fatalf("结果数量应与文件数量一致: %d", len(results))
+	}
+	for i, result := range results {
+		if _, ok := types.AsTimeoutError(result.Error); !ok {
+			t.Errorf("第 %d 个结果应为超时错误: %v", i, result.Error)
+		}
+	}
+}
+
+// panickingParser 解析时panic的解析器
+type panickingParser struct {
+	Parser
+}
+
+// ParseDocument 总是panic
+func (pp *panickingParser) ParseDocument(filePath string) (*types.Document, error) {
+	panic("corrupt input")
+}
+
+// TestConcurrentProcessor_Panic 测试解析器和任务的panic成为失败的任务，工作协程继续处理后续任务
+func TestConcurrentProcessor_Panic(t *testing.T) {
+	if _, err := WithContext(&panickingParser{Parser: &DefaultParser{}}).ParseDocumentContext(context.Background(), "bad.docx"); err == nil {
+		t.Error("解析器panic时应返回错误")
+	}
+
+	processor := NewConcurrentProcessorWithQueue(1, 3)
+	processor.SetParser(&panickingParser{Parser: &DefaultParser{}})
+	processor.Start()
+	defer processor.Stop()
+
+	if err := processor.SubmitJob(Job{ID: "parse", FilePath: "bad.docx"}); err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	if err := processor.SubmitTask(context.Background(), "task", func(context.Context) error { panic("boom") }); err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	if err := processor.SubmitTask(context.Background(), "ok", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		result := <-processor.GetResults()
+		if (result.Error == nil) != (result.JobID == "ok") {
+			t.Errorf("任务 %s 的结果不正确: %v", result.JobID, result.Error)
+		}
+	}
+	if stats := processor.GetStats(); stats.JobsFailed != 2 {
+		t.Errorf("应记录2个失败的任务，实际 %d", stats.JobsFailed)
+	}
+}
+
+// TestConcurrentProcessor_SubmitAfterStop 测试停止后提交任务返回ErrProcessorStopped，重复停止不会panic
+func TestConcurrentProcessor_SubmitAfterStop(t *testing.T) {
+	processor := NewConcurrentProcessor(1)
+	processor.Start()
+	processor.Stop()
+	processor.Stop()
+
+	if err := processor.SubmitTask(context.Background(), "late", func(context.Context) error { return nil }); !errors.Is(err, ErrProcessorStopped) {
+		t.Errorf("停止后提交任务应返回ErrProcessorStopped，实际: %v", err)
+	}
+	if err := processor.SubmitJobContext(context.Background(), Job{ID: "late"}); !errors.Is(err, ErrProcessorStopped) {
+		t.Errorf("停止后提交任务应返回ErrProcessorStopped，实际: %v", err)
+	}
+}
+
+// TestBatchProcessor_SecondBatch 测试处理过一个批次后再次处理返回ErrProcessorStopped，而不是空的结果
+func TestBatchProcessor_SecondBatch(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	processor := NewBatchProcessor(2)
+	processor.SetParser(&blockingParser{Parser: &DefaultParser{}, release: release})
+
+	files := []string{"a.docx", "b.docx"}
+	documents, errs := processor.ProcessFiles(files)
+	for i := range files {
+		if documents[i] == nil || errs[i] != nil {
+			t.Fatalf("第一个批次的第 %d 个文档应解析成功: %v", i, errs[i])
+		}
+	}
+
+	documents, errs = processor.ProcessFiles(files)
+	for i := range files {
+		if documents[i] != nil || !errors.Is(errs[i], ErrProcessorStopped) {
+			t.Errorf("第二个批次的第 %d 个文档应返回ErrProcessorStopped，实际 %v %v", i, documents[i], errs[i])
+		}
+	}
+
+	var callbackErrs []error
+	err := processor.ProcessFilesWithCallbackContext(context.Background(), files, func(_ int, _ *types.Document, err error) {
+		callbackErrs = append(callbackErrs, err)
+	})
+	if !errors.Is(err, ErrProcessorStopped) || len(callbackErrs) != len(files) || !errors.Is(callbackErrs[0], ErrProcessorStopped) {
+		t.Errorf("回调批处理应对每个文档报告ErrProcessorStopped，实际 %v %v", err, callbackErrs)
+	}
+
+	streaming := NewStreamingBatchProcessor(1)
+	streaming.processor.Stop()
+	if _, err := streaming.ProcessFilesStream(files); !errors.Is(err, ErrProcessorStopped) {
+		t.Errorf("已停止的流式处理器应返回ErrProcessorStopped，实际 %v", err)
+	}
+}
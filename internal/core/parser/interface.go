@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"context"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/sniff"
 	"fmt"
 	"os"
 	"time"
@@ -31,6 +33,110 @@ type Parser interface {
 	ValidateFile(filePath string) error
 }
 
+// ContextParser 支持context的解析器
+//
+// ctx被取消或超过截止时间时，方法返回*types.TimeoutError，同时返回中断前已完成的部分结果。
+type ContextParser interface {
+	Parser
+
+	// ParseDocumentContext 解析文档文件
+	ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error)
+
+	// ParseMetadataContext 解析文档元数据
+	ParseMetadataContext(ctx context.Context, filePath string) (*types.DocumentMetadata, error)
+
+	// ParseContentContext 解析文档内容
+	ParseContentContext(ctx context.Context, filePath string) (*types.DocumentContent, error)
+
+	// ParseStylesContext 解析文档样式
+	ParseStylesContext(ctx context.Context, filePath string) (*types.DocumentStyles, error)
+
+	// ParseFormatRulesContext 解析格式规则
+	ParseFormatRulesContext(ctx context.Context, filePath string) (*types.FormatRules, error)
+}
+
+// WithContext 返回parser的ContextParser形式
+//
+// parser未实现ContextParser时，解析在后台协程中进行，ctx结束时立即返回*types.TimeoutError，
+// 后台解析完成后结果被丢弃。
+func WithContext(parser Parser) ContextParser {
+	if contextParser, ok := parser.(ContextParser); ok {
+		return contextParser
+	}
+	return &contextAdapter{Parser: parser}
+}
+
+// contextAdapter 为不支持context的解析器提供ContextParser实现
+type contextAdapter struct {
+	Parser
+}
+
+// ParseDocumentContext 解析文档
+func (ca *contextAdapter) ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error) {
+	return runContext(ctx, "parse document", filePath, func() (*types.Document, error) {
+		return ca.ParseDocument(filePath)
+	})
+}
+
+// ParseMetadataContext 解析元数据
+func (ca *contextAdapter) ParseMetadataContext(ctx context.Context, filePath string) (*types.DocumentMetadata, error) {
+	return runContext(ctx, "parse metadata", filePath, func() (*types.DocumentMetadata, error) {
+		return ca.ParseMetadata(filePath)
+	})
+}
+
+// ParseContentContext 解析内容
+func (ca *contextAdapter) ParseContentContext(ctx context.Context, filePath string) (*types.DocumentContent, error) {
+	return runContext(ctx, "parse content", filePath, func() (*types.DocumentContent, error) {
+		return ca.ParseContent(filePath)
+	})
+}
+
+// ParseStylesContext 解析样式
+func (ca *contextAdapter) ParseStylesContext(ctx context.Context, filePath string) (*types.DocumentStyles, error) {
+	return runContext(ctx, "parse styles", filePath, func() (*types.DocumentStyles, error) {
+		return ca.ParseStyles(filePath)
+	})
+}
+
+// ParseFormatRulesContext 解析格式规则
+func (ca *contextAdapter) ParseFormatRulesContext(ctx context.Context, filePath string) (*types.FormatRules, error) {
+	return runContext(ctx, "parse format rules", filePath, func() (*types.FormatRules, error) {
+		return ca.ParseFormatRules(filePath)
+	})
+}
+
+// runContext 在后台协程中执行parse，ctx先结束时返回TimeoutError
+func runContext[T any](ctx context.Context, op, filePath string, parse func() (T, error)) (T, error) {
+	var zero T
+	if err := types.CheckContext(ctx, op, filePath); err != nil {
+		return zero, err
+	}
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		// 后台协程中的panic无法由调用方恢复，转换为错误返回
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{zero, fmt.Errorf("%s %s: parser panicked: %v", op, filePath, r)}
+			}
+		}()
+		value, err := parse()
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return zero, types.CheckContext(ctx, op, filePath)
+	}
+}
+
 // DefaultParser 默认解析器实现
 type DefaultParser struct{}
 
@@ -92,6 +198,46 @@ func (dp *DefaultParser) ParseFormatRules(filePath string) (*types.FormatRules,
 	return &types.FormatRules{}, nil
 }
 
+// ParseDocumentContext 解析文档
+func (dp *DefaultParser) ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error) {
+	if err := types.CheckContext(ctx, "parse document", filePath); err != nil {
+		return nil, err
+	}
+	return dp.ParseDocument(filePath)
+}
+
+// ParseMetadataContext 解析元数据
+func (dp *DefaultParser) ParseMetadataContext(ctx context.Context, filePath string) (*types.DocumentMetadata, error) {
+	if err := types.CheckContext(ctx, "parse metadata", filePath); err != nil {
+		return nil, err
+	}
+	return dp.ParseMetadata(filePath)
+}
+
+// ParseContentContext 解析内容
+func (dp *DefaultParser) ParseContentContext(ctx context.Context, filePath string) (*types.DocumentContent, error) {
+	if err := types.CheckContext(ctx, "parse content", filePath); err != nil {
+		return nil, err
+	}
+	return dp.ParseContent(filePath)
+}
+
+// ParseStylesContext 解析样式
+func (dp *DefaultParser) ParseStylesContext(ctx context.Context, filePath string) (*types.DocumentStyles, error) {
+	if err := types.CheckContext(ctx, "parse styles", filePath); err != nil {
+		return nil, err
+	}
+	return dp.ParseStyles(filePath)
+}
+
+// ParseFormatRulesContext 解析格式规则
+func (dp *DefaultParser) ParseFormatRulesContext(ctx context.Context, filePath string) (*types.FormatRules, error) {
+	if err := types.CheckContext(ctx, "parse format rules", filePath); err != nil {
+		return nil, err
+	}
+	return dp.ParseFormatRules(filePath)
+}
+
 // GetSupportedFormats 获取支持的格式
 func (dp *DefaultParser) GetSupportedFormats() []string {
 	return []string{"docx", "doc", "rtf", "wpd"}
@@ -212,6 +358,66 @@ func (pf *ParserFactory) ParseFormatRules(filePath string) (*types.FormatRules,
 	return parser.ParseFormatRules(filePath)
 }
 
+// contextParser 按文件格式获取解析器的ContextParser形式
+func (pf *ParserFactory) contextParser(filePath string) (ContextParser, error) {
+	format, err := pf.detectFormat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	parser, err := pf.GetParser(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithContext(parser), nil
+}
+
+// ParseDocumentContext 解析文档
+func (pf *ParserFactory) ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error) {
+	parser, err := pf.contextParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseDocumentContext(ctx, filePath)
+}
+
+// ParseMetadataContext 解析元数据
+func (pf *ParserFactory) ParseMetadataContext(ctx context.Context, filePath string) (*types.DocumentMetadata, error) {
+	parser, err := pf.contextParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseMetadataContext(ctx, filePath)
+}
+
+// ParseContentContext 解析内容
+func (pf *ParserFactory) ParseContentContext(ctx context.Context, filePath string) (*types.DocumentContent, error) {
+	parser, err := pf.contextParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseContentContext(ctx, filePath)
+}
+
+// ParseStylesContext 解析样式
+func (pf *ParserFactory) ParseStylesContext(ctx context.Context, filePath string) (*types.DocumentStyles, error) {
+	parser, err := pf.contextParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseStylesContext(ctx, filePath)
+}
+
+// ParseFormatRulesContext 解析格式规则
+func (pf *ParserFactory) ParseFormatRulesContext(ctx context.Context, filePath string) (*types.FormatRules, error) {
+	parser, err := pf.contextParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseFormatRulesContext(ctx, filePath)
+}
+
 // GetSupportedFormats 获取支持的格式
 func (pf *ParserFactory) GetSupportedFormats() []string {
 	return []string{"docx", "doc", "rtf", "wpd"}
@@ -234,12 +440,23 @@ func (pf *ParserFactory) ValidateFile(filePath string) error {
 
 // detectFormat 检测文件格式
 func (pf *ParserFactory) detectFormat(filePath string) (string, error) {
-	// 根据文件扩展名检测格式
-	// 这里可以扩展为更复杂的格式检测逻辑
+	// 优先按文件内容检测格式，模板、启用宏的文件和Flat OPC都使用docx解析器
+	if result, err := sniff.File(filePath); err == nil {
+		switch {
+		case result.Format.IsOOXML(), result.Format == sniff.FlatOPC:
+			return "docx", nil
+		case result.Format == sniff.DOC, result.Format == sniff.RTF, result.Format == sniff.WPD:
+			return string(result.Format), nil
+		case result.Format != sniff.Unknown:
+			return "", ErrUnsupportedFormat
+		}
+	}
+
+	// 无法识别内容时根据文件扩展名检测格式
 	ext := getFileExtension(filePath)
 
 	switch ext {
-	case ".docx":
+	case ".docx", ".dotx", ".docm", ".dotm":
 		return "docx", nil
 	case ".doc":
 		return "doc", nil
@@ -3,15 +3,19 @@ package parser
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"archive/zip"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
 )
 
 // StreamingParser 流式解析器，用于处理大文件
@@ -43,40 +47,77 @@ func NewStreamingParser(bufferSize, workers int) *StreamingParser {
 
 // ParseStream 流式解析文档
 func (sp *StreamingParser) ParseStream(filePath string) (<-chan StreamingResult, error) {
+	return sp.ParseStreamContext(context.Background(), filePath)
+}
+
+// ParseStreamContext 受ctx控制的流式解析
+//
+// ctx结束时停止解码，发送已解析的部分文档和*types.TimeoutError后关闭通道；
+// 接收方不再读取时，ctx结束也会使后台协程退出。
+func (sp *StreamingParser) ParseStreamContext(ctx context.Context, filePath string) (<-chan StreamingResult, error) {
 	resultChan := make(chan StreamingResult, sp.workers)
 
 	go func() {
 		defer close(resultChan)
 
 		start := time.Now()
+		send := func(result StreamingResult) bool {
+			select {
+			case resultChan <- result:
+				return true
+			case <-ctx.Done():
+				// 超时结果优先送达，通道已满时放弃
+				if _, ok := types.AsTimeoutError(result.Error); ok {
+					select {
+					case resultChan <- result:
+					default:
+					}
+				}
+				return false
+			}
+		}
 
 		// 根据文件类型选择解析策略
 		ext := getFileExtensionForStreaming(filePath)
+		var ok bool
 		switch ext {
 		case ".docx":
-			sp.parseDocxStream(filePath, resultChan)
+			ok = sp.parseDocxStream(ctx, filePath, send)
 		case ".doc":
-			sp.parseDocStream(filePath, resultChan)
+			ok = sp.parseDocStream(ctx, filePath, send)
 		case ".rtf":
-			sp.parseRtfStream(filePath, resultChan)
+			ok = sp.parseRtfStream(ctx, filePath, send)
 		default:
 			// 使用传统解析器作为后备
-			sp.parseLegacyStream(filePath, resultChan)
+			ok = sp.parseLegacyStream(ctx, filePath, send)
+		}
+		if !ok {
+			return
 		}
 
 		duration := time.Since(start)
-		resultChan <- StreamingResult{
+		send(StreamingResult{
 			Document: nil,
 			Error:    nil,
 			Duration: duration,
-		}
+		})
 	}()
 
 	return resultChan, nil
 }
 
+// sendPartial 发送超时前的部分结果，ctx未结束时返回true
+func (sp *StreamingParser) sendPartial(ctx context.Context, filePath string, doc *types.Document, send func(StreamingResult) bool) bool {
+	err := types.CheckContext(ctx, "stream document", filePath)
+	if err == nil {
+		return true
+	}
+	send(StreamingResult{Document: doc, Error: err})
+	return false
+}
+
 // parseDocxStream 流式解析DOCX文件
-func (sp *StreamingParser) parseDocxStream(filePath string, resultChan chan<- StreamingResult) {
+func (sp *StreamingParser) parseDocxStream(ctx context.Context, filePath string, send func(StreamingResult) bool) bool {
 	// 从池中获取文档对象
 	doc := sp.pool.Get().(*types.Document)
 	defer sp.pool.Put(doc)
@@ -87,31 +128,33 @@ func (sp *StreamingParser) parseDocxStream(filePath string, resultChan chan<- St
 	// 打开ZIP文件
 	reader, err := zip.OpenReader(filePath)
 	if err != nil {
-		resultChan <- StreamingResult{Error: fmt.Errorf("failed to open DOCX: %w", err)}
-		return
+		return send(StreamingResult{Error: fmt.Errorf("failed to open DOCX: %w", err)})
 	}
 	defer reader.Close()
 
 	// 流式解析XML文件
 	for _, file := range reader.File {
 		if file.Name == "word/document.xml" {
-			sp.parseDocumentXML(file, doc)
+			sp.parseDocumentXML(ctx, file, doc)
 		} else if file.Name == "docProps/core.xml" {
 			sp.parseCoreXML(file, doc)
 		} else if file.Name == "word/styles.xml" {
 			sp.parseStylesXML(file, doc)
 		}
+		if !sp.sendPartial(ctx, filePath, doc, send) {
+			return false
+		}
 	}
 
-	resultChan <- StreamingResult{
+	return send(StreamingResult{
 		Document: doc,
 		Error:    nil,
 		Duration: time.Since(time.Now()),
-	}
+	})
 }
 
-// parseDocumentXML 流式解析文档XML
-func (sp *StreamingParser) parseDocumentXML(file *zip.File, doc *types.Document) {
+// parseDocumentXML 流式解析文档XML，逐个段落和表格行解码，ctx结束时保留已解析的部分
+func (sp *StreamingParser) parseDocumentXML(ctx context.Context, file *zip.File, doc *types.Document) {
 	rc, err := file.Open()
 	if err != nil {
 		return
@@ -120,83 +163,39 @@ func (sp *StreamingParser) parseDocumentXML(file *zip.File, doc *types.Document)
 
 	// 使用缓冲读取器
 	reader := bufio.NewReaderSize(rc, sp.bufferSize)
-	decoder := xml.NewDecoder(reader)
-
-	// 流式解析XML
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			continue
-		}
 
-		// 处理XML标签
-		switch t := token.(type) {
-		case xml.StartElement:
-			sp.handleXMLElement(t, doc)
+	documents.WalkDocumentContext(ctx, reader, nil, func(event *documents.StreamEvent) error {
+		switch event.Type {
+		case documents.EventParagraph:
+			doc.Content.Paragraphs = append(doc.Content.Paragraphs, *event.Paragraph)
+		case documents.EventTableStart:
+			doc.Content.Tables = append(doc.Content.Tables, *event.Table)
+		case documents.EventTableRow:
+			table := &doc.Content.Tables[len(doc.Content.Tables)-1]
+			table.Rows = append(table.Rows, *event.Row)
+		case documents.EventSection:
+			doc.Content.Sections = append(doc.Content.Sections, *event.Section)
 		}
-	}
-}
-
-// handleXMLElement 处理XML元素
-func (sp *StreamingParser) handleXMLElement(element xml.StartElement, doc *types.Document) {
-	switch element.Name.Local {
-	case "document":
-		// 开始解析文档
-	case "body":
-		// 开始解析正文
-	case "p":
-		// 解析段落
-		sp.parseParagraph(element, doc)
-	case "tbl":
-		// 解析表格
-		sp.parseTable(element, doc)
-	}
-}
-
-// parseParagraph 流式解析段落
-func (sp *StreamingParser) parseParagraph(element xml.StartElement, doc *types.Document) {
-	paragraph := types.Paragraph{
-		ID:    generateID(),
-		Style: types.ParagraphStyle{Name: "Normal"},
-	}
-
-	// 解析段落属性
-	for _, attr := range element.Attr {
-		switch attr.Name.Local {
-		case "style":
-			paragraph.Style.Name = attr.Value
-		}
-	}
-
-	// 解析段落内容
-	// 这里简化实现，实际需要递归解析子元素
-	paragraph.Runs = append(paragraph.Runs, types.TextRun{
-		Text: "段落内容",
-		Font: types.Font{Name: "Calibri", Size: 11.0},
+		return nil
 	})
-
-	doc.Content.Paragraphs = append(doc.Content.Paragraphs, paragraph)
 }
 
-// parseTable 流式解析表格
-func (sp *StreamingParser) parseTable(element xml.StartElement, doc *types.Document) {
-	table := types.Table{
-		ID:   generateID(),
-		Rows: []types.TableRow{},
+// Walk 以事件方式流式遍历DOCX正文，不构建完整文档，适合校验或导出超大文档
+//
+// fn返回documents.ErrStopStream时提前结束遍历。
+func (sp *StreamingParser) Walk(filePath string, fn func(*documents.StreamEvent) error) error {
+	if ext := getFileExtensionForStreaming(filePath); ext != ".docx" {
+		return fmt.Errorf("事件流只支持DOCX文件: %s", filePath)
 	}
+	return documents.StreamDocument(filePath, fn)
+}
 
-	// 解析表格属性
-	for _, attr := range element.Attr {
-		switch attr.Name.Local {
-		case "style":
-			table.Style.Name = attr.Value
-		}
+// WalkContext 受ctx控制的Walk，ctx结束时返回*types.TimeoutError
+func (sp *StreamingParser) WalkContext(ctx context.Context, filePath string, fn func(*documents.StreamEvent) error) error {
+	if ext := getFileExtensionForStreaming(filePath); ext != ".docx" {
+		return fmt.Errorf("事件流只支持DOCX文件: %s", filePath)
 	}
-
-	doc.Content.Tables = append(doc.Content.Tables, table)
+	return documents.StreamDocumentContext(ctx, filePath, fn)
 }
 
 // parseCoreXML 流式解析核心XML
@@ -280,7 +279,7 @@ func (sp *StreamingParser) handleStyleElement(element xml.StartElement, doc *typ
 }
 
 // parseDocStream 流式解析DOC文件
-func (sp *StreamingParser) parseDocStream(filePath string, resultChan chan<- StreamingResult) {
+func (sp *StreamingParser) parseDocStream(ctx context.Context, filePath string, send func(StreamingResult) bool) bool {
 	doc := sp.pool.Get().(*types.Document)
 	defer sp.pool.Put(doc)
 
@@ -289,8 +288,7 @@ func (sp *StreamingParser) parseDocStream(filePath string, resultChan chan<- Str
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		resultChan <- StreamingResult{Error: fmt.Errorf("failed to open DOC: %w", err)}
-		return
+		return send(StreamingResult{Error: fmt.Errorf("failed to open DOC: %w", err)})
 	}
 	defer file.Close()
 
@@ -301,21 +299,23 @@ func (sp *StreamingParser) parseDocStream(filePath string, resultChan chan<- Str
 	header := make([]byte, 64)
 	_, err = reader.Read(header)
 	if err != nil {
-		resultChan <- StreamingResult{Error: fmt.Errorf("failed to read header: %w", err)}
-		return
+		return send(StreamingResult{Error: fmt.Errorf("failed to read header: %w", err)})
 	}
 
 	// 解析元数据
 	sp.parseDocMetadata(reader, doc)
 
 	// 解析内容
-	sp.parseDocContent(reader, doc)
+	sp.parseDocContent(ctx, reader, doc)
+	if !sp.sendPartial(ctx, filePath, doc, send) {
+		return false
+	}
 
-	resultChan <- StreamingResult{
+	return send(StreamingResult{
 		Document: doc,
 		Error:    nil,
 		Duration: time.Since(time.Now()),
-	}
+	})
 }
 
 // parseDocMetadata 流式解析DOC元数据
@@ -345,15 +345,15 @@ func (sp *StreamingParser) parseDocMetadata(reader *bufio.Reader, doc *types.Doc
 	doc.Metadata.Version = "8.0"
 }
 
-// parseDocContent 流式解析DOC内容
-func (sp *StreamingParser) parseDocContent(reader *bufio.Reader, doc *types.Document) {
+// parseDocContent 流式解析DOC内容，ctx结束时停止读取
+func (sp *StreamingParser) parseDocContent(ctx context.Context, reader *bufio.Reader, doc *types.Document) {
 	// 跳过元数据区域
 	reader.Discard(512)
 
 	// 分块读取内容
 	buffer := make([]byte, sp.bufferSize)
 
-	for {
+	for ctx.Err() == nil {
 		n, err := reader.Read(buffer)
 		if err == io.EOF {
 			break
@@ -391,7 +391,7 @@ func (sp *StreamingParser) processContentChunk(chunk []byte, doc *types.Document
 }
 
 // parseRtfStream 流式解析RTF文件
-func (sp *StreamingParser) parseRtfStream(filePath string, resultChan chan<- StreamingResult) {
+func (sp *StreamingParser) parseRtfStream(ctx context.Context, filePath string, send func(StreamingResult) bool) bool {
 	doc := sp.pool.Get().(*types.Document)
 	defer sp.pool.Put(doc)
 
@@ -400,25 +400,27 @@ func (sp *StreamingParser) parseRtfStream(filePath string, resultChan chan<- Str
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		resultChan <- StreamingResult{Error: fmt.Errorf("failed to open RTF: %w", err)}
-		return
+		return send(StreamingResult{Error: fmt.Errorf("failed to open RTF: %w", err)})
 	}
 	defer file.Close()
 
 	reader := bufio.NewReaderSize(file, sp.bufferSize)
 
 	// 流式解析RTF
-	sp.parseRtfContent(reader, doc)
+	sp.parseRtfContent(ctx, reader, doc)
+	if !sp.sendPartial(ctx, filePath, doc, send) {
+		return false
+	}
 
-	resultChan <- StreamingResult{
+	return send(StreamingResult{
 		Document: doc,
 		Error:    nil,
 		Duration: time.Since(time.Now()),
-	}
+	})
 }
 
-// parseRtfContent 流式解析RTF内容
-func (sp *StreamingParser) parseRtfContent(reader *bufio.Reader, doc *types.Document) {
+// parseRtfContent 流式解析RTF内容，ctx结束时停止读取
+func (sp *StreamingParser) parseRtfContent(ctx context.Context, reader *bufio.Reader, doc *types.Document) {
 	// 设置默认元数据
 	doc.Metadata.Title = "RTF Document"
 	doc.Metadata.Author = "Unknown"
@@ -429,7 +431,7 @@ func (sp *StreamingParser) parseRtfContent(reader *bufio.Reader, doc *types.Docu
 	// 分块读取RTF内容
 	buffer := make([]byte, sp.bufferSize)
 
-	for {
+	for ctx.Err() == nil {
 		n, err := reader.Read(buffer)
 		if err == io.EOF {
 			break
@@ -474,7 +476,7 @@ func (sp *StreamingParser) cleanRtfText(text string) string {
 }
 
 // parseLegacyStream 流式解析历史格式
-func (sp *StreamingParser) parseLegacyStream(filePath string, resultChan chan<- StreamingResult) {
+func (sp *StreamingParser) parseLegacyStream(ctx context.Context, filePath string, send func(StreamingResult) bool) bool {
 	doc := sp.pool.Get().(*types.Document)
 	defer sp.pool.Put(doc)
 
@@ -484,29 +486,25 @@ func (sp *StreamingParser) parseLegacyStream(filePath string, resultChan chan<-
 	// 使用传统解析器作为后备
 	legacyParser := NewParserFactory()
 	legacyParser.RegisterParser("docx", &DefaultParser{})
-	legacyDoc, err := legacyParser.ParseDocument(filePath)
+	legacyDoc, err := legacyParser.ParseDocumentContext(ctx, filePath)
 	if err != nil {
-		resultChan <- StreamingResult{Error: err}
-		return
+		_, timedOut := types.AsTimeoutError(err)
+		return send(StreamingResult{Document: legacyDoc, Error: err}) && !timedOut
 	}
 
 	*doc = *legacyDoc
 
-	resultChan <- StreamingResult{
+	return send(StreamingResult{
 		Document: doc,
 		Error:    nil,
 		Duration: time.Since(time.Now()),
-	}
+	})
 }
 
 // 辅助函数
 
 func getFileExtensionForStreaming(filePath string) string {
-	// 简化实现，实际需要更复杂的扩展名检测
-	if len(filePath) > 4 {
-		return filePath[len(filePath)-4:]
-	}
-	return ""
+	return strings.ToLower(filepath.Ext(filePath))
 }
 
 func generateID() string {
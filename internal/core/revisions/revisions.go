@@ -0,0 +1,374 @@
+package revisions
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"docs-parser/internal/core/types"
+)
+
+// Policy 修订处理策略，决定对比时如何看待未处理的修订
+type Policy string
+
+const (
+	// PolicyAccepted 按接受全部修订后的文档进行评估（默认）
+	PolicyAccepted Policy = "accepted"
+	// PolicyOriginal 按修订前的原始文档进行评估
+	PolicyOriginal Policy = "original"
+)
+
+// ParsePolicy 解析策略名称，空字符串视为默认策略
+func ParsePolicy(value string) (Policy, error) {
+	switch Policy(value) {
+	case "", PolicyAccepted:
+		return PolicyAccepted, nil
+	case PolicyOriginal:
+		return PolicyOriginal, nil
+	default:
+		return "", fmt.Errorf("unknown revision policy: %s (expected %s or %s)", value, PolicyAccepted, PolicyOriginal)
+	}
+}
+
+// Apply 返回按策略处理修订后的文档副本，结果中不再包含修订信息
+//
+// 段落标记的修订按ParagraphStream合并段落；格式规则中的段落规则和字体规则按处理后的内容重新生成。
+func Apply(doc *types.Document, policy Policy) *types.Document {
+	if doc == nil {
+		return nil
+	}
+
+	result := *doc
+	paragraphs, sources := applyParagraphs(doc.Content.Paragraphs, policy)
+	result.Content.Paragraphs = paragraphs
+
+	result.Content.Tables = make([]types.Table, len(doc.Content.Tables))
+	for i, table := range doc.Content.Tables {
+		rows := make([]types.TableRow, len(table.Rows))
+		for j, row := range table.Rows {
+			cells := make([]types.TableCell, len(row.Cells))
+			for k, cell := range row.Cells {
+				cell.Content, _ = applyParagraphs(cell.Content, policy)
+				cells[k] = cell
+			}
+			row.Cells = cells
+			rows[j] = row
+		}
+		table.Rows = rows
+		result.Content.Tables[i] = table
+	}
+
+	result.FormatRules.ParagraphRules = paragraphRules(doc.FormatRules.ParagraphRules, paragraphs, sources)
+	result.FormatRules.FontRules = fontRules(doc.FormatRules.FontRules, runFonts(&doc.Content), runFonts(&result.Content))
+
+	return &result
+}
+
+// paragraphRules 按处理后的段落重新生成段落规则，保留原规则中段落格式以外的属性
+func paragraphRules(original []types.ParagraphRule, paragraphs []types.Paragraph, sources []int) []types.ParagraphRule {
+	if original == nil {
+		return nil
+	}
+
+	byID := make(map[string]types.ParagraphRule, len(original))
+	for _, rule := range original {
+		byID[rule.ID] = rule
+	}
+
+	rules := make([]types.ParagraphRule, len(paragraphs))
+	for i, para := range paragraphs {
+		rule := byID[fmt.Sprintf("paragraph_%d", sources[i]+1)]
+		rule.ID = fmt.Sprintf("paragraph_%d", i+1)
+		rule.Name = para.Style.Name
+		rule.Alignment = para.Alignment
+		rule.Indentation = para.Indentation
+		rule.Spacing = para.Spacing
+		rules[i] = rule
+	}
+	return rules
+}
+
+// runFonts 正文和表格中文本运行使用的字体
+func runFonts(content *types.DocumentContent) map[string]bool {
+	fonts := make(map[string]bool)
+	add := func(paragraphs []types.Paragraph) {
+		for _, para := range paragraphs {
+			for _, run := range para.Runs {
+				if run.Font.Name != "" {
+					fonts[run.Font.Name] = true
+				}
+			}
+		}
+	}
+
+	add(content.Paragraphs)
+	for _, table := range content.Tables {
+		for _, row := range table.Rows {
+			for _, cell := range row.Cells {
+				add(cell.Content)
+			}
+		}
+	}
+	return fonts
+}
+
+// fontRules 去掉只被已移除文本使用的字体，补充恢复的原始格式中新出现的字体（默认12磅黑色，与字体表一致）
+func fontRules(original []types.FontRule, before, after map[string]bool) []types.FontRule {
+	var rules []types.FontRule
+	seen := make(map[string]bool)
+	for _, rule := range original {
+		if before[rule.Name] && !after[rule.Name] {
+			continue
+		}
+		seen[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	var added []string
+	for name := range after {
+		if !seen[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		rules = append(rules, types.FontRule{
+			ID:    name,
+			Name:  name,
+			Size:  12.0,
+			Color: types.Color{RGB: "000000"},
+		})
+	}
+	return rules
+}
+
+// applyParagraphs 按策略处理段落中的修订，返回处理后的段落和各段落在原列表中的序号
+func applyParagraphs(paragraphs []types.Paragraph, policy Policy) ([]types.Paragraph, []int) {
+	if paragraphs == nil {
+		return nil, nil
+	}
+
+	result := make([]types.Paragraph, 0, len(paragraphs))
+	sources := make([]int, 0, len(paragraphs))
+	stream := NewParagraphStream(policy)
+	for i, para := range paragraphs {
+		if merged, ok := stream.Add(para); ok {
+			result = append(result, merged)
+			sources = append(sources, i)
+		}
+	}
+	if last, ok := stream.Flush(); ok {
+		result = append(result, last)
+		sources = append(sources, len(paragraphs)-1)
+	}
+
+	return result, sources
+}
+
+// ParagraphStream 逐段按策略处理修订，用于不保留整个正文的流式遍历
+//
+// 段落标记被移除（接受视图中已删除、原始视图中为插入）的段落与下一段合并，合并后的段落使用下一段的段落属性，
+// 与接受或拒绝修订后Word的结果一致；这样的段落在下一段到来之前暂存，最后一个段落没有可并入的段落，保持不变。
+type ParagraphStream struct {
+	policy  Policy
+	pending *types.Paragraph // 段落标记被移除、等待并入下一段的段落
+}
+
+// NewParagraphStream 创建按策略处理修订的段落流
+func NewParagraphStream(policy Policy) *ParagraphStream {
+	return &ParagraphStream{policy: policy}
+}
+
+// Add 处理下一个段落，返回处理完成的段落；段落需要并入下一段时返回false
+func (ps *ParagraphStream) Add(para types.Paragraph) (types.Paragraph, bool) {
+	markRemoved := para.MarkRevision.IsRemovedWhenAccepted()
+	if ps.policy == PolicyOriginal {
+		markRemoved = para.MarkRevision.IsRemovedWhenRejected()
+	}
+	para = applyParagraph(para, ps.policy)
+
+	if ps.pending != nil {
+		para.Runs = append(ps.pending.Runs, para.Runs...)
+		para.Text = ps.pending.Text + para.Text
+		ps.pending = nil
+	}
+	if markRemoved {
+		ps.pending = &para
+		return types.Paragraph{}, false
+	}
+	return para, true
+}
+
+// Flush 返回暂存的最后一个段落
+func (ps *ParagraphStream) Flush() (types.Paragraph, bool) {
+	if ps.pending == nil {
+		return types.Paragraph{}, false
+	}
+	para := *ps.pending
+	ps.pending = nil
+	return para, true
+}
+
+// applyParagraph 按策略处理一个段落中的修订
+func applyParagraph(para types.Paragraph, policy Policy) types.Paragraph {
+	if policy == PolicyOriginal && para.PropertyChange != nil {
+		original := para.PropertyChange.Original
+		para.Style.Name = original.StyleName
+		para.Alignment = original.Alignment
+		para.Indentation = original.Indentation
+		para.Spacing = original.Spacing
+	}
+	para.PropertyChange = nil
+	para.MarkRevision = nil
+
+	var runs []types.TextRun
+	text := ""
+	for _, run := range para.Runs {
+		if policy == PolicyOriginal {
+			if run.Revision.IsRemovedWhenRejected() {
+				continue
+			}
+			if run.PropertyChange != nil {
+				restoreRunProperties(&run, run.PropertyChange.Original)
+			}
+		} else if run.Revision.IsRemovedWhenAccepted() {
+			continue
+		}
+		run.Revision = nil
+		run.PropertyChange = nil
+		runs = append(runs, run)
+		text += run.Text
+	}
+	para.Runs = runs
+	para.Text = text
+	return para
+}
+
+// restoreRunProperties 恢复文本运行修订前的格式
+func restoreRunProperties(run *types.TextRun, original types.RunProperties) {
+	run.Bold = original.Bold
+	run.Italic = original.Italic
+	run.Underline = original.Underline
+	run.Font.Name = original.Font.Name
+	run.Font.Size = original.Size
+	run.Font.Color = original.Color
+	run.Size = original.Size
+	run.Color = original.Color
+}
+
+// Entry 一条未处理的修订
+type Entry struct {
+	Type     types.RevisionType     `json:"type"`
+	ID       string                 `json:"id"`
+	Author   string                 `json:"author"`
+	Date     time.Time              `json:"date"`
+	Location string                 `json:"location"`
+	Text     string                 `json:"text"`
+	Original map[string]interface{} `json:"original,omitempty"`
+}
+
+// Report 修订报告
+type Report struct {
+	Total    int            `json:"total"`
+	ByType   map[string]int `json:"by_type"`
+	ByAuthor map[string]int `json:"by_author"`
+	Entries  []Entry        `json:"entries"`
+}
+
+// BuildReport 列出文档中所有未处理的修订
+func BuildReport(doc *types.Document) *Report {
+	report := &Report{
+		ByType:   make(map[string]int),
+		ByAuthor: make(map[string]int),
+		Entries:  []Entry{},
+	}
+	if doc == nil {
+		return report
+	}
+
+	for i, para := range doc.Content.Paragraphs {
+		collectParagraph(report, para, fmt.Sprintf("第%d段", i+1))
+	}
+	for i, table := range doc.Content.Tables {
+		for j, row := range table.Rows {
+			for k, cell := range row.Cells {
+				for l, para := range cell.Content {
+					collectParagraph(report, para, fmt.Sprintf("表格%d第%d行第%d列第%d段", i+1, j+1, k+1, l+1))
+				}
+			}
+		}
+	}
+
+	report.Total = len(report.Entries)
+	return report
+}
+
+// collectParagraph 收集段落及其文本运行中的修订
+func collectParagraph(report *Report, para types.Paragraph, location string) {
+	if para.MarkRevision != nil {
+		report.add(Entry{
+			Type:     para.MarkRevision.Type,
+			ID:       para.MarkRevision.ID,
+			Author:   para.MarkRevision.Author,
+			Date:     para.MarkRevision.Date,
+			Location: location,
+			Text:     para.Text,
+		})
+	}
+
+	if change := para.PropertyChange; change != nil {
+		report.add(Entry{
+			Type:     change.Revision.Type,
+			ID:       change.Revision.ID,
+			Author:   change.Revision.Author,
+			Date:     change.Revision.Date,
+			Location: location,
+			Text:     para.Text,
+			Original: map[string]interface{}{
+				"style":       change.Original.StyleName,
+				"alignment":   change.Original.Alignment,
+				"indentation": change.Original.Indentation,
+				"spacing":     change.Original.Spacing,
+			},
+		})
+	}
+
+	for j, run := range para.Runs {
+		runLocation := fmt.Sprintf("%s第%d个文本", location, j+1)
+		if run.Revision != nil {
+			report.add(Entry{
+				Type:     run.Revision.Type,
+				ID:       run.Revision.ID,
+				Author:   run.Revision.Author,
+				Date:     run.Revision.Date,
+				Location: runLocation,
+				Text:     run.Text,
+			})
+		}
+		if change := run.PropertyChange; change != nil {
+			report.add(Entry{
+				Type:     change.Revision.Type,
+				ID:       change.Revision.ID,
+				Author:   change.Revision.Author,
+				Date:     change.Revision.Date,
+				Location: runLocation,
+				Text:     run.Text,
+				Original: map[string]interface{}{
+					"fontName":  change.Original.Font.Name,
+					"fontSize":  change.Original.Size,
+					"fontColor": change.Original.Color.RGB,
+					"bold":      change.Original.Bold,
+					"italic":    change.Original.Italic,
+					"underline": change.Original.Underline,
+				},
+			})
+		}
+	}
+}
+
+// add 添加修订条目并更新统计
+func (r *Report) add(entry Entry) {
+	r.Entries = append(r.Entries, entry)
+	r.ByType[string(entry.Type)]++
+	r.ByAuthor[entry.Author]++
+}
@@ -0,0 +1,258 @@
+package revisions
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
+	"docs-parser/internal/testutil"
+)
+
+// writeRevisionDocx 创建包含修订的测试DOCX文件
+func writeRevisionDocx(t *testing.T, body string) string {
+	t.Helper()
+	return testutil.WriteDocx(t, filepath.Join(t.TempDir(), "revisions.docx"), body)
+}
+
+// parseRevisionDocx 解析测试文档
+func parseRevisionDocx(t *testing.T, body string) *types.Document {
+	t.Helper()
+
+	wd := documents.NewWordprocessingDocument(writeRevisionDocx(t, body))
+	if err := wd.Open(); err != nil {
+		t.Fatalf("打开文档失败: %v", err)
+	}
+	defer wd.Close()
+
+	doc, err := wd.Parse()
+	if err != nil {
+		t.Fatalf("解析文档失败: %v", err)
+	}
+	return doc
+}
+
+const revisionBody = `<w:p>
+<w:pPr><w:jc w:val="center"/><w:pPrChange w:id="5" w:author="李四" w:date="2024-03-01T10:00:00Z"><w:pPr><w:jc w:val="left"/></w:pPr></w:pPrChange></w:pPr>
+<w:r><w:t>保留</w:t></w:r>
+<w:ins w:id="1" w:author="张三" w:date="2024-03-01T09:00:00Z"><w:r><w:t>新增</w:t></w:r></w:ins>
+<w:del w:id="2" w:author="李四" w:date="2024-03-02T09:00:00Z"><w:r><w:delText>删除</w:delText></w:r></w:del>
+<w:hyperlink><w:moveTo w:id="3" w:author="张三"><w:r><w:t>移入</w:t></w:r></w:moveTo></w:hyperlink>
+<w:r><w:rPr><w:b/><w:sz w:val="28"/><w:rPrChange w:id="4" w:author="张三"><w:rPr><w:sz w:val="24"/></w:rPr></w:rPrChange></w:rPr><w:t>加粗</w:t></w:r>
+</w:p>`
+
+// TestParseRevisions 测试修订在段落和文本运行上的解析
+func TestParseRevisions(t *testing.T) {
+	doc := parseRevisionDocx(t, revisionBody)
+
+	if len(doc.Content.Paragraphs) != 1 {
+		t.Fatalf("期望1个段落，实际 %d", len(doc.Content.Paragraphs))
+	}
+	para := doc.Content.Paragraphs[0]
+
+	if para.Text != "保留新增移入加粗" {
+		t.Errorf("段落文本应为接受修订后的文本，实际 %q", para.Text)
+	}
+	if len(para.Runs) != 5 {
+		t.Fatalf("期望保留全部5个文本运行，实际 %d", len(para.Runs))
+	}
+	if r := para.Runs[1].Revision; r == nil || r.Type != types.RevisionInsert || r.Author != "张三" || r.Date.IsZero() {
+		t.Errorf("插入修订解析不正确: %+v", r)
+	}
+	if r := para.Runs[2].Revision; r == nil || r.Type != types.RevisionDelete || para.Runs[2].Text != "删除" {
+		t.Errorf("删除修订解析不正确: %+v, 文本 %q", r, para.Runs[2].Text)
+	}
+	if r := para.Runs[3].Revision; r == nil || r.Type != types.RevisionMoveTo {
+		t.Errorf("移入修订解析不正确: %+v", r)
+	}
+	if c := para.Runs[4].PropertyChange; c == nil || c.Original.Size != 12 || c.Original.Bold {
+		t.Errorf("字符格式修订解析不正确: %+v", c)
+	}
+	if c := para.PropertyChange; c == nil || c.Revision.Author != "李四" || c.Original.Alignment != types.AlignLeft {
+		t.Errorf("段落格式修订解析不正确: %+v", c)
+	}
+}
+
+// TestApplyPolicy 测试按策略处理修订
+func TestApplyPolicy(t *testing.T) {
+	doc := parseRevisionDocx(t, revisionBody)
+
+	accepted := Apply(doc, PolicyAccepted).Content.Paragraphs[0]
+	if accepted.Text != "保留新增移入加粗" || len(accepted.Runs) != 4 || accepted.Alignment != types.AlignCenter {
+		t.Errorf("接受修订视图不正确: %q, %d 个文本运行, 对齐 %s", accepted.Text, len(accepted.Runs), accepted.Alignment)
+	}
+
+	original := Apply(doc, PolicyOriginal)
+	para := original.Content.Paragraphs[0]
+	if para.Text != "保留删除加粗" || len(para.Runs) != 3 {
+		t.Errorf("原始视图文本不正确: %q, %d 个文本运行", para.Text, len(para.Runs))
+	}
+	if para.Alignment != types.AlignLeft || original.FormatRules.ParagraphRules[0].Alignment != types.AlignLeft {
+		t.Errorf("原始视图应恢复段落格式，实际 %s", para.Alignment)
+	}
+	if last := para.Runs[2]; last.Bold || last.Size != 12 || last.PropertyChange != nil {
+		t.Errorf("原始视图应恢复字符格式: %+v", last)
+	}
+
+	// 原文档不应被修改
+	if doc.Content.Paragraphs[0].Runs[4].PropertyChange == nil {
+		t.Error("Apply不应修改原文档")
+	}
+}
+
+// TestApplyParagraphMark 测试段落标记修订的合并和格式规则的重新生成
+func TestApplyParagraphMark(t *testing.T) {
+	doc := parseRevisionDocx(t, `<w:p><w:pPr><w:jc w:val="left"/><w:rPr><w:del w:id="7" w:author="张三"/></w:rPr></w:pPr>
+<w:r><w:rPr><w:rFonts w:val="宋体"/></w:rPr><w:t>前半</w:t></w:r>
+<w:del w:id="8" w:author="张三"><w:r><w:rPr><w:rFonts w:val="Arial"/></w:rPr><w:delText>删除</w:delText></w:r></w:del></w:p>
+<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:rPr><w:rFonts w:val="宋体"/></w:rPr><w:t>后半</w:t></w:r></w:p>`)
+
+	hasFont := func(doc *types.Document, name string) bool {
+		for _, rule := range doc.FormatRules.FontRules {
+			if rule.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasFont(doc, "Arial") {
+		t.Fatalf("解析结果应包含删除文本的字体: %+v", doc.FormatRules.FontRules)
+	}
+
+	accepted := Apply(doc, PolicyAccepted)
+	if paras := accepted.Content.Paragraphs; len(paras) != 1 || paras[0].Text != "前半后半" || paras[0].Alignment != types.AlignCenter {
+		t.Fatalf("接受修订后删除段落标记的段落应并入下一段: %+v", paras)
+	}
+	if rules := accepted.FormatRules.ParagraphRules; len(rules) != 1 || rules[0].ID != "paragraph_1" || rules[0].Alignment != types.AlignCenter {
+		t.Errorf("段落规则应按合并后的段落生成: %+v", rules)
+	}
+	if hasFont(accepted, "Arial") || !hasFont(accepted, "宋体") {
+		t.Errorf("字体规则不应包含只被删除文本使用的字体: %+v", accepted.FormatRules.FontRules)
+	}
+
+	original := Apply(doc, PolicyOriginal)
+	if paras := original.Content.Paragraphs; len(paras) != 2 || paras[0].Text != "前半删除" || len(original.FormatRules.ParagraphRules) != 2 {
+		t.Errorf("原始视图应保留段落标记: %+v", paras)
+	}
+	if !hasFont(original, "Arial") {
+		t.Errorf("原始视图的字体规则应包含删除文本的字体: %+v", original.FormatRules.FontRules)
+	}
+}
+
+// TestBuildReport 测试修订报告
+func TestBuildReport(t *testing.T) {
+	report := BuildReport(parseRevisionDocx(t, revisionBody))
+
+	if report.Total != 5 {
+		t.Fatalf("期望5处修订，实际 %d", report.Total)
+	}
+	if report.ByAuthor["张三"] != 3 || report.ByAuthor["李四"] != 2 {
+		t.Errorf("按作者统计不正确: %v", report.ByAuthor)
+	}
+	if report.Entries[0].Type != types.RevisionParagraphProps || report.Entries[0].Location != "第1段" {
+		t.Errorf("第一条应为段落格式修订: %+v", report.Entries[0])
+	}
+	if report.Entries[2].Location != "第1段第3个文本" || report.Entries[2].Text != "删除" {
+		t.Errorf("删除修订位置不正确: %+v", report.Entries[2])
+	}
+}
+
+// TestParsePolicy 测试策略名称解析
+func TestParsePolicy(t *testing.T) {
+	if policy, err := ParsePolicy(""); err != nil || policy != PolicyAccepted {
+		t.Errorf("空策略应为默认策略: %s, %v", policy, err)
+	}
+	if _, err := ParsePolicy("unknown"); err == nil {
+		t.Error("未知策略应返回错误")
+	}
+}
+
+const rewriteBody = `<w:p>
+<w:pPr><w:rPr><w:del w:id="10" w:author="李四"/></w:rPr></w:pPr>
+<w:bookmarkStart w:id="0" w:name="开头"/><w:r><w:t>第一段</w:t></w:r><w:bookmarkEnd w:id="0"/>
+<w:del w:id="11" w:author="李四"><w:commentRangeStart w:id="1"/><w:r><w:delText xml:space="preserve">旧文本</w:delText></w:r></w:del>
+</w:p>
+<w:p>
+<w:pPr><w:jc w:val="center"/></w:pPr>
+<w:ins w:id="12" w:author="张三"><w:r><w:t>新文本</w:t></w:r></w:ins><w:commentRangeEnd w:id="1"/><w:r><w:commentReference w:id="1"/></w:r>
+<w:moveFromRangeStart w:id="13" w:author="张三" w:name="move1"/><w:moveFrom w:id="14" w:author="张三"><w:r><w:t>移动</w:t></w:r></w:moveFrom><w:moveFromRangeEnd w:id="13"/>
+<w:r><w:rPr><w:b/><w:rPrChange w:id="15" w:author="张三"><w:rPr><w:i/></w:rPr></w:rPrChange></w:rPr><w:t>格式</w:t></w:r>
+</w:p>`
+
+// processRevisionDocx 处理修订并解析输出文档
+func processRevisionDocx(t *testing.T, action Action, options Options) (*Result, *types.Document, string) {
+	t.Helper()
+
+	input := writeRevisionDocx(t, rewriteBody)
+	output := filepath.Join(t.TempDir(), "out.docx")
+	result, err := Process(input, output, action, options)
+	if err != nil {
+		t.Fatalf("处理修订失败: %v", err)
+	}
+
+	wd := documents.NewWordprocessingDocument(output)
+	if err := wd.Open(); err != nil {
+		t.Fatalf("打开输出文档失败: %v", err)
+	}
+	defer wd.Close()
+	doc, err := wd.Parse()
+	if err != nil {
+		t.Fatalf("解析输出文档失败: %v", err)
+	}
+	content, _ := wd.Container.ReadFile("word/document.xml")
+	return result, doc, string(content)
+}
+
+// TestProcessAccept 测试接受全部修订
+func TestProcessAccept(t *testing.T) {
+	result, doc, content := processRevisionDocx(t, ActionAccept, Options{})
+
+	if result.Total != 5 {
+		t.Errorf("期望处理5处修订，实际 %d: %v", result.Total, result.ByType)
+	}
+	if len(doc.Content.Paragraphs) != 1 {
+		t.Fatalf("删除段落标记后应合并为1段，实际 %d", len(doc.Content.Paragraphs))
+	}
+	para := doc.Content.Paragraphs[0]
+	if para.Text != "第一段新文本格式" || para.Alignment != types.AlignCenter {
+		t.Errorf("接受后的段落不正确: %q, 对齐 %s", para.Text, para.Alignment)
+	}
+	if BuildReport(doc).Total != 0 {
+		t.Error("接受后不应再有修订")
+	}
+	for _, marker := range []string{`<w:commentRangeStart w:id="1"/>`, `<w:commentRangeEnd w:id="1"/>`, `<w:commentReference w:id="1"/>`, `<w:bookmarkStart w:id="0" w:name="开头"/>`, `<w:bookmarkEnd w:id="0"/>`} {
+		if !strings.Contains(content, marker) {
+			t.Errorf("应保留 %s", marker)
+		}
+	}
+	if strings.Contains(content, "moveFromRange") {
+		t.Error("移动范围标记应被移除")
+	}
+}
+
+// TestProcessRejectByAuthor 测试按作者拒绝修订
+func TestProcessRejectByAuthor(t *testing.T) {
+	result, doc, content := processRevisionDocx(t, ActionReject, Options{Author: "张三"})
+
+	if result.Total != 3 {
+		t.Errorf("期望处理张三的3处修订，实际 %d: %v", result.Total, result.ByType)
+	}
+	if len(doc.Content.Paragraphs) != 2 {
+		t.Fatalf("李四的段落标记删除未处理，应保留2段，实际 %d", len(doc.Content.Paragraphs))
+	}
+	if text := doc.Content.Paragraphs[1].Text; text != "移动格式" {
+		t.Errorf("拒绝后第二段文本不正确: %q", text)
+	}
+	if !strings.Contains(content, `<w:r><w:rPr><w:i/></w:rPr><w:t>格式</w:t></w:r>`) {
+		t.Error("拒绝后应恢复原始字符格式")
+	}
+	if !strings.Contains(content, `<w:del w:id="11" w:author="李四">`) {
+		t.Error("其他作者的修订应保持不变")
+	}
+
+	report := BuildReport(doc)
+	if report.Total != 2 || report.ByAuthor["李四"] != 2 {
+		t.Errorf("应只剩李四的修订: %+v", report.ByAuthor)
+	}
+}
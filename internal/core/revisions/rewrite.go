@@ -0,0 +1,501 @@
+package revisions
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// Action 修订处理动作
+type Action string
+
+const (
+	// ActionAccept 接受修订
+	ActionAccept Action = "accept"
+	// ActionReject 拒绝修订
+	ActionReject Action = "reject"
+)
+
+// Options 修订处理选项
+type Options struct {
+	// Author 只处理该作者的修订，为空时处理全部修订
+	Author string
+}
+
+// Result 修订处理结果
+type Result struct {
+	Action     Action         `json:"action"`
+	Author     string         `json:"author"`
+	OutputPath string         `json:"output_path"`
+	Total      int            `json:"total"`
+	ByType     map[string]int `json:"by_type"`
+	Parts      []string       `json:"parts"`
+}
+
+// 内容修订元素对应的修订类型
+var contentRevisionTypes = map[string]types.RevisionType{
+	"ins":      types.RevisionInsert,
+	"del":      types.RevisionDelete,
+	"moveFrom": types.RevisionMoveFrom,
+	"moveTo":   types.RevisionMoveTo,
+}
+
+// 格式修订元素（<属性元素>Change）对应的修订类型
+var propertyRevisionTypes = map[string]types.RevisionType{
+	"rPr":     types.RevisionRunProperties,
+	"pPr":     types.RevisionParagraphProps,
+	"sectPr":  types.RevisionSectionProps,
+	"tblPr":   types.RevisionTableProps,
+	"trPr":    types.RevisionTableProps,
+	"tcPr":    types.RevisionTableProps,
+	"tblGrid": types.RevisionTableProps,
+	"tblPrEx": types.RevisionTableProps,
+}
+
+// 拒绝格式修订时需要保留的当前子元素，这些元素不属于修订前的属性记录
+var preservedPropertyChildren = map[string]map[string]bool{
+	"pPr":    {"rPr": true, "sectPr": true},
+	"rPr":    {"ins": true, "del": true, "moveFrom": true, "moveTo": true},
+	"trPr":   {"ins": true, "del": true},
+	"sectPr": {"headerReference": true, "footerReference": true},
+}
+
+// 这些元素中的ins/del是修订标记而不是内容容器，由段落和表格行单独处理
+var markerParents = map[string]bool{
+	"rPr":   true,
+	"trPr":  true,
+	"numPr": true,
+}
+
+// 删除内容时需要保留的批注范围和书签标记
+var rangeMarkers = map[string]bool{
+	"commentRangeStart": true,
+	"commentRangeEnd":   true,
+	"bookmarkStart":     true,
+	"bookmarkEnd":       true,
+}
+
+// AcceptAll 接受文档中的修订并写出新文档
+func AcceptAll(inputPath, outputPath string, options Options) (*Result, error) {
+	return Process(inputPath, outputPath, ActionAccept, options)
+}
+
+// RejectAll 拒绝文档中的修订并写出新文档
+func RejectAll(inputPath, outputPath string, options Options) (*Result, error) {
+	return Process(inputPath, outputPath, ActionReject, options)
+}
+
+// Process 按动作处理DOCX中的修订，输出不含所处理修订的文档
+//
+// 正文、页眉页脚、脚注尾注和批注中的插入、删除、移动和格式修订都会被处理，
+// 批注范围和书签保持不变，其余部件原样复制。
+func Process(inputPath, outputPath string, action Action, options Options) (*Result, error) {
+	if action != ActionAccept && action != ActionReject {
+		return nil, fmt.Errorf("unknown revision action: %s", action)
+	}
+	if sameFile(inputPath, outputPath) {
+		return nil, fmt.Errorf("output path must differ from input path: %s", outputPath)
+	}
+
+	reader, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer reader.Close()
+
+	if dir := filepath.Dir(outputPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	tempPath := outputPath + ".tmp"
+	tempFile, err := os.Create(tempPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tempPath)
+
+	result := &Result{
+		Action:     action,
+		Author:     options.Author,
+		OutputPath: outputPath,
+		ByType:     make(map[string]int),
+		Parts:      []string{},
+	}
+	rw := &rewriter{action: action, options: options, result: result, movedRanges: make(map[string]bool)}
+
+	zipWriter := zip.NewWriter(tempFile)
+	for _, file := range reader.File {
+		if !isRevisionPart(file.Name) {
+			if err := zipWriter.Copy(file); err != nil {
+				tempFile.Close()
+				return nil, fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		before := result.Total
+		content, err := readZipFile(file)
+		if err == nil {
+			content, err = rw.rewritePart(content)
+		}
+		if err != nil {
+			tempFile.Close()
+			return nil, fmt.Errorf("failed to process %s: %w", file.Name, err)
+		}
+		if result.Total > before {
+			result.Parts = append(result.Parts, file.Name)
+		}
+
+		writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   file.Method,
+			Modified: file.Modified,
+		})
+		if err == nil {
+			_, err = writer.Write(content)
+		}
+		if err != nil {
+			tempFile.Close()
+			return nil, fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		tempFile.Close()
+		return nil, fmt.Errorf("failed to close output: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close output: %w", err)
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		return nil, fmt.Errorf("failed to move output: %w", err)
+	}
+
+	return result, nil
+}
+
+// isRevisionPart 判断部件是否可能包含修订
+func isRevisionPart(name string) bool {
+	if !strings.HasPrefix(name, "word/") || strings.Contains(name[len("word/"):], "/") || !strings.HasSuffix(name, ".xml") {
+		return false
+	}
+	base := strings.TrimSuffix(strings.TrimPrefix(name, "word/"), ".xml")
+	switch base {
+	case "document", "footnotes", "endnotes", "comments":
+		return true
+	}
+	return strings.HasPrefix(base, "header") || strings.HasPrefix(base, "footer")
+}
+
+// readZipFile 读取压缩包中的文件内容
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// sameFile 判断两个路径是否指向同一文件
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// rewriter 在节点树上接受或拒绝修订
+type rewriter struct {
+	action      Action
+	options     Options
+	result      *Result
+	movedRanges map[string]bool
+}
+
+// rewritePart 处理单个XML部件
+func (rw *rewriter) rewritePart(content []byte) ([]byte, error) {
+	root, err := parseTree(content)
+	if err != nil {
+		return nil, err
+	}
+	root.children = rw.transform(root.children, "")
+	return root.bytes(), nil
+}
+
+// matches 判断修订是否属于要处理的作者
+func (rw *rewriter) matches(revision *node) bool {
+	return rw.options.Author == "" || revision.attr("author") == rw.options.Author
+}
+
+// count 记录一处已处理的修订
+func (rw *rewriter) count(revisionType types.RevisionType) {
+	rw.result.Total++
+	rw.result.ByType[string(revisionType)]++
+}
+
+// removes 判断处理后修订内容是否被移除
+func (rw *rewriter) removes(revisionType types.RevisionType) bool {
+	if rw.action == ActionAccept {
+		return revisionType == types.RevisionDelete || revisionType == types.RevisionMoveFrom
+	}
+	return revisionType == types.RevisionInsert || revisionType == types.RevisionMoveTo
+}
+
+// transform 处理节点列表，parent为所在元素的本地名称
+func (rw *rewriter) transform(nodes []*node, parent string) []*node {
+	var result []*node
+	for _, n := range nodes {
+		if !n.element {
+			result = append(result, n)
+			continue
+		}
+
+		revisionType, isContentRevision := contentRevisionTypes[n.local]
+		switch {
+		case isContentRevision && !markerParents[parent]:
+			if !rw.matches(n) {
+				n.children = rw.transform(n.children, n.local)
+				result = append(result, n)
+				continue
+			}
+			rw.count(revisionType)
+			if rw.removes(revisionType) {
+				result = append(result, salvageMarkers(n.children, true)...)
+				continue
+			}
+			if revisionType == types.RevisionDelete || revisionType == types.RevisionMoveFrom {
+				restoreDeletedText(n.children)
+			}
+			result = append(result, rw.transform(n.children, parent)...)
+		case strings.HasPrefix(n.local, "moveFromRange") || strings.HasPrefix(n.local, "moveToRange"):
+			// 移动范围标记在接受或拒绝移动后都不再需要
+			if strings.HasSuffix(n.local, "Start") && rw.matches(n) {
+				rw.movedRanges[n.attr("id")] = true
+				continue
+			}
+			if strings.HasSuffix(n.local, "End") && rw.movedRanges[n.attr("id")] {
+				continue
+			}
+			result = append(result, n)
+		case propertyRevisionTypes[n.local] != "":
+			rw.transformProperties(n)
+			result = append(result, n)
+		case n.local == "tr":
+			if rw.transformRow(n) {
+				result = append(result, salvageMarkers(n.children, false)...)
+				continue
+			}
+			n.children = rw.transform(n.children, n.local)
+			result = append(result, n)
+		default:
+			n.children = rw.transform(n.children, n.local)
+			result = append(result, n)
+		}
+	}
+
+	return rw.mergeParagraphs(result)
+}
+
+// transformProperties 接受或拒绝属性元素中的格式修订
+func (rw *rewriter) transformProperties(props *node) {
+	change := props.child(props.local + "Change")
+	if change != nil && rw.matches(change) {
+		rw.count(propertyRevisionTypes[props.local])
+		props.removeChild(change)
+
+		if rw.action == ActionReject {
+			var original []*node
+			if inner := change.firstElement(); inner != nil {
+				original = inner.children
+			}
+			var preserved []*node
+			for _, c := range props.children {
+				if c.element && preservedPropertyChildren[props.local][c.local] {
+					preserved = append(preserved, c)
+				}
+			}
+			// rPr和sectPr中保留的元素位于开头，其余位于末尾
+			if props.local == "rPr" || props.local == "sectPr" {
+				props.children = append(preserved, original...)
+			} else {
+				props.children = append(original, preserved...)
+			}
+			// 原属性可能来自自闭合元素，确保有结束标签
+			ensureEndTag(props)
+		}
+	}
+
+	props.children = rw.transform(props.children, props.local)
+}
+
+// transformRow 处理表格行的插入/删除标记，返回该行是否应被移除
+func (rw *rewriter) transformRow(row *node) bool {
+	props := row.child("trPr")
+	if props == nil {
+		return false
+	}
+	for _, marker := range []struct {
+		local        string
+		revisionType types.RevisionType
+	}{
+		{"ins", types.RevisionRowInsert},
+		{"del", types.RevisionRowDelete},
+	} {
+		m := props.child(marker.local)
+		if m == nil || !rw.matches(m) {
+			continue
+		}
+		rw.count(marker.revisionType)
+		props.removeChild(m)
+		if (rw.action == ActionAccept && marker.local == "del") || (rw.action == ActionReject && marker.local == "ins") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeParagraphs 处理段落标记的插入/删除：段落标记被移除时，该段内容并入下一段
+func (rw *rewriter) mergeParagraphs(nodes []*node) []*node {
+	var result []*node
+	for i, n := range nodes {
+		if !n.element || n.local != "p" {
+			result = append(result, n)
+			continue
+		}
+
+		removed, ok := rw.paragraphMark(n)
+		if !ok {
+			result = append(result, n)
+			continue
+		}
+		next := nextElement(nodes[i+1:])
+		if !removed || next == nil || next.local != "p" {
+			result = append(result, n)
+			continue
+		}
+
+		// 合并后的段落使用下一段的段落属性
+		var content []*node
+		for _, c := range n.children {
+			if !(c.element && c.local == "pPr") {
+				content = append(content, c)
+			}
+		}
+		insertAt := 0
+		for j, c := range next.children {
+			if c.element && c.local == "pPr" {
+				insertAt = j + 1
+				break
+			}
+		}
+		merged := append([]*node{}, next.children[:insertAt]...)
+		merged = append(merged, content...)
+		next.children = append(merged, next.children[insertAt:]...)
+		ensureEndTag(next)
+	}
+	return result
+}
+
+// paragraphMark 处理段落标记修订，返回段落标记是否被移除以及是否存在待处理的段落标记修订
+func (rw *rewriter) paragraphMark(paragraph *node) (bool, bool) {
+	props := paragraph.child("pPr")
+	if props == nil {
+		return false, false
+	}
+	markProps := props.child("rPr")
+	if markProps == nil {
+		return false, false
+	}
+
+	for _, marker := range []struct {
+		local        string
+		revisionType types.RevisionType
+	}{
+		{"ins", types.RevisionParagraphInsert},
+		{"del", types.RevisionParagraphDelete},
+		{"moveTo", types.RevisionParagraphInsert},
+		{"moveFrom", types.RevisionParagraphDelete},
+	} {
+		m := markProps.child(marker.local)
+		if m == nil || !rw.matches(m) {
+			continue
+		}
+		rw.count(marker.revisionType)
+		markProps.removeChild(m)
+		deleted := marker.revisionType == types.RevisionParagraphDelete
+		return (rw.action == ActionAccept) == deleted, true
+	}
+	return false, false
+}
+
+// nextElement 返回列表中的第一个元素节点
+func nextElement(nodes []*node) *node {
+	for _, n := range nodes {
+		if n.element {
+			return n
+		}
+	}
+	return nil
+}
+
+// salvageMarkers 从被移除的内容中保留批注范围、书签和批注引用
+func salvageMarkers(nodes []*node, allowRuns bool) []*node {
+	var result []*node
+	for _, n := range nodes {
+		if !n.element {
+			continue
+		}
+		if rangeMarkers[n.local] {
+			result = append(result, n)
+			continue
+		}
+		if n.local == "r" {
+			if allowRuns && n.child("commentReference") != nil {
+				result = append(result, n)
+			}
+			continue
+		}
+		result = append(result, salvageMarkers(n.children, allowRuns)...)
+	}
+	return result
+}
+
+// restoreDeletedText 拒绝删除时将删除文本恢复为普通文本
+func restoreDeletedText(nodes []*node) {
+	for _, n := range nodes {
+		if !n.element {
+			continue
+		}
+		switch n.local {
+		case "delText":
+			n.rename("t")
+		case "delInstrText":
+			n.rename("instrText")
+		}
+		restoreDeletedText(n.children)
+	}
+}
+
+// ensureEndTag 自闭合元素增加子节点后改写为成对标签
+func ensureEndTag(n *node) {
+	if len(n.end) > 0 || len(n.children) == 0 {
+		return
+	}
+	start := strings.TrimSpace(string(n.start))
+	if !strings.HasSuffix(start, "/>") {
+		return
+	}
+	n.start = []byte(strings.TrimSpace(strings.TrimSuffix(start, "/>")) + ">")
+	name := n.local
+	if n.prefix != "" {
+		name = n.prefix + ":" + n.local
+	}
+	n.end = []byte("</" + name + ">")
+}
@@ -0,0 +1,141 @@
+package revisions
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// node XML节点，保留原始字节以便改写后其余内容与原文件完全一致
+//
+// 元素节点的start/end为原始开始/结束标签（自闭合元素的end为空），
+// 其他节点（文本、注释、处理指令）只使用start。
+type node struct {
+	element  bool
+	prefix   string
+	local    string
+	attrs    []xml.Attr
+	start    []byte
+	end      []byte
+	children []*node
+}
+
+// parseTree 将XML解析为保留原始字节的节点树，返回的根节点只包含顶层节点
+func parseTree(content []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	root := &node{}
+	stack := []*node{root}
+	offset := int64(0)
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		next := decoder.InputOffset()
+		raw := content[offset:next]
+		offset = next
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &node{
+				element: true,
+				prefix:  t.Name.Space,
+				local:   t.Name.Local,
+				attrs:   t.Attr,
+				start:   raw,
+			}
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unexpected end element </%s>", t.Name.Local)
+			}
+			parent.end = raw
+			stack = stack[:len(stack)-1]
+		default:
+			parent.children = append(parent.children, &node{start: raw})
+		}
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unclosed element <%s>", stack[len(stack)-1].local)
+	}
+	return root, nil
+}
+
+// write 按原始字节输出节点树
+func (n *node) write(buf *bytes.Buffer) {
+	buf.Write(n.start)
+	for _, child := range n.children {
+		child.write(buf)
+	}
+	buf.Write(n.end)
+}
+
+// bytes 返回节点树的XML内容
+func (n *node) bytes() []byte {
+	var buf bytes.Buffer
+	n.write(&buf)
+	return buf.Bytes()
+}
+
+// attr 按本地名称读取属性值
+func (n *node) attr(local string) string {
+	for _, attr := range n.attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// child 返回第一个指定本地名称的子元素
+func (n *node) child(local string) *node {
+	for _, c := range n.children {
+		if c.element && c.local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// firstElement 返回第一个子元素
+func (n *node) firstElement() *node {
+	for _, c := range n.children {
+		if c.element {
+			return c
+		}
+	}
+	return nil
+}
+
+// removeChild 移除指定子节点
+func (n *node) removeChild(target *node) {
+	for i, c := range n.children {
+		if c == target {
+			n.children = append(n.children[:i:i], n.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// rename 修改元素的本地名称，保留前缀和属性
+func (n *node) rename(local string) {
+	qualified := n.local
+	renamed := local
+	if n.prefix != "" {
+		qualified = n.prefix + ":" + n.local
+		renamed = n.prefix + ":" + local
+	}
+	n.start = bytes.Replace(n.start, []byte("<"+qualified), []byte("<"+renamed), 1)
+	if len(n.end) > 0 {
+		n.end = bytes.Replace(n.end, []byte("</"+qualified), []byte("</"+renamed), 1)
+	}
+	n.local = local
+}
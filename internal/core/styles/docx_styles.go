@@ -199,53 +199,11 @@ func (dsp *DocxStyleParser) parseThemeXML(file *zip.File, manager *types.StyleMa
 	}
 	defer rc.Close()
 
-	decoder := xml.NewDecoder(rc)
-	var currentTheme *types.ThemeStyle
-
-	for {
-		token, err := decoder.Token()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			continue
-		}
-
-		switch t := token.(type) {
-		case xml.StartElement:
-			switch t.Name.Local {
-			case "a:theme":
-				currentTheme = &types.ThemeStyle{
-					ThemeName:   "Theme1",
-					ColorScheme: "Office",
-					FontScheme:  "Office",
-					Effects:     "Office",
-					Version:     "1.0",
-				}
-
-			case "a:clrScheme":
-				if currentTheme != nil {
-					currentTheme.ColorScheme = dsp.getAttribute(t, "name")
-				}
-
-			case "a:fontScheme":
-				if currentTheme != nil {
-					currentTheme.FontScheme = dsp.getAttribute(t, "name")
-				}
-
-			case "a:fmtScheme":
-				if currentTheme != nil {
-					currentTheme.Effects = dsp.getAttribute(t, "name")
-				}
-			}
-
-		case xml.EndElement:
-			if t.Name.Local == "a:theme" && currentTheme != nil {
-				manager.ThemeStyles["Theme1"] = currentTheme
-				currentTheme = nil
-			}
-		}
+	theme, err := ParseTheme(rc, "Theme1")
+	if err != nil {
+		return err
 	}
+	manager.ThemeStyles["Theme1"] = theme
 
 	return nil
 }
@@ -467,56 +425,13 @@ func (dsp *DocxStyleParser) ParseThemeStyles(filePath string) (map[string]*types
 			if err != nil {
 				continue
 			}
-			defer rc.Close()
-
-			decoder := xml.NewDecoder(rc)
-			var currentTheme *types.ThemeStyle
-
-			for {
-				token, err := decoder.Token()
-				if err == io.EOF {
-					break
-				}
-				if err != nil {
-					continue
-				}
-
-				switch t := token.(type) {
-				case xml.StartElement:
-					switch t.Name.Local {
-					case "a:theme":
-						themeName := strings.TrimSuffix(strings.TrimPrefix(file.Name, "word/theme/"), ".xml")
-						currentTheme = &types.ThemeStyle{
-							ThemeName:   themeName,
-							ColorScheme: "Office",
-							FontScheme:  "Office",
-							Effects:     "Office",
-							Version:     "1.0",
-						}
-
-					case "a:clrScheme":
-						if currentTheme != nil {
-							currentTheme.ColorScheme = dsp.getAttribute(t, "name")
-						}
-
-					case "a:fontScheme":
-						if currentTheme != nil {
-							currentTheme.FontScheme = dsp.getAttribute(t, "name")
-						}
-
-					case "a:fmtScheme":
-						if currentTheme != nil {
-							currentTheme.Effects = dsp.getAttribute(t, "name")
-						}
-					}
-
-				case xml.EndElement:
-					if t.Name.Local == "a:theme" && currentTheme != nil {
-						themeStyles[currentTheme.ThemeName] = currentTheme
-						currentTheme = nil
-					}
-				}
+			themeName := strings.TrimSuffix(strings.TrimPrefix(file.Name, "word/theme/"), ".xml")
+			theme, err := ParseTheme(rc, themeName)
+			rc.Close()
+			if err != nil {
+				continue
 			}
+			themeStyles[themeName] = theme
 		}
 	}
 
@@ -0,0 +1,445 @@
+package styles
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// themeElement 主题XML的通用元素树
+type themeElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr     `xml:",any,attr"`
+	Children []themeElement `xml:",any"`
+}
+
+// attr 按本地名称读取属性
+func (e *themeElement) attr(name string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// child 返回第一个指定本地名称的子元素
+func (e *themeElement) child(name string) *themeElement {
+	for i := range e.Children {
+		if e.Children[i].XMLName.Local == name {
+			return &e.Children[i]
+		}
+	}
+	return nil
+}
+
+// ParseTheme 解析主题XML（word/theme/theme1.xml），包括颜色方案、字体方案和格式方案
+//
+// themeName为空时使用a:theme的name属性。
+func ParseTheme(r io.Reader, themeName string) (*types.ThemeStyle, error) {
+	var root themeElement
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("解析主题XML失败: %w", err)
+	}
+	if root.XMLName.Local != "theme" {
+		return nil, fmt.Errorf("不是主题XML: %s", root.XMLName.Local)
+	}
+
+	if themeName == "" {
+		themeName = root.attr("name")
+	}
+	theme := &types.ThemeStyle{
+		ThemeName:   themeName,
+		ColorScheme: "Office",
+		FontScheme:  "Office",
+		Effects:     "Office",
+		Version:     "1.0",
+	}
+
+	elements := root.child("themeElements")
+	if elements == nil {
+		return theme, nil
+	}
+
+	if scheme := elements.child("clrScheme"); scheme != nil {
+		theme.ColorScheme = scheme.attr("name")
+		theme.Colors = parseColorScheme(scheme)
+	}
+	if scheme := elements.child("fontScheme"); scheme != nil {
+		theme.FontScheme = scheme.attr("name")
+		theme.Fonts.Major = parseFontCollection(scheme.child("majorFont"))
+		theme.Fonts.Minor = parseFontCollection(scheme.child("minorFont"))
+	}
+	if scheme := elements.child("fmtScheme"); scheme != nil {
+		theme.Effects = scheme.attr("name")
+		theme.Format = parseFormatScheme(scheme)
+	}
+
+	return theme, nil
+}
+
+// parseColorScheme 解析十二种方案颜色
+func parseColorScheme(scheme *themeElement) types.ThemeColorScheme {
+	colors := types.ThemeColorScheme{}
+	targets := map[string]*string{
+		"dk1":      &colors.Dark1,
+		"lt1":      &colors.Light1,
+		"dk2":      &colors.Dark2,
+		"lt2":      &colors.Light2,
+		"accent1":  &colors.Accent1,
+		"accent2":  &colors.Accent2,
+		"accent3":  &colors.Accent3,
+		"accent4":  &colors.Accent4,
+		"accent5":  &colors.Accent5,
+		"accent6":  &colors.Accent6,
+		"hlink":    &colors.Hyperlink,
+		"folHlink": &colors.FollowedHyperlink,
+	}
+	for i := range scheme.Children {
+		if target, exists := targets[scheme.Children[i].XMLName.Local]; exists {
+			*target = schemeColorValue(&scheme.Children[i])
+		}
+	}
+	return colors
+}
+
+// schemeColorValue 读取方案颜色元素中的RGB值
+func schemeColorValue(element *themeElement) string {
+	for i := range element.Children {
+		color := &element.Children[i]
+		switch color.XMLName.Local {
+		case "srgbClr":
+			return strings.ToUpper(color.attr("val"))
+		case "sysClr":
+			if last := color.attr("lastClr"); last != "" {
+				return strings.ToUpper(last)
+			}
+			switch color.attr("val") {
+			case "window":
+				return "FFFFFF"
+			case "windowText":
+				return "000000"
+			}
+		}
+	}
+	return ""
+}
+
+// parseFontCollection 解析主要或次要字体及按书写系统的覆盖
+func parseFontCollection(element *themeElement) types.ThemeFontCollection {
+	collection := types.ThemeFontCollection{Scripts: make(map[string]string)}
+	if element == nil {
+		return collection
+	}
+	for i := range element.Children {
+		font := &element.Children[i]
+		switch font.XMLName.Local {
+		case "latin":
+			collection.Latin = font.attr("typeface")
+		case "ea":
+			collection.EastAsian = font.attr("typeface")
+		case "cs":
+			collection.ComplexScript = font.attr("typeface")
+		case "font":
+			collection.Scripts[font.attr("script")] = font.attr("typeface")
+		}
+	}
+	return collection
+}
+
+// parseFormatScheme 解析格式方案中的填充、线条、效果和背景样式
+func parseFormatScheme(scheme *themeElement) types.ThemeFormatScheme {
+	format := types.ThemeFormatScheme{
+		Name:             scheme.attr("name"),
+		FillStyles:       []types.ThemeFill{},
+		LineStyles:       []types.ThemeLine{},
+		EffectStyles:     []types.ThemeEffect{},
+		BackgroundStyles: []types.ThemeFill{},
+	}
+
+	if list := scheme.child("fillStyleLst"); list != nil {
+		for i := range list.Children {
+			format.FillStyles = append(format.FillStyles, parseThemeFill(&list.Children[i]))
+		}
+	}
+	if list := scheme.child("bgFillStyleLst"); list != nil {
+		for i := range list.Children {
+			format.BackgroundStyles = append(format.BackgroundStyles, parseThemeFill(&list.Children[i]))
+		}
+	}
+	if list := scheme.child("lnStyleLst"); list != nil {
+		for i := range list.Children {
+			line := &list.Children[i]
+			style := types.ThemeLine{Cap: line.attr("cap")}
+			if w, err := strconv.ParseFloat(line.attr("w"), 64); err == nil {
+				style.Width = w / 12700 // EMU转磅
+			}
+			for j := range line.Children {
+				child := &line.Children[j]
+				if child.XMLName.Local == "prstDash" {
+					style.Dash = child.attr("val")
+				} else if fill := parseThemeFill(child); fill.Type != "" {
+					style.Fill = fill
+				}
+			}
+			format.LineStyles = append(format.LineStyles, style)
+		}
+	}
+	if list := scheme.child("effectStyleLst"); list != nil {
+		for i := range list.Children {
+			effect := types.ThemeEffect{Effects: []string{}}
+			if effects := list.Children[i].child("effectLst"); effects != nil {
+				for j := range effects.Children {
+					effect.Effects = append(effect.Effects, effects.Children[j].XMLName.Local)
+				}
+			}
+			format.EffectStyles = append(format.EffectStyles, effect)
+		}
+	}
+
+	return format
+}
+
+// parseThemeFill 解析填充元素，非填充元素返回空类型
+func parseThemeFill(element *themeElement) types.ThemeFill {
+	fill := types.ThemeFill{Colors: []string{}}
+	switch element.XMLName.Local {
+	case "solidFill":
+		fill.Type = "solid"
+	case "gradFill":
+		fill.Type = "gradient"
+	case "pattFill":
+		fill.Type = "pattern"
+	case "blipFill":
+		fill.Type = "picture"
+	case "noFill":
+		fill.Type = "none"
+	default:
+		return types.ThemeFill{}
+	}
+	collectFillColors(element, &fill.Colors)
+	return fill
+}
+
+// collectFillColors 收集填充中引用的颜色
+func collectFillColors(element *themeElement, colors *[]string) {
+	for i := range element.Children {
+		child := &element.Children[i]
+		switch child.XMLName.Local {
+		case "schemeClr", "srgbClr", "prstClr":
+			*colors = append(*colors, child.attr("val"))
+		case "sysClr":
+			*colors = append(*colors, child.attr("lastClr"))
+		default:
+			collectFillColors(child, colors)
+		}
+	}
+}
+
+// SchemeColor 返回主题颜色名称对应的RGB值，支持w:themeColor的取值（如accent1、text1、background2）
+// 和方案颜色的简写（如dk1、lt2、hlink）
+func SchemeColor(theme *types.ThemeStyle, name string) (string, bool) {
+	if theme == nil {
+		return "", false
+	}
+	colors := theme.Colors
+	var value string
+	// 文本/背景颜色按Word默认的颜色映射（w:clrSchemeMapping）解析
+	switch name {
+	case "dark1", "dk1", "text1", "tx1":
+		value = colors.Dark1
+	case "light1", "lt1", "background1", "bg1":
+		value = colors.Light1
+	case "dark2", "dk2", "text2", "tx2":
+		value = colors.Dark2
+	case "light2", "lt2", "background2", "bg2":
+		value = colors.Light2
+	case "accent1":
+		value = colors.Accent1
+	case "accent2":
+		value = colors.Accent2
+	case "accent3":
+		value = colors.Accent3
+	case "accent4":
+		value = colors.Accent4
+	case "accent5":
+		value = colors.Accent5
+	case "accent6":
+		value = colors.Accent6
+	case "hyperlink", "hlink":
+		value = colors.Hyperlink
+	case "followedHyperlink", "folHlink":
+		value = colors.FollowedHyperlink
+	}
+	return value, value != ""
+}
+
+// ResolveThemeColor 将w:themeColor、w:themeTint和w:themeShade解析为RRGGBB颜色
+//
+// tint和shade为十六进制字节（00-FF），为空表示不调整。主题中没有该颜色时返回false。
+func ResolveThemeColor(theme *types.ThemeStyle, themeColor, themeTint, themeShade string) (string, bool) {
+	rgb, ok := SchemeColor(theme, themeColor)
+	if !ok {
+		return "", false
+	}
+	if tint, ok := parseHexFraction(themeTint); ok {
+		rgb = ApplyTint(rgb, tint)
+	}
+	if shade, ok := parseHexFraction(themeShade); ok {
+		rgb = ApplyShade(rgb, shade)
+	}
+	return rgb, true
+}
+
+// parseHexFraction 将十六进制字节转换为0-1之间的比例
+func parseHexFraction(value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(value, 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return float64(v) / 255, true
+}
+
+// ApplyTint 按比例提亮颜色：在HSL空间中 L' = L*tint + (1-tint)
+func ApplyTint(rgb string, tint float64) string {
+	return adjustLuminance(rgb, func(l float64) float64 { return l*tint + (1 - tint) })
+}
+
+// ApplyShade 按比例加深颜色：在HSL空间中 L' = L*shade
+func ApplyShade(rgb string, shade float64) string {
+	return adjustLuminance(rgb, func(l float64) float64 { return l * shade })
+}
+
+// adjustLuminance 调整颜色亮度，无法解析的颜色原样返回
+func adjustLuminance(rgb string, adjust func(float64) float64) string {
+	value, err := strconv.ParseUint(strings.TrimPrefix(rgb, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(rgb, "#")) != 6 {
+		return rgb
+	}
+	r := float64(value>>16&0xFF) / 255
+	g := float64(value>>8&0xFF) / 255
+	b := float64(value&0xFF) / 255
+
+	h, s, l := rgbToHSL(r, g, b)
+	l = math.Max(0, math.Min(1, adjust(l)))
+	r, g, b = hslToRGB(h, s, l)
+
+	// Word截断而非四舍五入到整数通道值
+	channel := func(v float64) uint64 { return uint64(math.Floor(v*255 + 1e-6)) }
+	return fmt.Sprintf("%02X%02X%02X", channel(r), channel(g), channel(b))
+}
+
+// rgbToHSL RGB转HSL，h单位为度
+func rgbToHSL(r, g, b float64) (float64, float64, float64) {
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	l := (max + min) / 2
+	if max == min {
+		return 0, 0, l
+	}
+
+	d := max - min
+	s := d / (max + min)
+	if l > 0.5 {
+		s = d / (2 - max - min)
+	}
+
+	var h float64
+	switch max {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// hslToRGB HSL转RGB
+func hslToRGB(h, s, l float64) (float64, float64, float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// 语言标记对应的书写系统，用于在ea字体为空时选择主题字体
+var langScripts = map[string]string{
+	"zh-CN": "Hans",
+	"zh-SG": "Hans",
+	"zh-TW": "Hant",
+	"zh-HK": "Hant",
+	"zh-MO": "Hant",
+	"ja-JP": "Jpan",
+	"ko-KR": "Hang",
+	"ar-SA": "Arab",
+	"he-IL": "Hebr",
+	"th-TH": "Thai",
+}
+
+// ResolveThemeFont 解析w:rFonts中的主题字体（如minorEastAsia、majorHAnsi）
+//
+// lang为对应的w:lang值，东亚或复杂文种字体为空时按语言选择书写系统覆盖字体。
+func ResolveThemeFont(theme *types.ThemeStyle, themeFont, lang string) string {
+	if theme == nil {
+		return ""
+	}
+
+	var collection types.ThemeFontCollection
+	switch {
+	case strings.HasPrefix(themeFont, "major"):
+		collection = theme.Fonts.Major
+	case strings.HasPrefix(themeFont, "minor"):
+		collection = theme.Fonts.Minor
+	default:
+		return ""
+	}
+
+	var font string
+	switch strings.TrimPrefix(strings.TrimPrefix(themeFont, "major"), "minor") {
+	case "Ascii", "HAnsi":
+		return collection.Latin
+	case "EastAsia":
+		font = collection.EastAsian
+	case "Bidi":
+		font = collection.ComplexScript
+	default:
+		return ""
+	}
+
+	if font == "" {
+		if script, exists := langScripts[lang]; exists {
+			font = collection.Scripts[script]
+		}
+	}
+	return font
+}
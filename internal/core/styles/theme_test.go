@@ -0,0 +1,107 @@
+package styles
+
+import (
+	"strings"
+	"testing"
+)
+
+const testThemeXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Office Theme"><a:themeElements>
+<a:clrScheme name="Office">
+<a:dk1><a:sysClr val="windowText" lastClr="000000"/></a:dk1><a:lt1><a:sysClr val="window"/></a:lt1>
+<a:dk2><a:srgbClr val="44546A"/></a:dk2><a:lt2><a:srgbClr val="E7E6E6"/></a:lt2>
+<a:accent1><a:srgbClr val="4472C4"/></a:accent1><a:accent2><a:srgbClr val="ED7D31"/></a:accent2>
+<a:accent3><a:srgbClr val="A5A5A5"/></a:accent3><a:accent4><a:srgbClr val="FFC000"/></a:accent4>
+<a:accent5><a:srgbClr val="5B9BD5"/></a:accent5><a:accent6><a:srgbClr val="70AD47"/></a:accent6>
+<a:hlink><a:srgbClr val="0563C1"/></a:hlink><a:folHlink><a:srgbClr val="954F72"/></a:folHlink>
+</a:clrScheme>
+<a:fontScheme name="Office">
+<a:majorFont><a:latin typeface="Calibri Light"/><a:ea typeface=""/><a:cs typeface=""/><a:font script="Hans" typeface="等线 Light"/></a:majorFont>
+<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/><a:font script="Hans" typeface="等线"/><a:font script="Jpan" typeface="游明朝"/></a:minorFont>
+</a:fontScheme>
+<a:fmtScheme name="Office">
+<a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:gradFill><a:gsLst><a:gs pos="0"><a:schemeClr val="phClr"><a:tint val="67000"/></a:schemeClr></a:gs><a:gs pos="100000"><a:schemeClr val="phClr"/></a:gs></a:gsLst></a:gradFill></a:fillStyleLst>
+<a:lnStyleLst><a:ln w="6350" cap="flat"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:prstDash val="solid"/></a:ln></a:lnStyleLst>
+<a:effectStyleLst><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst><a:outerShdw blurRad="57150"/></a:effectLst></a:effectStyle></a:effectStyleLst>
+<a:bgFillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:bgFillStyleLst>
+</a:fmtScheme>
+</a:themeElements></a:theme>`
+
+// TestParseTheme 测试主题颜色、字体和格式方案的解析
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme(strings.NewReader(testThemeXML), "")
+	if err != nil {
+		t.Fatalf("解析主题失败: %v", err)
+	}
+
+	if theme.ThemeName != "Office Theme" || theme.ColorScheme != "Office" {
+		t.Errorf("主题名称解析不正确: %s, %s", theme.ThemeName, theme.ColorScheme)
+	}
+	if theme.Colors.Dark1 != "000000" || theme.Colors.Light1 != "FFFFFF" || theme.Colors.Accent1 != "4472C4" || theme.Colors.FollowedHyperlink != "954F72" {
+		t.Errorf("颜色方案解析不正确: %+v", theme.Colors)
+	}
+	if theme.Fonts.Major.Latin != "Calibri Light" || theme.Fonts.Minor.Scripts["Jpan"] != "游明朝" {
+		t.Errorf("字体方案解析不正确: %+v", theme.Fonts)
+	}
+
+	format := theme.Format
+	if len(format.FillStyles) != 2 || format.FillStyles[1].Type != "gradient" || len(format.FillStyles[1].Colors) != 2 {
+		t.Errorf("填充样式解析不正确: %+v", format.FillStyles)
+	}
+	if len(format.LineStyles) != 1 || format.LineStyles[0].Width != 0.5 || format.LineStyles[0].Dash != "solid" || format.LineStyles[0].Fill.Type != "solid" {
+		t.Errorf("线条样式解析不正确: %+v", format.LineStyles)
+	}
+	if len(format.EffectStyles) != 2 || len(format.EffectStyles[1].Effects) != 1 || format.EffectStyles[1].Effects[0] != "outerShdw" {
+		t.Errorf("效果样式解析不正确: %+v", format.EffectStyles)
+	}
+}
+
+// TestResolveThemeColor 测试主题颜色及tint/shade换算，期望值为Word调色板中显示的颜色
+func TestResolveThemeColor(t *testing.T) {
+	theme, err := ParseTheme(strings.NewReader(testThemeXML), "")
+	if err != nil {
+		t.Fatalf("解析主题失败: %v", err)
+	}
+
+	tests := []struct {
+		color, tint, shade string
+		expected           string
+	}{
+		{"accent1", "", "", "4472C4"},
+		{"accent1", "", "BF", "2F5496"}, // 深色25%
+		{"accent1", "99", "", "8EAADB"}, // 浅色40%
+		{"text1", "", "", "000000"},
+		{"background1", "", "A6", "A6A6A6"},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveThemeColor(theme, tt.color, tt.tint, tt.shade)
+		if !ok || got != tt.expected {
+			t.Errorf("%s tint=%s shade=%s: 期望 %s，实际 %s", tt.color, tt.tint, tt.shade, tt.expected, got)
+		}
+	}
+
+	if _, ok := ResolveThemeColor(theme, "unknown", "", ""); ok {
+		t.Error("未知主题颜色应返回false")
+	}
+	if _, ok := ResolveThemeColor(nil, "accent1", "", ""); ok {
+		t.Error("没有主题时应返回false")
+	}
+}
+
+// TestResolveThemeFont 测试主题字体解析
+func TestResolveThemeFont(t *testing.T) {
+	theme, err := ParseTheme(strings.NewReader(testThemeXML), "")
+	if err != nil {
+		t.Fatalf("解析主题失败: %v", err)
+	}
+
+	if font := ResolveThemeFont(theme, "majorHAnsi", ""); font != "Calibri Light" {
+		t.Errorf("majorHAnsi 期望 Calibri Light，实际 %s", font)
+	}
+	if font := ResolveThemeFont(theme, "minorEastAsia", "zh-CN"); font != "等线" {
+		t.Errorf("minorEastAsia 期望 等线，实际 %s", font)
+	}
+	if font := ResolveThemeFont(theme, "minorEastAsia", ""); font != "" {
+		t.Errorf("未知语言时不应选择覆盖字体，实际 %s", font)
+	}
+}
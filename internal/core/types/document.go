@@ -26,8 +26,22 @@ type DocumentMetadata struct {
 	FileSize    int64     `json:"file_size"`
 	WordCount   int       `json:"word_count"`
 	PageCount   int       `json:"page_count"`
+	Warnings    []string  `json:"warnings,omitempty"` // 解析时的警告，如扩展名与文件内容不符
+	// 文档类型和是否包含宏：OOXML为VBA宏工程（vbaProject.bin），ODF为Basic模块
+	DocumentKind DocumentKind `json:"document_kind,omitempty"`
+	HasMacros    bool         `json:"has_macros,omitempty"`
 }
 
+// DocumentKind 文档类型，OOXML由主文档部件的内容类型决定，ODF由包的mimetype决定
+type DocumentKind string
+
+const (
+	KindDocument      DocumentKind = "document"
+	KindTemplate      DocumentKind = "template"
+	KindMacroDocument DocumentKind = "macro_enabled_document"
+	KindMacroTemplate DocumentKind = "macro_enabled_template"
+)
+
 // DocumentContent 文档内容
 type DocumentContent struct {
 	Paragraphs []Paragraph `json:"paragraphs"`
@@ -55,6 +69,18 @@ type Paragraph struct {
 	KeepLines   bool             `json:"keep_lines"`
 	KeepNext    bool             `json:"keep_next"`
 	OutlineLevel int             `json:"outline_level"`
+	List        *ListInfo        `json:"list,omitempty"` // 列表项的编号信息，非列表段落为nil
+	// 修订信息：段落标记的插入/删除，以及段落格式修改前的原始属性
+	MarkRevision   *Revision                `json:"mark_revision"`
+	PropertyChange *ParagraphPropertyChange `json:"property_change"`
+}
+
+// ListInfo 列表项的编号信息
+type ListInfo struct {
+	ID     string `json:"id"`     // 列表（编号实例）ID
+	Level  int    `json:"level"`  // 列表级别，从0开始
+	Format string `json:"format"` // 编号格式：decimal、upperRoman、lowerLetter、bullet等
+	Label  string `json:"label"`  // 显示的编号文本，如"1."、"(a)"或项目符号
 }
 
 // TextRun 文本运行
@@ -69,6 +95,9 @@ type TextRun struct {
 	Highlight Highlight  `json:"highlight"`
 	Size     float64    `json:"size"`
 	Position Position   `json:"position"`
+	// 修订信息：Runs中同时保留插入和删除的文本，Text为删除文本时Revision.Type为delete
+	Revision       *Revision          `json:"revision"`
+	PropertyChange *RunPropertyChange `json:"property_change"`
 }
 
 // Section 节
@@ -122,6 +151,7 @@ type FormatRules struct {
 	TableRules     []TableRule     `json:"table_rules"`
 	PageRules      []PageRule      `json:"page_rules"`
 	StyleRules     []StyleRule     `json:"style_rules"`
+	RoleRules      []RoleRule      `json:"role_rules,omitempty"` // 按段落角色匹配的格式规则
 }
 
 // FontRule 字体规则
@@ -289,6 +319,20 @@ type Comment struct {
 	Author  string `json:"author"`
 	Date    time.Time `json:"date"`
 	Text    string `json:"text"`
+	Initials  string        `json:"initials"`
+	ParaID    string        `json:"para_id"`    // 批注最后一段的w14:paraId，用于关联回复和解决状态
+	DurableID string        `json:"durable_id"` // commentsIds.xml中的持久ID
+	ParentID  string        `json:"parent_id"`  // 回复的批注ID，为空表示顶层批注
+	Replies   []string      `json:"replies"`    // 回复该批注的批注ID
+	Done      bool          `json:"done"`       // 是否已解决
+	Anchor    CommentAnchor `json:"anchor"`
+}
+
+// CommentAnchor 批注在正文中的锚定范围
+type CommentAnchor struct {
+	Paragraph    int    `json:"paragraph"`     // 范围起始的正文段落序号（从1开始），0表示不在正文段落中
+	EndParagraph int    `json:"end_paragraph"` // 范围结束的正文段落序号
+	Text         string `json:"text"`          // 范围内的文本
 }
 
 type Bookmark struct {
@@ -374,6 +418,7 @@ type DocumentStyles struct {
 	ParagraphStyles []ParagraphStyle `json:"paragraph_styles"`
 	CharacterStyles []CharacterStyle `json:"character_styles"`
 	TableStyles     []TableStyle     `json:"table_styles"`
+	Theme           *ThemeStyle      `json:"theme"`
 }
 
 type CharacterStyle struct {
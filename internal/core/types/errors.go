@@ -0,0 +1,56 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// TimeoutError 处理因超时或取消而中断
+//
+// 返回TimeoutError的函数同时返回中断前已完成的部分结果（如已解析的段落）。
+// Err为context.DeadlineExceeded或context.Canceled，可用errors.Is判断。
+type TimeoutError struct {
+	Op   string // 被中断的操作，如"parse document"
+	Path string // 正在处理的文件，可以为空
+	Err  error
+}
+
+// Error 实现error接口
+func (e *TimeoutError) Error() string {
+	reason := "timed out"
+	if errors.Is(e.Err, context.Canceled) {
+		reason = "canceled"
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%s %s", e.Op, reason)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Op, reason, e.Path)
+}
+
+// Unwrap 返回context错误
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout 是否因超过截止时间而中断（而非被取消）
+func (e *TimeoutError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// CheckContext ctx已结束时返回TimeoutError，否则返回nil
+func CheckContext(ctx context.Context, op, path string) error {
+	if err := ctx.Err(); err != nil {
+		return &TimeoutError{Op: op, Path: path, Err: err}
+	}
+	return nil
+}
+
+// AsTimeoutError 从错误链中取出TimeoutError
+func AsTimeoutError(err error) (*TimeoutError, bool) {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr, true
+	}
+	return nil, false
+}
@@ -152,11 +152,13 @@ type Picture struct {
 
 // GraphicContent 图形内容
 type GraphicContent struct {
-	Text     string       `json:"text" xml:"text"`
-	Image    ImageData    `json:"image" xml:"image"`
-	Chart    ChartData    `json:"chart" xml:"chart"`
-	SmartArt SmartArtData `json:"smartart" xml:"smartart"`
-	Formula  FormulaData  `json:"formula" xml:"formula"`
+	Text       string       `json:"text" xml:"text"`
+	Paragraphs []Paragraph  `json:"paragraphs" xml:"paragraphs>paragraph"` // 文本框内的段落
+	Image      ImageData    `json:"image" xml:"image"`
+	Chart      ChartData    `json:"chart" xml:"chart"`
+	SmartArt   SmartArtData `json:"smartart" xml:"smartart"`
+	Formula    FormulaData  `json:"formula" xml:"formula"`
+	WordArt    WordArtData  `json:"wordart" xml:"wordart"`
 }
 
 // ImageData 图片数据
@@ -255,6 +257,17 @@ type FormulaData struct {
 	Size    float64 `json:"size" xml:"size,attr"`
 }
 
+// WordArtData 艺术字数据（VML v:textpath）
+type WordArtData struct {
+	Text       string  `json:"text" xml:"text"`
+	FontFamily string  `json:"font_family" xml:"font-family,attr"`
+	FontSize   float64 `json:"font_size" xml:"font-size,attr"`
+	Bold       bool    `json:"bold" xml:"bold,attr"`
+	Italic     bool    `json:"italic" xml:"italic,attr"`
+	FitShape   bool    `json:"fit_shape" xml:"fit-shape,attr"`
+	Preset     string  `json:"preset" xml:"preset,attr"` // 形状类型，如 #_x0000_t136
+}
+
 // GraphicMetadata 图形元数据
 type GraphicMetadata struct {
 	FileName string    `json:"file_name" xml:"file-name"`
@@ -263,6 +276,9 @@ type GraphicMetadata struct {
 	Author   string    `json:"author" xml:"author"`
 	Comments string    `json:"comments" xml:"comments"`
 	Tags     []string  `json:"tags" xml:"tags>tag"`
+	Name     string    `json:"name" xml:"name"`
+	Source   string    `json:"source" xml:"source"` // 来源表示：drawingml, vml
+	Part     string    `json:"part" xml:"part"`     // 所在部件，如 word/document.xml
 }
 
 // Anchor 锚点信息
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"time"
+)
+
+// RevisionType 修订类型
+type RevisionType string
+
+const (
+	RevisionInsert          RevisionType = "insert"           // w:ins 插入
+	RevisionDelete          RevisionType = "delete"           // w:del 删除
+	RevisionMoveFrom        RevisionType = "move_from"        // w:moveFrom 移出（原位置）
+	RevisionMoveTo          RevisionType = "move_to"          // w:moveTo 移入（新位置）
+	RevisionRunProperties   RevisionType = "run_properties"   // w:rPrChange 字符格式修改
+	RevisionParagraphProps  RevisionType = "paragraph_props"  // w:pPrChange 段落格式修改
+	RevisionParagraphInsert RevisionType = "paragraph_insert" // 段落标记插入
+	RevisionParagraphDelete RevisionType = "paragraph_delete" // 段落标记删除
+	RevisionRowInsert       RevisionType = "row_insert"       // 表格行插入
+	RevisionRowDelete       RevisionType = "row_delete"       // 表格行删除
+	RevisionTableProps      RevisionType = "table_props"      // 表格、行、单元格格式修改
+	RevisionSectionProps    RevisionType = "section_props"    // w:sectPrChange 节格式修改
+)
+
+// Revision 修订信息
+type Revision struct {
+	ID     string       `json:"id"`
+	Type   RevisionType `json:"type"`
+	Author string       `json:"author"`
+	Date   time.Time    `json:"date"`
+}
+
+// RunProperties 文本运行的格式属性，用于记录修订前的原始格式
+type RunProperties struct {
+	Font      Font      `json:"font"`
+	Bold      bool      `json:"bold"`
+	Italic    bool      `json:"italic"`
+	Underline Underline `json:"underline"`
+	Color     Color     `json:"color"`
+	Size      float64   `json:"size"`
+}
+
+// RunPropertyChange 字符格式修订（w:rPrChange）
+type RunPropertyChange struct {
+	Revision Revision      `json:"revision"`
+	Original RunProperties `json:"original"`
+}
+
+// ParagraphProperties 段落格式属性，用于记录修订前的原始格式
+type ParagraphProperties struct {
+	StyleName   string      `json:"style_name"`
+	Alignment   Alignment   `json:"alignment"`
+	Indentation Indentation `json:"indentation"`
+	Spacing     Spacing     `json:"spacing"`
+}
+
+// ParagraphPropertyChange 段落格式修订（w:pPrChange）
+type ParagraphPropertyChange struct {
+	Revision Revision            `json:"revision"`
+	Original ParagraphProperties `json:"original"`
+}
+
+// IsRemovedWhenAccepted 接受修订后该内容是否消失（删除或移出）
+func (r *Revision) IsRemovedWhenAccepted() bool {
+	return r != nil && (r.Type == RevisionDelete || r.Type == RevisionMoveFrom || r.Type == RevisionParagraphDelete)
+}
+
+// IsRemovedWhenRejected 拒绝修订后该内容是否消失（插入或移入）
+func (r *Revision) IsRemovedWhenRejected() bool {
+	return r != nil && (r.Type == RevisionInsert || r.Type == RevisionMoveTo || r.Type == RevisionParagraphInsert)
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"slices"
+	"strings"
+)
+
+// 常用段落角色，标题按级别为heading1~heading9，无法识别的样式以样式ID作为角色
+const (
+	RoleTitle   = "title"
+	RoleHeading = "heading"
+	RoleBody    = "body"
+	RoleCaption = "caption"
+	RoleList    = "list"
+	RoleTOC     = "toc"
+)
+
+// RoleRule 某一类段落（标题、正文、题注等）应使用的格式
+//
+// 与按顺序对应的ParagraphRule不同，RoleRule按段落角色匹配，适用于段落数量与模板不同的文档。
+// 字体名称、字号、行距和对齐方式为空值时不作要求。
+type RoleRule struct {
+	Role        string        `json:"role"`
+	Description string        `json:"description,omitempty"`
+	Font        Font          `json:"font"`
+	Alignment   Alignment     `json:"alignment"`
+	Indentation Indentation   `json:"indentation"`
+	Spacing     Spacing       `json:"spacing"`
+	Confidence  float64       `json:"confidence,omitempty"` // 学习样本中使用该格式的段落比例
+	Variance    *RoleVariance `json:"variance,omitempty"`   // 除上述格式外允许的取值
+}
+
+// RoleVariance 角色规则允许的其他取值，通常从样本文档中学习得到
+type RoleVariance struct {
+	FontNames    []string    `json:"font_names,omitempty"`
+	FontSizes    []float64   `json:"font_sizes,omitempty"`
+	Alignments   []Alignment `json:"alignments,omitempty"`
+	LineSpacings []float64   `json:"line_spacings,omitempty"`
+}
+
+// 段落格式与角色规则不符的属性
+const (
+	DeviationFontName      = "font.name"
+	DeviationFontSize      = "font.size"
+	DeviationBold          = "font.bold"
+	DeviationItalic        = "font.italic"
+	DeviationAlignment     = "alignment"
+	DeviationSpacingBefore = "spacing.before"
+	DeviationSpacingAfter  = "spacing.after"
+	DeviationLineSpacing   = "spacing.line"
+)
+
+// ParagraphRole 根据段落样式判断段落角色，没有样式或使用正文样式的段落为body
+func ParagraphRole(paragraph *Paragraph) string {
+	styleID := paragraph.Style.Name
+	style := strings.ToLower(strings.ReplaceAll(styleID, " ", ""))
+
+	switch {
+	case style == "" || style == "normal" || style == "bodytext" || style == "正文":
+		return RoleBody
+	case style == "title" || style == "标题":
+		return RoleTitle
+	case style == "caption" || style == "题注":
+		return RoleCaption
+	case strings.HasPrefix(style, "toc") || strings.HasPrefix(style, "目录"):
+		return RoleTOC
+	case strings.Contains(style, "list") || strings.HasPrefix(style, "列表"):
+		return RoleList
+	}
+
+	// Heading1或中文版Word内置标题样式的ID"1"~"9"
+	level := strings.TrimPrefix(strings.TrimPrefix(style, "heading"), "标题")
+	if len(level) == 1 && level[0] >= '1' && level[0] <= '9' {
+		return RoleHeading + level
+	}
+	return styleID
+}
+
+// RoleRuleOf 以RoleRule的形式返回段落自身的格式，字体取第一个有文字且设置了字体的文本运行
+func RoleRuleOf(paragraph *Paragraph) RoleRule {
+	rule := RoleRule{
+		Role:        ParagraphRole(paragraph),
+		Alignment:   paragraph.Alignment,
+		Indentation: paragraph.Indentation,
+		Spacing:     paragraph.Spacing,
+	}
+	for _, run := range paragraph.Runs {
+		if strings.TrimSpace(run.Text) == "" || run.Font.Name == "" {
+			continue
+		}
+		rule.Font = Font{
+			Name:   run.Font.Name,
+			Size:   run.Font.Size,
+			Color:  run.Font.Color,
+			Bold:   run.Bold || run.Font.Bold,
+			Italic: run.Italic || run.Font.Italic,
+		}
+		break
+	}
+	return rule
+}
+
+// Deviations 返回段落格式actual与规则不符的属性
+//
+// 规则未设置的字体名称、字号、对齐方式和行距不作检查，段落未设置字体名称或字号时也不检查；
+// Variance中列出的取值视为符合。粗体、斜体和段前段后间距总是检查。
+func (r *RoleRule) Deviations(actual RoleRule) []string {
+	var variance RoleVariance
+	if r.Variance != nil {
+		variance = *r.Variance
+	}
+
+	var deviations []string
+	if r.Font.Name != "" && actual.Font.Name != "" && actual.Font.Name != r.Font.Name && !slices.Contains(variance.FontNames, actual.Font.Name) {
+		deviations = append(deviations, DeviationFontName)
+	}
+	if r.Font.Size > 0 && actual.Font.Size > 0 && actual.Font.Size != r.Font.Size && !slices.Contains(variance.FontSizes, actual.Font.Size) {
+		deviations = append(deviations, DeviationFontSize)
+	}
+	if actual.Font.Bold != r.Font.Bold {
+		deviations = append(deviations, DeviationBold)
+	}
+	if actual.Font.Italic != r.Font.Italic {
+		deviations = append(deviations, DeviationItalic)
+	}
+	if r.Alignment != "" && !sameAlignment(actual.Alignment, r.Alignment) {
+		allowed := false
+		for _, alignment := range variance.Alignments {
+			allowed = allowed || sameAlignment(actual.Alignment, alignment)
+		}
+		if !allowed {
+			deviations = append(deviations, DeviationAlignment)
+		}
+	}
+	if actual.Spacing.Before != r.Spacing.Before {
+		deviations = append(deviations, DeviationSpacingBefore)
+	}
+	if actual.Spacing.After != r.Spacing.After {
+		deviations = append(deviations, DeviationSpacingAfter)
+	}
+	if r.Spacing.Line > 0 && actual.Spacing.Line != r.Spacing.Line && !slices.Contains(variance.LineSpacings, actual.Spacing.Line) {
+		deviations = append(deviations, DeviationLineSpacing)
+	}
+	return deviations
+}
+
+// NormalizeAlignment 将两端对齐的不同写法视为相同，空值和start视为左对齐，end视为右对齐
+func NormalizeAlignment(alignment Alignment) Alignment {
+	switch alignment {
+	case "both", "justify":
+		return AlignJustify
+	case "", "start":
+		return AlignLeft
+	case "end":
+		return AlignRight
+	}
+	return alignment
+}
+
+// sameAlignment 判断两种对齐方式是否相同
+func sameAlignment(a, b Alignment) bool {
+	return NormalizeAlignment(a) == NormalizeAlignment(b)
+}
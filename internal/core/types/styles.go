@@ -37,6 +37,67 @@ type ThemeStyle struct {
 	FontScheme  string `json:"font_scheme"`   // 字体方案
 	Effects     string `json:"effects"`       // 效果方案
 	Version     string `json:"version"`       // 主题版本
+	Colors      ThemeColorScheme  `json:"colors"`        // 颜色方案中的十二种颜色
+	Fonts       ThemeFontScheme   `json:"fonts"`         // 主要/次要字体
+	Format      ThemeFormatScheme `json:"format"`        // 格式方案（填充、线条、效果）
+}
+
+// ThemeColorScheme 主题颜色方案（a:clrScheme），颜色为RRGGBB形式
+type ThemeColorScheme struct {
+	Dark1             string `json:"dk1"`
+	Light1            string `json:"lt1"`
+	Dark2             string `json:"dk2"`
+	Light2            string `json:"lt2"`
+	Accent1           string `json:"accent1"`
+	Accent2           string `json:"accent2"`
+	Accent3           string `json:"accent3"`
+	Accent4           string `json:"accent4"`
+	Accent5           string `json:"accent5"`
+	Accent6           string `json:"accent6"`
+	Hyperlink         string `json:"hlink"`
+	FollowedHyperlink string `json:"fol_hlink"`
+}
+
+// ThemeFontScheme 主题字体方案（a:fontScheme）
+type ThemeFontScheme struct {
+	Major ThemeFontCollection `json:"major"` // 标题字体
+	Minor ThemeFontCollection `json:"minor"` // 正文字体
+}
+
+// ThemeFontCollection 一组主题字体，Scripts为按书写系统（如Hans、Jpan）覆盖的字体
+type ThemeFontCollection struct {
+	Latin         string            `json:"latin"`
+	EastAsian     string            `json:"east_asian"`
+	ComplexScript string            `json:"complex_script"`
+	Scripts       map[string]string `json:"scripts"`
+}
+
+// ThemeFormatScheme 主题格式方案（a:fmtScheme）
+type ThemeFormatScheme struct {
+	Name             string        `json:"name"`
+	FillStyles       []ThemeFill   `json:"fill_styles"`
+	LineStyles       []ThemeLine   `json:"line_styles"`
+	EffectStyles     []ThemeEffect `json:"effect_styles"`
+	BackgroundStyles []ThemeFill   `json:"background_styles"`
+}
+
+// ThemeFill 主题填充样式
+type ThemeFill struct {
+	Type   string   `json:"type"`   // solid, gradient, pattern, picture, none
+	Colors []string `json:"colors"` // 颜色引用，如phClr或RRGGBB
+}
+
+// ThemeLine 主题线条样式
+type ThemeLine struct {
+	Width float64   `json:"width"` // 磅
+	Dash  string    `json:"dash"`
+	Cap   string    `json:"cap"`
+	Fill  ThemeFill `json:"fill"`
+}
+
+// ThemeEffect 主题效果样式
+type ThemeEffect struct {
+	Effects []string `json:"effects"` // 效果元素名称，如outerShdw、glow
 }
 
 // ConditionalStyle 条件样式
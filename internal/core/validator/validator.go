@@ -1,7 +1,9 @@
 package validator
 
 import (
+	"context"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
 	"docs-parser/internal/formats"
 	"docs-parser/internal/utils"
 	"fmt"
@@ -24,25 +26,65 @@ func NewValidator() *Validator {
 
 // ValidateDocument 验证文档
 func (v *Validator) ValidateDocument(filePath string) (*ValidationResult, error) {
+	return v.ValidateDocumentContext(context.Background(), filePath)
+}
+
+// ValidateDocumentContext 受ctx控制的ValidateDocument，解析被中断时返回*types.TimeoutError
+func (v *Validator) ValidateDocumentContext(ctx context.Context, filePath string) (*ValidationResult, error) {
 	// 验证文件存在
 	if err := utils.ValidateFile(filePath); err != nil {
 		return nil, fmt.Errorf("file validation failed: %w", err)
 	}
 
-	// 解析文档
-	doc, err := v.wordParser.ParseDocument(filePath)
+	// 流式解析文档，逐个检查段落和表格，不保留整个正文
+	content := &contentValidation{}
+	doc, err := v.wordParser.StreamDocumentContext(ctx, filePath, func(event *documents.StreamEvent) error {
+		v.validateEvent(content, event)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse document: %w", err)
 	}
 
 	// 执行验证
-	result := v.validateDocument(doc)
+	result := v.validateDocument(doc, content)
 
 	return result, nil
 }
 
-// validateDocument 验证文档内容
-func (v *Validator) validateDocument(doc *types.Document) *ValidationResult {
+// contentValidation 逐个检查正文段落和表格时按类别收集的问题
+type contentValidation struct {
+	fontIssues      []ValidationIssue
+	paragraphIssues []ValidationIssue
+	tableIssues     []ValidationIssue
+
+	// 当前表格：ID、行数和各行的问题，行的问题在表格结束时排在表格本身的问题之后
+	tableID   string
+	rows      int
+	rowIssues []ValidationIssue
+}
+
+// validateEvent 检查一个正文事件
+func (v *Validator) validateEvent(content *contentValidation, event *documents.StreamEvent) {
+	switch event.Type {
+	case documents.EventParagraph:
+		content.fontIssues = append(content.fontIssues, v.validateFontRules(event.Paragraph)...)
+		content.paragraphIssues = append(content.paragraphIssues, v.validateParagraphRules(event.Paragraph)...)
+	case documents.EventTableStart:
+		content.tableID = event.Table.ID
+		content.rows = 0
+		content.rowIssues = nil
+	case documents.EventTableRow:
+		content.rows++
+		content.rowIssues = append(content.rowIssues, v.validateTableRow(content.tableID, event.Row)...)
+	case documents.EventTableEnd:
+		content.tableIssues = append(content.tableIssues, v.validateTableRules(event.Table, content.rows)...)
+		content.tableIssues = append(content.tableIssues, content.rowIssues...)
+	}
+}
+
+// validateDocument 验证文档内容，正文段落和表格的问题已在流式解析时收集
+func (v *Validator) validateDocument(doc *types.Document, content *contentValidation) *ValidationResult {
 	result := &ValidationResult{
 		ComplianceRate:  0.0,
 		Issues:          []ValidationIssue{},
@@ -50,16 +92,13 @@ func (v *Validator) validateDocument(doc *types.Document) *ValidationResult {
 	}
 
 	// 验证字体规则
-	fontIssues := v.validateFontRules(doc)
-	result.Issues = append(result.Issues, fontIssues...)
+	result.Issues = append(result.Issues, content.fontIssues...)
 
 	// 验证段落规则
-	paragraphIssues := v.validateParagraphRules(doc)
-	result.Issues = append(result.Issues, paragraphIssues...)
+	result.Issues = append(result.Issues, content.paragraphIssues...)
 
 	// 验证表格规则
-	tableIssues := v.validateTableRules(doc)
-	result.Issues = append(result.Issues, tableIssues...)
+	result.Issues = append(result.Issues, content.tableIssues...)
 
 	// 验证页面规则
 	pageIssues := v.validatePageRules(doc)
@@ -78,131 +117,64 @@ func (v *Validator) validateDocument(doc *types.Document) *ValidationResult {
 	return result
 }
 
-// validateFontRules 验证字体规则
-func (v *Validator) validateFontRules(doc *types.Document) []ValidationIssue {
-	var issues []ValidationIssue
-
-	for _, paragraph := range doc.Content.Paragraphs {
-		for _, run := range paragraph.Runs {
-			// 验证字体大小
-			if run.Font.Size < 10.0 {
-				issues = append(issues, ValidationIssue{
-					ID:          "font_size_minimum",
-					Type:        "font",
-					Severity:    "medium",
-					Location:    run.ID,
-					Description: "字体大小不符合最小要求",
-					Current:     run.Font.Size,
-					Expected:    ">= 10.0",
-					Rule:        "font_size_minimum",
-				})
-			}
-
-			// 验证字体名称
-			if run.Font.Name == "" {
-				issues = append(issues, ValidationIssue{
-					ID:          "font_name_required",
-					Type:        "font",
-					Severity:    "high",
-					Location:    run.ID,
-					Description: "字体名称未设置",
-					Current:     run.Font.Name,
-					Expected:    "标准字体名称",
-					Rule:        "font_name_required",
-				})
-			}
-
-			// 验证字体颜色
-			if run.Font.Color.RGB == "" {
-				issues = append(issues, ValidationIssue{
-					ID:          "font_color_required",
-					Type:        "font",
-					Severity:    "low",
-					Location:    run.ID,
-					Description: "字体颜色未设置",
-					Current:     run.Font.Color,
-					Expected:    "有效的颜色值",
-					Rule:        "font_color_required",
-				})
-			}
-
-			// 验证字体粗细
-			if run.Font.Bold && run.Font.Size < 12.0 {
-				issues = append(issues, ValidationIssue{
-					ID:          "bold_font_size",
-					Type:        "font",
-					Severity:    "low",
-					Location:    run.ID,
-					Description: "粗体字体大小过小",
-					Current:     run.Font.Size,
-					Expected:    ">= 12.0",
-					Rule:        "bold_font_size_minimum",
-				})
-			}
-		}
-	}
-
-	return issues
-}
-
-// validateParagraphRules 验证段落规则
-func (v *Validator) validateParagraphRules(doc *types.Document) []ValidationIssue {
+// validateFontRules 验证段落中各文本运行的字体规则
+func (v *Validator) validateFontRules(paragraph *types.Paragraph) []ValidationIssue {
 	var issues []ValidationIssue
 
-	for _, paragraph := range doc.Content.Paragraphs {
-		// 验证段落对齐方式
-		if paragraph.Alignment == "" {
+	for _, run := range paragraph.Runs {
+		// 验证字体大小
+		if run.Font.Size < 10.0 {
 			issues = append(issues, ValidationIssue{
-				ID:          "paragraph_alignment_required",
-				Type:        "paragraph",
+				ID:          "font_size_minimum",
+				Type:        "font",
 				Severity:    "medium",
-				Location:    paragraph.ID,
-				Description: "段落对齐方式未设置",
-				Current:     paragraph.Alignment,
-				Expected:    "left/center/right/justify",
-				Rule:        "paragraph_alignment_required",
+				Location:    run.ID,
+				Description: "字体大小不符合最小要求",
+				Current:     run.Font.Size,
+				Expected:    ">= 10.0",
+				Rule:        "font_size_minimum",
 			})
 		}
 
-		// 验证段落间距
-		if paragraph.Spacing.Before < 0 || paragraph.Spacing.After < 0 {
+		// 验证字体名称
+		if run.Font.Name == "" {
 			issues = append(issues, ValidationIssue{
-				ID:          "paragraph_spacing_valid",
-				Type:        "paragraph",
-				Severity:    "low",
-				Location:    paragraph.ID,
-				Description: "段落间距设置不当",
-				Current:     paragraph.Spacing,
-				Expected:    ">= 0",
-				Rule:        "paragraph_spacing_valid",
+				ID:          "font_name_required",
+				Type:        "font",
+				Severity:    "high",
+				Location:    run.ID,
+				Description: "字体名称未设置",
+				Current:     run.Font.Name,
+				Expected:    "标准字体名称",
+				Rule:        "font_name_required",
 			})
 		}
 
-		// 验证段落缩进
-		if paragraph.Indentation.Left < 0 || paragraph.Indentation.Right < 0 {
+		// 验证字体颜色
+		if run.Font.Color.RGB == "" {
 			issues = append(issues, ValidationIssue{
-				ID:          "paragraph_indentation_valid",
-				Type:        "paragraph",
+				ID:          "font_color_required",
+				Type:        "font",
 				Severity:    "low",
-				Location:    paragraph.ID,
-				Description: "段落缩进设置不当",
-				Current:     paragraph.Indentation,
-				Expected:    ">= 0",
-				Rule:        "paragraph_indentation_valid",
+				Location:    run.ID,
+				Description: "字体颜色未设置",
+				Current:     run.Font.Color,
+				Expected:    "有效的颜色值",
+				Rule:        "font_color_required",
 			})
 		}
 
-		// 验证段落行距
-		if paragraph.Spacing.Line < 1.0 {
+		// 验证字体粗细
+		if run.Font.Bold && run.Font.Size < 12.0 {
 			issues = append(issues, ValidationIssue{
-				ID:          "paragraph_line_spacing",
-				Type:        "paragraph",
+				ID:          "bold_font_size",
+				Type:        "font",
 				Severity:    "low",
-				Location:    paragraph.ID,
-				Description: "段落行距设置不当",
-				Current:     paragraph.Spacing.Line,
-				Expected:    ">= 1.0",
-				Rule:        "paragraph_line_spacing_minimum",
+				Location:    run.ID,
+				Description: "粗体字体大小过小",
+				Current:     run.Font.Size,
+				Expected:    ">= 12.0",
+				Rule:        "bold_font_size_minimum",
 			})
 		}
 	}
@@ -210,68 +182,134 @@ func (v *Validator) validateParagraphRules(doc *types.Document) []ValidationIssu
 	return issues
 }
 
-// validateTableRules 验证表格规则
-func (v *Validator) validateTableRules(doc *types.Document) []ValidationIssue {
+// validateParagraphRules 验证段落规则
+func (v *Validator) validateParagraphRules(paragraph *types.Paragraph) []ValidationIssue {
 	var issues []ValidationIssue
 
-	for _, table := range doc.Content.Tables {
-		// 验证表格边框
-		if table.Borders.Top.Style == "" {
-			issues = append(issues, ValidationIssue{
-				ID:          "table_border_required",
-				Type:        "table",
-				Severity:    "medium",
-				Location:    table.ID,
-				Description: "表格边框未设置",
-				Current:     table.Borders,
-				Expected:    "完整的边框设置",
-				Rule:        "table_border_required",
-			})
-		}
+	// 验证段落对齐方式
+	if paragraph.Alignment == "" {
+		issues = append(issues, ValidationIssue{
+			ID:          "paragraph_alignment_required",
+			Type:        "paragraph",
+			Severity:    "medium",
+			Location:    paragraph.ID,
+			Description: "段落对齐方式未设置",
+			Current:     paragraph.Alignment,
+			Expected:    "left/center/right/justify",
+			Rule:        "paragraph_alignment_required",
+		})
+	}
 
-		// 验证表格宽度
-		if table.Width <= 0 {
-			issues = append(issues, ValidationIssue{
-				ID:          "table_width_required",
-				Type:        "table",
-				Severity:    "low",
-				Location:    table.ID,
-				Description: "表格宽度未设置",
-				Current:     table.Width,
-				Expected:    "> 0",
-				Rule:        "table_width_required",
-			})
-		}
+	// 验证段落间距
+	if paragraph.Spacing.Before < 0 || paragraph.Spacing.After < 0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "paragraph_spacing_valid",
+			Type:        "paragraph",
+			Severity:    "low",
+			Location:    paragraph.ID,
+			Description: "段落间距设置不当",
+			Current:     paragraph.Spacing,
+			Expected:    ">= 0",
+			Rule:        "paragraph_spacing_valid",
+		})
+	}
 
-		// 验证表格行
-		if len(table.Rows) == 0 {
-			issues = append(issues, ValidationIssue{
-				ID:          "table_rows_required",
-				Type:        "table",
-				Severity:    "high",
-				Location:    table.ID,
-				Description: "表格没有行",
-				Current:     len(table.Rows),
-				Expected:    "> 0",
-				Rule:        "table_rows_required",
-			})
-		}
+	// 验证段落缩进
+	if paragraph.Indentation.Left < 0 || paragraph.Indentation.Right < 0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "paragraph_indentation_valid",
+			Type:        "paragraph",
+			Severity:    "low",
+			Location:    paragraph.ID,
+			Description: "段落缩进设置不当",
+			Current:     paragraph.Indentation,
+			Expected:    ">= 0",
+			Rule:        "paragraph_indentation_valid",
+		})
+	}
 
-		// 验证表格单元格
-		for _, row := range table.Rows {
-			if len(row.Cells) == 0 {
-				issues = append(issues, ValidationIssue{
-					ID:          "table_cells_required",
-					Type:        "table",
-					Severity:    "high",
-					Location:    fmt.Sprintf("%s_row_%s", table.ID, row.ID),
-					Description: "表格行没有单元格",
-					Current:     len(row.Cells),
-					Expected:    "> 0",
-					Rule:        "table_cells_required",
-				})
-			}
-		}
+	// 验证段落行距
+	if paragraph.Spacing.Line < 1.0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "paragraph_line_spacing",
+			Type:        "paragraph",
+			Severity:    "low",
+			Location:    paragraph.ID,
+			Description: "段落行距设置不当",
+			Current:     paragraph.Spacing.Line,
+			Expected:    ">= 1.0",
+			Rule:        "paragraph_line_spacing_minimum",
+		})
+	}
+
+	return issues
+}
+
+// validateTableRules 验证表格规则，rows为表格的行数
+func (v *Validator) validateTableRules(table *types.Table, rows int) []ValidationIssue {
+	var issues []ValidationIssue
+
+	// 验证表格边框
+	if table.Borders.Top.Style == "" {
+		issues = append(issues, ValidationIssue{
+			ID:          "table_border_required",
+			Type:        "table",
+			Severity:    "medium",
+			Location:    table.ID,
+			Description: "表格边框未设置",
+			Current:     table.Borders,
+			Expected:    "完整的边框设置",
+			Rule:        "table_border_required",
+		})
+	}
+
+	// 验证表格宽度
+	if table.Width <= 0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "table_width_required",
+			Type:        "table",
+			Severity:    "low",
+			Location:    table.ID,
+			Description: "表格宽度未设置",
+			Current:     table.Width,
+			Expected:    "> 0",
+			Rule:        "table_width_required",
+		})
+	}
+
+	// 验证表格行
+	if rows == 0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "table_rows_required",
+			Type:        "table",
+			Severity:    "high",
+			Location:    table.ID,
+			Description: "表格没有行",
+			Current:     rows,
+			Expected:    "> 0",
+			Rule:        "table_rows_required",
+		})
+	}
+
+
+	return issues
+}
+
+// validateTableRow 验证表格行的单元格
+func (v *Validator) validateTableRow(tableID string, row *types.TableRow) []ValidationIssue {
+	var issues []ValidationIssue
+
+	if len(row.Cells) == 0 {
+		issues = append(issues, ValidationIssue{
+			ID:          "table_cells_required",
+			Type:        "table",
+			Severity:    "high",
+			Location:    fmt.Sprintf("%s_row_%s", tableID, row.ID),
+			Description: "表格行没有单元格",
+			Current:     len(row.Cells),
+			Expected:    "> 0",
+			Rule:        "table_cells_required",
+		})
 	}
 
 	return issues
@@ -0,0 +1,245 @@
+package documents
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// 批注相关部件
+const (
+	CommentsPart         = "word/comments.xml"
+	CommentsExtendedPart = "word/commentsExtended.xml"
+	CommentsIdsPart      = "word/commentsIds.xml"
+)
+
+// parseComments 解析批注及其在正文中的锚定范围
+func (wd *WordprocessingDocument) parseComments(doc *types.Document) error {
+	if !wd.Container.HasFile(CommentsPart) {
+		return nil
+	}
+
+	parts := make(map[string][]byte)
+	for _, name := range []string{CommentsPart, CommentsExtendedPart, CommentsIdsPart} {
+		if !wd.Container.HasFile(name) {
+			continue
+		}
+		content, err := wd.Container.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		parts[name] = content
+	}
+
+	comments, err := ParseComments(parts[CommentsPart], parts[CommentsExtendedPart], parts[CommentsIdsPart])
+	if err != nil {
+		return err
+	}
+
+	if _, exists := wd.Parts["document.xml"]; exists {
+		rc, err := wd.openMainDocument()
+		if err != nil {
+			return err
+		}
+		anchors, err := parseCommentAnchors(bufio.NewReader(rc))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse comment anchors: %w", err)
+		}
+		for i := range comments {
+			comments[i].Anchor = anchors[comments[i].ID]
+		}
+	}
+
+	doc.Content.Comments = comments
+	return nil
+}
+
+// ParseComments 解析comments.xml，并用commentsExtended.xml和commentsIds.xml补充回复关系、解决状态和持久ID
+//
+// extended和ids可以为nil（Word 2013之前的文档没有这些部件）。
+func ParseComments(comments, extended, ids []byte) ([]types.Comment, error) {
+	var commentsXML struct {
+		Comments []struct {
+			ID         string         `xml:"id,attr"`
+			Author     string         `xml:"author,attr"`
+			Initials   string         `xml:"initials,attr"`
+			Date       string         `xml:"date,attr"`
+			Paragraphs []xmlParagraph `xml:"p"`
+		} `xml:"comment"`
+	}
+	if err := xml.Unmarshal(comments, &commentsXML); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal comments: %w", err)
+	}
+
+	result := make([]types.Comment, 0, len(commentsXML.Comments))
+	byParaID := make(map[string]int)
+	for _, c := range commentsXML.Comments {
+		comment := types.Comment{
+			ID:       c.ID,
+			Author:   c.Author,
+			Initials: c.Initials,
+			Date:     parseRevisionDate(c.Date),
+			Replies:  []string{},
+		}
+
+		var lines []string
+		for i := range c.Paragraphs {
+			paragraph := convertParagraph(&c.Paragraphs[i], "", func(int) string { return "" }, nil)
+			lines = append(lines, paragraph.Text)
+			if c.Paragraphs[i].ParaID != "" {
+				comment.ParaID = c.Paragraphs[i].ParaID
+			}
+		}
+		comment.Text = strings.Join(lines, "\n")
+
+		if comment.ParaID != "" {
+			byParaID[comment.ParaID] = len(result)
+		}
+		result = append(result, comment)
+	}
+
+	// commentsExtended.xml：回复关系和解决状态
+	if len(extended) > 0 {
+		var extendedXML struct {
+			Comments []struct {
+				ParaID       string `xml:"paraId,attr"`
+				ParaIDParent string `xml:"paraIdParent,attr"`
+				Done         string `xml:"done,attr"`
+			} `xml:"commentEx"`
+		}
+		if err := xml.Unmarshal(extended, &extendedXML); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal comments extended: %w", err)
+		}
+		for _, ex := range extendedXML.Comments {
+			index, exists := byParaID[ex.ParaID]
+			if !exists {
+				continue
+			}
+			result[index].Done = ex.Done == "1" || ex.Done == "true"
+			if parent, exists := byParaID[ex.ParaIDParent]; exists && ex.ParaIDParent != "" {
+				result[index].ParentID = result[parent].ID
+				result[parent].Replies = append(result[parent].Replies, result[index].ID)
+			}
+		}
+	}
+
+	// commentsIds.xml：持久ID
+	if len(ids) > 0 {
+		var idsXML struct {
+			Comments []struct {
+				ParaID    string `xml:"paraId,attr"`
+				DurableID string `xml:"durableId,attr"`
+			} `xml:"commentId"`
+		}
+		if err := xml.Unmarshal(ids, &idsXML); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal comment ids: %w", err)
+		}
+		for _, id := range idsXML.Comments {
+			if index, exists := byParaID[id.ParaID]; exists {
+				result[index].DurableID = id.DurableID
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// parseCommentAnchors 从document.xml中读取每个批注的锚定段落和范围文本
+func parseCommentAnchors(r io.Reader) (map[string]types.CommentAnchor, error) {
+	anchors := make(map[string]types.CommentAnchor)
+	active := make(map[string]*strings.Builder)
+
+	decoder := xml.NewDecoder(r)
+	var stack []string
+	paragraph := 0
+	bodyParagraph := 0
+	inText := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			stack = append(stack, t.Name.Local)
+
+			switch t.Name.Local {
+			case "p":
+				if parent == "body" {
+					bodyParagraph++
+					paragraph = bodyParagraph
+				}
+			case "t":
+				inText = true
+			case "commentRangeStart":
+				id := attrLocal(t.Attr, "id")
+				anchor := anchors[id]
+				anchor.Paragraph = paragraph
+				anchors[id] = anchor
+				active[id] = &strings.Builder{}
+			case "commentRangeEnd":
+				id := attrLocal(t.Attr, "id")
+				anchor := anchors[id]
+				anchor.EndParagraph = paragraph
+				if text, exists := active[id]; exists {
+					anchor.Text = strings.TrimSuffix(text.String(), "\n")
+					delete(active, id)
+				}
+				anchors[id] = anchor
+			case "commentReference":
+				// 没有范围标记的批注锚定在引用所在段落
+				id := attrLocal(t.Attr, "id")
+				if _, exists := anchors[id]; !exists {
+					anchors[id] = types.CommentAnchor{Paragraph: paragraph, EndParagraph: paragraph}
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				for _, text := range active {
+					text.WriteString("\n")
+				}
+				if len(stack) > 0 && stack[len(stack)-1] == "body" {
+					paragraph = 0
+				}
+			}
+		case xml.CharData:
+			if inText {
+				for _, text := range active {
+					text.Write(t)
+				}
+			}
+		}
+	}
+
+	return anchors, nil
+}
+
+// attrLocal 按本地名称读取属性值
+func attrLocal(attrs []xml.Attr, local string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,363 @@
+package documents
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"docs-parser/internal/core/styles"
+	"docs-parser/internal/core/types"
+)
+
+// xmlRevisionAttrs 修订标记的公共属性（w:id、w:author、w:date）
+type xmlRevisionAttrs struct {
+	ID     string `xml:"id,attr"`
+	Author string `xml:"author,attr"`
+	Date   string `xml:"date,attr"`
+}
+
+// xmlRunProperties 文本运行属性（w:rPr）
+type xmlRunProperties struct {
+	Font struct {
+		Val string `xml:"val,attr"`
+	} `xml:"rFonts"`
+	Size struct {
+		Val string `xml:"val,attr"`
+	} `xml:"sz"`
+	Bold      bool `xml:"b"`
+	Italic    bool `xml:"i"`
+	Underline struct {
+		Val string `xml:"val,attr"`
+	} `xml:"u"`
+	Color  xmlColor              `xml:"color"`
+	Change *xmlRunPropertyChange `xml:"rPrChange"`
+}
+
+// xmlColor 颜色（w:color），可引用主题颜色并按tint/shade调整
+type xmlColor struct {
+	Val        string `xml:"val,attr"`
+	ThemeColor string `xml:"themeColor,attr"`
+	ThemeTint  string `xml:"themeTint,attr"`
+	ThemeShade string `xml:"themeShade,attr"`
+}
+
+// resolve 返回颜色的RGB值，主题颜色优先，无法解析时回退到val
+func (c *xmlColor) resolve(theme *types.ThemeStyle) string {
+	if c.ThemeColor != "" {
+		if rgb, ok := styles.ResolveThemeColor(theme, c.ThemeColor, c.ThemeTint, c.ThemeShade); ok {
+			return rgb
+		}
+	}
+	return c.Val
+}
+
+// xmlRunPropertyChange 字符格式修订（w:rPrChange），内含修改前的w:rPr
+type xmlRunPropertyChange struct {
+	xmlRevisionAttrs
+	Properties xmlRunProperties `xml:"rPr"`
+}
+
+// xmlParagraphProperties 段落属性（w:pPr）
+type xmlParagraphProperties struct {
+	Style struct {
+		Val string `xml:"val,attr"`
+	} `xml:"pStyle"`
+	Justification struct {
+		Val string `xml:"val,attr"`
+	} `xml:"jc"`
+	Indentation struct {
+		Left    string `xml:"left,attr"`
+		Right   string `xml:"right,attr"`
+		First   string `xml:"firstLine,attr"`
+		Hanging string `xml:"hanging,attr"`
+	} `xml:"ind"`
+	Spacing struct {
+		Before string `xml:"before,attr"`
+		After  string `xml:"after,attr"`
+		Line   string `xml:"line,attr"`
+	} `xml:"spacing"`
+	// 段落标记的修订记录在pPr/rPr中
+	Mark struct {
+		Inserted *xmlRevisionAttrs `xml:"ins"`
+		Deleted  *xmlRevisionAttrs `xml:"del"`
+	} `xml:"rPr"`
+	Change *xmlParagraphPropertyChange `xml:"pPrChange"`
+	// 分节符：节属性位于节最后一个段落的pPr中
+	Section *xmlSectionProperties `xml:"sectPr"`
+}
+
+// xmlParagraphPropertyChange 段落格式修订（w:pPrChange），内含修改前的w:pPr
+type xmlParagraphPropertyChange struct {
+	xmlRevisionAttrs
+	Properties xmlParagraphProperties `xml:"pPr"`
+}
+
+// xmlRun 文本运行（w:r），Revision为其所在的插入/删除/移动修订
+type xmlRun struct {
+	Properties  xmlRunProperties `xml:"rPr"`
+	Texts       []string         `xml:"t"`
+	DeletedText []string         `xml:"delText"`
+	Revision    *types.Revision  `xml:"-"`
+}
+
+// Text 返回文本运行的文本，删除修订中的文本位于w:delText
+func (r *xmlRun) Text() string {
+	return strings.Join(r.Texts, "") + strings.Join(r.DeletedText, "")
+}
+
+// xmlParagraph 段落（w:p），按文档顺序收集修订标记内外的文本运行
+type xmlParagraph struct {
+	ParaID     string
+	Properties xmlParagraphProperties
+	Runs       []xmlRun
+}
+
+// revisionContainers 需要向下查找文本运行的行内容器
+var revisionContainers = map[string]bool{
+	"hyperlink":  true,
+	"smartTag":   true,
+	"sdt":        true,
+	"sdtContent": true,
+	"fldSimple":  true,
+	"customXml":  true,
+}
+
+// revisionElementTypes 行内修订元素对应的修订类型
+var revisionElementTypes = map[string]types.RevisionType{
+	"ins":      types.RevisionInsert,
+	"del":      types.RevisionDelete,
+	"moveFrom": types.RevisionMoveFrom,
+	"moveTo":   types.RevisionMoveTo,
+}
+
+// UnmarshalXML 实现xml.Unmarshaler
+func (p *xmlParagraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "paraId" {
+			p.ParaID = attr.Value
+		}
+	}
+	return p.collect(d, nil)
+}
+
+// collect 读取到当前元素结束，revision为外层修订标记
+func (p *xmlParagraph) collect(d *xml.Decoder, revision *types.Revision) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "pPr":
+				if err := d.DecodeElement(&p.Properties, &t); err != nil {
+					return err
+				}
+			case t.Name.Local == "r":
+				var run xmlRun
+				if err := d.DecodeElement(&run, &t); err != nil {
+					return err
+				}
+				run.Revision = revision
+				p.Runs = append(p.Runs, run)
+			case revisionElementTypes[t.Name.Local] != "":
+				if err := p.collect(d, newRevision(t.Attr, revisionElementTypes[t.Name.Local])); err != nil {
+					return err
+				}
+			case revisionContainers[t.Name.Local]:
+				if err := p.collect(d, revision); err != nil {
+					return err
+				}
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// newRevision 从修订元素的属性创建修订信息
+func newRevision(attrs []xml.Attr, revisionType types.RevisionType) *types.Revision {
+	revision := &types.Revision{Type: revisionType}
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "id":
+			revision.ID = attr.Value
+		case "author":
+			revision.Author = attr.Value
+		case "date":
+			revision.Date = parseRevisionDate(attr.Value)
+		}
+	}
+	return revision
+}
+
+// toRevision 将修订属性转换为修订信息
+func (a *xmlRevisionAttrs) toRevision(revisionType types.RevisionType) types.Revision {
+	return types.Revision{
+		ID:     a.ID,
+		Type:   revisionType,
+		Author: a.Author,
+		Date:   parseRevisionDate(a.Date),
+	}
+}
+
+// parseRevisionDate 解析修订日期，Word写入的是不带时区的ISO 8601时间
+func parseRevisionDate(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// convertRun 将w:r转换为文本运行，theme用于解析主题颜色，可以为nil
+func convertRun(r *xmlRun, id string, theme *types.ThemeStyle) types.TextRun {
+	run := types.TextRun{
+		ID:       id,
+		Text:     r.Text(),
+		Revision: r.Revision,
+	}
+	applyRunProperties(&run, r.Properties.toRunProperties(theme))
+
+	if change := r.Properties.Change; change != nil {
+		run.PropertyChange = &types.RunPropertyChange{
+			Revision: change.toRevision(types.RevisionRunProperties),
+			Original: change.Properties.toRunProperties(theme),
+		}
+	}
+
+	return run
+}
+
+// applyRunProperties 将格式属性写入文本运行
+func applyRunProperties(run *types.TextRun, props types.RunProperties) {
+	run.Bold = props.Bold
+	run.Italic = props.Italic
+	run.Underline = props.Underline
+	run.Font.Name = props.Font.Name
+	run.Font.Size = props.Size
+	run.Size = props.Size
+	run.Font.Color = props.Color
+	run.Color = props.Color
+}
+
+// toRunProperties 解析字体、字号、颜色等格式属性
+func (rp *xmlRunProperties) toRunProperties(theme *types.ThemeStyle) types.RunProperties {
+	props := types.RunProperties{
+		Bold:      rp.Bold,
+		Italic:    rp.Italic,
+		Underline: types.Underline(rp.Underline.Val),
+	}
+
+	// 解析内联字体信息
+	if rp.Font.Val != "" {
+		props.Font.Name = rp.Font.Val
+	}
+
+	// 解析内联字体大小
+	if rp.Size.Val != "" {
+		if sz, err := strconv.ParseFloat(rp.Size.Val, 64); err == nil {
+			props.Font.Size = sz / 2.0
+			props.Size = sz / 2.0
+		}
+	}
+
+	// 解析内联颜色
+	if color := rp.Color.resolve(theme); color != "" {
+		props.Font.Color.RGB = color
+		props.Color.RGB = color
+	}
+
+	return props
+}
+
+// applyParagraphProperties 将段落属性及其修订写入段落
+func applyParagraphProperties(paragraph *types.Paragraph, pp *xmlParagraphProperties) {
+	props := pp.toParagraphProperties()
+	paragraph.Style.Name = props.StyleName
+	paragraph.Alignment = props.Alignment
+	paragraph.Indentation = props.Indentation
+	paragraph.Spacing = props.Spacing
+
+	if change := pp.Change; change != nil {
+		paragraph.PropertyChange = &types.ParagraphPropertyChange{
+			Revision: change.toRevision(types.RevisionParagraphProps),
+			Original: change.Properties.toParagraphProperties(),
+		}
+	}
+
+	if mark := pp.Mark.Inserted; mark != nil {
+		revision := mark.toRevision(types.RevisionParagraphInsert)
+		paragraph.MarkRevision = &revision
+	}
+	if mark := pp.Mark.Deleted; mark != nil {
+		revision := mark.toRevision(types.RevisionParagraphDelete)
+		paragraph.MarkRevision = &revision
+	}
+}
+
+// toParagraphProperties 解析样式、对齐、缩进和间距
+func (pp *xmlParagraphProperties) toParagraphProperties() types.ParagraphProperties {
+	props := types.ParagraphProperties{
+		StyleName: pp.Style.Val,
+	}
+
+	// 解析对齐方式
+	if pp.Justification.Val != "" {
+		props.Alignment = types.Alignment(pp.Justification.Val)
+	}
+
+	// 解析缩进
+	props.Indentation.Left = parseTwips(pp.Indentation.Left)
+	props.Indentation.Right = parseTwips(pp.Indentation.Right)
+	props.Indentation.First = parseTwips(pp.Indentation.First)
+
+	// 解析间距
+	props.Spacing.Before = parseTwips(pp.Spacing.Before)
+	props.Spacing.After = parseTwips(pp.Spacing.After)
+	if pp.Spacing.Line != "" {
+		if val, err := strconv.ParseFloat(pp.Spacing.Line, 64); err == nil {
+			props.Spacing.Line = val / 240.0
+		}
+	}
+
+	return props
+}
+
+// parseTwips 将缇（1/20磅）转换为磅，空值或无效值返回0
+func parseTwips(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	val, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return val / 20.0
+}
+
+// convertParagraph 将w:p转换为段落，段落文本为接受全部修订后的文本
+func convertParagraph(p *xmlParagraph, id string, runID func(j int) string, theme *types.ThemeStyle) types.Paragraph {
+	paragraph := types.Paragraph{ID: id}
+	applyParagraphProperties(&paragraph, &p.Properties)
+
+	var paragraphText strings.Builder
+	for j := range p.Runs {
+		run := convertRun(&p.Runs[j], runID(j), theme)
+		paragraph.Runs = append(paragraph.Runs, run)
+		if !run.Revision.IsRemovedWhenAccepted() {
+			paragraphText.WriteString(run.Text)
+		}
+	}
+	paragraph.Text = paragraphText.String()
+
+	return paragraph
+}
@@ -0,0 +1,475 @@
+package documents
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"docs-parser/internal/core/styles"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging"
+)
+
+// StreamEventType 流式解析事件类型
+type StreamEventType string
+
+const (
+	EventParagraph  StreamEventType = "paragraph"   // 正文段落，包含全部文本运行
+	EventRun        StreamEventType = "run"         // 文本运行，紧随所属段落事件之后
+	EventTableStart StreamEventType = "table_start" // 表格开始，包含表格属性但不包含行
+	EventTableRow   StreamEventType = "table_row"   // 表格行
+	EventTableEnd   StreamEventType = "table_end"   // 表格结束
+	EventSection    StreamEventType = "section"     // 节结束：段落中的分节符或正文末尾的节属性
+)
+
+// StreamEvent 流式解析事件，只有与Type对应的字段有值
+//
+// Index为从1开始的序号：段落、表格和节在正文中计数，行在所属表格中计数，文本运行在所属段落中计数。
+// 段落和表格的ID与WordprocessingDocument.Parse的结果一致。
+type StreamEvent struct {
+	Type      StreamEventType  `json:"type"`
+	Index     int              `json:"index"`
+	Offset    int64            `json:"offset"` // 事件元素结束处在document.xml中的字节偏移
+	Paragraph *types.Paragraph `json:"paragraph,omitempty"`
+	Run       *types.TextRun   `json:"run,omitempty"`
+	Table     *types.Table     `json:"table,omitempty"`
+	Row       *types.TableRow  `json:"row,omitempty"`
+	Section   *types.Section   `json:"section,omitempty"`
+}
+
+// ErrStopStream 由回调返回以提前结束遍历，WalkDocument和StreamDocument不会把它作为错误返回
+var ErrStopStream = errors.New("stop stream")
+
+// xmlSectionProperties 节属性（w:sectPr）
+type xmlSectionProperties struct {
+	PageSize struct {
+		Width  string `xml:"w,attr"`
+		Height string `xml:"h,attr"`
+	} `xml:"pgSz"`
+	PageMargins struct {
+		Top    string `xml:"top,attr"`
+		Bottom string `xml:"bottom,attr"`
+		Left   string `xml:"left,attr"`
+		Right  string `xml:"right,attr"`
+		Header string `xml:"header,attr"`
+		Footer string `xml:"footer,attr"`
+	} `xml:"pgMar"`
+	Columns struct {
+		Num        string `xml:"num,attr"`
+		Space      string `xml:"space,attr"`
+		EqualWidth string `xml:"equalWidth,attr"`
+	} `xml:"cols"`
+	PageNumbering struct {
+		Start  string `xml:"start,attr"`
+		Format string `xml:"fmt,attr"`
+	} `xml:"pgNumType"`
+	LineNumbering struct {
+		CountBy string `xml:"countBy,attr"`
+		Start   string `xml:"start,attr"`
+		Restart string `xml:"restart,attr"`
+	} `xml:"lnNumType"`
+}
+
+// toSection 将节属性转换为节，长度单位为磅
+func (sp *xmlSectionProperties) toSection(id string) types.Section {
+	section := types.Section{
+		ID: id,
+		PageSize: types.PageSize{
+			Width:  parseTwips(sp.PageSize.Width),
+			Height: parseTwips(sp.PageSize.Height),
+		},
+		PageMargins: types.PageMargins{
+			Top:    parseTwips(sp.PageMargins.Top),
+			Bottom: parseTwips(sp.PageMargins.Bottom),
+			Left:   parseTwips(sp.PageMargins.Left),
+			Right:  parseTwips(sp.PageMargins.Right),
+			Header: parseTwips(sp.PageMargins.Header),
+			Footer: parseTwips(sp.PageMargins.Footer),
+		},
+		HeaderDistance: parseTwips(sp.PageMargins.Header),
+		FooterDistance: parseTwips(sp.PageMargins.Footer),
+		Columns: types.Columns{
+			Count:   1,
+			Spacing: parseTwips(sp.Columns.Space),
+			Equal:   sp.Columns.EqualWidth != "0" && sp.Columns.EqualWidth != "false",
+		},
+		PageNumbering: types.PageNumbering{
+			Format: sp.PageNumbering.Format,
+		},
+		LineNumbering: types.LineNumbering{
+			Restart: sp.LineNumbering.Restart != "" && sp.LineNumbering.Restart != "continuous",
+		},
+	}
+
+	if n, err := strconv.Atoi(sp.Columns.Num); err == nil && n > 0 {
+		section.Columns.Count = n
+	}
+	if n, err := strconv.Atoi(sp.PageNumbering.Start); err == nil {
+		section.PageNumbering.Start = n
+		section.PageNumbering.Restart = true
+	}
+	if n, err := strconv.Atoi(sp.LineNumbering.CountBy); err == nil {
+		section.LineNumbering.Increment = n
+	}
+	if n, err := strconv.Atoi(sp.LineNumbering.Start); err == nil {
+		section.LineNumbering.Start = n
+	}
+
+	return section
+}
+
+// StreamReader 按文档顺序逐个读取document.xml正文中的事件
+//
+// 读取器每次只解码一个段落或一个表格行，内存占用与文档大小无关。
+// 正文中除段落、表格和节属性以外的元素（如书签、结构化文档标记）被跳过，与Parse的行为一致。
+type StreamReader struct {
+	ctx     context.Context
+	decoder *xml.Decoder
+	theme   *types.ThemeStyle
+	pending []*StreamEvent
+	inBody  bool
+	tokens  int
+
+	// 当前表格，tableStarted表示表格开始事件是否已发出
+	table        *types.Table
+	tableStarted bool
+	tableRows    int
+
+	paragraphs int
+	tables     int
+	sections   int
+}
+
+// NewStreamReader 创建流式读取器，theme用于解析主题颜色，可以为nil
+func NewStreamReader(r io.Reader, theme *types.ThemeStyle) *StreamReader {
+	return NewStreamReaderContext(context.Background(), r, theme)
+}
+
+// NewStreamReaderContext 创建受ctx控制的流式读取器，ctx结束后Next返回*types.TimeoutError
+func NewStreamReaderContext(ctx context.Context, r io.Reader, theme *types.ThemeStyle) *StreamReader {
+	return &StreamReader{
+		ctx:     ctx,
+		decoder: xml.NewDecoder(r),
+		theme:   theme,
+	}
+}
+
+// contextCheckInterval 每读取多少个XML标记检查一次ctx
+const contextCheckInterval = 256
+
+// Next 返回下一个事件，读取完毕时返回io.EOF
+func (sr *StreamReader) Next() (*StreamEvent, error) {
+	for len(sr.pending) == 0 {
+		sr.tokens++
+		if sr.tokens%contextCheckInterval == 0 {
+			if err := types.CheckContext(sr.ctx, "decode document.xml", ""); err != nil {
+				return nil, err
+			}
+		}
+
+		token, err := sr.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if err := sr.start(t); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			sr.end(t)
+		}
+	}
+
+	event := sr.pending[0]
+	sr.pending[0] = nil
+	sr.pending = sr.pending[1:]
+	return event, nil
+}
+
+// start 处理开始标签，段落、表格行和节属性整体解码后生成事件
+func (sr *StreamReader) start(t xml.StartElement) error {
+	switch {
+	case sr.table != nil:
+		switch t.Name.Local {
+		case "tblPr":
+			var props xmlTableProperties
+			if err := sr.decoder.DecodeElement(&props, &t); err != nil {
+				return fmt.Errorf("failed to decode table properties: %w", err)
+			}
+			applyTableProperties(sr.table, &props)
+		case "tr":
+			var row xmlTableRow
+			if err := sr.decoder.DecodeElement(&row, &t); err != nil {
+				return fmt.Errorf("failed to decode table row: %w", err)
+			}
+			sr.startTable()
+			sr.tableRows++
+			tableRow := convertTableRow(&row, sr.tables, sr.tableRows, sr.theme)
+			sr.emit(&StreamEvent{Type: EventTableRow, Index: sr.tableRows, Row: &tableRow})
+		default:
+			return sr.decoder.Skip()
+		}
+
+	case sr.inBody:
+		switch t.Name.Local {
+		case "p":
+			var p xmlParagraph
+			if err := sr.decoder.DecodeElement(&p, &t); err != nil {
+				return fmt.Errorf("failed to decode paragraph: %w", err)
+			}
+			sr.paragraphs++
+			i := sr.paragraphs
+			paragraph := convertParagraph(&p, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+				return fmt.Sprintf("run_%d_%d", i, j+1)
+			}, sr.theme)
+			sr.emit(&StreamEvent{Type: EventParagraph, Index: i, Paragraph: &paragraph})
+			for j := range paragraph.Runs {
+				sr.emit(&StreamEvent{Type: EventRun, Index: j + 1, Run: &paragraph.Runs[j]})
+			}
+			if p.Properties.Section != nil {
+				sr.emitSection(p.Properties.Section)
+			}
+		case "tbl":
+			sr.tables++
+			sr.table = &types.Table{ID: fmt.Sprintf("table_%d", sr.tables)}
+			sr.tableStarted = false
+			sr.tableRows = 0
+		case "sectPr":
+			var props xmlSectionProperties
+			if err := sr.decoder.DecodeElement(&props, &t); err != nil {
+				return fmt.Errorf("failed to decode section properties: %w", err)
+			}
+			sr.emitSection(&props)
+		default:
+			return sr.decoder.Skip()
+		}
+
+	case t.Name.Local == "body":
+		sr.inBody = true
+	}
+
+	return nil
+}
+
+// end 处理结束标签
+func (sr *StreamReader) end(t xml.EndElement) {
+	switch {
+	case sr.table != nil && t.Name.Local == "tbl":
+		sr.startTable()
+		sr.emit(&StreamEvent{Type: EventTableEnd, Index: sr.tables, Table: sr.table})
+		sr.table = nil
+	case t.Name.Local == "body":
+		sr.inBody = false
+	}
+}
+
+// startTable 在第一行之前（或空表格结束时）发出表格开始事件，此时表格属性已解析
+func (sr *StreamReader) startTable() {
+	if sr.tableStarted {
+		return
+	}
+	sr.tableStarted = true
+	sr.emit(&StreamEvent{Type: EventTableStart, Index: sr.tables, Table: sr.table})
+}
+
+// emitSection 发出节事件
+func (sr *StreamReader) emitSection(props *xmlSectionProperties) {
+	sr.sections++
+	section := props.toSection(fmt.Sprintf("section_%d", sr.sections))
+	sr.emit(&StreamEvent{Type: EventSection, Index: sr.sections, Section: &section})
+}
+
+// emit 将事件加入待读取队列
+func (sr *StreamReader) emit(event *StreamEvent) {
+	event.Offset = sr.decoder.InputOffset()
+	sr.pending = append(sr.pending, event)
+}
+
+// WalkDocument 遍历document.xml中的事件并依次调用fn，fn返回ErrStopStream时提前结束
+func WalkDocument(r io.Reader, theme *types.ThemeStyle, fn func(*StreamEvent) error) error {
+	return WalkDocumentContext(context.Background(), r, theme, fn)
+}
+
+// WalkDocumentContext 受ctx控制的WalkDocument，ctx结束时返回*types.TimeoutError，
+// 此前的事件已全部交给fn处理
+func WalkDocumentContext(ctx context.Context, r io.Reader, theme *types.ThemeStyle, fn func(*StreamEvent) error) error {
+	reader := NewStreamReaderContext(ctx, r, theme)
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// StreamDocument 流式遍历DOCX文件的正文，document.xml直接从压缩包中解压读取，不整体载入内存
+func StreamDocument(path string, fn func(*StreamEvent) error) error {
+	return StreamDocumentContext(context.Background(), path, fn)
+}
+
+// StreamDocumentContext 受ctx控制的StreamDocument
+func StreamDocumentContext(ctx context.Context, path string, fn func(*StreamEvent) error) error {
+	container := packaging.NewOPCContainer(path)
+	if err := container.Open(); err != nil {
+		return fmt.Errorf("failed to open OPC container: %w", err)
+	}
+	defer container.Close()
+
+	// 主题很小，整体读取
+	var theme *types.ThemeStyle
+	if container.HasFile("word/theme/theme1.xml") {
+		content, err := container.ReadFile("word/theme/theme1.xml")
+		if err != nil {
+			return fmt.Errorf("failed to read theme: %w", err)
+		}
+		theme, _ = styles.ParseTheme(bytes.NewReader(content), "theme1")
+	}
+
+	mainPart, _ := container.MainPart()
+	file, err := container.GetFile(mainPart)
+	if err != nil {
+		return fmt.Errorf("failed to find main document: %w", err)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open main document: %w", err)
+	}
+	defer rc.Close()
+
+	err = WalkDocumentContext(ctx, bufio.NewReader(rc), theme, fn)
+	if timeoutErr, ok := types.AsTimeoutError(err); ok {
+		timeoutErr.Path = path
+	}
+	return err
+}
+
+// StreamContext 流式解析文档：先解析元数据和样式，再把正文事件依次交给fn，最后解析批注和格式规则
+//
+// 段落事件中的段落已应用段落样式，与ParseContext的结果一致。返回的文档包含元数据、批注、样式和格式规则，
+// 但不保留正文段落和表格，格式规则中也没有逐段的段落规则，内存占用与正文大小无关。
+// fn返回ErrStopStream时提前结束正文遍历，此时字体规则只反映已读取的部分。
+func (wd *WordprocessingDocument) StreamContext(ctx context.Context, fn func(*StreamEvent) error) (*types.Document, error) {
+	doc := &types.Document{}
+	if err := wd.parseMetadata(doc); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+
+	doc.Styles = types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+		Theme:           wd.Theme,
+	}
+	// 没有styles.xml时与ParseContext一样按正文中使用的样式名生成空样式，段落样式的属性为零值
+	stylesErr := wd.parseStylesXML(doc)
+	styleMap := make(map[string]*types.ParagraphStyle)
+	for i := range doc.Styles.ParagraphStyles {
+		styleMap[doc.Styles.ParagraphStyles[i].ID] = &doc.Styles.ParagraphStyles[i]
+	}
+	usedStyles := make(map[string]bool)
+	usedFonts := make(inlineFonts)
+
+	err := wd.walkMainDocument(ctx, func(event *StreamEvent) error {
+		if event.Type == EventParagraph {
+			paragraph := event.Paragraph
+			if stylesErr != nil {
+				collectInlineStyles(paragraph, usedStyles)
+				if paragraph.Style.Name != "" {
+					applyParagraphStyle(paragraph, &types.ParagraphStyle{})
+				}
+			} else if style, exists := styleMap[paragraph.Style.Name]; exists && paragraph.Style.Name != "" {
+				applyParagraphStyle(paragraph, style)
+			}
+			for _, run := range paragraph.Runs {
+				usedFonts.add(run)
+			}
+		}
+		return fn(event)
+	})
+	if timeoutErr, ok := types.AsTimeoutError(err); ok {
+		timeoutErr.Path = wd.Container.Path
+		return doc, timeoutErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	if err := wd.parseComments(doc); err != nil {
+		return nil, fmt.Errorf("failed to parse content: failed to parse comments: %w", err)
+	}
+	if stylesErr != nil {
+		addInlineStyles(doc, usedStyles)
+	}
+	wd.addFontRules(doc, usedFonts)
+	if err := wd.extractPageRules(doc); err != nil {
+		return nil, fmt.Errorf("failed to parse format rules: %w", err)
+	}
+
+	wd.Document = doc
+	return doc, nil
+}
+
+// WalkParsedDocument 按事件遍历已解析的文档，用于没有流式读取器的格式
+//
+// 事件顺序为全部段落（各自后跟文本运行）、全部表格、全部节，Offset为0。fn返回ErrStopStream时提前结束。
+func WalkParsedDocument(doc *types.Document, fn func(*StreamEvent) error) error {
+	err := walkParsedDocument(doc, fn)
+	if errors.Is(err, ErrStopStream) {
+		return nil
+	}
+	return err
+}
+
+func walkParsedDocument(doc *types.Document, fn func(*StreamEvent) error) error {
+	for i := range doc.Content.Paragraphs {
+		paragraph := &doc.Content.Paragraphs[i]
+		if err := fn(&StreamEvent{Type: EventParagraph, Index: i + 1, Paragraph: paragraph}); err != nil {
+			return err
+		}
+		for j := range paragraph.Runs {
+			if err := fn(&StreamEvent{Type: EventRun, Index: j + 1, Run: &paragraph.Runs[j]}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range doc.Content.Tables {
+		table := doc.Content.Tables[i]
+		rows := table.Rows
+		table.Rows = nil
+		if err := fn(&StreamEvent{Type: EventTableStart, Index: i + 1, Table: &table}); err != nil {
+			return err
+		}
+		for j := range rows {
+			if err := fn(&StreamEvent{Type: EventTableRow, Index: j + 1, Row: &rows[j]}); err != nil {
+				return err
+			}
+		}
+		if err := fn(&StreamEvent{Type: EventTableEnd, Index: i + 1, Table: &table}); err != nil {
+			return err
+		}
+	}
+
+	for i := range doc.Content.Sections {
+		if err := fn(&StreamEvent{Type: EventSection, Index: i + 1, Section: &doc.Content.Sections[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
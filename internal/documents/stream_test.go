@@ -0,0 +1,119 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+)
+
+const streamDocumentXML = `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:jc w:val="center"/></w:pPr><w:r><w:t>标题</w:t></w:r></w:p>
+<w:bookmarkStart w:id="0" w:name="跳过"/>
+<w:p><w:pPr><w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:cols w:num="2" w:space="420"/></w:sectPr></w:pPr><w:r><w:t>第一</w:t></w:r><w:r><w:t>节</w:t></w:r></w:p>
+<w:tbl><w:tblPr><w:jc w:val="center"/></w:tblPr><w:tblGrid><w:gridCol w:w="100"/></w:tblGrid>
+<w:tr><w:tc><w:p><w:r><w:t>单元格</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p/></w:tc></w:tr>
+</w:tbl>
+<w:sectPr><w:pgSz w:w="16838" w:h="11906"/><w:pgMar w:top="1440" w:bottom="1440" w:left="1800" w:right="1800" w:header="851" w:footer="992"/></w:sectPr>
+</w:body></w:document>`
+
+// TestWalkDocument 测试流式事件的顺序和内容
+func TestWalkDocument(t *testing.T) {
+	var events []string
+	var last *StreamEvent
+	err := WalkDocument(strings.NewReader(streamDocumentXML), nil, func(event *StreamEvent) error {
+		events = append(events, string(event.Type))
+		switch event.Type {
+		case EventTableStart:
+			if event.Table.ID != "table_1" || event.Table.Alignment != "center" {
+				t.Errorf("表格开始事件应包含表格属性: %+v", event.Table)
+			}
+		case EventTableRow:
+			if event.Index == 1 && event.Row.Cells[0].Content[0].Text != "单元格" {
+				t.Errorf("表格行内容不正确: %+v", event.Row)
+			}
+		case EventSection:
+			if event.Index == 1 && (event.Section.Columns.Count != 2 || event.Section.Columns.Spacing != 21) {
+				t.Errorf("分节符节属性不正确: %+v", event.Section)
+			}
+		case EventParagraph:
+			if event.Index == 2 && (event.Paragraph.ID != "paragraph_2" || event.Paragraph.Text != "第一节") {
+				t.Errorf("段落事件不正确: %+v", event.Paragraph)
+			}
+		}
+		last = event
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("遍历文档失败: %v", err)
+	}
+
+	expected := "paragraph run paragraph run run section table_start table_row table_row table_end section"
+	if got := strings.Join(events, " "); got != expected {
+		t.Errorf("事件顺序不正确:\n期望 %s\n实际 %s", expected, got)
+	}
+	if last.Section.PageSize.Width != 841.9 || last.Section.PageMargins.Left != 90 {
+		t.Errorf("正文末尾节属性不正确: %+v", last.Section)
+	}
+	if last.Offset <= 0 || last.Offset > int64(len(streamDocumentXML)) {
+		t.Errorf("事件偏移不正确: %d", last.Offset)
+	}
+}
+
+// TestWalkDocumentStop 测试回调提前结束遍历
+func TestWalkDocumentStop(t *testing.T) {
+	count := 0
+	err := WalkDocument(strings.NewReader(streamDocumentXML), nil, func(event *StreamEvent) error {
+		count++
+		if event.Type == EventParagraph {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil || count != 1 {
+		t.Errorf("返回ErrStopStream应立即结束且不报错: %v, %d 个事件", err, count)
+	}
+
+	if err := WalkDocument(strings.NewReader("<w:document><w:body><w:p>"), nil, func(*StreamEvent) error { return nil }); err == nil {
+		t.Error("不完整的XML应返回错误")
+	}
+}
+
+// TestWalkDocumentContext 测试取消传递到XML解码循环，已解析的事件保留
+func TestWalkDocumentContext(t *testing.T) {
+	var body strings.Builder
+	body.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for i := 0; i < 2000; i++ {
+		body.WriteString(`<w:p><w:r><w:t>段落</w:t></w:r></w:p>`)
+	}
+	body.WriteString(`</w:body></w:document>`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	paragraphs := 0
+	err := WalkDocumentContext(ctx, strings.NewReader(body.String()), nil, func(event *StreamEvent) error {
+		if event.Type == EventParagraph {
+			paragraphs++
+			if paragraphs == 10 {
+				cancel()
+			}
+		}
+		return nil
+	})
+
+	timeoutErr, ok := types.AsTimeoutError(err)
+	if !ok {
+		t.Fatalf("取消后应返回TimeoutError，实际: %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || timeoutErr.Timeout() {
+		t.Errorf("错误应标明为取消而非超时: %v", err)
+	}
+	if paragraphs < 10 || paragraphs >= 2000 {
+		t.Errorf("取消后应尽快停止解码，实际处理了 %d 个段落", paragraphs)
+	}
+}
@@ -0,0 +1,151 @@
+package documents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// xmlBorders 边框（w:tblBorders、w:tcBorders）
+type xmlBorders struct {
+	Top struct {
+		Val string `xml:"val,attr"`
+	} `xml:"top"`
+	Bottom struct {
+		Val string `xml:"val,attr"`
+	} `xml:"bottom"`
+	Left struct {
+		Val string `xml:"val,attr"`
+	} `xml:"left"`
+	Right struct {
+		Val string `xml:"val,attr"`
+	} `xml:"right"`
+}
+
+// xmlTableProperties 表格属性（w:tblPr）
+type xmlTableProperties struct {
+	Width struct {
+		Val string `xml:"val,attr"`
+	} `xml:"tblW"`
+	Justification struct {
+		Val string `xml:"val,attr"`
+	} `xml:"jc"`
+	Borders xmlBorders `xml:"tblBorders"`
+}
+
+// xmlTableRow 表格行（w:tr）
+type xmlTableRow struct {
+	Properties struct {
+		Height struct {
+			Val string `xml:"val,attr"`
+		} `xml:"trHeight"`
+	} `xml:"trPr"`
+	Cells []struct {
+		Properties struct {
+			Width struct {
+				Val string `xml:"val,attr"`
+			} `xml:"tcW"`
+			Borders xmlBorders `xml:"tcBorders"`
+		} `xml:"tcPr"`
+		Paragraphs []xmlParagraph `xml:"p"`
+	} `xml:"tc"`
+}
+
+// applyTableProperties 解析表格宽度、对齐和边框
+func applyTableProperties(table *types.Table, props *xmlTableProperties) {
+	if props.Width.Val != "" {
+		if val, err := strconv.ParseFloat(props.Width.Val, 64); err == nil {
+			table.Width = val / 20.0
+		}
+	}
+
+	if props.Justification.Val != "" {
+		table.Alignment = types.Alignment(props.Justification.Val)
+	}
+
+	// 解析表格边框
+	if props.Borders.Top.Val != "" {
+		table.Borders.Top.Style = types.BorderStyle(props.Borders.Top.Val)
+	}
+	if props.Borders.Bottom.Val != "" {
+		table.Borders.Bottom.Style = types.BorderStyle(props.Borders.Bottom.Val)
+	}
+	if props.Borders.Left.Val != "" {
+		table.Borders.Left.Style = types.BorderStyle(props.Borders.Left.Val)
+	}
+	if props.Borders.Right.Val != "" {
+		table.Borders.Right.Style = types.BorderStyle(props.Borders.Right.Val)
+	}
+}
+
+// convertTableRow 将w:tr转换为表格行，i、j为表格和行从1开始的序号
+func convertTableRow(row *xmlTableRow, i, j int, theme *types.ThemeStyle) types.TableRow {
+	tableRow := types.TableRow{
+		ID: fmt.Sprintf("row_%d_%d", i, j),
+	}
+
+	// 解析行高度
+	if row.Properties.Height.Val != "" {
+		if val, err := strconv.ParseFloat(row.Properties.Height.Val, 64); err == nil {
+			tableRow.Height = val / 20.0
+		}
+	}
+
+	for k, cell := range row.Cells {
+		tableCell := types.TableCell{
+			ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k+1),
+		}
+
+		// 解析单元格宽度
+		if cell.Properties.Width.Val != "" {
+			if val, err := strconv.ParseFloat(cell.Properties.Width.Val, 64); err == nil {
+				tableCell.Width = val / 20.0
+			}
+		}
+
+		// 解析单元格边框
+		if cell.Properties.Borders.Top.Val != "" {
+			tableCell.Borders.Top.Style = types.BorderStyle(cell.Properties.Borders.Top.Val)
+		}
+		if cell.Properties.Borders.Bottom.Val != "" {
+			tableCell.Borders.Bottom.Style = types.BorderStyle(cell.Properties.Borders.Bottom.Val)
+		}
+		if cell.Properties.Borders.Left.Val != "" {
+			tableCell.Borders.Left.Style = types.BorderStyle(cell.Properties.Borders.Left.Val)
+		}
+		if cell.Properties.Borders.Right.Val != "" {
+			tableCell.Borders.Right.Style = types.BorderStyle(cell.Properties.Borders.Right.Val)
+		}
+
+		// 解析单元格内容
+		var cellText strings.Builder
+		for _, para := range cell.Paragraphs {
+			cellParagraph := types.Paragraph{
+				ID: fmt.Sprintf("cell_para_%d_%d_%d", i, j, k+1),
+			}
+
+			// 解析段落对齐方式
+			if para.Properties.Justification.Val != "" {
+				cellParagraph.Alignment = types.Alignment(para.Properties.Justification.Val)
+			}
+
+			// 解析段落文本运行
+			for r := range para.Runs {
+				cellRun := convertRun(&para.Runs[r], fmt.Sprintf("cell_run_%d_%d_%d", i, j, k+1), theme)
+				cellParagraph.Runs = append(cellParagraph.Runs, cellRun)
+				if !cellRun.Revision.IsRemovedWhenAccepted() {
+					cellText.WriteString(cellRun.Text)
+				}
+			}
+
+			cellParagraph.Text = cellText.String()
+			tableCell.Content = append(tableCell.Content, cellParagraph)
+		}
+
+		tableRow.Cells = append(tableRow.Cells, tableCell)
+	}
+
+	return tableRow
+}
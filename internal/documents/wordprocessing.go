@@ -1,12 +1,17 @@
 package documents
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 
+	"docs-parser/internal/core/styles"
 	"docs-parser/internal/core/types"
 	"docs-parser/internal/packaging"
 	"docs-parser/internal/utils"
@@ -17,10 +22,19 @@ type WordprocessingDocument struct {
 	Container *packaging.OPCContainer
 	Document  *types.Document
 	Parts     map[string]*DocumentPart
+	Theme     *types.ThemeStyle
 	Monitor   *utils.PerformanceMonitor
 }
 
-// DocumentPart 表示文档部分
+// documentKinds 主文档部件内容类型对应的文档类型
+var documentKinds = map[string]types.DocumentKind{
+	packaging.ContentTypeDocument:      types.KindDocument,
+	packaging.ContentTypeTemplate:      types.KindTemplate,
+	packaging.ContentTypeMacroDocument: types.KindMacroDocument,
+	packaging.ContentTypeMacroTemplate: types.KindMacroTemplate,
+}
+
+// DocumentPart 表示文档部分，主文档部件只记录名称和类型，内容按需从压缩包中流式读取
 type DocumentPart struct {
 	Name     string
 	Content  []byte
@@ -75,6 +89,11 @@ func (wd *WordprocessingDocument) loadParts() error {
 		return fmt.Errorf("failed to load styles: %w", err)
 	}
 
+	// 加载主题
+	if err := wd.loadTheme(); err != nil {
+		return fmt.Errorf("failed to load theme: %w", err)
+	}
+
 	// 加载字体表
 	if err := wd.loadFontTable(); err != nil {
 		return fmt.Errorf("failed to load font table: %w", err)
@@ -88,22 +107,40 @@ func (wd *WordprocessingDocument) loadParts() error {
 	return nil
 }
 
-// loadMainDocument 加载主文档
+// loadMainDocument 加载主文档，文档、模板和启用宏的文件按[Content_Types].xml中声明的主文档部件读取
+//
+// document.xml可能很大，这里只确认部件存在，内容在解析时由openMainDocument流式读取。
 func (wd *WordprocessingDocument) loadMainDocument() error {
-	content, err := wd.Container.ReadFile("word/document.xml")
-	if err != nil {
+	name, contentType := wd.Container.MainPart()
+	if _, err := wd.Container.GetFile(name); err != nil {
 		return fmt.Errorf("failed to read main document: %w", err)
 	}
 
 	wd.Parts["document.xml"] = &DocumentPart{
-		Name:    "document.xml",
-		Content: content,
-		Type:    "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml",
+		Name: name,
+		Type: contentType,
 	}
 
 	return nil
 }
 
+// openMainDocument 打开主文档部件的解压流，调用方负责关闭
+func (wd *WordprocessingDocument) openMainDocument() (io.ReadCloser, error) {
+	part, exists := wd.Parts["document.xml"]
+	if !exists {
+		return nil, fmt.Errorf("main document part not found")
+	}
+	file, err := wd.Container.GetFile(part.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find main document: %w", err)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open main document: %w", err)
+	}
+	return rc, nil
+}
+
 // loadStyles 加载样式
 func (wd *WordprocessingDocument) loadStyles() error {
 	if wd.Container.HasFile("word/styles.xml") {
@@ -122,6 +159,29 @@ func (wd *WordprocessingDocument) loadStyles() error {
 	return nil
 }
 
+// loadTheme 加载主题，主题颜色和主题字体的解析依赖它
+func (wd *WordprocessingDocument) loadTheme() error {
+	if wd.Container.HasFile("word/theme/theme1.xml") {
+		content, err := wd.Container.ReadFile("word/theme/theme1.xml")
+		if err != nil {
+			return fmt.Errorf("failed to read theme: %w", err)
+		}
+
+		wd.Parts["theme1.xml"] = &DocumentPart{
+			Name:    "theme1.xml",
+			Content: content,
+			Type:    "application/vnd.openxmlformats-officedocument.theme+xml",
+		}
+
+		// 主题损坏时按无主题处理，颜色回退到w:color的val值
+		if theme, err := styles.ParseTheme(bytes.NewReader(content), "theme1"); err == nil {
+			wd.Theme = theme
+		}
+	}
+
+	return nil
+}
+
 // loadFontTable 加载字体表
 func (wd *WordprocessingDocument) loadFontTable() error {
 	if wd.Container.HasFile("word/fontTable.xml") {
@@ -160,6 +220,13 @@ func (wd *WordprocessingDocument) loadSettings() error {
 
 // Parse 解析Word文档
 func (wd *WordprocessingDocument) Parse() (*types.Document, error) {
+	return wd.ParseContext(context.Background())
+}
+
+// ParseContext 受ctx控制的Parse，取消和截止时间会传递到正文的XML解码循环
+//
+// ctx结束时返回*types.TimeoutError和部分结果：已解析的元数据和正文段落、表格，样式和格式规则为空。
+func (wd *WordprocessingDocument) ParseContext(ctx context.Context) (*types.Document, error) {
 	doc := &types.Document{}
 
 	// 解析元数据
@@ -171,11 +238,19 @@ func (wd *WordprocessingDocument) Parse() (*types.Document, error) {
 
 	// 解析内容
 	contentStep := wd.Monitor.StartStep("解析内容")
-	if err := wd.parseContent(doc); err != nil {
+	if err := wd.parseContent(ctx, doc); err != nil {
+		if timeoutErr, ok := types.AsTimeoutError(err); ok {
+			timeoutErr.Path = wd.Container.Path
+			return doc, timeoutErr
+		}
 		return nil, fmt.Errorf("failed to parse content: %w", err)
 	}
 	contentStep()
 
+	if err := types.CheckContext(ctx, "parse document", wd.Container.Path); err != nil {
+		return doc, err
+	}
+
 	// 解析样式
 	styleStep := wd.Monitor.StartStep("解析样式")
 	if err := wd.parseStyles(doc); err != nil {
@@ -206,6 +281,12 @@ func (wd *WordprocessingDocument) parseMetadata(doc *types.Document) error {
 		return err
 	}
 
+	// 文档类型和宏工程
+	if part, exists := wd.Parts["document.xml"]; exists {
+		doc.Metadata.DocumentKind = documentKinds[part.Type]
+	}
+	doc.Metadata.HasMacros = wd.Container.HasVBAProject()
+
 	return nil
 }
 
@@ -297,371 +378,53 @@ func (wd *WordprocessingDocument) parseAppProperties(doc *types.Document) error
 }
 
 // parseContent 解析内容
-func (wd *WordprocessingDocument) parseContent(doc *types.Document) error {
-	part, exists := wd.Parts["document.xml"]
-	if !exists {
-		return fmt.Errorf("main document part not found")
-	}
-
+func (wd *WordprocessingDocument) parseContent(ctx context.Context, doc *types.Document) error {
 	// 解析主文档内容
-	if err := wd.parseMainDocument(part.Content, doc); err != nil {
+	if err := wd.parseMainDocument(ctx, doc); err != nil {
 		return fmt.Errorf("failed to parse main document: %w", err)
 	}
 
+	// 解析批注
+	if err := wd.parseComments(doc); err != nil {
+		return fmt.Errorf("failed to parse comments: %w", err)
+	}
+
 	return nil
 }
 
 // parseMainDocument 解析主文档
-func (wd *WordprocessingDocument) parseMainDocument(content []byte, doc *types.Document) error {
-	var document struct {
-		XMLName xml.Name `xml:"document"`
-		Body    struct {
-			XMLName    xml.Name `xml:"body"`
-			Paragraphs []struct {
-				XMLName    xml.Name `xml:"p"`
-				Properties struct {
-					Style struct {
-						Val string `xml:"val,attr"`
-					} `xml:"pStyle"`
-					Justification struct {
-						Val string `xml:"val,attr"`
-					} `xml:"jc"`
-					Indentation struct {
-						Left    string `xml:"left,attr"`
-						Right   string `xml:"right,attr"`
-						First   string `xml:"firstLine,attr"`
-						Hanging string `xml:"hanging,attr"`
-					} `xml:"ind"`
-					Spacing struct {
-						Before string `xml:"before,attr"`
-						After  string `xml:"after,attr"`
-						Line   string `xml:"line,attr"`
-					} `xml:"spacing"`
-				} `xml:"pPr"`
-				Runs []struct {
-					XMLName    xml.Name `xml:"r"`
-					Properties struct {
-						Font struct {
-							Val string `xml:"val,attr"`
-						} `xml:"rFonts"`
-						Size struct {
-							Val string `xml:"val,attr"`
-						} `xml:"sz"`
-						Bold   bool `xml:"b"`
-						Italic bool `xml:"i"`
-						Color  struct {
-							Val string `xml:"val,attr"`
-						} `xml:"color"`
-					} `xml:"rPr"`
-					Text string `xml:"t"`
-				} `xml:"r"`
-			} `xml:"p"`
-			Tables []struct {
-				XMLName    xml.Name `xml:"tbl"`
-				Properties struct {
-					Width struct {
-						Val string `xml:"val,attr"`
-					} `xml:"tblW"`
-					Justification struct {
-						Val string `xml:"val,attr"`
-					} `xml:"jc"`
-					Borders struct {
-						Top struct {
-							Val string `xml:"val,attr"`
-						} `xml:"top"`
-						Bottom struct {
-							Val string `xml:"val,attr"`
-						} `xml:"bottom"`
-						Left struct {
-							Val string `xml:"val,attr"`
-						} `xml:"left"`
-						Right struct {
-							Val string `xml:"val,attr"`
-						} `xml:"right"`
-					} `xml:"tblBorders"`
-				} `xml:"tblPr"`
-				Rows []struct {
-					XMLName    xml.Name `xml:"tr"`
-					Properties struct {
-						Height struct {
-							Val string `xml:"val,attr"`
-						} `xml:"trHeight"`
-					} `xml:"trPr"`
-					Cells []struct {
-						XMLName    xml.Name `xml:"tc"`
-						Properties struct {
-							Width struct {
-								Val string `xml:"val,attr"`
-							} `xml:"tcW"`
-							Borders struct {
-								Top struct {
-									Val string `xml:"val,attr"`
-								} `xml:"top"`
-								Bottom struct {
-									Val string `xml:"val,attr"`
-								} `xml:"bottom"`
-								Left struct {
-									Val string `xml:"val,attr"`
-								} `xml:"left"`
-								Right struct {
-									Val string `xml:"val,attr"`
-								} `xml:"right"`
-							} `xml:"tcBorders"`
-						} `xml:"tcPr"`
-						Paragraphs []struct {
-							Properties struct {
-								Alignment struct {
-									Val string `xml:"val,attr"`
-								} `xml:"jc"`
-							} `xml:"pPr"`
-							Runs []struct {
-								Properties struct {
-									Font struct {
-										Val string `xml:"val,attr"`
-									} `xml:"rFonts"`
-									Size struct {
-										Val string `xml:"val,attr"`
-									} `xml:"sz"`
-									Bold   bool `xml:"b"`
-									Italic bool `xml:"i"`
-									Color  struct {
-										Val string `xml:"val,attr"`
-									} `xml:"color"`
-								} `xml:"rPr"`
-								Text string `xml:"t"`
-							} `xml:"r"`
-						} `xml:"p"`
-					} `xml:"tc"`
-				} `xml:"tr"`
-			} `xml:"tbl"`
-		} `xml:"body"`
-	}
-
-	if err := xml.Unmarshal(content, &document); err != nil {
-		return fmt.Errorf("failed to unmarshal document: %w", err)
-	}
-
-	// 解析段落
-	for i, p := range document.Body.Paragraphs {
-		paragraph := types.Paragraph{
-			ID: fmt.Sprintf("paragraph_%d", i+1),
-			Style: types.ParagraphStyle{
-				Name: p.Properties.Style.Val,
-			},
-		}
-
-		// 解析对齐方式
-		if p.Properties.Justification.Val != "" {
-			paragraph.Alignment = types.Alignment(p.Properties.Justification.Val)
-		}
-
-		// 解析缩进
-		if p.Properties.Indentation.Left != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Indentation.Left, 64); err == nil {
-				paragraph.Indentation.Left = val / 20.0
-			}
-		}
-		if p.Properties.Indentation.Right != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Indentation.Right, 64); err == nil {
-				paragraph.Indentation.Right = val / 20.0
-			}
-		}
-		if p.Properties.Indentation.First != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Indentation.First, 64); err == nil {
-				paragraph.Indentation.First = val / 20.0
-			}
-		}
-
-		// 解析间距
-		if p.Properties.Spacing.Before != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Spacing.Before, 64); err == nil {
-				paragraph.Spacing.Before = val / 20.0
-			}
-		}
-		if p.Properties.Spacing.After != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Spacing.After, 64); err == nil {
-				paragraph.Spacing.After = val / 20.0
-			}
-		}
-		if p.Properties.Spacing.Line != "" {
-			if val, err := strconv.ParseFloat(p.Properties.Spacing.Line, 64); err == nil {
-				paragraph.Spacing.Line = val / 240.0
-			}
-		}
-
-		// 解析文本运行
-		var paragraphText strings.Builder
-		for j, r := range p.Runs {
-			run := types.TextRun{
-				ID:     fmt.Sprintf("run_%d_%d", i+1, j+1),
-				Text:   r.Text,
-				Bold:   r.Properties.Bold,
-				Italic: r.Properties.Italic,
-			}
-
-			// 解析内联字体信息
-			if r.Properties.Font.Val != "" {
-				run.Font.Name = r.Properties.Font.Val
-			}
-
-			// 解析内联字体大小
-			if r.Properties.Size.Val != "" {
-				if sz, err := strconv.ParseFloat(r.Properties.Size.Val, 64); err == nil {
-					run.Font.Size = sz / 2.0
-					run.Size = sz / 2.0
-				}
-			}
-
-			// 解析内联颜色
-			if r.Properties.Color.Val != "" {
-				run.Font.Color.RGB = r.Properties.Color.Val
-				run.Color.RGB = r.Properties.Color.Val
-			}
-
-			// 如果没有内联字体信息，尝试从段落样式中获取
-			if run.Font.Name == "" {
-				if paragraph.Style.Name != "" {
-					// 不在这里设置默认值，让applyStylesToContent来处理
-					// run.Font.Name = "宋体"
-					// run.Font.Size = 12.0
-				}
-			}
-
-			paragraph.Runs = append(paragraph.Runs, run)
-			paragraphText.WriteString(r.Text)
+func (wd *WordprocessingDocument) parseMainDocument(ctx context.Context, doc *types.Document) error {
+	err := wd.walkMainDocument(ctx, func(event *StreamEvent) error {
+		switch event.Type {
+		case EventParagraph:
+			doc.Content.Paragraphs = append(doc.Content.Paragraphs, *event.Paragraph)
+		case EventTableStart:
+			doc.Content.Tables = append(doc.Content.Tables, *event.Table)
+		case EventTableRow:
+			table := &doc.Content.Tables[len(doc.Content.Tables)-1]
+			table.Rows = append(table.Rows, *event.Row)
 		}
-
-		paragraph.Text = paragraphText.String()
-		doc.Content.Paragraphs = append(doc.Content.Paragraphs, paragraph)
+		return nil
+	})
+	if _, ok := types.AsTimeoutError(err); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal document: %w", err)
 	}
 
-	// 解析表格
-	for i, t := range document.Body.Tables {
-		table := types.Table{
-			ID: fmt.Sprintf("table_%d", i+1),
-		}
-
-		// 解析表格属性
-		if t.Properties.Width.Val != "" {
-			if val, err := strconv.ParseFloat(t.Properties.Width.Val, 64); err == nil {
-				table.Width = val / 20.0
-			}
-		}
-
-		if t.Properties.Justification.Val != "" {
-			table.Alignment = types.Alignment(t.Properties.Justification.Val)
-		}
-
-		// 解析表格边框
-		if t.Properties.Borders.Top.Val != "" {
-			table.Borders.Top.Style = types.BorderStyle(t.Properties.Borders.Top.Val)
-		}
-		if t.Properties.Borders.Bottom.Val != "" {
-			table.Borders.Bottom.Style = types.BorderStyle(t.Properties.Borders.Bottom.Val)
-		}
-		if t.Properties.Borders.Left.Val != "" {
-			table.Borders.Left.Style = types.BorderStyle(t.Properties.Borders.Left.Val)
-		}
-		if t.Properties.Borders.Right.Val != "" {
-			table.Borders.Right.Style = types.BorderStyle(t.Properties.Borders.Right.Val)
-		}
-
-		for j, row := range t.Rows {
-			tableRow := types.TableRow{
-				ID: fmt.Sprintf("row_%d_%d", i+1, j+1),
-			}
-
-			// 解析行高度
-			if row.Properties.Height.Val != "" {
-				if val, err := strconv.ParseFloat(row.Properties.Height.Val, 64); err == nil {
-					tableRow.Height = val / 20.0
-				}
-			}
-
-			for k, cell := range row.Cells {
-				tableCell := types.TableCell{
-					ID: fmt.Sprintf("cell_%d_%d_%d", i+1, j+1, k+1),
-				}
-
-				// 解析单元格宽度
-				if cell.Properties.Width.Val != "" {
-					if val, err := strconv.ParseFloat(cell.Properties.Width.Val, 64); err == nil {
-						tableCell.Width = val / 20.0
-					}
-				}
-
-				// 解析单元格边框
-				if cell.Properties.Borders.Top.Val != "" {
-					tableCell.Borders.Top.Style = types.BorderStyle(cell.Properties.Borders.Top.Val)
-				}
-				if cell.Properties.Borders.Bottom.Val != "" {
-					tableCell.Borders.Bottom.Style = types.BorderStyle(cell.Properties.Borders.Bottom.Val)
-				}
-				if cell.Properties.Borders.Left.Val != "" {
-					tableCell.Borders.Left.Style = types.BorderStyle(cell.Properties.Borders.Left.Val)
-				}
-				if cell.Properties.Borders.Right.Val != "" {
-					tableCell.Borders.Right.Style = types.BorderStyle(cell.Properties.Borders.Right.Val)
-				}
-
-				// 解析单元格内容
-				var cellText strings.Builder
-				for _, para := range cell.Paragraphs {
-					cellParagraph := types.Paragraph{
-						ID: fmt.Sprintf("cell_para_%d_%d_%d", i+1, j+1, k+1),
-					}
-
-					// 解析段落对齐方式
-					if para.Properties.Alignment.Val != "" {
-						cellParagraph.Alignment = types.Alignment(para.Properties.Alignment.Val)
-					}
-
-					// 解析段落文本运行
-					for _, run := range para.Runs {
-						cellRun := types.TextRun{
-							ID:     fmt.Sprintf("cell_run_%d_%d_%d", i+1, j+1, k+1),
-							Text:   run.Text,
-							Bold:   run.Properties.Bold,
-							Italic: run.Properties.Italic,
-						}
-
-						// 解析字体
-						if run.Properties.Font.Val != "" {
-							cellRun.Font.Name = run.Properties.Font.Val
-						}
-
-						// 解析字体大小
-						if run.Properties.Size.Val != "" {
-							if sz, err := strconv.ParseFloat(run.Properties.Size.Val, 64); err == nil {
-								cellRun.Font.Size = sz / 2.0
-								cellRun.Size = sz / 2.0
-							}
-						}
-
-						// 解析颜色
-						if run.Properties.Color.Val != "" {
-							cellRun.Font.Color.RGB = run.Properties.Color.Val
-							cellRun.Color.RGB = run.Properties.Color.Val
-						}
-
-						cellParagraph.Runs = append(cellParagraph.Runs, cellRun)
-						cellText.WriteString(run.Text)
-					}
-
-					cellParagraph.Text = cellText.String()
-					tableCell.Content = append(tableCell.Content, cellParagraph)
-				}
-
-				tableRow.Cells = append(tableRow.Cells, tableCell)
-			}
-
-			table.Rows = append(table.Rows, tableRow)
-		}
+	return nil
+}
 
-		doc.Content.Tables = append(doc.Content.Tables, table)
+// walkMainDocument 从压缩包中流式读取主文档，依次把正文事件交给fn
+func (wd *WordprocessingDocument) walkMainDocument(ctx context.Context, fn func(*StreamEvent) error) error {
+	rc, err := wd.openMainDocument()
+	if err != nil {
+		return err
 	}
+	defer rc.Close()
 
-	return nil
+	return WalkDocumentContext(ctx, bufio.NewReader(rc), wd.Theme, fn)
 }
 
 // parseStyles 解析样式
@@ -671,6 +434,7 @@ func (wd *WordprocessingDocument) parseStyles(doc *types.Document) error {
 		ParagraphStyles: []types.ParagraphStyle{},
 		CharacterStyles: []types.CharacterStyle{},
 		TableStyles:     []types.TableStyle{},
+		Theme:           wd.Theme,
 	}
 
 	// 尝试解析styles.xml
@@ -718,13 +482,17 @@ func (wd *WordprocessingDocument) parseStylesXML(doc *types.Document) error {
 					HAnsi    string `xml:"hAnsi,attr"`
 					EastAsia string `xml:"eastAsia,attr"`
 					CS       string `xml:"cs,attr"`
+					// 主题字体
+					AsciiTheme    string `xml:"asciiTheme,attr"`
+					EastAsiaTheme string `xml:"eastAsiaTheme,attr"`
 				} `xml:"rFonts"`
 				Size struct {
 					Val string `xml:"val,attr"`
 				} `xml:"sz"`
-				Color struct {
-					Val string `xml:"val,attr"`
-				} `xml:"color"`
+				Color xmlColor `xml:"color"`
+				Lang  struct {
+					EastAsia string `xml:"eastAsia,attr"`
+				} `xml:"lang"`
 				Bold struct {
 					Val string `xml:"val,attr"`
 				} `xml:"b"`
@@ -764,6 +532,12 @@ func (wd *WordprocessingDocument) parseStylesXML(doc *types.Document) error {
 			if fontName == "" {
 				fontName = style.Properties.Font.Ascii
 			}
+			if fontName == "" {
+				fontName = styles.ResolveThemeFont(wd.Theme, style.Properties.Font.EastAsiaTheme, style.Properties.Lang.EastAsia)
+			}
+			if fontName == "" {
+				fontName = styles.ResolveThemeFont(wd.Theme, style.Properties.Font.AsciiTheme, "")
+			}
 			if fontName == "" {
 				fontName = "宋体" // 默认字体
 			}
@@ -779,7 +553,7 @@ func (wd *WordprocessingDocument) parseStylesXML(doc *types.Document) error {
 			font := types.Font{
 				Name:  fontName,
 				Size:  fontSize,
-				Color: types.Color{RGB: style.Properties.Color.Val},
+				Color: types.Color{RGB: style.Properties.Color.resolve(wd.Theme)},
 				Bold:  style.Properties.Bold.Val == "true" || style.Properties.Bold.Val == "1",
 				Italic: style.Properties.Italic.Val == "true" || style.Properties.Italic.Val == "1",
 			}
@@ -857,23 +631,28 @@ func (wd *WordprocessingDocument) parseStylesXML(doc *types.Document) error {
 func (wd *WordprocessingDocument) extractInlineStyles(doc *types.Document) error {
 	// 从文档内容中提取使用的样式
 	usedStyles := make(map[string]bool)
-
-	// 从段落中提取样式
-	for _, para := range doc.Content.Paragraphs {
-		if para.Style.Name != "" {
-			usedStyles[para.Style.Name] = true
-		}
+	for i := range doc.Content.Paragraphs {
+		collectInlineStyles(&doc.Content.Paragraphs[i], usedStyles)
 	}
 
-	// 从文本运行中提取样式
-	for _, para := range doc.Content.Paragraphs {
-		for _, run := range para.Runs {
-			if run.Font.Name != "" {
-				usedStyles[run.Font.Name] = true
-			}
+	addInlineStyles(doc, usedStyles)
+	return nil
+}
+
+// collectInlineStyles 记录段落样式名和文本运行的字体名
+func collectInlineStyles(para *types.Paragraph, usedStyles map[string]bool) {
+	if para.Style.Name != "" {
+		usedStyles[para.Style.Name] = true
+	}
+	for _, run := range para.Runs {
+		if run.Font.Name != "" {
+			usedStyles[run.Font.Name] = true
 		}
 	}
+}
 
+// addInlineStyles 为正文中使用的样式名创建段落样式和字符样式
+func addInlineStyles(doc *types.Document, usedStyles map[string]bool) {
 	// 创建样式对象
 	for styleName := range usedStyles {
 		// 创建段落样式
@@ -890,8 +669,6 @@ func (wd *WordprocessingDocument) extractInlineStyles(doc *types.Document) error
 		}
 		doc.Styles.CharacterStyles = append(doc.Styles.CharacterStyles, charStyle)
 	}
-
-	return nil
 }
 
 // parseFormatRules 解析格式规则
@@ -914,23 +691,31 @@ func (wd *WordprocessingDocument) parseFormatRules(doc *types.Document) error {
 
 // extractFontRules 提取字体规则
 func (wd *WordprocessingDocument) extractFontRules(doc *types.Document) error {
-	// 首先尝试从fontTable.xml获取字体信息
+	usedFonts := make(inlineFonts)
+	for _, para := range doc.Content.Paragraphs {
+		for _, run := range para.Runs {
+			usedFonts.add(run)
+		}
+	}
+
+	wd.addFontRules(doc, usedFonts)
+	return nil
+}
+
+// addFontRules 添加字体规则，首先尝试从fontTable.xml获取字体信息，没有字体表时使用正文中的字体
+func (wd *WordprocessingDocument) addFontRules(doc *types.Document, usedFonts inlineFonts) {
 	fontMap := make(map[string]*types.FontRule)
 
 	// 尝试解析fontTable.xml
 	if err := wd.parseFontTable(fontMap); err != nil {
 		// 如果fontTable.xml不存在，从内联样式中提取
-		if err := wd.extractInlineFonts(doc, fontMap); err != nil {
-			return fmt.Errorf("failed to extract font rules: %w", err)
-		}
+		fontMap = usedFonts.rules()
 	}
 
 	// 将字体规则添加到文档中
 	for _, fontRule := range fontMap {
 		doc.FormatRules.FontRules = append(doc.FormatRules.FontRules, *fontRule)
 	}
-
-	return nil
 }
 
 // parseFontTable 解析fontTable.xml
@@ -972,69 +757,62 @@ func (wd *WordprocessingDocument) parseFontTable(fontMap map[string]*types.FontR
 	return nil
 }
 
-// extractInlineFonts 从内联样式中提取字体信息
-func (wd *WordprocessingDocument) extractInlineFonts(doc *types.Document, fontMap map[string]*types.FontRule) error {
-	// 从文档内容中提取使用的字体
-	usedFonts := make(map[string]*types.FontRule)
+// inlineFonts 从内联样式中提取的字体信息，按字体名合并
+type inlineFonts map[string]*types.FontRule
 
-	for _, para := range doc.Content.Paragraphs {
-		for _, run := range para.Runs {
-			// 使用run.Font.Name，如果为空则使用默认字体
-			fontName := run.Font.Name
-			if fontName == "" {
-				fontName = "宋体" // 默认字体
-			}
-			
-			// 确保字体大小不为0
-			fontSize := run.Font.Size
-			if fontSize == 0 {
-				fontSize = 12.0 // 默认字体大小
-			}
-			
-			if _, exists := usedFonts[fontName]; !exists {
-				fontRule := &types.FontRule{
-					ID:     fontName,
-					Name:   fontName,
-					Size:   fontSize,
-					Color:  run.Font.Color,
-					Bold:   run.Bold,
-					Italic: run.Italic,
-				}
-				usedFonts[fontName] = fontRule
-			} else {
-				// 更新现有字体规则，合并属性
-				existing := usedFonts[fontName]
-				if fontSize > 0 {
-					existing.Size = fontSize
-				}
-				if run.Font.Color.RGB != "" {
-					existing.Color = run.Font.Color
-				}
-				existing.Bold = existing.Bold || run.Bold
-				existing.Italic = existing.Italic || run.Italic
-			}
-		}
+// add 记录文本运行使用的字体
+func (usedFonts inlineFonts) add(run types.TextRun) {
+	// 使用run.Font.Name，如果为空则使用默认字体
+	fontName := run.Font.Name
+	if fontName == "" {
+		fontName = "宋体" // 默认字体
+	}
+	
+	// 确保字体大小不为0
+	fontSize := run.Font.Size
+	if fontSize == 0 {
+		fontSize = 12.0 // 默认字体大小
+	}
+	
+	if _, exists := usedFonts[fontName]; !exists {
+		fontRule := &types.FontRule{
+			ID:     fontName,
+			Name:   fontName,
+			Size:   fontSize,
+			Color:  run.Font.Color,
+			Bold:   run.Bold,
+			Italic: run.Italic,
+		}
+		usedFonts[fontName] = fontRule
+	} else {
+		// 更新现有字体规则，合并属性
+		existing := usedFonts[fontName]
+		if fontSize > 0 {
+			existing.Size = fontSize
+		}
+		if run.Font.Color.RGB != "" {
+			existing.Color = run.Font.Color
+		}
+		existing.Bold = existing.Bold || run.Bold
+		existing.Italic = existing.Italic || run.Italic
 	}
+}
 
-	// 如果没有找到字体，创建默认字体规则
+// rules 返回字体规则，没有找到字体时返回默认字体规则
+func (usedFonts inlineFonts) rules() map[string]*types.FontRule {
 	if len(usedFonts) == 0 {
-		defaultFont := &types.FontRule{
-			ID:     "Default",
-			Name:   "宋体",
-			Size:   12.0,
-			Color:  types.Color{RGB: "000000"},
-			Bold:   false,
-			Italic: false,
+		return map[string]*types.FontRule{
+			"Default": {
+				ID:     "Default",
+				Name:   "宋体",
+				Size:   12.0,
+				Color:  types.Color{RGB: "000000"},
+				Bold:   false,
+				Italic: false,
+			},
 		}
-		usedFonts["Default"] = defaultFont
-	}
-
-	// 将提取的字体规则复制到fontMap
-	for name, rule := range usedFonts {
-		fontMap[name] = rule
 	}
-
-	return nil
+	return usedFonts
 }
 
 // extractParagraphRules 提取段落规则
@@ -1102,27 +880,32 @@ func (wd *WordprocessingDocument) applyStylesToContent(doc *types.Document) erro
 		// 如果段落有样式名称，应用对应的样式
 		if paragraph.Style.Name != "" {
 			if style, exists := styleMap[paragraph.Style.Name]; exists {
-				// 应用段落样式
-				paragraph.Alignment = style.Alignment
-				paragraph.Indentation = style.Indentation
-				paragraph.Spacing = style.Spacing
-				
-				// 为段落中的每个运行应用字体样式
-				for j := range paragraph.Runs {
-					run := &paragraph.Runs[j]
-					
-					// 如果没有内联字体信息，使用样式中的字体信息
-					if run.Font.Name == "" {
-						run.Font.Name = style.Font.Name
-						run.Font.Size = style.Font.Size
-						run.Font.Color = style.Font.Color
-						run.Font.Bold = style.Font.Bold
-						run.Font.Italic = style.Font.Italic
-					}
-				}
+				applyParagraphStyle(paragraph, style)
 			}
 		}
 	}
 
 	return nil
 }
+
+// applyParagraphStyle 将段落样式应用到段落及其文本运行
+func applyParagraphStyle(paragraph *types.Paragraph, style *types.ParagraphStyle) {
+	// 应用段落样式
+	paragraph.Alignment = style.Alignment
+	paragraph.Indentation = style.Indentation
+	paragraph.Spacing = style.Spacing
+	
+	// 为段落中的每个运行应用字体样式
+	for j := range paragraph.Runs {
+		run := &paragraph.Runs[j]
+		
+		// 如果没有内联字体信息，使用样式中的字体信息
+		if run.Font.Name == "" {
+			run.Font.Name = style.Font.Name
+			run.Font.Size = style.Font.Size
+			run.Font.Color = style.Font.Color
+			run.Font.Bold = style.Font.Bold
+			run.Font.Italic = style.Font.Italic
+		}
+	}
+}
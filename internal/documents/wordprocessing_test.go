@@ -0,0 +1,108 @@
+package documents
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/testutil"
+)
+
+const streamStylesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:pPr><w:jc w:val="center"/></w:pPr><w:rPr><w:rFonts w:ascii="黑体"/><w:sz w:val="32"/></w:rPr></w:style>
+</w:styles>`
+
+const streamBody = `<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>标题</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:rFonts w:val="宋体"/><w:sz w:val="24"/></w:rPr><w:t>正文</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>单元格</w:t></w:r></w:p></w:tc></w:tr></w:tbl>`
+
+// TestStreamContext 测试流式解析的段落、样式和格式规则与完整解析一致
+func TestStreamContext(t *testing.T) {
+	withStyles := testutil.DocxParts(streamBody)
+	withStyles["word/styles.xml"] = streamStylesXML
+
+	tests := []struct {
+		name  string
+		parts map[string]string
+	}{
+		{"styles.xml", withStyles},
+		{"内联样式", testutil.DocxParts(streamBody)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := testutil.WriteZip(t, filepath.Join(t.TempDir(), "stream.docx"), tt.parts)
+
+			parsed := NewWordprocessingDocument(path)
+			if err := parsed.Open(); err != nil {
+				t.Fatalf("打开文档失败: %v", err)
+			}
+			defer parsed.Close()
+			expected, err := parsed.Parse()
+			if err != nil {
+				t.Fatalf("解析文档失败: %v", err)
+			}
+
+			streamed := NewWordprocessingDocument(path)
+			if err := streamed.Open(); err != nil {
+				t.Fatalf("打开文档失败: %v", err)
+			}
+			defer streamed.Close()
+			var paragraphs []types.Paragraph
+			var tables int
+			doc, err := streamed.StreamContext(context.Background(), func(event *StreamEvent) error {
+				switch event.Type {
+				case EventParagraph:
+					paragraphs = append(paragraphs, *event.Paragraph)
+				case EventTableEnd:
+					tables++
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("流式解析失败: %v", err)
+			}
+
+			if !reflect.DeepEqual(paragraphs, expected.Content.Paragraphs) {
+				t.Errorf("流式段落与完整解析不一致:\n期望 %+v\n实际 %+v", expected.Content.Paragraphs, paragraphs)
+			}
+			if tt.parts["word/styles.xml"] != "" && paragraphs[0].Runs[0].Font.Name != "黑体" {
+				t.Errorf("流式段落应应用段落样式的字体: %+v", paragraphs[0].Runs[0].Font)
+			}
+			if tables != len(expected.Content.Tables) {
+				t.Errorf("表格数量不一致: 期望 %d，实际 %d", len(expected.Content.Tables), tables)
+			}
+			if len(doc.Content.Paragraphs) != 0 || len(doc.FormatRules.ParagraphRules) != 0 {
+				t.Error("流式解析的结果不应保留正文段落和段落规则")
+			}
+			if got, want := styleIDs(doc.Styles.ParagraphStyles), styleIDs(expected.Styles.ParagraphStyles); !reflect.DeepEqual(got, want) {
+				t.Errorf("段落样式不一致: 期望 %v，实际 %v", want, got)
+			}
+			if got, want := fontRules(doc.FormatRules.FontRules), fontRules(expected.FormatRules.FontRules); !reflect.DeepEqual(got, want) {
+				t.Errorf("字体规则不一致:\n期望 %+v\n实际 %+v", want, got)
+			}
+			if !reflect.DeepEqual(doc.FormatRules.PageRules, expected.FormatRules.PageRules) {
+				t.Errorf("页面规则不一致: %+v", doc.FormatRules.PageRules)
+			}
+		})
+	}
+}
+
+func styleIDs(styles []types.ParagraphStyle) []string {
+	var ids []string
+	for _, style := range styles {
+		ids = append(ids, style.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func fontRules(rules []types.FontRule) []types.FontRule {
+	sorted := append([]types.FontRule(nil), rules...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
@@ -0,0 +1,81 @@
+package formats
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// codePageEncodings Windows代码页对应的编码
+var codePageEncodings = map[int]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	1258:  charmap.Windows1258,
+	10000: charmap.Macintosh,
+	20936: simplifiedchinese.HZGB2312,
+	54936: simplifiedchinese.GB18030,
+}
+
+// xmlCharsetReader 供encoding/xml解码声明了非UTF-8编码（如GB2312、windows-1252）的XML
+func xmlCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// decodeCodePage 按Windows代码页解码文本并去掉末尾的空字符
+//
+// 1200为UTF-16LE，65001为UTF-8；未知的代码页在数据是有效UTF-8时按UTF-8解码，否则按Windows-1252解码。
+func decodeCodePage(codePage int, data []byte) string {
+	var text string
+	switch codePage {
+	case 1200:
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+		text = string(utf16.Decode(units))
+	case 65001:
+		text = string(data)
+	default:
+		enc, ok := codePageEncodings[codePage]
+		if !ok {
+			if utf8.Valid(data) {
+				return strings.TrimRight(string(data), "\x00")
+			}
+			enc = charmap.Windows1252
+		}
+		decoded, err := enc.NewDecoder().Bytes(data)
+		if err != nil {
+			decoded = data
+		}
+		text = string(decoded)
+	}
+	return strings.TrimRight(text, "\x00")
+}
@@ -2,6 +2,7 @@ package formats
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 
 	"docs-parser/internal/core/parser"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/cfb"
 )
 
 // DocParser .doc格式解析器
@@ -59,15 +61,13 @@ func (dp *DocParser) ParseDocument(filePath string) (*types.Document, error) {
 
 	fmt.Printf("文档版本: %s %d.%d.%d\n", header.Version.Platform, header.Version.Major, header.Version.Minor, header.Version.Build)
 
-	doc := &types.Document{}
-
-	// 解析元数据
-	fmt.Println("正在解析文档元数据...")
-	metadata, err := dp.parseMetadata(filePath, header)
+	fmt.Println("正在解析文档...")
+	doc, err := dp.parseParts(filePath, header)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+		return nil, err
 	}
-	doc.Metadata = *metadata
+	metadata, content, styles, formatRules := &doc.Metadata, &doc.Content, &doc.Styles, &doc.FormatRules
+
 	fmt.Printf("元数据解析完成:\n")
 	fmt.Printf("  - 标题: %s\n", metadata.Title)
 	fmt.Printf("  - 作者: %s\n", metadata.Author)
@@ -76,13 +76,6 @@ func (dp *DocParser) ParseDocument(filePath string) (*types.Document, error) {
 	fmt.Printf("  - 页数: %d\n", metadata.PageCount)
 	fmt.Printf("  - 字数: %d\n", metadata.WordCount)
 
-	// 解析内容
-	fmt.Println("正在解析文档内容...")
-	content, err := dp.parseContent(filePath, header)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse content: %w", err)
-	}
-	doc.Content = *content
 	fmt.Printf("内容解析完成:\n")
 	fmt.Printf("  - 段落数量: %d\n", len(content.Paragraphs))
 	fmt.Printf("  - 表格数量: %d\n", len(content.Tables))
@@ -91,25 +84,11 @@ func (dp *DocParser) ParseDocument(filePath string) (*types.Document, error) {
 	fmt.Printf("  - 页脚数量: %d\n", len(content.Footers))
 	fmt.Printf("  - 节数量: %d\n", len(content.Sections))
 
-	// 解析样式
-	fmt.Println("正在解析文档样式...")
-	styles, err := dp.parseStyles(filePath, header)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse styles: %w", err)
-	}
-	doc.Styles = *styles
 	fmt.Printf("样式解析完成:\n")
 	fmt.Printf("  - 段落样式数量: %d\n", len(styles.ParagraphStyles))
 	fmt.Printf("  - 字符样式数量: %d\n", len(styles.CharacterStyles))
 	fmt.Printf("  - 表格样式数量: %d\n", len(styles.TableStyles))
 
-	// 解析格式规则
-	fmt.Println("正在解析格式规则...")
-	formatRules, err := dp.parseFormatRules(filePath, header)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse format rules: %w", err)
-	}
-	doc.FormatRules = *formatRules
 	fmt.Printf("格式规则解析完成:\n")
 	fmt.Printf("  - 字体规则数量: %d\n", len(formatRules.FontRules))
 	fmt.Printf("  - 段落规则数量: %d\n", len(formatRules.ParagraphRules))
@@ -184,6 +163,61 @@ func (dp *DocParser) ParseDocument(filePath string) (*types.Document, error) {
 	return doc, nil
 }
 
+// parseParts 依次解析元数据、内容、样式和格式规则，Word 97-2003文档只读取一次复合文件
+func (dp *DocParser) parseParts(filePath string, header *DocHeader) (*types.Document, error) {
+	if header.FileType == "Word 97-2003" {
+		return dp.parseWord97Document(filePath)
+	}
+
+	doc := &types.Document{}
+	metadata, err := dp.parseMetadata(filePath, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	doc.Metadata = *metadata
+
+	content, err := dp.parseContent(filePath, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+	doc.Content = *content
+
+	styles, err := dp.parseStyles(filePath, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse styles: %w", err)
+	}
+	doc.Styles = *styles
+
+	formatRules, err := dp.parseFormatRules(filePath, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse format rules: %w", err)
+	}
+	doc.FormatRules = *formatRules
+	return doc, nil
+}
+
+// parseWord97Document 读取Word 97-2003复合文件中的FIB、片段表、格式页、样式表、节属性和字体表
+func (dp *DocParser) parseWord97Document(filePath string) (*types.Document, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := cfb.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	defer file.Close()
+
+	wb, err := readWordBinary(file)
+	if err != nil {
+		if errors.Is(err, ErrEncryptedDoc) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	return wb.document(info.Size()), nil
+}
+
 // ParseMetadata 解析元数据
 func (dp *DocParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
 	header, err := dp.parseDocHeader(filePath)
@@ -226,19 +260,24 @@ func (dp *DocParser) GetSupportedFormats() []string {
 }
 
 // ValidateFile 验证文件格式
+//
+// 按内容识别格式，内容有效时不要求扩展名匹配；内容无效且扩展名也不属于该格式时返回ErrUnsupportedFormat。
 func (dp *DocParser) ValidateFile(filePath string) error {
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return parser.ErrFileNotFound
 	}
 
-	// 检查文件扩展名
-	ext := filepath.Ext(filePath)
+	err := dp.validateContent(filePath)
 	supported := map[string]bool{".doc": true, ".dot": true, ".wbk": true}
-	if !supported[ext] {
+	if err != nil && !supported[strings.ToLower(filepath.Ext(filePath))] {
 		return parser.ErrUnsupportedFormat
 	}
+	return err
+}
 
+// validateContent 检查文件头
+func (dp *DocParser) validateContent(filePath string) error {
 	// 检查文件头
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -257,6 +296,18 @@ func (dp *DocParser) ValidateFile(filePath string) error {
 		return parser.ErrInvalidFile
 	}
 
+	// 复合文件必须包含WordDocument流
+	if cfb.IsCFB(header) {
+		cf, err := cfb.Open(filePath)
+		if err != nil {
+			return parser.ErrInvalidFile
+		}
+		defer cf.Close()
+		if entry, err := cf.Entry("WordDocument"); err != nil || !entry.IsStream() {
+			return parser.ErrInvalidFile
+		}
+	}
+
 	return nil
 }
 
@@ -306,10 +357,9 @@ func (dp *DocParser) parseDocHeader(filePath string) (*DocHeader, error) {
 		Signature: header[:8],
 	}
 
-	// 根据魔数确定版本
-	if header[0] == 0xD0 && header[1] == 0xCF {
-		docHeader.Version = DocVersion{Major: 8, Minor: 0, Platform: "Windows"}
-		docHeader.FileType = "Word 97-2003"
+	// 根据魔数确定版本，复合文件按FIB确定
+	if cfb.IsCFB(header) {
+		return dp.parseCompoundHeader(filePath, docHeader)
 	} else if header[0] == 0x50 && header[1] == 0x4B {
 		docHeader.Version = DocVersion{Major: 12, Minor: 0, Platform: "Windows"}
 		docHeader.FileType = "Word 2007+"
@@ -348,6 +398,48 @@ func (dp *DocParser) parseDocHeader(filePath string) (*DocHeader, error) {
 	return docHeader, nil
 }
 
+// parseCompoundHeader 读取复合文件中WordDocument流开头的FIB，确定版本、模板和加密标志
+//
+// nFib早于Word 97的复合文件为Word 6.0/95文档。
+func (dp *DocParser) parseCompoundHeader(filePath string, docHeader *DocHeader) (*DocHeader, error) {
+	file, err := cfb.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	defer file.Close()
+
+	stream, err := file.Open("WordDocument")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	// FIB不超过WordDocument流开头的4KB
+	data := make([]byte, min(stream.Size(), 4096))
+	if _, err := stream.ReadAt(data, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	wb := &wordBinary{word: data}
+	err = wb.readFIB()
+	if errors.Is(err, errDocVersion) {
+		docHeader.Version = DocVersion{Major: 6, Minor: 0, Platform: "Windows"}
+		docHeader.FileType = "Word 6.0/95"
+		docHeader.DocumentType = "Document"
+		return docHeader, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+
+	docHeader.Version = DocVersion{Major: wb.fib.major(), Build: int(wb.fib.nFib), Platform: "Windows"}
+	docHeader.FileType = "Word 97-2003"
+	docHeader.DocumentType = "Document"
+	if wb.fib.flags&fibFlagTemplate != 0 {
+		docHeader.DocumentType = "Template"
+	}
+	docHeader.IsEncrypted = wb.fib.flags&(fibFlagEncrypted|fibFlagObfuscated) != 0
+	docHeader.HasPassword = docHeader.IsEncrypted
+	return docHeader, nil
+}
+
 // parseMetadata 解析元数据
 func (dp *DocParser) parseMetadata(filePath string, header *DocHeader) (*types.DocumentMetadata, error) {
 	metadata := &types.DocumentMetadata{}
@@ -368,7 +460,11 @@ func (dp *DocParser) parseMetadata(filePath string, header *DocHeader) (*types.D
 	// 根据版本解析不同的元数据
 	switch header.FileType {
 	case "Word 97-2003":
-		return dp.parseWord97Metadata(file, metadata)
+		doc, err := dp.parseWord97Document(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &doc.Metadata, nil
 	case "Word 2007+":
 		return dp.parseWord2007Metadata(file, metadata)
 	case "Word 6.0/95":
@@ -387,61 +483,6 @@ func (dp *DocParser) parseMetadata(filePath string, header *DocHeader) (*types.D
 	return metadata, nil
 }
 
-// parseWord97Metadata 解析Word 97-2003元数据
-func (dp *DocParser) parseWord97Metadata(file *os.File, metadata *types.DocumentMetadata) (*types.DocumentMetadata, error) {
-	// Word 97-2003使用OLE2复合文档格式
-	// 这里提供基础实现，实际需要更复杂的OLE2解析
-
-	// 跳过文件头
-	file.Seek(512, 0)
-
-	// 读取元数据区域
-	metaData := make([]byte, 256)
-	file.Read(metaData)
-
-	// 提取标题
-	if len(metaData) > 32 {
-		title := strings.TrimRight(string(metaData[32:64]), "\x00")
-		if title != "" {
-			metadata.Title = title
-		} else {
-			metadata.Title = "Word 97-2003 Document"
-		}
-	}
-
-	// 提取作者
-	if len(metaData) > 64 {
-		author := strings.TrimRight(string(metaData[64:96]), "\x00")
-		if author != "" {
-			metadata.Author = author
-		} else {
-			metadata.Author = "Unknown"
-		}
-	}
-
-	// 提取时间信息
-	if len(metaData) > 96 {
-		createdTime := dp.parseDocTime(metaData[96:104])
-		if !createdTime.IsZero() {
-			metadata.Created = createdTime
-		} else {
-			metadata.Created = time.Now()
-		}
-	}
-
-	if len(metaData) > 104 {
-		modifiedTime := dp.parseDocTime(metaData[104:112])
-		if !modifiedTime.IsZero() {
-			metadata.Modified = modifiedTime
-		} else {
-			metadata.Modified = time.Now()
-		}
-	}
-
-	metadata.Version = "8.0"
-	return metadata, nil
-}
-
 // parseWord2007Metadata 解析Word 2007+元数据
 func (dp *DocParser) parseWord2007Metadata(file *os.File, metadata *types.DocumentMetadata) (*types.DocumentMetadata, error) {
 	// Word 2007+使用ZIP格式
@@ -547,7 +588,11 @@ func (dp *DocParser) parseContent(filePath string, header *DocHeader) (*types.Do
 	// 根据版本解析不同的内容结构
 	switch header.FileType {
 	case "Word 97-2003":
-		return dp.parseWord97Content(filePath, content)
+		doc, err := dp.parseWord97Document(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &doc.Content, nil
 	case "Word 2007+":
 		return dp.parseWord2007Content(filePath, content)
 	case "Word 6.0/95":
@@ -566,40 +611,6 @@ func (dp *DocParser) parseContent(filePath string, header *DocHeader) (*types.Do
 	return content, nil
 }
 
-// parseWord97Content 解析Word 97-2003内容
-func (dp *DocParser) parseWord97Content(filePath string, content *types.DocumentContent) (*types.DocumentContent, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Word 97-2003使用OLE2格式，需要更复杂的解析
-	// 这里提供基础实现
-
-	// 跳过文件头
-	file.Seek(1024, 0)
-
-	// 读取文档内容区域
-	docContent := make([]byte, 2048)
-	file.Read(docContent)
-
-	// 解析段落 (简化实现)
-	paragraphs := dp.parseDocParagraphs(docContent)
-	content.Paragraphs = paragraphs
-
-	// 解析表格 (简化实现)
-	tables := dp.parseDocTables(docContent)
-	content.Tables = tables
-
-	// 解析页眉页脚 (简化实现)
-	headers, footers := dp.parseDocHeadersFooters(docContent)
-	content.Headers = headers
-	content.Footers = footers
-
-	return content, nil
-}
-
 // parseWord2007Content 解析Word 2007+内容
 func (dp *DocParser) parseWord2007Content(filePath string, content *types.DocumentContent) (*types.DocumentContent, error) {
 	// Word 2007+使用ZIP格式
@@ -721,42 +732,6 @@ func (dp *DocParser) parseDocTables(content []byte) []types.Table {
 	return tables
 }
 
-// parseDocHeadersFooters 解析.doc页眉页脚
-func (dp *DocParser) parseDocHeadersFooters(content []byte) ([]types.Header, []types.Footer) {
-	var headers []types.Header
-	var footers []types.Footer
-
-	// 简化的页眉页脚解析逻辑
-	// 在实际实现中，需要根据具体的Word版本格式进行详细解析
-
-	// 添加示例页眉
-	header := types.Header{
-		ID: "h1",
-		Content: []types.Paragraph{
-			{
-				ID:   "hp1",
-				Text: "Header content",
-			},
-		},
-	}
-
-	// 添加示例页脚
-	footer := types.Footer{
-		ID: "f1",
-		Content: []types.Paragraph{
-			{
-				ID:   "fp1",
-				Text: "Footer content",
-			},
-		},
-	}
-
-	headers = append(headers, header)
-	footers = append(footers, footer)
-
-	return headers, footers
-}
-
 // parseStyles 解析样式
 func (dp *DocParser) parseStyles(filePath string, header *DocHeader) (*types.DocumentStyles, error) {
 	styles := &types.DocumentStyles{}
@@ -764,7 +739,11 @@ func (dp *DocParser) parseStyles(filePath string, header *DocHeader) (*types.Doc
 	// 根据版本解析不同的样式
 	switch header.FileType {
 	case "Word 97-2003":
-		return dp.parseWord97Styles(filePath, styles)
+		doc, err := dp.parseWord97Document(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &doc.Styles, nil
 	case "Word 2007+":
 		return dp.parseWord2007Styles(filePath, styles)
 	case "Word 6.0/95":
@@ -781,16 +760,6 @@ func (dp *DocParser) parseStyles(filePath string, header *DocHeader) (*types.Doc
 	return styles, nil
 }
 
-// parseWord97Styles 解析Word 97-2003样式
-func (dp *DocParser) parseWord97Styles(filePath string, styles *types.DocumentStyles) (*types.DocumentStyles, error) {
-	// Word 97-2003样式解析
-	styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{ID: "ps1", Name: "Normal"})
-	styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: "cs1", Name: "Default Paragraph Font"})
-	styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: "ts1", Name: "Table Grid"})
-
-	return styles, nil
-}
-
 // parseWord2007Styles 解析Word 2007+样式
 func (dp *DocParser) parseWord2007Styles(filePath string, styles *types.DocumentStyles) (*types.DocumentStyles, error) {
 	// Word 2007+样式解析
@@ -828,7 +797,11 @@ func (dp *DocParser) parseFormatRules(filePath string, header *DocHeader) (*type
 	// 根据版本解析不同的格式规则
 	switch header.FileType {
 	case "Word 97-2003":
-		return dp.parseWord97FormatRules(filePath, formatRules)
+		doc, err := dp.parseWord97Document(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &doc.FormatRules, nil
 	case "Word 2007+":
 		return dp.parseWord2007FormatRules(filePath, formatRules)
 	case "Word 6.0/95":
@@ -846,17 +819,6 @@ func (dp *DocParser) parseFormatRules(filePath string, header *DocHeader) (*type
 	return formatRules, nil
 }
 
-// parseWord97FormatRules 解析Word 97-2003格式规则
-func (dp *DocParser) parseWord97FormatRules(filePath string, formatRules *types.FormatRules) (*types.FormatRules, error) {
-	// Word 97-2003格式规则
-	formatRules.FontRules = append(formatRules.FontRules, types.FontRule{ID: "fr1", Name: "Times New Roman", Size: 12.0})
-	formatRules.ParagraphRules = append(formatRules.ParagraphRules, types.ParagraphRule{ID: "pr1", Name: "Normal", Alignment: types.AlignLeft})
-	formatRules.TableRules = append(formatRules.TableRules, types.TableRule{ID: "tr1", Name: "Table Grid", Width: 100.0, Alignment: types.AlignLeft})
-	formatRules.PageRules = append(formatRules.PageRules, types.PageRule{ID: "pg1", Name: "Normal", PageSize: types.PageSize{Width: 612.0, Height: 792.0}, PageMargins: types.PageMargins{Top: 72.0, Bottom: 72.0, Left: 72.0, Right: 72.0, Header: 36.0, Footer: 36.0}})
-
-	return formatRules, nil
-}
-
 // parseWord2007FormatRules 解析Word 2007+格式规则
 func (dp *DocParser) parseWord2007FormatRules(filePath string, formatRules *types.FormatRules) (*types.FormatRules, error) {
 	// Word 2007+格式规则
@@ -0,0 +1,347 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/testutil"
+)
+
+// testBytes 按小端序拼接整数和字节切片
+func testBytes(values ...any) []byte {
+	var buf bytes.Buffer
+	for _, value := range values {
+		if data, ok := value.([]byte); ok {
+			buf.Write(data)
+			continue
+		}
+		binary.Write(&buf, binary.LittleEndian, value)
+	}
+	return buf.Bytes()
+}
+
+// testUTF16 返回UTF-16LE编码的文本
+func testUTF16(text string) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// testFKP 构造一个格式页，grpprls[i]为第i段的属性（段落页中以istd开头），nil表示没有属性
+func testFKP(fcs []uint32, grpprls [][]byte, paragraph bool) []byte {
+	page := make([]byte, 512)
+	crun := len(grpprls)
+	for i, fc := range fcs {
+		binary.LittleEndian.PutUint32(page[4*i:], fc)
+	}
+	entrySize := 1
+	if paragraph {
+		entrySize = 13
+	}
+
+	end := 511
+	for i, grpprl := range grpprls {
+		if grpprl == nil {
+			continue
+		}
+		var data []byte
+		switch {
+		case !paragraph:
+			data = append([]byte{byte(len(grpprl))}, grpprl...)
+		case len(grpprl)%2 == 1:
+			data = append([]byte{byte((len(grpprl) + 1) / 2)}, grpprl...)
+		default:
+			data = append([]byte{0, byte(len(grpprl) / 2)}, grpprl...)
+		}
+		end = (end - len(data)) &^ 1
+		copy(page[end:], data)
+		page[4*(crun+1)+entrySize*i] = byte(end / 2)
+	}
+	page[511] = byte(crun)
+	return page
+}
+
+// testStyle 构造STD：sti、stk、基础样式、名称和UPX
+func testStyle(sti, stk, base uint16, name string, upxs ...[]byte) []byte {
+	std := testBytes(sti, stk|base<<4, uint16(len(upxs)), uint16(0), uint16(0))
+	std = append(std, testBytes(uint16(len([]rune(name))), testUTF16(name), uint16(0))...)
+	for _, upx := range upxs {
+		std = append(std, testBytes(uint16(len(upx)), upx)...)
+		if len(upx)%2 == 1 {
+			std = append(std, 0)
+		}
+	}
+	return testBytes(uint16(len(std)), std)
+}
+
+// testFont 构造FFN
+func testFont(name string) []byte {
+	ffn := append(make([]byte, 39), testBytes(testUTF16(name), uint16(0))...)
+	return append([]byte{byte(len(ffn))}, ffn...)
+}
+
+// buildTestDoc 构造一个Word 2007保存的.doc文件
+//
+// 正文：UTF-16片段中的标题段落，压缩片段中的正文段落（含粗体文字）、一行两列的表格和带域的段落；
+// 页眉文字在另一个UTF-16片段中。flags为FIB中额外设置的标志位。
+func buildTestDoc(flags uint16) []byte {
+	le := binary.LittleEndian
+	heading := "标题一\r"
+	body := "Hello Bold world\rA1\aB1\a\aField \x13 PAGE \x141\x15 end\r"
+	header := "页眉\r"
+	ccpText := len([]rune(heading)) + len(body)
+
+	// WordDocument流：FIB、SEPX（0x600）、三个片段（0x800、0x900、0xA00）和两个格式页（第8、9页）
+	word := make([]byte, 0x1400)
+	const bodyFC = 0x900
+	copy(word[0x800:], testUTF16(heading))
+	copy(word[bodyFC:], body)
+	copy(word[0xA00:], testUTF16(header))
+	bodyEnd := uint32(bodyFC + len(body))
+
+	sepx := testBytes(uint16(0xB01F), uint16(11906), uint16(0xB020), uint16(16838), uint16(0x500B), uint16(1))
+	copy(word[0x600:], testBytes(uint16(len(sepx)), sepx))
+
+	tableRow := testBytes(uint16(0x2416), byte(1), uint16(0x2417), byte(1),
+		uint16(0xD608), uint16(8), byte(2), int16(0), int16(2880), int16(5760))
+	copy(word[0x1000:], testFKP(
+		[]uint32{0x800, 0x808, bodyFC, bodyFC + 17, bodyFC + 23, bodyFC + 24, bodyEnd, 0xA00, 0xA06},
+		[][]byte{
+			testBytes(uint16(1)),
+			nil,
+			testBytes(uint16(0), uint16(0x2403), byte(1), uint16(0xA414), uint16(240)),
+			testBytes(uint16(0), uint16(0x2416), byte(1)),
+			testBytes(uint16(0), tableRow),
+			nil,
+			nil,
+			nil,
+		}, true))
+	copy(word[0x1200:], testFKP(
+		[]uint32{0x800, bodyFC + 6, bodyFC + 10, 0xA06},
+		[][]byte{nil, testBytes(uint16(0x0835), byte(1), uint16(0x4A43), uint16(28), uint16(0x2A42), byte(6)), nil},
+		false))
+
+	// 表格流
+	var table bytes.Buffer
+	fcLcb := make([][2]uint32, 93)
+	add := func(index int, data []byte) {
+		fcLcb[index] = [2]uint32{uint32(table.Len()), uint32(len(data))}
+		table.Write(data)
+	}
+
+	stshi := testBytes(uint16(2), uint16(10), uint16(0), uint16(0), uint16(0), uint16(0), uint16(1), uint16(2), uint16(1))
+	var stsh []byte
+	stsh = append(stsh, testBytes(uint16(len(stshi)), stshi)...)
+	stsh = append(stsh, testStyle(0, 1, 0xFFF, "Normal", testBytes(uint16(0)), testBytes(uint16(0x4A43), uint16(24)))...)
+	stsh = append(stsh, testStyle(1, 1, 0, "标题 1",
+		testBytes(uint16(1), uint16(0x2406), byte(1), uint16(0x2640), byte(0)),
+		testBytes(uint16(0x0835), byte(1), uint16(0x4A43), uint16(32)))...)
+	add(1, stsh)
+
+	plcfSed := testBytes(uint32(0), uint32(ccpText), uint16(0), uint32(0x600), uint16(0), uint32(0xFFFFFFFF))
+	add(6, plcfSed)
+
+	var plcfHdd []byte
+	for i := 0; i < 14; i++ {
+		cp := uint32(0)
+		if i >= 8 {
+			cp = 3
+		}
+		plcfHdd = le.AppendUint32(plcfHdd, cp)
+	}
+	add(11, plcfHdd)
+	add(12, testBytes(uint32(0x800), uint32(0xA06), uint32(9)))
+	add(13, testBytes(uint32(0x800), uint32(0xA06), uint32(8)))
+
+	fonts := testBytes(uint16(3), uint16(0))
+	for _, name := range []string{"Symbol", "Times New Roman", "宋体"} {
+		fonts = append(fonts, testFont(name)...)
+	}
+	add(15, fonts)
+	add(21, testBytes(uint16(0xFFFF), uint16(1), uint16(0), uint16(5), testUTF16("_Toc1")))
+
+	plcPcd := testBytes(uint32(0), uint32(4), uint32(ccpText), uint32(ccpText+3),
+		uint16(0), uint32(0x800), uint16(0),
+		uint16(0), uint32(bodyFC*2|0x40000000), uint16(0),
+		uint16(0), uint32(0xA00), uint16(0))
+	add(33, testBytes(byte(2), uint32(len(plcPcd)), plcPcd))
+
+	// FIB
+	fib := testBytes(uint16(0xA5EC), uint16(0x00C1), uint16(0), uint16(0), uint16(0), uint16(0x0200|flags))
+	fib = append(fib, make([]byte, 32-len(fib))...)
+	fib = append(fib, testBytes(uint16(14), make([]byte, 28), uint16(22))...)
+	lw := make([]uint32, 22)
+	lw[3], lw[5] = uint32(ccpText), 3
+	fib = append(fib, testBytes(lw, uint16(len(fcLcb)))...)
+	for _, entry := range fcLcb {
+		fib = append(fib, testBytes(entry[0], entry[1])...)
+	}
+	fib = append(fib, testBytes(uint16(2), uint16(0x0112), uint16(0))...)
+	copy(word, fib)
+
+	// 摘要信息，字符串使用GBK代码页
+	encode := func(text string) []byte {
+		data, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(text))
+		data = append(data, 0)
+		return testBytes(uint16(0x1E), uint16(0), uint32(len(data)), data, make([]byte, (4-len(data)%4)%4))
+	}
+	values := []struct {
+		id    uint32
+		value []byte
+	}{
+		{1, testBytes(uint16(2), uint16(0), uint16(936), uint16(0))},
+		{2, encode("测试文档")},
+		{4, encode("张三")},
+		{9, encode("7")},
+		{12, testBytes(uint16(0x40), uint16(0), uint64(133000000000000000))},
+		{14, testBytes(uint16(3), uint16(0), int32(3))},
+	}
+	var properties, section []byte
+	offset := 8 + 8*len(values)
+	for _, property := range values {
+		section = append(section, testBytes(property.id, uint32(offset+len(properties)))...)
+		properties = append(properties, property.value...)
+	}
+	section = append(testBytes(uint32(8+len(section)+len(properties)), uint32(len(values))), section...)
+	section = append(section, properties...)
+	summary := testBytes(uint16(0xFFFE), uint16(0), uint32(0), make([]byte, 16), uint32(1), make([]byte, 16), uint32(48), section)
+
+	return testutil.CFBBytes(3,
+		testutil.CFBEntry{Name: "WordDocument", Data: word},
+		testutil.CFBEntry{Name: "1Table", Data: table.Bytes()},
+		testutil.CFBEntry{Name: "\x05SummaryInformation", Data: summary},
+	)
+}
+
+// writeTestDoc 将文件内容写入临时目录中的.doc文件
+func writeTestDoc(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.doc")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+// TestDocParser_Word97 测试读取Word 97-2003二进制文档的正文、表格、格式、样式、节和属性
+func TestDocParser_Word97(t *testing.T) {
+	path := writeTestDoc(t, buildTestDoc(0))
+	doc, err := NewDocParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析.doc文档失败: %v", err)
+	}
+
+	paragraphs := doc.Content.Paragraphs
+	wantTexts := []string{"标题一", "Hello Bold world", "Field 1 end"}
+	if len(paragraphs) != len(wantTexts) {
+		t.Fatalf("正文段落应为 %q，实际 %d 段", wantTexts, len(paragraphs))
+	}
+	for i, want := range wantTexts {
+		if paragraphs[i].Text != want {
+			t.Errorf("第%d段文本应为 %q，实际 %q", i+1, want, paragraphs[i].Text)
+		}
+	}
+
+	heading := paragraphs[0]
+	if heading.ID != "paragraph_1" || heading.Style.Name != "Heading1" || types.ParagraphRole(&heading) != "heading1" {
+		t.Errorf("标题段落的ID或样式不正确: %s %q", heading.ID, heading.Style.Name)
+	}
+	if !heading.KeepNext || heading.OutlineLevel != 1 {
+		t.Errorf("标题段落应继承样式的与下段同页和大纲级别: %+v", heading)
+	}
+	if run := heading.Runs[0]; run.Font.Name != "宋体" || run.Size != 16 || !run.Bold {
+		t.Errorf("标题文本应为宋体16磅粗体，实际 %q %.1f %v", run.Font.Name, run.Size, run.Bold)
+	}
+
+	normal := paragraphs[1]
+	if normal.Style.Name != "" || normal.Alignment != types.AlignCenter || normal.Spacing.After != 12 {
+		t.Errorf("正文段落的格式不正确: %q %s %.1f", normal.Style.Name, normal.Alignment, normal.Spacing.After)
+	}
+	if len(normal.Runs) != 3 || normal.Runs[1].Text != "Bold" {
+		t.Fatalf("正文段落应分为3个文本运行，实际 %+v", normal.Runs)
+	}
+	bold, plain := normal.Runs[1], normal.Runs[0]
+	if !bold.Bold || bold.Size != 14 || bold.Color.RGB != "FF0000" || bold.ID != "run_2_2" {
+		t.Errorf("粗体文本的格式不正确: %+v", bold)
+	}
+	if plain.Bold || plain.Size != 12 || plain.Font.Name != "Times New Roman" {
+		t.Errorf("普通文本的格式不正确: %+v", plain)
+	}
+
+	if len(doc.Content.Tables) != 1 {
+		t.Fatalf("应有1个表格，实际 %d", len(doc.Content.Tables))
+	}
+	table := doc.Content.Tables[0]
+	if len(table.Rows) != 1 || len(table.Rows[0].Cells) != 2 {
+		t.Fatalf("表格应为1行2列: %+v", table)
+	}
+	for k, want := range []string{"A1", "B1"} {
+		cell := table.Rows[0].Cells[k]
+		if len(cell.Content) != 1 || cell.Content[0].Text != want || cell.Width != 144 {
+			t.Errorf("单元格%d应为 %q、宽144磅，实际 %+v", k+1, want, cell)
+		}
+	}
+	if table.ID != "table_1" || table.Rows[0].Cells[1].ID != "cell_1_1_2" || table.Width != 288 {
+		t.Errorf("表格ID或宽度不正确: %s %s %.1f", table.ID, table.Rows[0].Cells[1].ID, table.Width)
+	}
+
+	if len(doc.Content.Headers) != 1 || doc.Content.Headers[0].Content[0].Text != "页眉" {
+		t.Errorf("页眉应为 \"页眉\"，实际 %+v", doc.Content.Headers)
+	}
+	if len(doc.Content.Bookmarks) != 1 || doc.Content.Bookmarks[0].Name != "_Toc1" {
+		t.Errorf("书签不正确: %+v", doc.Content.Bookmarks)
+	}
+
+	sections := doc.Content.Sections
+	if len(sections) != 1 || sections[0].PageSize.Width != 595.3 || sections[0].Columns.Count != 2 || sections[0].PageMargins.Left != 90 {
+		t.Errorf("节属性不正确: %+v", sections)
+	}
+	if len(doc.FormatRules.PageRules) != 1 || doc.FormatRules.PageRules[0].PageSize.Height != 841.9 {
+		t.Errorf("页面规则不正确: %+v", doc.FormatRules.PageRules)
+	}
+	if len(doc.FormatRules.ParagraphRules) != 3 || doc.FormatRules.ParagraphRules[0].Name != "Heading1" {
+		t.Errorf("段落规则不正确: %+v", doc.FormatRules.ParagraphRules)
+	}
+	if len(doc.FormatRules.FontRules) != 3 {
+		t.Errorf("字体规则应来自字体表，实际 %+v", doc.FormatRules.FontRules)
+	}
+
+	styles := doc.Styles.ParagraphStyles
+	if len(styles) != 2 || styles[1].ID != "Heading1" || styles[1].Name != "标题 1" || !styles[1].Font.Bold || styles[1].Font.Size != 16 {
+		t.Errorf("段落样式不正确: %+v", styles)
+	}
+
+	metadata := doc.Metadata
+	if metadata.Title != "测试文档" || metadata.Author != "张三" || metadata.Revision != 7 || metadata.PageCount != 3 || metadata.Version != "12.0" {
+		t.Errorf("文档属性不正确: %+v", metadata)
+	}
+	if metadata.Created.Year() != 2022 {
+		t.Errorf("创建时间不正确: %v", metadata.Created)
+	}
+}
+
+// TestDocParser_Word97Errors 测试加密文档和损坏的片段表
+func TestDocParser_Word97Errors(t *testing.T) {
+	if _, err := NewDocParser().ParseContent(writeTestDoc(t, buildTestDoc(0x0100))); !errors.Is(err, ErrEncryptedDoc) {
+		t.Errorf("加密文档应返回ErrEncryptedDoc，实际 %v", err)
+	}
+
+	data := buildTestDoc(0)
+	// 将片段表中第二个片段的文件偏移改到WordDocument流之外
+	index := bytes.Index(data, testBytes(uint32(0x900*2|0x40000000)))
+	binary.LittleEndian.PutUint32(data[index:], 0x100000*2|0x40000000)
+	if _, err := NewDocParser().ParseContent(writeTestDoc(t, data)); !errors.Is(err, parser.ErrInvalidFile) {
+		t.Errorf("损坏的片段表应返回ErrInvalidFile，实际 %v", err)
+	}
+}
@@ -0,0 +1,1692 @@
+package formats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/cfb"
+)
+
+// Word 97-2003二进制文档（MS-DOC）的读取
+//
+// WordDocument流以文件信息块（FIB）开头，FIB给出表格流（0Table或1Table）中各结构的位置：
+// CLX中的片段表把字符位置（CP）映射到WordDocument流中的文本，CHPX/PAPX格式页（FKP）按文件偏移（FC）
+// 记录字符和段落属性，STSH为样式表，PlcfSed指向各节的属性（SEPX），SttbfFfn为字体表。
+
+// ErrEncryptedDoc 文档已加密或混淆，无法读取正文
+var ErrEncryptedDoc = errors.New("encrypted Word documents are not supported")
+
+// errDocStructure 二进制文档中的结构越界或损坏
+var errDocStructure = errors.New("corrupt Word binary structure")
+
+// errDocVersion FIB版本早于Word 97
+var errDocVersion = errors.New("Word binary format older than Word 97")
+
+var le = binary.LittleEndian
+
+// FIB中的标识和标志位
+const (
+	fibIdent          = 0xA5EC
+	fibMinWord97      = 0x00C1
+	fibFlagTemplate   = 0x0001 // fDot
+	fibFlagEncrypted  = 0x0100 // fEncrypted
+	fibFlagTable1     = 0x0200 // fWhichTblStm，表格流为1Table
+	fibFlagObfuscated = 0x8000 // fObfuscated
+)
+
+// FibRgFcLcb97中各结构的序号
+const (
+	fcLcbStshf       = 1
+	fcLcbPlcfSed     = 6
+	fcLcbPlcfHdd     = 11
+	fcLcbPlcfBteChpx = 12
+	fcLcbPlcfBtePapx = 13
+	fcLcbSttbfFfn    = 15
+	fcLcbSttbfBkmk   = 21
+	fcLcbClx         = 33
+)
+
+// 正文中的特殊字符
+const (
+	docCharPicture      = 0x01
+	docCharTab          = 0x09
+	docCharLineBreak    = 0x0B
+	docCharPageBreak    = 0x0C
+	docCharParagraph    = 0x0D
+	docCharCell         = 0x07
+	docCharFieldBegin   = 0x13
+	docCharFieldSep     = 0x14
+	docCharFieldEnd     = 0x15
+	docCharNonBreakHyph = 0x1E
+	docCharOptionalHyph = 0x1F
+)
+
+// 字符属性的Sprm
+const (
+	sprmCFSpec       = 0x0855
+	sprmCPicLocation = 0x6A03
+	sprmCIstd        = 0x4A30
+	sprmCFBold       = 0x0835
+	sprmCFItalic     = 0x0836
+	sprmCKul         = 0x2A3E
+	sprmCIco         = 0x2A42
+	sprmCHps         = 0x4A43
+	sprmCIss         = 0x2A48
+	sprmCRgFtc0      = 0x4A4F
+	sprmCRgFtc1      = 0x4A50
+	sprmCRgFtc2      = 0x4A51
+	sprmCHighlight   = 0x2A0C
+	sprmCCv          = 0x6870
+)
+
+// 段落和表格属性的Sprm
+const (
+	sprmPJc80             = 0x2403
+	sprmPFKeep            = 0x2405
+	sprmPFKeepFollow      = 0x2406
+	sprmPFPageBreakBefore = 0x2407
+	sprmPDxaRight80       = 0x840E
+	sprmPDxaLeft80        = 0x840F
+	sprmPDxaLeft180       = 0x8411
+	sprmPDyaLine          = 0x6412
+	sprmPDyaBefore        = 0xA413
+	sprmPDyaAfter         = 0xA414
+	sprmPFInTable         = 0x2416
+	sprmPFTtp             = 0x2417
+	sprmPOutLvl           = 0x2640
+	sprmPDxaRight         = 0x845D
+	sprmPDxaLeft          = 0x845E
+	sprmPDxaLeft1         = 0x8460
+	sprmPJc               = 0x2461
+	sprmPItap             = 0x6649
+	sprmPChgTabs          = 0xC615
+	sprmTTableHeader      = 0x3404
+	sprmTDyaRowHeight     = 0x9407
+	sprmTDefTable10       = 0xD606
+	sprmTDefTable         = 0xD608
+)
+
+// 节属性的Sprm
+const (
+	sprmSFEvenlySpaced = 0x3005
+	sprmSCcolumns      = 0x500B
+	sprmSDxaColumns    = 0x900C
+	sprmSNfcPgn        = 0x300E
+	sprmSFPgnRestart   = 0x3011
+	sprmSLnc           = 0x3013
+	sprmSNLnnMod       = 0x5015
+	sprmSDyaHdrTop     = 0xB017
+	sprmSDyaHdrBottom  = 0xB018
+	sprmSLnnMin        = 0x501B
+	sprmSPgnStart      = 0x501C
+	sprmSXaPage        = 0xB01F
+	sprmSYaPage        = 0xB020
+	sprmSDxaLeft       = 0xB021
+	sprmSDxaRight      = 0xB022
+	sprmSDyaTop        = 0x9023
+	sprmSDyaBottom     = 0x9024
+)
+
+// docFIB 文件信息块中用到的字段
+type docFIB struct {
+	nFib    uint16
+	nFibNew uint16
+	flags   uint16
+	ccpText int32
+	ccpFtn  int32
+	ccpHdd  int32
+	fcLcb   [][2]uint32
+}
+
+// entry 返回FibRgFcLcb中第index个结构在表格流中的偏移和长度
+func (f *docFIB) entry(index int) (uint32, uint32) {
+	if index >= len(f.fcLcb) {
+		return 0, 0
+	}
+	return f.fcLcb[index][0], f.fcLcb[index][1]
+}
+
+// major 返回保存文档的Word主版本号，Word 2000起FibBase中的nFib固定为0x00C1，实际版本由nFibNew给出
+func (f *docFIB) major() int {
+	n := f.nFib
+	if f.nFibNew != 0 {
+		n = f.nFibNew
+	}
+	switch {
+	case n >= 0x0112:
+		return 12
+	case n >= 0x010C:
+		return 11
+	case n >= 0x0101:
+		return 10
+	case n >= 0x00D9:
+		return 9
+	}
+	return 8
+}
+
+// version 返回保存文档的Word版本号
+func (f *docFIB) version() string {
+	return fmt.Sprintf("%d.0", f.major())
+}
+
+// docPiece 片段表中的一段文本
+type docPiece struct {
+	cpStart, cpEnd int32
+	fc             uint32 // 文本在WordDocument流中的字节偏移
+	compressed     bool   // 每个字符一个字节（Windows-1252），否则为UTF-16LE
+	prm            uint16 // 作用于整段文本的属性修改
+}
+
+// docChar 文档中的一个字符（UTF-16码元）及其位置
+type docChar struct {
+	unit  uint16
+	fc    uint32
+	piece int
+}
+
+// docFormatRun FKP中一段文件偏移范围的属性
+type docFormatRun struct {
+	fcStart, fcEnd uint32
+	istd           uint16 // 段落样式，仅PAPX
+	grpprl         []byte
+}
+
+// docStyle STSH中的样式
+type docStyle struct {
+	name string
+	sti  uint16 // 内置样式标识，0x0FFE为用户定义样式
+	kind uint16 // stk：1段落、2字符、3表格、4编号
+	base uint16 // istdBase，0x0FFF表示没有基础样式
+	papx []byte
+	chpx []byte
+}
+
+// docCharProps 字符属性
+type docCharProps struct {
+	fonts      [3]uint16 // 西文、东亚和其他文字的字体序号
+	halfPoints uint16
+	bold       bool
+	italic     bool
+	underline  types.Underline
+	color      string
+	highlight  types.Highlight
+	position   types.Position
+	special    bool   // fSpec，特殊字符（如图片）
+	picture    uint32 // 图片在Data流中的偏移
+}
+
+// docParaProps 段落属性
+type docParaProps struct {
+	istd        uint16
+	alignment   types.Alignment
+	indentation types.Indentation
+	spacing     types.Spacing
+	keepLines   bool
+	keepNext    bool
+	pageBreak   bool
+	outline     int
+	inTable     bool
+	rowEnd      bool // fTtp，表格行结束段落
+	depth       int32
+	cellWidths  []float64
+	rowHeight   float64
+	header      bool
+}
+
+// docRun 段落中属性相同的一段文本
+type docRun struct {
+	text  []uint16
+	props docCharProps
+}
+
+// docParagraph 由段落标记（或单元格标记）结束的段落
+type docParagraph struct {
+	runs      []docRun
+	props     docParaProps
+	mark      uint16
+	pageBreak bool
+	images    []uint32
+}
+
+// docSection 以cpEnd结束的节
+type docSection struct {
+	cpEnd   int32
+	section types.Section
+}
+
+// wordBinary 读取后的二进制Word文档
+type wordBinary struct {
+	file     *cfb.File
+	word     []byte
+	table    []byte
+	data     []byte
+	fib      docFIB
+	pieces   []docPiece
+	prcs     [][]byte
+	papx     []docFormatRun
+	chpx     []docFormatRun
+	styles   []*docStyle
+	fonts    []string
+	sections []docSection
+	defaults docCharProps
+}
+
+// readWordBinary 读取复合文件中的Word文档结构
+//
+// FIB和片段表损坏时返回错误；格式页、样式表、节属性和字体表损坏时只忽略对应的格式信息，正文仍然可以读取。
+func readWordBinary(file *cfb.File) (*wordBinary, error) {
+	wb := &wordBinary{file: file}
+	word, err := file.ReadStream("WordDocument")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WordDocument stream: %w", err)
+	}
+	wb.word = word
+	if err := wb.readFIB(); err != nil {
+		return nil, err
+	}
+	if wb.fib.flags&(fibFlagEncrypted|fibFlagObfuscated) != 0 {
+		return nil, ErrEncryptedDoc
+	}
+
+	tableName := "0Table"
+	if wb.fib.flags&fibFlagTable1 != 0 {
+		tableName = "1Table"
+	}
+	if wb.table, err = file.ReadStream(tableName); err != nil {
+		return nil, fmt.Errorf("failed to read %s stream: %w", tableName, err)
+	}
+	if data, err := file.ReadStream("Data"); err == nil {
+		wb.data = data
+	}
+
+	if err := wb.readPieces(); err != nil {
+		return nil, err
+	}
+	wb.fonts = wb.readFonts()
+	wb.readStyles()
+	wb.papx, _ = wb.readFKPs(fcLcbPlcfBtePapx, true)
+	wb.chpx, _ = wb.readFKPs(fcLcbPlcfBteChpx, false)
+	wb.sections = wb.readSections()
+	return wb, nil
+}
+
+// tableSlice 返回表格流中的一段数据
+func (wb *wordBinary) tableSlice(fc, lcb uint32) ([]byte, error) {
+	if uint64(fc)+uint64(lcb) > uint64(len(wb.table)) {
+		return nil, fmt.Errorf("%w: table stream range %d+%d is outside the stream", errDocStructure, fc, lcb)
+	}
+	return wb.table[fc : fc+lcb], nil
+}
+
+// readFIB 读取FIB：FibBase、FibRgW97、FibRgLw97和FibRgFcLcb
+func (wb *wordBinary) readFIB() error {
+	w := wb.word
+	if len(w) < 34 {
+		return fmt.Errorf("%w: FIB is truncated", errDocStructure)
+	}
+	if ident := le.Uint16(w); ident != fibIdent {
+		return fmt.Errorf("%w: bad FIB identifier %#x", errDocStructure, ident)
+	}
+	fib := docFIB{nFib: le.Uint16(w[2:]), flags: le.Uint16(w[10:])}
+	if fib.nFib < fibMinWord97 {
+		return errDocVersion
+	}
+
+	pos := 32
+	pos += 2 + 2*int(le.Uint16(w[pos:]))
+	if pos+2 > len(w) {
+		return fmt.Errorf("%w: FIB is truncated", errDocStructure)
+	}
+	cslw := int(le.Uint16(w[pos:]))
+	pos += 2
+	lw := pos
+	pos += 4 * cslw
+	if pos+2 > len(w) || cslw < 6 {
+		return fmt.Errorf("%w: FIB is truncated", errDocStructure)
+	}
+	fib.ccpText = int32(le.Uint32(w[lw+12:]))
+	fib.ccpFtn = int32(le.Uint32(w[lw+16:]))
+	fib.ccpHdd = int32(le.Uint32(w[lw+20:]))
+
+	count := int(le.Uint16(w[pos:]))
+	pos += 2
+	if pos+8*count > len(w) {
+		return fmt.Errorf("%w: FIB is truncated", errDocStructure)
+	}
+	fib.fcLcb = make([][2]uint32, count)
+	for i := range fib.fcLcb {
+		fib.fcLcb[i] = [2]uint32{le.Uint32(w[pos+8*i:]), le.Uint32(w[pos+8*i+4:])}
+	}
+	pos += 8 * count
+	if pos+4 <= len(w) && le.Uint16(w[pos:]) > 0 {
+		fib.nFibNew = le.Uint16(w[pos+2:])
+	}
+
+	if fib.ccpText < 0 || fib.ccpFtn < 0 || fib.ccpHdd < 0 {
+		return fmt.Errorf("%w: negative text length in FIB", errDocStructure)
+	}
+	wb.fib = fib
+	return nil
+}
+
+// readPieces 读取CLX：属性修改列表（Prc）和片段表（Pcdt）
+func (wb *wordBinary) readPieces() error {
+	clx, err := wb.tableSlice(wb.fib.entry(fcLcbClx))
+	if err != nil {
+		return err
+	}
+
+	pos := 0
+	for pos < len(clx) {
+		switch clx[pos] {
+		case 0x01:
+			if pos+3 > len(clx) {
+				return fmt.Errorf("%w: truncated Prc", errDocStructure)
+			}
+			size := int(int16(le.Uint16(clx[pos+1:])))
+			if size < 0 || pos+3+size > len(clx) {
+				return fmt.Errorf("%w: Prc is outside the CLX", errDocStructure)
+			}
+			wb.prcs = append(wb.prcs, clx[pos+3:pos+3+size])
+			pos += 3 + size
+		case 0x02:
+			if pos+5 > len(clx) {
+				return fmt.Errorf("%w: truncated piece table", errDocStructure)
+			}
+			size := le.Uint32(clx[pos+1:])
+			plc := clx[pos+5:]
+			if uint64(size) > uint64(len(plc)) || size < 4 || (size-4)%12 != 0 {
+				return fmt.Errorf("%w: bad piece table size %d", errDocStructure, size)
+			}
+			return wb.readPieceTable(plc[:size])
+		default:
+			return fmt.Errorf("%w: unknown CLX entry %#x", errDocStructure, clx[pos])
+		}
+	}
+	return fmt.Errorf("%w: CLX has no piece table", errDocStructure)
+}
+
+// readPieceTable 读取PlcPcd，检查每个片段都在WordDocument流中
+func (wb *wordBinary) readPieceTable(plc []byte) error {
+	n := (len(plc) - 4) / 12
+	for i := 0; i < n; i++ {
+		piece := docPiece{
+			cpStart: int32(le.Uint32(plc[4*i:])),
+			cpEnd:   int32(le.Uint32(plc[4*(i+1):])),
+		}
+		pcd := plc[4*(n+1)+8*i:]
+		fc := le.Uint32(pcd[2:])
+		piece.prm = le.Uint16(pcd[6:])
+		piece.compressed = fc&0x40000000 != 0
+		piece.fc = fc & 0x3FFFFFFF
+		width := uint64(2)
+		if piece.compressed {
+			piece.fc /= 2
+			width = 1
+		}
+
+		if piece.cpStart < 0 || piece.cpEnd < piece.cpStart {
+			return fmt.Errorf("%w: piece %d has bad character positions", errDocStructure, i)
+		}
+		if uint64(piece.fc)+width*uint64(piece.cpEnd-piece.cpStart) > uint64(len(wb.word)) {
+			return fmt.Errorf("%w: piece %d is outside the WordDocument stream", errDocStructure, i)
+		}
+		wb.pieces = append(wb.pieces, piece)
+	}
+	return nil
+}
+
+// cp1252Specials 压缩片段中0x80-0x9F字节对应的字符
+var cp1252Specials = map[byte]uint16{
+	0x82: 0x201A, 0x83: 0x0192, 0x84: 0x201E, 0x85: 0x2026, 0x86: 0x2020, 0x87: 0x2021,
+	0x88: 0x02C6, 0x89: 0x2030, 0x8A: 0x0160, 0x8B: 0x2039, 0x8C: 0x0152, 0x91: 0x2018,
+	0x92: 0x2019, 0x93: 0x201C, 0x94: 0x201D, 0x95: 0x2022, 0x96: 0x2013, 0x97: 0x2014,
+	0x98: 0x02DC, 0x99: 0x2122, 0x9A: 0x0161, 0x9B: 0x203A, 0x9C: 0x0153, 0x9F: 0x0178,
+}
+
+// chars 返回字符位置[start, end)中的字符
+func (wb *wordBinary) chars(start, end int32) []docChar {
+	var chars []docChar
+	for i, piece := range wb.pieces {
+		from, to := max(start, piece.cpStart), min(end, piece.cpEnd)
+		for cp := from; cp < to; cp++ {
+			offset := uint32(cp - piece.cpStart)
+			if piece.compressed {
+				fc := piece.fc + offset
+				unit := uint16(wb.word[fc])
+				if special, ok := cp1252Specials[wb.word[fc]]; ok {
+					unit = special
+				}
+				chars = append(chars, docChar{unit: unit, fc: fc, piece: i})
+			} else {
+				fc := piece.fc + 2*offset
+				chars = append(chars, docChar{unit: le.Uint16(wb.word[fc:]), fc: fc, piece: i})
+			}
+		}
+	}
+	return chars
+}
+
+// readFKPs 读取PlcBteChpx或PlcBtePapx指向的所有格式页
+func (wb *wordBinary) readFKPs(index int, paragraph bool) ([]docFormatRun, error) {
+	plc, err := wb.tableSlice(wb.fib.entry(index))
+	if err != nil || len(plc) < 4 {
+		return nil, err
+	}
+
+	var runs []docFormatRun
+	n := (len(plc) - 4) / 8
+	for i := 0; i < n; i++ {
+		pn := le.Uint32(plc[4*(n+1)+4*i:]) & 0x3FFFFF
+		offset := uint64(pn) * 512
+		if offset+512 > uint64(len(wb.word)) {
+			return runs, fmt.Errorf("%w: FKP page %d is outside the WordDocument stream", errDocStructure, pn)
+		}
+		page := wb.word[offset : offset+512]
+		crun := int(page[511])
+		entrySize := 1
+		if paragraph {
+			entrySize = 13
+		}
+		if 4*(crun+1)+entrySize*crun > 511 {
+			return runs, fmt.Errorf("%w: FKP page %d has too many runs", errDocStructure, pn)
+		}
+
+		for j := 0; j < crun; j++ {
+			run := docFormatRun{fcStart: le.Uint32(page[4*j:]), fcEnd: le.Uint32(page[4*(j+1):])}
+			b := 2 * int(page[4*(crun+1)+entrySize*j])
+			if paragraph {
+				run.istd, run.grpprl = papxInFkp(page, b)
+			} else if b != 0 && b < 511 && b+1+int(page[b]) <= 511 {
+				run.grpprl = page[b+1 : b+1+int(page[b])]
+			}
+			runs = append(runs, run)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].fcStart < runs[j].fcStart })
+	return runs, nil
+}
+
+// papxInFkp 读取FKP中偏移b处的PapxInFkp，返回段落样式和属性修改
+func papxInFkp(page []byte, b int) (uint16, []byte) {
+	if b == 0 || b >= 511 {
+		return 0, nil
+	}
+	start, size := b+1, 2*int(page[b])-1
+	if page[b] == 0 {
+		if b+1 >= 511 {
+			return 0, nil
+		}
+		start, size = b+2, 2*int(page[b+1])
+	}
+	if size < 2 || start+size > 511 {
+		return 0, nil
+	}
+	return le.Uint16(page[start:]), page[start+2 : start+size]
+}
+
+// findRun 查找包含文件偏移fc的格式段
+func findRun(runs []docFormatRun, fc uint32) int {
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].fcEnd > fc })
+	if i < len(runs) && runs[i].fcStart <= fc {
+		return i
+	}
+	return -1
+}
+
+// eachSprm 依次处理grpprl中的属性修改，遇到无法确定长度的操作数时停止
+func eachSprm(grpprl []byte, fn func(sprm uint16, operand []byte)) {
+	pos := 0
+	for pos+2 <= len(grpprl) {
+		sprm := le.Uint16(grpprl[pos:])
+		pos += 2
+		size := sprmOperandSize(sprm, grpprl[pos:])
+		if size < 0 || pos+size > len(grpprl) {
+			return
+		}
+		fn(sprm, grpprl[pos:pos+size])
+		pos += size
+	}
+}
+
+// sprmOperandSize 返回Sprm操作数的字节数，由Sprm的spra位决定
+func sprmOperandSize(sprm uint16, rest []byte) int {
+	switch sprm >> 13 {
+	case 0, 1:
+		return 1
+	case 2, 4, 5:
+		return 2
+	case 3:
+		return 4
+	case 7:
+		return 3
+	}
+
+	switch sprm {
+	case sprmTDefTable, sprmTDefTable10:
+		if len(rest) < 2 {
+			return -1
+		}
+		return int(le.Uint16(rest)) + 1
+	case sprmPChgTabs:
+		if len(rest) < 1 || rest[0] == 255 {
+			return -1
+		}
+	}
+	if len(rest) < 1 {
+		return -1
+	}
+	return int(rest[0]) + 1
+}
+
+// operandInt 以有符号整数读取1、2或4字节的操作数
+func operandInt(operand []byte) int {
+	switch len(operand) {
+	case 1:
+		return int(operand[0])
+	case 2:
+		return int(int16(le.Uint16(operand)))
+	case 4:
+		return int(int32(le.Uint32(operand)))
+	}
+	return 0
+}
+
+// readFonts 读取SttbfFfn中的字体名称
+func (wb *wordBinary) readFonts() []string {
+	data, err := wb.tableSlice(wb.fib.entry(fcLcbSttbfFfn))
+	if err != nil || len(data) < 4 {
+		return nil
+	}
+
+	pos := 4
+	count := int(le.Uint16(data))
+	if count == 0xFFFF {
+		if len(data) < 6 {
+			return nil
+		}
+		count, pos = int(le.Uint16(data[2:])), 6
+	}
+
+	var fonts []string
+	for i := 0; i < count && pos < len(data); i++ {
+		size := int(data[pos])
+		if pos+1+size > len(data) {
+			break
+		}
+		ffn := data[pos+1 : pos+1+size]
+		pos += 1 + size
+
+		// FFN的固定部分为39字节，其后是以空字符结尾的UTF-16字体名称
+		var units []uint16
+		for j := 39; j+1 < len(ffn); j += 2 {
+			unit := le.Uint16(ffn[j:])
+			if unit == 0 {
+				break
+			}
+			units = append(units, unit)
+		}
+		fonts = append(fonts, string(utf16.Decode(units)))
+	}
+	return fonts
+}
+
+// readStyles 读取STSH中的样式和默认字体
+func (wb *wordBinary) readStyles() {
+	wb.defaults = docCharProps{halfPoints: 20}
+	data, err := wb.tableSlice(wb.fib.entry(fcLcbStshf))
+	if err != nil || len(data) < 2 {
+		return
+	}
+	size := int(le.Uint16(data))
+	if size < 4 || 2+size > len(data) {
+		return
+	}
+	stshi := data[2 : 2+size]
+	count := int(le.Uint16(stshi))
+	baseSize := int(le.Uint16(stshi[2:]))
+	if size >= 18 {
+		for i := range wb.defaults.fonts {
+			wb.defaults.fonts[i] = le.Uint16(stshi[12+2*i:])
+		}
+	}
+
+	pos := 2 + size
+	for i := 0; i < count && pos+2 <= len(data); i++ {
+		cb := int(le.Uint16(data[pos:]))
+		pos += 2
+		if pos+cb > len(data) {
+			break
+		}
+		wb.styles = append(wb.styles, parseDocStyle(data[pos:pos+cb], baseSize))
+		pos += cb
+	}
+}
+
+// parseDocStyle 解析STD：StdfBase、样式名称和各UPX
+func parseDocStyle(std []byte, baseSize int) *docStyle {
+	if len(std) < 10 || baseSize < 10 || baseSize+2 > len(std) {
+		return nil
+	}
+	style := &docStyle{
+		sti:  le.Uint16(std) & 0x0FFF,
+		kind: le.Uint16(std[2:]) & 0x000F,
+		base: le.Uint16(std[2:]) >> 4,
+	}
+	cupx := int(le.Uint16(std[4:]) & 0x000F)
+
+	pos := baseSize
+	cch := int(le.Uint16(std[pos:]))
+	pos += 2
+	if pos+2*cch > len(std) {
+		return style
+	}
+	units := make([]uint16, cch)
+	for i := range units {
+		units[i] = le.Uint16(std[pos+2*i:])
+	}
+	style.name = string(utf16.Decode(units))
+	pos += 2*cch + 2
+
+	var upxs [][]byte
+	for i := 0; i < cupx; i++ {
+		pos += pos % 2
+		if pos+2 > len(std) {
+			break
+		}
+		cb := int(le.Uint16(std[pos:]))
+		pos += 2
+		if pos+cb > len(std) {
+			break
+		}
+		upxs = append(upxs, std[pos:pos+cb])
+		pos += cb
+	}
+
+	// 段落样式为UpxPapx、UpxChpx，字符样式为UpxChpx，表格样式为UpxTapx、UpxPapx、UpxChpx
+	upx := func(i int) []byte {
+		if i < len(upxs) {
+			return upxs[i]
+		}
+		return nil
+	}
+	switch style.kind {
+	case 1:
+		style.papx, style.chpx = upx(0), upx(1)
+	case 2:
+		style.chpx = upx(0)
+	case 3:
+		style.papx, style.chpx = upx(1), upx(2)
+	case 4:
+		style.papx = upx(0)
+	}
+	if len(style.papx) >= 2 {
+		// UpxPapx以段落样式序号开头
+		style.papx = style.papx[2:]
+	}
+	return style
+}
+
+// style 返回序号为istd的样式，不存在时返回nil
+func (wb *wordBinary) style(istd uint16) *docStyle {
+	if int(istd) < len(wb.styles) && wb.styles[istd] != nil {
+		return wb.styles[istd]
+	}
+	return nil
+}
+
+// styleChain 返回从最底层基础样式到istd的样式链，循环的继承在重复处截断
+func (wb *wordBinary) styleChain(istd uint16) []*docStyle {
+	var chain []*docStyle
+	seen := make(map[uint16]bool)
+	for style := wb.style(istd); style != nil && !seen[istd]; style = wb.style(istd) {
+		seen[istd] = true
+		chain = append([]*docStyle{style}, chain...)
+		istd = style.base
+	}
+	return chain
+}
+
+// styleProps 返回样式链合并后的段落和字符属性
+func (wb *wordBinary) styleProps(istd uint16) (docParaProps, docCharProps) {
+	para := docParaProps{istd: istd, alignment: types.AlignLeft, outline: 9}
+	chars := wb.defaults
+	for _, style := range wb.styleChain(istd) {
+		para.apply(style.papx)
+		base := chars
+		chars.apply(style.chpx, &base)
+	}
+	return para, chars
+}
+
+// characterStyleProps 在字符属性上应用字符样式链
+func (wb *wordBinary) characterStyleProps(istd uint16, chars docCharProps) docCharProps {
+	for _, style := range wb.styleChain(istd) {
+		if style.kind == 2 {
+			base := chars
+			chars.apply(style.chpx, &base)
+		}
+	}
+	return chars
+}
+
+// pieceGrpprl 返回片段的属性修改，只支持引用Prc的复杂Prm
+func (wb *wordBinary) pieceGrpprl(piece int) []byte {
+	prm := wb.pieces[piece].prm
+	if prm&1 == 0 {
+		return nil
+	}
+	if index := int(prm >> 1); index < len(wb.prcs) {
+		return wb.prcs[index]
+	}
+	return nil
+}
+
+// apply 应用字符属性修改，base为切换类属性（0x80、0x81）参照的样式属性
+func (p *docCharProps) apply(grpprl []byte, base *docCharProps) {
+	toggle := func(operand []byte, styleValue bool) bool {
+		switch operand[0] {
+		case 0x80:
+			return styleValue
+		case 0x81:
+			return !styleValue
+		}
+		return operand[0] == 1
+	}
+
+	eachSprm(grpprl, func(sprm uint16, operand []byte) {
+		switch sprm {
+		case sprmCFBold:
+			p.bold = toggle(operand, base.bold)
+		case sprmCFItalic:
+			p.italic = toggle(operand, base.italic)
+		case sprmCHps:
+			p.halfPoints = le.Uint16(operand)
+		case sprmCRgFtc0:
+			p.fonts[0] = le.Uint16(operand)
+		case sprmCRgFtc1:
+			p.fonts[1] = le.Uint16(operand)
+		case sprmCRgFtc2:
+			p.fonts[2] = le.Uint16(operand)
+		case sprmCKul:
+			p.underline = docUnderline(operand[0])
+		case sprmCIco:
+			p.color = docColor(operand[0])
+		case sprmCCv:
+			if operand[3] == 0xFF {
+				p.color = ""
+			} else {
+				p.color = fmt.Sprintf("%02X%02X%02X", operand[0], operand[1], operand[2])
+			}
+		case sprmCHighlight:
+			p.highlight = docHighlight(operand[0])
+		case sprmCIss:
+			switch operand[0] {
+			case 1:
+				p.position = types.PositionSuperscript
+			case 2:
+				p.position = types.PositionSubscript
+			default:
+				p.position = ""
+			}
+		case sprmCFSpec:
+			p.special = operand[0] == 1
+		case sprmCPicLocation:
+			p.picture = le.Uint32(operand)
+		}
+	})
+}
+
+// apply 应用段落和表格属性修改，长度单位转换为磅，行距转换为倍数
+func (p *docParaProps) apply(grpprl []byte) {
+	eachSprm(grpprl, func(sprm uint16, operand []byte) {
+		switch sprm {
+		case sprmPJc80, sprmPJc:
+			p.alignment = docAlignment(operand[0])
+		case sprmPFKeep:
+			p.keepLines = operand[0] != 0
+		case sprmPFKeepFollow:
+			p.keepNext = operand[0] != 0
+		case sprmPFPageBreakBefore:
+			p.pageBreak = operand[0] != 0
+		case sprmPDxaLeft80, sprmPDxaLeft:
+			p.indentation.Left = float64(operandInt(operand)) / 20.0
+		case sprmPDxaRight80, sprmPDxaRight:
+			p.indentation.Right = float64(operandInt(operand)) / 20.0
+		case sprmPDxaLeft180, sprmPDxaLeft1:
+			first := float64(operandInt(operand)) / 20.0
+			p.indentation.First, p.indentation.Hanging = first, 0
+			if first < 0 {
+				p.indentation.First, p.indentation.Hanging = 0, -first
+			}
+		case sprmPDyaBefore:
+			p.spacing.Before = float64(le.Uint16(operand)) / 20.0
+		case sprmPDyaAfter:
+			p.spacing.After = float64(le.Uint16(operand)) / 20.0
+		case sprmPDyaLine:
+			// 与DOCX的解析相同，行距值统一按1/240换算；固定行距为负值
+			line := int(int16(le.Uint16(operand)))
+			if line < 0 {
+				line = -line
+			}
+			p.spacing.Line = float64(line) / 240.0
+		case sprmPFInTable:
+			p.inTable = operand[0] != 0
+			if p.inTable && p.depth == 0 {
+				p.depth = 1
+			}
+		case sprmPFTtp:
+			p.rowEnd = operand[0] != 0
+		case sprmPItap:
+			p.depth = int32(le.Uint32(operand))
+			p.inTable = p.depth > 0
+		case sprmPOutLvl:
+			p.outline = int(operand[0])
+		case sprmTTableHeader:
+			p.header = operand[0] != 0
+		case sprmTDyaRowHeight:
+			height := operandInt(operand)
+			if height < 0 {
+				height = -height
+			}
+			p.rowHeight = float64(height) / 20.0
+		case sprmTDefTable, sprmTDefTable10:
+			p.cellWidths = docCellWidths(operand)
+		}
+	})
+}
+
+// docCellWidths 从TDefTableOperand的单元格边界计算各单元格的宽度
+func docCellWidths(operand []byte) []float64 {
+	if len(operand) < 3 {
+		return nil
+	}
+	count := int(operand[2])
+	if 3+2*(count+1) > len(operand) {
+		return nil
+	}
+	widths := make([]float64, count)
+	for i := range widths {
+		left := int16(le.Uint16(operand[3+2*i:]))
+		right := int16(le.Uint16(operand[3+2*(i+1):]))
+		widths[i] = float64(right-left) / 20.0
+	}
+	return widths
+}
+
+// docAlignment 将jc值转换为对齐方式
+func docAlignment(jc byte) types.Alignment {
+	switch jc {
+	case 1:
+		return types.AlignCenter
+	case 2:
+		return types.AlignRight
+	case 3, 4:
+		return types.AlignJustify
+	}
+	return types.AlignLeft
+}
+
+// docUnderline 将kul值转换为与OOXML相同的下划线名称
+func docUnderline(kul byte) types.Underline {
+	names := map[byte]types.Underline{
+		1: types.UnderlineSingle, 2: "words", 3: types.UnderlineDouble, 4: types.UnderlineDotted,
+		6: "thick", 7: "dash", 9: "dotDash", 10: "dotDotDash", 11: "wave",
+	}
+	return names[kul]
+}
+
+// docIcoColors ico颜色序号对应的RGB值，0为自动
+var docIcoColors = []string{
+	"", "000000", "0000FF", "00FFFF", "00FF00", "FF00FF", "FF0000", "FFFF00", "FFFFFF",
+	"000080", "008080", "008000", "800080", "800000", "808000", "808080", "C0C0C0",
+}
+
+// docColor 将ico颜色序号转换为RGB值
+func docColor(ico byte) string {
+	if int(ico) < len(docIcoColors) {
+		return docIcoColors[ico]
+	}
+	return ""
+}
+
+// docHighlight 将ico颜色序号转换为与OOXML相同的突出显示颜色名称
+func docHighlight(ico byte) types.Highlight {
+	names := []types.Highlight{
+		"", "black", types.HighlightBlue, "cyan", types.HighlightGreen, "magenta", types.HighlightRed,
+		types.HighlightYellow, "white", "darkBlue", "darkCyan", "darkGreen", "darkMagenta", "darkRed",
+		"darkYellow", "darkGray", "lightGray",
+	}
+	if int(ico) < len(names) {
+		return names[ico]
+	}
+	return ""
+}
+
+// readSections 读取PlcfSed和各节的SEPX
+func (wb *wordBinary) readSections() []docSection {
+	plc, err := wb.tableSlice(wb.fib.entry(fcLcbPlcfSed))
+	if err != nil || len(plc) < 4 {
+		return nil
+	}
+
+	var sections []docSection
+	n := (len(plc) - 4) / 16
+	for i := 0; i < n; i++ {
+		section := defaultDocSection(fmt.Sprintf("section_%d", i+1))
+		sed := plc[4*(n+1)+12*i:]
+		if fc := le.Uint32(sed[2:]); fc != 0xFFFFFFFF && uint64(fc)+2 <= uint64(len(wb.word)) {
+			size := uint64(le.Uint16(wb.word[fc:]))
+			if uint64(fc)+2+size <= uint64(len(wb.word)) {
+				applySectionSprms(&section, wb.word[fc+2:uint64(fc)+2+size])
+			}
+		}
+		sections = append(sections, docSection{cpEnd: int32(le.Uint32(plc[4*(i+1):])), section: section})
+	}
+	return sections
+}
+
+// defaultDocSection 返回SEPX未设置时的默认节属性（Letter纸张）
+func defaultDocSection(id string) types.Section {
+	return types.Section{
+		ID:             id,
+		PageSize:       types.PageSize{Width: 12240 / 20.0, Height: 15840 / 20.0},
+		PageMargins:    types.PageMargins{Top: 1440 / 20.0, Bottom: 1440 / 20.0, Left: 1800 / 20.0, Right: 1800 / 20.0, Header: 720 / 20.0, Footer: 720 / 20.0},
+		HeaderDistance: 720 / 20.0,
+		FooterDistance: 720 / 20.0,
+		Columns:        types.Columns{Count: 1, Spacing: 720 / 20.0, Equal: true},
+		PageNumbering:  types.PageNumbering{Format: "decimal"},
+	}
+}
+
+// applySectionSprms 应用节属性修改，长度单位转换为磅
+func applySectionSprms(section *types.Section, grpprl []byte) {
+	pageFormats := []string{"decimal", "upperRoman", "lowerRoman", "upperLetter", "lowerLetter"}
+	twips := func(operand []byte) float64 {
+		return float64(le.Uint16(operand)) / 20.0
+	}
+
+	eachSprm(grpprl, func(sprm uint16, operand []byte) {
+		switch sprm {
+		case sprmSXaPage:
+			section.PageSize.Width = twips(operand)
+		case sprmSYaPage:
+			section.PageSize.Height = twips(operand)
+		case sprmSDxaLeft:
+			section.PageMargins.Left = twips(operand)
+		case sprmSDxaRight:
+			section.PageMargins.Right = twips(operand)
+		case sprmSDyaTop:
+			// 负值表示固定的上下边距，不随页眉页脚调整
+			section.PageMargins.Top = float64(abs(operandInt(operand))) / 20.0
+		case sprmSDyaBottom:
+			section.PageMargins.Bottom = float64(abs(operandInt(operand))) / 20.0
+		case sprmSDyaHdrTop:
+			section.PageMargins.Header = twips(operand)
+			section.HeaderDistance = section.PageMargins.Header
+		case sprmSDyaHdrBottom:
+			section.PageMargins.Footer = twips(operand)
+			section.FooterDistance = section.PageMargins.Footer
+		case sprmSCcolumns:
+			section.Columns.Count = int(le.Uint16(operand)) + 1
+		case sprmSDxaColumns:
+			section.Columns.Spacing = twips(operand)
+		case sprmSFEvenlySpaced:
+			section.Columns.Equal = operand[0] != 0
+		case sprmSNfcPgn:
+			if int(operand[0]) < len(pageFormats) {
+				section.PageNumbering.Format = pageFormats[operand[0]]
+			}
+		case sprmSFPgnRestart:
+			section.PageNumbering.Restart = operand[0] != 0
+		case sprmSPgnStart:
+			section.PageNumbering.Start = int(le.Uint16(operand))
+		case sprmSNLnnMod:
+			section.LineNumbering.Increment = int(le.Uint16(operand))
+		case sprmSLnnMin:
+			section.LineNumbering.Start = int(le.Uint16(operand))
+		case sprmSLnc:
+			section.LineNumbering.Restart = operand[0] != 2
+		}
+	})
+	if section.LineNumbering.Increment == 0 {
+		section.LineNumbering.Restart = false
+	}
+}
+
+// abs 返回整数的绝对值
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// isSectionEnd 判断字符位置cp处的分页符是否为分节符
+func (wb *wordBinary) isSectionEnd(cp int32) bool {
+	for _, section := range wb.sections {
+		if section.cpEnd == cp+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// paragraphs 读取字符位置[start, end)中的段落
+//
+// 域代码（0x13与0x14之间）被跳过，只保留域结果；分页符结束段落的条件是它同时是分节符。
+func (wb *wordBinary) paragraphs(start, end int32) []docParagraph {
+	var paragraphs []docParagraph
+	var current docParagraph
+	var segments []docSegment
+	var fields []bool // 每层域是否已到达域结果
+
+	visible := func() bool {
+		for _, result := range fields {
+			if !result {
+				return false
+			}
+		}
+		return true
+	}
+	addUnit := func(char docChar, unit uint16) {
+		chpx := findRun(wb.chpx, char.fc)
+		if n := len(segments); n > 0 && segments[n-1].chpx == chpx && segments[n-1].piece == char.piece {
+			segments[n-1].text = append(segments[n-1].text, unit)
+			return
+		}
+		segments = append(segments, docSegment{chpx: chpx, piece: char.piece, text: []uint16{unit}})
+	}
+	finish := func(char docChar, mark uint16) {
+		current.mark = mark
+		current.props = wb.paragraphProps(char)
+		current.runs = wb.resolveRuns(segments, current.props.istd)
+		paragraphs = append(paragraphs, current)
+		current, segments = docParagraph{}, nil
+	}
+
+	for i, char := range wb.chars(start, end) {
+		cp := start + int32(i)
+		switch char.unit {
+		case docCharFieldBegin:
+			fields = append(fields, false)
+			continue
+		case docCharFieldSep:
+			if len(fields) > 0 {
+				fields[len(fields)-1] = true
+			}
+			continue
+		case docCharFieldEnd:
+			if len(fields) > 0 {
+				fields = fields[:len(fields)-1]
+			}
+			continue
+		case docCharParagraph, docCharCell:
+			finish(char, char.unit)
+			continue
+		case docCharPageBreak:
+			if wb.isSectionEnd(cp) {
+				finish(char, char.unit)
+			} else if visible() {
+				current.pageBreak = true
+			}
+			continue
+		}
+		if !visible() {
+			continue
+		}
+
+		switch char.unit {
+		case docCharPicture:
+			if chpx := findRun(wb.chpx, char.fc); chpx >= 0 {
+				var props docCharProps
+				props.apply(wb.chpx[chpx].grpprl, &props)
+				if props.special {
+					current.images = append(current.images, props.picture)
+				}
+			}
+		case docCharTab:
+			addUnit(char, '\t')
+		case docCharLineBreak:
+			addUnit(char, '\n')
+		case docCharNonBreakHyph:
+			addUnit(char, '-')
+		case docCharOptionalHyph:
+		default:
+			if char.unit >= 0x20 {
+				addUnit(char, char.unit)
+			}
+		}
+	}
+	if len(segments) > 0 {
+		current.props, _ = wb.styleProps(0)
+		current.runs = wb.resolveRuns(segments, 0)
+		paragraphs = append(paragraphs, current)
+	}
+	return paragraphs
+}
+
+// docSegment 段落中CHPX和片段都相同的一段文本，段落结束后才能解析其属性
+type docSegment struct {
+	chpx  int
+	piece int
+	text  []uint16
+}
+
+// paragraphProps 返回以char结束的段落的属性：样式、PAPX和片段的属性修改
+func (wb *wordBinary) paragraphProps(char docChar) docParaProps {
+	var istd uint16
+	var grpprl []byte
+	if i := findRun(wb.papx, char.fc); i >= 0 {
+		istd, grpprl = wb.papx[i].istd, wb.papx[i].grpprl
+	}
+	props, _ := wb.styleProps(istd)
+	props.apply(grpprl)
+	props.apply(wb.pieceGrpprl(char.piece))
+	return props
+}
+
+// resolveRuns 解析各段文本的字符属性：段落样式、字符样式、CHPX和片段的属性修改，属性相同的相邻文本合并
+func (wb *wordBinary) resolveRuns(segments []docSegment, istd uint16) []docRun {
+	_, styleChars := wb.styleProps(istd)
+
+	var runs []docRun
+	for _, segment := range segments {
+		props := styleChars
+		var grpprl []byte
+		if segment.chpx >= 0 {
+			grpprl = wb.chpx[segment.chpx].grpprl
+		}
+		eachSprm(grpprl, func(sprm uint16, operand []byte) {
+			if sprm == sprmCIstd {
+				props = wb.characterStyleProps(le.Uint16(operand), props)
+			}
+		})
+		base := props
+		props.apply(grpprl, &base)
+		props.apply(wb.pieceGrpprl(segment.piece), &base)
+		props.special, props.picture = false, 0
+
+		if n := len(runs); n > 0 && runs[n-1].props == props {
+			runs[n-1].text = append(runs[n-1].text, segment.text...)
+			continue
+		}
+		runs = append(runs, docRun{text: segment.text, props: props})
+	}
+	return runs
+}
+
+// fontName 返回运行使用的字体：含东亚文字时取东亚字体，否则取西文字体
+func (wb *wordBinary) fontName(props docCharProps, text string) string {
+	index := props.fonts[0]
+	if hasEastAsian(text) {
+		index = props.fonts[1]
+	}
+	if int(index) < len(wb.fonts) {
+		return wb.fonts[index]
+	}
+	return ""
+}
+
+// hasEastAsian 判断文本中是否含有中日韩文字或全角标点
+func hasEastAsian(text string) bool {
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+			(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerStories 返回页眉页脚文档中各部分的字符位置范围，按PlcfHdd的顺序排列
+//
+// 前6个部分为脚注和尾注的分隔符，其后每节依次为偶数页页眉、奇数页页眉、偶数页页脚、奇数页页脚、首页页眉和首页页脚。
+func (wb *wordBinary) headerStories() [][2]int32 {
+	plc, err := wb.tableSlice(wb.fib.entry(fcLcbPlcfHdd))
+	if err != nil || len(plc) < 8 {
+		return nil
+	}
+	base := wb.fib.ccpText + wb.fib.ccpFtn
+	count := len(plc)/4 - 1
+	stories := make([][2]int32, 0, count)
+	for i := 0; i < count; i++ {
+		start := int32(le.Uint32(plc[4*i:]))
+		end := int32(le.Uint32(plc[4*(i+1):]))
+		if start < 0 || end < start || end > wb.fib.ccpHdd {
+			start, end = 0, 0
+		}
+		stories = append(stories, [2]int32{base + start, base + end})
+	}
+	return stories
+}
+
+// bookmarks 读取SttbfBkmk中的书签名称
+func (wb *wordBinary) bookmarks() []types.Bookmark {
+	data, err := wb.tableSlice(wb.fib.entry(fcLcbSttbfBkmk))
+	if err != nil || len(data) < 6 || le.Uint16(data) != 0xFFFF {
+		return nil
+	}
+
+	var bookmarks []types.Bookmark
+	count, pos := int(le.Uint16(data[2:])), 6
+	for i := 0; i < count && pos+2 <= len(data); i++ {
+		cch := int(le.Uint16(data[pos:]))
+		pos += 2
+		if pos+2*cch > len(data) {
+			break
+		}
+		units := make([]uint16, cch)
+		for j := range units {
+			units[j] = le.Uint16(data[pos+2*j:])
+		}
+		pos += 2 * cch
+		bookmarks = append(bookmarks, types.Bookmark{ID: strconv.Itoa(i), Name: string(utf16.Decode(units))})
+	}
+	return bookmarks
+}
+
+// pictureSize 读取Data流中PICF记录的图片显示大小（磅）
+func (wb *wordBinary) pictureSize(offset uint32) (float64, float64) {
+	if uint64(offset)+36 > uint64(len(wb.data)) {
+		return 0, 0
+	}
+	picf := wb.data[offset:]
+	dxaGoal, dyaGoal := float64(int16(le.Uint16(picf[28:]))), float64(int16(le.Uint16(picf[30:])))
+	mx, my := float64(le.Uint16(picf[32:])), float64(le.Uint16(picf[34:]))
+	if mx == 0 {
+		mx = 1000
+	}
+	if my == 0 {
+		my = 1000
+	}
+	return dxaGoal * mx / 1000 / 20, dyaGoal * my / 1000 / 20
+}
+
+// 摘要信息属性集中的属性ID
+const (
+	pidCodePage   = 1
+	pidTitle      = 2
+	pidSubject    = 3
+	pidAuthor     = 4
+	pidKeywords   = 5
+	pidLastAuthor = 8
+	pidRevision   = 9
+	pidCreated    = 12
+	pidSaved      = 13
+	pidPageCount  = 14
+	pidWordCount  = 15
+)
+
+// summaryInformation 读取\x05SummaryInformation属性集中的字符串、整数和时间属性
+func (wb *wordBinary) summaryInformation() map[uint32]any {
+	data, err := wb.file.ReadStream("\x05SummaryInformation")
+	if err != nil || len(data) < 48 || le.Uint16(data) != 0xFFFE {
+		return nil
+	}
+	offset := uint64(le.Uint32(data[44:]))
+	if offset+8 > uint64(len(data)) {
+		return nil
+	}
+	section := data[offset:]
+	count := int(le.Uint32(section[4:]))
+	if count > (len(section)-8)/8 {
+		return nil
+	}
+
+	properties := make(map[uint32]any)
+	var strings [][2]any
+	for i := 0; i < count; i++ {
+		id := le.Uint32(section[8+8*i:])
+		at := uint64(le.Uint32(section[12+8*i:]))
+		if at+8 > uint64(len(section)) {
+			continue
+		}
+		value := section[at:]
+		switch le.Uint16(value) {
+		case 0x0002: // VT_I2
+			properties[id] = int(int16(le.Uint16(value[4:])))
+		case 0x0003: // VT_I4
+			properties[id] = int(int32(le.Uint32(value[4:])))
+		case 0x001E: // VT_LPSTR，按代码页解码
+			size := uint64(le.Uint32(value[4:]))
+			if 8+size <= uint64(len(value)) {
+				strings = append(strings, [2]any{id, value[8 : 8+size]})
+			}
+		case 0x0040: // VT_FILETIME
+			if at+12 <= uint64(len(section)) {
+				properties[id] = docFileTime(le.Uint64(value[4:]))
+			}
+		}
+	}
+
+	codePage, _ := properties[pidCodePage].(int)
+	if codePage < 0 {
+		codePage += 65536
+	}
+	for _, entry := range strings {
+		properties[entry[0].(uint32)] = decodeCodePage(codePage, entry[1].([]byte))
+	}
+	return properties
+}
+
+// docFileTime 将FILETIME（自1601年起的100纳秒数）转换为时间，0表示未设置
+func docFileTime(value uint64) time.Time {
+	const epochDifference = 116444736000000000
+	if value < epochDifference {
+		return time.Time{}
+	}
+	ticks := value - epochDifference
+	return time.Unix(int64(ticks/10000000), int64(ticks%10000000)*100).UTC()
+}
+
+// docBuiltinStyleIDs 内置样式标识（sti）对应的OOXML样式ID，本地化版本的Word中样式名称会被翻译，样式ID不变
+var docBuiltinStyleIDs = map[uint16]string{
+	0: "Normal", 1: "Heading1", 2: "Heading2", 3: "Heading3", 4: "Heading4", 5: "Heading5",
+	6: "Heading6", 7: "Heading7", 8: "Heading8", 9: "Heading9",
+	19: "TOC1", 20: "TOC2", 21: "TOC3", 22: "TOC4", 23: "TOC5", 24: "TOC6", 25: "TOC7", 26: "TOC8", 27: "TOC9",
+	34: "Caption", 62: "Title", 65: "DefaultParagraphFont", 66: "BodyText", 74: "Subtitle",
+	105: "TableNormal", 153: "TableGrid",
+}
+
+// id 返回样式ID：内置样式使用固定的ID，其他样式由名称生成
+func (s *docStyle) id() string {
+	if id, ok := docBuiltinStyleIDs[s.sti]; ok {
+		return id
+	}
+	return styleIDFromName(s.name)
+}
+
+// document 将读取的结构转换为与DOCX解析结果相同的文档
+func (wb *wordBinary) document(fileSize int64) *types.Document {
+	doc := &types.Document{
+		Metadata: wb.metadata(fileSize),
+		Content:  wb.content(),
+		Styles:   wb.documentStyles(),
+	}
+	doc.FormatRules = wb.formatRules(doc)
+	return doc
+}
+
+// metadata 读取摘要信息中的文档属性
+func (wb *wordBinary) metadata(fileSize int64) types.DocumentMetadata {
+	metadata := types.DocumentMetadata{Version: wb.fib.version(), FileSize: fileSize}
+	properties := wb.summaryInformation()
+	text := func(id uint32) string {
+		value, _ := properties[id].(string)
+		return strings.TrimSpace(value)
+	}
+	number := func(id uint32) int {
+		value, _ := properties[id].(int)
+		return value
+	}
+
+	metadata.Title = text(pidTitle)
+	metadata.Subject = text(pidSubject)
+	metadata.Author = text(pidAuthor)
+	metadata.LastSavedBy = text(pidLastAuthor)
+	if keywords := text(pidKeywords); keywords != "" {
+		metadata.Keywords = strings.Split(keywords, ",")
+	}
+	metadata.Revision, _ = strconv.Atoi(text(pidRevision))
+	metadata.Created, _ = properties[pidCreated].(time.Time)
+	metadata.Modified, _ = properties[pidSaved].(time.Time)
+	metadata.PageCount = number(pidPageCount)
+	metadata.WordCount = number(pidWordCount)
+	return metadata
+}
+
+// content 读取正文段落、表格、图片、节、页眉页脚和书签
+//
+// ID与DOCX解析结果一致：正文段落为paragraph_i，表格单元格中的段落不计入正文段落。
+func (wb *wordBinary) content() types.DocumentContent {
+	content := types.DocumentContent{Bookmarks: wb.bookmarks()}
+	for _, section := range wb.sections {
+		content.Sections = append(content.Sections, section.section)
+	}
+	if len(content.Sections) == 0 {
+		content.Sections = append(content.Sections, defaultDocSection("section_1"))
+	}
+
+	var table *types.Table
+	var row *types.TableRow
+	var cell *types.TableCell
+	flush := func() {
+		if table == nil {
+			return
+		}
+		if row != nil {
+			if cell != nil {
+				row.Cells = append(row.Cells, *cell)
+			}
+			table.Rows = append(table.Rows, *row)
+		}
+		if len(table.Rows) > 0 {
+			for _, cell := range table.Rows[0].Cells {
+				table.Width += cell.Width
+			}
+		}
+		content.Tables = append(content.Tables, *table)
+		table, row, cell = nil, nil, nil
+	}
+
+	for _, p := range wb.paragraphs(0, wb.fib.ccpText) {
+		if !p.props.inTable {
+			flush()
+			i := len(content.Paragraphs) + 1
+			paragraph := wb.convertParagraph(&p, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+				return fmt.Sprintf("run_%d_%d", i, j+1)
+			})
+			content.Paragraphs = append(content.Paragraphs, paragraph)
+			content.Images = wb.appendImages(content.Images, p.images)
+			continue
+		}
+
+		if table == nil {
+			table = &types.Table{ID: fmt.Sprintf("table_%d", len(content.Tables)+1)}
+		}
+		i, j := len(content.Tables)+1, len(table.Rows)+1
+		if row == nil {
+			row = &types.TableRow{ID: fmt.Sprintf("row_%d_%d", i, j)}
+		}
+
+		// 行结束标记所在的段落只携带行属性（单元格宽度、行高、标题行）
+		if p.props.rowEnd && p.props.depth <= 1 {
+			row.Height = p.props.rowHeight
+			row.Header, row.Repeat = p.props.header, p.props.header
+			for k := range row.Cells {
+				if k < len(p.props.cellWidths) {
+					row.Cells[k].Width = p.props.cellWidths[k]
+				}
+			}
+			table.Rows = append(table.Rows, *row)
+			row, cell = nil, nil
+			continue
+		}
+
+		k := len(row.Cells) + 1
+		if cell == nil {
+			cell = &types.TableCell{ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k)}
+		}
+		paragraph := wb.convertParagraph(&p, fmt.Sprintf("cell_para_%d_%d_%d", i, j, k), func(int) string {
+			return fmt.Sprintf("cell_run_%d_%d_%d", i, j, k)
+		})
+		cell.Content = append(cell.Content, paragraph)
+		content.Images = wb.appendImages(content.Images, p.images)
+		if p.mark == docCharCell && p.props.depth <= 1 {
+			row.Cells = append(row.Cells, *cell)
+			cell = nil
+		}
+	}
+	flush()
+
+	content.Headers, content.Footers = wb.headersFooters()
+	return content
+}
+
+// convertParagraph 将段落转换为types.Paragraph，runID返回第j个文本运行的ID
+func (wb *wordBinary) convertParagraph(p *docParagraph, id string, runID func(j int) string) types.Paragraph {
+	paragraph := types.Paragraph{
+		ID:          id,
+		Alignment:   p.props.alignment,
+		Indentation: p.props.indentation,
+		Spacing:     p.props.spacing,
+		PageBreak:   p.pageBreak || p.props.pageBreak,
+		KeepLines:   p.props.keepLines,
+		KeepNext:    p.props.keepNext,
+	}
+	// 与DOCX一致，正文样式的段落不记录样式名称
+	if style := wb.style(p.props.istd); style != nil && style.sti != 0 {
+		paragraph.Style.Name = style.id()
+	}
+	// 大纲级别从1开始，0表示正文
+	if p.props.outline < 9 {
+		paragraph.OutlineLevel = p.props.outline + 1
+	}
+
+	var text strings.Builder
+	for j, r := range p.runs {
+		run := wb.convertRun(r.props, string(utf16.Decode(r.text)))
+		run.ID = runID(j)
+		paragraph.Runs = append(paragraph.Runs, run)
+		text.WriteString(run.Text)
+	}
+	paragraph.Text = text.String()
+	return paragraph
+}
+
+// convertRun 将字符属性转换为文本运行
+func (wb *wordBinary) convertRun(props docCharProps, text string) types.TextRun {
+	font := wb.convertFont(props, text)
+	return types.TextRun{
+		Text:      text,
+		Font:      font,
+		Bold:      props.bold,
+		Italic:    props.italic,
+		Underline: props.underline,
+		Color:     font.Color,
+		Highlight: props.highlight,
+		Size:      font.Size,
+		Position:  props.position,
+	}
+}
+
+// convertFont 返回字符属性对应的字体，text用于选择西文或东亚字体
+func (wb *wordBinary) convertFont(props docCharProps, text string) types.Font {
+	return types.Font{
+		Name:      wb.fontName(props, text),
+		Size:      float64(props.halfPoints) / 2.0,
+		Color:     types.Color{RGB: props.color},
+		Bold:      props.bold,
+		Italic:    props.italic,
+		Underline: props.underline,
+		Highlight: props.highlight,
+	}
+}
+
+// appendImages 添加段落中的图片，大小取自Data流中的PICF
+func (wb *wordBinary) appendImages(images []types.Image, offsets []uint32) []types.Image {
+	for _, offset := range offsets {
+		width, height := wb.pictureSize(offset)
+		images = append(images, types.Image{
+			ID:     fmt.Sprintf("image_%d", len(images)+1),
+			Width:  width,
+			Height: height,
+		})
+	}
+	return images
+}
+
+// headersFooters 读取各节非空的页眉和页脚
+func (wb *wordBinary) headersFooters() ([]types.Header, []types.Footer) {
+	var headers []types.Header
+	var footers []types.Footer
+	stories := wb.headerStories()
+	for i := 6; i < len(stories); i++ {
+		var paragraphs []types.Paragraph
+		isHeader := (i-6)%6 == 0 || (i-6)%6 == 1 || (i-6)%6 == 4
+		for _, p := range wb.paragraphs(stories[i][0], stories[i][1]) {
+			prefix, n := "footer", len(footers)+1
+			if isHeader {
+				prefix, n = "header", len(headers)+1
+			}
+			k := len(paragraphs) + 1
+			paragraph := wb.convertParagraph(&p, fmt.Sprintf("%s_para_%d_%d", prefix, n, k), func(j int) string {
+				return fmt.Sprintf("%s_run_%d_%d_%d", prefix, n, k, j+1)
+			})
+			paragraphs = append(paragraphs, paragraph)
+		}
+
+		empty := true
+		for _, paragraph := range paragraphs {
+			empty = empty && strings.TrimSpace(paragraph.Text) == ""
+		}
+		if empty {
+			continue
+		}
+		if isHeader {
+			headers = append(headers, types.Header{ID: fmt.Sprintf("header_%d", len(headers)+1), Content: paragraphs})
+		} else {
+			footers = append(footers, types.Footer{ID: fmt.Sprintf("footer_%d", len(footers)+1), Content: paragraphs})
+		}
+	}
+	return headers, footers
+}
+
+// documentStyles 返回样式表中的段落、字符和表格样式，段落样式包含沿继承链合并后的格式
+func (wb *wordBinary) documentStyles() types.DocumentStyles {
+	styles := types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+	}
+	for istd, style := range wb.styles {
+		if style == nil || style.name == "" {
+			continue
+		}
+		switch style.kind {
+		case 1:
+			para, chars := wb.styleProps(uint16(istd))
+			styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{
+				ID:          style.id(),
+				Name:        style.name,
+				Font:        wb.convertFont(chars, style.name),
+				Alignment:   para.alignment,
+				Indentation: para.indentation,
+				Spacing:     para.spacing,
+			})
+		case 2:
+			styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: style.id(), Name: style.name})
+		case 3:
+			styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: style.id(), Name: style.name})
+		}
+	}
+	return styles
+}
+
+// formatRules 生成格式规则，样式规则来自样式表
+func (wb *wordBinary) formatRules(doc *types.Document) types.FormatRules {
+	rules := contentFormatRules(doc, wb.fonts)
+	kinds := map[uint16]string{1: "paragraph", 2: "character", 3: "table", 4: "numbering"}
+	for _, style := range wb.styles {
+		if style == nil || style.name == "" {
+			continue
+		}
+		rule := types.StyleRule{ID: style.id(), Name: style.name, Type: kinds[style.kind]}
+		if base := wb.style(style.base); base != nil {
+			rule.BasedOn = base.id()
+		}
+		rules.StyleRules = append(rules.StyleRules, rule)
+	}
+	return rules
+}
@@ -1,6 +1,7 @@
 package formats
 
 import (
+	"context"
 	"fmt"
 
 	"docs-parser/internal/core/parser"
@@ -20,6 +21,11 @@ func NewDocxParser() *DocxParser {
 
 // ParseDocument 解析.docx文档
 func (dp *DocxParser) ParseDocument(filePath string) (*types.Document, error) {
+	return dp.ParseDocumentContext(context.Background(), filePath)
+}
+
+// ParseDocumentContext 受ctx控制的ParseDocument，超时或取消时返回部分结果和*types.TimeoutError
+func (dp *DocxParser) ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error) {
 	fmt.Printf("开始解析DOCX文档: %s\n", filePath)
 
 	// 验证文件
@@ -37,7 +43,10 @@ func (dp *DocxParser) ParseDocument(filePath string) (*types.Document, error) {
 	}
 
 	// 解析文档
-	doc, err := wordDoc.Parse()
+	doc, err := wordDoc.ParseContext(ctx)
+	if _, ok := types.AsTimeoutError(err); ok {
+		return doc, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse word document: %w", err)
 	}
@@ -81,6 +90,31 @@ func (dp *DocxParser) ParseDocument(filePath string) (*types.Document, error) {
 	return doc, nil
 }
 
+// StreamDocumentContext 流式解析DOCX文档，正文事件依次交给fn，document.xml不整体载入内存
+//
+// 返回的文档不保留正文段落、表格和段落规则，见documents.WordprocessingDocument.StreamContext。
+func (dp *DocxParser) StreamDocumentContext(ctx context.Context, filePath string, fn func(*documents.StreamEvent) error) (*types.Document, error) {
+	if err := dp.ValidateFile(filePath); err != nil {
+		return nil, err
+	}
+
+	wordDoc := documents.NewWordprocessingDocument(filePath)
+	defer wordDoc.Close()
+
+	if err := wordDoc.Open(); err != nil {
+		return nil, fmt.Errorf("failed to open word document: %w", err)
+	}
+
+	doc, err := wordDoc.StreamContext(ctx, fn)
+	if _, ok := types.AsTimeoutError(err); ok {
+		return doc, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse word document: %w", err)
+	}
+	return doc, nil
+}
+
 // ParseMetadata 解析文档元数据
 func (dp *DocxParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
 	wordDoc := documents.NewWordprocessingDocument(filePath)
@@ -151,7 +185,7 @@ func (dp *DocxParser) ParseFormatRules(filePath string) (*types.FormatRules, err
 
 // GetSupportedFormats 获取支持的格式
 func (dp *DocxParser) GetSupportedFormats() []string {
-	return []string{".docx"}
+	return []string{".docx", ".dotx", ".docm", ".dotm"}
 }
 
 // ValidateFile 验证文件
@@ -0,0 +1,95 @@
+package formats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging"
+	"docs-parser/internal/testutil"
+)
+
+// writeTestOOXML 写入主文档部件位于mainPart、内容类型为contentType的OOXML包，macros为true时包含vbaProject.bin
+func writeTestOOXML(t *testing.T, name, mainPart, contentType string, macros bool) string {
+	t.Helper()
+	parts := map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/` + mainPart + `" ContentType="` + contentType + `"/></Types>`,
+		"_rels/.rels": testutil.RelationshipsXML,
+		mainPart:      testutil.DocumentXML(`<w:p><w:r><w:t>模板正文</w:t></w:r></w:p>`),
+	}
+	if macros {
+		parts["word/vbaProject.bin"] = "\xD0\xCF\x11\xE0"
+	}
+	return testutil.WriteZip(t, filepath.Join(t.TempDir(), name), parts)
+}
+
+// TestWordParser_OOXMLKinds 测试模板和启用宏的文件按OOXML解析，并记录文档类型和宏工程
+func TestWordParser_OOXMLKinds(t *testing.T) {
+	tests := []struct {
+		name        string
+		mainPart    string
+		contentType string
+		macros      bool
+		kind        types.DocumentKind
+	}{
+		{"template.dotx", "word/document.xml", packaging.ContentTypeTemplate, false, types.KindTemplate},
+		{"macros.docm", "word/document.xml", packaging.ContentTypeMacroDocument, true, types.KindMacroDocument},
+		{"macros.dotm", "word/main.xml", packaging.ContentTypeMacroTemplate, true, types.KindMacroTemplate},
+		{"plain.docx", "word/document.xml", packaging.ContentTypeDocument, false, types.KindDocument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestOOXML(t, tt.name, tt.mainPart, tt.contentType, tt.macros)
+			doc, err := NewWordParser().ParseDocument(path)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if len(doc.Content.Paragraphs) != 1 || doc.Content.Paragraphs[0].Text != "模板正文" {
+				t.Errorf("应从主文档部件读取正文，实际 %+v", doc.Content.Paragraphs)
+			}
+			if doc.Metadata.DocumentKind != tt.kind || doc.Metadata.HasMacros != tt.macros {
+				t.Errorf("文档类型应为 %s、宏工程 %v，实际 %s、%v", tt.kind, tt.macros, doc.Metadata.DocumentKind, doc.Metadata.HasMacros)
+			}
+			if len(doc.Metadata.Warnings) != 0 {
+				t.Errorf("扩展名与内容相符时不应有警告，实际 %q", doc.Metadata.Warnings)
+			}
+		})
+	}
+}
+
+// TestWordParser_FlatOPC 测试单文件XML形式的OOXML包按DOCX解析
+func TestWordParser_FlatOPC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flat.xml")
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<pkg:package xmlns:pkg="http://schemas.microsoft.com/office/2006/xmlPackage">
+  <pkg:part pkg:name="/_rels/.rels" pkg:contentType="application/vnd.openxmlformats-package.relationships+xml">
+    <pkg:xmlData><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/></pkg:xmlData>
+  </pkg:part>
+  <pkg:part pkg:name="/word/document.xml" pkg:contentType="` + packaging.ContentTypeTemplate + `">
+    <pkg:xmlData>
+      <w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>模板正文</w:t></w:r></w:p></w:body></w:document>
+    </pkg:xmlData>
+  </pkg:part>
+  <pkg:part pkg:name="/word/vbaProject.bin" pkg:contentType="application/vnd.ms-office.vbaProject">
+    <pkg:binaryData>0M8R4A==</pkg:binaryData>
+  </pkg:part>
+</pkg:package>`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(doc.Content.Paragraphs) != 1 || doc.Content.Paragraphs[0].Text != "模板正文" {
+		t.Errorf("应从pkg:xmlData读取正文，实际 %+v", doc.Content.Paragraphs)
+	}
+	if doc.Metadata.DocumentKind != types.KindTemplate || !doc.Metadata.HasMacros {
+		t.Errorf("文档类型应为模板且包含宏工程，实际 %s、%v", doc.Metadata.DocumentKind, doc.Metadata.HasMacros)
+	}
+}
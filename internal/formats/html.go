@@ -0,0 +1,231 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/sniff"
+)
+
+// errMHTMLDocument 单个文件网页中没有HTML部件
+var errMHTMLDocument = errors.New("MHTML file has no text/html part")
+
+// HtmlParser Word另存为的网页（.html/.htm）和单个文件网页（.mht/.mhtml）解析器
+type HtmlParser struct{}
+
+// NewHtmlParser 创建网页解析器
+func NewHtmlParser() *HtmlParser {
+	return &HtmlParser{}
+}
+
+// ParseDocument 解析网页文档
+func (hp *HtmlParser) ParseDocument(filePath string) (*types.Document, error) {
+	if err := hp.ValidateFile(filePath); err != nil {
+		return nil, err
+	}
+	return hp.parse(filePath)
+}
+
+// ParseMetadata 解析元数据
+func (hp *HtmlParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
+	doc, err := hp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Metadata, nil
+}
+
+// ParseContent 解析内容
+func (hp *HtmlParser) ParseContent(filePath string) (*types.DocumentContent, error) {
+	doc, err := hp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Content, nil
+}
+
+// ParseStyles 解析样式
+func (hp *HtmlParser) ParseStyles(filePath string) (*types.DocumentStyles, error) {
+	doc, err := hp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Styles, nil
+}
+
+// ParseFormatRules 解析格式规则
+func (hp *HtmlParser) ParseFormatRules(filePath string) (*types.FormatRules, error) {
+	doc, err := hp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.FormatRules, nil
+}
+
+// GetSupportedFormats 获取支持的格式
+func (hp *HtmlParser) GetSupportedFormats() []string {
+	return []string{"html", "htm", "mht", "mhtml"}
+}
+
+// ValidateFile 验证文件格式
+//
+// 按内容识别格式，内容有效时不要求扩展名匹配（Word另存为网页的文件常以.doc结尾）；
+// 内容无效且扩展名也不属于该格式时返回ErrUnsupportedFormat。
+func (hp *HtmlParser) ValidateFile(filePath string) error {
+	// 检查文件是否存在
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return parser.ErrFileNotFound
+	}
+
+	err := hp.validateContent(filePath)
+	supported := map[string]bool{".html": true, ".htm": true, ".mht": true, ".mhtml": true}
+	if err != nil && !supported[strings.ToLower(filepath.Ext(filePath))] {
+		return parser.ErrUnsupportedFormat
+	}
+	return err
+}
+
+// validateContent 检查文件是否以HTML前导或MIME头开始
+func (hp *HtmlParser) validateContent(filePath string) error {
+	result, err := sniff.File(filePath)
+	if err != nil || (result.Format != sniff.HTML && result.Format != sniff.MHTML) {
+		return parser.ErrInvalidFile
+	}
+	return nil
+}
+
+// parse 读取并转换整个网页，单个文件网页先拆分MIME部件
+func (hp *HtmlParser) parse(filePath string) (*types.Document, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, parser.ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	result, err := sniff.Reader(bytes.NewReader(data), int64(len(data)), filepath.Ext(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	if result.Format != sniff.MHTML {
+		return readHTML(decodeHTMLText(data, ""), nil, int64(len(data))), nil
+	}
+	text, parts, err := readMHTML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	return readHTML(text, parts, int64(len(data))), nil
+}
+
+// readMHTML 拆分单个文件网页，返回第一个HTML部件（主文档）和其他HTML部件（如Word写出的header.htm）
+func readMHTML(data []byte) (string, []string, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		return "", nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, err := decodeMIMEBody(message.Body, message.Header.Get("Content-Transfer-Encoding"))
+		if err != nil {
+			return "", nil, err
+		}
+		return decodeHTMLText(body, params["charset"]), nil, nil
+	}
+
+	// NextPart已经解码quoted-printable编码的部件
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var main string
+	var parts []string
+	found := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if found {
+				break
+			}
+			return "", nil, err
+		}
+		partType, partParams, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if partType != "text/html" {
+			continue
+		}
+		body, err := decodeMIMEBody(part, part.Header.Get("Content-Transfer-Encoding"))
+		if err != nil {
+			return "", nil, err
+		}
+		text := decodeHTMLText(body, partParams["charset"])
+		if found {
+			parts = append(parts, text)
+		} else {
+			main, found = text, true
+		}
+	}
+	if !found {
+		return "", nil, errMHTMLDocument
+	}
+	return main, parts, nil
+}
+
+// decodeMIMEBody 按Content-Transfer-Encoding解码部件内容
+func decodeMIMEBody(body io.Reader, encoding string) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	return io.ReadAll(body)
+}
+
+// htmlMetaCharset meta元素声明的编码：<meta charset="utf-8">或http-equiv中的charset参数
+var htmlMetaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w.:-]+)`)
+
+// decodeHTMLText 将网页解码为UTF-8：依次按字节序标记、MIME部件声明的编码和meta元素识别编码，
+// 都没有时有效的UTF-8按UTF-8解码，否则按Windows-1252解码
+func decodeHTMLText(data []byte, label string) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		data, label = data[2:], "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		data, label = data[2:], "utf-16be"
+	}
+	if label == "" {
+		if match := htmlMetaCharset.FindSubmatch(data[:min(len(data), 4096)]); match != nil {
+			label = string(match[1])
+		}
+	}
+	if label != "" && !strings.EqualFold(label, "utf-8") {
+		if reader, err := xmlCharsetReader(label, bytes.NewReader(data)); err == nil {
+			if decoded, err := io.ReadAll(reader); err == nil {
+				return string(decoded)
+			}
+		}
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return decodeCodePage(1252, data)
+}
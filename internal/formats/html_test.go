@@ -0,0 +1,404 @@
+package formats
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+)
+
+// testWordHTML Word另存为的网页：样式表、@list列表、@page节、条件注释中的文档属性、域、表格和脚注
+const testWordHTML = `<html xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:w="urn:schemas-microsoft-com:office:word">
+<head>
+<meta http-equiv=Content-Type content="text/html; charset=utf-8">
+<meta name=Generator content="Microsoft Word 15">
+<title>年度报告</title>
+<!--[if gte mso 9]><xml>
+ <o:DocumentProperties>
+  <o:Author>张三</o:Author>
+  <o:LastAuthor>李四</o:LastAuthor>
+  <o:Revision>3</o:Revision>
+  <o:Created>2024-03-01T08:00:00Z</o:Created>
+  <o:Pages>2</o:Pages>
+  <o:Words>42</o:Words>
+ </o:DocumentProperties>
+</xml><![endif]-->
+<style>
+<!--
+ /* Font Definitions */
+ @font-face
+	{font-family:宋体;
+	panose-1:2 1 6 0 3 1 1 1 1 1;}
+@font-face
+	{font-family:Calibri;}
+ /* Style Definitions */
+ p.MsoNormal, li.MsoNormal, div.MsoNormal
+	{mso-style-unhide:no;
+	mso-style-parent:"";
+	margin:0cm;
+	text-align:justify;
+	font-size:10.5pt;
+	font-family:"Calibri",sans-serif;
+	mso-fareast-font-family:宋体;}
+h1
+	{mso-style-next:Normal;
+	margin-top:17.0pt;
+	margin-right:0cm;
+	margin-bottom:16.5pt;
+	margin-left:0cm;
+	line-height:240%;
+	page-break-after:avoid;
+	mso-outline-level:1;
+	font-size:22.0pt;}
+p.MsoTitle
+	{mso-style-name:Title;
+	mso-style-next:Normal;
+	text-align:center;
+	font-size:16.0pt;
+	font-weight:bold;}
+p.MsoListParagraph
+	{margin-left:21.0pt;
+	text-indent:21.0pt;}
+span.Heading1Char
+	{mso-style-name:"Heading 1 Char";
+	font-weight:bold;}
+span.SpellE
+	{mso-style-name:"";
+	mso-spl-e:yes;}
+@page WordSection1
+	{size:595.3pt 841.9pt;
+	margin:72.0pt 90.0pt 72.0pt 90.0pt;
+	mso-header-margin:42.55pt;
+	mso-footer-margin:49.6pt;}
+div.WordSection1
+	{page:WordSection1;}
+@page WordSection2
+	{size:841.9pt 595.3pt;
+	margin:90.0pt 72.0pt 90.0pt 72.0pt;
+	mso-columns:2 even 21.0pt;
+	mso-page-numbers:1;}
+div.WordSection2
+	{page:WordSection2;}
+ /* List Definitions */
+ @list l0:level1
+	{mso-level-text:"%1\.";}
+@list l0:level2
+	{mso-level-number-format:alpha-lower;
+	mso-level-text:"%2\)";}
+@list l1:level1
+	{mso-level-number-format:bullet;
+	mso-level-text:\F0B7;
+	font-family:Symbol;}
+-->
+</style>
+<!--[if gte mso 10]>
+<style>
+ table.MsoNormalTable
+	{mso-style-name:普通表格;}
+table.MsoTableGrid
+	{mso-style-name:"Table Grid";
+	border:solid windowtext 1.0pt;}
+</style>
+<![endif]-->
+</head>
+<body lang=ZH-CN style='tab-interval:21.0pt'>
+<div class=WordSection1>
+<h1><a name="_Toc1"></a><span lang=EN-US>Overview</span></h1>
+<p class=MsoNormal style='text-indent:21.0pt'><span style='font-family:宋体'>普通</span><b><u>加粗</u></b><span
+lang=EN-US style='mso-tab-count:1'>&nbsp;&nbsp;&nbsp; </span><span style='color:#C00000;
+background:yellow'>强调</span><span lang=EN-US>&nbsp;<span class=SpellE>end</span></span><sup>1</sup></p>
+<p class=MsoListParagraph style='margin-left:18.0pt;text-indent:-18.0pt;mso-list:l0 level1 lfo1'><![if !supportLists]><span
+lang=EN-US><span style='mso-list:Ignore'>1.<span style='font:7.0pt "Times New Roman"'>&nbsp;&nbsp;&nbsp;
+</span></span></span><![endif]><span>第一项</span></p>
+<p class=MsoListParagraph style='mso-list:l0 level2 lfo1'><![if !supportLists]><span style='mso-list:Ignore'>a)<span>&nbsp; </span></span><![endif]>子项</p>
+<p class=MsoListParagraph style='mso-list:l1 level1 lfo2'><![if !supportLists]><span style='font-family:Symbol'><span style='mso-list:Ignore'>·<span>&nbsp;</span></span></span><![endif]>项目</p>
+<p class=MsoNormal><![if supportFields]><span style='mso-element:field-begin'></span> PAGE <span style='mso-element:field-separator'></span><![endif]>3<![if supportFields]><span style='mso-element:field-end'></span><![endif]></p>
+<p class=MsoNormal><o:p>&nbsp;</o:p></p>
+<table class=MsoTableGrid border=1 cellspacing=0 cellpadding=0 width=576 style='width:432.0pt;border-collapse:collapse;border:none'>
+ <thead>
+ <tr style='height:20.0pt'>
+  <td width=384 colspan=2 valign=top style='width:288.0pt;border:solid windowtext 1.0pt;background:#D9D9D9'><p class=MsoNormal align=center style='text-align:center'><b>标题</b></p></td>
+  <td rowspan=2 style='vertical-align:middle'><p class=MsoNormal>合并</p></td>
+ </tr>
+ </thead>
+ <tr>
+  <td><p class=MsoNormal>A</p></td>
+  <td>B
+ </tr>
+</table>
+<p class=MsoNormal><img width=192 height=96 src="report.files/image001.png" alt="标志"></p>
+</div>
+<span lang=EN-US><br clear=all style='page-break-before:always;mso-break-type:section-break'></span>
+<div class=WordSection2>
+<p class=MsoTitle>附录</p>
+<ul><li>甲</li><li>乙<ol type=i><li>丙</ol></ul>
+<div style='mso-element:footnote-list'><p class=MsoFootnoteText>脚注</p></div>
+</div>
+</body>
+</html>`
+
+// TestHtmlParser_Document 测试Word网页的样式、列表编号、域、表格、节和文档属性
+func TestHtmlParser_Document(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.htm")
+	if err := os.WriteFile(path, []byte(testWordHTML), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	metadata := doc.Metadata
+	if metadata.Title != "年度报告" || metadata.Author != "张三" || metadata.LastSavedBy != "李四" || metadata.Revision != 3 ||
+		metadata.PageCount != 2 || metadata.WordCount != 42 || metadata.Created.Year() != 2024 || len(metadata.Warnings) != 0 {
+		t.Errorf("文档属性不正确: %+v", metadata)
+	}
+
+	paragraphs := doc.Content.Paragraphs
+	if len(paragraphs) != 12 {
+		for _, p := range paragraphs {
+			t.Logf("%s %q", p.ID, p.Text)
+		}
+		t.Fatalf("应有12个段落（脚注和表格中的段落不计入），实际 %d", len(paragraphs))
+	}
+	heading := paragraphs[0]
+	if heading.Style.Name != "Heading1" || heading.OutlineLevel != 1 || !heading.KeepNext || heading.Text != "Overview" ||
+		heading.Spacing.Before != 17 || heading.Spacing.After != 16.5 || heading.Spacing.Line != 2.4 {
+		t.Errorf("标题段落不正确: %+v", heading)
+	}
+	if run := heading.Runs[0]; !run.Bold || run.Size != 22 {
+		t.Errorf("标题的字符格式不正确: %+v", run)
+	}
+
+	body := paragraphs[1]
+	if body.Style.Name != "" || body.Alignment != types.AlignJustify || body.Indentation.First != 21 {
+		t.Errorf("正文段落应使用Normal样式的格式: %+v", body)
+	}
+	if body.Text != "普通加粗\t强调 end1" || len(body.Runs) != 6 {
+		t.Errorf("正文文本或文本运行不正确: %q %d", body.Text, len(body.Runs))
+	}
+	if run := body.Runs[1]; !run.Bold || run.Underline != types.UnderlineSingle || run.Font.Name != "宋体" || run.Size != 10.5 {
+		t.Errorf("中文文本应使用东亚字体: %+v", run)
+	}
+	if run := body.Runs[3]; run.Color.RGB != "C00000" || run.Highlight != types.HighlightYellow {
+		t.Errorf("颜色和突出显示不正确: %+v", run)
+	}
+	if run := body.Runs[4]; run.Font.Name != "Calibri" || run.Text != " end" {
+		t.Errorf("不间断空格应保留为空格: %+v", run)
+	}
+	if run := body.Runs[5]; run.Position != types.PositionSuperscript {
+		t.Errorf("上标不正确: %+v", run)
+	}
+
+	lists := []types.ListInfo{
+		{ID: "lfo1", Level: 0, Format: "decimal", Label: "1."},
+		{ID: "lfo1", Level: 1, Format: "lowerLetter", Label: "a)"},
+		{ID: "lfo2", Level: 0, Format: "bullet", Label: "•"},
+	}
+	for i, want := range lists {
+		if list := paragraphs[i+2].List; list == nil || *list != want {
+			t.Errorf("段落%d编号应为 %+v，实际 %+v", i+3, want, list)
+		}
+	}
+	if first := paragraphs[2]; first.Style.Name != "ListParagraph" || first.Text != "第一项" || first.Indentation.Left != 18 || first.Indentation.Hanging != 18 {
+		t.Errorf("列表段落的文本或缩进不正确: %q %+v", first.Text, first.Indentation)
+	}
+	if paragraphs[5].Text != "3" || paragraphs[6].Text != "" {
+		t.Errorf("域代码和空段落不应输出文本: %q %q", paragraphs[5].Text, paragraphs[6].Text)
+	}
+
+	title := paragraphs[8]
+	if title.Style.Name != "Title" || title.Alignment != types.AlignCenter || !title.PageBreak || !title.Runs[0].Bold {
+		t.Errorf("新节的第一个段落应分页并使用标题样式: %+v", title)
+	}
+	labels := []string{"•", "•", "i."}
+	for i, label := range labels {
+		if list := paragraphs[i+9].List; list == nil || list.Label != label || list.Level != i/2 {
+			t.Errorf("HTML列表项%d编号应为 %q，实际 %+v", i+1, label, list)
+		}
+	}
+	if paragraphs[11].Indentation.Left != 60 {
+		t.Errorf("嵌套列表应累加缩进，实际 %v", paragraphs[11].Indentation.Left)
+	}
+
+	if len(doc.Content.Bookmarks) != 1 || doc.Content.Bookmarks[0].Name != "_Toc1" {
+		t.Errorf("书签不正确: %+v", doc.Content.Bookmarks)
+	}
+	if images := doc.Content.Images; len(images) != 1 || images[0].Width != 144 || images[0].Height != 72 || images[0].AltText != "标志" {
+		t.Errorf("图片不正确: %+v", images)
+	}
+
+	if len(doc.Content.Tables) != 1 {
+		t.Fatalf("应有1个表格，实际 %d", len(doc.Content.Tables))
+	}
+	table := doc.Content.Tables[0]
+	if table.Style.ID != "TableGrid" || table.Style.Name != "Table Grid" || table.Width != 432 || len(table.Rows) != 2 ||
+		!table.Rows[0].Header || table.Rows[1].Header || table.Rows[0].Height != 20 {
+		t.Errorf("表格属性不正确: %+v", table)
+	}
+	if table.Borders.Top.Style != types.BorderNone || table.Borders.InsideH.Style != types.BorderSingle {
+		t.Errorf("表格边框不正确: %+v", table.Borders)
+	}
+	cell := table.Rows[0].Cells[0]
+	if cell.Merge.Horizontal != 2 || cell.Width != 288 || cell.VerticalAlignment != types.VAlignTop || cell.Shading.Fill.RGB != "D9D9D9" ||
+		cell.Borders.Top.Style != types.BorderSingle || cell.Borders.Top.Width != 1 || cell.Content[0].Text != "标题" || cell.Content[0].ID != "cell_para_1_1_1" {
+		t.Errorf("合并单元格的格式不正确: %+v", cell)
+	}
+	if cell := table.Rows[0].Cells[1]; cell.Merge.Vertical != 2 || cell.VerticalAlignment != types.VAlignCenter {
+		t.Errorf("纵向合并单元格不正确: %+v", cell)
+	}
+	if cells := table.Rows[1].Cells; len(cells) != 2 || len(cells[1].Content) != 1 || cells[1].Content[0].Text != "B" {
+		t.Errorf("未关闭的单元格应隐含结束: %+v", cells)
+	}
+
+	sections := doc.Content.Sections
+	if len(sections) != 2 {
+		t.Fatalf("每个div.WordSection应为一个节，实际 %d 个节", len(sections))
+	}
+	if s := sections[0]; s.PageSize.Width != 595.3 || s.PageMargins.Left != 90 || s.PageMargins.Header != 42.55 || s.HeaderDistance != 42.55 {
+		t.Errorf("第一节页面设置不正确: %+v %+v", s.PageSize, s.PageMargins)
+	}
+	if s := sections[1]; s.PageSize.Width <= s.PageSize.Height || s.Columns.Count != 2 || s.Columns.Spacing != 21 || !s.PageNumbering.Restart {
+		t.Errorf("第二节应为横向双栏并重新编号: %+v", s)
+	}
+
+	styles := doc.Styles
+	if len(styles.ParagraphStyles) != 4 || len(styles.CharacterStyles) != 1 || len(styles.TableStyles) != 1 {
+		t.Errorf("样式表不正确: %+v", styles)
+	}
+	if normal := styles.ParagraphStyles[0]; normal.ID != "Normal" || normal.Font.Size != 10.5 || normal.Alignment != types.AlignJustify {
+		t.Errorf("Normal样式不正确: %+v", normal)
+	}
+	var headingRule *types.StyleRule
+	for i, rule := range doc.FormatRules.StyleRules {
+		if rule.ID == "Heading1" {
+			headingRule = &doc.FormatRules.StyleRules[i]
+		}
+	}
+	if headingRule == nil || headingRule.Name != "heading 1" || headingRule.Next != "Normal" {
+		t.Errorf("样式规则不正确: %+v", doc.FormatRules.StyleRules)
+	}
+	if len(doc.FormatRules.FontRules) != 2 {
+		t.Errorf("字体规则应来自@font-face，实际 %+v", doc.FormatRules.FontRules)
+	}
+}
+
+// TestHtmlParser_MHTML 测试单个文件网页：按部件的编码解码主文档，页眉来自header.htm部件
+func TestHtmlParser_MHTML(t *testing.T) {
+	main, err := simplifiedchinese.GBK.NewEncoder().String(`<html><head><meta http-equiv=Content-Type content="text/html; charset=gb2312">` +
+		`<style>p.MsoHeader {mso-style-name:header; text-align:center;}</style></head>` +
+		`<body><div class=WordSection1><p class=MsoNormal>中文内容</p></div></body></html>`)
+	if err != nil {
+		t.Fatalf("编码测试文档失败: %v", err)
+	}
+	mhtml := strings.Join([]string{
+		"MIME-Version: 1.0",
+		"X-Document-Type: Word",
+		`Content-Type: multipart/related; boundary="----=_NextPart_01DA"`,
+		"",
+		"This document is a Single File Web Page, also known as a Web Archive file.",
+		"",
+		"------=_NextPart_01DA",
+		"Content-Location: file:///C:/report.htm",
+		"Content-Transfer-Encoding: base64",
+		`Content-Type: text/html; charset="gb2312"`,
+		"",
+		base64.StdEncoding.EncodeToString([]byte(main)),
+		"",
+		"------=_NextPart_01DA",
+		"Content-Location: file:///C:/report.files/header.htm",
+		"Content-Transfer-Encoding: quoted-printable",
+		`Content-Type: text/html; charset="us-ascii"`,
+		"",
+		"<html><body><div style=3D'mso-element:header' id=3Dh1><p class=3DMsoHeader>Confi=",
+		"dential</p></div><div style=3D'mso-element:footer' id=3Df1><p class=3DMsoFooter><o:p>&nbsp;</o:p></p></div></body></html>",
+		"",
+		"------=_NextPart_01DA",
+		"Content-Location: file:///C:/report.files/image001.png",
+		"Content-Transfer-Encoding: base64",
+		"Content-Type: image/png",
+		"",
+		"iVBORw0KGgo=",
+		"",
+		"------=_NextPart_01DA--",
+		"",
+	}, "\r\n")
+	path := filepath.Join(t.TempDir(), "report.doc")
+	if err := os.WriteFile(path, []byte(mhtml), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(doc.Content.Paragraphs) != 1 || doc.Content.Paragraphs[0].Text != "中文内容" {
+		t.Errorf("主文档应按GB2312解码: %+v", doc.Content.Paragraphs)
+	}
+	if len(doc.Metadata.Warnings) != 1 {
+		t.Errorf("保存为doc的网页应给出扩展名不符的警告，实际 %q", doc.Metadata.Warnings)
+	}
+	headers := doc.Content.Headers
+	if len(headers) != 1 || headers[0].Content[0].Text != "Confidential" || headers[0].Content[0].Alignment != types.AlignCenter ||
+		headers[0].Content[0].ID != "header_para_1_1" || len(doc.Content.Footers) != 0 {
+		t.Errorf("应只有非空的页眉并使用主文档的样式表: %+v %+v", headers, doc.Content.Footers)
+	}
+}
+
+// TestHtmlParser_ValidateFile 测试内容和扩展名都不是网页时拒绝文件
+func TestHtmlParser_ValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    error
+	}{
+		{"page.doc", "<!DOCTYPE html><html><body>x</body></html>", nil},
+		{"notes.htm", "plain text", parser.ErrInvalidFile},
+		{"notes.txt", "plain text", parser.ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+		if err := NewHtmlParser().ValidateFile(path); err != tt.want {
+			t.Errorf("%s的检查结果应为 %v，实际 %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+// TestParseHTML_Truncated 测试在标签中间截断的网页：结尾的"</"按文本保留
+func TestParseHTML_Truncated(t *testing.T) {
+	tests := map[string]string{
+		"<p>abc</":      "abc</",
+		"</":            "</",
+		"<p>abc</p":     "abc",
+		"<p>abc<!--x":   "abc",
+		"<p>abc<b":      "abc",
+		"<title>abc</t": "abc</t",
+	}
+	for input, want := range tests {
+		root, _ := parseHTML(input)
+		if got := root.textContent(); got != want {
+			t.Errorf("%q 的文本应为 %q，实际 %q", input, want, got)
+		}
+	}
+}
+
+// FuzzParseHTML 解析损坏的网页时不能崩溃或陷入循环
+func FuzzParseHTML(f *testing.F) {
+	f.Add(testWordHTML)
+	f.Add("<p>abc</")
+	f.Add("<!DOCTYPE html><html><body><table><tr><td>x</td></tr></table><script>if (a < b) {}</script></body></html>")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		parseHTML(text)
+	})
+}
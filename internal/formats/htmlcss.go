@@ -0,0 +1,411 @@
+package formats
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"docs-parser/internal/core/types"
+)
+
+// Word另存为HTML时写出的CSS
+//
+// 样式表中p.MsoNormal、h1、span.Heading1Char等规则对应Word样式，mso-style-name、mso-style-parent和mso-style-next
+// 记录样式名称和继承关系，mso-outline-level为大纲级别，mso-list引用@list规则定义的编号。页面设置写在@page规则中，
+// 由div.WordSection1 {page:WordSection1}这样的规则关联到正文中的节。只支持标签、类和标签.类三种简单选择器。
+
+// cssDeclarations CSS声明，属性名为小写，后出现的声明覆盖之前的
+type cssDeclarations map[string]string
+
+// cssSelector 简单选择器：标签名（小写）和类名，任一可以为空
+type cssSelector struct {
+	tag   string
+	class string
+}
+
+// styleSheet 文档中所有样式表合并后的规则
+type styleSheet struct {
+	rules     map[cssSelector]cssDeclarations
+	order     []cssSelector              // 选择器第一次出现的顺序
+	pages     map[string]cssDeclarations // @page规则，键为页面名称，未命名的为空字符串
+	lists     map[string]cssDeclarations // @list规则，键为"l0"或"l0:level1"
+	fontFaces []string                   // @font-face声明的字体族
+}
+
+// newStyleSheet 创建空样式表
+func newStyleSheet() *styleSheet {
+	return &styleSheet{
+		rules: make(map[cssSelector]cssDeclarations),
+		pages: make(map[string]cssDeclarations),
+		lists: make(map[string]cssDeclarations),
+	}
+}
+
+// cssComment CSS注释，以及Word用于对旧浏览器隐藏样式表的HTML注释标记
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/|<!--|-->`)
+
+// cssSimpleSelector 标签、.类或标签.类
+var cssSimpleSelector = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?(?:\.([\w-]+))?$`)
+
+// parse 解析样式表文本并合并到规则中
+func (sheet *styleSheet) parse(text string) {
+	text = cssComment.ReplaceAllString(text, " ")
+	for len(text) > 0 {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			return
+		}
+		prelude := strings.TrimSpace(text[:open])
+		end := cssBlockEnd(text, open)
+		block := text[open+1 : end]
+		text = text[min(end+1, len(text)):]
+
+		if strings.HasPrefix(prelude, "@") {
+			sheet.parseAtRule(prelude, block)
+			continue
+		}
+		declarations := parseCSSDeclarations(block)
+		for _, selector := range strings.Split(prelude, ",") {
+			match := cssSimpleSelector.FindStringSubmatch(strings.TrimSpace(selector))
+			if match == nil || (match[1] == "" && match[2] == "") {
+				continue
+			}
+			key := cssSelector{tag: strings.ToLower(match[1]), class: match[2]}
+			rule, ok := sheet.rules[key]
+			if !ok {
+				rule = make(cssDeclarations)
+				sheet.rules[key] = rule
+				sheet.order = append(sheet.order, key)
+			}
+			for name, value := range declarations {
+				rule[name] = value
+			}
+		}
+	}
+}
+
+// cssBlockEnd 返回与open处的左花括号匹配的右花括号位置，没有时返回文本末尾
+func cssBlockEnd(text string, open int) int {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(text)
+}
+
+// parseAtRule 读取@page、@list和@font-face规则，@media等其他规则忽略
+func (sheet *styleSheet) parseAtRule(prelude, block string) {
+	fields := strings.Fields(prelude)
+	name := ""
+	if len(fields) > 1 {
+		name = fields[1]
+	}
+	switch strings.ToLower(fields[0]) {
+	case "@page":
+		mergeDeclarations(sheet.pages, strings.TrimPrefix(name, ":"), parseCSSDeclarations(block))
+	case "@list":
+		mergeDeclarations(sheet.lists, name, parseCSSDeclarations(block))
+	case "@font-face":
+		if family := cssFontFamily(parseCSSDeclarations(block)["font-family"]); family != "" {
+			sheet.fontFaces = append(sheet.fontFaces, family)
+		}
+	}
+}
+
+// mergeDeclarations 将声明合并到rules[key]中
+func mergeDeclarations(rules map[string]cssDeclarations, key string, declarations cssDeclarations) {
+	rule, ok := rules[key]
+	if !ok {
+		rule = make(cssDeclarations)
+		rules[key] = rule
+	}
+	for name, value := range declarations {
+		rule[name] = value
+	}
+}
+
+// parseCSSDeclarations 解析声明块或style属性，分号和冒号在引号中时不作为分隔符
+func parseCSSDeclarations(text string) cssDeclarations {
+	declarations := make(cssDeclarations)
+	var quote rune
+	start := 0
+	add := func(declaration string) {
+		name, value, ok := strings.Cut(declaration, ":")
+		if !ok {
+			return
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		if name != "" {
+			declarations[name] = strings.TrimSpace(value)
+		}
+	}
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ';':
+			add(text[start:i])
+			start = i + 1
+		}
+	}
+	add(text[start:])
+	return declarations
+}
+
+// style 返回元素的层叠样式：标签规则、类规则、标签.类规则，最后是style属性
+func (sheet *styleSheet) style(n *htmlNode) cssDeclarations {
+	declarations := make(cssDeclarations)
+	apply := func(selector cssSelector) {
+		for name, value := range sheet.rules[selector] {
+			declarations[name] = value
+		}
+	}
+	apply(cssSelector{tag: n.name})
+	classes := strings.Fields(n.attrs["class"])
+	for _, class := range classes {
+		apply(cssSelector{class: class})
+	}
+	for _, class := range classes {
+		apply(cssSelector{tag: n.name, class: class})
+	}
+	for name, value := range parseCSSDeclarations(n.attrs["style"]) {
+		declarations[name] = value
+	}
+	return declarations
+}
+
+// cssFontFamily 返回字体族列表中的第一个字体，去除引号
+func cssFontFamily(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	return strings.Trim(strings.TrimSpace(first), `"'`)
+}
+
+// cssUnquote 去除值两端的引号并解析CSS转义（如Word写出的"\F0B7"和"%1\."）
+func cssUnquote(value string) string {
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	var unescaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			unescaped.WriteByte(value[i])
+			continue
+		}
+		j := i + 1
+		for j < len(value) && j < i+7 && strings.IndexByte("0123456789abcdefABCDEF", value[j]) >= 0 {
+			j++
+		}
+		if j == i+1 {
+			unescaped.WriteByte(value[j])
+			i = j
+			continue
+		}
+		code, _ := strconv.ParseUint(value[i+1:j], 16, 32)
+		if utf8.ValidRune(rune(code)) {
+			unescaped.WriteRune(rune(code))
+		}
+		if j < len(value) && value[j] == ' ' {
+			j++
+		}
+		i = j - 1
+	}
+	return unescaped.String()
+}
+
+// cssColors 常用的CSS颜色名称，windowtext和auto为自动颜色
+var cssColors = map[string]string{
+	"black": "000000", "white": "FFFFFF", "red": "FF0000", "green": "008000", "blue": "0000FF", "yellow": "FFFF00",
+	"gray": "808080", "grey": "808080", "silver": "C0C0C0", "maroon": "800000", "navy": "000080", "purple": "800080",
+	"teal": "008080", "olive": "808000", "lime": "00FF00", "aqua": "00FFFF", "fuchsia": "FF00FF", "orange": "FFA500",
+	"windowtext": "", "auto": "",
+}
+
+// cssColor 将颜色值转换为大写的十六进制RGB，第二个返回值表示是否为颜色
+func cssColor(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if rgb, ok := cssColors[value]; ok {
+		return rgb, true
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if _, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return strings.ToUpper(hex), true
+		}
+		return "", false
+	}
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "rgb("), ")"), ",")
+		if len(parts) != 3 {
+			return "", false
+		}
+		var rgb strings.Builder
+		for _, part := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n < 0 || n > 255 {
+				return "", false
+			}
+			rgb.WriteString(strings.ToUpper(strconv.FormatInt(int64(n)+0x100, 16)[1:]))
+		}
+		return rgb.String(), true
+	}
+	return "", false
+}
+
+// cssBorder 读取border简写属性："solid windowtext 1.0pt"等，线型、颜色和宽度可以任意顺序出现
+func cssBorder(value string) types.Border {
+	var border types.Border
+	for _, part := range strings.Fields(value) {
+		switch strings.ToLower(part) {
+		case "none", "hidden":
+			border.Style = types.BorderNone
+		case "solid":
+			border.Style = types.BorderSingle
+		case "double":
+			border.Style = types.BorderDouble
+		case "dotted":
+			border.Style = types.BorderDotted
+		case "dashed":
+			border.Style = types.BorderDashed
+		case "thin":
+			border.Width = 0.75
+		case "medium":
+			border.Width = 2.25
+		case "thick":
+			border.Width = 4.5
+		default:
+			if color, ok := cssColor(part); ok {
+				border.Color.RGB = color
+			} else if width, ok := cssLength(part); ok {
+				border.Width = width
+			}
+		}
+	}
+	return border
+}
+
+// cssBoxValues 展开margin等一至四个值的简写属性，返回上、右、下、左
+func cssBoxValues(value string) [4]string {
+	fields := strings.Fields(value)
+	switch len(fields) {
+	case 1:
+		return [4]string{fields[0], fields[0], fields[0], fields[0]}
+	case 2:
+		return [4]string{fields[0], fields[1], fields[0], fields[1]}
+	case 3:
+		return [4]string{fields[0], fields[1], fields[2], fields[1]}
+	case 4:
+		return [4]string{fields[0], fields[1], fields[2], fields[3]}
+	}
+	return [4]string{}
+}
+
+// margin 返回margin-side的值，未单独设置时取margin简写属性中的对应值；side为top、right、bottom或left
+func (declarations cssDeclarations) margin(side string) (float64, bool) {
+	if value, ok := declarations["margin-"+side]; ok {
+		return cssLength(value)
+	}
+	value, ok := declarations["margin"]
+	if !ok {
+		return 0, false
+	}
+	index := map[string]int{"top": 0, "right": 1, "bottom": 2, "left": 3}[side]
+	return cssLength(cssBoxValues(value)[index])
+}
+
+// cssPageSizes @page size属性中的纸张名称，单位为磅
+var cssPageSizes = map[string][2]float64{
+	"a3": {841.9, 1190.55}, "a4": {595.3, 841.9}, "a5": {419.55, 595.3},
+	"b4": {708.65, 1000.6}, "b5": {498.9, 708.65}, "letter": {612, 792}, "legal": {612, 1008},
+}
+
+// cssPageNumberFormats mso-page-number-format和mso-level-number-format的值对应的编号格式
+var cssPageNumberFormats = map[string]string{
+	"roman-upper": "upperRoman", "roman-lower": "lowerRoman", "alpha-upper": "upperLetter", "alpha-lower": "lowerLetter",
+	"bullet": "bullet", "none": "none", "arabic-leading-zero": "decimalZero",
+}
+
+// pageSection 按@page规则生成节：纸张大小、页边距、页眉页脚距离、分栏、页码和行号
+func pageSection(page cssDeclarations, id string) types.Section {
+	section := defaultDocSection(id)
+	if page == nil {
+		return section
+	}
+
+	if size := strings.Fields(strings.ToLower(page["size"])); len(size) > 0 {
+		landscape := false
+		var lengths []float64
+		for _, field := range size {
+			if named, ok := cssPageSizes[field]; ok {
+				section.PageSize.Width, section.PageSize.Height = named[0], named[1]
+			} else if field == "landscape" {
+				landscape = true
+			} else if length, ok := cssLength(field); ok {
+				lengths = append(lengths, length)
+			}
+		}
+		if len(lengths) == 2 {
+			section.PageSize.Width, section.PageSize.Height = lengths[0], lengths[1]
+		}
+		if landscape && section.PageSize.Width < section.PageSize.Height {
+			section.PageSize.Width, section.PageSize.Height = section.PageSize.Height, section.PageSize.Width
+		}
+	}
+	for side, target := range map[string]*float64{
+		"top": &section.PageMargins.Top, "bottom": &section.PageMargins.Bottom,
+		"left": &section.PageMargins.Left, "right": &section.PageMargins.Right,
+	} {
+		if v, ok := page.margin(side); ok {
+			*target = v
+		}
+	}
+	if v, ok := cssLength(page["mso-header-margin"]); ok {
+		section.PageMargins.Header = v
+	}
+	if v, ok := cssLength(page["mso-footer-margin"]); ok {
+		section.PageMargins.Footer = v
+	}
+	section.HeaderDistance = section.PageMargins.Header
+	section.FooterDistance = section.PageMargins.Footer
+
+	// mso-columns:2 even 36.0pt
+	if columns := strings.Fields(page["mso-columns"]); len(columns) > 0 {
+		if n, err := strconv.Atoi(columns[0]); err == nil && n > 0 {
+			section.Columns.Count = n
+		}
+		for _, field := range columns[1:] {
+			if field == "even" || field == "uneven" {
+				section.Columns.Equal = field == "even"
+			} else if v, ok := cssLength(field); ok {
+				section.Columns.Spacing = v
+			}
+		}
+	}
+	if start, err := strconv.Atoi(page["mso-page-numbers"]); err == nil {
+		section.PageNumbering.Start = start
+		section.PageNumbering.Restart = true
+	}
+	if format, ok := cssPageNumberFormats[page["mso-page-number-format"]]; ok {
+		section.PageNumbering.Format = format
+	}
+	if n, err := strconv.Atoi(page["mso-line-numbers-count-by"]); err == nil {
+		section.LineNumbering.Increment = n
+		section.LineNumbering.Start, _ = strconv.Atoi(page["mso-line-numbers-start"])
+		section.LineNumbering.Restart = page["mso-line-numbers-restart"] != "continuous"
+	}
+	return section
+}
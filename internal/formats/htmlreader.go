@@ -0,0 +1,1582 @@
+package formats
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// Word另存为HTML的文档（.htm/.html，或单个文件网页.mht/.mhtml）
+//
+// Word写出的HTML以类选择器对应样式（p.MsoNormal、p.MsoTitle、span.Heading1Char），用带mso-前缀的CSS属性记录
+// HTML无法表达的格式：mso-list和@list规则为列表编号，mso-element标记页眉页脚和脚注，mso-tab-count为制表符。
+// 文档属性写在条件注释<!--[if gte mso 9]><xml><o:DocumentProperties>中，<![if !supportLists]>等下层可见的条件
+// 标记本身忽略、其中的内容保留。正文每个div.WordSection对应一个节，页面设置来自其page属性引用的@page规则。
+
+// htmlNode HTML元素或文本节点，name为空时为文本节点；元素名和属性名为小写，保留o:p等命名空间前缀
+type htmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*htmlNode
+	text     string
+}
+
+// htmlVoidElements 没有内容和结束标签的元素
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlRawTextElements 内容按原样读取、不识别标签的元素
+var htmlRawTextElements = map[string]bool{"script": true, "style": true, "title": true, "textarea": true, "xmp": true}
+
+// htmlBlockElements 块级元素，其中的段落元素总是生成一个段落
+var htmlBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true, "center": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"html": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "ul": true, "head": true, "script": true, "style": true, "title": true, "xml": true,
+}
+
+// htmlParagraphElements 即使没有内容也生成段落的元素
+var htmlParagraphElements = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"li": true, "pre": true, "dt": true, "dd": true, "address": true,
+}
+
+// htmlClosesP 开始时隐含结束未关闭的p元素的元素
+var htmlClosesP = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "center": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "fieldset": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// htmlTreeBuilder 按开始和结束标签构建元素树，容忍未关闭和多余的结束标签
+type htmlTreeBuilder struct {
+	root     *htmlNode
+	stack    []*htmlNode
+	comments []string
+}
+
+// parseHTML 将HTML解析为元素树，同时返回所有注释的内容
+func parseHTML(text string) (*htmlNode, []string) {
+	b := &htmlTreeBuilder{root: &htmlNode{name: "#document", attrs: map[string]string{}}}
+	b.stack = []*htmlNode{b.root}
+	for i := 0; i < len(text); {
+		if text[i] != '<' {
+			end := strings.IndexByte(text[i:], '<')
+			if end < 0 {
+				end = len(text) - i
+			}
+			b.text(html.UnescapeString(text[i : i+end]))
+			i += end
+			continue
+		}
+		rest := text[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				b.comments = append(b.comments, rest[4:])
+				i = len(text)
+				continue
+			}
+			b.comments = append(b.comments, rest[4:4+end])
+			i += 4 + end + 3
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			// 文档类型声明、处理指令和<![if !supportLists]>等条件标记
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			i += end + 1
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end < 2 {
+				// 输入以"</"结尾，没有标签名
+				b.text(rest)
+				i = len(text)
+				continue
+			}
+			name, _, _ := strings.Cut(strings.TrimSpace(rest[2:end]), " ")
+			b.end(strings.ToLower(name))
+			i += end + 1
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			n, consumed, selfClosing := parseHTMLTag(rest)
+			i += consumed
+			b.start(n, selfClosing)
+			if htmlRawTextElements[n.name] && !selfClosing {
+				end := indexHTMLEndTag(text[i:], n.name)
+				if end < 0 {
+					end = len(text) - i
+				}
+				raw := text[i : i+end]
+				if n.name != "style" && n.name != "script" {
+					raw = html.UnescapeString(raw)
+				}
+				if n.name != "script" {
+					b.text(raw)
+				}
+				b.end(n.name)
+				i += end
+			}
+		default:
+			b.text("<")
+			i++
+		}
+	}
+	return b.root, b.comments
+}
+
+// isASCIILetter 是否为ASCII字母
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isHTMLSpace 是否为HTML空白字符
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexHTMLEndTag 返回元素name的结束标签位置（不区分大小写），没有时返回-1
+func indexHTMLEndTag(text, name string) int {
+	for i := 0; ; i += 2 {
+		j := strings.Index(text[i:], "</")
+		if j < 0 {
+			return -1
+		}
+		i += j
+		if len(text) >= i+2+len(name) && strings.EqualFold(text[i+2:i+2+len(name)], name) {
+			return i
+		}
+	}
+}
+
+// parseHTMLTag 解析以<开始的开始标签，返回元素、标签长度和是否以/>结束
+func parseHTMLTag(text string) (*htmlNode, int, bool) {
+	i := 1
+	for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '>' && text[i] != '/' {
+		i++
+	}
+	n := &htmlNode{name: strings.ToLower(text[1:i]), attrs: make(map[string]string)}
+	for i < len(text) {
+		for i < len(text) && isHTMLSpace(text[i]) {
+			i++
+		}
+		if i >= len(text) {
+			break
+		}
+		switch text[i] {
+		case '>':
+			return n, i + 1, false
+		case '/':
+			if i+1 < len(text) && text[i+1] == '>' {
+				return n, i + 2, true
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '>' && text[i] != '=' && text[i] != '/' {
+			i++
+		}
+		if i == start {
+			i++
+			continue
+		}
+		name := strings.ToLower(text[start:i])
+		for i < len(text) && isHTMLSpace(text[i]) {
+			i++
+		}
+		value := ""
+		if i < len(text) && text[i] == '=' {
+			i++
+			for i < len(text) && isHTMLSpace(text[i]) {
+				i++
+			}
+			if i < len(text) && (text[i] == '"' || text[i] == '\'') {
+				end := strings.IndexByte(text[i+1:], text[i])
+				if end < 0 {
+					value, i = text[i+1:], len(text)
+				} else {
+					value, i = text[i+1:i+1+end], i+end+2
+				}
+			} else {
+				start := i
+				for i < len(text) && !isHTMLSpace(text[i]) && text[i] != '>' {
+					i++
+				}
+				value = text[start:i]
+			}
+		}
+		if _, ok := n.attrs[name]; !ok {
+			n.attrs[name] = html.UnescapeString(value)
+		}
+	}
+	return n, len(text), false
+}
+
+// current 返回当前打开的元素
+func (b *htmlTreeBuilder) current() *htmlNode {
+	return b.stack[len(b.stack)-1]
+}
+
+// text 添加文本节点，与前一个文本节点相邻时合并
+func (b *htmlTreeBuilder) text(text string) {
+	if text == "" {
+		return
+	}
+	parent := b.current()
+	if n := len(parent.children); n > 0 && parent.children[n-1].name == "" {
+		parent.children[n-1].text += text
+		return
+	}
+	parent.children = append(parent.children, &htmlNode{text: text})
+}
+
+// start 添加元素，先按HTML的规则结束被它隐含结束的元素
+func (b *htmlTreeBuilder) start(n *htmlNode, selfClosing bool) {
+	switch n.name {
+	case "li":
+		b.closeWithin([]string{"li"}, "ul", "ol", "table")
+	case "dt", "dd":
+		b.closeWithin([]string{"dt", "dd"}, "dl", "table")
+	case "tr":
+		b.closeWithin([]string{"tr"}, "table")
+	case "td", "th":
+		b.closeWithin([]string{"td", "th"}, "tr", "table")
+	case "thead", "tbody", "tfoot":
+		b.closeWithin([]string{"thead", "tbody", "tfoot"}, "table")
+	}
+	if htmlClosesP[n.name] {
+		b.closeWithin([]string{"p"}, "td", "th", "table", "button")
+	}
+	parent := b.current()
+	parent.children = append(parent.children, n)
+	if !htmlVoidElements[n.name] && !selfClosing {
+		b.stack = append(b.stack, n)
+	}
+}
+
+// closeWithin 从当前元素向外查找names中的元素并结束它，遇到boundaries中的元素时停止
+func (b *htmlTreeBuilder) closeWithin(names []string, boundaries ...string) {
+	for i := len(b.stack) - 1; i > 0; i-- {
+		name := b.stack[i].name
+		for _, target := range names {
+			if name == target {
+				b.stack = b.stack[:i]
+				return
+			}
+		}
+		for _, boundary := range boundaries {
+			if name == boundary {
+				return
+			}
+		}
+	}
+}
+
+// end 结束最近打开的同名元素；表格之外的结束标签不能结束表格中的元素
+func (b *htmlTreeBuilder) end(name string) {
+	// 文档结束前的</body>和</html>不结束元素，</br>无意义
+	if name == "html" || name == "body" || name == "br" {
+		return
+	}
+	boundaries := []string{"table", "td", "th", "caption"}
+	switch name {
+	case "td", "th", "tr", "thead", "tbody", "tfoot", "caption", "table":
+		boundaries = []string{"table"}
+	}
+	for i := len(b.stack) - 1; i > 0; i-- {
+		current := b.stack[i].name
+		if current == name {
+			b.stack = b.stack[:i]
+			return
+		}
+		if name != "table" {
+			for _, boundary := range boundaries {
+				if current == boundary {
+					return
+				}
+			}
+		}
+	}
+}
+
+// find 深度优先查找第一个名为name的元素
+func (n *htmlNode) find(name string) *htmlNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+		if found := c.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// textContent 返回元素中的全部文本
+func (n *htmlNode) textContent() string {
+	if n.name == "" {
+		return n.text
+	}
+	var text strings.Builder
+	for _, c := range n.children {
+		text.WriteString(c.textContent())
+	}
+	return text.String()
+}
+
+// hasContent 是否含有非空白文本或图片
+func (n *htmlNode) hasContent() bool {
+	switch n.name {
+	case "":
+		return strings.TrimSpace(n.text) != ""
+	case "img":
+		return true
+	case "script", "style":
+		return false
+	}
+	for _, c := range n.children {
+		if c.hasContent() {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlCharProps 字符属性
+type htmlCharProps struct {
+	font      string
+	eastAsia  string
+	size      float64
+	color     string
+	bold      bool
+	italic    bool
+	underline types.Underline
+	highlight types.Highlight
+	position  types.Position
+}
+
+// htmlParaProps 段落属性；styleID、间距、分页和大纲级别只作用于设置它们的元素
+type htmlParaProps struct {
+	styleID     string
+	alignment   types.Alignment
+	indentation types.Indentation
+	spacing     types.Spacing
+	outline     int
+	pageBreak   bool
+	keepNext    bool
+	keepLines   bool
+	msoList     string // mso-list的值，如"l0 level1 lfo1"
+}
+
+// htmlList ol或ul元素的编号
+type htmlList struct {
+	id      string
+	level   int
+	format  string
+	bullet  string
+	counter int
+}
+
+// htmlListItem li元素的编号，只用于其中的第一个段落
+type htmlListItem struct {
+	info *types.ListInfo
+	used bool
+}
+
+// htmlContext 遍历时从祖先元素继承的格式
+type htmlContext struct {
+	chars htmlCharProps
+	para  htmlParaProps
+	list  *htmlList
+	item  *htmlListItem
+	pre   bool
+}
+
+// htmlBlock 段落或表格
+type htmlBlock struct {
+	node    *htmlNode   // 段落或表格元素；由行内内容组成的段落为所在的容器
+	inlines []*htmlNode // 段落的行内内容
+	ctx     htmlContext // 已应用元素自身格式的上下文
+	page    string      // 从该块开始的节使用的@page名称
+}
+
+// htmlDocument 读取中的HTML文档
+type htmlDocument struct {
+	sheet      *styleSheet
+	root       *htmlNode
+	parts      []*htmlNode // MHTML中的其他HTML部件，Word将页眉页脚写在单独的header.htm中
+	properties *wmlNode    // 条件注释中的o:DocumentProperties所在的xml元素
+	lists      map[string]*wmlList
+	listCount  int
+	page       string // 下一个块开始的节
+	blocks     int    // 已输出的块数
+	bookmarks  []types.Bookmark
+	images     []types.Image
+}
+
+// htmlConditionalStyle 条件注释中的样式表，Word将表格样式等写在<!--[if gte mso 10]><style>中
+var htmlConditionalStyle = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+
+// htmlConditionalXML 条件注释中的XML数据岛
+var htmlConditionalXML = regexp.MustCompile(`(?is)<xml>.*?</xml>`)
+
+// readHTML 读取HTML文档，parts为MHTML中除主文档外的HTML部件，均已解码为UTF-8
+func readHTML(text string, parts []string, fileSize int64) *types.Document {
+	hd := &htmlDocument{sheet: newStyleSheet(), lists: make(map[string]*wmlList)}
+	var comments []string
+	hd.root, comments = parseHTML(text)
+	for _, part := range parts {
+		root, partComments := parseHTML(part)
+		hd.parts = append(hd.parts, root)
+		comments = append(comments, partComments...)
+	}
+
+	for _, root := range append([]*htmlNode{hd.root}, hd.parts...) {
+		hd.collectStyles(root)
+	}
+	for _, comment := range comments {
+		if !strings.HasPrefix(comment, "[if") {
+			continue
+		}
+		for _, match := range htmlConditionalStyle.FindAllStringSubmatch(comment, -1) {
+			hd.sheet.parse(match[1])
+		}
+		for _, island := range htmlConditionalXML.FindAllString(comment, -1) {
+			root, err := readWMLTree(strings.NewReader(island))
+			if err == nil && root.child("DocumentProperties") != nil && hd.properties == nil {
+				hd.properties = root
+			}
+		}
+	}
+	return hd.document(fileSize)
+}
+
+// collectStyles 读取所有style元素中的样式表
+func (hd *htmlDocument) collectStyles(n *htmlNode) {
+	for _, c := range n.children {
+		if c.name == "style" {
+			hd.sheet.parse(c.textContent())
+			continue
+		}
+		hd.collectStyles(c)
+	}
+}
+
+// document 将读取的结构转换为与DOCX解析结果相同的文档
+func (hd *htmlDocument) document(fileSize int64) *types.Document {
+	doc := &types.Document{
+		Metadata: hd.metadata(fileSize),
+		Content:  hd.content(),
+		Styles:   hd.documentStyles(),
+	}
+	if doc.Metadata.WordCount == 0 {
+		for _, paragraph := range doc.Content.Paragraphs {
+			doc.Metadata.WordCount += len(strings.Fields(paragraph.Text))
+		}
+	}
+	doc.FormatRules = hd.formatRules(doc)
+	return doc
+}
+
+// metadata 读取o:DocumentProperties中的文档属性，缺少的标题、作者和关键字取自title和meta元素
+func (hd *htmlDocument) metadata(fileSize int64) types.DocumentMetadata {
+	properties := hd.properties
+	if properties == nil {
+		properties = &wmlNode{}
+	}
+	metadata := (&wordML{root: properties}).metadata(fileSize)
+	metadata.DocumentKind = types.KindDocument
+
+	metas := make(map[string]string)
+	var visit func(n *htmlNode)
+	visit = func(n *htmlNode) {
+		for _, c := range n.children {
+			if c.name == "meta" && c.attrs["name"] != "" {
+				metas[strings.ToLower(c.attrs["name"])] = strings.TrimSpace(c.attrs["content"])
+			}
+			visit(c)
+		}
+	}
+	if head := hd.root.find("head"); head != nil {
+		visit(head)
+		if metadata.Title == "" {
+			if title := head.find("title"); title != nil {
+				metadata.Title = strings.Join(strings.Fields(title.textContent()), " ")
+			}
+		}
+	}
+	if metadata.Author == "" {
+		metadata.Author = metas["author"]
+	}
+	if metadata.Subject == "" {
+		metadata.Subject = metas["subject"]
+	}
+	if len(metadata.Keywords) == 0 && metas["keywords"] != "" {
+		for _, keyword := range strings.Split(metas["keywords"], ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				metadata.Keywords = append(metadata.Keywords, keyword)
+			}
+		}
+	}
+	return metadata
+}
+
+// rootContext 返回浏览器默认的格式：12磅Times New Roman
+func rootContext() htmlContext {
+	return htmlContext{chars: htmlCharProps{font: "Times New Roman", size: 12}}
+}
+
+// hiddenHTML 元素是否不显示
+func hiddenHTML(declarations cssDeclarations) bool {
+	return declarations["display"] == "none" || declarations["mso-hide"] == "all" || declarations["visibility"] == "hidden"
+}
+
+// skippedMSOElement 正文中不输出的Word元素：页眉页脚、脚注尾注和批注列表
+func skippedMSOElement(element string) bool {
+	switch element {
+	case "header", "footer", "footnote", "endnote", "comment":
+		return true
+	}
+	return strings.HasSuffix(element, "-list") || strings.HasSuffix(element, "separator")
+}
+
+// block 处理块级元素
+func (hd *htmlDocument) block(n *htmlNode, parent htmlContext, fn func(htmlBlock)) {
+	switch n.name {
+	case "head", "script", "style", "title", "xml", "hr", "noscript", "template":
+		return
+	}
+	declarations := hd.sheet.style(n)
+	if hiddenHTML(declarations) || skippedMSOElement(declarations["mso-element"]) {
+		return
+	}
+	if page := declarations["page"]; page != "" {
+		hd.page = page
+	}
+	ctx := hd.context(n, declarations, parent)
+	switch n.name {
+	case "table":
+		hd.emit(htmlBlock{node: n, ctx: ctx}, fn)
+	case "ul", "ol":
+		ctx.list = hd.newList(n, declarations, parent.list)
+		ctx.item = nil
+		hd.eachBlock(n, ctx, fn)
+	case "li":
+		if ctx.list == nil {
+			ctx.list = hd.newList(&htmlNode{name: "ul", attrs: map[string]string{}}, nil, nil)
+		}
+		ctx.item = &htmlListItem{info: ctx.list.next(n)}
+		hd.eachBlock(n, ctx, fn)
+	default:
+		hd.eachBlock(n, ctx, fn)
+	}
+}
+
+// emit 输出块，第一个块记录之前进入的节
+func (hd *htmlDocument) emit(block htmlBlock, fn func(htmlBlock)) {
+	block.page, hd.page = hd.page, ""
+	hd.blocks++
+	fn(block)
+}
+
+// eachBlock 遍历容器中的内容：块级子元素分别处理，相邻的行内内容组成一个段落
+func (hd *htmlDocument) eachBlock(n *htmlNode, ctx htmlContext, fn func(htmlBlock)) {
+	var inlines []*htmlNode
+	blocks := hd.blocks
+	flush := func(force bool) {
+		content := false
+		for _, inline := range inlines {
+			content = content || inline.hasContent()
+		}
+		if content || force {
+			hd.emit(htmlBlock{node: n, inlines: inlines, ctx: ctx}, fn)
+		}
+		inlines = nil
+	}
+	for _, c := range n.children {
+		if c.name == "" || !htmlBlockElements[c.name] {
+			inlines = append(inlines, c)
+			continue
+		}
+		flush(false)
+		hd.block(c, ctx, fn)
+	}
+	flush(htmlParagraphElements[n.name] && hd.blocks == blocks)
+}
+
+// context 返回元素内部的格式：继承字符属性、对齐、缩进和行距，清除只作用于父元素的段落属性
+func (hd *htmlDocument) context(n *htmlNode, declarations cssDeclarations, parent htmlContext) htmlContext {
+	ctx := parent
+	ctx.para.styleID = ""
+	ctx.para.spacing.Before, ctx.para.spacing.After = 0, 0
+	ctx.para.outline = 0
+	ctx.para.pageBreak, ctx.para.keepNext, ctx.para.keepLines = false, false, false
+	ctx.para.msoList = ""
+	applyHTMLChars(&ctx.chars, n, declarations)
+	hd.applyHTMLPara(&ctx.para, n, declarations, ctx.chars.size)
+	if n.name == "pre" || strings.HasPrefix(declarations["white-space"], "pre") {
+		ctx.pre = true
+	}
+	return ctx
+}
+
+// htmlHeadingSizes h1至h6的默认字号
+var htmlHeadingSizes = map[string]float64{"h1": 24, "h2": 18, "h3": 14, "h4": 12, "h5": 10, "h6": 8}
+
+// htmlFontSizes font元素size属性对应的字号
+var htmlFontSizes = map[string]float64{"1": 7.5, "2": 10, "3": 12, "4": 13.5, "5": 18, "6": 24, "7": 36}
+
+// applyHTMLChars 按元素的默认格式和CSS设置字符属性
+func applyHTMLChars(c *htmlCharProps, n *htmlNode, declarations cssDeclarations) {
+	switch n.name {
+	case "b", "strong", "th":
+		c.bold = true
+	case "i", "em", "cite", "var", "dfn":
+		c.italic = true
+	case "u", "ins":
+		c.underline = types.UnderlineSingle
+	case "sup":
+		c.position = types.PositionSuperscript
+	case "sub":
+		c.position = types.PositionSubscript
+	case "code", "tt", "pre", "kbd", "samp":
+		c.font = "Courier New"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.bold = true
+		c.size = htmlHeadingSizes[n.name]
+	case "font":
+		if face := cssFontFamily(n.attrs["face"]); face != "" {
+			c.font = face
+		}
+		if size, ok := htmlFontSizes[n.attrs["size"]]; ok {
+			c.size = size
+		}
+		if color, ok := cssColor(n.attrs["color"]); ok {
+			c.color = color
+		}
+	}
+
+	if family := cssFontFamily(declarations["font-family"]); family != "" {
+		c.font = family
+	}
+	if family := cssFontFamily(declarations["mso-ascii-font-family"]); family != "" {
+		c.font = family
+	}
+	if family := cssFontFamily(declarations["mso-fareast-font-family"]); family != "" {
+		c.eastAsia = family
+	}
+	if size, ok := htmlFontSize(declarations["font-size"], c.size); ok {
+		c.size = size
+	}
+	switch weight := declarations["font-weight"]; weight {
+	case "bold", "bolder", "600", "700", "800", "900":
+		c.bold = true
+	case "normal", "lighter", "100", "200", "300", "400", "500":
+		c.bold = false
+	}
+	switch declarations["font-style"] {
+	case "italic", "oblique":
+		c.italic = true
+	case "normal":
+		c.italic = false
+	}
+	if decoration, ok := declarations["text-decoration"]; ok {
+		if strings.Contains(decoration, "underline") {
+			c.underline = types.UnderlineSingle
+		} else if strings.Contains(decoration, "none") {
+			c.underline = ""
+		}
+	}
+	switch declarations["text-underline"] {
+	case "double":
+		c.underline = types.UnderlineDouble
+	case "dotted":
+		c.underline = types.UnderlineDotted
+	case "dash", "dashed":
+		c.underline = types.UnderlineDashed
+	case "single":
+		c.underline = types.UnderlineSingle
+	case "none":
+		c.underline = ""
+	}
+	if color, ok := cssColor(declarations["color"]); ok {
+		c.color = color
+	}
+	// 段落的背景是底纹，只有行内元素的背景表示突出显示
+	if !htmlBlockElements[n.name] {
+		for _, name := range []string{"background", "background-color"} {
+			if highlight, ok := htmlHighlight(declarations[name]); ok {
+				c.highlight = highlight
+			}
+		}
+	}
+	if highlight, ok := htmlHighlight(declarations["mso-highlight"]); ok {
+		c.highlight = highlight
+	}
+	switch declarations["vertical-align"] {
+	case "super":
+		c.position = types.PositionSuperscript
+	case "sub":
+		c.position = types.PositionSubscript
+	case "baseline":
+		c.position = ""
+	}
+}
+
+// htmlFontSize 读取font-size，百分比和em相对于父元素的字号
+func htmlFontSize(value string, parent float64) (float64, bool) {
+	keywords := map[string]float64{
+		"xx-small": 7.5, "x-small": 7.5, "small": 10, "medium": 12, "large": 13.5, "x-large": 18, "xx-large": 24,
+		"smaller": parent / 1.2, "larger": parent * 1.2,
+	}
+	if size, ok := keywords[value]; ok {
+		return size, true
+	}
+	return htmlLength(value, parent)
+}
+
+// htmlLength 读取CSS长度，百分比和em相对于base
+func htmlLength(value string, base float64) (float64, bool) {
+	value = strings.TrimSpace(value)
+	for suffix, scale := range map[string]float64{"%": base / 100, "em": base} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			return n * scale, err == nil
+		}
+	}
+	return cssLength(value)
+}
+
+// htmlPixels 读取width、height等HTML属性：无单位时为像素
+func htmlPixels(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		return 0, false
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n * 0.75, true
+	}
+	return cssLength(value)
+}
+
+// htmlHighlight 将背景色或mso-highlight转换为突出显示颜色
+func htmlHighlight(value string) (types.Highlight, bool) {
+	if value == "" {
+		return "", false
+	}
+	color, ok := cssColor(strings.Fields(value)[0])
+	if !ok {
+		return "", false
+	}
+	highlights := map[string]types.Highlight{
+		"FFFF00": types.HighlightYellow, "00FF00": types.HighlightGreen, "FF00FF": types.HighlightPink,
+		"00FFFF": types.HighlightBlue, "0000FF": types.HighlightBlue, "FF0000": types.HighlightRed,
+	}
+	highlight, ok := highlights[color]
+	return highlight, ok
+}
+
+// htmlAlignments align属性和text-align对应的对齐方式
+var htmlAlignments = map[string]types.Alignment{
+	"left": types.AlignLeft, "start": types.AlignLeft, "center": types.AlignCenter, "middle": types.AlignCenter,
+	"right": types.AlignRight, "end": types.AlignRight, "justify": types.AlignJustify,
+}
+
+// applyHTMLPara 按元素的默认格式和CSS设置段落属性，左右边距累加到缩进上
+func (hd *htmlDocument) applyHTMLPara(p *htmlParaProps, n *htmlNode, declarations cssDeclarations, fontSize float64) {
+	// 表格的边距不影响单元格中的段落，div.WordSection1等节元素不是段落样式
+	structural := false
+	switch n.name {
+	case "table", "thead", "tbody", "tfoot", "tr", "td", "th":
+		structural = true
+	}
+	if !structural && declarations["page"] == "" {
+		p.styleID = hd.styleID(n)
+	}
+	if _, ok := htmlHeadingSizes[n.name]; ok {
+		p.outline = int(n.name[1] - '0')
+	}
+	switch n.name {
+	case "center", "th":
+		p.alignment = types.AlignCenter
+	case "p":
+		p.spacing.Before, p.spacing.After = fontSize, fontSize
+	case "blockquote":
+		p.indentation.Left += 30
+		p.indentation.Right += 30
+	case "ul", "ol":
+		if padding, ok := htmlLength(declarations["padding-left"], fontSize); ok {
+			p.indentation.Left += padding
+		} else if _, ok := declarations.margin("left"); !ok {
+			p.indentation.Left += 30
+		}
+	}
+	if alignment, ok := htmlAlignments[strings.ToLower(n.attrs["align"])]; ok && n.name != "table" && n.name != "img" {
+		p.alignment = alignment
+	}
+	if alignment, ok := htmlAlignments[declarations["text-align"]]; ok {
+		p.alignment = alignment
+	}
+
+	if !structural {
+		for side, target := range map[string]*float64{"left": &p.indentation.Left, "right": &p.indentation.Right} {
+			if value, ok := declarations.margin(side); ok {
+				*target += value
+			}
+		}
+		if value, ok := declarations.margin("top"); ok {
+			p.spacing.Before = value
+		}
+		if value, ok := declarations.margin("bottom"); ok {
+			p.spacing.After = value
+		}
+	}
+	if indent, ok := htmlLength(declarations["text-indent"], fontSize); ok {
+		p.indentation.First, p.indentation.Hanging = max(indent, 0), max(-indent, 0)
+	}
+	if height := declarations["line-height"]; height == "normal" {
+		p.spacing.Line = 0
+	} else if percent, ok := strings.CutSuffix(height, "%"); ok {
+		if v, err := strconv.ParseFloat(percent, 64); err == nil {
+			p.spacing.Line = v / 100
+		}
+	} else if v, err := strconv.ParseFloat(height, 64); err == nil {
+		p.spacing.Line = v
+	} else if v, ok := cssLength(height); ok {
+		p.spacing.Line = v / 12
+	}
+
+	switch declarations["page-break-before"] {
+	case "always", "page", "left", "right":
+		p.pageBreak = true
+	}
+	if declarations["page-break-after"] == "avoid" {
+		p.keepNext = true
+	}
+	if declarations["page-break-inside"] == "avoid" || strings.Contains(declarations["mso-pagination"], "lines-together") {
+		p.keepLines = true
+	}
+	if level, err := strconv.Atoi(declarations["mso-outline-level"]); err == nil && level > 0 {
+		p.outline = level
+	}
+	p.msoList = declarations["mso-list"]
+}
+
+// styleID 返回元素对应的Word样式ID：MsoNormal为Normal，其他Mso类去掉前缀，h1至h6为Heading1至Heading6
+func (hd *htmlDocument) styleID(n *htmlNode) string {
+	class, _, _ := strings.Cut(strings.TrimSpace(n.attrs["class"]), " ")
+	return htmlStyleID(n.name, class)
+}
+
+// htmlStyleID 由元素名和类名生成样式ID
+func htmlStyleID(tag, class string) string {
+	if class == "" {
+		if _, ok := htmlHeadingSizes[tag]; ok {
+			return "Heading" + tag[1:]
+		}
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(class, "Mso"); ok && rest != "" && rest[0] >= 'A' && rest[0] <= 'Z' {
+		return rest
+	}
+	return class
+}
+
+// newList 创建ol或ul的编号，格式来自type属性或list-style-type
+func (hd *htmlDocument) newList(n *htmlNode, declarations cssDeclarations, parent *htmlList) *htmlList {
+	hd.listCount++
+	list := &htmlList{id: fmt.Sprintf("list_%d", hd.listCount), format: "decimal"}
+	if parent != nil {
+		list.level = parent.level + 1
+	}
+	if n.name == "ul" {
+		list.format, list.bullet = "bullet", "•"
+	}
+	styles := map[string][2]string{
+		"1": {"decimal"}, "a": {"lowerLetter"}, "A": {"upperLetter"}, "i": {"lowerRoman"}, "I": {"upperRoman"},
+		"decimal": {"decimal"}, "lower-alpha": {"lowerLetter"}, "lower-latin": {"lowerLetter"},
+		"upper-alpha": {"upperLetter"}, "upper-latin": {"upperLetter"}, "lower-roman": {"lowerRoman"},
+		"upper-roman": {"upperRoman"}, "disc": {"bullet", "•"}, "circle": {"bullet", "◦"}, "square": {"bullet", "▪"},
+		"none": {"none"},
+	}
+	for _, value := range []string{n.attrs["type"], declarations["list-style-type"]} {
+		if style, ok := styles[value]; ok {
+			list.format, list.bullet = style[0], style[1]
+		}
+	}
+	if start, err := strconv.Atoi(n.attrs["start"]); err == nil {
+		list.counter = start - 1
+	}
+	return list
+}
+
+// next 返回li元素的编号，value属性重新设置计数
+func (l *htmlList) next(li *htmlNode) *types.ListInfo {
+	if value, err := strconv.Atoi(li.attrs["value"]); err == nil {
+		l.counter = value
+	} else {
+		l.counter++
+	}
+	label := ""
+	switch l.format {
+	case "bullet":
+		label = l.bullet
+	case "none":
+	default:
+		label = listNumber(l.format, l.counter) + "."
+	}
+	return &types.ListInfo{ID: l.id, Level: l.level, Format: l.format, Label: label}
+}
+
+// wordListItem 返回mso-list:l0 level1 lfo1表示的Word列表编号
+//
+// 编号文本优先取<span style='mso-list:Ignore'>中Word写出的文本，项目符号和缺少该文本时按@list规则计数生成。
+func (hd *htmlDocument) wordListItem(value, label string) *types.ListInfo {
+	id, instance, level := "", "", 0
+	for _, field := range strings.Fields(value) {
+		switch {
+		case strings.HasPrefix(field, "level"):
+			if n, err := strconv.Atoi(field[len("level"):]); err == nil {
+				level = min(max(n-1, 0), 8)
+			}
+		case strings.HasPrefix(field, "lfo"):
+			instance = field
+		case len(field) > 1 && field[0] == 'l' && field[1] >= '0' && field[1] <= '9':
+			id = field
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	if instance == "" {
+		instance = id
+	}
+	list, ok := hd.lists[instance]
+	if !ok {
+		list = &wmlList{}
+		for i := range list.levels {
+			rule := hd.sheet.lists[fmt.Sprintf("%s:level%d", id, i+1)]
+			lvl := &wmlListLevel{start: 1, format: "decimal", text: fmt.Sprintf("%%%d.", i+1)}
+			if start, err := strconv.Atoi(rule["mso-level-start-at"]); err == nil {
+				lvl.start = start
+			}
+			if format, ok := cssPageNumberFormats[rule["mso-level-number-format"]]; ok {
+				lvl.format = format
+			}
+			if text, ok := rule["mso-level-text"]; ok {
+				lvl.text = cssUnquote(text)
+			} else if lvl.format == "bullet" {
+				lvl.text = "•"
+			}
+			list.levels[i] = lvl
+		}
+		hd.lists[instance] = list
+	}
+
+	generated := list.next(level)
+	format := list.levels[level].format
+	if label == "" || format == "bullet" {
+		label = generated
+	}
+	return &types.ListInfo{ID: instance, Level: level, Format: format, Label: label}
+}
+
+// htmlInline 段落中已输出的文本
+type htmlInline struct {
+	texts     []strings.Builder
+	chars     []htmlCharProps
+	label     string // mso-list:Ignore中的列表编号
+	content   bool   // 是否已输出文本，段落开头的空白不输出
+	space     bool   // 是否有待输出的空白
+	fields    []bool // 嵌套的域是否处于域代码部分
+	pageBreak bool
+}
+
+// write 输出文本，字符属性与前一段文本相同时合并为一个文本运行
+func (in *htmlInline) write(text string, chars htmlCharProps) {
+	if n := len(in.chars); n == 0 || in.chars[n-1] != chars {
+		in.texts = append(in.texts, strings.Builder{})
+		in.chars = append(in.chars, chars)
+	}
+	in.texts[len(in.texts)-1].WriteString(text)
+	in.content = true
+}
+
+// writeText 按HTML的空白规则输出文本：连续空白折叠为一个空格，不间断空格保留
+func (in *htmlInline) writeText(text string, ctx htmlContext) {
+	for _, field := range in.fields {
+		if field {
+			return
+		}
+	}
+	if ctx.pre {
+		in.space = false
+		in.write(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\u00a0", " "), ctx.chars)
+		return
+	}
+	var collapsed strings.Builder
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			in.space = in.content || collapsed.Len() > 0
+			continue
+		}
+		if in.space {
+			collapsed.WriteByte(' ')
+			in.space = false
+		}
+		if r == '\u00a0' {
+			r = ' '
+		}
+		collapsed.WriteRune(r)
+	}
+	if collapsed.Len() > 0 {
+		in.write(collapsed.String(), ctx.chars)
+	}
+}
+
+// inline 输出行内内容：进入span、a、b等元素，处理Word的列表编号、制表符、域和分页符
+func (hd *htmlDocument) inline(n *htmlNode, ctx htmlContext, in *htmlInline) {
+	if n.name == "" {
+		in.writeText(n.text, ctx)
+		return
+	}
+	switch n.name {
+	case "script", "style", "head", "title", "xml":
+		return
+	}
+	declarations := hd.sheet.style(n)
+	if hiddenHTML(declarations) {
+		return
+	}
+	if declarations["mso-list"] == "Ignore" {
+		in.label += strings.Join(strings.Fields(n.textContent()), " ")
+		return
+	}
+	switch declarations["mso-element"] {
+	case "field-begin":
+		in.fields = append(in.fields, true)
+		return
+	case "field-separator":
+		if len(in.fields) > 0 {
+			in.fields[len(in.fields)-1] = false
+		}
+		return
+	case "field-end":
+		if len(in.fields) > 0 {
+			in.fields = in.fields[:len(in.fields)-1]
+		}
+		return
+	}
+	if count, err := strconv.Atoi(declarations["mso-tab-count"]); err == nil && count > 0 {
+		in.space = false
+		in.write(strings.Repeat("\t", count), ctx.chars)
+		return
+	}
+
+	switch n.name {
+	case "br":
+		switch declarations["page-break-before"] {
+		case "always", "page", "left", "right":
+			in.pageBreak = true
+			return
+		}
+		in.space = false
+		in.write("\n", ctx.chars)
+		return
+	case "img":
+		hd.appendImage(n, declarations)
+		return
+	case "o:p":
+		if strings.TrimSpace(n.textContent()) == "" {
+			return
+		}
+	case "a":
+		name := n.attrs["name"]
+		if name == "" && n.attrs["href"] == "" {
+			name = n.attrs["id"]
+		}
+		if name != "" {
+			hd.bookmarks = append(hd.bookmarks, types.Bookmark{ID: strconv.Itoa(len(hd.bookmarks)), Name: name})
+		}
+	}
+
+	child := ctx
+	applyHTMLChars(&child.chars, n, declarations)
+	for _, c := range n.children {
+		hd.inline(c, child, in)
+	}
+}
+
+// appendImage 添加img元素表示的图片，尺寸为CSS像素对应的磅
+func (hd *htmlDocument) appendImage(img *htmlNode, declarations cssDeclarations) {
+	image := types.Image{
+		ID:      fmt.Sprintf("image_%d", len(hd.images)+1),
+		Path:    img.attrs["src"],
+		AltText: strings.TrimSpace(img.attrs["alt"]),
+	}
+	image.Width, _ = htmlPixels(img.attrs["width"])
+	image.Height, _ = htmlPixels(img.attrs["height"])
+	if v, ok := cssLength(declarations["width"]); ok {
+		image.Width = v
+	}
+	if v, ok := cssLength(declarations["height"]); ok {
+		image.Height = v
+	}
+	hd.images = append(hd.images, image)
+}
+
+// convertParagraph 将段落块转换为types.Paragraph，runID返回第j个文本运行的ID
+func (hd *htmlDocument) convertParagraph(block htmlBlock, id string, runID func(j int) string) types.Paragraph {
+	props := block.ctx.para
+	paragraph := types.Paragraph{
+		ID:           id,
+		Alignment:    props.alignment,
+		Indentation:  props.indentation,
+		Spacing:      props.spacing,
+		PageBreak:    props.pageBreak,
+		KeepLines:    props.keepLines,
+		KeepNext:     props.keepNext,
+		OutlineLevel: props.outline,
+	}
+	// 与DOCX一致，默认段落样式不记录样式名称
+	if props.styleID != "Normal" {
+		paragraph.Style.Name = props.styleID
+	}
+
+	in := &htmlInline{}
+	for _, n := range block.inlines {
+		hd.inline(n, block.ctx, in)
+	}
+	paragraph.PageBreak = paragraph.PageBreak || in.pageBreak
+
+	if list := hd.wordListItem(props.msoList, in.label); list != nil {
+		paragraph.List = list
+	} else if item := block.ctx.item; item != nil && !item.used {
+		paragraph.List = item.info
+		item.used = true
+	}
+
+	var text strings.Builder
+	for j := range in.texts {
+		chars := in.chars[j]
+		run := types.TextRun{
+			ID:        runID(j),
+			Text:      in.texts[j].String(),
+			Font:      convertHTMLFont(chars, in.texts[j].String()),
+			Bold:      chars.bold,
+			Italic:    chars.italic,
+			Underline: chars.underline,
+			Color:     types.Color{RGB: chars.color},
+			Highlight: chars.highlight,
+			Size:      chars.size,
+			Position:  chars.position,
+		}
+		paragraph.Runs = append(paragraph.Runs, run)
+		text.WriteString(run.Text)
+	}
+	paragraph.Text = text.String()
+	return paragraph
+}
+
+// convertHTMLFont 返回字符属性对应的字体，含东亚文字时取东亚字体
+func convertHTMLFont(chars htmlCharProps, text string) types.Font {
+	name := chars.font
+	if chars.eastAsia != "" && (name == "" || hasEastAsian(text)) {
+		name = chars.eastAsia
+	}
+	return types.Font{
+		Name:      name,
+		Size:      chars.size,
+		Color:     types.Color{RGB: chars.color},
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Highlight: chars.highlight,
+	}
+}
+
+// content 读取正文中的段落、表格和节，以及页眉页脚
+//
+// ID与DOCX解析结果一致：正文段落为paragraph_i，表格单元格中的段落不计入正文段落。
+func (hd *htmlDocument) content() types.DocumentContent {
+	var content types.DocumentContent
+	pages := []string{""}
+	started := false
+	body := hd.root.find("body")
+	if body == nil {
+		body = hd.root
+	}
+	hd.block(body, rootContext(), func(block htmlBlock) {
+		newPage := false
+		if block.page != "" {
+			if started && block.page != pages[len(pages)-1] {
+				pages = append(pages, block.page)
+				newPage = true
+			} else if !started {
+				pages[0] = block.page
+			}
+		}
+		started = true
+
+		if block.node.name == "table" {
+			content.Tables = append(content.Tables, hd.convertTable(block, len(content.Tables)+1))
+			return
+		}
+		i := len(content.Paragraphs) + 1
+		paragraph := hd.convertParagraph(block, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+			return fmt.Sprintf("run_%d_%d", i, j+1)
+		})
+		paragraph.PageBreak = paragraph.PageBreak || newPage
+		content.Paragraphs = append(content.Paragraphs, paragraph)
+	})
+
+	for i, page := range pages {
+		content.Sections = append(content.Sections, pageSection(hd.pageDeclarations(page), fmt.Sprintf("section_%d", i+1)))
+	}
+	content.Headers, content.Footers = hd.headersFooters()
+	content.Bookmarks = hd.bookmarks
+	content.Images = hd.images
+	return content
+}
+
+// pageDeclarations 返回命名页面的@page规则，未命名的@page规则作为默认值
+func (hd *htmlDocument) pageDeclarations(name string) cssDeclarations {
+	page := make(cssDeclarations)
+	for _, key := range []string{"", name} {
+		for property, value := range hd.sheet.pages[key] {
+			page[property] = value
+		}
+	}
+	if len(page) == 0 {
+		return nil
+	}
+	return page
+}
+
+// convertTable 将table元素转换为表格，i为表格从1开始的序号
+func (hd *htmlDocument) convertTable(block htmlBlock, i int) types.Table {
+	tbl := block.node
+	declarations := hd.sheet.style(tbl)
+	table := types.Table{ID: fmt.Sprintf("table_%d", i)}
+	if class, _, _ := strings.Cut(strings.TrimSpace(tbl.attrs["class"]), " "); class != "" && class != "MsoNormalTable" {
+		id := htmlStyleID("table", class)
+		table.Style = types.TableStyle{ID: id, Name: cssUnquote(hd.sheet.rules[cssSelector{tag: "table", class: class}]["mso-style-name"])}
+	}
+	if width, ok := htmlPixels(tbl.attrs["width"]); ok {
+		table.Width = width
+	}
+	if width, ok := cssLength(declarations["width"]); ok {
+		table.Width = width
+	}
+	if alignment, ok := htmlAlignments[strings.ToLower(tbl.attrs["align"])]; ok {
+		table.Alignment = alignment
+	}
+	if declarations["margin-left"] == "auto" && declarations["margin-right"] == "auto" {
+		table.Alignment = types.AlignCenter
+	}
+
+	// border属性为表格和单元格加上边框，CSS边框只作用于表格自身
+	if width, err := strconv.Atoi(tbl.attrs["border"]); err == nil && width > 0 {
+		border := types.Border{Style: types.BorderSingle, Width: float64(width) * 0.75}
+		table.Borders = types.TableBorders{Top: border, Bottom: border, Left: border, Right: border, InsideH: border, InsideV: border}
+	}
+	if value, ok := declarations["border"]; ok {
+		border := cssBorder(value)
+		table.Borders.Top, table.Borders.Bottom, table.Borders.Left, table.Borders.Right = border, border, border, border
+	}
+	for name, target := range map[string]*types.Border{
+		"border-top": &table.Borders.Top, "border-bottom": &table.Borders.Bottom,
+		"border-left": &table.Borders.Left, "border-right": &table.Borders.Right,
+	} {
+		if value, ok := declarations[name]; ok {
+			*target = cssBorder(value)
+		}
+	}
+	if fill, ok := htmlBackground(tbl, declarations); ok {
+		table.Shading.Fill.RGB = fill
+	}
+
+	type htmlRow struct {
+		node   *htmlNode
+		header bool
+	}
+	var rows []htmlRow
+	for _, c := range tbl.children {
+		switch c.name {
+		case "tr":
+			rows = append(rows, htmlRow{c, false})
+		case "thead", "tbody", "tfoot":
+			for _, tr := range c.children {
+				if tr.name == "tr" {
+					rows = append(rows, htmlRow{tr, c.name == "thead"})
+				}
+			}
+		}
+	}
+
+	for _, tr := range rows {
+		j := len(table.Rows) + 1
+		row := types.TableRow{ID: fmt.Sprintf("row_%d_%d", i, j), Header: tr.header, Repeat: tr.header}
+		rowDeclarations := hd.sheet.style(tr.node)
+		if height, ok := htmlPixels(tr.node.attrs["height"]); ok {
+			row.Height = height
+		}
+		if height, ok := cssLength(rowDeclarations["height"]); ok {
+			row.Height = height
+		}
+		rowCtx := hd.context(tr.node, rowDeclarations, block.ctx)
+		for _, td := range tr.node.children {
+			if td.name != "td" && td.name != "th" {
+				continue
+			}
+			k := len(row.Cells) + 1
+			row.Cells = append(row.Cells, hd.convertCell(td, rowCtx, i, j, k))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// convertCell 转换单元格：合并、宽度、垂直对齐、底纹、边框和其中的段落
+func (hd *htmlDocument) convertCell(td *htmlNode, parent htmlContext, i, j, k int) types.TableCell {
+	declarations := hd.sheet.style(td)
+	cell := types.TableCell{ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k)}
+	if n, err := strconv.Atoi(td.attrs["colspan"]); err == nil && n > 1 {
+		cell.Merge.Horizontal = n
+	}
+	if n, err := strconv.Atoi(td.attrs["rowspan"]); err == nil && n > 1 {
+		cell.Merge.Vertical = n
+	}
+	if width, ok := htmlPixels(td.attrs["width"]); ok {
+		cell.Width = width
+	}
+	if width, ok := cssLength(declarations["width"]); ok {
+		cell.Width = width
+	}
+	if height, ok := cssLength(declarations["height"]); ok {
+		cell.Height = height
+	}
+	valign := td.attrs["valign"]
+	if value, ok := declarations["vertical-align"]; ok {
+		valign = value
+	}
+	switch strings.ToLower(valign) {
+	case "top":
+		cell.VerticalAlignment = types.VAlignTop
+	case "middle", "center":
+		cell.VerticalAlignment = types.VAlignCenter
+	case "bottom":
+		cell.VerticalAlignment = types.VAlignBottom
+	}
+	if fill, ok := htmlBackground(td, declarations); ok {
+		cell.Shading.Fill.RGB = fill
+	}
+	if value, ok := declarations["border"]; ok {
+		border := cssBorder(value)
+		cell.Borders = types.CellBorders{Top: border, Bottom: border, Left: border, Right: border}
+	}
+	for name, target := range map[string]*types.Border{
+		"border-top": &cell.Borders.Top, "border-bottom": &cell.Borders.Bottom,
+		"border-left": &cell.Borders.Left, "border-right": &cell.Borders.Right,
+	} {
+		if value, ok := declarations[name]; ok {
+			*target = cssBorder(value)
+		}
+	}
+	hd.cellParagraphs(td, hd.context(td, declarations, parent), &cell, i, j, k)
+	return cell
+}
+
+// cellParagraphs 读取单元格中的段落，嵌套表格中的段落按顺序并入单元格
+func (hd *htmlDocument) cellParagraphs(node *htmlNode, ctx htmlContext, cell *types.TableCell, i, j, k int) {
+	hd.eachBlock(node, ctx, func(block htmlBlock) {
+		if block.node.name == "table" {
+			for _, td := range htmlTableCells(block.node) {
+				hd.cellParagraphs(td, hd.context(td, hd.sheet.style(td), block.ctx), cell, i, j, k)
+			}
+			return
+		}
+		paragraph := hd.convertParagraph(block, fmt.Sprintf("cell_para_%d_%d_%d", i, j, k), func(int) string {
+			return fmt.Sprintf("cell_run_%d_%d_%d", i, j, k)
+		})
+		cell.Content = append(cell.Content, paragraph)
+	})
+}
+
+// htmlTableCells 按顺序返回表格中的所有单元格，不进入嵌套的表格
+func htmlTableCells(n *htmlNode) []*htmlNode {
+	var cells []*htmlNode
+	for _, c := range n.children {
+		switch c.name {
+		case "td", "th":
+			cells = append(cells, c)
+		case "tr", "thead", "tbody", "tfoot":
+			cells = append(cells, htmlTableCells(c)...)
+		}
+	}
+	return cells
+}
+
+// htmlBackground 返回bgcolor属性或CSS背景色
+func htmlBackground(n *htmlNode, declarations cssDeclarations) (string, bool) {
+	fill, ok := cssColor(n.attrs["bgcolor"])
+	for _, name := range []string{"background", "background-color"} {
+		if value := strings.Fields(declarations[name]); len(value) > 0 {
+			if color, isColor := cssColor(value[0]); isColor {
+				fill, ok = color, true
+			}
+		}
+	}
+	return fill, ok && fill != ""
+}
+
+// headersFooters 读取mso-element为header或footer的元素，Word将其写在MHTML的header.htm部件中；空的页眉页脚跳过
+func (hd *htmlDocument) headersFooters() ([]types.Header, []types.Footer) {
+	var headers []types.Header
+	var footers []types.Footer
+	var visit func(node *htmlNode)
+	visit = func(node *htmlNode) {
+		for _, c := range node.children {
+			if c.name == "" {
+				continue
+			}
+			declarations := hd.sheet.style(c)
+			element := declarations["mso-element"]
+			if element != "header" && element != "footer" {
+				visit(c)
+				continue
+			}
+			prefix, n := "footer", len(footers)+1
+			if element == "header" {
+				prefix, n = "header", len(headers)+1
+			}
+			var paragraphs []types.Paragraph
+			empty := true
+			hd.eachBlock(c, hd.context(c, declarations, rootContext()), func(block htmlBlock) {
+				if block.node.name == "table" {
+					return
+				}
+				k := len(paragraphs) + 1
+				paragraph := hd.convertParagraph(block, fmt.Sprintf("%s_para_%d_%d", prefix, n, k), func(j int) string {
+					return fmt.Sprintf("%s_run_%d_%d_%d", prefix, n, k, j+1)
+				})
+				empty = empty && strings.TrimSpace(paragraph.Text) == ""
+				paragraphs = append(paragraphs, paragraph)
+			})
+			if empty {
+				continue
+			}
+			if element == "header" {
+				headers = append(headers, types.Header{ID: fmt.Sprintf("header_%d", n), Content: paragraphs})
+			} else {
+				footers = append(footers, types.Footer{ID: fmt.Sprintf("footer_%d", n), Content: paragraphs})
+			}
+		}
+	}
+	for _, root := range append([]*htmlNode{hd.root}, hd.parts...) {
+		visit(root)
+	}
+	return headers, footers
+}
+
+// htmlStyleKinds 选择器的元素名对应的样式类型
+var htmlStyleKinds = map[string]string{
+	"p": "paragraph", "li": "paragraph", "h1": "paragraph", "h2": "paragraph", "h3": "paragraph",
+	"h4": "paragraph", "h5": "paragraph", "h6": "paragraph", "span": "character", "table": "table",
+}
+
+// htmlStyle 样式表中的一个Word样式
+type htmlStyle struct {
+	id           string
+	name         string
+	kind         string
+	selector     cssSelector
+	declarations cssDeclarations
+}
+
+// styles 按样式表中出现的顺序返回样式，p.MsoNormal、li.MsoNormal和div.MsoNormal等同一样式只返回一次
+func (hd *htmlDocument) styles() []htmlStyle {
+	var styles []htmlStyle
+	seen := make(map[string]bool)
+	for _, selector := range hd.sheet.order {
+		kind, ok := htmlStyleKinds[selector.tag]
+		id := htmlStyleID(selector.tag, selector.class)
+		if !ok || id == "" || (selector.class == "" && kind != "paragraph") || seen[kind+"/"+id] {
+			continue
+		}
+		declarations := hd.sheet.rules[selector]
+		name := cssUnquote(declarations["mso-style-name"])
+		// span.SpellE等校对标记的样式名为空，MsoNormalTable是表格的默认格式
+		if (kind == "character" && name == "") || selector.class == "MsoNormalTable" {
+			continue
+		}
+		if name == "" {
+			name = id
+			if level, ok := strings.CutPrefix(id, "Heading"); ok {
+				name = "heading " + level
+			}
+		}
+		seen[kind+"/"+id] = true
+		styles = append(styles, htmlStyle{id: id, name: name, kind: kind, selector: selector, declarations: declarations})
+	}
+	return styles
+}
+
+// documentStyles 返回样式表中的段落、字符和表格样式
+func (hd *htmlDocument) documentStyles() types.DocumentStyles {
+	styles := types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+	}
+	for _, style := range hd.styles() {
+		name := style.name
+		switch style.kind {
+		case "paragraph":
+			element := &htmlNode{name: style.selector.tag, attrs: map[string]string{"class": style.selector.class}}
+			ctx := hd.context(element, hd.sheet.style(element), rootContext())
+			styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{
+				ID:          style.id,
+				Name:        name,
+				Font:        convertHTMLFont(ctx.chars, name),
+				Alignment:   ctx.para.alignment,
+				Indentation: ctx.para.indentation,
+				Spacing:     ctx.para.spacing,
+			})
+		case "character":
+			styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: style.id, Name: name})
+		case "table":
+			styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: style.id, Name: name})
+		}
+	}
+	return styles
+}
+
+// formatRules 生成格式规则，样式规则来自样式表中的mso-style-name、mso-style-parent和mso-style-next
+func (hd *htmlDocument) formatRules(doc *types.Document) types.FormatRules {
+	rules := contentFormatRules(doc, hd.sheet.fontFaces)
+	for _, style := range hd.styles() {
+		rules.StyleRules = append(rules.StyleRules, types.StyleRule{
+			ID:      style.id,
+			Name:    style.name,
+			Type:    style.kind,
+			BasedOn: styleIDFromName(cssUnquote(style.declarations["mso-style-parent"])),
+			Next:    styleIDFromName(cssUnquote(style.declarations["mso-style-next"])),
+		})
+	}
+	return rules
+}
@@ -0,0 +1,112 @@
+package formats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/sniff"
+)
+
+// OdtParser OpenDocument文本（.odt/.ott）格式解析器
+type OdtParser struct{}
+
+// NewOdtParser 创建OpenDocument文本解析器
+func NewOdtParser() *OdtParser {
+	return &OdtParser{}
+}
+
+// ParseDocument 解析OpenDocument文本文档
+func (op *OdtParser) ParseDocument(filePath string) (*types.Document, error) {
+	if err := op.ValidateFile(filePath); err != nil {
+		return nil, err
+	}
+	return op.parse(filePath)
+}
+
+// ParseMetadata 解析元数据
+func (op *OdtParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
+	doc, err := op.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Metadata, nil
+}
+
+// ParseContent 解析内容
+func (op *OdtParser) ParseContent(filePath string) (*types.DocumentContent, error) {
+	doc, err := op.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Content, nil
+}
+
+// ParseStyles 解析样式
+func (op *OdtParser) ParseStyles(filePath string) (*types.DocumentStyles, error) {
+	doc, err := op.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Styles, nil
+}
+
+// ParseFormatRules 解析格式规则
+func (op *OdtParser) ParseFormatRules(filePath string) (*types.FormatRules, error) {
+	doc, err := op.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.FormatRules, nil
+}
+
+// GetSupportedFormats 获取支持的格式
+func (op *OdtParser) GetSupportedFormats() []string {
+	return []string{"odt", "ott"}
+}
+
+// ValidateFile 验证文件格式
+//
+// 按内容识别格式，内容有效时不要求扩展名匹配；内容无效且扩展名也不属于该格式时返回ErrUnsupportedFormat。
+func (op *OdtParser) ValidateFile(filePath string) error {
+	// 检查文件是否存在
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return parser.ErrFileNotFound
+	}
+
+	err := op.validateContent(filePath)
+	supported := map[string]bool{".odt": true, ".ott": true}
+	if err != nil && !supported[strings.ToLower(filepath.Ext(filePath))] {
+		return parser.ErrUnsupportedFormat
+	}
+	return err
+}
+
+// validateContent 检查ODF包的mimetype是否为文本文档或文本模板
+func (op *OdtParser) validateContent(filePath string) error {
+	result, err := sniff.File(filePath)
+	if err != nil || (result.Format != sniff.ODT && result.Format != sniff.OTT) {
+		return parser.ErrInvalidFile
+	}
+	return nil
+}
+
+// parse 读取并转换整个ODF包
+func (op *OdtParser) parse(filePath string) (*types.Document, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, parser.ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	doc, err := readODT(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	return doc, nil
+}
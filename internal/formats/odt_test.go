@@ -0,0 +1,325 @@
+package formats
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging"
+)
+
+// odfNamespaces ODF部件根元素的命名空间声明
+const odfNamespaces = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+	` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+	` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0"` +
+	` xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:xlink="http://www.w3.org/1999/xlink"` +
+	` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0"`
+
+// testODFStyles styles.xml：默认样式、继承的标题样式、列表样式、两种页面布局和带页眉的主控页
+const testODFStyles = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-styles ` + odfNamespaces + ` office:version="1.3">
+  <office:font-face-decls>
+    <style:font-face style:name="Liberation Serif" svg:font-family="'Liberation Serif'"/>
+    <style:font-face style:name="SimSun" svg:font-family="宋体"/>
+  </office:font-face-decls>
+  <office:styles>
+    <style:default-style style:family="paragraph">
+      <style:text-properties style:font-name="Liberation Serif" fo:font-size="12pt" style:font-name-asian="SimSun"/>
+    </style:default-style>
+    <style:style style:name="Standard" style:family="paragraph"/>
+    <style:style style:name="Heading" style:family="paragraph" style:parent-style-name="Standard" style:next-style-name="Text_20_body">
+      <style:paragraph-properties fo:margin-top="0.423cm" fo:margin-bottom="0.212cm" fo:keep-with-next="always"/>
+      <style:text-properties fo:font-size="14pt" fo:font-weight="bold"/>
+    </style:style>
+    <style:style style:name="Heading_20_1" style:display-name="Heading 1" style:family="paragraph" style:parent-style-name="Heading" style:default-outline-level="1">
+      <style:text-properties fo:font-size="130%"/>
+    </style:style>
+    <style:style style:name="Text_20_body" style:display-name="Text body" style:family="paragraph" style:parent-style-name="Standard">
+      <style:paragraph-properties fo:text-align="justify" fo:text-indent="24pt" fo:line-height="150%"/>
+    </style:style>
+    <style:style style:name="Emphasis" style:family="text">
+      <style:text-properties fo:font-style="italic" fo:color="#c9211e"/>
+    </style:style>
+    <text:list-style style:name="Numbering_20_123" style:display-name="Numbering 123">
+      <text:list-level-style-number text:level="1" style:num-suffix="." style:num-format="1">
+        <style:list-level-properties text:list-level-position-and-space-mode="label-alignment">
+          <style:list-level-label-alignment fo:margin-left="0.5in" fo:text-indent="-0.25in"/>
+        </style:list-level-properties>
+      </text:list-level-style-number>
+      <text:list-level-style-number text:level="2" style:num-suffix=")" style:num-format="a" text:display-levels="2"/>
+    </text:list-style>
+    <text:list-style style:name="List_20_1">
+      <text:list-level-style-bullet text:level="1" text:bullet-char="•"/>
+    </text:list-style>
+  </office:styles>
+  <office:automatic-styles>
+    <style:page-layout style:name="pm1">
+      <style:page-layout-properties fo:page-width="21cm" fo:page-height="29.7cm" fo:margin-top="1in" fo:margin-bottom="1in" fo:margin-left="1.25in" fo:margin-right="1.25in" style:num-format="1"/>
+      <style:header-style><style:header-footer-properties fo:min-height="0.5in" fo:margin-bottom="0.25in"/></style:header-style>
+    </style:page-layout>
+    <style:page-layout style:name="pm2">
+      <style:page-layout-properties fo:page-width="29.7cm" fo:page-height="21cm" fo:margin-top="2cm" fo:margin-bottom="2cm" fo:margin-left="2cm" fo:margin-right="2cm" style:first-page-number="1">
+        <style:columns fo:column-count="2" fo:column-gap="0.5in"/>
+      </style:page-layout-properties>
+    </style:page-layout>
+    <style:style style:name="MP1" style:family="paragraph" style:parent-style-name="Standard">
+      <style:paragraph-properties fo:text-align="center"/>
+    </style:style>
+  </office:automatic-styles>
+  <office:master-styles>
+    <style:master-page style:name="Standard" style:page-layout-name="pm1">
+      <style:header><text:p text:style-name="MP1">机密文件</text:p></style:header>
+      <style:footer><text:p/></style:footer>
+    </style:master-page>
+    <style:master-page style:name="Landscape" style:page-layout-name="pm2"/>
+  </office:master-styles>
+</office:document-styles>`
+
+// testODFContent content.xml：标题、带字符样式的正文、嵌套编号列表、项目符号列表、合并单元格的表格和横向的第二节
+const testODFContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content ` + odfNamespaces + ` office:version="1.3">
+  <office:automatic-styles>
+    <style:style style:name="P1" style:family="paragraph" style:parent-style-name="Text_20_body">
+      <style:text-properties fo:font-size="11pt"/>
+    </style:style>
+    <style:style style:name="P2" style:family="paragraph" style:parent-style-name="Standard" style:master-page-name="Landscape"/>
+    <style:style style:name="T1" style:family="text">
+      <style:text-properties fo:font-weight="bold" style:text-underline-style="solid"/>
+    </style:style>
+    <style:style style:name="Table1" style:family="table">
+      <style:table-properties style:width="6in" table:align="center"/>
+    </style:style>
+    <style:style style:name="Table1.A" style:family="table-column">
+      <style:table-column-properties style:column-width="2in"/>
+    </style:style>
+    <style:style style:name="Table1.A1" style:family="table-cell">
+      <style:table-cell-properties style:vertical-align="middle" fo:background-color="#dddddd" fo:border="0.5pt solid #000000"/>
+    </style:style>
+  </office:automatic-styles>
+  <office:body>
+    <office:text>
+      <text:sequence-decls/>
+      <text:h text:style-name="Heading_20_1" text:outline-level="1"><text:bookmark text:name="intro"/>Overview</text:h>
+      <text:p text:style-name="P1">普通  <text:span text:style-name="T1">加粗</text:span><text:s text:c="2"/><text:span text:style-name="Emphasis">强调</text:span><text:tab/>结尾</text:p>
+      <text:list text:style-name="Numbering_20_123">
+        <text:list-item><text:p text:style-name="Standard">第一项</text:p>
+          <text:list><text:list-item><text:p>子项</text:p></text:list-item></text:list>
+        </text:list-item>
+        <text:list-item><text:p>第二项</text:p><text:p>续段</text:p></text:list-item>
+      </text:list>
+      <text:list text:style-name="List_20_1"><text:list-item><text:p>要点</text:p></text:list-item></text:list>
+      <text:list text:style-name="Numbering_20_123" text:continue-numbering="true"><text:list-item><text:p>第三项</text:p></text:list-item></text:list>
+      <text:tracked-changes><text:changed-region><text:deletion><text:p>已删除</text:p></text:deletion></text:changed-region></text:tracked-changes>
+      <table:table table:name="Table1" table:style-name="Table1">
+        <table:table-column table:style-name="Table1.A" table:number-columns-repeated="3"/>
+        <table:table-header-rows>
+          <table:table-row>
+            <table:table-cell table:style-name="Table1.A1" table:number-columns-spanned="2"><text:p>标题</text:p></table:table-cell>
+            <table:covered-table-cell/>
+            <table:table-cell table:number-rows-spanned="2"><text:p>合并</text:p></table:table-cell>
+          </table:table-row>
+        </table:table-header-rows>
+        <table:table-row>
+          <table:table-cell><text:p>甲</text:p></table:table-cell>
+          <table:table-cell><text:p>乙</text:p></table:table-cell>
+          <table:covered-table-cell/>
+        </table:table-row>
+      </table:table>
+      <text:p text:style-name="P2">横向<draw:frame draw:name="图片1" svg:width="2in" svg:height="1in"><draw:image xlink:href="Pictures/logo.png"/><svg:title>标志</svg:title></draw:frame></text:p>
+    </office:text>
+  </office:body>
+</office:document-content>`
+
+// testODFMeta meta.xml：文档属性和统计信息
+const testODFMeta = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-meta ` + odfNamespaces + ` office:version="1.3">
+  <office:meta>
+    <dc:title>年度报告</dc:title>
+    <meta:initial-creator>张三</meta:initial-creator>
+    <dc:creator>李四</dc:creator>
+    <meta:keyword>报告</meta:keyword>
+    <meta:keyword>年度</meta:keyword>
+    <meta:creation-date>2024-03-01T08:00:00.123456789</meta:creation-date>
+    <meta:editing-cycles>5</meta:editing-cycles>
+    <meta:document-statistic meta:page-count="2" meta:word-count="42"/>
+  </office:meta>
+</office:document-meta>`
+
+// writeTestODF 写入mimetype为mimeType的ODF包，parts为部件名到内容的映射
+func writeTestODF(t *testing.T, name, mimeType string, parts map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	w, err := writer.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatalf("写入mimetype失败: %v", err)
+	}
+	w.Write([]byte(mimeType))
+	for part, content := range parts {
+		w, err := writer.Create(part)
+		if err != nil {
+			t.Fatalf("写入%s失败: %v", part, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("生成测试文档失败: %v", err)
+	}
+	return path
+}
+
+// TestOdtParser_Document 测试ODF文本文档的样式继承、列表编号、表格、主控页和文档属性
+func TestOdtParser_Document(t *testing.T) {
+	path := writeTestODF(t, "report.odt", packaging.MimeTypeText, map[string]string{
+		"content.xml": testODFContent,
+		"styles.xml":  testODFStyles,
+		"meta.xml":    testODFMeta,
+	})
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	metadata := doc.Metadata
+	if metadata.Title != "年度报告" || metadata.Author != "张三" || metadata.LastSavedBy != "李四" || metadata.Revision != 5 ||
+		metadata.PageCount != 2 || metadata.Created.Year() != 2024 || len(metadata.Keywords) != 2 {
+		t.Errorf("文档属性不正确: %+v", metadata)
+	}
+	if metadata.DocumentKind != types.KindDocument || metadata.HasMacros || len(metadata.Warnings) != 0 {
+		t.Errorf("文档类型不正确: %s %v %q", metadata.DocumentKind, metadata.HasMacros, metadata.Warnings)
+	}
+
+	paragraphs := doc.Content.Paragraphs
+	if len(paragraphs) != 9 {
+		t.Fatalf("应有9个段落（修订记录中删除的段落不计入），实际 %d", len(paragraphs))
+	}
+	heading := paragraphs[0]
+	if heading.Style.Name != "Heading_20_1" || heading.OutlineLevel != 1 || !heading.KeepNext || heading.Text != "Overview" {
+		t.Errorf("标题段落不正确: %+v", heading)
+	}
+	if run := heading.Runs[0]; run.Font.Name != "Liberation Serif" || run.Size != 18.2 || !run.Bold {
+		t.Errorf("标题应继承父样式的加粗并按百分比计算字号，实际 %+v", run.Font)
+	}
+
+	body := paragraphs[1]
+	if body.Style.Name != "Text_20_body" || body.Alignment != types.AlignJustify || body.Indentation.First != 24 || body.Spacing.Line != 1.5 {
+		t.Errorf("正文段落应使用自动样式的父样式和继承的格式: %+v", body)
+	}
+	if body.Text != "普通 加粗  强调\t结尾" || len(body.Runs) != 5 {
+		t.Errorf("正文文本或文本运行不正确: %q %d", body.Text, len(body.Runs))
+	}
+	if run := body.Runs[0]; run.Size != 11 || run.Font.Name != "宋体" {
+		t.Errorf("中文文本应使用东亚字体和自动样式的字号，实际 %+v", run.Font)
+	}
+	if run := body.Runs[1]; !run.Bold || run.Underline != types.UnderlineSingle {
+		t.Errorf("字符自动样式不正确: %+v", run)
+	}
+	if run := body.Runs[3]; !run.Italic || run.Color.RGB != "C9211E" || run.Text != "强调" {
+		t.Errorf("字符样式和颜色不正确: %+v", run)
+	}
+
+	labels := []string{"1.", "1.a)", "2.", "", "•", "3."}
+	for i, label := range labels {
+		list := paragraphs[i+2].List
+		if label == "" {
+			if list != nil {
+				t.Errorf("列表项的后续段落不应编号，实际 %+v", list)
+			}
+			continue
+		}
+		if list == nil || list.Label != label {
+			t.Errorf("段落%d编号应为 %q，实际 %+v", i+3, label, list)
+		}
+	}
+	if first := paragraphs[2]; first.Style.Name != "" || first.Indentation.Left != 36 || first.Indentation.Hanging != 18 || first.List.Format != "decimal" {
+		t.Errorf("列表级别的缩进和格式不正确: %+v %+v", first.Indentation, first.List)
+	}
+
+	if len(doc.Content.Bookmarks) != 1 || doc.Content.Bookmarks[0].Name != "intro" {
+		t.Errorf("书签不正确: %+v", doc.Content.Bookmarks)
+	}
+	if images := doc.Content.Images; len(images) != 1 || images[0].Width != 144 || images[0].Path != "Pictures/logo.png" || images[0].AltText != "标志" {
+		t.Errorf("图片不正确: %+v", images)
+	}
+
+	if len(doc.Content.Tables) != 1 {
+		t.Fatalf("应有1个表格，实际 %d", len(doc.Content.Tables))
+	}
+	table := doc.Content.Tables[0]
+	if table.Width != 432 || table.Alignment != types.AlignCenter || len(table.Rows) != 2 || !table.Rows[0].Header || table.Rows[1].Header {
+		t.Errorf("表格属性不正确: %+v", table)
+	}
+	title := table.Rows[0].Cells[0]
+	if title.Merge.Horizontal != 2 || title.Width != 288 || title.VerticalAlignment != types.VAlignCenter ||
+		title.Shading.Fill.RGB != "DDDDDD" || title.Borders.Top.Width != 0.5 || title.Borders.Top.Style != types.BorderSingle {
+		t.Errorf("合并单元格的格式不正确: %+v", title)
+	}
+	if cell := table.Rows[0].Cells[1]; cell.Merge.Vertical != 2 || cell.Content[0].Text != "合并" {
+		t.Errorf("纵向合并应跨2行: %+v", cell)
+	}
+	if len(table.Rows[1].Cells) != 2 {
+		t.Errorf("被覆盖的单元格不应计入，实际 %d 个单元格", len(table.Rows[1].Cells))
+	}
+
+	sections := doc.Content.Sections
+	if len(sections) != 2 {
+		t.Fatalf("主控页变化应开始新的节，实际 %d 个节", len(sections))
+	}
+	if s := sections[0]; int(s.PageSize.Width) != 595 || s.PageMargins.Left != 90 || s.PageMargins.Header != 72 || s.PageMargins.Top != 126 {
+		t.Errorf("第一节页面设置不正确: %+v %+v", s.PageSize, s.PageMargins)
+	}
+	if s := sections[1]; s.PageSize.Width <= s.PageSize.Height || s.Columns.Count != 2 || s.Columns.Spacing != 36 || !s.PageNumbering.Restart {
+		t.Errorf("第二节应为横向双栏并重新编号: %+v", s)
+	}
+	if !paragraphs[8].PageBreak {
+		t.Error("使用新主控页的段落应分页")
+	}
+	if len(doc.Content.Headers) != 1 || doc.Content.Headers[0].Content[0].Text != "机密文件" ||
+		doc.Content.Headers[0].Content[0].Alignment != types.AlignCenter || len(doc.Content.Footers) != 0 {
+		t.Errorf("应只有非空的页眉并使用styles.xml中的自动样式: %+v %+v", doc.Content.Headers, doc.Content.Footers)
+	}
+
+	if len(doc.Styles.ParagraphStyles) != 4 || len(doc.Styles.CharacterStyles) != 1 {
+		t.Errorf("样式表不正确: %+v", doc.Styles)
+	}
+	var headingRule *types.StyleRule
+	for i, rule := range doc.FormatRules.StyleRules {
+		if rule.ID == "Heading" {
+			headingRule = &doc.FormatRules.StyleRules[i]
+		}
+	}
+	if headingRule == nil || headingRule.BasedOn != "Standard" || headingRule.Next != "Text_20_body" {
+		t.Errorf("样式规则不正确: %+v", doc.FormatRules.StyleRules)
+	}
+}
+
+// TestOdtParser_Template 测试OTT模板记录为模板，StarBasic模块记录为宏
+func TestOdtParser_Template(t *testing.T) {
+	path := writeTestODF(t, "letter.ott", packaging.MimeTypeTextTemplate, map[string]string{
+		"content.xml":                  `<office:document-content ` + odfNamespaces + `><office:body><office:text><text:p>模板正文</text:p></office:text></office:body></office:document-content>`,
+		"Basic/Standard/script-lb.xml": `<library:library/>`,
+		"Basic/Standard/Module1.xml":   `<script:module/>`,
+	})
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if doc.Metadata.DocumentKind != types.KindTemplate || !doc.Metadata.HasMacros {
+		t.Errorf("应为包含宏的模板，实际 %s、%v", doc.Metadata.DocumentKind, doc.Metadata.HasMacros)
+	}
+	if len(doc.Content.Paragraphs) != 1 || doc.Content.Paragraphs[0].Text != "模板正文" || len(doc.Content.Sections) != 1 {
+		t.Errorf("没有styles.xml时应使用默认节: %+v", doc.Content)
+	}
+
+	if err := NewOdtParser().ValidateFile(writeTestOOXML(t, "plain.docx", "word/document.xml", packaging.ContentTypeDocument, false)); err == nil {
+		t.Error("OOXML文档不应通过ODF验证")
+	}
+}
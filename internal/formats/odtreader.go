@@ -0,0 +1,1337 @@
+package formats
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging"
+)
+
+// OpenDocument文本文档（.odt/.ott）的读取
+//
+// ODF包是ZIP归档：content.xml中是正文和正文使用的自动样式，styles.xml中是常用样式、默认样式、页面布局和主控页
+// （页眉页脚），meta.xml中是文档属性。样式按style:parent-style-name继承，自动样式的父样式是常用样式；
+// 段落样式的style:master-page-name开始使用新主控页的节。长度带单位（cm、in、pt等），统一转换为磅。
+
+// ODF包中的部件
+const (
+	odfContentPart = "content.xml"
+	odfStylesPart  = "styles.xml"
+	odfMetaPart    = "meta.xml"
+)
+
+// odfDefaultStyle 默认段落样式，与DOCX的Normal相同，段落不记录该样式名称
+const odfDefaultStyle = "Standard"
+
+// errODFContent 包中没有content.xml
+var errODFContent = errors.New("package has no content.xml")
+
+// odfNode ODF XML中的元素或文本节点，name为空表示文本节点，属性按本地名称索引
+type odfNode struct {
+	name     string
+	attrs    map[string]string
+	children []*odfNode
+	text     string
+}
+
+// readODFTree 将XML解析为保留文本与元素顺序的节点树
+func readODFTree(r io.Reader) (*odfNode, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = xmlCharsetReader
+	var root *odfNode
+	var stack []*odfNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &odfNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" {
+					node.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &odfNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty XML document")
+	}
+	return root, nil
+}
+
+// child 返回第一个名为name的子元素，不存在时返回nil
+func (n *odfNode) child(name string) *odfNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// elements 返回子节点，元素不存在时返回nil
+func (n *odfNode) elements() []*odfNode {
+	if n == nil {
+		return nil
+	}
+	return n.children
+}
+
+// attr 返回属性值，元素不存在时返回空字符串
+func (n *odfNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.attrs[name]
+}
+
+// length 读取带单位的长度属性并转换为磅
+func (n *odfNode) length(name string) (float64, bool) {
+	value := n.attr(name)
+	if value == "" {
+		return 0, false
+	}
+	return cssLength(value)
+}
+
+// textContent 返回元素中的全部文本
+func (n *odfNode) textContent() string {
+	if n == nil {
+		return ""
+	}
+	if n.name == "" {
+		return n.text
+	}
+	var text strings.Builder
+	for _, c := range n.children {
+		text.WriteString(c.textContent())
+	}
+	return text.String()
+}
+
+// odfStyle style:style样式
+type odfStyle struct {
+	name        string
+	displayName string
+	family      string // paragraph、text、table、table-column、table-row或table-cell
+	parent      string
+	next        string
+	masterPage  string
+	outline     int
+	automatic   bool
+	node        *odfNode
+}
+
+// odfListStyle text:list-style列表样式，编号文本按Word 2003 XML列表的占位符形式保存，计数规则相同
+type odfListStyle struct {
+	name    string
+	levels  [9]*wmlListLevel
+	indents [9]*types.Indentation // 编号级别的缩进，未设置时为nil
+}
+
+// odfParaProps 段落属性
+type odfParaProps struct {
+	alignment   types.Alignment
+	indentation types.Indentation
+	spacing     types.Spacing
+	keepNext    bool
+	keepLines   bool
+	pageBreak   bool
+}
+
+// odfCharProps 字符属性
+type odfCharProps struct {
+	font      string
+	eastAsia  string
+	size      float64
+	color     string
+	bold      bool
+	italic    bool
+	underline types.Underline
+	position  types.Position
+}
+
+// odtDocument 读取中的ODF文本文档
+type odtDocument struct {
+	mimeType    string
+	hasMacros   bool
+	meta        *odfNode
+	content     *odfNode
+	fontFaces   map[string]string    // style:font-face名称到字体族
+	fonts       []string             // 字体声明中的字体族
+	common      map[string]*odfStyle // 常用样式，键为"族/名称"
+	commonOrder []*odfStyle
+	defaults    map[string]*odfNode  // 各样式族的默认样式
+	auto        map[string]*odfStyle // 当前使用的自动样式：正文为content.xml中的，页眉页脚为styles.xml中的
+	contentAuto map[string]*odfStyle
+	stylesAuto  map[string]*odfStyle
+	listStyles  map[string]*odfListStyle
+	listOrder   []*odfListStyle
+	lists       map[string]*wmlList // 按列表样式计数
+	pageLayouts map[string]*odfNode
+	masterPages map[string]*odfNode
+	firstMaster string
+	lineNumbers *odfNode
+	bookmarks   []types.Bookmark
+	images      []types.Image
+}
+
+// readODT 读取ODF文本文档
+func readODT(data []byte) (*types.Document, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[string]*zip.File)
+	od := &odtDocument{
+		mimeType:    packaging.ODFMimeType(archive.File),
+		fontFaces:   make(map[string]string),
+		common:      make(map[string]*odfStyle),
+		defaults:    make(map[string]*odfNode),
+		contentAuto: make(map[string]*odfStyle),
+		stylesAuto:  make(map[string]*odfStyle),
+		listStyles:  make(map[string]*odfListStyle),
+		lists:       make(map[string]*wmlList),
+		pageLayouts: make(map[string]*odfNode),
+		masterPages: make(map[string]*odfNode),
+	}
+	for _, file := range archive.File {
+		parts[file.Name] = file
+		// Basic/库名/模块名.xml为StarBasic宏模块
+		if strings.HasPrefix(file.Name, "Basic/") && strings.Count(file.Name, "/") == 2 &&
+			path.Ext(file.Name) == ".xml" && path.Base(file.Name) != "script-lb.xml" {
+			od.hasMacros = true
+		}
+	}
+
+	readPart := func(name string) (*odfNode, error) {
+		file, ok := parts[name]
+		if !ok {
+			return nil, nil
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer reader.Close()
+		root, err := readODFTree(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		return root, nil
+	}
+	if od.content, err = readPart(odfContentPart); err != nil {
+		return nil, err
+	}
+	if od.content == nil {
+		return nil, errODFContent
+	}
+	styles, err := readPart(odfStylesPart)
+	if err != nil {
+		return nil, err
+	}
+	if od.meta, err = readPart(odfMetaPart); err != nil {
+		return nil, err
+	}
+
+	// styles.xml在前：content.xml中的自动样式以其中的常用样式为父样式
+	for _, root := range []*odfNode{styles, od.content} {
+		od.readFontFaces(root.child("font-face-decls"))
+	}
+	od.readStyles(styles.child("styles"), od.common, false)
+	od.readStyles(styles.child("automatic-styles"), od.stylesAuto, true)
+	od.readStyles(od.content.child("automatic-styles"), od.contentAuto, true)
+	od.readMasterPages(styles.child("master-styles"))
+	return od.document(int64(len(data))), nil
+}
+
+// readFontFaces 读取字体声明
+func (od *odtDocument) readFontFaces(decls *odfNode) {
+	for _, face := range decls.elements() {
+		if face.name != "font-face" {
+			continue
+		}
+		family := strings.Trim(face.attr("font-family"), `'"`)
+		if family == "" {
+			family = face.attr("name")
+		}
+		if _, seen := od.fontFaces[face.attr("name")]; !seen {
+			od.fonts = append(od.fonts, family)
+		}
+		od.fontFaces[face.attr("name")] = family
+	}
+}
+
+// readStyles 读取office:styles或office:automatic-styles中的样式、列表样式和页面布局
+func (od *odtDocument) readStyles(container *odfNode, styles map[string]*odfStyle, automatic bool) {
+	for _, node := range container.elements() {
+		switch node.name {
+		case "style":
+			style := &odfStyle{
+				name:        node.attr("name"),
+				displayName: node.attr("display-name"),
+				family:      node.attr("family"),
+				parent:      node.attr("parent-style-name"),
+				next:        node.attr("next-style-name"),
+				masterPage:  node.attr("master-page-name"),
+				automatic:   automatic,
+				node:        node,
+			}
+			style.outline, _ = strconv.Atoi(node.attr("default-outline-level"))
+			if style.displayName == "" {
+				style.displayName = style.name
+			}
+			styles[style.family+"/"+style.name] = style
+			if !automatic {
+				od.commonOrder = append(od.commonOrder, style)
+			}
+		case "default-style":
+			od.defaults[node.attr("family")] = node
+		case "list-style":
+			od.readListStyle(node, !automatic)
+		case "page-layout":
+			od.pageLayouts[node.attr("name")] = node
+		case "linenumbering-configuration":
+			od.lineNumbers = node
+		}
+	}
+}
+
+// odfNumFormats style:num-format对应的OOXML编号格式
+var odfNumFormats = map[string]string{
+	"1": "decimal", "a": "lowerLetter", "A": "upperLetter", "i": "lowerRoman", "I": "upperRoman", "": "none",
+}
+
+// readListStyle 读取列表样式的各级编号：编号文本转换为"%1.%2"形式的占位符，项目符号保存符号字符
+func (od *odtDocument) readListStyle(node *odfNode, common bool) {
+	list := &odfListStyle{name: node.attr("name")}
+	for _, lvl := range node.elements() {
+		index, err := strconv.Atoi(lvl.attr("level"))
+		if err != nil || index < 1 || index > len(list.levels) {
+			continue
+		}
+		index--
+		level := &wmlListLevel{start: 1}
+		switch lvl.name {
+		case "list-level-style-number":
+			format, ok := odfNumFormats[lvl.attr("num-format")]
+			if !ok {
+				format = "decimal"
+			}
+			level.format = format
+			if start, err := strconv.Atoi(lvl.attr("start-value")); err == nil {
+				level.start = start
+			}
+			if format != "none" {
+				display := 1
+				if n, err := strconv.Atoi(lvl.attr("display-levels")); err == nil && n > 1 {
+					display = min(n, index+1)
+				}
+				placeholders := make([]string, 0, display)
+				for i := index - display + 1; i <= index; i++ {
+					placeholders = append(placeholders, "%"+strconv.Itoa(i+1))
+				}
+				level.text = lvl.attr("num-prefix") + strings.Join(placeholders, ".") + lvl.attr("num-suffix")
+			}
+		case "list-level-style-bullet":
+			level.format = "bullet"
+			level.text = lvl.attr("bullet-char")
+		case "list-level-style-image":
+			level.format = "bullet"
+		default:
+			continue
+		}
+		list.levels[index] = level
+		list.indents[index] = odfListIndent(lvl.child("list-level-properties"))
+	}
+	od.listStyles[list.name] = list
+	if common {
+		od.listOrder = append(od.listOrder, list)
+	}
+}
+
+// odfListIndent 读取编号级别的缩进：label-alignment模式使用fo:margin-left和fo:text-indent，
+// 旧的position-and-space模式使用text:space-before和text:min-label-width
+func odfListIndent(props *odfNode) *types.Indentation {
+	if props == nil {
+		return nil
+	}
+	if alignment := props.child("list-level-label-alignment"); alignment != nil {
+		indent := &types.Indentation{}
+		indent.Left, _ = alignment.length("margin-left")
+		if v, ok := alignment.length("text-indent"); ok {
+			if v < 0 {
+				indent.Hanging = -v
+			} else {
+				indent.First = v
+			}
+		}
+		return indent
+	}
+	before, hasBefore := props.length("space-before")
+	width, hasWidth := props.length("min-label-width")
+	if !hasBefore && !hasWidth {
+		return nil
+	}
+	return &types.Indentation{Left: before + width, Hanging: width}
+}
+
+// readMasterPages 读取主控页，第一个主控页为文档开头使用的主控页（通常为Standard）
+func (od *odtDocument) readMasterPages(styles *odfNode) {
+	for _, master := range styles.elements() {
+		if master.name != "master-page" {
+			continue
+		}
+		od.masterPages[master.attr("name")] = master
+		if od.firstMaster == "" {
+			od.firstMaster = master.attr("name")
+		}
+	}
+	if _, ok := od.masterPages[odfDefaultStyle]; ok {
+		od.firstMaster = odfDefaultStyle
+	}
+}
+
+// lookup 查找样式：先查当前的自动样式，再查常用样式
+func (od *odtDocument) lookup(family, name string) *odfStyle {
+	if style, ok := od.auto[family+"/"+name]; ok {
+		return style
+	}
+	return od.common[family+"/"+name]
+}
+
+// styleChain 返回从最底层父样式到name的样式链，循环的继承在重复处截断
+func (od *odtDocument) styleChain(family, name string) []*odfStyle {
+	var chain []*odfStyle
+	seen := make(map[*odfStyle]bool)
+	for style := od.lookup(family, name); style != nil && !seen[style]; {
+		seen[style] = true
+		chain = append([]*odfStyle{style}, chain...)
+		parent := od.common[family+"/"+style.parent]
+		if parent == nil {
+			parent = od.lookup(family, style.parent)
+		}
+		style = parent
+	}
+	return chain
+}
+
+// commonStyle 返回样式链中最近的常用样式，即自动样式的父样式，没有时返回nil
+func commonStyle(chain []*odfStyle) *odfStyle {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !chain[i].automatic {
+			return chain[i]
+		}
+	}
+	return nil
+}
+
+// styleProps 返回默认样式和样式链合并后的段落和字符属性
+func (od *odtDocument) styleProps(family, name string) (odfParaProps, odfCharProps) {
+	para := odfParaProps{alignment: types.AlignLeft}
+	chars := odfCharProps{size: 10}
+	if defaults := od.defaults[family]; defaults != nil {
+		para.apply(defaults.child("paragraph-properties"))
+		od.applyText(&chars, defaults.child("text-properties"))
+	}
+	for _, style := range od.styleChain(family, name) {
+		para.apply(style.node.child("paragraph-properties"))
+		od.applyText(&chars, style.node.child("text-properties"))
+	}
+	return para, chars
+}
+
+// apply 应用style:paragraph-properties中的段落属性
+func (p *odfParaProps) apply(props *odfNode) {
+	if props == nil {
+		return
+	}
+	switch props.attr("text-align") {
+	case "start", "left":
+		p.alignment = types.AlignLeft
+	case "end", "right":
+		p.alignment = types.AlignRight
+	case "center":
+		p.alignment = types.AlignCenter
+	case "justify":
+		p.alignment = types.AlignJustify
+	}
+	if v, ok := props.length("margin-left"); ok {
+		p.indentation.Left = v
+	}
+	if v, ok := props.length("margin-right"); ok {
+		p.indentation.Right = v
+	}
+	if v, ok := props.length("text-indent"); ok {
+		p.indentation.First, p.indentation.Hanging = 0, 0
+		if v < 0 {
+			p.indentation.Hanging = -v
+		} else {
+			p.indentation.First = v
+		}
+	}
+	if v, ok := props.length("margin-top"); ok {
+		p.spacing.Before = v
+	}
+	if v, ok := props.length("margin-bottom"); ok {
+		p.spacing.After = v
+	}
+	// 与DOCX一致：百分比行距为倍数，固定行距按12磅一行转换
+	if height := props.attr("line-height"); strings.HasSuffix(height, "%") {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(height, "%"), 64); err == nil {
+			p.spacing.Line = v / 100.0
+		}
+	} else if v, ok := props.length("line-height"); ok {
+		p.spacing.Line = v / 12.0
+	} else if v, ok := props.length("line-height-at-least"); ok {
+		p.spacing.Line = v / 12.0
+	}
+	if keep := props.attr("keep-with-next"); keep != "" {
+		p.keepNext = keep == "always"
+	}
+	if keep := props.attr("keep-together"); keep != "" {
+		p.keepLines = keep == "always"
+	}
+	if pageBreak := props.attr("break-before"); pageBreak != "" {
+		p.pageBreak = pageBreak == "page"
+	}
+}
+
+// applyText 应用style:text-properties中的字符属性，百分比字号相对于父样式的字号
+func (od *odtDocument) applyText(c *odfCharProps, props *odfNode) {
+	if props == nil {
+		return
+	}
+	if name := props.attr("font-name"); name != "" {
+		c.font = od.fontFamily(name)
+	} else if family := props.attr("font-family"); family != "" {
+		c.font = strings.Trim(family, `'"`)
+	}
+	if name := props.attr("font-name-asian"); name != "" {
+		c.eastAsia = od.fontFamily(name)
+	} else if family := props.attr("font-family-asian"); family != "" {
+		c.eastAsia = strings.Trim(family, `'"`)
+	}
+	if size := props.attr("font-size"); strings.HasSuffix(size, "%") {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(size, "%"), 64); err == nil {
+			c.size = c.size * v / 100.0
+		}
+	} else if v, ok := props.length("font-size"); ok {
+		c.size = v
+	}
+	switch weight := props.attr("font-weight"); weight {
+	case "":
+	case "bold", "bolder":
+		c.bold = true
+	default:
+		n, err := strconv.Atoi(weight)
+		c.bold = err == nil && n >= 600
+	}
+	switch props.attr("font-style") {
+	case "italic", "oblique":
+		c.italic = true
+	case "normal":
+		c.italic = false
+	}
+	if style := props.attr("text-underline-style"); style != "" {
+		c.underline = odfUnderline(style, props.attr("text-underline-type"))
+	}
+	if color := props.attr("color"); strings.HasPrefix(color, "#") {
+		c.color = strings.ToUpper(strings.TrimPrefix(color, "#"))
+	}
+	if position := props.attr("text-position"); position != "" {
+		c.position = odfPosition(position)
+	}
+}
+
+// fontFamily 返回字体声明对应的字体族，未声明时返回名称本身
+func (od *odtDocument) fontFamily(name string) string {
+	if family, ok := od.fontFaces[name]; ok {
+		return family
+	}
+	return name
+}
+
+// odfUnderline 将下划线线型转换为与OOXML相同的名称
+func odfUnderline(style, kind string) types.Underline {
+	switch {
+	case style == "none":
+		return types.UnderlineNone
+	case kind == "double":
+		return types.UnderlineDouble
+	case style == "dotted":
+		return types.UnderlineDotted
+	case strings.Contains(style, "dash"):
+		return types.UnderlineDashed
+	}
+	return types.UnderlineSingle
+}
+
+// odfPosition 转换上标和下标：super、sub或以百分比表示的基线偏移
+func odfPosition(position string) types.Position {
+	fields := strings.Fields(position)
+	if len(fields) == 0 {
+		return ""
+	}
+	offset := fields[0]
+	switch {
+	case offset == "super" || (!strings.HasPrefix(offset, "-") && offset != "0%" && strings.HasSuffix(offset, "%")):
+		return types.PositionSuperscript
+	case offset == "sub" || strings.HasPrefix(offset, "-"):
+		return types.PositionSubscript
+	}
+	return ""
+}
+
+// odfListItem 段落所在的列表项
+type odfListItem struct {
+	style    string // 列表样式名称
+	level    int    // 从0开始的列表级别
+	header   bool   // text:list-header，不编号
+	start    int    // text:start-value，0表示继续计数
+	labelled bool   // 列表项的第一个段落已编号，之后的段落不再编号
+}
+
+// odtBlockContainers 遍历正文时进入的容器元素，其他元素（如保存已删除内容的修订记录）不进入
+var odtBlockContainers = map[string]bool{
+	"section": true, "index-body": true, "index-title": true, "table-of-content": true, "illustration-index": true,
+	"table-index": true, "object-index": true, "user-index": true, "alphabetical-index": true, "bibliography": true,
+}
+
+// eachBlock 按文档顺序遍历段落、标题和表格，进入节、目录和列表，item为段落所在的列表项
+func (od *odtDocument) eachBlock(node *odfNode, item *odfListItem, fn func(block *odfNode, item *odfListItem)) {
+	for _, c := range node.elements() {
+		switch {
+		case c.name == "p", c.name == "h", c.name == "table":
+			fn(c, item)
+		case c.name == "list":
+			od.eachListItem(c, item, fn)
+		case odtBlockContainers[c.name]:
+			od.eachBlock(c, item, fn)
+		}
+	}
+}
+
+// eachListItem 遍历text:list中的列表项，最外层列表除非声明继续编号，否则重新开始计数
+func (od *odtDocument) eachListItem(list *odfNode, parent *odfListItem, fn func(block *odfNode, item *odfListItem)) {
+	style, level := list.attr("style-name"), 0
+	if parent != nil {
+		level = parent.level + 1
+		if style == "" {
+			style = parent.style
+		}
+	} else if list.attr("continue-numbering") != "true" && list.attr("continue-list") == "" {
+		delete(od.lists, style)
+	}
+	for _, c := range list.children {
+		if c.name != "list-item" && c.name != "list-header" {
+			continue
+		}
+		item := &odfListItem{style: style, level: level, header: c.name == "list-header"}
+		item.start, _ = strconv.Atoi(c.attr("start-value"))
+		od.eachBlock(c, item, fn)
+	}
+}
+
+// listLabel 为列表项的第一个段落计数并返回编号信息，列表项中的其他段落只返回缩进
+func (od *odtDocument) listLabel(item *odfListItem) (*types.ListInfo, *types.Indentation) {
+	style := od.listStyles[item.style]
+	if style == nil || item.level >= len(style.levels) || style.levels[item.level] == nil {
+		return nil, nil
+	}
+	level, indent := style.levels[item.level], style.indents[item.level]
+	if item.header || item.labelled {
+		return nil, indent
+	}
+	item.labelled = true
+
+	list := od.lists[item.style]
+	if list == nil {
+		list = &wmlList{levels: style.levels}
+		od.lists[item.style] = list
+	}
+	if item.start > 0 {
+		list.counters[item.level] = item.start - 1
+		list.started[item.level] = true
+	}
+	return &types.ListInfo{ID: item.style, Level: item.level, Format: level.format, Label: list.next(item.level)}, indent
+}
+
+// document 将读取的结构转换为与DOCX解析结果相同的文档
+func (od *odtDocument) document(fileSize int64) *types.Document {
+	doc := &types.Document{
+		Metadata: od.metadata(fileSize),
+		Content:  od.body(),
+		Styles:   od.documentStyles(),
+	}
+	if doc.Metadata.WordCount == 0 {
+		for _, paragraph := range doc.Content.Paragraphs {
+			doc.Metadata.WordCount += len(strings.Fields(paragraph.Text))
+		}
+	}
+	doc.FormatRules = od.formatRules(doc)
+	return doc
+}
+
+// metadata 读取meta.xml中的文档属性，ODT和OTT分别记录为文档和模板
+func (od *odtDocument) metadata(fileSize int64) types.DocumentMetadata {
+	meta := od.meta.child("meta")
+	text := func(name string) string {
+		return strings.TrimSpace(meta.child(name).textContent())
+	}
+	date := func(name string) time.Time {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if t, err := time.Parse(layout, text(name)); err == nil {
+				return t
+			}
+		}
+		return time.Time{}
+	}
+
+	metadata := types.DocumentMetadata{
+		Title:        text("title"),
+		Subject:      text("subject"),
+		Author:       text("initial-creator"),
+		LastSavedBy:  text("creator"),
+		Version:      od.meta.attr("version"),
+		Created:      date("creation-date"),
+		Modified:     date("date"),
+		FileSize:     fileSize,
+		DocumentKind: types.KindDocument,
+		HasMacros:    od.hasMacros,
+	}
+	if od.mimeType == packaging.MimeTypeTextTemplate {
+		metadata.DocumentKind = types.KindTemplate
+	}
+	if metadata.Author == "" {
+		metadata.Author = metadata.LastSavedBy
+	}
+	for _, c := range meta.elements() {
+		if keyword := strings.TrimSpace(c.textContent()); c.name == "keyword" && keyword != "" {
+			metadata.Keywords = append(metadata.Keywords, keyword)
+		}
+	}
+	metadata.Revision, _ = strconv.Atoi(text("editing-cycles"))
+	statistics := meta.child("document-statistic")
+	metadata.PageCount, _ = strconv.Atoi(statistics.attr("page-count"))
+	metadata.WordCount, _ = strconv.Atoi(statistics.attr("word-count"))
+	return metadata
+}
+
+// body 读取office:text中的段落、表格和节，以及节使用的主控页中的页眉页脚
+//
+// ID与DOCX解析结果一致：正文段落为paragraph_i，表格单元格中的段落不计入正文段落。
+func (od *odtDocument) body() types.DocumentContent {
+	var content types.DocumentContent
+	od.auto = od.contentAuto
+	masters := []string{od.firstMaster}
+	started := false
+	text := od.content.child("body").child("text")
+	od.eachBlock(text, nil, func(block *odfNode, item *odfListItem) {
+		// 段落或表格样式指定主控页时开始新的节
+		family := "paragraph"
+		if block.name == "table" {
+			family = "table"
+		}
+		master := ""
+		for _, style := range od.styleChain(family, block.attr("style-name")) {
+			if style.masterPage != "" {
+				master = style.masterPage
+			}
+		}
+		newPage := false
+		if master != "" {
+			if started {
+				masters = append(masters, master)
+				newPage = true
+			} else {
+				masters[0] = master
+			}
+		}
+		started = true
+
+		switch block.name {
+		case "p", "h":
+			i := len(content.Paragraphs) + 1
+			paragraph := od.convertParagraph(block, item, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+				return fmt.Sprintf("run_%d_%d", i, j+1)
+			})
+			paragraph.PageBreak = paragraph.PageBreak || newPage
+			content.Paragraphs = append(content.Paragraphs, paragraph)
+		case "table":
+			content.Tables = append(content.Tables, od.convertTable(block, len(content.Tables)+1))
+		}
+	})
+
+	for i, master := range masters {
+		content.Sections = append(content.Sections, od.section(master, fmt.Sprintf("section_%d", i+1)))
+	}
+	content.Headers, content.Footers = od.headersFooters(masters)
+	content.Bookmarks = od.bookmarks
+	content.Images = od.images
+	return content
+}
+
+// convertParagraph 将text:p或text:h转换为types.Paragraph，runID返回第j个文本运行的ID
+func (od *odtDocument) convertParagraph(p *odfNode, item *odfListItem, id string, runID func(j int) string) types.Paragraph {
+	styleName := p.attr("style-name")
+	props, chars := od.styleProps("paragraph", styleName)
+	chain := od.styleChain("paragraph", styleName)
+	paragraph := types.Paragraph{
+		ID:          id,
+		Alignment:   props.alignment,
+		Indentation: props.indentation,
+		Spacing:     props.spacing,
+		PageBreak:   props.pageBreak,
+		KeepLines:   props.keepLines,
+		KeepNext:    props.keepNext,
+	}
+	// 与DOCX一致，默认段落样式不记录样式名称
+	if style := commonStyle(chain); style != nil && style.name != odfDefaultStyle {
+		paragraph.Style.Name = style.name
+	}
+	if p.name == "h" {
+		paragraph.OutlineLevel = 1
+		for _, style := range chain {
+			if style.outline > 0 {
+				paragraph.OutlineLevel = style.outline
+			}
+		}
+		if level, err := strconv.Atoi(p.attr("outline-level")); err == nil && level > 0 {
+			paragraph.OutlineLevel = level
+		}
+	}
+	if item != nil {
+		list, indent := od.listLabel(item)
+		paragraph.List = list
+		// 列表级别的缩进覆盖段落样式的缩进，自动样式中直接设置的缩进仍然优先
+		if indent != nil {
+			paragraph.Indentation = *indent
+			for _, style := range chain {
+				if style.automatic {
+					direct := odfParaProps{indentation: paragraph.Indentation}
+					direct.apply(style.node.child("paragraph-properties"))
+					paragraph.Indentation = direct.indentation
+				}
+			}
+		}
+	}
+
+	// 字符属性相同的相邻文本合并为一个文本运行
+	var segments []strings.Builder
+	var segmentChars []odfCharProps
+	od.inline(p, chars, func(text string, chars odfCharProps) {
+		if n := len(segmentChars); n == 0 || segmentChars[n-1] != chars {
+			segments = append(segments, strings.Builder{})
+			segmentChars = append(segmentChars, chars)
+		}
+		segments[len(segments)-1].WriteString(text)
+	})
+	var text strings.Builder
+	for j := range segments {
+		run := convertODFRun(segments[j].String(), segmentChars[j])
+		run.ID = runID(j)
+		paragraph.Runs = append(paragraph.Runs, run)
+		text.WriteString(run.Text)
+	}
+	paragraph.Text = text.String()
+	return paragraph
+}
+
+// inline 按顺序输出段落中的文本及其字符属性，进入text:span、超链接和域，跳过脚注、批注和修订标记
+func (od *odtDocument) inline(node *odfNode, chars odfCharProps, emit func(text string, chars odfCharProps)) {
+	for _, c := range node.elements() {
+		switch c.name {
+		case "":
+			// 与XML空白处理规则一致，段落中连续的空白折叠为一个空格，text:s表示额外的空格
+			if text := collapseODFSpace(c.text); text != "" {
+				emit(text, chars)
+			}
+		case "span", "a":
+			spanChars := chars
+			for _, style := range od.styleChain("text", c.attr("style-name")) {
+				od.applyText(&spanChars, style.node.child("text-properties"))
+			}
+			od.inline(c, spanChars, emit)
+		case "s":
+			count, err := strconv.Atoi(c.attr("c"))
+			if err != nil || count < 1 {
+				count = 1
+			}
+			emit(strings.Repeat(" ", count), chars)
+		case "tab":
+			emit("\t", chars)
+		case "line-break":
+			emit("\n", chars)
+		case "bookmark", "bookmark-start":
+			od.bookmarks = append(od.bookmarks, types.Bookmark{ID: strconv.Itoa(len(od.bookmarks)), Name: c.attr("name")})
+		case "frame":
+			od.appendImage(c)
+		case "note", "annotation", "annotation-end", "change", "change-start", "change-end",
+			"bookmark-end", "soft-page-break", "ruby-text", "reference-mark", "reference-mark-start", "reference-mark-end":
+		default:
+			// 域（页码、日期、交叉引用等）输出其中的文本
+			od.inline(c, chars, emit)
+		}
+	}
+}
+
+// collapseODFSpace 将连续的空白字符折叠为一个空格
+func collapseODFSpace(text string) string {
+	var collapsed strings.Builder
+	space := false
+	for _, r := range text {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			if !space {
+				collapsed.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		collapsed.WriteRune(r)
+		space = false
+	}
+	return collapsed.String()
+}
+
+// convertODFRun 返回字符属性对应的文本运行
+func convertODFRun(text string, chars odfCharProps) types.TextRun {
+	font := convertODFFont(chars, text)
+	return types.TextRun{
+		Text:      text,
+		Font:      font,
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Color:     font.Color,
+		Size:      font.Size,
+		Position:  chars.position,
+	}
+}
+
+// convertODFFont 返回字符属性对应的字体，含东亚文字时取东亚字体
+func convertODFFont(chars odfCharProps, text string) types.Font {
+	name := chars.font
+	if chars.eastAsia != "" && (name == "" || hasEastAsian(text)) {
+		name = chars.eastAsia
+	}
+	return types.Font{
+		Name:      name,
+		Size:      chars.size,
+		Color:     types.Color{RGB: chars.color},
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+	}
+}
+
+// appendImage 添加draw:frame中的图片，路径为包内的部件名
+func (od *odtDocument) appendImage(frame *odfNode) {
+	image := types.Image{ID: fmt.Sprintf("image_%d", len(od.images)+1)}
+	image.Width, _ = frame.length("width")
+	image.Height, _ = frame.length("height")
+	image.Path = frame.child("image").attr("href")
+	image.AltText = strings.TrimSpace(frame.child("title").textContent())
+	if image.AltText == "" {
+		image.AltText = strings.TrimSpace(frame.child("desc").textContent())
+	}
+	od.images = append(od.images, image)
+}
+
+// convertTable 将table:table转换为表格，i为表格从1开始的序号
+func (od *odtDocument) convertTable(tbl *odfNode, i int) types.Table {
+	table := types.Table{ID: fmt.Sprintf("table_%d", i)}
+	chain := od.styleChain("table", tbl.attr("style-name"))
+	if style := commonStyle(chain); style != nil {
+		table.Style = types.TableStyle{ID: style.name, Name: style.displayName}
+	}
+	for _, style := range chain {
+		props := style.node.child("table-properties")
+		if width, ok := props.length("width"); ok {
+			table.Width = width
+		}
+		switch props.attr("align") {
+		case "center":
+			table.Alignment = types.AlignCenter
+		case "right":
+			table.Alignment = types.AlignRight
+		case "left", "margins":
+			table.Alignment = types.AlignLeft
+		}
+	}
+
+	// 列宽按网格列展开，合并单元格的宽度为所跨列宽之和
+	var columns []float64
+	var rows []struct {
+		node   *odfNode
+		header bool
+	}
+	var collect func(node *odfNode, header bool)
+	collect = func(node *odfNode, header bool) {
+		for _, c := range node.elements() {
+			switch c.name {
+			case "table-column":
+				width := 0.0
+				for _, style := range od.styleChain("table-column", c.attr("style-name")) {
+					if v, ok := style.node.child("table-column-properties").length("column-width"); ok {
+						width = v
+					}
+				}
+				repeat, err := strconv.Atoi(c.attr("number-columns-repeated"))
+				if err != nil || repeat < 1 {
+					repeat = 1
+				}
+				for n := 0; n < min(repeat, 64); n++ {
+					columns = append(columns, width)
+				}
+			case "table-row":
+				rows = append(rows, struct {
+					node   *odfNode
+					header bool
+				}{c, header})
+			case "table-header-rows":
+				collect(c, true)
+			case "table-columns", "table-header-columns", "table-column-group", "table-rows", "table-row-group":
+				collect(c, header)
+			}
+		}
+	}
+	collect(tbl, false)
+
+	for _, tr := range rows {
+		j := len(table.Rows) + 1
+		row := types.TableRow{ID: fmt.Sprintf("row_%d_%d", i, j), Header: tr.header, Repeat: tr.header}
+		for _, style := range od.styleChain("table-row", tr.node.attr("style-name")) {
+			props := style.node.child("table-row-properties")
+			if v, ok := props.length("row-height"); ok {
+				row.Height = v
+			} else if v, ok := props.length("min-row-height"); ok {
+				row.Height = v
+			}
+		}
+
+		column := 0
+		for _, tc := range tr.node.children {
+			if tc.name == "covered-table-cell" {
+				column++
+				continue
+			}
+			if tc.name != "table-cell" {
+				continue
+			}
+			k := len(row.Cells) + 1
+			cell := types.TableCell{ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k)}
+			span := 1
+			if n, err := strconv.Atoi(tc.attr("number-columns-spanned")); err == nil && n > 1 {
+				span = n
+				cell.Merge.Horizontal = n
+			}
+			if n, err := strconv.Atoi(tc.attr("number-rows-spanned")); err == nil && n > 1 {
+				cell.Merge.Vertical = n
+			}
+			for c := column; c < column+span && c < len(columns); c++ {
+				cell.Width += columns[c]
+			}
+			for _, style := range od.styleChain("table-cell", tc.attr("style-name")) {
+				applyODFCell(&cell, style.node.child("table-cell-properties"))
+			}
+			od.cellParagraphs(tc, &cell, i, j, k)
+			row.Cells = append(row.Cells, cell)
+			column += span
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	if table.Width == 0 {
+		for _, width := range columns {
+			table.Width += width
+		}
+	}
+	return table
+}
+
+// applyODFCell 应用style:table-cell-properties中的垂直对齐、底纹和边框
+func applyODFCell(cell *types.TableCell, props *odfNode) {
+	if props == nil {
+		return
+	}
+	switch props.attr("vertical-align") {
+	case "top":
+		cell.VerticalAlignment = types.VAlignTop
+	case "middle":
+		cell.VerticalAlignment = types.VAlignCenter
+	case "bottom":
+		cell.VerticalAlignment = types.VAlignBottom
+	}
+	if fill := props.attr("background-color"); strings.HasPrefix(fill, "#") {
+		cell.Shading.Fill.RGB = strings.ToUpper(strings.TrimPrefix(fill, "#"))
+	}
+	if border := props.attr("border"); border != "" {
+		all := odfBorder(border)
+		cell.Borders = types.CellBorders{Top: all, Bottom: all, Left: all, Right: all}
+	}
+	for name, target := range map[string]*types.Border{
+		"border-top": &cell.Borders.Top, "border-bottom": &cell.Borders.Bottom,
+		"border-left": &cell.Borders.Left, "border-right": &cell.Borders.Right,
+	} {
+		if border := props.attr(name); border != "" {
+			*target = odfBorder(border)
+		}
+	}
+}
+
+// odfBorder 读取"0.5pt solid #000000"形式的边框：宽度、线型和颜色可以任意顺序出现
+func odfBorder(value string) types.Border {
+	var border types.Border
+	for _, part := range strings.Fields(value) {
+		switch {
+		case part == "none" || part == "hidden":
+			border.Style = types.BorderNone
+		case part == "solid":
+			border.Style = types.BorderSingle
+		case part == "double":
+			border.Style = types.BorderDouble
+		case part == "dotted":
+			border.Style = types.BorderDotted
+		case part == "dashed":
+			border.Style = types.BorderDashed
+		case strings.HasPrefix(part, "#"):
+			border.Color.RGB = strings.ToUpper(strings.TrimPrefix(part, "#"))
+		default:
+			if width, ok := cssLength(part); ok {
+				border.Width = width
+			}
+		}
+	}
+	return border
+}
+
+// cellParagraphs 读取单元格中的段落和列表，嵌套表格中的段落按顺序并入单元格
+func (od *odtDocument) cellParagraphs(node *odfNode, cell *types.TableCell, i, j, k int) {
+	od.eachBlock(node, nil, func(block *odfNode, item *odfListItem) {
+		if block.name == "table" {
+			for _, tc := range odfTableCells(block) {
+				od.cellParagraphs(tc, cell, i, j, k)
+			}
+			return
+		}
+		paragraph := od.convertParagraph(block, item, fmt.Sprintf("cell_para_%d_%d_%d", i, j, k), func(int) string {
+			return fmt.Sprintf("cell_run_%d_%d_%d", i, j, k)
+		})
+		cell.Content = append(cell.Content, paragraph)
+	})
+}
+
+// odfTableCells 按顺序返回表格中的所有单元格，包括表头行和行组中的
+func odfTableCells(node *odfNode) []*odfNode {
+	var cells []*odfNode
+	for _, c := range node.elements() {
+		switch c.name {
+		case "table-cell":
+			cells = append(cells, c)
+		case "table-row", "table-header-rows", "table-rows", "table-row-group":
+			cells = append(cells, odfTableCells(c)...)
+		}
+	}
+	return cells
+}
+
+// odfPageFormats 页码格式
+var odfPageFormats = map[string]string{
+	"1": "decimal", "a": "lowerLetter", "A": "upperLetter", "i": "lowerRoman", "I": "upperRoman",
+}
+
+// section 按主控页使用的页面布局生成节
+//
+// ODF的上边距是页面边缘到页眉的距离，正文上边距为其加上页眉高度和间距，与DOCX的页眉距离和上边距对应；页脚相同。
+func (od *odtDocument) section(masterName, id string) types.Section {
+	section := defaultDocSection(id)
+	master := od.masterPages[masterName]
+	layout := od.pageLayouts[master.attr("page-layout-name")]
+	props := layout.child("page-layout-properties")
+	if props == nil {
+		return section
+	}
+
+	if v, ok := props.length("page-width"); ok {
+		section.PageSize.Width = v
+	}
+	if v, ok := props.length("page-height"); ok {
+		section.PageSize.Height = v
+	}
+	for name, target := range map[string]*float64{
+		"margin-top": &section.PageMargins.Top, "margin-bottom": &section.PageMargins.Bottom,
+		"margin-left": &section.PageMargins.Left, "margin-right": &section.PageMargins.Right,
+	} {
+		if v, ok := props.length(name); ok {
+			*target = v
+		}
+	}
+	section.PageMargins.Header, section.PageMargins.Footer = section.PageMargins.Top, section.PageMargins.Bottom
+	if header := layout.child("header-style").child("header-footer-properties"); master.child("header") != nil && header != nil {
+		height, _ := header.length("min-height")
+		spacing, _ := header.length("margin-bottom")
+		section.PageMargins.Top += height + spacing
+	}
+	if footer := layout.child("footer-style").child("header-footer-properties"); master.child("footer") != nil && footer != nil {
+		height, _ := footer.length("min-height")
+		spacing, _ := footer.length("margin-top")
+		section.PageMargins.Bottom += height + spacing
+	}
+	section.HeaderDistance = section.PageMargins.Header
+	section.FooterDistance = section.PageMargins.Footer
+
+	if columns := props.child("columns"); columns != nil {
+		if n, err := strconv.Atoi(columns.attr("column-count")); err == nil && n > 0 {
+			section.Columns.Count = n
+		}
+		if v, ok := columns.length("column-gap"); ok {
+			section.Columns.Spacing = v
+		}
+	}
+	if format, ok := odfPageFormats[props.attr("num-format")]; ok {
+		section.PageNumbering.Format = format
+	}
+	if start, err := strconv.Atoi(props.attr("first-page-number")); err == nil {
+		section.PageNumbering.Start = start
+		section.PageNumbering.Restart = true
+	}
+	if od.lineNumbers.attr("number-lines") == "true" {
+		section.LineNumbering.Increment, _ = strconv.Atoi(od.lineNumbers.attr("increment"))
+		section.LineNumbering.Start = 1
+		section.LineNumbering.Restart = od.lineNumbers.attr("restart-on-page") == "true"
+	}
+	return section
+}
+
+// headersFooters 读取各节主控页中非空的页眉和页脚，多个节使用同一主控页时只读取一次
+func (od *odtDocument) headersFooters(masters []string) ([]types.Header, []types.Footer) {
+	var headers []types.Header
+	var footers []types.Footer
+	od.auto = od.stylesAuto
+	defer func() { od.auto = od.contentAuto }()
+
+	seen := make(map[string]bool)
+	for _, name := range masters {
+		master := od.masterPages[name]
+		if master == nil || seen[name] {
+			continue
+		}
+		seen[name] = true
+		for _, story := range master.children {
+			if (story.name != "header" && story.name != "footer") || story.attr("display") == "false" {
+				continue
+			}
+			prefix, n := "footer", len(footers)+1
+			if story.name == "header" {
+				prefix, n = "header", len(headers)+1
+			}
+			var paragraphs []types.Paragraph
+			empty := true
+			od.eachBlock(story, nil, func(block *odfNode, item *odfListItem) {
+				if block.name == "table" {
+					return
+				}
+				k := len(paragraphs) + 1
+				paragraph := od.convertParagraph(block, item, fmt.Sprintf("%s_para_%d_%d", prefix, n, k), func(j int) string {
+					return fmt.Sprintf("%s_run_%d_%d_%d", prefix, n, k, j+1)
+				})
+				empty = empty && strings.TrimSpace(paragraph.Text) == ""
+				paragraphs = append(paragraphs, paragraph)
+			})
+			if empty {
+				continue
+			}
+			if story.name == "header" {
+				headers = append(headers, types.Header{ID: fmt.Sprintf("header_%d", n), Content: paragraphs})
+			} else {
+				footers = append(footers, types.Footer{ID: fmt.Sprintf("footer_%d", n), Content: paragraphs})
+			}
+		}
+	}
+	return headers, footers
+}
+
+// documentStyles 返回styles.xml中的常用段落、字符和表格样式，段落样式包含沿继承链合并后的格式
+func (od *odtDocument) documentStyles() types.DocumentStyles {
+	styles := types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+	}
+	od.auto = nil
+	defer func() { od.auto = od.contentAuto }()
+	for _, style := range od.commonOrder {
+		switch style.family {
+		case "paragraph":
+			para, chars := od.styleProps("paragraph", style.name)
+			styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{
+				ID:          style.name,
+				Name:        style.displayName,
+				Font:        convertODFFont(chars, style.displayName),
+				Alignment:   para.alignment,
+				Indentation: para.indentation,
+				Spacing:     para.spacing,
+			})
+		case "text":
+			styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: style.name, Name: style.displayName})
+		case "table":
+			styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: style.name, Name: style.displayName})
+		}
+	}
+	return styles
+}
+
+// formatRules 生成格式规则，样式规则来自常用样式和列表样式
+func (od *odtDocument) formatRules(doc *types.Document) types.FormatRules {
+	rules := contentFormatRules(doc, od.fonts)
+	kinds := map[string]string{"paragraph": "paragraph", "text": "character", "table": "table"}
+	for _, style := range od.commonOrder {
+		kind, ok := kinds[style.family]
+		if !ok {
+			continue
+		}
+		rules.StyleRules = append(rules.StyleRules, types.StyleRule{
+			ID:      style.name,
+			Name:    style.displayName,
+			Type:    kind,
+			BasedOn: style.parent,
+			Next:    style.next,
+		})
+	}
+	for _, list := range od.listOrder {
+		rules.StyleRules = append(rules.StyleRules, types.StyleRule{ID: list.name, Name: list.name, Type: "numbering"})
+	}
+	return rules
+}
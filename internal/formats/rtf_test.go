@@ -0,0 +1,291 @@
+package formats
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+)
+
+// testRTF 测试用的RTF文档，正文使用GBK编码的宋体，页面设置为A4
+const testRTF = `{\rtf1\ansi\ansicpg936\deff0` +
+	`{\fonttbl{\f0\froman\fcharset0 Times New Roman;}{\f1\fnil\fcharset134 \'cb\'ce\'cc\'e5;}}` +
+	`{\colortbl;\red255\green0\blue0;\red255\green255\blue0;}` +
+	`{\stylesheet{\s0 Normal;}{\s1\sbasedon0\qc\b\fs32 heading 1;}{\*\cs10 Default Paragraph Font;}}` +
+	`{\*\generator Test;}` +
+	`{\info{\title \'b2\'e2\'ca\'d4}{\author Alice}{\operator Bob}{\keywords a,b}` +
+	`{\creatim\yr2024\mo3\dy5\hr10\min20}{\version3}{\nofpages2}}` +
+	`\paperw11906\paperh16838\margl1800\margr1800\margt1440\margb1440` + "\r\n" +
+	`\sectd{\header\pard\plain\f1 \'d2\'b3\'c3\'bc\par}` +
+	`\pard\plain\s1\qc\keepn\outlinelevel0\b\fs32 Title\par` +
+	`\pard\plain\f1\fs24\sa120 \'d6\'d0\'ce\'c4{\b bold}\uc1\u8364?{\cf1\highlight2 red}\tab x\par` +
+	`\pard\plain\fi-360\li720 {\*\bkmkstart mark}{\field{\*\fldinst PAGE}{\fldrslt 1}}` +
+	`{\*\unknown skipped}\emdash\par` +
+	`\trowd\trhdr\cellx2880\cellx5760\pard\intbl A1\cell B1\cell\row` +
+	`\pard\plain after{\pict\pngblip\picw10\pich10\picwgoal1440\pichgoal720\picscalex50 89504e47}\par` +
+	`\sect\sectd\pgwsxn16838\pghsxn11906\cols2\pgnrestart\pgnucrm\pard last\par}`
+
+// TestRtfParser_Document 测试解析RTF的编码、格式、表格、页眉、域、图片和节
+func TestRtfParser_Document(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.rtf")
+	if err := os.WriteFile(path, []byte(testRTF), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	doc, err := NewRtfParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析.rtf文档失败: %v", err)
+	}
+
+	metadata := doc.Metadata
+	if metadata.Title != "测试" || metadata.Author != "Alice" || metadata.LastSavedBy != "Bob" ||
+		metadata.Revision != 3 || metadata.PageCount != 2 || len(metadata.Keywords) != 2 ||
+		metadata.Created.Year() != 2024 || metadata.Created.Minute() != 20 {
+		t.Errorf("文档属性不正确: %+v", metadata)
+	}
+
+	wantTexts := []string{"Title", "中文bold€red\tx", "1—", "after", "last"}
+	paragraphs := doc.Content.Paragraphs
+	if len(paragraphs) != len(wantTexts) {
+		t.Fatalf("正文段落应为 %q，实际 %+v", wantTexts, paragraphs)
+	}
+	for i, want := range wantTexts {
+		if paragraphs[i].Text != want {
+			t.Errorf("第%d段文本应为 %q，实际 %q", i+1, want, paragraphs[i].Text)
+		}
+	}
+
+	heading := paragraphs[0]
+	if heading.ID != "paragraph_1" || heading.Style.Name != "Heading1" || heading.Alignment != types.AlignCenter ||
+		!heading.KeepNext || heading.OutlineLevel != 1 || !heading.Runs[0].Bold || heading.Runs[0].Size != 16 {
+		t.Errorf("标题段落的格式不正确: %+v", heading)
+	}
+
+	runs := paragraphs[1].Runs
+	if len(runs) != 5 {
+		t.Fatalf("第2段应分为5个文本运行，实际 %+v", runs)
+	}
+	if runs[0].Text != "中文" || runs[0].Font.Name != "宋体" || runs[0].ID != "run_2_1" || paragraphs[1].Spacing.After != 6 {
+		t.Errorf("中文文本运行不正确: %+v", runs[0])
+	}
+	if !runs[1].Bold || runs[2].Text != "€" {
+		t.Errorf("粗体或Unicode文本运行不正确: %+v", runs)
+	}
+	if runs[3].Color.RGB != "FF0000" || runs[3].Highlight != "yellow" {
+		t.Errorf("颜色文本运行不正确: %+v", runs[3])
+	}
+	if indentation := paragraphs[2].Indentation; indentation.Left != 36 || indentation.Hanging != 18 {
+		t.Errorf("悬挂缩进不正确: %+v", indentation)
+	}
+
+	if len(doc.Content.Tables) != 1 {
+		t.Fatalf("应有1个表格，实际 %d", len(doc.Content.Tables))
+	}
+	table := doc.Content.Tables[0]
+	if len(table.Rows) != 1 || len(table.Rows[0].Cells) != 2 || !table.Rows[0].Header || table.Width != 288 {
+		t.Fatalf("表格应为1行2列的标题行: %+v", table)
+	}
+	for k, want := range []string{"A1", "B1"} {
+		cell := table.Rows[0].Cells[k]
+		if len(cell.Content) != 1 || cell.Content[0].Text != want || cell.Width != 144 {
+			t.Errorf("单元格%d应为 %q、宽144磅，实际 %+v", k+1, want, cell)
+		}
+	}
+
+	if len(doc.Content.Headers) != 1 || doc.Content.Headers[0].Content[0].Text != "页眉" {
+		t.Errorf("页眉应为 \"页眉\"，实际 %+v", doc.Content.Headers)
+	}
+	if len(doc.Content.Bookmarks) != 1 || doc.Content.Bookmarks[0].Name != "mark" {
+		t.Errorf("书签不正确: %+v", doc.Content.Bookmarks)
+	}
+	if images := doc.Content.Images; len(images) != 1 || images[0].Width != 36 || images[0].Height != 36 {
+		t.Errorf("图片大小不正确: %+v", images)
+	}
+
+	sections := doc.Content.Sections
+	if len(sections) != 2 || sections[0].PageSize.Width != 595.3 || sections[0].PageMargins.Left != 90 ||
+		sections[1].PageSize.Width != 841.9 || sections[1].Columns.Count != 2 ||
+		!sections[1].PageNumbering.Restart || sections[1].PageNumbering.Format != "upperRoman" {
+		t.Errorf("节属性不正确: %+v", sections)
+	}
+
+	if len(doc.FormatRules.FontRules) != 2 || len(doc.FormatRules.PageRules) != 2 {
+		t.Errorf("字体或页面规则不正确: %+v %+v", doc.FormatRules.FontRules, doc.FormatRules.PageRules)
+	}
+	if styles := doc.Styles.ParagraphStyles; len(styles) != 2 || styles[1].ID != "Heading1" || !styles[1].Font.Bold {
+		t.Errorf("段落样式不正确: %+v", styles)
+	}
+}
+
+// TestRtfParser_ValidateFile 测试RTF文件头的检查
+func TestRtfParser_ValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    error
+	}{
+		{`{\rtf1 text}`, nil},
+		{"\xEF\xBB\xBF \r\n{\\rtf1\\ansi text}", nil},
+		{`plain text`, parser.ErrInvalidFile},
+		{``, parser.ErrInvalidFile},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, "test.rtf")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+		if err := NewRtfParser().ValidateFile(path); err != tt.want {
+			t.Errorf("第%d个文件的检查结果应为 %v，实际 %v", i+1, tt.want, err)
+		}
+	}
+}
+
+// TestWordParser_SniffedFormat 测试按内容选择解析器：扩展名为.doc的RTF文件按RTF解析并给出警告
+func TestWordParser_SniffedFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "saved-as.doc")
+	if err := os.WriteFile(path, []byte(`{\rtf1\ansi Hello}`), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	if err := NewRtfParser().ValidateFile(path); err != nil {
+		t.Errorf("内容有效的RTF文件不应因扩展名被拒绝，实际 %v", err)
+	}
+	if err := NewDocParser().ValidateFile(path); err != parser.ErrInvalidFile {
+		t.Errorf("DocParser检查RTF内容应返回ErrInvalidFile，实际 %v", err)
+	}
+
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(doc.Content.Paragraphs) != 1 || doc.Content.Paragraphs[0].Text != "Hello" {
+		t.Errorf("应按RTF解析出文本Hello，实际 %+v", doc.Content.Paragraphs)
+	}
+	if len(doc.Metadata.Warnings) != 1 || !strings.Contains(doc.Metadata.Warnings[0], "does not match detected format rtf") {
+		t.Errorf("应给出扩展名不符的警告，实际 %q", doc.Metadata.Warnings)
+	}
+
+	textPath := filepath.Join(dir, "notes.rtf")
+	if err := os.WriteFile(textPath, []byte("plain text"), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	if err := NewWordParser().ValidateFile(textPath); err != parser.ErrInvalidFile {
+		t.Errorf("无法识别内容时应按扩展名交给RtfParser检查，实际 %v", err)
+	}
+	htmlPath := filepath.Join(dir, "page.doc")
+	if err := os.WriteFile(htmlPath, []byte("<html><body>x</body></html>"), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	doc, err = NewWordParser().ParseDocument(htmlPath)
+	if err != nil || len(doc.Content.Paragraphs) != 1 || len(doc.Metadata.Warnings) != 1 {
+		t.Errorf("保存为doc的网页应按HTML解析并给出警告，实际 %v", err)
+	}
+	xmlPath := filepath.Join(dir, "feed.doc")
+	if err := os.WriteFile(xmlPath, []byte(`<?xml version="1.0"?><rss version="2.0"/>`), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	if _, err := NewWordParser().ParseDocument(xmlPath); err == nil || !strings.Contains(err.Error(), "unsupported file format: xml") {
+		t.Errorf("其他XML内容应返回不支持的格式，实际 %v", err)
+	}
+}
+
+// TestWordParserCachedWarning 测试扩展名不符的警告不写入缓存，内容相同的文件不共享警告
+func TestWordParserCachedWarning(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(`{\rtf1\ansi Hello}`)
+	misnamed := filepath.Join(dir, "saved-as.doc")
+	named := filepath.Join(dir, "hello.rtf")
+	for _, path := range []string{misnamed, named} {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	wordParser := NewWordParser()
+	wordParser.SetCache(cache.NewParseCache(10, ""))
+	for i := 0; i < 2; i++ {
+		doc, err := wordParser.ParseDocument(misnamed)
+		if err != nil || len(doc.Metadata.Warnings) != 1 {
+			t.Errorf("第%d次解析应只有一条警告，实际 %q %v", i+1, doc.Metadata.Warnings, err)
+		}
+	}
+	doc, err := wordParser.ParseDocumentContext(context.Background(), named)
+	if err != nil || len(doc.Metadata.Warnings) != 0 {
+		t.Errorf("扩展名相符的文件不应带有缓存中的警告，实际 %q %v", doc.Metadata.Warnings, err)
+	}
+	if stats := wordParser.Cache().Stats(); stats.Hits != 2 {
+		t.Errorf("内容相同的文件应命中缓存，实际 %+v", stats)
+	}
+}
+
+// TestWriteRTF 测试写出的RTF可以重新读取，批注写为RTF批注
+func TestWriteRTF(t *testing.T) {
+	source := parseRTF([]byte(testRTF), int64(len(testRTF)))
+	date := time.Date(2024, 5, 6, 7, 8, 0, 0, time.UTC)
+	source.Content.Comments = []types.Comment{
+		{ID: "0", Author: "Docs Parser", Initials: "DP", Date: date, Text: "问题: 字体不正确\n建议: 使用黑体", Anchor: types.CommentAnchor{Paragraph: 2, EndParagraph: 2}},
+		{ID: "1", Author: "Docs Parser", Text: "未锚定"},
+	}
+
+	var buf bytes.Buffer
+	warnings, err := WriteRTF(&buf, source)
+	if err != nil {
+		t.Fatalf("写出RTF失败: %v", err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "1 image(s) omitted") || !strings.Contains(warnings[1], "1 table(s) moved") {
+		t.Errorf("应报告丢失的图片和移动的表格: %v", warnings)
+	}
+	doc := parseRTF(buf.Bytes(), int64(buf.Len()))
+
+	if len(doc.Content.Paragraphs) != len(source.Content.Paragraphs) {
+		t.Fatalf("重新读取的段落数应为 %d，实际 %d\n%s", len(source.Content.Paragraphs), len(doc.Content.Paragraphs), buf.String())
+	}
+	for i, paragraph := range doc.Content.Paragraphs {
+		want := source.Content.Paragraphs[i]
+		if paragraph.Text != want.Text || paragraph.Style.Name != want.Style.Name || paragraph.Alignment != want.Alignment ||
+			paragraph.Indentation != want.Indentation || paragraph.Spacing != want.Spacing || len(paragraph.Runs) != len(want.Runs) {
+			t.Errorf("第%d段应为 %+v，实际 %+v", i+1, want, paragraph)
+			continue
+		}
+		for j, run := range paragraph.Runs {
+			if run.Font != want.Runs[j].Font || run.Position != want.Runs[j].Position {
+				t.Errorf("第%d段第%d个文本运行的格式应为 %+v，实际 %+v", i+1, j+1, want.Runs[j].Font, run.Font)
+			}
+		}
+	}
+
+	if metadata := doc.Metadata; metadata.Title != "测试" || metadata.Author != "Alice" || !metadata.Created.Equal(source.Metadata.Created) {
+		t.Errorf("文档属性不正确: %+v", metadata)
+	}
+	if len(doc.Content.Tables) != 1 || doc.Content.Tables[0].Width != 288 || doc.Content.Tables[0].Rows[0].Cells[1].Content[0].Text != "B1" {
+		t.Errorf("表格不正确: %+v", doc.Content.Tables)
+	}
+	if len(doc.Content.Headers) != 1 || doc.Content.Headers[0].Content[0].Text != "页眉" {
+		t.Errorf("页眉不正确: %+v", doc.Content.Headers)
+	}
+	if len(doc.Content.Images) != 0 || strings.Contains(buf.String(), `\pict`) {
+		t.Errorf("没有图片数据时不应写出空的\\pict: %+v", doc.Content.Images)
+	}
+	if sections := doc.Content.Sections; len(sections) != 2 || sections[0] != source.Content.Sections[0] || sections[1] != source.Content.Sections[1] {
+		t.Errorf("节属性应为 %+v，实际 %+v", source.Content.Sections, sections)
+	}
+
+	comments := doc.Content.Comments
+	if len(comments) != 2 {
+		t.Fatalf("应有2个批注，实际 %+v", comments)
+	}
+	if comments[0].Author != "Docs Parser" || comments[0].Initials != "DP" || comments[0].Text != source.Content.Comments[0].Text ||
+		comments[0].Anchor.Paragraph != 2 || !comments[0].Date.Equal(date) {
+		t.Errorf("批注不正确: %+v", comments[0])
+	}
+	if comments[1].Text != "未锚定" || comments[1].Anchor.Paragraph != len(doc.Content.Paragraphs) {
+		t.Errorf("未锚定的批注应写在最后一段: %+v", comments[1])
+	}
+}
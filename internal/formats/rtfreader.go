@@ -0,0 +1,1262 @@
+package formats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"docs-parser/internal/core/types"
+)
+
+// RTF的词法分析和按组状态的解释
+//
+// 词法分析把RTF拆分为组开始、组结束、控制字、控制符号、\'hh字节和文本；解释器为每个组保存一份状态
+// （目标、字符格式、段落格式和\uc回退字符数），组结束时恢复外层状态。文本字节按当前字体的字符集
+// （或\ansicpg）解码，\uN之后跳过\ucN个回退字符。以\*开头且不认识的目标整组跳过。
+
+// rtfTokenKind RTF记号类型
+type rtfTokenKind int
+
+const (
+	rtfTokenText       rtfTokenKind = iota // 普通文本字节
+	rtfTokenGroupStart                     // {
+	rtfTokenGroupEnd                       // }
+	rtfTokenControl                        // 控制字，如\par、\fs24
+	rtfTokenSymbol                         // 控制符号，如\~、\*
+	rtfTokenByte                           // \'hh表示的一个字节
+	rtfTokenBinary                         // \binN之后的N字节二进制数据
+)
+
+// rtfToken RTF记号
+type rtfToken struct {
+	kind     rtfTokenKind
+	word     string // 控制字名称或控制符号
+	param    int
+	hasParam bool
+	data     []byte // 文本、\'hh字节或二进制数据
+}
+
+// rtfLexer RTF词法分析器
+type rtfLexer struct {
+	data []byte
+	pos  int
+}
+
+// next 返回下一个记号，输入结束时返回false
+func (l *rtfLexer) next() (rtfToken, bool) {
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case '{':
+			l.pos++
+			return rtfToken{kind: rtfTokenGroupStart}, true
+		case '}':
+			l.pos++
+			return rtfToken{kind: rtfTokenGroupEnd}, true
+		case '\\':
+			return l.control(), true
+		case '\r', '\n':
+			// 换行只用于排版RTF源文件，不是文本
+			l.pos++
+			continue
+		}
+
+		start := l.pos
+		for l.pos < len(l.data) && !isRTFSpecial(l.data[l.pos]) {
+			l.pos++
+		}
+		return rtfToken{kind: rtfTokenText, data: l.data[start:l.pos]}, true
+	}
+	return rtfToken{}, false
+}
+
+// isRTFSpecial 判断字节是否结束一段普通文本
+func isRTFSpecial(c byte) bool {
+	return c == '{' || c == '}' || c == '\\' || c == '\r' || c == '\n'
+}
+
+// isRTFLetter 判断字节是否为控制字中的字母
+func isRTFLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// control 读取反斜杠开始的控制字或控制符号
+func (l *rtfLexer) control() rtfToken {
+	l.pos++
+	if l.pos >= len(l.data) {
+		return rtfToken{kind: rtfTokenSymbol}
+	}
+
+	c := l.data[l.pos]
+	if !isRTFLetter(c) {
+		l.pos++
+		switch c {
+		case '\'':
+			if l.pos+2 <= len(l.data) {
+				if value, err := strconv.ParseUint(string(l.data[l.pos:l.pos+2]), 16, 8); err == nil {
+					l.pos += 2
+					return rtfToken{kind: rtfTokenByte, data: []byte{byte(value)}}
+				}
+			}
+		case '\\', '{', '}':
+			return rtfToken{kind: rtfTokenText, data: []byte{c}}
+		case '\r', '\n':
+			// 反斜杠加换行等同于\par
+			return rtfToken{kind: rtfTokenControl, word: "par"}
+		}
+		return rtfToken{kind: rtfTokenSymbol, word: string(c)}
+	}
+
+	// 控制字最长32个字母，参数为可带负号的整数，之后的一个空格属于控制字
+	start := l.pos
+	for l.pos < len(l.data) && isRTFLetter(l.data[l.pos]) && l.pos-start < 32 {
+		l.pos++
+	}
+	token := rtfToken{kind: rtfTokenControl, word: string(l.data[start:l.pos])}
+
+	numberStart := l.pos
+	if l.pos < len(l.data) && l.data[l.pos] == '-' {
+		l.pos++
+	}
+	digitStart := l.pos
+	for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' && l.pos-digitStart < 10 {
+		l.pos++
+	}
+	if l.pos > digitStart {
+		token.param, _ = strconv.Atoi(string(l.data[numberStart:l.pos]))
+		token.hasParam = true
+	} else {
+		l.pos = numberStart
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == ' ' {
+		l.pos++
+	}
+
+	if token.word == "bin" && token.param > 0 {
+		size := min(token.param, len(l.data)-l.pos)
+		token.kind, token.data = rtfTokenBinary, l.data[l.pos:l.pos+size]
+		l.pos += size
+	}
+	return token
+}
+
+// rtfDest 组的目标，决定组中文本的去向
+type rtfDest int
+
+const (
+	rtfDestText       rtfDest = iota // 正文、页眉页脚、域结果等可见文本
+	rtfDestSkip                      // 忽略的目标
+	rtfDestFontTable                 // \fonttbl
+	rtfDestColorTable                // \colortbl
+	rtfDestStyleSheet                // \stylesheet
+	rtfDestStyle                     // 样式表中的一个样式
+	rtfDestInfo                      // \info
+	rtfDestInfoField                 // 文本属性，如\info中的\title和批注的\atnauthor
+	rtfDestInfoTime                  // \info中的时间，如\creatim
+	rtfDestBookmark                  // \bkmkstart
+	rtfDestPicture                   // \pict
+)
+
+// rtfSkipDestinations 不以\*开头但不属于正文的目标
+var rtfSkipDestinations = map[string]bool{
+	"listtext": true, "pntext": true, "revtbl": true, "footnote": true, "fldinst": true,
+	"xe": true, "tc": true, "txe": true, "rxe": true, "private": true, "nonshppict": true,
+	"filetbl": true, "listtable": true, "listoverridetable": true, "themedata": true,
+	"colorschememapping": true, "latentstyles": true, "datastore": true, "xmlnstbl": true,
+}
+
+// rtfInfoFields \info中的文本属性
+var rtfInfoFields = map[string]bool{
+	"title": true, "subject": true, "author": true, "keywords": true, "operator": true,
+	"company": true, "category": true, "manager": true, "doccomm": true, "comment": true,
+}
+
+// rtfAnnotationFields 批注的文本属性
+var rtfAnnotationFields = map[string]bool{
+	"atnid": true, "atnauthor": true, "atnref": true, "atndate": true,
+}
+
+// rtfCharState 字符格式
+type rtfCharState struct {
+	font       int // 字体序号，-1表示默认字体
+	halfPoints int
+	bold       bool
+	italic     bool
+	hidden     bool
+	underline  types.Underline
+	color      int // 颜色表序号，0表示自动
+	highlight  int
+	position   types.Position
+}
+
+// rtfParaState 段落格式
+type rtfParaState struct {
+	style       int
+	alignment   types.Alignment
+	indentation types.Indentation
+	spacing     types.Spacing
+	keepLines   bool
+	keepNext    bool
+	pageBreak   bool
+	outline     int // 大纲级别，从1开始，0表示正文
+	inTable     bool
+}
+
+// rtfGroup 一个组的状态，进入组时复制外层状态
+type rtfGroup struct {
+	dest       rtfDest
+	field      string // 文本属性的名称
+	chars      rtfCharState
+	para       rtfParaState
+	uc         int
+	story      *rtfStory
+	style      *rtfStyle
+	picture    *rtfPicture
+	buffer     *strings.Builder // 样式名称、属性和书签名称等文本的去向
+	ignorable  bool             // 组以\*开始，尚未读到目标控制字
+	firstToken bool
+}
+
+// rtfStory 正文或一个页眉页脚中的段落
+type rtfStory struct {
+	footer     bool
+	annotation bool
+	paragraphs []types.Paragraph
+	runs       []types.TextRun
+	runChars   []rtfCharState // 与runs对应的字符格式，用于合并格式相同的相邻文本
+	pageBreak  bool
+}
+
+// rtfFont 字体表中的字体
+type rtfFont struct {
+	name     string
+	codePage int
+	buffer   strings.Builder
+}
+
+// rtfStyle 样式表中的样式
+type rtfStyle struct {
+	index   int
+	kind    string // paragraph、character或table
+	name    string
+	basedOn int
+	chars   rtfCharState
+	para    rtfParaState
+}
+
+// rtfPicture \pict的显示大小
+type rtfPicture struct {
+	widthGoal, heightGoal int
+	width, height         int
+	scaleX, scaleY        int
+}
+
+// rtfReader RTF解释器
+type rtfReader struct {
+	lexer         rtfLexer
+	stack         []*rtfGroup
+	pending       []byte // 尚未解码的文本字节
+	skip          int    // \uN之后尚需跳过的回退字符数
+	highSurrogate rune
+
+	version      int
+	ansiCodePage int
+	defaultFont  int
+	fonts        map[int]*rtfFont
+	fontOrder    []int
+	currentFont  *rtfFont
+	colors       []string
+	color        [3]int
+	colorSet     bool
+	styles       map[int]*rtfStyle
+	styleOrder   []*rtfStyle
+
+	fields   map[string]string // \info中的文档属性和批注的文本属性
+	times    map[string]*[6]int
+	revision int
+	pages    int
+	words    int
+
+	body       *rtfStory
+	stories    []*rtfStory
+	tables     []types.Table
+	table      *types.Table
+	row        *types.TableRow
+	cell       *types.TableCell
+	cellBounds []int
+	rowHeight  int
+	rowHeader  bool
+	images     []types.Image
+	bookmarks  []types.Bookmark
+	comments   []types.Comment
+
+	docSection types.Section
+	section    *types.Section
+	sections   []types.Section
+}
+
+// parseRTF 解释RTF数据并生成与DOCX解析结果相同结构的文档
+func parseRTF(data []byte, fileSize int64) *types.Document {
+	r := &rtfReader{
+		lexer:        rtfLexer{data: data},
+		ansiCodePage: 1252,
+		fonts:        make(map[int]*rtfFont),
+		styles:       make(map[int]*rtfStyle),
+		fields:       make(map[string]string),
+		times:        make(map[string]*[6]int),
+		body:         &rtfStory{},
+		docSection:   defaultDocSection(""),
+	}
+	r.run()
+	return r.document(fileSize)
+}
+
+// defaultRTFChars 返回默认字符格式（12磅）
+func defaultRTFChars() rtfCharState {
+	return rtfCharState{font: -1, halfPoints: 24}
+}
+
+// defaultRTFPara 返回默认段落格式
+func defaultRTFPara() rtfParaState {
+	return rtfParaState{alignment: types.AlignLeft}
+}
+
+// run 依次处理所有记号，未闭合的组在输入结束时视为闭合
+func (r *rtfReader) run() {
+	r.stack = []*rtfGroup{{dest: rtfDestText, chars: defaultRTFChars(), para: defaultRTFPara(), uc: 1, story: r.body}}
+	for {
+		token, ok := r.lexer.next()
+		if !ok {
+			break
+		}
+		r.handle(token)
+	}
+	r.flush()
+	for len(r.stack) > 1 {
+		r.popGroup()
+	}
+
+	g := r.group()
+	if len(r.body.runs) > 0 {
+		r.endParagraph(g, false)
+	}
+	r.endTable()
+	r.endSection()
+}
+
+// group 返回当前组
+func (r *rtfReader) group() *rtfGroup {
+	return r.stack[len(r.stack)-1]
+}
+
+// handle 处理一个记号
+func (r *rtfReader) handle(token rtfToken) {
+	g := r.group()
+	switch token.kind {
+	case rtfTokenText:
+		data := token.data
+		if r.skip > 0 {
+			n := min(r.skip, len(data))
+			data, r.skip = data[n:], r.skip-n
+		}
+		r.pending = append(r.pending, data...)
+		g.firstToken = false
+		return
+	case rtfTokenByte:
+		if r.skip > 0 {
+			r.skip--
+			return
+		}
+		r.pending = append(r.pending, token.data...)
+		g.firstToken = false
+		return
+	}
+
+	r.flush()
+	switch token.kind {
+	case rtfTokenGroupStart:
+		r.skip = 0
+		child := *g
+		child.ignorable, child.firstToken = false, true
+		r.stack = append(r.stack, &child)
+		if g.dest == rtfDestStyleSheet {
+			child.dest = rtfDestStyle
+			child.style = &rtfStyle{kind: "paragraph", basedOn: -1}
+			child.chars, child.para = defaultRTFChars(), defaultRTFPara()
+			child.buffer = &strings.Builder{}
+		}
+	case rtfTokenGroupEnd:
+		r.skip = 0
+		if len(r.stack) > 1 {
+			r.popGroup()
+		}
+	case rtfTokenBinary:
+		if r.skip > 0 {
+			r.skip--
+		}
+	case rtfTokenSymbol:
+		if r.skip > 0 {
+			r.skip--
+			return
+		}
+		r.symbol(g, token.word)
+	case rtfTokenControl:
+		first := g.firstToken
+		g.firstToken = false
+		if r.skip > 0 {
+			r.skip--
+			return
+		}
+		if g.ignorable {
+			g.ignorable = false
+			if !r.destination(g, token) {
+				g.dest = rtfDestSkip
+			}
+			return
+		}
+		if first && r.destination(g, token) {
+			return
+		}
+		r.control(g, token)
+	}
+}
+
+// popGroup 结束当前组，完成组中定义的样式、图片和页眉页脚
+func (r *rtfReader) popGroup() {
+	g := r.group()
+	r.stack = r.stack[:len(r.stack)-1]
+	parent := r.group()
+
+	if g.style != nil && g.style != parent.style {
+		style := g.style
+		style.name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(g.buffer.String()), ";"))
+		style.chars, style.para = g.chars, g.para
+		if _, exists := r.styles[style.index]; !exists {
+			r.styleOrder = append(r.styleOrder, style)
+		}
+		r.styles[style.index] = style
+	}
+	if g.picture != nil && g.picture != parent.picture {
+		r.addPicture(g.picture)
+	}
+	if g.story != parent.story && len(g.story.runs) > 0 {
+		r.endParagraph(g, false)
+	}
+	if g.dest == rtfDestInfoField && parent.dest != rtfDestInfoField {
+		r.fields[g.field] = g.buffer.String()
+	}
+	if g.story != parent.story && g.story.annotation {
+		r.addComment(g.story, parent)
+	}
+	if g.dest == rtfDestBookmark && parent.dest != rtfDestBookmark {
+		name := strings.TrimSpace(g.buffer.String())
+		r.bookmarks = append(r.bookmarks, types.Bookmark{ID: strconv.Itoa(len(r.bookmarks)), Name: name})
+	}
+}
+
+// destination 处理组开始处的目标控制字，不是目标时返回false
+func (r *rtfReader) destination(g *rtfGroup, token rtfToken) bool {
+	word := token.word
+	switch {
+	case word == "fonttbl":
+		g.dest = rtfDestFontTable
+	case word == "colortbl":
+		g.dest = rtfDestColorTable
+	case word == "stylesheet":
+		g.dest = rtfDestStyleSheet
+	case word == "info":
+		g.dest = rtfDestInfo
+	case g.dest == rtfDestInfo && rtfInfoFields[word]:
+		g.dest, g.field, g.buffer = rtfDestInfoField, word, &strings.Builder{}
+	case g.dest == rtfDestInfo && (word == "creatim" || word == "revtim"):
+		g.dest, g.field = rtfDestInfoTime, word
+		r.times[word] = &[6]int{}
+	case word == "header" || word == "headerl" || word == "headerr" || word == "headerf" ||
+		word == "footer" || word == "footerl" || word == "footerr" || word == "footerf":
+		g.dest = rtfDestText
+		g.story = &rtfStory{footer: strings.HasPrefix(word, "footer")}
+		g.para = defaultRTFPara()
+		r.stories = append(r.stories, g.story)
+	case rtfAnnotationFields[word]:
+		g.dest, g.field, g.buffer = rtfDestInfoField, word, &strings.Builder{}
+	case word == "annotation":
+		g.dest = rtfDestText
+		g.story = &rtfStory{annotation: true}
+		g.para = defaultRTFPara()
+	case word == "pict":
+		g.dest = rtfDestPicture
+		g.picture = &rtfPicture{scaleX: 100, scaleY: 100}
+	case word == "bkmkstart":
+		g.dest, g.buffer = rtfDestBookmark, &strings.Builder{}
+	case word == "shppict" || word == "fldrslt" || word == "result":
+		// 图片的新格式、域结果和对象的显示结果属于正文
+	case g.dest == rtfDestStyle && (word == "cs" || word == "ts" || word == "ds"):
+		// 样式表中以\*开始的字符样式和表格样式
+		r.control(g, token)
+	case rtfSkipDestinations[word]:
+		g.dest = rtfDestSkip
+	default:
+		return false
+	}
+	return true
+}
+
+// symbol 处理控制符号
+func (r *rtfReader) symbol(g *rtfGroup, symbol string) {
+	switch symbol {
+	case "*":
+		g.ignorable = true
+	case "~":
+		r.text(g, " ")
+	case "_":
+		r.text(g, "-")
+	}
+}
+
+// control 处理控制字
+func (r *rtfReader) control(g *rtfGroup, token rtfToken) {
+	if g.dest == rtfDestSkip {
+		return
+	}
+	param := token.param
+	flag := !token.hasParam || param != 0
+	twips := float64(param) / 20.0
+
+	// Unicode字符可以出现在任何目标中，包括字体名称和文档属性
+	switch token.word {
+	case "uc":
+		g.uc = max(param, 0)
+		return
+	case "u":
+		r.unicode(g, param)
+		return
+	}
+
+	switch g.dest {
+	case rtfDestFontTable:
+		switch token.word {
+		case "f":
+			if _, exists := r.fonts[param]; !exists {
+				r.fontOrder = append(r.fontOrder, param)
+			}
+			r.currentFont = &rtfFont{codePage: r.ansiCodePage}
+			r.fonts[param] = r.currentFont
+		case "fcharset":
+			if r.currentFont != nil {
+				r.currentFont.codePage = rtfCharsetCodePage(param, r.ansiCodePage)
+			}
+		case "cpg":
+			if r.currentFont != nil {
+				r.currentFont.codePage = param
+			}
+		}
+		return
+	case rtfDestColorTable:
+		switch token.word {
+		case "red":
+			r.color[0], r.colorSet = param, true
+		case "green":
+			r.color[1], r.colorSet = param, true
+		case "blue":
+			r.color[2], r.colorSet = param, true
+		}
+		return
+	case rtfDestInfo, rtfDestInfoField:
+		switch token.word {
+		case "version":
+			r.revision = param
+		case "nofpages":
+			r.pages = param
+		case "nofwords":
+			r.words = param
+		}
+		return
+	case rtfDestInfoTime:
+		fields := map[string]int{"yr": 0, "mo": 1, "dy": 2, "hr": 3, "min": 4, "sec": 5}
+		if i, ok := fields[token.word]; ok {
+			r.times[g.field][i] = param
+		}
+		return
+	case rtfDestPicture:
+		picture := g.picture
+		switch token.word {
+		case "picwgoal":
+			picture.widthGoal = param
+		case "pichgoal":
+			picture.heightGoal = param
+		case "picw":
+			picture.width = param
+		case "pich":
+			picture.height = param
+		case "picscalex":
+			picture.scaleX = param
+		case "picscaley":
+			picture.scaleY = param
+		}
+		return
+	case rtfDestStyle:
+		switch token.word {
+		case "s":
+			g.style.index, g.style.kind = param, "paragraph"
+			return
+		case "cs":
+			g.style.index, g.style.kind = param, "character"
+			return
+		case "ts":
+			g.style.index, g.style.kind = param, "table"
+			return
+		case "sbasedon":
+			g.style.basedOn = param
+			return
+		}
+	}
+
+	// 文档属性
+	switch token.word {
+	case "rtf":
+		r.version = param
+	case "ansicpg":
+		r.ansiCodePage = param
+	case "deff":
+		r.defaultFont = param
+
+	// 字符格式
+	case "plain":
+		g.chars = defaultRTFChars()
+	case "f":
+		g.chars.font = param
+	case "fs":
+		g.chars.halfPoints = param
+	case "b":
+		g.chars.bold = flag
+	case "i":
+		g.chars.italic = flag
+	case "v":
+		g.chars.hidden = flag
+	case "ul":
+		g.chars.underline = ""
+		if flag {
+			g.chars.underline = types.UnderlineSingle
+		}
+	case "ulnone":
+		g.chars.underline = ""
+	case "uld":
+		g.chars.underline = types.UnderlineDotted
+	case "uldb":
+		g.chars.underline = types.UnderlineDouble
+	case "ulw":
+		g.chars.underline = "words"
+	case "ulth":
+		g.chars.underline = "thick"
+	case "uldash":
+		g.chars.underline = types.UnderlineDashed
+	case "ulwave":
+		g.chars.underline = "wave"
+	case "cf":
+		g.chars.color = param
+	case "highlight":
+		g.chars.highlight = param
+	case "super":
+		g.chars.position = types.PositionSuperscript
+	case "sub":
+		g.chars.position = types.PositionSubscript
+	case "nosupersub":
+		g.chars.position = ""
+
+	// 段落格式
+	case "pard":
+		g.para = defaultRTFPara()
+	case "s":
+		g.para.style = param
+	case "ql":
+		g.para.alignment = types.AlignLeft
+	case "qc":
+		g.para.alignment = types.AlignCenter
+	case "qr":
+		g.para.alignment = types.AlignRight
+	case "qj", "qd":
+		g.para.alignment = types.AlignJustify
+	case "li", "lin":
+		g.para.indentation.Left = twips
+	case "ri", "rin":
+		g.para.indentation.Right = twips
+	case "fi":
+		g.para.indentation.First, g.para.indentation.Hanging = twips, 0
+		if twips < 0 {
+			g.para.indentation.First, g.para.indentation.Hanging = 0, -twips
+		}
+	case "sb":
+		g.para.spacing.Before = twips
+	case "sa":
+		g.para.spacing.After = twips
+	case "sl":
+		// 与DOCX和DOC的解析相同，行距值统一按1/240换算；固定行距为负值
+		g.para.spacing.Line = float64(abs(param)) / 240.0
+	case "keep":
+		g.para.keepLines = flag
+	case "keepn":
+		g.para.keepNext = flag
+	case "pagebb":
+		g.para.pageBreak = flag
+	case "outlinelevel":
+		g.para.outline = param + 1
+	case "intbl":
+		g.para.inTable = flag
+	case "itap":
+		g.para.inTable = param > 0
+
+	// 表格
+	case "trowd":
+		r.cellBounds, r.rowHeight, r.rowHeader = nil, 0, false
+	case "cellx":
+		r.cellBounds = append(r.cellBounds, param)
+	case "trrh":
+		r.rowHeight = abs(param)
+	case "trhdr":
+		r.rowHeader = true
+
+	// 节和页面
+	case "paperw", "paperh", "margl", "margr", "margt", "margb":
+		r.pageSetting(&r.docSection, token.word, twips)
+		r.pageSetting(r.currentSection(), token.word, twips)
+	case "pgwsxn", "pghsxn", "marglsxn", "margrsxn", "margtsxn", "margbsxn", "headery", "footery":
+		r.pageSetting(r.currentSection(), strings.TrimSuffix(token.word, "sxn"), twips)
+	case "sectd":
+		section := r.docSection
+		r.section = &section
+	case "cols":
+		r.currentSection().Columns.Count = max(param, 1)
+	case "colsx":
+		r.currentSection().Columns.Spacing = twips
+	case "pgnstarts":
+		r.currentSection().PageNumbering.Start = param
+	case "pgnrestart":
+		r.currentSection().PageNumbering.Restart = true
+	case "pgndec", "pgnucrm", "pgnlcrm", "pgnucltr", "pgnlcltr":
+		formats := map[string]string{"pgndec": "decimal", "pgnucrm": "upperRoman", "pgnlcrm": "lowerRoman", "pgnucltr": "upperLetter", "pgnlcltr": "lowerLetter"}
+		r.currentSection().PageNumbering.Format = formats[token.word]
+	case "linemod":
+		r.currentSection().LineNumbering.Increment = param
+	case "linestarts":
+		r.currentSection().LineNumbering.Start = param
+	case "linerestart":
+		r.currentSection().LineNumbering.Restart = true
+
+	// 特殊字符和结构
+	case "par":
+		r.endParagraph(g, false)
+	case "cell":
+		r.endParagraph(g, true)
+	case "nestcell":
+		r.endParagraph(g, false)
+	case "row":
+		r.endRow()
+	case "sect":
+		if len(g.story.runs) > 0 {
+			r.endParagraph(g, false)
+		}
+		r.endTable()
+		r.endSection()
+	case "page":
+		g.story.pageBreak = true
+	case "line":
+		r.text(g, "\n")
+	case "tab":
+		r.text(g, "\t")
+	case "emdash":
+		r.text(g, "—")
+	case "endash":
+		r.text(g, "–")
+	case "bullet":
+		r.text(g, "•")
+	case "lquote":
+		r.text(g, "‘")
+	case "rquote":
+		r.text(g, "’")
+	case "ldblquote":
+		r.text(g, "“")
+	case "rdblquote":
+		r.text(g, "”")
+	case "emspace", "enspace", "qmspace":
+		r.text(g, " ")
+	}
+}
+
+// unicode 处理\uN：N为有符号16位整数，随后的\uc个回退字符被跳过
+func (r *rtfReader) unicode(g *rtfGroup, value int) {
+	if value < 0 {
+		value += 65536
+	}
+	unit := rune(value)
+	r.skip = g.uc
+
+	switch {
+	case utf16.IsSurrogate(unit) && unit < 0xDC00:
+		r.highSurrogate = unit
+		return
+	case utf16.IsSurrogate(unit) && r.highSurrogate != 0:
+		unit = utf16.DecodeRune(r.highSurrogate, unit)
+	}
+	r.highSurrogate = 0
+	r.text(g, string(unit))
+}
+
+// rtfCharsetCodePage 返回\fcharset对应的代码页，ANSI和默认字符集使用文档的\ansicpg
+func rtfCharsetCodePage(charset, ansiCodePage int) int {
+	codePages := map[int]int{
+		77: 10000, 128: 932, 129: 949, 134: 936, 136: 950, 161: 1253, 162: 1254, 163: 1258,
+		177: 1255, 178: 1256, 186: 1257, 204: 1251, 222: 874, 238: 1250, 254: 437, 255: 850,
+	}
+	if codePage, ok := codePages[charset]; ok {
+		return codePage
+	}
+	return ansiCodePage
+}
+
+// codePage 返回当前文本字节使用的代码页
+func (r *rtfReader) codePage() int {
+	g := r.group()
+	if g.dest == rtfDestFontTable && r.currentFont != nil {
+		return r.currentFont.codePage
+	}
+	font := g.chars.font
+	if font < 0 {
+		font = r.defaultFont
+	}
+	if f, ok := r.fonts[font]; ok && f.codePage != 0 {
+		return f.codePage
+	}
+	return r.ansiCodePage
+}
+
+// flush 解码尚未处理的文本字节并交给当前目标
+func (r *rtfReader) flush() {
+	if len(r.pending) == 0 {
+		return
+	}
+	text := decodeCodePage(r.codePage(), r.pending)
+	r.pending = r.pending[:0]
+	r.text(r.group(), text)
+}
+
+// text 按当前组的目标处理解码后的文本
+func (r *rtfReader) text(g *rtfGroup, text string) {
+	switch g.dest {
+	case rtfDestText:
+		if g.chars.hidden || text == "" {
+			return
+		}
+		story := g.story
+		if n := len(story.runs); n > 0 && story.runChars[n-1] == g.chars {
+			story.runs[n-1].Text += text
+			return
+		}
+		story.runs = append(story.runs, r.convertRun(g.chars, text))
+		story.runChars = append(story.runChars, g.chars)
+	case rtfDestFontTable:
+		if r.currentFont == nil {
+			return
+		}
+		for i, part := range strings.Split(text, ";") {
+			if i > 0 {
+				r.currentFont.name = strings.TrimSpace(r.currentFont.buffer.String())
+				r.currentFont.buffer.Reset()
+			}
+			r.currentFont.buffer.WriteString(part)
+		}
+	case rtfDestColorTable:
+		for range strings.Count(text, ";") {
+			color := ""
+			if r.colorSet {
+				color = fmt.Sprintf("%02X%02X%02X", r.color[0]&0xFF, r.color[1]&0xFF, r.color[2]&0xFF)
+			}
+			r.colors = append(r.colors, color)
+			r.color, r.colorSet = [3]int{}, false
+		}
+	case rtfDestStyle, rtfDestInfoField, rtfDestBookmark:
+		g.buffer.WriteString(text)
+	}
+}
+
+// convertRun 将字符格式转换为文本运行
+func (r *rtfReader) convertRun(chars rtfCharState, text string) types.TextRun {
+	font := r.convertFont(chars)
+	return types.TextRun{
+		Text:      text,
+		Font:      font,
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Color:     font.Color,
+		Highlight: font.Highlight,
+		Size:      font.Size,
+		Position:  chars.position,
+	}
+}
+
+// convertFont 返回字符格式对应的字体
+func (r *rtfReader) convertFont(chars rtfCharState) types.Font {
+	font := types.Font{
+		Size:      float64(chars.halfPoints) / 2.0,
+		Color:     types.Color{RGB: r.colorAt(chars.color)},
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+	}
+	index := chars.font
+	if index < 0 {
+		index = r.defaultFont
+	}
+	if f, ok := r.fonts[index]; ok {
+		font.Name = f.name
+	}
+	// 突出显示颜色取颜色表中的颜色，转换为与OOXML相同的名称
+	if rgb := r.colorAt(chars.highlight); rgb != "" {
+		for ico, color := range docIcoColors {
+			if color == rgb {
+				font.Highlight = docHighlight(byte(ico))
+			}
+		}
+	}
+	return font
+}
+
+// colorAt 返回颜色表中的颜色，序号越界或为自动颜色时返回空字符串
+func (r *rtfReader) colorAt(index int) string {
+	if index > 0 && index < len(r.colors) {
+		return r.colors[index]
+	}
+	return ""
+}
+
+// styleID 返回段落样式的样式ID，正文样式（\s0）与DOCX一致返回空字符串
+func (r *rtfReader) styleID(index int) string {
+	if index == 0 {
+		return ""
+	}
+	if style, ok := r.styles[index]; ok {
+		return styleIDFromName(style.name)
+	}
+	return ""
+}
+
+// endParagraph 结束当前段落；cellEnd表示段落以\cell结束，同时结束单元格
+//
+// 页眉页脚中的表格不单独记录，单元格中的段落直接作为页眉页脚的段落。
+func (r *rtfReader) endParagraph(g *rtfGroup, cellEnd bool) {
+	story := g.story
+	paragraph := types.Paragraph{
+		Style:        types.ParagraphStyle{Name: r.styleID(g.para.style)},
+		Alignment:    g.para.alignment,
+		Indentation:  g.para.indentation,
+		Spacing:      g.para.spacing,
+		Runs:         story.runs,
+		PageBreak:    story.pageBreak || g.para.pageBreak,
+		KeepLines:    g.para.keepLines,
+		KeepNext:     g.para.keepNext,
+		OutlineLevel: g.para.outline,
+	}
+	var text strings.Builder
+	for _, run := range story.runs {
+		text.WriteString(run.Text)
+	}
+	paragraph.Text = text.String()
+	story.runs, story.runChars, story.pageBreak = nil, nil, false
+
+	if story != r.body {
+		story.paragraphs = append(story.paragraphs, paragraph)
+		return
+	}
+	if !g.para.inTable && !cellEnd {
+		r.endTable()
+		i := len(story.paragraphs) + 1
+		setParagraphIDs(&paragraph, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+			return fmt.Sprintf("run_%d_%d", i, j+1)
+		})
+		story.paragraphs = append(story.paragraphs, paragraph)
+		return
+	}
+
+	if r.table == nil {
+		r.table = &types.Table{ID: fmt.Sprintf("table_%d", len(r.tables)+1)}
+	}
+	i, j := len(r.tables)+1, len(r.table.Rows)+1
+	if r.row == nil {
+		r.row = &types.TableRow{ID: fmt.Sprintf("row_%d_%d", i, j)}
+	}
+	k := len(r.row.Cells) + 1
+	if r.cell == nil {
+		r.cell = &types.TableCell{ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k)}
+	}
+	setParagraphIDs(&paragraph, fmt.Sprintf("cell_para_%d_%d_%d", i, j, k), func(int) string {
+		return fmt.Sprintf("cell_run_%d_%d_%d", i, j, k)
+	})
+	r.cell.Content = append(r.cell.Content, paragraph)
+	if cellEnd {
+		r.row.Cells = append(r.row.Cells, *r.cell)
+		r.cell = nil
+	}
+}
+
+// addComment 添加批注，批注锚定在引用它的正文段落，表格和页眉页脚中的批注不锚定到正文段落
+func (r *rtfReader) addComment(story *rtfStory, parent *rtfGroup) {
+	var lines []string
+	for _, paragraph := range story.paragraphs {
+		lines = append(lines, paragraph.Text)
+	}
+	comment := types.Comment{
+		ID:       strings.TrimSpace(r.fields["atnref"]),
+		Author:   strings.TrimSpace(r.fields["atnauthor"]),
+		Initials: strings.TrimSpace(r.fields["atnid"]),
+		Text:     strings.Join(lines, "\n"),
+	}
+	if comment.ID == "" {
+		comment.ID = strconv.Itoa(len(r.comments))
+	}
+	if comment.Author == "" {
+		comment.Author = comment.Initials
+	}
+	if value, err := strconv.Atoi(strings.TrimSpace(r.fields["atndate"])); err == nil {
+		comment.Date = rtfDTTM(value)
+	}
+	if parent.story == r.body && !parent.para.inTable {
+		n := len(r.body.paragraphs) + 1
+		comment.Anchor = types.CommentAnchor{Paragraph: n, EndParagraph: n}
+	}
+	r.comments = append(r.comments, comment)
+	for name := range rtfAnnotationFields {
+		delete(r.fields, name)
+	}
+}
+
+// rtfDTTM 将\atndate的DTTM值转换为时间：位0-5为分钟，6-10为小时，11-15为日，16-19为月，20-28为年份减1900
+func rtfDTTM(value int) time.Time {
+	if value == 0 {
+		return time.Time{}
+	}
+	minute, hour := value&0x3F, value>>6&0x1F
+	day, month, year := value>>11&0x1F, value>>16&0xF, value>>20&0x1FF
+	return time.Date(1900+year, time.Month(max(month, 1)), max(day, 1), hour, minute, 0, 0, time.UTC)
+}
+
+// setParagraphIDs 设置段落和其中文本运行的ID
+func setParagraphIDs(paragraph *types.Paragraph, id string, runID func(j int) string) {
+	paragraph.ID = id
+	for j := range paragraph.Runs {
+		paragraph.Runs[j].ID = runID(j)
+	}
+}
+
+// endRow 结束表格行，单元格宽度由\cellx给出的右边界计算
+func (r *rtfReader) endRow() {
+	if r.row == nil {
+		return
+	}
+	if r.cell != nil {
+		r.row.Cells = append(r.row.Cells, *r.cell)
+		r.cell = nil
+	}
+	left := 0
+	for k := range r.row.Cells {
+		if k < len(r.cellBounds) {
+			r.row.Cells[k].Width = float64(r.cellBounds[k]-left) / 20.0
+			left = r.cellBounds[k]
+		}
+	}
+	r.row.Height = float64(r.rowHeight) / 20.0
+	r.row.Header, r.row.Repeat = r.rowHeader, r.rowHeader
+	r.table.Rows = append(r.table.Rows, *r.row)
+	r.row = nil
+}
+
+// endTable 结束当前表格，表格宽度取第一行各单元格宽度之和
+func (r *rtfReader) endTable() {
+	if r.table == nil {
+		return
+	}
+	r.endRow()
+	if len(r.table.Rows) > 0 {
+		for _, cell := range r.table.Rows[0].Cells {
+			r.table.Width += cell.Width
+		}
+	}
+	r.tables = append(r.tables, *r.table)
+	r.table = nil
+}
+
+// currentSection 返回当前节的属性，尚未开始新节时以文档的页面设置为准
+func (r *rtfReader) currentSection() *types.Section {
+	if r.section == nil {
+		section := r.docSection
+		r.section = &section
+	}
+	return r.section
+}
+
+// pageSetting 设置页面大小、页边距或页眉页脚距离
+func (r *rtfReader) pageSetting(section *types.Section, word string, value float64) {
+	switch word {
+	case "paperw", "pgw":
+		section.PageSize.Width = value
+	case "paperh", "pgh":
+		section.PageSize.Height = value
+	case "margl":
+		section.PageMargins.Left = value
+	case "margr":
+		section.PageMargins.Right = value
+	case "margt":
+		section.PageMargins.Top = value
+	case "margb":
+		section.PageMargins.Bottom = value
+	case "headery":
+		section.PageMargins.Header, section.HeaderDistance = value, value
+	case "footery":
+		section.PageMargins.Footer, section.FooterDistance = value, value
+	}
+}
+
+// endSection 结束当前节
+func (r *rtfReader) endSection() {
+	section := *r.currentSection()
+	section.ID = fmt.Sprintf("section_%d", len(r.sections)+1)
+	r.sections = append(r.sections, section)
+	r.section = nil
+}
+
+// addPicture 添加图片，显示大小为目标大小乘以缩放比例
+func (r *rtfReader) addPicture(picture *rtfPicture) {
+	width, height := picture.widthGoal, picture.heightGoal
+	if width == 0 {
+		width = picture.width
+	}
+	if height == 0 {
+		height = picture.height
+	}
+	r.images = append(r.images, types.Image{
+		ID:     fmt.Sprintf("image_%d", len(r.images)+1),
+		Width:  float64(width) * float64(picture.scaleX) / 100 / 20,
+		Height: float64(height) * float64(picture.scaleY) / 100 / 20,
+	})
+}
+
+// document 生成文档
+func (r *rtfReader) document(fileSize int64) *types.Document {
+	doc := &types.Document{
+		Metadata: r.metadata(fileSize),
+		Content: types.DocumentContent{
+			Paragraphs: r.body.paragraphs,
+			Sections:   r.sections,
+			Tables:     r.tables,
+			Images:     r.images,
+			Bookmarks:  r.bookmarks,
+			Comments:   r.comments,
+		},
+		Styles: r.documentStyles(),
+	}
+	doc.Content.Headers, doc.Content.Footers = r.headersFooters()
+
+	var fonts []string
+	for _, index := range r.fontOrder {
+		fonts = append(fonts, r.fonts[index].name)
+	}
+	doc.FormatRules = contentFormatRules(doc, fonts)
+	for _, style := range r.styleOrder {
+		rule := types.StyleRule{ID: styleIDFromName(style.name), Name: style.name, Type: style.kind}
+		if base, ok := r.styles[style.basedOn]; ok && style.basedOn != style.index {
+			rule.BasedOn = styleIDFromName(base.name)
+		}
+		doc.FormatRules.StyleRules = append(doc.FormatRules.StyleRules, rule)
+	}
+	return doc
+}
+
+// metadata 返回\info中的文档属性
+func (r *rtfReader) metadata(fileSize int64) types.DocumentMetadata {
+	metadata := types.DocumentMetadata{
+		Title:       strings.TrimSpace(r.fields["title"]),
+		Subject:     strings.TrimSpace(r.fields["subject"]),
+		Author:      strings.TrimSpace(r.fields["author"]),
+		LastSavedBy: strings.TrimSpace(r.fields["operator"]),
+		Revision:    r.revision,
+		Version:     fmt.Sprintf("%d.0", max(r.version, 1)),
+		FileSize:    fileSize,
+		PageCount:   r.pages,
+		WordCount:   r.words,
+	}
+	if keywords := strings.TrimSpace(r.fields["keywords"]); keywords != "" {
+		metadata.Keywords = strings.Split(keywords, ",")
+	}
+	rtfTime := func(name string) time.Time {
+		t := r.times[name]
+		if t == nil || t[0] == 0 {
+			return time.Time{}
+		}
+		return time.Date(t[0], time.Month(max(t[1], 1)), max(t[2], 1), t[3], t[4], t[5], 0, time.UTC)
+	}
+	metadata.Created = rtfTime("creatim")
+	metadata.Modified = rtfTime("revtim")
+	return metadata
+}
+
+// headersFooters 返回非空的页眉和页脚
+func (r *rtfReader) headersFooters() ([]types.Header, []types.Footer) {
+	var headers []types.Header
+	var footers []types.Footer
+	for _, story := range r.stories {
+		empty := true
+		for _, paragraph := range story.paragraphs {
+			empty = empty && strings.TrimSpace(paragraph.Text) == ""
+		}
+		if empty {
+			continue
+		}
+
+		prefix, n := "header", len(headers)+1
+		if story.footer {
+			prefix, n = "footer", len(footers)+1
+		}
+		for k := range story.paragraphs {
+			setParagraphIDs(&story.paragraphs[k], fmt.Sprintf("%s_para_%d_%d", prefix, n, k+1), func(j int) string {
+				return fmt.Sprintf("%s_run_%d_%d_%d", prefix, n, k+1, j+1)
+			})
+		}
+		if story.footer {
+			footers = append(footers, types.Footer{ID: fmt.Sprintf("footer_%d", n), Content: story.paragraphs})
+		} else {
+			headers = append(headers, types.Header{ID: fmt.Sprintf("header_%d", n), Content: story.paragraphs})
+		}
+	}
+	return headers, footers
+}
+
+// documentStyles 返回样式表中的段落、字符和表格样式
+func (r *rtfReader) documentStyles() types.DocumentStyles {
+	styles := types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+	}
+	for _, style := range r.styleOrder {
+		if style.name == "" {
+			continue
+		}
+		id := styleIDFromName(style.name)
+		switch style.kind {
+		case "paragraph":
+			styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{
+				ID:          id,
+				Name:        style.name,
+				Font:        r.convertFont(style.chars),
+				Alignment:   style.para.alignment,
+				Indentation: style.para.indentation,
+				Spacing:     style.para.spacing,
+			})
+		case "character":
+			styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: id, Name: style.name})
+		case "table":
+			styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: id, Name: style.name})
+		}
+	}
+	return styles
+}
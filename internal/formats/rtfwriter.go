@@ -0,0 +1,596 @@
+package formats
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"docs-parser/internal/core/types"
+)
+
+// RTF的写出
+//
+// 写出的结构与rtfreader.go读取的结构对应：字体表、颜色表、样式表、\info、各节的页面设置、页眉页脚、正文段落
+// 和表格。正文写在第一节中，其余各节只保留页面设置。锚定在正文段落的批注写为RTF批注（\atnid、\atnauthor、
+// \chatn和\annotation），未锚定的批注写在最后一个正文段落。非ASCII字符一律写为\uN，回退字符为'?'。
+//
+// 写出是有损的：types.Document不记录表格在正文中的位置，表格依次写在正文段落之后；types.Image只有大小没有图片数据，
+// 图片不写出。WriteRTF以警告的形式返回这些丢失。
+
+// rtfHeaderKinds 页眉页脚依次使用的目标，超过4个时循环使用
+var (
+	rtfHeaderKinds = []string{"header", "headerf", "headerl", "headerr"}
+	rtfFooterKinds = []string{"footer", "footerf", "footerl", "footerr"}
+)
+
+// rtfWriter RTF写出器
+type rtfWriter struct {
+	doc        *types.Document
+	out        strings.Builder
+	fonts      []string
+	fontIndex  map[string]int
+	colors     []string // 颜色表，第0项为自动颜色
+	colorIndex map[string]int
+	styleIndex map[string]int          // 样式ID对应的\s、\cs或\ts序号
+	comments   map[int][]types.Comment // 正文段落序号（从1开始）对应的批注
+}
+
+// WriteRTF 将文档写为RTF，返回写出时丢失或移动的内容的警告
+func WriteRTF(w io.Writer, doc *types.Document) ([]string, error) {
+	rw := &rtfWriter{
+		doc:        doc,
+		fontIndex:  make(map[string]int),
+		colors:     []string{""},
+		colorIndex: make(map[string]int),
+		styleIndex: make(map[string]int),
+		comments:   make(map[int][]types.Comment),
+	}
+	rw.collect()
+	rw.document()
+	_, err := io.WriteString(w, rw.out.String())
+	return rtfWriteWarnings(doc), err
+}
+
+// rtfWriteWarnings 返回写出RTF时无法保留的内容
+func rtfWriteWarnings(doc *types.Document) []string {
+	var warnings []string
+	if n := len(doc.Content.Images); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d image(s) omitted: image data is not available in the parsed document", n))
+	}
+	if n := len(doc.Content.Tables); n > 0 && len(doc.Content.Paragraphs) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d table(s) moved after the body paragraphs: table positions are not recorded in the parsed document", n))
+	}
+	return warnings
+}
+
+// collect 收集字体、颜色、样式序号和批注位置
+func (rw *rtfWriter) collect() {
+	content := rw.doc.Content
+	for _, style := range rw.doc.Styles.ParagraphStyles {
+		rw.addFont(style.Font.Name)
+		rw.addColor(style.Font.Color.RGB)
+	}
+	for _, rule := range rw.doc.FormatRules.FontRules {
+		rw.addFont(rule.Name)
+	}
+	for _, paragraph := range rtfAllParagraphs(content) {
+		for _, run := range paragraph.Runs {
+			rw.addFont(run.Font.Name)
+			rw.addColor(run.Color.RGB)
+			rw.addColor(rtfHighlightColor(run.Highlight))
+		}
+	}
+	if len(rw.fonts) == 0 {
+		rw.addFont("Times New Roman")
+	}
+
+	// 正文样式固定为\s0，其余样式依次编号
+	next := 1
+	for _, style := range rw.doc.Styles.ParagraphStyles {
+		if style.ID == "Normal" {
+			rw.styleIndex[style.ID] = 0
+			continue
+		}
+		rw.styleIndex[style.ID] = next
+		next++
+	}
+	for _, style := range rw.doc.Styles.CharacterStyles {
+		rw.styleIndex[style.ID] = next
+		next++
+	}
+	for _, style := range rw.doc.Styles.TableStyles {
+		rw.styleIndex[style.ID] = next
+		next++
+	}
+
+	for _, comment := range content.Comments {
+		n := comment.Anchor.Paragraph
+		if n < 1 || n > len(content.Paragraphs) {
+			n = max(len(content.Paragraphs), 1)
+		}
+		rw.comments[n] = append(rw.comments[n], comment)
+	}
+}
+
+// rtfAllParagraphs 返回正文、表格和页眉页脚中的所有段落
+func rtfAllParagraphs(content types.DocumentContent) []types.Paragraph {
+	paragraphs := append([]types.Paragraph{}, content.Paragraphs...)
+	for _, table := range content.Tables {
+		for _, row := range table.Rows {
+			for _, cell := range row.Cells {
+				paragraphs = append(paragraphs, cell.Content...)
+			}
+		}
+	}
+	for _, header := range content.Headers {
+		paragraphs = append(paragraphs, header.Content...)
+	}
+	for _, footer := range content.Footers {
+		paragraphs = append(paragraphs, footer.Content...)
+	}
+	return paragraphs
+}
+
+// addFont 将字体加入字体表
+func (rw *rtfWriter) addFont(name string) {
+	if _, exists := rw.fontIndex[name]; name == "" || exists {
+		return
+	}
+	rw.fontIndex[name] = len(rw.fonts)
+	rw.fonts = append(rw.fonts, name)
+}
+
+// addColor 将RGB颜色加入颜色表，忽略自动颜色和无效值
+func (rw *rtfWriter) addColor(rgb string) {
+	rgb = strings.ToUpper(rgb)
+	if _, exists := rw.colorIndex[rgb]; exists || !isRGB(rgb) {
+		return
+	}
+	rw.colorIndex[rgb] = len(rw.colors)
+	rw.colors = append(rw.colors, rgb)
+}
+
+// isRGB 判断是否为6位十六进制RGB值
+func isRGB(value string) bool {
+	if len(value) != 6 {
+		return false
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789ABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// rtfHighlightColor 返回突出显示颜色名称对应的RGB值
+func rtfHighlightColor(highlight types.Highlight) string {
+	for ico := 1; ico < len(docIcoColors); ico++ {
+		if docHighlight(byte(ico)) == highlight {
+			return docIcoColors[ico]
+		}
+	}
+	return ""
+}
+
+// document 写出整个文档
+func (rw *rtfWriter) document() {
+	rw.out.WriteString(`{\rtf1\ansi\ansicpg1252\deff0\uc1` + "\n")
+	rw.fontTable()
+	rw.colorTable()
+	rw.styleSheet()
+	rw.info()
+
+	content := rw.doc.Content
+	sections := content.Sections
+	if len(sections) == 0 {
+		sections = []types.Section{defaultDocSection("")}
+	}
+	first := sections[0]
+	fmt.Fprintf(&rw.out, `\paperw%d\paperh%d\margl%d\margr%d\margt%d\margb%d`+"\n",
+		rtfTwips(first.PageSize.Width), rtfTwips(first.PageSize.Height),
+		rtfTwips(first.PageMargins.Left), rtfTwips(first.PageMargins.Right),
+		rtfTwips(first.PageMargins.Top), rtfTwips(first.PageMargins.Bottom))
+
+	for i, section := range sections {
+		if i > 0 {
+			rw.out.WriteString(`\sect`)
+		}
+		rw.section(section)
+		if i > 0 {
+			continue
+		}
+
+		for j, header := range content.Headers {
+			rw.story(rtfHeaderKinds[j%len(rtfHeaderKinds)], header.Content)
+		}
+		for j, footer := range content.Footers {
+			rw.story(rtfFooterKinds[j%len(rtfFooterKinds)], footer.Content)
+		}
+		paragraphs := content.Paragraphs
+		if len(paragraphs) == 0 && len(content.Comments) > 0 {
+			paragraphs = []types.Paragraph{{}}
+		}
+		for j, paragraph := range paragraphs {
+			rw.paragraph(paragraph, false, `\par`, rw.comments[j+1])
+		}
+		for _, table := range content.Tables {
+			rw.table(table, first)
+		}
+	}
+	rw.out.WriteString("}\n")
+}
+
+// fontTable 写出字体表，东亚字体使用GB2312字符集
+func (rw *rtfWriter) fontTable() {
+	rw.out.WriteString(`{\fonttbl`)
+	for i, name := range rw.fonts {
+		charset := 0
+		if hasEastAsian(name) {
+			charset = 134
+		}
+		fmt.Fprintf(&rw.out, `{\f%d\fnil\fcharset%d %s;}`, i, charset, rtfEscape(name))
+	}
+	rw.out.WriteString("}\n")
+}
+
+// colorTable 写出颜色表
+func (rw *rtfWriter) colorTable() {
+	rw.out.WriteString(`{\colortbl;`)
+	for _, rgb := range rw.colors[1:] {
+		var r, g, b int
+		fmt.Sscanf(rgb, "%02X%02X%02X", &r, &g, &b)
+		fmt.Fprintf(&rw.out, `\red%d\green%d\blue%d;`, r, g, b)
+	}
+	rw.out.WriteString("}\n")
+}
+
+// styleSheet 写出样式表
+func (rw *rtfWriter) styleSheet() {
+	styles := rw.doc.Styles
+	normal := `{\s0 Normal;}`
+	var entries []string
+	for _, style := range styles.ParagraphStyles {
+		name := rtfStyleName(style.Name, style.ID)
+		format := rw.paragraphFormat(style.Alignment, style.Indentation, style.Spacing) + rw.fontFormat(style.Font)
+		if style.ID == "Normal" {
+			normal = fmt.Sprintf(`{\s0%s %s;}`, format, rtfEscape(name))
+			continue
+		}
+		entries = append(entries, fmt.Sprintf(`{\s%d\sbasedon0%s %s;}`, rw.styleIndex[style.ID], format, rtfEscape(name)))
+	}
+	for _, style := range styles.CharacterStyles {
+		entries = append(entries, fmt.Sprintf(`{\*\cs%d\additive %s;}`, rw.styleIndex[style.ID], rtfEscape(rtfStyleName(style.Name, style.ID))))
+	}
+	for _, style := range styles.TableStyles {
+		entries = append(entries, fmt.Sprintf(`{\*\ts%d\tsrowd %s;}`, rw.styleIndex[style.ID], rtfEscape(rtfStyleName(style.Name, style.ID))))
+	}
+	rw.out.WriteString(`{\stylesheet` + normal + strings.Join(entries, "") + "}\n")
+}
+
+// rtfStyleName 返回样式名称，名称为空时使用样式ID
+func rtfStyleName(name, id string) string {
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+// info 写出文档属性
+func (rw *rtfWriter) info() {
+	metadata := rw.doc.Metadata
+	rw.out.WriteString(`{\info`)
+	fields := []struct{ word, value string }{
+		{"title", metadata.Title},
+		{"subject", metadata.Subject},
+		{"author", metadata.Author},
+		{"operator", metadata.LastSavedBy},
+		{"keywords", strings.Join(metadata.Keywords, ",")},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Fprintf(&rw.out, `{\%s %s}`, field.word, rtfEscape(field.value))
+		}
+	}
+	rtfTime := func(word string, t time.Time) {
+		if !t.IsZero() {
+			fmt.Fprintf(&rw.out, `{\%s\yr%d\mo%d\dy%d\hr%d\min%d\sec%d}`, word, t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
+		}
+	}
+	rtfTime("creatim", metadata.Created)
+	rtfTime("revtim", metadata.Modified)
+	counts := []struct {
+		word  string
+		value int
+	}{{"version", metadata.Revision}, {"nofpages", metadata.PageCount}, {"nofwords", metadata.WordCount}}
+	for _, count := range counts {
+		if count.value > 0 {
+			fmt.Fprintf(&rw.out, `{\%s%d}`, count.word, count.value)
+		}
+	}
+	rw.out.WriteString("}\n")
+}
+
+// section 写出节的页面设置
+func (rw *rtfWriter) section(section types.Section) {
+	header := section.PageMargins.Header
+	if header == 0 {
+		header = section.HeaderDistance
+	}
+	footer := section.PageMargins.Footer
+	if footer == 0 {
+		footer = section.FooterDistance
+	}
+	fmt.Fprintf(&rw.out, `\sectd\pgwsxn%d\pghsxn%d\marglsxn%d\margrsxn%d\margtsxn%d\margbsxn%d\headery%d\footery%d`,
+		rtfTwips(section.PageSize.Width), rtfTwips(section.PageSize.Height),
+		rtfTwips(section.PageMargins.Left), rtfTwips(section.PageMargins.Right),
+		rtfTwips(section.PageMargins.Top), rtfTwips(section.PageMargins.Bottom),
+		rtfTwips(header), rtfTwips(footer))
+
+	if section.Columns.Count > 1 {
+		fmt.Fprintf(&rw.out, `\cols%d\colsx%d`, section.Columns.Count, rtfTwips(section.Columns.Spacing))
+	}
+	numbering := section.PageNumbering
+	if numbering.Start > 0 {
+		fmt.Fprintf(&rw.out, `\pgnstarts%d`, numbering.Start)
+	}
+	if numbering.Restart {
+		rw.out.WriteString(`\pgnrestart`)
+	}
+	formats := map[string]string{"decimal": "pgndec", "upperRoman": "pgnucrm", "lowerRoman": "pgnlcrm", "upperLetter": "pgnucltr", "lowerLetter": "pgnlcltr"}
+	if word, ok := formats[numbering.Format]; ok {
+		rw.out.WriteString(`\` + word)
+	}
+	lines := section.LineNumbering
+	if lines.Increment > 0 {
+		fmt.Fprintf(&rw.out, `\linemod%d\linestarts%d`, lines.Increment, max(lines.Start, 1))
+		if lines.Restart {
+			rw.out.WriteString(`\linerestart`)
+		}
+	}
+	rw.out.WriteString("\n")
+}
+
+// story 写出页眉或页脚
+func (rw *rtfWriter) story(kind string, paragraphs []types.Paragraph) {
+	rw.out.WriteString(`{\` + kind)
+	for _, paragraph := range paragraphs {
+		rw.paragraph(paragraph, false, `\par`, nil)
+	}
+	rw.out.WriteString("}\n")
+}
+
+// table 写出表格，宽度为0的单元格平分版心宽度
+func (rw *rtfWriter) table(table types.Table, section types.Section) {
+	for _, row := range table.Rows {
+		if len(row.Cells) == 0 {
+			continue
+		}
+		rw.out.WriteString(`\trowd\trgaph108`)
+		if row.Header {
+			rw.out.WriteString(`\trhdr`)
+		}
+		if row.Height > 0 {
+			fmt.Fprintf(&rw.out, `\trrh%d`, rtfTwips(row.Height))
+		}
+		textWidth := section.PageSize.Width - section.PageMargins.Left - section.PageMargins.Right
+		right := 0
+		for _, cell := range row.Cells {
+			width := cell.Width
+			if width <= 0 {
+				width = textWidth / float64(len(row.Cells))
+			}
+			right += rtfTwips(width)
+			fmt.Fprintf(&rw.out, `\cellx%d`, right)
+		}
+		rw.out.WriteString("\n")
+
+		for _, cell := range row.Cells {
+			if len(cell.Content) == 0 {
+				rw.out.WriteString(`\pard\plain\intbl\cell` + "\n")
+				continue
+			}
+			for k, paragraph := range cell.Content {
+				end := `\par`
+				if k == len(cell.Content)-1 {
+					end = `\cell`
+				}
+				rw.paragraph(paragraph, true, end, nil)
+			}
+		}
+		rw.out.WriteString(`\row` + "\n")
+	}
+	rw.out.WriteString(`\pard` + "\n")
+}
+
+// paragraph 写出段落；end为结束段落的控制字（\par或单元格最后一段的\cell）
+func (rw *rtfWriter) paragraph(paragraph types.Paragraph, inTable bool, end string, comments []types.Comment) {
+	rw.out.WriteString(`\pard\plain`)
+	if index, ok := rw.styleIndex[paragraph.Style.Name]; ok && paragraph.Style.Name != "" {
+		fmt.Fprintf(&rw.out, `\s%d`, index)
+	}
+	if inTable {
+		rw.out.WriteString(`\intbl`)
+	}
+	rw.out.WriteString(rw.paragraphFormat(paragraph.Alignment, paragraph.Indentation, paragraph.Spacing))
+	if paragraph.KeepLines {
+		rw.out.WriteString(`\keep`)
+	}
+	if paragraph.KeepNext {
+		rw.out.WriteString(`\keepn`)
+	}
+	if paragraph.PageBreak {
+		rw.out.WriteString(`\pagebb`)
+	}
+	if paragraph.OutlineLevel > 0 {
+		fmt.Fprintf(&rw.out, `\outlinelevel%d`, paragraph.OutlineLevel-1)
+	}
+	rw.out.WriteString(" ")
+
+	for _, comment := range comments {
+		fmt.Fprintf(&rw.out, `{\*\atrfstart %s}`, rtfEscape(comment.ID))
+	}
+	for _, run := range paragraph.Runs {
+		if run.Text == "" {
+			continue
+		}
+		rw.out.WriteString(`{` + rw.runFormat(run) + " " + rtfEscape(run.Text) + `}`)
+	}
+	for _, comment := range comments {
+		rw.annotation(comment)
+	}
+	rw.out.WriteString(end + "\n")
+}
+
+// annotation 写出批注：批注标记之后是作者和批注内容，批注的每行写为一个段落
+func (rw *rtfWriter) annotation(comment types.Comment) {
+	initials := comment.Initials
+	if initials == "" {
+		initials = comment.Author
+	}
+	fmt.Fprintf(&rw.out, `{\*\atrfend %s}{\*\atnid %s}{\*\atnauthor %s}\chatn{\*\annotation{\*\atnref %s}`,
+		rtfEscape(comment.ID), rtfEscape(initials), rtfEscape(comment.Author), rtfEscape(comment.ID))
+	if value := rtfDTTMValue(comment.Date); value != 0 {
+		fmt.Fprintf(&rw.out, `{\*\atndate %d}`, value)
+	}
+	for _, line := range strings.Split(comment.Text, "\n") {
+		rw.out.WriteString(`\pard\plain ` + rtfEscape(line) + `\par`)
+	}
+	rw.out.WriteString(`}`)
+}
+
+// paragraphFormat 返回对齐方式、缩进和间距的控制字
+func (rw *rtfWriter) paragraphFormat(alignment types.Alignment, indentation types.Indentation, spacing types.Spacing) string {
+	var b strings.Builder
+	switch alignment {
+	case types.AlignCenter:
+		b.WriteString(`\qc`)
+	case types.AlignRight:
+		b.WriteString(`\qr`)
+	case types.AlignJustify:
+		b.WriteString(`\qj`)
+	default:
+		b.WriteString(`\ql`)
+	}
+	if indentation.Left != 0 {
+		fmt.Fprintf(&b, `\li%d`, rtfTwips(indentation.Left))
+	}
+	if indentation.Right != 0 {
+		fmt.Fprintf(&b, `\ri%d`, rtfTwips(indentation.Right))
+	}
+	if indentation.Hanging > 0 {
+		fmt.Fprintf(&b, `\fi-%d`, rtfTwips(indentation.Hanging))
+	} else if indentation.First != 0 {
+		fmt.Fprintf(&b, `\fi%d`, rtfTwips(indentation.First))
+	}
+	if spacing.Before != 0 {
+		fmt.Fprintf(&b, `\sb%d`, rtfTwips(spacing.Before))
+	}
+	if spacing.After != 0 {
+		fmt.Fprintf(&b, `\sa%d`, rtfTwips(spacing.After))
+	}
+	if spacing.Line > 0 {
+		fmt.Fprintf(&b, `\sl%d\slmult1`, int(math.Round(spacing.Line*240)))
+	}
+	return b.String()
+}
+
+// fontFormat 返回字体的控制字
+func (rw *rtfWriter) fontFormat(font types.Font) string {
+	var b strings.Builder
+	if index, ok := rw.fontIndex[font.Name]; ok {
+		fmt.Fprintf(&b, `\f%d`, index)
+	}
+	if font.Size > 0 {
+		fmt.Fprintf(&b, `\fs%d`, int(math.Round(font.Size*2)))
+	}
+	if font.Bold {
+		b.WriteString(`\b`)
+	}
+	if font.Italic {
+		b.WriteString(`\i`)
+	}
+	b.WriteString(rtfUnderline(font.Underline))
+	if index, ok := rw.colorIndex[strings.ToUpper(font.Color.RGB)]; ok {
+		fmt.Fprintf(&b, `\cf%d`, index)
+	}
+	return b.String()
+}
+
+// runFormat 返回文本运行的字符格式控制字
+func (rw *rtfWriter) runFormat(run types.TextRun) string {
+	font := run.Font
+	font.Bold, font.Italic, font.Underline = run.Bold, run.Italic, run.Underline
+	if run.Size > 0 {
+		font.Size = run.Size
+	}
+	if run.Color.RGB != "" {
+		font.Color = run.Color
+	}
+	format := rw.fontFormat(font)
+	if index, ok := rw.colorIndex[rtfHighlightColor(run.Highlight)]; ok {
+		format += fmt.Sprintf(`\highlight%d`, index)
+	}
+	switch run.Position {
+	case types.PositionSuperscript:
+		format += `\super`
+	case types.PositionSubscript:
+		format += `\sub`
+	}
+	if run.Revision != nil && run.Revision.Type == types.RevisionDelete {
+		format += `\deleted`
+	}
+	return format
+}
+
+// rtfUnderline 返回下划线类型的控制字
+func rtfUnderline(underline types.Underline) string {
+	words := map[types.Underline]string{
+		types.UnderlineSingle: `\ul`, types.UnderlineDouble: `\uldb`, types.UnderlineDotted: `\uld`,
+		types.UnderlineDashed: `\uldash`, "words": `\ulw`, "thick": `\ulth`, "wave": `\ulwave`,
+	}
+	return words[underline]
+}
+
+// rtfEscape 转义文本：\、{、}前加反斜杠，制表符和换行写为控制字，非ASCII字符写为\uN
+func rtfEscape(text string) string {
+	var b strings.Builder
+	for _, c := range text {
+		switch {
+		case c == '\\' || c == '{' || c == '}':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c == '\t':
+			b.WriteString(`\tab `)
+		case c == '\n':
+			b.WriteString(`\line `)
+		case c < 0x20:
+			// 其他控制字符不能出现在RTF文本中
+		case c < 0x80:
+			b.WriteRune(c)
+		default:
+			for _, unit := range utf16.Encode([]rune{c}) {
+				fmt.Fprintf(&b, `\u%d?`, int16(unit))
+			}
+		}
+	}
+	return b.String()
+}
+
+// rtfTwips 将磅转换为缇
+func rtfTwips(points float64) int {
+	return int(math.Round(points * 20))
+}
+
+// rtfDTTMValue 将时间转换为\atndate的DTTM值，是rtfDTTM的逆运算
+func rtfDTTMValue(t time.Time) int32 {
+	if t.IsZero() || t.Year() < 1900 || t.Year() > 1900+0x1FF {
+		return 0
+	}
+	value := uint32(t.Minute()) | uint32(t.Hour())<<6 | uint32(t.Day())<<11 |
+		uint32(t.Month())<<16 | uint32(t.Year()-1900)<<20 | uint32(t.Weekday())<<29
+	return int32(value)
+}
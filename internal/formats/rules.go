@@ -0,0 +1,73 @@
+package formats
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"docs-parser/internal/core/types"
+)
+
+// styleIDFromName 按Word的规则由样式名称生成样式ID：去掉空格，各单词首字母大写
+func styleIDFromName(name string) string {
+	var id strings.Builder
+	for _, word := range strings.Fields(name) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		id.WriteString(string(runes))
+	}
+	return id.String()
+}
+
+// contentFormatRules 按与DOCX相同的方式生成格式规则
+//
+// 字体规则来自字体表（默认12磅黑色），段落规则对应各正文段落，表格规则和页面规则对应各表格和各节。
+func contentFormatRules(doc *types.Document, fonts []string) types.FormatRules {
+	var rules types.FormatRules
+	seen := make(map[string]bool)
+	for _, name := range fonts {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		rules.FontRules = append(rules.FontRules, types.FontRule{
+			ID:    name,
+			Name:  name,
+			Size:  12.0,                       // 默认大小
+			Color: types.Color{RGB: "000000"}, // 默认黑色
+		})
+	}
+
+	for i, para := range doc.Content.Paragraphs {
+		rules.ParagraphRules = append(rules.ParagraphRules, types.ParagraphRule{
+			ID:           fmt.Sprintf("paragraph_%d", i+1),
+			Name:         para.Style.Name,
+			Alignment:    para.Alignment,
+			Indentation:  para.Indentation,
+			Spacing:      para.Spacing,
+			OutlineLevel: para.OutlineLevel,
+			KeepLines:    para.KeepLines,
+			KeepNext:     para.KeepNext,
+			PageBreak:    para.PageBreak,
+		})
+	}
+
+	for _, table := range doc.Content.Tables {
+		rules.TableRules = append(rules.TableRules, types.TableRule{ID: table.ID, Name: table.Style.Name, Width: table.Width, Alignment: table.Alignment})
+	}
+
+	for i, section := range doc.Content.Sections {
+		rules.PageRules = append(rules.PageRules, types.PageRule{
+			ID:             fmt.Sprintf("page_%d", i+1),
+			Name:           section.ID,
+			PageSize:       section.PageSize,
+			PageMargins:    section.PageMargins,
+			HeaderDistance: section.HeaderDistance,
+			FooterDistance: section.FooterDistance,
+			Columns:        section.Columns,
+			PageNumbering:  section.PageNumbering,
+			LineNumbering:  section.LineNumbering,
+		})
+	}
+	return rules
+}
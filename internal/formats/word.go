@@ -1,43 +1,298 @@
 package formats
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/parser"
 	"docs-parser/internal/core/types"
+	"docs-parser/internal/documents"
+	"docs-parser/internal/packaging/sniff"
 )
 
+// ErrEncryptedPackage 设置了密码的OOXML文档（内容加密存放在复合文件的EncryptedPackage流中）
+var ErrEncryptedPackage = errors.New("password-protected Office Open XML documents are not supported")
+
+// sniffedParsers 按内容识别出的格式对应的解析器扩展名
+var sniffedParsers = map[sniff.Format]string{
+	sniff.DOCX: ".docx",
+	sniff.DOTX: ".docx",
+	sniff.DOCM: ".docx",
+	sniff.DOTM: ".docx",
+	sniff.DOC:  ".doc",
+	sniff.RTF:  ".rtf",
+	sniff.WPD:  ".wpd",
+
+	sniff.FlatOPC: ".docx",
+	sniff.WordML:  ".xml",
+	sniff.ODT:     ".odt",
+	sniff.OTT:     ".odt",
+	sniff.HTML:    ".html",
+	sniff.MHTML:   ".mht",
+}
+
 // WordParser 通用Word文档解析器（自动分发到具体格式解析器）
 type WordParser struct {
-	parsers map[string]any // 扩展名到解析器实例
+	parsers map[string]any     // 扩展名到解析器实例
+	cache   *cache.ParseCache // 解析结果缓存，nil表示不缓存
 }
 
 // NewWordParser 创建通用Word解析器
 func NewWordParser() *WordParser {
 	return &WordParser{
 		parsers: map[string]any{
-			".docx": NewDocxParser(),
-			".doc":  NewDocParser(),
-			".rtf":  NewRtfParser(),
-			".wpd":  NewWpdParser(),
-			".dot":  NewLegacyParser(),
-			".dotx": NewLegacyParser(),
+			".docx":  NewDocxParser(),
+			".doc":   NewDocParser(),
+			".rtf":   NewRtfParser(),
+			".wpd":   NewWpdParser(),
+			".dot":   NewDocParser(),
+			".dotx":  NewDocxParser(),
+			".docm":  NewDocxParser(),
+			".dotm":  NewDocxParser(),
+			".xml":   NewWordMLParser(),
+			".odt":   NewOdtParser(),
+			".ott":   NewOdtParser(),
+			".html":  NewHtmlParser(),
+			".htm":   NewHtmlParser(),
+			".mht":   NewHtmlParser(),
+			".mhtml": NewHtmlParser(),
 		},
 	}
 }
 
-// ParseDocument 自动识别并解析Word文档
+// SetCache 设置解析结果缓存，nil表示不缓存
+func (wp *WordParser) SetCache(c *cache.ParseCache) {
+	wp.cache = c
+}
+
+// Cache 返回解析结果缓存
+func (wp *WordParser) Cache() *cache.ParseCache {
+	return wp.cache
+}
+
+// ParseDocument 自动识别并解析Word文档，设置了缓存时内容相同的文件只解析一次
 func (wp *WordParser) ParseDocument(filePath string) (*types.Document, error) {
 	// 检查文件路径是否为空
 	if filePath == "" {
 		return nil, fmt.Errorf("file path is empty")
 	}
 
+	parser, ext, warning, err := wp.resolveParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不同格式使用不同的解析器，解析器对应的扩展名作为解析选项参与缓存键
+	// 警告与文件名有关，内容相同的文件共享缓存条目，因此在缓存之外添加
+	doc, err := wp.cache.Parse(filePath, ext, func(filePath string) (*types.Document, error) {
+		return parseWith(parser, filePath, ext)
+	})
+	return withWarning(doc, warning), err
+}
+
+// ParseDocumentContext 受ctx控制的ParseDocument
+//
+// 超时或取消时返回部分结果和*types.TimeoutError，部分结果不写入缓存。
+func (wp *WordParser) ParseDocumentContext(ctx context.Context, filePath string) (*types.Document, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("file path is empty")
+	}
+	if err := types.CheckContext(ctx, "parse document", filePath); err != nil {
+		return nil, err
+	}
+
+	formatParser, ext, warning, err := wp.resolveParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var partial *types.Document
+	doc, err := wp.cache.Parse(filePath, ext, func(filePath string) (*types.Document, error) {
+		doc, err := parseWithContext(ctx, formatParser, filePath, ext)
+		if err != nil {
+			partial = doc
+		}
+		return doc, err
+	})
+	if err != nil {
+		return withWarning(partial, warning), err
+	}
+	return withWarning(doc, warning), nil
+}
+
+// StreamDocumentContext 按事件遍历文档正文，返回不含正文段落、表格和段落规则的文档
+//
+// DOCX等OOXML文档从压缩包中流式读取document.xml，内存占用与正文大小无关，结果不写入缓存；
+// 其他格式先完整解析（使用缓存），再按documents.WalkParsedDocument的顺序发出事件。
+func (wp *WordParser) StreamDocumentContext(ctx context.Context, filePath string, fn func(*documents.StreamEvent) error) (*types.Document, error) {
+	if filePath == "" {
+		return nil, fmt.Errorf("file path is empty")
+	}
+	if err := types.CheckContext(ctx, "parse document", filePath); err != nil {
+		return nil, err
+	}
+
+	formatParser, _, warning, err := wp.resolveParser(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if docx, ok := formatParser.(*DocxParser); ok {
+		doc, err := docx.StreamDocumentContext(ctx, filePath, fn)
+		return withWarning(doc, warning), err
+	}
+
+	doc, err := wp.ParseDocumentContext(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := documents.WalkParsedDocument(doc, fn); err != nil {
+		return nil, err
+	}
+
+	outline := *doc
+	outline.Content.Paragraphs = nil
+	outline.Content.Tables = nil
+	outline.FormatRules.ParagraphRules = nil
+	return &outline, nil
+}
+
+// ParseMetadata 解析文档元数据
+func (wp *WordParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
+	return wp.ParseMetadataContext(context.Background(), filePath)
+}
+
+// ParseContent 解析文档内容
+func (wp *WordParser) ParseContent(filePath string) (*types.DocumentContent, error) {
+	return wp.ParseContentContext(context.Background(), filePath)
+}
+
+// ParseStyles 解析文档样式
+func (wp *WordParser) ParseStyles(filePath string) (*types.DocumentStyles, error) {
+	return wp.ParseStylesContext(context.Background(), filePath)
+}
+
+// ParseFormatRules 解析格式规则
+func (wp *WordParser) ParseFormatRules(filePath string) (*types.FormatRules, error) {
+	return wp.ParseFormatRulesContext(context.Background(), filePath)
+}
+
+// ParseMetadataContext 受ctx控制的ParseMetadata
+func (wp *WordParser) ParseMetadataContext(ctx context.Context, filePath string) (*types.DocumentMetadata, error) {
+	doc, err := wp.ParseDocumentContext(ctx, filePath)
+	if doc == nil {
+		return nil, err
+	}
+	return &doc.Metadata, err
+}
+
+// ParseContentContext 受ctx控制的ParseContent
+func (wp *WordParser) ParseContentContext(ctx context.Context, filePath string) (*types.DocumentContent, error) {
+	doc, err := wp.ParseDocumentContext(ctx, filePath)
+	if doc == nil {
+		return nil, err
+	}
+	return &doc.Content, err
+}
+
+// ParseStylesContext 受ctx控制的ParseStyles
+func (wp *WordParser) ParseStylesContext(ctx context.Context, filePath string) (*types.DocumentStyles, error) {
+	doc, err := wp.ParseDocumentContext(ctx, filePath)
+	if doc == nil {
+		return nil, err
+	}
+	return &doc.Styles, err
+}
+
+// ParseFormatRulesContext 受ctx控制的ParseFormatRules
+func (wp *WordParser) ParseFormatRulesContext(ctx context.Context, filePath string) (*types.FormatRules, error) {
+	doc, err := wp.ParseDocumentContext(ctx, filePath)
+	if doc == nil {
+		return nil, err
+	}
+	return &doc.FormatRules, err
+}
+
+// GetSupportedFormats 获取支持的扩展名
+func (wp *WordParser) GetSupportedFormats() []string {
+	formats := make([]string, 0, len(wp.parsers))
+	for ext := range wp.parsers {
+		formats = append(formats, ext)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ValidateFile 使用文件内容对应的解析器验证文件
+func (wp *WordParser) ValidateFile(filePath string) error {
+	resolved, ext, _, err := wp.resolveParser(filePath)
+	if err != nil {
+		return err
+	}
+	formatParser, ok := resolved.(parser.Parser)
+	if !ok {
+		return fmt.Errorf("unsupported file extension: %s", ext)
+	}
+	return formatParser.ValidateFile(filePath)
+}
+
+// resolveParser 按文件内容选择解析器，返回解析器、解析器对应的扩展名和扩展名与内容不符时的警告
+//
+// 无法读取或识别文件内容时按扩展名选择，由具体解析器报告错误。
+func (wp *WordParser) resolveParser(filePath string) (any, string, string, error) {
+	if result, err := sniff.File(filePath); err == nil {
+		switch result.Format {
+		case sniff.Unknown:
+		case sniff.EncryptedOOXML:
+			return nil, "", "", ErrEncryptedPackage
+		default:
+			ext, ok := sniffedParsers[result.Format]
+			if !ok {
+				return nil, "", "", fmt.Errorf("unsupported file format: %s (%s)", result.Format, result.Detail)
+			}
+			return wp.parsers[ext], ext, result.Warning, nil
+		}
+	}
+
 	ext := strings.ToLower(getFileExt(filePath))
-	parser, ok := wp.parsers[ext]
+	formatParser, ok := wp.parsers[ext]
 	if !ok {
-		return nil, fmt.Errorf("unsupported file extension: %s", ext)
+		return nil, "", "", fmt.Errorf("unsupported file extension: %s", ext)
 	}
+	return formatParser, ext, "", nil
+}
+
+// withWarning 返回在元数据中记录了扩展名与内容不符警告的文档
+//
+// doc可能是缓存中共享的文档，警告记录在浅拷贝上，Warnings使用新的切片。
+func withWarning(doc *types.Document, warning string) *types.Document {
+	if doc == nil || warning == "" {
+		return doc
+	}
+	copied := *doc
+	copied.Metadata.Warnings = append(append([]string(nil), doc.Metadata.Warnings...), warning)
+	return &copied
+}
+
+// parseWithContext 使用指定解析器在ctx控制下解析文档
+//
+// DOCX的取消会传递到XML解码循环，其他格式的解析器在ctx结束时被放弃。
+func parseWithContext(ctx context.Context, formatParser any, filePath, ext string) (*types.Document, error) {
+	switch p := formatParser.(type) {
+	case *DocxParser:
+		return p.ParseDocumentContext(ctx, filePath)
+	case parser.Parser:
+		return parser.WithContext(p).ParseDocumentContext(ctx, filePath)
+	default:
+		return nil, fmt.Errorf("unknown parser type for extension: %s", ext)
+	}
+}
+
+// parseWith 使用指定解析器解析文档
+func parseWith(parser any, filePath, ext string) (*types.Document, error) {
 	switch p := parser.(type) {
 	case *DocxParser:
 		return p.ParseDocument(filePath)
@@ -47,6 +302,12 @@ func (wp *WordParser) ParseDocument(filePath string) (*types.Document, error) {
 		return p.ParseDocument(filePath)
 	case *WpdParser:
 		return p.ParseDocument(filePath)
+	case *WordMLParser:
+		return p.ParseDocument(filePath)
+	case *OdtParser:
+		return p.ParseDocument(filePath)
+	case *HtmlParser:
+		return p.ParseDocument(filePath)
 	case *LegacyParser:
 		return p.ParseDocument(filePath)
 	default:
@@ -0,0 +1,111 @@
+package formats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/packaging/sniff"
+)
+
+// WordMLParser Word 2003 XML（.xml）格式解析器
+type WordMLParser struct{}
+
+// NewWordMLParser 创建Word 2003 XML解析器
+func NewWordMLParser() *WordMLParser {
+	return &WordMLParser{}
+}
+
+// ParseDocument 解析Word 2003 XML文档
+func (wp *WordMLParser) ParseDocument(filePath string) (*types.Document, error) {
+	if err := wp.ValidateFile(filePath); err != nil {
+		return nil, err
+	}
+	return wp.parse(filePath)
+}
+
+// ParseMetadata 解析元数据
+func (wp *WordMLParser) ParseMetadata(filePath string) (*types.DocumentMetadata, error) {
+	doc, err := wp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Metadata, nil
+}
+
+// ParseContent 解析内容
+func (wp *WordMLParser) ParseContent(filePath string) (*types.DocumentContent, error) {
+	doc, err := wp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Content, nil
+}
+
+// ParseStyles 解析样式
+func (wp *WordMLParser) ParseStyles(filePath string) (*types.DocumentStyles, error) {
+	doc, err := wp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Styles, nil
+}
+
+// ParseFormatRules 解析格式规则
+func (wp *WordMLParser) ParseFormatRules(filePath string) (*types.FormatRules, error) {
+	doc, err := wp.parse(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.FormatRules, nil
+}
+
+// GetSupportedFormats 获取支持的格式
+func (wp *WordMLParser) GetSupportedFormats() []string {
+	return []string{"xml"}
+}
+
+// ValidateFile 验证文件格式
+//
+// 按内容识别格式，内容有效时不要求扩展名匹配；内容无效且扩展名也不是.xml时返回ErrUnsupportedFormat。
+func (wp *WordMLParser) ValidateFile(filePath string) error {
+	// 检查文件是否存在
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return parser.ErrFileNotFound
+	}
+
+	err := wp.validateContent(filePath)
+	if err != nil && strings.ToLower(filepath.Ext(filePath)) != ".xml" {
+		return parser.ErrUnsupportedFormat
+	}
+	return err
+}
+
+// validateContent 检查根元素是否为w:wordDocument
+func (wp *WordMLParser) validateContent(filePath string) error {
+	result, err := sniff.File(filePath)
+	if err != nil || result.Format != sniff.WordML {
+		return parser.ErrInvalidFile
+	}
+	return nil
+}
+
+// parse 读取并转换整个Word 2003 XML文件
+func (wp *WordMLParser) parse(filePath string) (*types.Document, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, parser.ErrFileNotFound
+		}
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	doc, err := readWordML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", parser.ErrInvalidFile, err)
+	}
+	return doc, nil
+}
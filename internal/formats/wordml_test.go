@@ -0,0 +1,219 @@
+package formats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/core/types"
+)
+
+// testWordML Word 2003 XML文档：继承的段落样式、编号和项目符号列表、合并单元格、节属性和页眉
+const testWordML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<?mso-application progid="Word.Document"?>
+<w:wordDocument xmlns:w="http://schemas.microsoft.com/office/word/2003/wordml"
+    xmlns:wx="http://schemas.microsoft.com/office/word/2003/auxHint"
+    xmlns:o="urn:schemas-microsoft-com:office:office"
+    xmlns:aml="http://schemas.microsoft.com/aml/2001/core">
+  <o:DocumentProperties>
+    <o:Title>年度报告</o:Title>
+    <o:Author>张三</o:Author>
+    <o:Revision>3</o:Revision>
+    <o:Created>2024-03-01T08:00:00Z</o:Created>
+  </o:DocumentProperties>
+  <w:fonts>
+    <w:defaultFonts w:ascii="Times New Roman" w:fareast="宋体"/>
+    <w:font w:name="Arial"/>
+  </w:fonts>
+  <w:lists>
+    <w:listDef w:listDefId="0">
+      <w:lvl w:ilvl="0"><w:start w:val="1"/><w:nfc w:val="0"/><w:lvlText w:val="%1."/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl>
+      <w:lvl w:ilvl="1"><w:start w:val="1"/><w:nfc w:val="4"/><w:lvlText w:val="%1.%2)"/></w:lvl>
+    </w:listDef>
+    <w:listDef w:listDefId="1">
+      <w:lvl w:ilvl="0"><w:nfc w:val="23"/><w:lvlText w:val="&#xF0B7;"/></w:lvl>
+    </w:listDef>
+    <w:list w:ilfo="1"><w:ilst w:val="0"/></w:list>
+    <w:list w:ilfo="2"><w:ilst w:val="1"/></w:list>
+  </w:lists>
+  <w:styles>
+    <w:style w:type="paragraph" w:default="on" w:styleId="Normal">
+      <w:name w:val="Normal"/>
+      <w:rPr><w:sz w:val="24"/></w:rPr>
+    </w:style>
+    <w:style w:type="paragraph" w:styleId="Heading1">
+      <w:name w:val="heading 1"/>
+      <w:basedOn w:val="Normal"/>
+      <w:next w:val="Normal"/>
+      <w:pPr><w:keepNext/><w:spacing w:before="240" w:after="60"/><w:outlineLvl w:val="0"/></w:pPr>
+      <w:rPr><w:rFonts w:ascii="Arial" w:h-ansi="Arial"/><w:b/><w:sz w:val="32"/></w:rPr>
+    </w:style>
+    <w:style w:type="character" w:styleId="Emphasis">
+      <w:name w:val="Emphasis"/>
+      <w:rPr><w:i/></w:rPr>
+    </w:style>
+  </w:styles>
+  <w:body>
+    <wx:sect>
+      <w:p>
+        <w:pPr><w:pStyle w:val="Heading1"/><w:jc w:val="center"/></w:pPr>
+        <aml:annotation aml:id="0" w:type="Word.Bookmark.Start" w:name="intro"/>
+        <w:r><w:t>Overview</w:t></w:r>
+      </w:p>
+      <w:p>
+        <w:r><w:t>普通</w:t></w:r>
+        <w:r><w:rPr><w:rStyle w:val="Emphasis"/><w:color w:val="ff0000"/></w:rPr><w:t>强调</w:t></w:r>
+        <aml:annotation w:type="Word.Deletion"><aml:content><w:r><w:t>已删除</w:t></w:r></aml:content></aml:annotation>
+      </w:p>
+      <w:p><w:pPr><w:listPr><w:ilvl w:val="0"/><w:ilfo w:val="1"/></w:listPr></w:pPr><w:r><w:t>第一项</w:t></w:r></w:p>
+      <w:p><w:pPr><w:listPr><w:ilvl w:val="1"/><w:ilfo w:val="1"/></w:listPr></w:pPr><w:r><w:t>子项</w:t></w:r></w:p>
+      <w:p><w:pPr><w:listPr><w:ilvl w:val="0"/><w:ilfo w:val="1"/></w:listPr></w:pPr><w:r><w:t>第二项</w:t></w:r></w:p>
+      <w:p><w:pPr><w:listPr><w:ilvl w:val="0"/><w:ilfo w:val="2"/></w:listPr></w:pPr><w:r><w:t>要点</w:t></w:r><w:r><w:br w:type="page"/></w:r></w:p>
+      <w:tbl>
+        <w:tblPr><w:tblW w:w="7200" w:type="dxa"/><w:tblBorders><w:top w:val="single" w:sz="8" w:color="000000"/></w:tblBorders></w:tblPr>
+        <w:tr>
+          <w:trPr><w:tblHeader/></w:trPr>
+          <w:tc><w:tcPr><w:tcW w:w="4800" w:type="dxa"/><w:gridSpan w:val="2"/></w:tcPr><w:p><w:r><w:t>标题</w:t></w:r></w:p></w:tc>
+          <w:tc><w:tcPr><w:tcW w:w="2400" w:type="dxa"/><w:vmerge w:val="restart"/><w:vAlign w:val="center"/></w:tcPr><w:p><w:r><w:t>合并</w:t></w:r></w:p></w:tc>
+        </w:tr>
+        <w:tr>
+          <w:tc><w:tcPr><w:tcW w:w="2400" w:type="dxa"/></w:tcPr><w:p><w:r><w:t>甲</w:t></w:r></w:p></w:tc>
+          <w:tc><w:tcPr><w:tcW w:w="2400" w:type="dxa"/></w:tcPr><w:p><w:r><w:t>乙</w:t></w:r></w:p></w:tc>
+          <w:tc><w:tcPr><w:tcW w:w="2400" w:type="dxa"/><w:vmerge/></w:tcPr><w:p/></w:tc>
+        </w:tr>
+      </w:tbl>
+      <w:sectPr>
+        <w:hdr w:type="odd"><w:p><w:r><w:t>页眉文字</w:t></w:r></w:p></w:hdr>
+        <w:ftr w:type="odd"><w:p/></w:ftr>
+        <w:pgSz w:w="11906" w:h="16838"/>
+        <w:pgMar w:top="1440" w:right="1800" w:bottom="1440" w:left="1800" w:header="851" w:footer="992"/>
+        <w:cols w:space="425"/>
+      </w:sectPr>
+    </wx:sect>
+  </w:body>
+</w:wordDocument>`
+
+// TestWordMLParser_Document 测试Word 2003 XML的样式继承、列表编号、表格和节属性
+func TestWordMLParser_Document(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := os.WriteFile(path, []byte(testWordML), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	doc, err := NewWordParser().ParseDocument(path)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if doc.Metadata.Title != "年度报告" || doc.Metadata.Author != "张三" || doc.Metadata.Revision != 3 || doc.Metadata.Created.Year() != 2024 {
+		t.Errorf("文档属性不正确: %+v", doc.Metadata)
+	}
+	if len(doc.Metadata.Warnings) != 0 {
+		t.Errorf("不应有扩展名警告，实际 %q", doc.Metadata.Warnings)
+	}
+
+	paragraphs := doc.Content.Paragraphs
+	if len(paragraphs) != 6 {
+		t.Fatalf("应有6个段落，实际 %d", len(paragraphs))
+	}
+	heading := paragraphs[0]
+	if heading.Style.Name != "Heading1" || heading.OutlineLevel != 1 || !heading.KeepNext || heading.Alignment != types.AlignCenter || heading.Spacing.Before != 12 {
+		t.Errorf("标题段落格式不正确: %+v", heading)
+	}
+	if run := heading.Runs[0]; run.Font.Name != "Arial" || run.Size != 16 || !run.Bold {
+		t.Errorf("标题应继承样式的字体、字号和加粗，实际 %+v", run.Font)
+	}
+
+	body := paragraphs[1]
+	if body.Style.Name != "" || body.Text != "普通强调" || len(body.Runs) != 2 {
+		t.Errorf("正文段落不正确，删除的修订不应计入: %q %+v", body.Text, body.Runs)
+	}
+	if run := body.Runs[0]; run.Size != 12 || run.Font.Name != "宋体" {
+		t.Errorf("正文应使用默认样式的字号和东亚字体，实际 %+v", run.Font)
+	}
+	if run := body.Runs[1]; !run.Italic || run.Color.RGB != "FF0000" {
+		t.Errorf("字符样式和颜色不正确: %+v", run)
+	}
+
+	labels := []string{"1.", "1.a)", "2.", "•"}
+	for i, label := range labels {
+		list := paragraphs[i+2].List
+		if list == nil || list.Label != label {
+			t.Errorf("段落%d编号应为 %q，实际 %+v", i+3, label, list)
+		}
+	}
+	if first := paragraphs[2]; first.Indentation.Left != 36 || first.Indentation.Hanging != 18 || first.List.Format != "decimal" {
+		t.Errorf("列表级别的缩进和格式不正确: %+v %+v", first.Indentation, first.List)
+	}
+	if !paragraphs[5].PageBreak || paragraphs[5].List.Format != "bullet" {
+		t.Errorf("项目符号段落应包含分页符: %+v", paragraphs[5])
+	}
+
+	if len(doc.Content.Bookmarks) != 1 || doc.Content.Bookmarks[0].Name != "intro" {
+		t.Errorf("书签不正确: %+v", doc.Content.Bookmarks)
+	}
+
+	if len(doc.Content.Tables) != 1 {
+		t.Fatalf("应有1个表格，实际 %d", len(doc.Content.Tables))
+	}
+	table := doc.Content.Tables[0]
+	if table.Width != 360 || table.Borders.Top.Width != 1 || len(table.Rows) != 2 || !table.Rows[0].Header {
+		t.Errorf("表格属性不正确: %+v", table)
+	}
+	if cell := table.Rows[0].Cells[0]; cell.Merge.Horizontal != 2 || cell.Content[0].Text != "标题" {
+		t.Errorf("横向合并不正确: %+v", cell)
+	}
+	if cell := table.Rows[0].Cells[1]; cell.Merge.Vertical != 2 || cell.VerticalAlignment != types.VAlignCenter {
+		t.Errorf("纵向合并应跨2行: %+v", cell)
+	}
+
+	if len(doc.Content.Sections) != 1 {
+		t.Fatalf("应有1个节，实际 %d", len(doc.Content.Sections))
+	}
+	section := doc.Content.Sections[0]
+	if section.PageSize.Width != 595.3 || section.PageMargins.Left != 90 || section.PageMargins.Header != 42.55 {
+		t.Errorf("页面设置不正确: %+v %+v", section.PageSize, section.PageMargins)
+	}
+	if len(doc.Content.Headers) != 1 || doc.Content.Headers[0].Content[0].Text != "页眉文字" || len(doc.Content.Footers) != 0 {
+		t.Errorf("应只有非空的页眉: %+v %+v", doc.Content.Headers, doc.Content.Footers)
+	}
+
+	if len(doc.Styles.ParagraphStyles) != 2 || len(doc.Styles.CharacterStyles) != 1 {
+		t.Errorf("样式表不正确: %+v", doc.Styles)
+	}
+	if rules := doc.FormatRules.StyleRules; len(rules) != 3 || rules[1].BasedOn != "Normal" || rules[1].Next != "Normal" {
+		t.Errorf("样式规则不正确: %+v", rules)
+	}
+}
+
+// TestWordMLParser_ValidateFile 测试根元素不是w:wordDocument的XML文件被拒绝
+func TestWordMLParser_ValidateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feed.xml")
+	if err := os.WriteFile(path, []byte(`<?xml version="1.0"?><rss version="2.0"/>`), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := NewWordMLParser().ValidateFile(path); err == nil {
+		t.Error("非Word 2003 XML文件应验证失败")
+	}
+	if _, err := NewWordParser().ParseDocument(path); err == nil {
+		t.Error("通用解析器不应解析其他XML文件")
+	}
+}
+
+// TestWordMLParser_Minimal 测试没有字体表、列表、样式表和节属性的文档
+func TestWordMLParser_Minimal(t *testing.T) {
+	for name, content := range map[string]string{
+		"只有正文": `<w:wordDocument xmlns:w="http://schemas.microsoft.com/office/word/2003/wordml"><w:body><w:p><w:r><w:t>正文</w:t></w:r></w:p></w:body></w:wordDocument>`,
+		"空文档":  `<w:wordDocument xmlns:w="http://schemas.microsoft.com/office/word/2003/wordml"/>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc, err := readWordML([]byte(content))
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if len(doc.Content.Sections) != 1 {
+				t.Errorf("没有节属性时应使用默认节，实际 %d 个节", len(doc.Content.Sections))
+			}
+		})
+	}
+}
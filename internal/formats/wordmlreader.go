@@ -0,0 +1,1153 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"docs-parser/internal/core/types"
+)
+
+// Word 2003 XML（WordprocessingML 2003）的读取
+//
+// 根元素为w:wordDocument：文档属性在o:DocumentProperties中，字体表、列表定义和样式表分别在w:fonts、w:lists和w:styles中，
+// 正文在w:body中，由wx:sect和wx:sub-section分组。长度与OOXML一样以缇（1/20磅）为单位，但部分元素和属性名不同
+// （w:first-line、w:vmerge、w:listPr、wx:font），布尔属性的值为on/off。
+
+// errWordMLRoot 根元素不是w:wordDocument
+var errWordMLRoot = errors.New("root element is not w:wordDocument")
+
+// wmlNode Word 2003 XML中的元素，属性按本地名称索引
+type wmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*wmlNode
+	text     strings.Builder
+}
+
+// readWMLTree 将XML解析为保留元素顺序的元素树
+func readWMLTree(r io.Reader) (*wmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = xmlCharsetReader
+	var root *wmlNode
+	var stack []*wmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &wmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" {
+					node.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty XML document")
+	}
+	return root, nil
+}
+
+// child 返回第一个名为name的子元素，不存在时返回nil
+func (n *wmlNode) child(name string) *wmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// elements 返回子元素，元素不存在时返回nil
+func (n *wmlNode) elements() []*wmlNode {
+	if n == nil {
+		return nil
+	}
+	return n.children
+}
+
+// attr 返回属性值，元素不存在时返回空字符串
+func (n *wmlNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.attrs[name]
+}
+
+// val 返回子元素name的val属性
+func (n *wmlNode) val(name string) string {
+	return n.child(name).attr("val")
+}
+
+// flag 读取子元素name表示的布尔属性，第二个返回值表示是否设置了该属性
+func (n *wmlNode) flag(name string) (bool, bool) {
+	c := n.child(name)
+	if c == nil {
+		return false, false
+	}
+	switch c.attrs["val"] {
+	case "off", "false", "0", "none":
+		return false, true
+	}
+	return true, true
+}
+
+// twips 读取以缇为单位的属性并转换为磅
+func (n *wmlNode) twips(name string) (float64, bool) {
+	value, err := strconv.ParseFloat(n.attr(name), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value / 20.0, true
+}
+
+// number 读取整数属性
+func (n *wmlNode) number(name string) (int, bool) {
+	value, err := strconv.Atoi(n.attr(name))
+	return value, err == nil
+}
+
+// wmlParaProps 段落属性
+type wmlParaProps struct {
+	alignment   types.Alignment
+	indentation types.Indentation
+	spacing     types.Spacing
+	keepNext    bool
+	keepLines   bool
+	pageBreak   bool
+	outline     int // 大纲级别从1开始，0表示正文
+	listID      string
+	listLevel   int
+	listLabel   string // wx:t给出的编号文本
+}
+
+// apply 应用w:pPr中的段落属性
+func (p *wmlParaProps) apply(pPr *wmlNode) {
+	if pPr == nil {
+		return
+	}
+	if jc := pPr.val("jc"); jc != "" {
+		p.alignment = wmlAlignment(jc)
+	}
+	if ind := pPr.child("ind"); ind != nil {
+		if v, ok := ind.twips("left"); ok {
+			p.indentation.Left = v
+		}
+		if v, ok := ind.twips("right"); ok {
+			p.indentation.Right = v
+		}
+		if v, ok := ind.twips("first-line"); ok {
+			p.indentation.First = v
+		}
+		if v, ok := ind.twips("hanging"); ok {
+			p.indentation.Hanging = v
+		}
+	}
+	if spacing := pPr.child("spacing"); spacing != nil {
+		if v, ok := spacing.twips("before"); ok {
+			p.spacing.Before = v
+		}
+		if v, ok := spacing.twips("after"); ok {
+			p.spacing.After = v
+		}
+		if v, err := strconv.ParseFloat(spacing.attr("line"), 64); err == nil {
+			p.spacing.Line = v / 240.0 // 与DOCX一致转换为倍数
+		}
+	}
+	if v, ok := pPr.flag("keepNext"); ok {
+		p.keepNext = v
+	}
+	if v, ok := pPr.flag("keepLines"); ok {
+		p.keepLines = v
+	}
+	if v, ok := pPr.flag("pageBreakBefore"); ok {
+		p.pageBreak = v
+	}
+	if level, err := strconv.Atoi(pPr.val("outlineLvl")); err == nil && level >= 0 && level < 9 {
+		p.outline = level + 1
+	}
+	if listPr := pPr.child("listPr"); listPr != nil {
+		if ilfo := listPr.val("ilfo"); ilfo != "" {
+			p.listID = ilfo
+		}
+		if level, err := strconv.Atoi(listPr.val("ilvl")); err == nil {
+			p.listLevel = level
+		}
+		if label := listPr.val("t"); label != "" {
+			p.listLabel = label
+		}
+	}
+}
+
+// wmlAlignment 将jc值转换为对齐方式
+func wmlAlignment(jc string) types.Alignment {
+	switch jc {
+	case "center":
+		return types.AlignCenter
+	case "right":
+		return types.AlignRight
+	case "both", "distribute":
+		return types.AlignJustify
+	}
+	return types.AlignLeft
+}
+
+// wmlCharProps 字符属性
+type wmlCharProps struct {
+	font      string
+	eastAsia  string
+	size      float64
+	color     string
+	bold      bool
+	italic    bool
+	underline types.Underline
+	highlight types.Highlight
+	position  types.Position
+}
+
+// apply 应用w:rPr中的字符属性，wx:font为Word解析出的实际字体，优先于w:rFonts
+func (c *wmlCharProps) apply(rPr *wmlNode) {
+	if rPr == nil {
+		return
+	}
+	if fonts := rPr.child("rFonts"); fonts != nil {
+		if name := fonts.attr("ascii"); name != "" {
+			c.font = name
+		} else if name := fonts.attr("h-ansi"); name != "" {
+			c.font = name
+		}
+		if name := fonts.attr("fareast"); name != "" {
+			c.eastAsia = name
+		}
+	}
+	if name := rPr.val("font"); name != "" {
+		c.font = name
+	}
+	if v, ok := rPr.flag("b"); ok {
+		c.bold = v
+	}
+	if v, ok := rPr.flag("i"); ok {
+		c.italic = v
+	}
+	if u := rPr.child("u"); u != nil {
+		c.underline = wmlUnderline(u.attr("val"))
+	}
+	if color := rPr.val("color"); color != "" {
+		if color == "auto" {
+			color = ""
+		}
+		c.color = strings.ToUpper(color)
+	}
+	if highlight := rPr.val("highlight"); highlight != "" {
+		c.highlight = types.Highlight(highlight)
+	}
+	if halfPoints, err := strconv.ParseFloat(rPr.val("sz"), 64); err == nil {
+		c.size = halfPoints / 2.0
+	}
+	switch rPr.val("vertAlign") {
+	case "superscript":
+		c.position = types.PositionSuperscript
+	case "subscript":
+		c.position = types.PositionSubscript
+	case "baseline":
+		c.position = ""
+	}
+}
+
+// wmlUnderline 将下划线类型转换为与OOXML相同的名称
+func wmlUnderline(val string) types.Underline {
+	switch val {
+	case "none":
+		return types.UnderlineNone
+	case "double":
+		return types.UnderlineDouble
+	case "dotted":
+		return types.UnderlineDotted
+	case "dash", "dashed", "dash-long", "dot-dash", "dot-dot-dash":
+		return types.UnderlineDashed
+	}
+	return types.UnderlineSingle
+}
+
+// wmlStyle w:styles中的样式
+type wmlStyle struct {
+	id        string
+	name      string
+	kind      string // paragraph、character、table或list
+	basedOn   string
+	next      string
+	hidden    bool
+	isDefault bool
+	pPr       *wmlNode
+	rPr       *wmlNode
+}
+
+// wmlListFormats w:nfc编号格式对应的OOXML名称
+var wmlListFormats = map[int]string{
+	0: "decimal", 1: "upperRoman", 2: "lowerRoman", 3: "upperLetter", 4: "lowerLetter",
+	5: "ordinal", 22: "decimalZero", 23: "bullet", 255: "none",
+}
+
+// wmlListLevel 列表定义中的一级
+type wmlListLevel struct {
+	start  int
+	format string
+	text   string
+	pPr    *wmlNode
+}
+
+// wmlList 编号实例（w:list）及其计数
+type wmlList struct {
+	levels   [9]*wmlListLevel
+	counters [9]int
+	started  [9]bool
+}
+
+// wordML 读取后的Word 2003 XML文档
+type wordML struct {
+	root         *wmlNode
+	styles       map[string]*wmlStyle
+	styleOrder   []*wmlStyle
+	defaultStyle string // 默认段落样式
+	defaults     wmlCharProps
+	fonts        []string
+	lists        map[string]*wmlList
+	bookmarks    []types.Bookmark
+	images       []types.Image
+}
+
+// readWordML 读取Word 2003 XML文档
+func readWordML(data []byte) (*types.Document, error) {
+	root, err := readWMLTree(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if root.name != "wordDocument" {
+		return nil, errWordMLRoot
+	}
+
+	wm := &wordML{root: root, styles: make(map[string]*wmlStyle), lists: make(map[string]*wmlList)}
+	wm.defaults = wmlCharProps{size: 10}
+	wm.readFonts()
+	wm.readStyles()
+	wm.readLists()
+	return wm.document(int64(len(data))), nil
+}
+
+// readFonts 读取字体表和默认字体
+func (wm *wordML) readFonts() {
+	fonts := wm.root.child("fonts")
+	if defaults := fonts.child("defaultFonts"); defaults != nil {
+		wm.defaults.font = defaults.attr("ascii")
+		wm.defaults.eastAsia = defaults.attr("fareast")
+	}
+	for _, font := range fonts.elements() {
+		if font.name == "font" && font.attr("name") != "" {
+			wm.fonts = append(wm.fonts, font.attr("name"))
+		}
+	}
+}
+
+// readStyles 读取样式表
+func (wm *wordML) readStyles() {
+	for _, node := range wm.root.child("styles").elements() {
+		if node.name != "style" || node.attr("styleId") == "" {
+			continue
+		}
+		style := &wmlStyle{
+			id:        node.attr("styleId"),
+			name:      node.val("name"),
+			kind:      node.attr("type"),
+			basedOn:   node.val("basedOn"),
+			next:      node.val("next"),
+			isDefault: node.attr("default") == "on",
+			pPr:       node.child("pPr"),
+			rPr:       node.child("rPr"),
+		}
+		style.hidden, _ = node.flag("hidden")
+		if style.name == "" {
+			style.name = style.id
+		}
+		if style.kind == "paragraph" && style.isDefault {
+			wm.defaultStyle = style.id
+		}
+		wm.styles[style.id] = style
+		wm.styleOrder = append(wm.styleOrder, style)
+	}
+}
+
+// readLists 读取列表定义（w:listDef）和引用列表定义的编号实例（w:list）
+func (wm *wordML) readLists() {
+	lists := wm.root.child("lists")
+	definitions := make(map[string][9]*wmlListLevel)
+	for _, def := range lists.elements() {
+		if def.name != "listDef" {
+			continue
+		}
+		var levels [9]*wmlListLevel
+		for _, lvl := range def.children {
+			index, ok := lvl.number("ilvl")
+			if lvl.name != "lvl" || !ok || index < 0 || index >= 9 {
+				continue
+			}
+			level := &wmlListLevel{start: 1, format: "decimal", text: lvl.val("lvlText"), pPr: lvl.child("pPr")}
+			if start, err := strconv.Atoi(lvl.val("start")); err == nil {
+				level.start = start
+			}
+			if nfc, err := strconv.Atoi(lvl.val("nfc")); err == nil {
+				if format, ok := wmlListFormats[nfc]; ok {
+					level.format = format
+				}
+			}
+			levels[index] = level
+		}
+		definitions[def.attr("listDefId")] = levels
+	}
+
+	for _, node := range lists.elements() {
+		if node.name != "list" || node.attr("ilfo") == "" {
+			continue
+		}
+		list := &wmlList{levels: definitions[node.val("ilst")]}
+		// w:lvlOverride可以修改某一级的起始编号
+		for _, override := range node.children {
+			index, ok := override.number("ilvl")
+			if override.name != "lvlOverride" || !ok || index < 0 || index >= 9 || list.levels[index] == nil {
+				continue
+			}
+			if start, err := strconv.Atoi(override.val("startOverride")); err == nil {
+				level := *list.levels[index]
+				level.start = start
+				list.levels[index] = &level
+			}
+		}
+		wm.lists[node.attr("ilfo")] = list
+	}
+}
+
+// styleChain 返回从最底层基础样式到id的样式链，循环的继承在重复处截断
+func (wm *wordML) styleChain(id string) []*wmlStyle {
+	var chain []*wmlStyle
+	seen := make(map[string]bool)
+	for style := wm.styles[id]; style != nil && !seen[style.id]; style = wm.styles[style.basedOn] {
+		seen[style.id] = true
+		chain = append([]*wmlStyle{style}, chain...)
+	}
+	return chain
+}
+
+// styleProps 返回样式链合并后的段落和字符属性
+func (wm *wordML) styleProps(id string) (wmlParaProps, wmlCharProps) {
+	var para wmlParaProps
+	chars := wm.defaults
+	for _, style := range wm.styleChain(id) {
+		para.apply(style.pPr)
+		chars.apply(style.rPr)
+	}
+	return para, chars
+}
+
+// paragraphStyle 返回段落使用的样式ID，未指定时为默认段落样式
+func (wm *wordML) paragraphStyle(p *wmlNode) string {
+	if id := p.child("pPr").val("pStyle"); id != "" {
+		return id
+	}
+	return wm.defaultStyle
+}
+
+// paragraphProps 返回段落属性：样式、列表级别和直接格式依次覆盖，列表项的编号文本在这里计算
+func (wm *wordML) paragraphProps(p *wmlNode) (wmlParaProps, wmlCharProps) {
+	styleID := wm.paragraphStyle(p)
+	pPr := p.child("pPr")
+	props, chars := wm.styleProps(styleID)
+	props.apply(pPr)
+
+	list := wm.lists[props.listID]
+	if list == nil || props.listLevel < 0 || props.listLevel >= 9 || list.levels[props.listLevel] == nil {
+		props.listID = ""
+		return props, chars
+	}
+	label := props.listLabel
+	listID, listLevel := props.listID, props.listLevel
+	level := list.levels[listLevel]
+
+	// 样式 < 列表级别 < 直接格式
+	props, _ = wm.styleProps(styleID)
+	props.apply(level.pPr)
+	props.apply(pPr)
+	props.listID, props.listLevel = listID, listLevel
+	props.listLabel = label
+	if label == "" {
+		props.listLabel = list.next(listLevel)
+	} else {
+		list.next(listLevel)
+	}
+	return props, chars
+}
+
+// next 为级别level增加计数并返回编号文本，更深的级别重新开始计数
+func (l *wmlList) next(level int) string {
+	if !l.started[level] {
+		l.counters[level] = l.levels[level].start - 1
+		l.started[level] = true
+	}
+	l.counters[level]++
+	for deeper := level + 1; deeper < 9; deeper++ {
+		l.started[deeper] = false
+	}
+
+	lvl := l.levels[level]
+	switch lvl.format {
+	case "bullet":
+		return wmlBullet(lvl.text)
+	case "none":
+		return ""
+	}
+	text := lvl.text
+	for i := 0; i <= level; i++ {
+		placeholder := "%" + strconv.Itoa(i+1)
+		if !strings.Contains(text, placeholder) {
+			continue
+		}
+		format := "decimal"
+		if l.levels[i] != nil {
+			format = l.levels[i].format
+		}
+		text = strings.ReplaceAll(text, placeholder, listNumber(format, l.counters[i]))
+	}
+	return text
+}
+
+// wmlBullet 将Symbol和Wingdings字体的私用区项目符号转换为对应的Unicode字符
+func wmlBullet(text string) string {
+	symbols := map[rune]rune{0xF0B7: '•', 0xF0A7: '▪', 0xF06F: 'o', 0xF0D8: '➢', 0xF0FC: '✓', 0xF06E: '■', 0xF075: '◆'}
+	return strings.Map(func(r rune) rune {
+		if symbol, ok := symbols[r]; ok {
+			return symbol
+		}
+		return r
+	}, text)
+}
+
+// listNumber 按编号格式输出编号
+func listNumber(format string, n int) string {
+	switch format {
+	case "upperRoman":
+		return strings.ToUpper(romanNumber(n))
+	case "lowerRoman":
+		return romanNumber(n)
+	case "upperLetter":
+		return strings.ToUpper(letterNumber(n))
+	case "lowerLetter":
+		return letterNumber(n)
+	case "decimalZero":
+		return fmt.Sprintf("%02d", n)
+	}
+	return strconv.Itoa(n)
+}
+
+// romanNumber 返回小写罗马数字，非正数按十进制输出
+func romanNumber(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+	var roman strings.Builder
+	for i, value := range values {
+		for n >= value {
+			roman.WriteString(symbols[i])
+			n -= value
+		}
+	}
+	return roman.String()
+}
+
+// letterNumber 返回小写字母编号：a-z，之后为aa、bb……
+func letterNumber(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	letter := string(rune('a' + (n-1)%26))
+	return strings.Repeat(letter, (n-1)/26+1)
+}
+
+// document 将读取的结构转换为与DOCX解析结果相同的文档
+func (wm *wordML) document(fileSize int64) *types.Document {
+	doc := &types.Document{
+		Metadata: wm.metadata(fileSize),
+		Content:  wm.content(),
+		Styles:   wm.documentStyles(),
+	}
+	if doc.Metadata.WordCount == 0 {
+		for _, paragraph := range doc.Content.Paragraphs {
+			doc.Metadata.WordCount += len(strings.Fields(paragraph.Text))
+		}
+	}
+	doc.FormatRules = wm.formatRules(doc)
+	return doc
+}
+
+// metadata 读取o:DocumentProperties中的文档属性
+func (wm *wordML) metadata(fileSize int64) types.DocumentMetadata {
+	properties := wm.root.child("DocumentProperties")
+	text := func(name string) string {
+		if c := properties.child(name); c != nil {
+			return strings.TrimSpace(c.text.String())
+		}
+		return ""
+	}
+	date := func(name string) time.Time {
+		t, _ := time.Parse(time.RFC3339, text(name))
+		return t
+	}
+
+	metadata := types.DocumentMetadata{
+		Title:       text("Title"),
+		Subject:     text("Subject"),
+		Author:      text("Author"),
+		LastSavedBy: text("LastAuthor"),
+		Version:     text("Version"),
+		Created:     date("Created"),
+		Modified:    date("LastSaved"),
+		FileSize:    fileSize,
+	}
+	if keywords := text("Keywords"); keywords != "" {
+		metadata.Keywords = strings.Split(keywords, ",")
+	}
+	metadata.Revision, _ = strconv.Atoi(text("Revision"))
+	metadata.PageCount, _ = strconv.Atoi(text("Pages"))
+	metadata.WordCount, _ = strconv.Atoi(text("Words"))
+	return metadata
+}
+
+// eachBlock 按文档顺序遍历段落、表格和节属性，进入wx:sect、wx:sub-section等分组元素
+func eachBlock(node *wmlNode, fn func(*wmlNode)) {
+	for _, c := range node.elements() {
+		switch c.name {
+		case "p", "tbl", "sectPr":
+			fn(c)
+		case "sect", "sub-section", "sdt", "sdtContent", "body", "customXml", "smartTag":
+			eachBlock(c, fn)
+		case "annotation":
+			if c.attr("type") != "Word.Deletion" {
+				eachBlock(c, fn)
+			}
+		case "content":
+			eachBlock(c, fn)
+		}
+	}
+}
+
+// content 读取正文段落、表格、节、页眉页脚、书签和图片
+//
+// ID与DOCX解析结果一致：正文段落为paragraph_i，表格单元格中的段落不计入正文段落。
+func (wm *wordML) content() types.DocumentContent {
+	var content types.DocumentContent
+	var sectionEnds []*wmlNode
+	eachBlock(wm.root.child("body"), func(block *wmlNode) {
+		switch block.name {
+		case "p":
+			i := len(content.Paragraphs) + 1
+			paragraph := wm.convertParagraph(block, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+				return fmt.Sprintf("run_%d_%d", i, j+1)
+			})
+			content.Paragraphs = append(content.Paragraphs, paragraph)
+			if sectPr := block.child("pPr").child("sectPr"); sectPr != nil {
+				sectionEnds = append(sectionEnds, sectPr)
+			}
+		case "tbl":
+			content.Tables = append(content.Tables, wm.convertTable(block, len(content.Tables)+1))
+		case "sectPr":
+			sectionEnds = append(sectionEnds, block)
+		}
+	})
+
+	for i, sectPr := range sectionEnds {
+		content.Sections = append(content.Sections, wmlSection(sectPr, fmt.Sprintf("section_%d", i+1)))
+	}
+	if len(content.Sections) == 0 {
+		content.Sections = append(content.Sections, defaultDocSection("section_1"))
+	}
+	content.Headers, content.Footers = wm.headersFooters(sectionEnds)
+	content.Bookmarks = wm.bookmarks
+	content.Images = wm.images
+	return content
+}
+
+// convertParagraph 将w:p转换为types.Paragraph，runID返回第j个文本运行的ID
+func (wm *wordML) convertParagraph(p *wmlNode, id string, runID func(j int) string) types.Paragraph {
+	props, chars := wm.paragraphProps(p)
+	paragraph := types.Paragraph{
+		ID:           id,
+		Alignment:    props.alignment,
+		Indentation:  props.indentation,
+		Spacing:      props.spacing,
+		PageBreak:    props.pageBreak,
+		KeepLines:    props.keepLines,
+		KeepNext:     props.keepNext,
+		OutlineLevel: props.outline,
+	}
+	// 与DOCX一致，正文样式的段落不记录样式名称
+	if styleID := wm.paragraphStyle(p); styleID != wm.defaultStyle {
+		paragraph.Style.Name = styleID
+	}
+	if props.listID != "" {
+		list := wm.lists[props.listID]
+		paragraph.List = &types.ListInfo{
+			ID:     props.listID,
+			Level:  props.listLevel,
+			Format: list.levels[props.listLevel].format,
+			Label:  props.listLabel,
+		}
+	}
+
+	var text strings.Builder
+	wm.eachRun(p, func(r *wmlNode) {
+		run, pageBreak := wm.convertRun(r, chars)
+		paragraph.PageBreak = paragraph.PageBreak || pageBreak
+		run.ID = runID(len(paragraph.Runs))
+		paragraph.Runs = append(paragraph.Runs, run)
+		text.WriteString(run.Text)
+	})
+	paragraph.Text = text.String()
+	return paragraph
+}
+
+// eachRun 遍历段落中的文本运行，进入超链接、域和修订等容器元素，跳过删除的内容并记录书签
+func (wm *wordML) eachRun(node *wmlNode, fn func(*wmlNode)) {
+	for _, c := range node.children {
+		switch c.name {
+		case "r":
+			fn(c)
+		case "hlink", "fldSimple", "smartTag", "sdt", "sdtContent", "customXml", "content":
+			wm.eachRun(c, fn)
+		case "annotation":
+			switch c.attr("type") {
+			case "Word.Bookmark.Start":
+				wm.bookmarks = append(wm.bookmarks, types.Bookmark{ID: c.attr("id"), Name: c.attr("name")})
+			case "Word.Deletion", "Word.Comment":
+			default:
+				wm.eachRun(c, fn)
+			}
+		}
+	}
+}
+
+// convertRun 将w:r转换为文本运行，paragraphChars为段落样式的字符属性；第二个返回值表示运行中是否有分页符
+func (wm *wordML) convertRun(r *wmlNode, paragraphChars wmlCharProps) (types.TextRun, bool) {
+	chars := paragraphChars
+	rPr := r.child("rPr")
+	if styleID := rPr.val("rStyle"); styleID != "" {
+		for _, style := range wm.styleChain(styleID) {
+			chars.apply(style.rPr)
+		}
+	}
+	chars.apply(rPr)
+
+	var text strings.Builder
+	pageBreak := false
+	for _, c := range r.children {
+		switch c.name {
+		case "t":
+			text.WriteString(c.text.String())
+		case "tab":
+			text.WriteByte('\t')
+		case "br", "cr":
+			if c.attr("type") == "page" {
+				pageBreak = true
+			} else {
+				text.WriteByte('\n')
+			}
+		case "sym":
+			if code, err := strconv.ParseUint(c.attr("char"), 16, 32); err == nil {
+				text.WriteString(wmlBullet(string(rune(code))))
+			}
+		case "pict":
+			wm.appendImage(c)
+		}
+	}
+
+	font := wm.convertFont(chars, text.String())
+	return types.TextRun{
+		Text:      text.String(),
+		Font:      font,
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Color:     font.Color,
+		Highlight: chars.highlight,
+		Size:      font.Size,
+		Position:  chars.position,
+	}, pageBreak
+}
+
+// convertFont 返回字符属性对应的字体，含东亚文字时取东亚字体
+func (wm *wordML) convertFont(chars wmlCharProps, text string) types.Font {
+	name := chars.font
+	if chars.eastAsia != "" && (name == "" || hasEastAsian(text)) {
+		name = chars.eastAsia
+	}
+	return types.Font{
+		Name:      name,
+		Size:      chars.size,
+		Color:     types.Color{RGB: chars.color},
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Highlight: chars.highlight,
+	}
+}
+
+// appendImage 添加w:pict中的图片，大小取自v:shape的style属性
+func (wm *wordML) appendImage(pict *wmlNode) {
+	image := types.Image{ID: fmt.Sprintf("image_%d", len(wm.images)+1)}
+	if shape := pict.child("shape"); shape != nil {
+		image.AltText = shape.attr("alt")
+		for _, declaration := range strings.Split(shape.attr("style"), ";") {
+			name, value, ok := strings.Cut(declaration, ":")
+			if !ok {
+				continue
+			}
+			size, ok := cssLength(value)
+			if !ok {
+				continue
+			}
+			switch strings.TrimSpace(name) {
+			case "width":
+				image.Width = size
+			case "height":
+				image.Height = size
+			}
+		}
+	}
+	if binData := pict.child("binData"); binData != nil {
+		image.Path = binData.attr("name")
+	}
+	wm.images = append(wm.images, image)
+}
+
+// cssLength 将CSS长度转换为磅，支持pt、in、cm、mm、px和无单位的数值
+func cssLength(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	units := map[string]float64{"pt": 1, "in": 72, "cm": 72 / 2.54, "mm": 72 / 25.4, "px": 0.75, "pc": 12}
+	scale := 1.0
+	for unit, factor := range units {
+		if strings.HasSuffix(value, unit) {
+			value, scale = strings.TrimSuffix(value, unit), factor
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return number * scale, true
+}
+
+// convertTable 将w:tbl转换为表格，i为表格从1开始的序号
+func (wm *wordML) convertTable(tbl *wmlNode, i int) types.Table {
+	table := types.Table{ID: fmt.Sprintf("table_%d", i)}
+	tblPr := tbl.child("tblPr")
+	if styleID := tblPr.val("tblStyle"); styleID != "" {
+		table.Style = types.TableStyle{ID: styleID, Name: styleID}
+		if style := wm.styles[styleID]; style != nil {
+			table.Style.Name = style.name
+		}
+	}
+	if width := tblPr.child("tblW"); width.attr("type") == "dxa" {
+		table.Width, _ = width.twips("w")
+	}
+	if jc := tblPr.val("jc"); jc != "" {
+		table.Alignment = wmlAlignment(jc)
+	}
+	if borders := tblPr.child("tblBorders"); borders != nil {
+		table.Borders = types.TableBorders{
+			Top:     wmlBorder(borders.child("top")),
+			Bottom:  wmlBorder(borders.child("bottom")),
+			Left:    wmlBorder(borders.child("left")),
+			Right:   wmlBorder(borders.child("right")),
+			InsideH: wmlBorder(borders.child("insideH")),
+			InsideV: wmlBorder(borders.child("insideV")),
+		}
+	}
+
+	// 纵向合并：记录每个网格列上正在合并的起始单元格
+	type mergeStart struct{ row, cell int }
+	merging := make(map[int]mergeStart)
+	for _, tr := range tbl.children {
+		if tr.name != "tr" {
+			continue
+		}
+		j := len(table.Rows) + 1
+		row := types.TableRow{ID: fmt.Sprintf("row_%d_%d", i, j)}
+		trPr := tr.child("trPr")
+		row.Height, _ = trPr.child("trHeight").twips("val")
+		row.Header, _ = trPr.flag("tblHeader")
+		row.Repeat = row.Header
+
+		column := 0
+		for _, tc := range tr.children {
+			if tc.name != "tc" {
+				continue
+			}
+			k := len(row.Cells) + 1
+			cell := types.TableCell{ID: fmt.Sprintf("cell_%d_%d_%d", i, j, k)}
+			tcPr := tc.child("tcPr")
+			cell.Width, _ = tcPr.child("tcW").twips("w")
+			span := 1
+			if n, err := strconv.Atoi(tcPr.val("gridSpan")); err == nil && n > 1 {
+				span = n
+				cell.Merge.Horizontal = n
+			}
+			if vmerge := tcPr.child("vmerge"); vmerge != nil {
+				if vmerge.attr("val") == "restart" {
+					merging[column] = mergeStart{row: j - 1, cell: k - 1}
+					cell.Merge.Vertical = 1
+				} else if start, ok := merging[column]; ok {
+					table.Rows[start.row].Cells[start.cell].Merge.Vertical++
+				}
+			} else {
+				delete(merging, column)
+			}
+			switch tcPr.val("vAlign") {
+			case "center":
+				cell.VerticalAlignment = types.VAlignCenter
+			case "bottom":
+				cell.VerticalAlignment = types.VAlignBottom
+			case "top":
+				cell.VerticalAlignment = types.VAlignTop
+			}
+			if fill := tcPr.child("shd").attr("fill"); fill != "" && fill != "auto" {
+				cell.Shading.Fill.RGB = strings.ToUpper(fill)
+			}
+			if borders := tcPr.child("tcBorders"); borders != nil {
+				cell.Borders = types.CellBorders{
+					Top:    wmlBorder(borders.child("top")),
+					Bottom: wmlBorder(borders.child("bottom")),
+					Left:   wmlBorder(borders.child("left")),
+					Right:  wmlBorder(borders.child("right")),
+				}
+			}
+			wm.cellParagraphs(tc, &cell, i, j, k)
+			row.Cells = append(row.Cells, cell)
+			column += span
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	if table.Width == 0 && len(table.Rows) > 0 {
+		for _, cell := range table.Rows[0].Cells {
+			table.Width += cell.Width
+		}
+	}
+	return table
+}
+
+// cellParagraphs 读取单元格中的段落，嵌套表格中的段落按顺序并入单元格
+func (wm *wordML) cellParagraphs(node *wmlNode, cell *types.TableCell, i, j, k int) {
+	eachBlock(node, func(block *wmlNode) {
+		switch block.name {
+		case "p":
+			paragraph := wm.convertParagraph(block, fmt.Sprintf("cell_para_%d_%d_%d", i, j, k), func(int) string {
+				return fmt.Sprintf("cell_run_%d_%d_%d", i, j, k)
+			})
+			cell.Content = append(cell.Content, paragraph)
+		case "tbl":
+			for _, tr := range block.children {
+				for _, tc := range tr.children {
+					if tc.name == "tc" {
+						wm.cellParagraphs(tc, cell, i, j, k)
+					}
+				}
+			}
+		}
+	})
+}
+
+// wmlBorder 读取边框：线型、宽度（1/8磅）和颜色
+func wmlBorder(node *wmlNode) types.Border {
+	if node == nil {
+		return types.Border{}
+	}
+	border := types.Border{Style: types.BorderStyle(node.attr("val"))}
+	if border.Style == "nil" {
+		border.Style = types.BorderNone
+	}
+	if size, err := strconv.ParseFloat(node.attr("sz"), 64); err == nil {
+		border.Width = size / 8.0
+	}
+	if color := node.attr("color"); color != "" && color != "auto" {
+		border.Color.RGB = strings.ToUpper(color)
+	}
+	border.Space, _ = strconv.ParseFloat(node.attr("space"), 64)
+	return border
+}
+
+// wmlSection 读取w:sectPr中的页面大小、页边距、分栏、页码和行号
+func wmlSection(sectPr *wmlNode, id string) types.Section {
+	section := defaultDocSection(id)
+	if size := sectPr.child("pgSz"); size != nil {
+		if v, ok := size.twips("w"); ok {
+			section.PageSize.Width = v
+		}
+		if v, ok := size.twips("h"); ok {
+			section.PageSize.Height = v
+		}
+	}
+	if margins := sectPr.child("pgMar"); margins != nil {
+		for name, target := range map[string]*float64{
+			"top": &section.PageMargins.Top, "bottom": &section.PageMargins.Bottom,
+			"left": &section.PageMargins.Left, "right": &section.PageMargins.Right,
+			"header": &section.PageMargins.Header, "footer": &section.PageMargins.Footer,
+		} {
+			if v, ok := margins.twips(name); ok {
+				*target = v
+			}
+		}
+		section.HeaderDistance = section.PageMargins.Header
+		section.FooterDistance = section.PageMargins.Footer
+	}
+	if cols := sectPr.child("cols"); cols != nil {
+		if n, ok := cols.number("num"); ok && n > 0 {
+			section.Columns.Count = n
+		}
+		if v, ok := cols.twips("space"); ok {
+			section.Columns.Spacing = v
+		}
+		if equal := cols.attr("equalWidth"); equal != "" {
+			section.Columns.Equal = equal != "off" && equal != "0"
+		}
+	}
+	if numbering := sectPr.child("pgNumType"); numbering != nil {
+		if start, ok := numbering.number("start"); ok {
+			section.PageNumbering.Start = start
+			section.PageNumbering.Restart = true
+		}
+		if format := numbering.attr("fmt"); format != "" {
+			section.PageNumbering.Format = format
+		}
+	}
+	if lines := sectPr.child("lnNumType"); lines != nil {
+		section.LineNumbering.Increment, _ = lines.number("count-by")
+		section.LineNumbering.Start, _ = lines.number("start")
+		section.LineNumbering.Restart = lines.attr("restart") != "continuous"
+	}
+	return section
+}
+
+// headersFooters 读取各节非空的页眉和页脚
+func (wm *wordML) headersFooters(sections []*wmlNode) ([]types.Header, []types.Footer) {
+	var headers []types.Header
+	var footers []types.Footer
+	for _, sectPr := range sections {
+		for _, story := range sectPr.children {
+			if story.name != "hdr" && story.name != "ftr" {
+				continue
+			}
+			prefix, n := "footer", len(footers)+1
+			if story.name == "hdr" {
+				prefix, n = "header", len(headers)+1
+			}
+			var paragraphs []types.Paragraph
+			empty := true
+			eachBlock(story, func(block *wmlNode) {
+				if block.name != "p" {
+					return
+				}
+				k := len(paragraphs) + 1
+				paragraph := wm.convertParagraph(block, fmt.Sprintf("%s_para_%d_%d", prefix, n, k), func(j int) string {
+					return fmt.Sprintf("%s_run_%d_%d_%d", prefix, n, k, j+1)
+				})
+				empty = empty && strings.TrimSpace(paragraph.Text) == ""
+				paragraphs = append(paragraphs, paragraph)
+			})
+			if empty {
+				continue
+			}
+			if story.name == "hdr" {
+				headers = append(headers, types.Header{ID: fmt.Sprintf("header_%d", n), Content: paragraphs})
+			} else {
+				footers = append(footers, types.Footer{ID: fmt.Sprintf("footer_%d", n), Content: paragraphs})
+			}
+		}
+	}
+	return headers, footers
+}
+
+// documentStyles 返回样式表中的段落、字符和表格样式，段落样式包含沿继承链合并后的格式
+func (wm *wordML) documentStyles() types.DocumentStyles {
+	styles := types.DocumentStyles{
+		ParagraphStyles: []types.ParagraphStyle{},
+		CharacterStyles: []types.CharacterStyle{},
+		TableStyles:     []types.TableStyle{},
+	}
+	for _, style := range wm.styleOrder {
+		switch style.kind {
+		case "paragraph":
+			para, chars := wm.styleProps(style.id)
+			styles.ParagraphStyles = append(styles.ParagraphStyles, types.ParagraphStyle{
+				ID:          style.id,
+				Name:        style.name,
+				Font:        wm.convertFont(chars, style.name),
+				Alignment:   para.alignment,
+				Indentation: para.indentation,
+				Spacing:     para.spacing,
+			})
+		case "character":
+			styles.CharacterStyles = append(styles.CharacterStyles, types.CharacterStyle{ID: style.id, Name: style.name})
+		case "table":
+			styles.TableStyles = append(styles.TableStyles, types.TableStyle{ID: style.id, Name: style.name})
+		}
+	}
+	return styles
+}
+
+// formatRules 生成格式规则，样式规则来自样式表
+func (wm *wordML) formatRules(doc *types.Document) types.FormatRules {
+	rules := contentFormatRules(doc, wm.fonts)
+	kinds := map[string]string{"list": "numbering"}
+	for _, style := range wm.styleOrder {
+		kind := style.kind
+		if mapped, ok := kinds[kind]; ok {
+			kind = mapped
+		}
+		rules.StyleRules = append(rules.StyleRules, types.StyleRule{
+			ID:      style.id,
+			Name:    style.name,
+			Type:    kind,
+			BasedOn: style.basedOn,
+			Next:    style.next,
+			Hidden:  style.hidden,
+		})
+	}
+	return rules
+}
@@ -0,0 +1,200 @@
+package formats
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+)
+
+// testWpdHeader 返回16字节的WordPerfect文件头
+func testWpdHeader(major, minor byte, documentOffset uint32, encryption, indexOffset uint16) []byte {
+	return testBytes([]byte{0xFF, 'W', 'P', 'C'}, documentOffset, []byte{1, wpdFileTypeWP, major, minor}, encryption, indexOffset)
+}
+
+// testWP5Group 返回WP 5.x变长功能组，数据之后依次为长度、子组号和功能码
+func testWP5Group(code, sub byte, body []byte) []byte {
+	size := uint16(len(body) + 4)
+	return testBytes([]byte{code, sub}, size, body, size, []byte{sub, code})
+}
+
+// testWP6Group 返回WP 6+变长功能组，ids非空时带前缀ID列表
+func testWP6Group(code, sub byte, ids []uint16, body []byte) []byte {
+	var flags byte
+	var prefix []byte
+	if len(ids) > 0 {
+		flags = wp6GroupIDsFlag
+		prefix = append(prefix, byte(len(ids)))
+		for _, id := range ids {
+			prefix = append(prefix, testBytes(id)...)
+		}
+	}
+	content := testBytes([]byte{flags}, prefix, uint16(len(body)), body, []byte{code})
+	return testBytes([]byte{code, sub}, uint16(len(content)+4), content)
+}
+
+// buildTestWP5 构造WP 5.1文档：右对齐、页边距、扩展字符、斜体和硬分页
+func buildTestWP5(encryption uint16) []byte {
+	var text []byte
+	text = append(text, testWP5Group(wp5PageFormat, wp5LeftRightMargin, testBytes(uint16(1200), uint16(1200), uint16(2400), uint16(1800)))...)
+	text = append(text, testWP5Group(wp5PageFormat, wp5Justification, []byte{0, 3})...)
+	text = append(text, "Stra"...)
+	text = append(text, wp5ExtendedChar, 23, 1, wp5ExtendedChar)
+	text = append(text, 'e', wp5SoftReturn)
+	text = append(text, "two "...)
+	text = append(text, wp5AttributeOn, wpdAttrItalic, wp5AttributeOn)
+	text = append(text, "it"...)
+	text = append(text, wp5AttributeOff, wpdAttrItalic, wp5AttributeOff, wp5HardReturn)
+	text = append(text, wp5HardPage)
+	text = append(text, "end"...)
+	return append(testWpdHeader(wpdMajorWP5, 1, wpdHeaderSize, encryption, 0), text...)
+}
+
+// buildTestWP6 构造WP 6+文档：前缀区中的字体描述、字体修改、属性、扩展字符、对齐、页边距、制表符和硬分页
+func buildTestWP6() []byte {
+	var index []byte
+	index = append(index, testBytes(uint16(2), uint16(2), make([]byte, 10))...)
+
+	font := make([]byte, 22)
+	var nameChars []byte
+	for _, c := range []byte("Arial") {
+		nameChars = append(nameChars, c, 0)
+	}
+	nameChars = append(nameChars, 0, 0)
+	font = append(font, testBytes(uint16(len(nameChars)), nameChars)...)
+	fontOffset := uint32(wpdHeaderSize + 2*wp6IndexEntrySize)
+	index = append(index, testBytes([]byte{0, wp6PacketFont}, uint16(1), uint16(0), uint32(len(font)), fontOffset)...)
+
+	var text []byte
+	text = append(text, testWP6Group(wp6ColumnGroup, wp6LeftMargin, nil, testBytes(uint16(1800)))...)
+	text = append(text, testWP6Group(wp6PageGroup, wp6TopMargin, nil, testBytes(uint16(2400)))...)
+	text = append(text, testWP6Group(wp6CharacterGrp, wp6FontFaceChange, []uint16{1}, testBytes(uint16(0), uint16(0), uint16(0), uint16(700)))...)
+	text = append(text, "Hello"...)
+	text = append(text, wp6SoftSpace, wp6AttributeOn, wpdAttrBold, wp6AttributeOn)
+	text = append(text, "Bold"...)
+	text = append(text, wp6AttributeOff, wpdAttrBold, wp6AttributeOff, wp6SoftSpace)
+	text = append(text, "caf"...)
+	text = append(text, wp6ExtendedChar, 41, 1, wp6ExtendedChar, wp6HardReturn)
+	text = append(text, testWP6Group(wp6ParagraphGrp, wp6Justification, nil, []byte{2})...)
+	text = append(text, "Centered"...)
+	text = append(text, testWP6Group(wp6EOLGroup, wp6HardEOLFirst, nil, nil)...)
+	text = append(text, wp6HardPage)
+	text = append(text, "Next"...)
+	text = append(text, 0x07)
+	text = append(text, testWP6Group(wp6TabGroup, 0, nil, nil)...)
+	text = append(text, wp6AttributeOn, wpdAttrUnderline, wp6AttributeOn, 'u', wp6AttributeOff, wpdAttrUnderline, wp6AttributeOff)
+
+	documentOffset := uint32(wpdHeaderSize + len(index) + len(font))
+	data := testWpdHeader(wpdMajorWP6, 2, documentOffset, 0, wpdHeaderSize)
+	data = append(data, index...)
+	data = append(data, font...)
+	return append(data, text...)
+}
+
+// writeTestWpd 将文件内容写入临时目录中的.wpd文件
+func writeTestWpd(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.wpd")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+// TestWpdParser_WP6 测试读取WP 6+文档的前缀区字体、字符属性、扩展字符、对齐、页边距和分页
+func TestWpdParser_WP6(t *testing.T) {
+	doc, err := NewWpdParser().ParseDocument(writeTestWpd(t, buildTestWP6()))
+	if err != nil {
+		t.Fatalf("解析WP 6文档失败: %v", err)
+	}
+
+	paragraphs := doc.Content.Paragraphs
+	wantTexts := []string{"Hello Bold café", "Centered", "Nextá\tu"}
+	if len(paragraphs) != len(wantTexts) {
+		t.Fatalf("段落应为 %q，实际 %+v", wantTexts, paragraphs)
+	}
+	for i, want := range wantTexts {
+		if paragraphs[i].Text != want {
+			t.Errorf("第%d段文本应为 %q，实际 %q", i+1, want, paragraphs[i].Text)
+		}
+	}
+
+	first := paragraphs[0]
+	if len(first.Runs) != 3 || first.Runs[1].Text != "Bold" || !first.Runs[1].Bold || first.Runs[0].Bold {
+		t.Fatalf("第1段应分为3个文本运行且只有Bold为粗体，实际 %+v", first.Runs)
+	}
+	if run := first.Runs[0]; run.Font.Name != "Arial" || run.Size != 14 || run.ID != "run_1_1" {
+		t.Errorf("字体应为Arial 14磅，实际 %q %.1f %s", run.Font.Name, run.Size, run.ID)
+	}
+	if paragraphs[1].Alignment != types.AlignCenter || first.Alignment != types.AlignLeft {
+		t.Errorf("对齐方式不正确: %s %s", first.Alignment, paragraphs[1].Alignment)
+	}
+	last := paragraphs[2]
+	if !last.PageBreak || paragraphs[1].PageBreak {
+		t.Errorf("只有第3段应从新的一页开始")
+	}
+	if underline := last.Runs[len(last.Runs)-1]; underline.Text != "u" || underline.Underline != types.UnderlineSingle {
+		t.Errorf("最后一个文本运行应为单下划线的u，实际 %+v", underline)
+	}
+
+	sections := doc.Content.Sections
+	if len(sections) != 1 || sections[0].PageMargins.Left != 108 || sections[0].PageMargins.Top != 144 || sections[0].PageMargins.Right != 72 {
+		t.Errorf("页边距不正确: %+v", sections)
+	}
+	if doc.Metadata.PageCount != 2 || doc.Metadata.WordCount != 6 || doc.Metadata.Version != "6.2" {
+		t.Errorf("文档属性不正确: %+v", doc.Metadata)
+	}
+	if len(doc.FormatRules.FontRules) != 1 || doc.FormatRules.FontRules[0].Name != "Arial" {
+		t.Errorf("字体规则应来自使用过的字体，实际 %+v", doc.FormatRules.FontRules)
+	}
+}
+
+// TestWpdParser_WP5 测试读取WP 5.x文档的功能码
+func TestWpdParser_WP5(t *testing.T) {
+	content, err := NewWpdParser().ParseContent(writeTestWpd(t, buildTestWP5(0)))
+	if err != nil {
+		t.Fatalf("解析WP 5文档失败: %v", err)
+	}
+
+	paragraphs := content.Paragraphs
+	wantTexts := []string{"Straße two it", "end"}
+	if len(paragraphs) != len(wantTexts) {
+		t.Fatalf("段落应为 %q，实际 %+v", wantTexts, paragraphs)
+	}
+	for i, want := range wantTexts {
+		if paragraphs[i].Text != want {
+			t.Errorf("第%d段文本应为 %q，实际 %q", i+1, want, paragraphs[i].Text)
+		}
+	}
+	if runs := paragraphs[0].Runs; len(runs) != 2 || runs[1].Text != "it" || !runs[1].Italic || runs[0].Italic {
+		t.Errorf("第1段应分为普通文本和斜体文本，实际 %+v", runs)
+	}
+	if paragraphs[0].Alignment != types.AlignRight || !paragraphs[1].PageBreak {
+		t.Errorf("对齐方式或分页不正确: %s %v", paragraphs[0].Alignment, paragraphs[1].PageBreak)
+	}
+	if margins := content.Sections[0].PageMargins; margins.Left != 144 || margins.Right != 108 {
+		t.Errorf("左右边距应为144和108磅，实际 %+v", margins)
+	}
+}
+
+// TestWpdParser_Errors 测试加密文档、损坏的功能组和无效的文件头
+func TestWpdParser_Errors(t *testing.T) {
+	wp := NewWpdParser()
+	if _, err := wp.ParseDocument(writeTestWpd(t, buildTestWP5(0x1234))); !errors.Is(err, ErrEncryptedWpd) {
+		t.Errorf("加密文档应返回ErrEncryptedWpd，实际 %v", err)
+	}
+
+	data := buildTestWP5(0)
+	// 将第一个变长功能组的长度改到文件末尾之外
+	data[wpdHeaderSize+2] = 0xFF
+	if _, err := wp.ParseContent(writeTestWpd(t, data)); !errors.Is(err, parser.ErrInvalidFile) {
+		t.Errorf("损坏的功能组应返回ErrInvalidFile，实际 %v", err)
+	}
+
+	if err := wp.ValidateFile(writeTestWpd(t, []byte("not a WordPerfect file"))); !errors.Is(err, parser.ErrInvalidFile) {
+		t.Errorf("无效的文件头应返回ErrInvalidFile，实际 %v", err)
+	}
+}
@@ -0,0 +1,790 @@
+package formats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// WordPerfect 5.x/6.x+文档的读取
+//
+// 两个版本共用16字节的文件头：魔数FF 57 50 43、正文起始偏移、产品类型、文件类型、主次版本号和加密密钥。
+// 文件头和正文之间为前缀区，WP 6+的前缀区由索引描述，每个索引项指向一个数据包（字体描述、文档摘要等）。
+// 正文是字节流，除字符外由三类功能码组成：单字节功能码、以同一字节开始和结束的定长功能码，
+// 以及带子组号和长度的变长功能组。长度单位WPU为1/1200英寸，字号单位为1/50磅。
+
+// ErrEncryptedWpd 文档设置了密码，无法读取正文
+var ErrEncryptedWpd = errors.New("password-protected WordPerfect documents are not supported")
+
+// errWpdStructure WordPerfect文档中的结构越界或损坏
+var errWpdStructure = errors.New("corrupt WordPerfect structure")
+
+// errWpdVersion 不支持的WordPerfect版本（如Mac版）
+var errWpdVersion = errors.New("unsupported WordPerfect version")
+
+// 文件头
+const (
+	wpdHeaderSize = 16
+	wpdMajorWP5   = 0x00
+	wpdMajorWP6   = 0x02
+	wpdFileTypeWP = 0x0A // WordPerfect文档
+)
+
+// WP 6+前缀区
+const (
+	wp6IndexEntrySize = 14
+	wp6PacketFont     = 0x55 // 字体描述
+)
+
+// WordPerfect属性编号，5.x和6.x+相同
+const (
+	wpdAttrSuperscript     = 5
+	wpdAttrSubscript       = 6
+	wpdAttrItalic          = 8
+	wpdAttrDoubleUnderline = 11
+	wpdAttrBold            = 12
+	wpdAttrUnderline       = 14
+)
+
+// WP 5.x功能码
+const (
+	wp5Tab          = 0x09
+	wp5HardReturn   = 0x0A
+	wp5SoftPage     = 0x0B
+	wp5HardPage     = 0x0C
+	wp5SoftReturn   = 0x0D
+	wp5HardReturnSP = 0x8C // 硬回车同时在此处软分页
+	wp5HardSpace    = 0xA0
+	wp5HardHyphen   = 0xA9
+	wp5HardHyphenEL = 0xAA
+	wp5ExtendedChar = 0xC0
+	wp5TabGroup     = 0xC1
+	wp5AttributeOn  = 0xC3
+	wp5AttributeOff = 0xC4
+	wp5PageFormat   = 0xD0
+	wp5FontGroup    = 0xD1
+)
+
+// wp5FixedSizes 0xC0-0xCF定长功能码的长度
+var wp5FixedSizes = [16]int{4, 9, 11, 3, 3, 5, 6, 7, 4, 5, 6, 7, 8, 9, 10, 11}
+
+// WP 5.x页面格式组和字体组的子组
+const (
+	wp5LeftRightMargin = 0x01
+	wp5TopBottomMargin = 0x05
+	wp5Justification   = 0x06
+	wp5FontChange      = 0x01
+)
+
+// WP 6+功能码
+const (
+	wp6SoftSpace     = 0x80
+	wp6HardSpace     = 0x81
+	wp6HardHyphen    = 0x84
+	wp6HardPage      = 0xC7
+	wp6HardReturn    = 0xCC
+	wp6SoftReturn    = 0xCF
+	wp6EOLGroup      = 0xD0
+	wp6PageGroup     = 0xD1
+	wp6ColumnGroup   = 0xD2
+	wp6ParagraphGrp  = 0xD3
+	wp6CharacterGrp  = 0xD4
+	wp6TabGroup      = 0xE0
+	wp6ExtendedChar  = 0xF0
+	wp6Undo          = 0xF1
+	wp6AttributeOn   = 0xF2
+	wp6AttributeOff  = 0xF3
+	wp6GroupIDsFlag  = 0x80 // 变长功能组带有前缀ID
+	wp6MinGroupSize  = 7
+	wp6FixedSearchTo = 32 // 未知定长功能码向后查找结束字节的范围
+)
+
+// WP 6+功能组的子组
+const (
+	wp6TopMargin       = 0x00 // 页面组
+	wp6BottomMargin    = 0x01
+	wp6LeftMargin      = 0x00 // 分栏组
+	wp6RightMargin     = 0x01
+	wp6Justification   = 0x05 // 段落组
+	wp6FontFaceChange  = 0x00 // 字符组
+	wp6FontSizeChange  = 0x01
+	wp6SoftEOLAtEOP    = 0x03 // 行结束组
+	wp6HardEOLFirst    = 0x04
+	wp6HardEOLAtEOP    = 0x06
+	wp6HardEOP         = 0x09
+	wp6TableEOLFirst   = 0x0A
+	wp6TableEOLLast    = 0x13
+	wp6DelHardEOLFirst = 0x14
+	wp6DelHardEOLLast  = 0x16
+	wp6DelHardEOP      = 0x1A
+)
+
+// wpdPacket WP 6+前缀区索引项指向的数据包
+type wpdPacket struct {
+	flags  byte
+	kind   byte
+	size   uint32
+	offset uint32
+}
+
+// wpdCharState 字符格式
+type wpdCharState struct {
+	font      string
+	size      float64 // 磅
+	bold      bool
+	italic    bool
+	underline types.Underline
+	position  types.Position
+}
+
+// wpdParaState 段落格式，WordPerfect的格式码从所在位置起一直有效
+type wpdParaState struct {
+	alignment   types.Alignment
+	indentation types.Indentation
+}
+
+// wpdReader 按功能码解释WordPerfect正文
+type wpdReader struct {
+	data    []byte
+	header  *WpdHeader
+	packets []wpdPacket // 序号即前缀ID，0为索引头
+
+	chars     wpdCharState
+	para      wpdParaState
+	runs      []types.TextRun
+	runChars  []wpdCharState
+	pageBreak bool
+
+	paragraphs  []types.Paragraph
+	sections    []types.Section
+	sectionText bool // 当前节已有段落，此后的上下边距修改开始新的节
+	fonts       []string
+	pages       int
+}
+
+// readWpdHeader 读取16字节的文件头
+func readWpdHeader(data []byte) (*WpdHeader, error) {
+	if len(data) < wpdHeaderSize || !isWpdMagic(data) {
+		return nil, errWpdStructure
+	}
+	header := &WpdHeader{
+		Signature:      data[:4],
+		DocumentOffset: le.Uint32(data[4:]),
+		ProductType:    data[8],
+		EncryptionKey:  le.Uint16(data[12:]),
+		IndexOffset:    le.Uint16(data[14:]),
+	}
+	header.IsEncrypted = header.EncryptionKey != 0
+	header.HasPassword = header.IsEncrypted
+
+	minor := int(data[11])
+	switch data[10] {
+	case wpdMajorWP5:
+		header.Version = WpdVersion{Major: 5, Minor: minor, Platform: "DOS"}
+		header.FileType = "WordPerfect 5.x"
+	case wpdMajorWP6:
+		header.Version = WpdVersion{Major: 6, Minor: minor, Platform: "Windows"}
+		header.FileType = "WordPerfect 6.x+"
+	default:
+		header.Version = WpdVersion{Major: int(data[10]), Minor: minor}
+		header.FileType = "WordPerfect Document"
+	}
+	if data[9] == wpdFileTypeWP {
+		header.DocumentType = "Document"
+	} else {
+		header.DocumentType = fmt.Sprintf("Type %d", data[9])
+	}
+	return header, nil
+}
+
+// isWpdMagic 检查WordPerfect文件头魔数"\xFFWPC"
+func isWpdMagic(data []byte) bool {
+	return len(data) >= 4 && data[0] == 0xFF && data[1] == 'W' && data[2] == 'P' && data[3] == 'C'
+}
+
+// readWordPerfect 读取整个WordPerfect文档
+func readWordPerfect(data []byte) (*types.Document, error) {
+	header, err := readWpdHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.IsEncrypted {
+		return nil, ErrEncryptedWpd
+	}
+	if header.DocumentOffset < wpdHeaderSize || int64(header.DocumentOffset) > int64(len(data)) {
+		return nil, errWpdStructure
+	}
+
+	r := &wpdReader{
+		data:     data,
+		header:   header,
+		chars:    wpdCharState{size: 12},
+		para:     wpdParaState{alignment: types.AlignLeft},
+		sections: []types.Section{defaultWpdSection("section_1")},
+	}
+	switch header.Version.Major {
+	case 5:
+		err = r.readWP5Text()
+	case 6:
+		if err = r.readIndex(); err == nil {
+			err = r.readWP6Text()
+		}
+	default:
+		err = errWpdVersion
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(r.runs) > 0 {
+		r.endParagraph()
+	}
+	return r.document(int64(len(data))), nil
+}
+
+// defaultWpdSection 返回WordPerfect的默认页面设置：Letter纸张，四边1英寸
+func defaultWpdSection(id string) types.Section {
+	return types.Section{
+		ID:            id,
+		PageSize:      types.PageSize{Width: 612, Height: 792},
+		PageMargins:   types.PageMargins{Top: 72, Bottom: 72, Left: 72, Right: 72},
+		Columns:       types.Columns{Count: 1, Equal: true},
+		PageNumbering: types.PageNumbering{Format: "decimal"},
+	}
+}
+
+// wpuToPoints 将WPU（1/1200英寸）转换为磅
+func wpuToPoints(value uint16) float64 {
+	return float64(value) * 72 / 1200
+}
+
+// readIndex 读取WP 6+前缀区的索引，索引头本身计为第0项
+func (r *wpdReader) readIndex() error {
+	offset := int(r.header.IndexOffset)
+	if offset == 0 {
+		return nil
+	}
+	if offset+wp6IndexEntrySize > len(r.data) {
+		return errWpdStructure
+	}
+	count := int(le.Uint16(r.data[offset+2:]))
+	if offset+count*wp6IndexEntrySize > len(r.data) {
+		return errWpdStructure
+	}
+	r.packets = make([]wpdPacket, count)
+	for i := 1; i < count; i++ {
+		entry := r.data[offset+i*wp6IndexEntrySize:]
+		r.packets[i] = wpdPacket{
+			flags:  entry[0],
+			kind:   entry[1],
+			size:   le.Uint32(entry[6:]),
+			offset: le.Uint32(entry[10:]),
+		}
+	}
+	return nil
+}
+
+// packet 返回前缀ID对应的指定类型数据包内容，不存在或越界时返回nil
+func (r *wpdReader) packet(id uint16, kind byte) []byte {
+	if int(id) >= len(r.packets) || r.packets[id].kind != kind {
+		return nil
+	}
+	p := r.packets[id]
+	if uint64(p.offset)+uint64(p.size) > uint64(len(r.data)) {
+		return nil
+	}
+	return r.data[p.offset : p.offset+p.size]
+}
+
+// fontName 返回字体描述数据包中的字体名称，名称位于偏移22处，前两个字节为名称的字节数
+func (r *wpdReader) fontName(id uint16) string {
+	packet := r.packet(id, wp6PacketFont)
+	if len(packet) < 24 {
+		return ""
+	}
+	size := int(le.Uint16(packet[22:]))
+	name := packet[24:]
+	if size < len(name) {
+		name = name[:size]
+	}
+	return wp6String(name)
+}
+
+// wp6String 解码WP 6+的双字节字符串，每个字符低字节为字符、高字节为字符集，以0结束
+func wp6String(data []byte) string {
+	var text strings.Builder
+	for i := 0; i+1 < len(data); i += 2 {
+		char, set := data[i], data[i+1]
+		if char == 0 && set == 0 {
+			break
+		}
+		text.WriteRune(wpdChar(set, char))
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// readWP5Text 解释WP 5.x正文
+func (r *wpdReader) readWP5Text() error {
+	data := r.data
+	for pos := int(r.header.DocumentOffset); pos < len(data); {
+		c := data[pos]
+		switch {
+		case c >= 0x20 && c < 0x7F:
+			end := pos + 1
+			for end < len(data) && data[end] >= 0x20 && data[end] < 0x7F {
+				end++
+			}
+			r.text(string(data[pos:end]))
+			pos = end
+			continue
+		case c < 0x20:
+			r.wp5Control(c)
+		case c < 0xC0:
+			r.wp5SingleByte(c)
+		case c < 0xD0:
+			pos += r.wp5Fixed(pos)
+			continue
+		default:
+			n, err := r.wp5Group(pos)
+			if err != nil {
+				return err
+			}
+			pos += n
+			continue
+		}
+		pos++
+	}
+	return nil
+}
+
+// wp5Control 处理0x00-0x1F的控制字符
+func (r *wpdReader) wp5Control(c byte) {
+	switch c {
+	case wp5Tab:
+		r.text("\t")
+	case wp5HardReturn:
+		r.endParagraph()
+	case wp5SoftPage:
+		r.pages++
+		r.text(" ")
+	case wp5HardPage:
+		r.hardPage()
+	case wp5SoftReturn:
+		r.text(" ")
+	}
+}
+
+// wp5SingleByte 处理0x80-0xBF的单字节功能码
+func (r *wpdReader) wp5SingleByte(c byte) {
+	switch c {
+	case wp5HardReturnSP:
+		r.endParagraph()
+		r.pages++
+	case wp5HardSpace:
+		r.text("\u00A0")
+	case wp5HardHyphen, wp5HardHyphenEL:
+		r.text("-")
+	}
+}
+
+// wp5Fixed 处理0xC0-0xCF的定长功能码，返回功能码的长度；结束字节不符时只跳过功能码本身
+func (r *wpdReader) wp5Fixed(pos int) int {
+	c := r.data[pos]
+	n := wp5FixedSizes[c-0xC0]
+	if pos+n > len(r.data) || r.data[pos+n-1] != c {
+		return 1
+	}
+	body := r.data[pos+1 : pos+n-1]
+	switch c {
+	case wp5ExtendedChar:
+		r.text(string(wpdChar(body[1], body[0])))
+	case wp5TabGroup:
+		r.text("\t")
+	case wp5AttributeOn, wp5AttributeOff:
+		r.attribute(body[0], c == wp5AttributeOn)
+	}
+	return n
+}
+
+// wp5Group 处理0xD0-0xFF的变长功能组：功能码、子组号、后续字节数，最后一个字节重复功能码
+func (r *wpdReader) wp5Group(pos int) (int, error) {
+	if pos+4 > len(r.data) {
+		return 0, errWpdStructure
+	}
+	c, sub := r.data[pos], r.data[pos+1]
+	n := 4 + int(le.Uint16(r.data[pos+2:]))
+	if pos+n > len(r.data) || r.data[pos+n-1] != c {
+		return 0, errWpdStructure
+	}
+	body := r.data[pos+4 : pos+n]
+
+	switch {
+	case c == wp5PageFormat && sub == wp5LeftRightMargin && len(body) >= 8:
+		r.setMargin(true, le.Uint16(body[4:]))
+		r.setMargin(false, le.Uint16(body[6:]))
+	case c == wp5PageFormat && sub == wp5TopBottomMargin && len(body) >= 8:
+		r.setPageMargins(le.Uint16(body[4:]), le.Uint16(body[6:]))
+	case c == wp5PageFormat && sub == wp5Justification && len(body) >= 2:
+		r.para.alignment = wpdAlignment(body[1])
+	case c == wp5FontGroup && sub == wp5FontChange && len(body) >= 29:
+		// 新字体的编号位于偏移25处，字号（1/50磅）位于偏移27处
+		if size := le.Uint16(body[27:]); size > 0 {
+			r.chars.size = float64(size) / 50
+		}
+	}
+	return n, nil
+}
+
+// readWP6Text 解释WP 6+正文
+func (r *wpdReader) readWP6Text() error {
+	data := r.data
+	for pos := int(r.header.DocumentOffset); pos < len(data); {
+		c := data[pos]
+		switch {
+		case c >= 0x21 && c < 0x7F:
+			end := pos + 1
+			for end < len(data) && data[end] >= 0x21 && data[end] < 0x7F {
+				end++
+			}
+			r.text(string(data[pos:end]))
+			pos = end
+			continue
+		case c >= 0x01 && c <= 0x20:
+			r.text(string(wp6ExtendedInternational[c-1]))
+		case c >= 0x80 && c < 0xD0:
+			r.wp6SingleByte(c)
+		case c >= 0xD0 && c < 0xF0:
+			n, err := r.wp6Group(pos)
+			if err != nil {
+				return err
+			}
+			pos += n
+			continue
+		case c >= 0xF0:
+			pos += r.wp6Fixed(pos)
+			continue
+		}
+		pos++
+	}
+	return nil
+}
+
+// wp6SingleByte 处理0x80-0xCF的单字节功能码
+func (r *wpdReader) wp6SingleByte(c byte) {
+	switch c {
+	case wp6SoftSpace, wp6SoftReturn:
+		r.text(" ")
+	case wp6HardSpace:
+		r.text("\u00A0")
+	case wp6HardHyphen:
+		r.text("-")
+	case wp6HardReturn:
+		r.endParagraph()
+	case wp6HardPage:
+		r.hardPage()
+	}
+}
+
+// wp6Fixed 处理0xF0-0xFF的定长功能码，返回功能码的长度
+//
+// 扩展字符和属性开关的长度固定，其他功能码向后查找相同的结束字节。
+func (r *wpdReader) wp6Fixed(pos int) int {
+	c := r.data[pos]
+	n := 0
+	switch c {
+	case wp6ExtendedChar:
+		n = 4
+	case wp6Undo:
+		n = 5
+	case wp6AttributeOn, wp6AttributeOff:
+		n = 3
+	default:
+		for i := pos + 1; i < len(r.data) && i <= pos+wp6FixedSearchTo; i++ {
+			if r.data[i] == c {
+				return i - pos + 1
+			}
+		}
+		return 1
+	}
+	if pos+n > len(r.data) || r.data[pos+n-1] != c {
+		return 1
+	}
+	switch c {
+	case wp6ExtendedChar:
+		r.text(string(wpdChar(r.data[pos+2], r.data[pos+1])))
+	case wp6AttributeOn, wp6AttributeOff:
+		r.attribute(r.data[pos+1], c == wp6AttributeOn)
+	}
+	return n
+}
+
+// wp6Group 处理0xD0-0xEF的变长功能组，返回功能组的长度
+//
+// 功能组依次为：功能码、子组号、总长度、标志；标志最高位表示之后有前缀ID列表（个数和各ID），
+// 接着是不可删除数据的长度和数据本身，最后一个字节重复功能码。
+func (r *wpdReader) wp6Group(pos int) (int, error) {
+	if pos+4 > len(r.data) {
+		return 0, errWpdStructure
+	}
+	c, sub := r.data[pos], r.data[pos+1]
+	n := int(le.Uint16(r.data[pos+2:]))
+	if n < wp6MinGroupSize || pos+n > len(r.data) || r.data[pos+n-1] != c {
+		return 0, errWpdStructure
+	}
+	end := pos + n - 1
+	p := pos + 5
+	var ids []uint16
+	if r.data[pos+4]&wp6GroupIDsFlag != 0 {
+		count := int(r.data[p])
+		p++
+		for i := 0; i < count && p+2 <= end; i++ {
+			ids = append(ids, le.Uint16(r.data[p:]))
+			p += 2
+		}
+	}
+	p += 2 // 不可删除数据的长度
+	var body []byte
+	if p < end {
+		body = r.data[p:end]
+	}
+
+	switch c {
+	case wp6EOLGroup:
+		r.wp6EndOfLine(sub)
+	case wp6PageGroup:
+		if len(body) >= 2 && sub == wp6TopMargin {
+			r.setPageMargins(le.Uint16(body), 0)
+		} else if len(body) >= 2 && sub == wp6BottomMargin {
+			r.setPageMargins(0, le.Uint16(body))
+		}
+	case wp6ColumnGroup:
+		if len(body) >= 2 && (sub == wp6LeftMargin || sub == wp6RightMargin) {
+			r.setMargin(sub == wp6LeftMargin, le.Uint16(body))
+		}
+	case wp6ParagraphGrp:
+		if len(body) >= 1 && sub == wp6Justification {
+			r.para.alignment = wpdAlignment(body[0])
+		}
+	case wp6CharacterGrp:
+		r.wp6Character(sub, ids, body)
+	case wp6TabGroup:
+		r.text("\t")
+	}
+	return n, nil
+}
+
+// wp6EndOfLine 处理行结束组：软行尾视为空格，硬行尾和表格单元格结束段落，硬分页另起一页
+func (r *wpdReader) wp6EndOfLine(sub byte) {
+	switch {
+	case sub == wp6HardEOP || sub == wp6DelHardEOP:
+		r.hardPage()
+	case sub >= wp6HardEOLFirst && sub <= wp6HardEOLAtEOP,
+		sub >= wp6TableEOLFirst && sub <= wp6TableEOLLast,
+		sub >= wp6DelHardEOLFirst && sub <= wp6DelHardEOLLast:
+		r.endParagraph()
+		if sub == wp6HardEOLAtEOP || sub == wp6DelHardEOLLast {
+			r.pages++
+		}
+	case sub < wp6HardEOLFirst:
+		r.text(" ")
+		if sub == wp6SoftEOLAtEOP {
+			r.pages++
+		}
+	}
+}
+
+// wp6Character 处理字符组中的字体和字号修改
+//
+// 字体修改的第一个前缀ID指向字体描述数据包，数据依次为原字号、名称散列、匹配字体序号和匹配字号。
+func (r *wpdReader) wp6Character(sub byte, ids []uint16, body []byte) {
+	switch sub {
+	case wp6FontFaceChange:
+		if len(ids) > 0 {
+			if name := r.fontName(ids[0]); name != "" {
+				r.chars.font = name
+				r.useFont(name)
+			}
+		}
+		if len(body) >= 8 {
+			if size := le.Uint16(body[6:]); size > 0 {
+				r.chars.size = float64(size) / 50
+			}
+		}
+	case wp6FontSizeChange:
+		if len(body) >= 2 {
+			if size := le.Uint16(body); size > 0 {
+				r.chars.size = float64(size) / 50
+			}
+		}
+	}
+}
+
+// useFont 记录使用过的字体
+func (r *wpdReader) useFont(name string) {
+	for _, font := range r.fonts {
+		if font == name {
+			return
+		}
+	}
+	r.fonts = append(r.fonts, name)
+}
+
+// attribute 打开或关闭字符属性
+func (r *wpdReader) attribute(attr byte, on bool) {
+	switch attr {
+	case wpdAttrBold:
+		r.chars.bold = on
+	case wpdAttrItalic:
+		r.chars.italic = on
+	case wpdAttrUnderline, wpdAttrDoubleUnderline:
+		r.chars.underline = ""
+		if on && attr == wpdAttrUnderline {
+			r.chars.underline = types.UnderlineSingle
+		} else if on {
+			r.chars.underline = types.UnderlineDouble
+		}
+	case wpdAttrSuperscript, wpdAttrSubscript:
+		r.chars.position = ""
+		if on && attr == wpdAttrSuperscript {
+			r.chars.position = types.PositionSuperscript
+		} else if on {
+			r.chars.position = types.PositionSubscript
+		}
+	}
+}
+
+// wpdAlignment 将对齐方式编号转换为段落对齐：0左对齐、1和4两端对齐、2居中、3右对齐
+func wpdAlignment(value byte) types.Alignment {
+	switch value {
+	case 1, 4:
+		return types.AlignJustify
+	case 2:
+		return types.AlignCenter
+	case 3:
+		return types.AlignRight
+	}
+	return types.AlignLeft
+}
+
+// setMargin 设置左边距或右边距：正文之前修改节的页边距，之后的修改转换为段落相对页边距的缩进
+func (r *wpdReader) setMargin(left bool, value uint16) {
+	points := wpuToPoints(value)
+	margins := &r.sections[len(r.sections)-1].PageMargins
+	switch {
+	case !r.sectionText && left:
+		margins.Left = points
+	case !r.sectionText:
+		margins.Right = points
+	case left:
+		r.para.indentation.Left = points - margins.Left
+	default:
+		r.para.indentation.Right = points - margins.Right
+	}
+}
+
+// setPageMargins 设置上下边距，0表示不修改；当前节已有段落时开始新的节
+func (r *wpdReader) setPageMargins(top, bottom uint16) {
+	if r.sectionText {
+		section := r.sections[len(r.sections)-1]
+		section.ID = fmt.Sprintf("section_%d", len(r.sections)+1)
+		r.sections = append(r.sections, section)
+		r.sectionText = false
+	}
+	margins := &r.sections[len(r.sections)-1].PageMargins
+	if top > 0 {
+		margins.Top = wpuToPoints(top)
+	}
+	if bottom > 0 {
+		margins.Bottom = wpuToPoints(bottom)
+	}
+}
+
+// text 以当前字符格式添加文本，格式相同时合并到上一个文本运行
+func (r *wpdReader) text(text string) {
+	if n := len(r.runs); n > 0 && r.runChars[n-1] == r.chars {
+		r.runs[n-1].Text += text
+		return
+	}
+	r.runs = append(r.runs, r.convertRun(r.chars, text))
+	r.runChars = append(r.runChars, r.chars)
+}
+
+// hardPage 硬分页结束当前行，下一段从新的一页开始
+func (r *wpdReader) hardPage() {
+	if len(r.runs) > 0 {
+		r.endParagraph()
+	}
+	r.pageBreak = true
+	r.pages++
+}
+
+// endParagraph 结束当前段落
+func (r *wpdReader) endParagraph() {
+	paragraph := types.Paragraph{
+		Alignment:   r.para.alignment,
+		Indentation: r.para.indentation,
+		Runs:        r.runs,
+		PageBreak:   r.pageBreak,
+	}
+	var text strings.Builder
+	for _, run := range r.runs {
+		text.WriteString(run.Text)
+	}
+	paragraph.Text = text.String()
+
+	i := len(r.paragraphs) + 1
+	setParagraphIDs(&paragraph, fmt.Sprintf("paragraph_%d", i), func(j int) string {
+		return fmt.Sprintf("run_%d_%d", i, j+1)
+	})
+	r.paragraphs = append(r.paragraphs, paragraph)
+	r.runs, r.runChars, r.pageBreak = nil, nil, false
+	r.sectionText = true
+}
+
+// convertRun 将字符格式转换为文本运行
+func (r *wpdReader) convertRun(chars wpdCharState, text string) types.TextRun {
+	font := types.Font{
+		Name:      chars.font,
+		Size:      chars.size,
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+	}
+	return types.TextRun{
+		Text:      text,
+		Font:      font,
+		Bold:      chars.bold,
+		Italic:    chars.italic,
+		Underline: chars.underline,
+		Size:      chars.size,
+		Position:  chars.position,
+	}
+}
+
+// document 生成文档，页数按分页码计算，字数按空白分隔计算
+func (r *wpdReader) document(fileSize int64) *types.Document {
+	words := 0
+	for _, paragraph := range r.paragraphs {
+		words += len(strings.Fields(paragraph.Text))
+	}
+	doc := &types.Document{
+		Metadata: types.DocumentMetadata{
+			Version:   fmt.Sprintf("%d.%d", r.header.Version.Major, r.header.Version.Minor),
+			FileSize:  fileSize,
+			PageCount: r.pages + 1,
+			WordCount: words,
+		},
+		Content: types.DocumentContent{
+			Paragraphs: r.paragraphs,
+			Sections:   r.sections,
+		},
+		Styles: types.DocumentStyles{
+			ParagraphStyles: []types.ParagraphStyle{},
+			CharacterStyles: []types.CharacterStyle{},
+			TableStyles:     []types.TableStyle{},
+		},
+	}
+	doc.FormatRules = contentFormatRules(doc, r.fonts)
+	return doc
+}
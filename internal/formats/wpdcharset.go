@@ -0,0 +1,75 @@
+package formats
+
+// WordPerfect字符集到Unicode的映射
+//
+// WordPerfect用（字符集, 字符）两个字节表示扩展字符，5.x和6.x+使用相同的字符集编号。
+// 这里覆盖常用的字符集：0 ASCII、1 多语言拉丁字母、4 排版符号和8 希腊字母，其余字符映射为U+FFFD。
+
+// wp6ExtendedInternational WP 6+正文中0x01-0x20单字节表示的默认扩展国际字符
+var wp6ExtendedInternational = [32]rune{
+	'å', 'Å', 'æ', 'Æ', 'ä', 'Ä', 'á', 'à', 'â', 'ã', 'Ã', 'ç', 'Ç', 'ë', 'é', 'É',
+	'è', 'ê', 'í', 'ñ', 'Ñ', 'ø', 'Ø', 'õ', 'Õ', 'ö', 'Ö', 'ü', 'Ü', 'ú', 'ù', 'ß',
+}
+
+// wpdMultinational 字符集1（多语言），从字符23开始，之前的字符为单独的变音符号
+var wpdMultinational = []rune{
+	'ß', 'ĸ', 0, 'Á', 'á', 'Â', 'â', 'Ä', 'ä', // 23-31
+	'À', 'à', 'Å', 'å', 'Æ', 'æ', 'Ç', 'ç', // 32-39
+	'É', 'é', 'Ê', 'ê', 'Ë', 'ë', 'È', 'è', // 40-47
+	'Í', 'í', 'Î', 'î', 'Ï', 'ï', 'Ì', 'ì', // 48-55
+	'Ñ', 'ñ', 'Ó', 'ó', 'Ô', 'ô', 'Ö', 'ö', // 56-63
+	'Ò', 'ò', 'Ú', 'ú', 'Û', 'û', 'Ü', 'ü', // 64-71
+	'Ù', 'ù', 'Ÿ', 'ÿ', 'Ã', 'ã', 'Đ', 'đ', // 72-79
+	'Ø', 'ø', 'Õ', 'õ', 'Ý', 'ý', 'Ð', 'ð', // 80-87
+	'Þ', 'þ', 'Ă', 'ă', 'Ā', 'ā', 'Ą', 'ą', // 88-95
+	'Ć', 'ć', 'Č', 'č', 'Ĉ', 'ĉ', 'Ċ', 'ċ', // 96-103
+	'Ď', 'ď', 'Ě', 'ě', 'Ė', 'ė', 'Ē', 'ē', // 104-111
+	'Ę', 'ę', 'Ĝ', 'ĝ', 'Ğ', 'ğ', 'Ģ', 'ģ', // 112-119
+	'Ġ', 'ġ', 'Ĥ', 'ĥ', 'Ħ', 'ħ', // 120-125
+}
+
+// wpdMultinationalFirst 字符集1映射表中第一个字符的编号
+const wpdMultinationalFirst = 23
+
+// wpdTypographic 字符集4（排版符号）
+var wpdTypographic = []rune{
+	'•', '◦', '■', 0, 0, '¶', '§', '¡', // 0-7
+	'¿', '«', '»', '£', '¥', '₧', 'ƒ', 'ª', // 8-15
+	'º', '½', '¼', '¢', '²', 'ⁿ', '®', '©', // 16-23
+	'¤', '¾', '³', '‛', '’', '‘', '‟', '”', // 24-31
+	'“', '–', '—', '‹', '›', '○', '□', '†', // 32-39
+	'‡', '™', '℠', '℞', // 40-43
+}
+
+// wpdGreek 字符集8（希腊字母），大小写成对排列，β和σ各有一个变体
+var wpdGreek = []rune{
+	'Α', 'α', 'Β', 'β', 'Β', 'ϐ', 'Γ', 'γ', // 0-7
+	'Δ', 'δ', 'Ε', 'ε', 'Ζ', 'ζ', 'Η', 'η', // 8-15
+	'Θ', 'θ', 'Ι', 'ι', 'Κ', 'κ', 'Λ', 'λ', // 16-23
+	'Μ', 'μ', 'Ν', 'ν', 'Ξ', 'ξ', 'Ο', 'ο', // 24-31
+	'Π', 'π', 'Ρ', 'ρ', 'Σ', 'σ', 'Σ', 'ς', // 32-39
+	'Τ', 'τ', 'Υ', 'υ', 'Φ', 'φ', 'Χ', 'χ', // 40-47
+	'Ψ', 'ψ', 'Ω', 'ω', // 48-51
+}
+
+// wpdChar 返回WordPerfect字符集中字符对应的Unicode字符，未知字符返回U+FFFD
+func wpdChar(set, char byte) rune {
+	var table []rune
+	index := int(char)
+	switch set {
+	case 0:
+		if char >= 0x20 && char < 0x7F {
+			return rune(char)
+		}
+	case 1:
+		table, index = wpdMultinational, index-wpdMultinationalFirst
+	case 4:
+		table = wpdTypographic
+	case 8:
+		table = wpdGreek
+	}
+	if index >= 0 && index < len(table) && table[index] != 0 {
+		return table[index]
+	}
+	return '�'
+}
@@ -0,0 +1,657 @@
+// Package cfb 读取复合文件二进制格式（Compound File Binary，即OLE2结构化存储）
+//
+// .doc、.dot、.xls等旧版Office文档、加密的OOXML文档和嵌入的OLE对象都使用这种容器。
+// 支持版本3（512字节扇区）和版本4（4096字节扇区），读取FAT、MiniFAT、DIFAT和目录树，
+// 并以io.ReaderAt的形式提供流的内容。所有扇区链在打开时检查越界和循环，损坏的文件返回ErrInvalid。
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Signature 复合文件头部的8字节签名
+var Signature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// ErrInvalid 文件不是有效的复合文件或结构已损坏
+var ErrInvalid = errors.New("invalid compound file")
+
+// 扇区编号中的特殊值
+const (
+	maxRegularSector uint32 = 0xFFFFFFFA
+	difatSector      uint32 = 0xFFFFFFFC
+	fatSector        uint32 = 0xFFFFFFFD
+	endOfChain       uint32 = 0xFFFFFFFE
+	freeSector       uint32 = 0xFFFFFFFF
+	noStream         uint32 = 0xFFFFFFFF
+)
+
+const (
+	headerSize         = 512
+	headerDIFATCount   = 109
+	directoryEntrySize = 128
+)
+
+// EntryType 目录项类型
+type EntryType uint8
+
+// 目录项类型
+const (
+	TypeUnknown EntryType = 0
+	TypeStorage EntryType = 1
+	TypeStream  EntryType = 2
+	TypeRoot    EntryType = 5
+)
+
+// String 返回目录项类型的名称
+func (t EntryType) String() string {
+	switch t {
+	case TypeStorage:
+		return "storage"
+	case TypeStream:
+		return "stream"
+	case TypeRoot:
+		return "root"
+	}
+	return "unknown"
+}
+
+// Entry 目录项，即存储（目录）或流（文件）
+type Entry struct {
+	Name     string
+	Path     string // 从根存储开始以"/"分隔的路径，根存储为""
+	Type     EntryType
+	CLSID    [16]byte
+	Created  time.Time
+	Modified time.Time
+	Size     int64    // 流的字节数，存储为0
+	Children []*Entry // 存储中的子项，按复合文件的名称顺序排列
+
+	start              uint32
+	left, right, child uint32
+}
+
+// IsStream 判断目录项是否为流
+func (e *Entry) IsStream() bool {
+	return e.Type == TypeStream
+}
+
+// IsStorage 判断目录项是否为存储（包括根存储）
+func (e *Entry) IsStorage() bool {
+	return e.Type == TypeStorage || e.Type == TypeRoot
+}
+
+// File 打开的复合文件
+type File struct {
+	r              io.ReaderAt
+	closer         io.Closer
+	size           int64
+	version        int
+	sectorSize     int64
+	miniSectorSize int64
+	miniCutoff     int64
+	fat            []uint32
+	miniFAT        []uint32
+	entries        []*Entry // 按目录项编号索引，未使用的编号为nil
+	root           *Entry
+	miniStream     *Stream
+}
+
+// IsCFB 判断数据是否以复合文件签名开头
+func IsCFB(data []byte) bool {
+	return len(data) >= len(Signature) && bytes.Equal(data[:len(Signature)], Signature)
+}
+
+// Open 打开复合文件，使用完毕后应调用Close
+func Open(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open compound file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat compound file: %w", err)
+	}
+
+	cf, err := NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	cf.closer = file
+	return cf, nil
+}
+
+// NewReader 从r读取大小为size的复合文件
+func NewReader(r io.ReaderAt, size int64) (*File, error) {
+	cf := &File{r: r, size: size}
+	if err := cf.readHeader(); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+// Close 关闭由Open打开的文件
+func (cf *File) Close() error {
+	if cf.closer != nil {
+		return cf.closer.Close()
+	}
+	return nil
+}
+
+// Version 返回复合文件的主版本号（3或4）
+func (cf *File) Version() int {
+	return cf.version
+}
+
+// Root 返回根存储
+func (cf *File) Root() *Entry {
+	return cf.root
+}
+
+// Entries 按目录树的深度优先顺序返回所有存储和流，不包括根存储
+func (cf *File) Entries() []*Entry {
+	var entries []*Entry
+	var walk func(entry *Entry)
+	walk = func(entry *Entry) {
+		for _, child := range entry.Children {
+			entries = append(entries, child)
+			walk(child)
+		}
+	}
+	walk(cf.root)
+	return entries
+}
+
+// Entry 按路径查找目录项，路径以"/"分隔，名称比较不区分大小写
+func (cf *File) Entry(path string) (*Entry, error) {
+	entry := cf.root
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		var found *Entry
+		for _, child := range entry.Children {
+			if strings.EqualFold(child.Name, name) {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("entry not found: %s", path)
+		}
+		entry = found
+	}
+	return entry, nil
+}
+
+// Open 按路径打开流
+func (cf *File) Open(path string) (*Stream, error) {
+	entry, err := cf.Entry(path)
+	if err != nil {
+		return nil, err
+	}
+	return cf.OpenEntry(entry)
+}
+
+// ReadStream 读取流的全部内容
+func (cf *File) ReadStream(path string) ([]byte, error) {
+	stream, err := cf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, stream.Size())
+	if _, err := stream.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read stream %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// OpenEntry 打开目录项对应的流，小于MiniStreamCutoff的流从迷你流中读取
+func (cf *File) OpenEntry(entry *Entry) (*Stream, error) {
+	if !entry.IsStream() {
+		return nil, fmt.Errorf("entry is not a stream: %s", entry.Path)
+	}
+	if entry.Size == 0 {
+		return &Stream{sectorSize: cf.sectorSize}, nil
+	}
+
+	if entry.Size >= cf.miniCutoff {
+		return cf.newStream(entry.start, entry.Size, entry.Path)
+	}
+
+	miniStream, err := cf.openMiniStream()
+	if err != nil {
+		return nil, err
+	}
+	sectors, err := followChain(entry.start, cf.miniFAT, int((miniStream.Size()+cf.miniSectorSize-1)/cf.miniSectorSize), entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(sectors))*cf.miniSectorSize < entry.Size {
+		return nil, fmt.Errorf("%w: stream %s is longer than its mini sector chain", ErrInvalid, entry.Path)
+	}
+	offsets := make([]int64, len(sectors))
+	for i, sector := range sectors {
+		offsets[i] = int64(sector) * cf.miniSectorSize
+		if offsets[i] >= miniStream.Size() {
+			return nil, fmt.Errorf("%w: mini sector %d of %s is outside the mini stream", ErrInvalid, sector, entry.Path)
+		}
+	}
+	return &Stream{r: miniStream, offsets: offsets, sectorSize: cf.miniSectorSize, size: entry.Size}, nil
+}
+
+// openMiniStream 打开根存储中保存迷你扇区的流
+func (cf *File) openMiniStream() (*Stream, error) {
+	if cf.miniStream == nil {
+		stream, err := cf.newStream(cf.root.start, cf.root.Size, "mini stream")
+		if err != nil {
+			return nil, err
+		}
+		cf.miniStream = stream
+	}
+	return cf.miniStream, nil
+}
+
+// newStream 创建从start开始的普通扇区链上的流
+func (cf *File) newStream(start uint32, size int64, name string) (*Stream, error) {
+	sectors, err := followChain(start, cf.fat, cf.sectorCount(), name)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(sectors))*cf.sectorSize < size {
+		return nil, fmt.Errorf("%w: stream %s is longer than its sector chain", ErrInvalid, name)
+	}
+	offsets := make([]int64, len(sectors))
+	for i, sector := range sectors {
+		if offsets[i], err = cf.sectorOffset(sector); err != nil {
+			return nil, err
+		}
+	}
+	return &Stream{r: cf.r, offsets: offsets, sectorSize: cf.sectorSize, size: size}, nil
+}
+
+// sectorOffset 返回扇区在文件中的偏移，头部占用第一个扇区
+func (cf *File) sectorOffset(sector uint32) (int64, error) {
+	offset := (int64(sector) + 1) * cf.sectorSize
+	if sector > maxRegularSector || offset >= cf.size {
+		return 0, fmt.Errorf("%w: sector %d is outside the file", ErrInvalid, sector)
+	}
+	return offset, nil
+}
+
+// readSector 读取一个完整扇区，文件末尾不足一个扇区的部分补零
+func (cf *File) readSector(sector uint32) ([]byte, error) {
+	offset, err := cf.sectorOffset(sector)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, cf.sectorSize)
+	if _, err := cf.r.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read sector %d: %w", sector, err)
+	}
+	return data, nil
+}
+
+// sectorCount 返回文件中可能存在的扇区数，用于限制表和链的长度
+func (cf *File) sectorCount() int {
+	return int((cf.size + cf.sectorSize - 1) / cf.sectorSize)
+}
+
+// readHeader 读取并检查文件头，然后读取FAT、目录和MiniFAT
+func (cf *File) readHeader() error {
+	header := make([]byte, headerSize)
+	if n, err := cf.r.ReadAt(header, 0); n < headerSize {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalid, err)
+	}
+	if !IsCFB(header) {
+		return fmt.Errorf("%w: bad signature", ErrInvalid)
+	}
+
+	le := binary.LittleEndian
+	if le.Uint16(header[28:]) != 0xFFFE {
+		return fmt.Errorf("%w: bad byte order mark", ErrInvalid)
+	}
+	cf.version = int(le.Uint16(header[26:]))
+	sectorShift := le.Uint16(header[30:])
+	switch {
+	case cf.version == 3 && sectorShift == 9, cf.version == 4 && sectorShift == 12:
+	default:
+		return fmt.Errorf("%w: unsupported version %d with sector shift %d", ErrInvalid, cf.version, sectorShift)
+	}
+	if miniShift := le.Uint16(header[32:]); miniShift != 6 {
+		return fmt.Errorf("%w: unsupported mini sector shift %d", ErrInvalid, miniShift)
+	}
+	cf.sectorSize = 1 << sectorShift
+	cf.miniSectorSize = 64
+	cf.miniCutoff = int64(le.Uint32(header[56:]))
+	if cf.miniCutoff != 4096 {
+		return fmt.Errorf("%w: unsupported mini stream cutoff %d", ErrInvalid, cf.miniCutoff)
+	}
+
+	fatSectors := le.Uint32(header[44:])
+	firstDirectory := le.Uint32(header[48:])
+	firstMiniFAT := le.Uint32(header[60:])
+	miniFATSectors := le.Uint32(header[64:])
+	firstDIFAT := le.Uint32(header[68:])
+	difatSectors := le.Uint32(header[72:])
+	if int64(fatSectors) > int64(cf.sectorCount()) || int64(miniFATSectors) > int64(cf.sectorCount()) || int64(difatSectors) > int64(cf.sectorCount()) {
+		return fmt.Errorf("%w: sector counts exceed the file size", ErrInvalid)
+	}
+
+	difat := make([]uint32, 0, headerDIFATCount)
+	for i := 0; i < headerDIFATCount; i++ {
+		difat = append(difat, le.Uint32(header[76+4*i:]))
+	}
+	if err := cf.readFAT(difat, int(fatSectors), firstDIFAT, int(difatSectors)); err != nil {
+		return err
+	}
+	if err := cf.readDirectory(firstDirectory); err != nil {
+		return err
+	}
+	return cf.readMiniFAT(firstMiniFAT, int(miniFATSectors))
+}
+
+// readFAT 按DIFAT读取FAT：文件头中有前109个FAT扇区的位置，其余在DIFAT扇区链中
+func (cf *File) readFAT(difat []uint32, fatSectors int, firstDIFAT uint32, difatSectors int) error {
+	perSector := int(cf.sectorSize / 4)
+	sector := firstDIFAT
+	for i := 0; i < difatSectors && len(difat) < fatSectors; i++ {
+		if sector > maxRegularSector {
+			return fmt.Errorf("%w: DIFAT chain ends after %d of %d sectors", ErrInvalid, i, difatSectors)
+		}
+		data, err := cf.readSector(sector)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < perSector-1; j++ {
+			difat = append(difat, binary.LittleEndian.Uint32(data[4*j:]))
+		}
+		sector = binary.LittleEndian.Uint32(data[4*(perSector-1):])
+	}
+	if len(difat) < fatSectors {
+		return fmt.Errorf("%w: DIFAT lists fewer than %d FAT sectors", ErrInvalid, fatSectors)
+	}
+
+	cf.fat = make([]uint32, 0, fatSectors*perSector)
+	for i := 0; i < fatSectors; i++ {
+		data, err := cf.readSector(difat[i])
+		if err != nil {
+			return fmt.Errorf("FAT sector %d: %w", i, err)
+		}
+		for j := 0; j < perSector; j++ {
+			cf.fat = append(cf.fat, binary.LittleEndian.Uint32(data[4*j:]))
+		}
+	}
+	return nil
+}
+
+// readMiniFAT 读取MiniFAT扇区链
+func (cf *File) readMiniFAT(first uint32, sectors int) error {
+	if sectors == 0 || first == endOfChain {
+		return nil
+	}
+	chain, err := followChain(first, cf.fat, cf.sectorCount(), "MiniFAT")
+	if err != nil {
+		return err
+	}
+	if len(chain) < sectors {
+		sectors = len(chain)
+	}
+	perSector := int(cf.sectorSize / 4)
+	cf.miniFAT = make([]uint32, 0, sectors*perSector)
+	for _, sector := range chain[:sectors] {
+		data, err := cf.readSector(sector)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < perSector; j++ {
+			cf.miniFAT = append(cf.miniFAT, binary.LittleEndian.Uint32(data[4*j:]))
+		}
+	}
+	return nil
+}
+
+// readDirectory 读取目录扇区链中的所有目录项，并从根存储开始建立目录树
+func (cf *File) readDirectory(first uint32) error {
+	chain, err := followChain(first, cf.fat, cf.sectorCount(), "directory")
+	if err != nil {
+		return err
+	}
+
+	perSector := int(cf.sectorSize / directoryEntrySize)
+	cf.entries = make([]*Entry, len(chain)*perSector)
+	for i, sector := range chain {
+		data, err := cf.readSector(sector)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < perSector; j++ {
+			cf.entries[i*perSector+j] = cf.parseEntry(data[j*directoryEntrySize : (j+1)*directoryEntrySize])
+		}
+	}
+
+	if len(cf.entries) == 0 || cf.entries[0] == nil || cf.entries[0].Type != TypeRoot {
+		return fmt.Errorf("%w: missing root entry", ErrInvalid)
+	}
+	cf.root = cf.entries[0]
+	cf.root.Name, cf.root.Path = "", ""
+	return cf.buildTree()
+}
+
+// parseEntry 解析128字节的目录项，未使用的目录项返回nil
+func (cf *File) parseEntry(data []byte) *Entry {
+	le := binary.LittleEndian
+	entryType := EntryType(data[66])
+	if entryType != TypeStorage && entryType != TypeStream && entryType != TypeRoot {
+		return nil
+	}
+
+	nameLength := int(le.Uint16(data[64:]))
+	if nameLength > 64 {
+		nameLength = 64
+	}
+	units := make([]uint16, 0, nameLength/2)
+	for i := 0; i+1 < nameLength; i += 2 {
+		unit := le.Uint16(data[i:])
+		if unit == 0 {
+			break
+		}
+		units = append(units, unit)
+	}
+
+	entry := &Entry{
+		Name:     string(utf16.Decode(units)),
+		Type:     entryType,
+		Created:  fileTime(le.Uint64(data[100:])),
+		Modified: fileTime(le.Uint64(data[108:])),
+		start:    le.Uint32(data[116:]),
+		left:     le.Uint32(data[68:]),
+		right:    le.Uint32(data[72:]),
+		child:    le.Uint32(data[76:]),
+	}
+	copy(entry.CLSID[:], data[80:96])
+
+	size := le.Uint64(data[120:])
+	if cf.version == 3 {
+		// 版本3中高32位可能是未初始化的数据
+		size &= 0xFFFFFFFF
+	}
+	if size > uint64(cf.size)*64 || size > 1<<62 {
+		// 超出文件可能容纳的大小，打开流时按扇区链检查
+		size = 1 << 62
+	}
+	if entryType == TypeStream || entryType == TypeRoot {
+		entry.Size = int64(size)
+	}
+	return entry
+}
+
+// buildTree 遍历每个存储的红黑树得到子项，每个目录项只能出现一次
+func (cf *File) buildTree() error {
+	seen := make([]bool, len(cf.entries))
+	seen[0] = true
+	storages := []*Entry{cf.root}
+
+	for len(storages) > 0 {
+		storage := storages[len(storages)-1]
+		storages = storages[:len(storages)-1]
+
+		// 中序遍历红黑树，结果即复合文件的名称顺序
+		var stack []uint32
+		id := storage.child
+		for id != noStream || len(stack) > 0 {
+			for id != noStream {
+				if int64(id) >= int64(len(cf.entries)) || cf.entries[id] == nil {
+					return fmt.Errorf("%w: directory entry %d is missing", ErrInvalid, id)
+				}
+				if seen[id] {
+					return fmt.Errorf("%w: directory entry %d is referenced twice", ErrInvalid, id)
+				}
+				seen[id] = true
+				stack = append(stack, id)
+				id = cf.entries[id].left
+			}
+
+			id = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			entry := cf.entries[id]
+			if entry.Type == TypeRoot {
+				return fmt.Errorf("%w: root entry inside storage %q", ErrInvalid, storage.Path)
+			}
+			entry.Path = entry.Name
+			if storage.Path != "" {
+				entry.Path = storage.Path + "/" + entry.Name
+			}
+			storage.Children = append(storage.Children, entry)
+			if entry.Type == TypeStorage {
+				storages = append(storages, entry)
+			}
+			id = entry.right
+		}
+	}
+	return nil
+}
+
+// followChain 返回从start开始的扇区链
+//
+// 不含重复扇区的链不会长于表和扇区总数limit，超过时说明链是循环的。
+func followChain(start uint32, table []uint32, limit int, name string) ([]uint32, error) {
+	if limit > len(table) {
+		limit = len(table)
+	}
+	var chain []uint32
+	for sector := start; sector != endOfChain; sector = table[sector] {
+		if sector > maxRegularSector || int64(sector) >= int64(len(table)) {
+			return nil, fmt.Errorf("%w: %s chain points to invalid sector %#x", ErrInvalid, name, sector)
+		}
+		if len(chain) >= limit {
+			return nil, fmt.Errorf("%w: %s chain is cyclic", ErrInvalid, name)
+		}
+		chain = append(chain, sector)
+	}
+	return chain, nil
+}
+
+// fileTime 将Windows FILETIME（自1601年起的100纳秒数）转换为时间，0表示未设置
+func fileTime(value uint64) time.Time {
+	if value == 0 {
+		return time.Time{}
+	}
+	const epochDifference = 116444736000000000 // 1601-01-01到1970-01-01的100纳秒数
+	if value < epochDifference {
+		return time.Time{}
+	}
+	ticks := value - epochDifference
+	return time.Unix(int64(ticks/10000000), int64(ticks%10000000)*100).UTC()
+}
+
+// Stream 流的内容，按扇区映射到文件或迷你流中的位置
+type Stream struct {
+	r          io.ReaderAt
+	offsets    []int64 // 每个扇区在r中的偏移
+	sectorSize int64
+	size       int64
+	position   int64
+}
+
+// Size 返回流的字节数
+func (s *Stream) Size() int64 {
+	return s.size
+}
+
+// ReadAt 实现io.ReaderAt
+func (s *Stream) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= s.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(p) && off < s.size {
+		index := off / s.sectorSize
+		within := off % s.sectorSize
+		chunk := s.sectorSize - within
+		if remaining := s.size - off; chunk > remaining {
+			chunk = remaining
+		}
+		if chunk > int64(len(p)-n) {
+			chunk = int64(len(p) - n)
+		}
+
+		read, err := s.r.ReadAt(p[n:n+int(chunk)], s.offsets[index]+within)
+		n += read
+		off += int64(read)
+		if read < int(chunk) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read 实现io.Reader
+func (s *Stream) Read(p []byte) (int, error) {
+	n, err := s.ReadAt(p, s.position)
+	s.position += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek 实现io.Seeker
+func (s *Stream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.position
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative position %d", offset)
+	}
+	s.position = offset
+	return offset, nil
+}
@@ -0,0 +1,145 @@
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"docs-parser/internal/testutil"
+)
+
+// buildTestFile 构造一个复合文件：根存储下有1Table（迷你流）、WordDocument（普通扇区）
+// 和存储ObjectPool，ObjectPool中有\x01Ole10Native（迷你流）
+//
+// 版本3的扇区布局：0为FAT，1-2为目录，3为MiniFAT，4为迷你流，5起为WordDocument；
+// 目录项1-4依次为1Table、WordDocument、ObjectPool和\x01Ole10Native。
+func buildTestFile(version int, document, table, native []byte) []byte {
+	return testutil.CFBBytes(version,
+		testutil.CFBEntry{Name: "1Table", Data: table},
+		testutil.CFBEntry{Name: "WordDocument", Data: document},
+		testutil.CFBEntry{Name: "ObjectPool", Storage: true, Children: []testutil.CFBEntry{
+			{Name: "\x01Ole10Native", Data: native},
+		}},
+	)
+}
+
+// testContent 返回指定长度、内容可区分的数据
+func testContent(size int, seed byte) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7) + seed
+	}
+	return data
+}
+
+// TestReader 测试读取版本3和版本4的复合文件中的普通流、迷你流和嵌套存储
+func TestReader(t *testing.T) {
+	document := testContent(10000, 1)
+	table := testContent(300, 2)
+	native := testContent(100, 3)
+
+	for _, version := range []int{3, 4} {
+		data := buildTestFile(version, document, table, native)
+		cf, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("版本%d: 打开复合文件失败: %v", version, err)
+		}
+		if cf.Version() != version {
+			t.Errorf("版本应为%d，实际 %d", version, cf.Version())
+		}
+
+		var paths []string
+		for _, entry := range cf.Entries() {
+			paths = append(paths, entry.Path)
+		}
+		want := []string{"1Table", "WordDocument", "ObjectPool", "ObjectPool/\x01Ole10Native"}
+		if len(paths) != len(want) {
+			t.Fatalf("版本%d: 目录项应为 %q，实际 %q", version, want, paths)
+		}
+		for i := range want {
+			if paths[i] != want[i] {
+				t.Errorf("版本%d: 目录项应为 %q，实际 %q", version, want, paths)
+				break
+			}
+		}
+
+		for path, content := range map[string][]byte{"WordDocument": document, "1table": table, "ObjectPool/\x01Ole10Native": native} {
+			got, err := cf.ReadStream(path)
+			if err != nil {
+				t.Fatalf("版本%d: 读取%q失败: %v", version, path, err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("版本%d: %q的内容不正确", version, path)
+			}
+		}
+
+		// 跨扇区的随机读取
+		stream, err := cf.Open("WordDocument")
+		if err != nil {
+			t.Fatalf("打开流失败: %v", err)
+		}
+		stream.Seek(500, io.SeekStart)
+		part := make([]byte, 30)
+		if _, err := io.ReadFull(stream, part); err != nil || !bytes.Equal(part, document[500:530]) {
+			t.Errorf("版本%d: 跨扇区读取不正确: %v", version, err)
+		}
+		if _, err := cf.Open("ObjectPool"); err == nil {
+			t.Error("打开存储应返回错误")
+		}
+	}
+}
+
+// TestReader_Corrupted 测试损坏的扇区链返回ErrInvalid
+func TestReader_Corrupted(t *testing.T) {
+	data := buildTestFile(3, testContent(5000, 1), testContent(300, 2), testContent(100, 3))
+	le := binary.LittleEndian
+	fatOffset := 512
+
+	cases := map[string]func(data []byte){
+		"签名错误":       func(data []byte) { data[0] = 0 },
+		"目录链越界":      func(data []byte) { le.PutUint32(data[48:], 1000) },
+		"FAT链循环":     func(data []byte) { le.PutUint32(data[fatOffset+4*6:], 5) },
+		"流扇区越界":      func(data []byte) { le.PutUint32(data[fatOffset+4*6:], 900) },
+		"目录树循环":      func(data []byte) { le.PutUint32(data[1024+3*directoryEntrySize+76:], 1) },
+		"MiniFAT链循环": func(data []byte) { le.PutUint32(data[2048+4*4:], 2) },
+	}
+	for name, corrupt := range cases {
+		corrupted := append([]byte(nil), data...)
+		corrupt(corrupted)
+
+		cf, err := NewReader(bytes.NewReader(corrupted), int64(len(corrupted)))
+		if err == nil {
+			for _, entry := range cf.Entries() {
+				if !entry.IsStream() {
+					continue
+				}
+				if _, err = cf.ReadStream(entry.Path); err != nil {
+					break
+				}
+			}
+		}
+		if !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: 应返回ErrInvalid，实际 %v", name, err)
+		}
+	}
+}
+
+// FuzzReader 读取损坏的复合文件时不能崩溃或陷入循环
+func FuzzReader(f *testing.F) {
+	f.Add(buildTestFile(3, testContent(5000, 1), testContent(300, 2), testContent(100, 3)))
+	f.Add(buildTestFile(4, testContent(5000, 1), testContent(300, 2), testContent(100, 3)))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cf, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		for _, entry := range cf.Entries() {
+			if stream, err := cf.OpenEntry(entry); err == nil {
+				io.Copy(io.Discard, stream)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package packaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Flat OPC（单文件XML形式的OOXML包）
+//
+// 根元素pkg:package中的每个pkg:part对应包中的一个部件：XML部件的内容在pkg:xmlData中，二进制部件以Base64存放在
+// pkg:binaryData中，内容类型写在pkg:contentType属性上。读取时生成等价的[Content_Types].xml，
+// 部件写入内存中的ZIP归档，之后与ZIP包使用相同的部件访问方法。
+
+// FlatPart Flat OPC中的一个部件
+type FlatPart struct {
+	Name        string // 部件名，不带开头的"/"
+	ContentType string
+	Data        []byte
+}
+
+// IsFlatOPC 判断文件开头是否为Flat OPC的pkg:package根元素
+func IsFlatOPC(head []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(head))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "package" && strings.Contains(start.Name.Space, "xmlPackage")
+		}
+	}
+}
+
+// ReadFlatOPC 读取Flat OPC文档中的所有部件
+func ReadFlatOPC(r io.Reader) ([]FlatPart, error) {
+	decoder := xml.NewDecoder(r)
+	var parts []FlatPart
+	root := true
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse flat OPC package: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root {
+			if start.Name.Local != "package" {
+				return nil, fmt.Errorf("root element is %s, not pkg:package", start.Name.Local)
+			}
+			root = false
+			continue
+		}
+		if start.Name.Local != "part" {
+			continue
+		}
+
+		var part struct {
+			Name        string `xml:"name,attr"`
+			ContentType string `xml:"contentType,attr"`
+			XMLData     struct {
+				Inner []byte `xml:",innerxml"`
+			} `xml:"xmlData"`
+			BinaryData string `xml:"binaryData"`
+		}
+		if err := decoder.DecodeElement(&part, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse part: %w", err)
+		}
+		name := strings.TrimPrefix(part.Name, "/")
+		if name == "" {
+			return nil, fmt.Errorf("part without a name")
+		}
+
+		data := bytes.TrimSpace(part.XMLData.Inner)
+		if binary := strings.Join(strings.Fields(part.BinaryData), ""); binary != "" {
+			data, err = base64.StdEncoding.DecodeString(binary)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode binary part %s: %w", name, err)
+			}
+		} else if len(data) > 0 {
+			data = append([]byte(xml.Header), data...)
+		}
+		parts = append(parts, FlatPart{Name: name, ContentType: part.ContentType, Data: data})
+	}
+	if root {
+		return nil, fmt.Errorf("empty flat OPC package")
+	}
+	return parts, nil
+}
+
+// flatContentTypes 生成与部件内容类型等价的[Content_Types].xml
+func flatContentTypes(parts []FlatPart) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	for _, part := range parts {
+		if part.ContentType == "" {
+			continue
+		}
+		buf.WriteString(`<Override PartName="/`)
+		xml.EscapeText(&buf, []byte(part.Name))
+		buf.WriteString(`" ContentType="`)
+		xml.EscapeText(&buf, []byte(part.ContentType))
+		buf.WriteString(`"/>`)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.Bytes()
+}
+
+// openFlat 将Flat OPC的部件写入内存中的ZIP归档并建立索引
+func (oc *OPCContainer) openFlat(data []byte) error {
+	parts, err := ReadFlatOPC(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	write := func(name string, content []byte) error {
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+	if err := write("[Content_Types].xml", flatContentTypes(parts)); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if part.Name == "[Content_Types].xml" {
+			continue
+		}
+		if err := write(part.Name, part.Data); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		return err
+	}
+	for _, file := range reader.File {
+		oc.Files[file.Name] = file
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package packaging
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+)
+
+// OpenDocument文本文档包的mimetype
+const (
+	MimeTypeText         = "application/vnd.oasis.opendocument.text"
+	MimeTypeTextTemplate = "application/vnd.oasis.opendocument.text-template"
+)
+
+// ODFMimeType 读取ODF包中mimetype文件声明的媒体类型，不存在时返回空字符串
+//
+// 规范要求mimetype是归档中第一个不压缩的文件，这里不检查位置和压缩方式，以兼容重新打包过的文件。
+func ODFMimeType(files []*zip.File) string {
+	for _, file := range files {
+		if file.Name != "mimetype" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return ""
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(io.LimitReader(reader, 256))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
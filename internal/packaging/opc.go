@@ -2,11 +2,35 @@ package packaging
 
 import (
 	"archive/zip"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path"
 	"strings"
 )
 
+// WordprocessingML主文档部件的内容类型
+const (
+	ContentTypeDocument      = "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"
+	ContentTypeTemplate      = "application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml"
+	ContentTypeMacroDocument = "application/vnd.ms-word.document.macroEnabled.main+xml"
+	ContentTypeMacroTemplate = "application/vnd.ms-word.template.macroEnabledTemplate.main+xml"
+)
+
+// DefaultMainPart [Content_Types].xml未声明主文档部件时使用的默认位置
+const DefaultMainPart = "word/document.xml"
+
+// IsMainContentType 是否为WordprocessingML主文档部件（文档、模板或启用宏的文件）的内容类型
+func IsMainContentType(contentType string) bool {
+	switch contentType {
+	case ContentTypeDocument, ContentTypeTemplate, ContentTypeMacroDocument, ContentTypeMacroTemplate:
+		return true
+	}
+	return false
+}
+
 // OPCContainer 表示Open Packaging Convention容器
 type OPCContainer struct {
 	Path     string
@@ -24,9 +48,17 @@ func NewOPCContainer(path string) *OPCContainer {
 	}
 }
 
-// Open 打开OPC容器
+// Open 打开OPC容器，不是ZIP格式时按Flat OPC（单文件XML）读取
 func (oc *OPCContainer) Open() error {
 	reader, err := zip.OpenReader(oc.Path)
+	if errors.Is(err, zip.ErrFormat) {
+		if data, readErr := os.ReadFile(oc.Path); readErr == nil && IsFlatOPC(data) {
+			if err := oc.openFlat(data); err != nil {
+				return fmt.Errorf("failed to open flat OPC package: %w", err)
+			}
+			return nil
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open OPC container: %w", err)
 	}
@@ -82,37 +114,53 @@ func (oc *OPCContainer) HasFile(name string) bool {
 	return exists
 }
 
-// GetContentTypes 获取内容类型映射
+// GetContentTypes 获取内容类型映射（Override元素的部件名到内容类型）
 func (oc *OPCContainer) GetContentTypes() (map[string]string, error) {
 	content, err := oc.ReadFile("[Content_Types].xml")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read content types: %w", err)
 	}
 
-	// 简单的XML解析，提取内容类型映射
-	contentStr := string(content)
-	types := make(map[string]string)
+	var contentTypes struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.Unmarshal(content, &contentTypes); err != nil {
+		return nil, fmt.Errorf("failed to parse content types: %w", err)
+	}
 
-	// 解析Override元素
-	lines := strings.Split(contentStr, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "Override") {
-			// 提取PartName和ContentType
-			if strings.Contains(line, "PartName=") && strings.Contains(line, "ContentType=") {
-				// 简化解析，实际应该使用XML解析器
-				parts := strings.Split(line, " ")
-				for _, part := range parts {
-					if strings.HasPrefix(part, "PartName=\"") {
-						partName := strings.TrimPrefix(part, "PartName=\"")
-						partName = strings.TrimSuffix(partName, "\"")
-						types[partName] = "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"
-					}
-				}
+	types := make(map[string]string, len(contentTypes.Overrides))
+	for _, override := range contentTypes.Overrides {
+		types[override.PartName] = override.ContentType
+	}
+
+	return types, nil
+}
+
+// MainPart 返回主文档部件在压缩包中的名称和内容类型
+//
+// 按[Content_Types].xml中声明的内容类型查找，未声明时使用word/document.xml。
+func (oc *OPCContainer) MainPart() (string, string) {
+	if types, err := oc.GetContentTypes(); err == nil {
+		for partName, contentType := range types {
+			if IsMainContentType(contentType) {
+				return strings.TrimPrefix(partName, "/"), contentType
 			}
 		}
 	}
+	return DefaultMainPart, ContentTypeDocument
+}
 
-	return types, nil
+// HasVBAProject 是否包含VBA宏工程部件（vbaProject.bin）
+func (oc *OPCContainer) HasVBAProject() bool {
+	for name := range oc.Files {
+		if strings.EqualFold(path.Base(name), "vbaProject.bin") {
+			return true
+		}
+	}
+	return false
 }
 
 // GetRelationships 获取关系文件
@@ -159,8 +207,9 @@ func (oc *OPCContainer) Validate() error {
 	requiredFiles := []string{
 		"[Content_Types].xml",
 		"_rels/.rels",
-		"word/document.xml",
 	}
+	mainPart, _ := oc.MainPart()
+	requiredFiles = append(requiredFiles, mainPart)
 
 	for _, file := range requiredFiles {
 		if !oc.HasFile(file) {
@@ -0,0 +1,314 @@
+// Package sniff 按文件内容识别文档格式
+//
+// ZIP容器读取[Content_Types].xml中主文档部件的内容类型，区分文档、模板和启用宏的文件，ODF包读取mimetype；
+// 复合文件检查根存储中的WordDocument和EncryptedPackage流；其余按魔数和文本前导识别
+// WordPerfect、Word 6.0/95、RTF、HTML、MHTML和XML。识别出的格式与扩展名不符时给出警告，由调用方决定是否继续。
+package sniff
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"docs-parser/internal/packaging"
+	"docs-parser/internal/packaging/cfb"
+)
+
+// Format 识别出的文档格式
+type Format string
+
+// 文档格式
+const (
+	Unknown        Format = ""
+	DOCX           Format = "docx"
+	DOTX           Format = "dotx"
+	DOCM           Format = "docm"
+	DOTM           Format = "dotm"
+	DOC            Format = "doc"
+	EncryptedOOXML Format = "encrypted-ooxml" // 设置了密码的OOXML文档，内容加密存放在复合文件中
+	RTF            Format = "rtf"
+	HTML           Format = "html"
+	MHTML          Format = "mhtml" // 单个文件网页（MIME multipart/related）
+	XML            Format = "xml"
+	FlatOPC        Format = "flat-opc" // 单文件XML形式的OOXML包（pkg:package）
+	WordML         Format = "wordml"   // Word 2003 XML（w:wordDocument）
+	WPD            Format = "wpd"
+	ODT            Format = "odt"
+	OTT            Format = "ott"
+)
+
+// formatExtensions 各格式对应的扩展名
+var formatExtensions = map[Format][]string{
+	DOCX:           {".docx"},
+	DOTX:           {".dotx"},
+	DOCM:           {".docm"},
+	DOTM:           {".dotm"},
+	DOC:            {".doc", ".dot", ".wbk"},
+	EncryptedOOXML: {".docx", ".dotx", ".docm", ".dotm"},
+	RTF:            {".rtf"},
+	HTML:           {".html", ".htm"},
+	MHTML:          {".mht", ".mhtml"},
+	XML:            {".xml"},
+	FlatOPC:        {".xml"},
+	WordML:         {".xml"},
+	WPD:            {".wpd", ".wp", ".wpt"},
+	ODT:            {".odt"},
+	OTT:            {".ott"},
+}
+
+// mainContentTypes WordprocessingML主文档部件的内容类型
+var mainContentTypes = map[string]Format{
+	packaging.ContentTypeDocument:      DOCX,
+	packaging.ContentTypeTemplate:      DOTX,
+	packaging.ContentTypeMacroDocument: DOCM,
+	packaging.ContentTypeMacroTemplate: DOTM,
+}
+
+// 魔数
+var (
+	zipMagic   = []byte("PK\x03\x04")
+	wpdMagic   = []byte("\xFFWPC")
+	word6Magic = []byte{0x31, 0xBE, 0x00, 0x00}
+	word2Magic = []byte{0xDB, 0xA5, 0x2D, 0x00}
+	utf8BOM    = []byte("\xEF\xBB\xBF")
+	utf16LEBOM = []byte("\xFF\xFE")
+	utf16BEBOM = []byte("\xFE\xFF")
+)
+
+// prologueLen 文本格式只检查开头的字节
+const prologueLen = 4096
+
+// Result 识别结果
+type Result struct {
+	Format    Format `json:"format"`
+	Extension string `json:"extension"` // 小写的扩展名
+	Detail    string `json:"detail"`    // 识别依据，如主文档部件的内容类型或复合文件中的流
+	Warning   string `json:"warning"`   // 扩展名与内容不符时的警告
+}
+
+// Extensions 返回格式对应的扩展名，第一个为常用扩展名
+func (f Format) Extensions() []string {
+	return formatExtensions[f]
+}
+
+// IsOOXML 是否为WordprocessingML包（文档、模板或启用宏的文件）
+func (f Format) IsOOXML() bool {
+	return f == DOCX || f == DOTX || f == DOCM || f == DOTM
+}
+
+// MatchesExtension 扩展名（不区分大小写）是否属于该格式
+func (f Format) MatchesExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range formatExtensions[f] {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// File 识别文件的格式
+func File(path string) (*Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory: %s", path)
+	}
+	return Reader(file, info.Size(), filepath.Ext(path))
+}
+
+// Reader 识别r中内容的格式，ext为文件扩展名，用于检查扩展名是否与内容相符
+func Reader(r io.ReaderAt, size int64, ext string) (*Result, error) {
+	head := make([]byte, min(size, int64(prologueLen)))
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	result := &Result{Extension: strings.ToLower(ext)}
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		result.Format, result.Detail = sniffZip(r, size)
+	case cfb.IsCFB(head):
+		result.Format, result.Detail = sniffCFB(r, size)
+	case bytes.HasPrefix(head, wpdMagic):
+		result.Format, result.Detail = WPD, "WordPerfect header"
+	case bytes.HasPrefix(head, word6Magic):
+		result.Format, result.Detail = DOC, "Word 6.0/95 header"
+	case bytes.HasPrefix(head, word2Magic):
+		result.Format, result.Detail = DOC, "Word 2.0 header"
+	default:
+		result.Format, result.Detail = sniffText(head)
+	}
+
+	if result.Format != Unknown && !result.Format.MatchesExtension(result.Extension) {
+		extension := result.Extension
+		if extension == "" {
+			extension = "(none)"
+		}
+		result.Warning = fmt.Sprintf("file extension %s does not match detected format %s (%s)", extension, result.Format, result.Detail)
+	}
+	return result, nil
+}
+
+// sniffZip 按[Content_Types].xml中主文档部件的内容类型或ODF包的mimetype识别ZIP容器
+func sniffZip(r io.ReaderAt, size int64) (Format, string) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Unknown, "corrupt ZIP archive"
+	}
+	switch mimeType := packaging.ODFMimeType(archive.File); mimeType {
+	case packaging.MimeTypeText:
+		return ODT, mimeType
+	case packaging.MimeTypeTextTemplate:
+		return OTT, mimeType
+	}
+	hasDocument := false
+	for _, file := range archive.File {
+		switch file.Name {
+		case "[Content_Types].xml":
+			if format, contentType := mainContentType(file); format != Unknown {
+				return format, contentType
+			}
+		case packaging.DefaultMainPart:
+			hasDocument = true
+		}
+	}
+	// 没有声明主文档部件的内容类型时按默认位置的word/document.xml识别
+	if hasDocument {
+		return DOCX, "word/document.xml"
+	}
+	return Unknown, "ZIP archive without a WordprocessingML main part or ODF text mimetype"
+}
+
+// mainContentType 返回[Content_Types].xml中声明的主文档部件格式和内容类型
+func mainContentType(file *zip.File) (Format, string) {
+	reader, err := file.Open()
+	if err != nil {
+		return Unknown, ""
+	}
+	defer reader.Close()
+
+	var contentTypes struct {
+		Overrides []struct {
+			PartName    string `xml:"PartName,attr"`
+			ContentType string `xml:"ContentType,attr"`
+		} `xml:"Override"`
+	}
+	if err := xml.NewDecoder(reader).Decode(&contentTypes); err != nil {
+		return Unknown, ""
+	}
+	for _, override := range contentTypes.Overrides {
+		if format, ok := mainContentTypes[override.ContentType]; ok {
+			return format, override.ContentType
+		}
+	}
+	return Unknown, ""
+}
+
+// sniffCFB 按根存储中的流识别复合文件
+func sniffCFB(r io.ReaderAt, size int64) (Format, string) {
+	file, err := cfb.NewReader(r, size)
+	if err != nil {
+		return Unknown, "corrupt compound file"
+	}
+	if entry, err := file.Entry("EncryptedPackage"); err == nil && entry.IsStream() {
+		return EncryptedOOXML, "EncryptedPackage stream"
+	}
+	if entry, err := file.Entry("WordDocument"); err == nil && entry.IsStream() {
+		return DOC, "WordDocument stream"
+	}
+	return Unknown, "compound file without a WordDocument stream"
+}
+
+// sniffText 按文本前导识别RTF、HTML、MHTML和XML，支持UTF-8和UTF-16字节序标记
+func sniffText(head []byte) (Format, string) {
+	text := decodePrologue(head)
+	text = strings.TrimLeft(text, " \t\r\n")
+	if strings.HasPrefix(text, `{\rtf`) {
+		return RTF, `{\rtf prologue`
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, "<!doctype html"), strings.HasPrefix(lower, "<html"):
+		return HTML, "HTML prologue"
+	case strings.HasPrefix(lower, "<?xml"), strings.HasPrefix(lower, "<?mso-application"):
+		return sniffXML(lower)
+	case isMHTML(lower):
+		return MHTML, "MIME multipart/related"
+	}
+	return Unknown, ""
+}
+
+// isMHTML 按邮件头识别单个文件网页：头部含MIME-Version且内容类型为multipart/related
+func isMHTML(lower string) bool {
+	header := lower
+	for _, separator := range []string{"\r\n\r\n", "\n\n"} {
+		if end := strings.Index(header, separator); end >= 0 {
+			header = header[:end]
+		}
+	}
+	first, _, _ := strings.Cut(header, "\n")
+	name, _, ok := strings.Cut(first, ":")
+	if !ok || name == "" || strings.ContainsAny(name, " \t<{") {
+		return false
+	}
+	unfolded := strings.Join(strings.Fields(header), "")
+	return strings.Contains(unfolded, "mime-version:") && strings.Contains(unfolded, "content-type:multipart/related")
+}
+
+// sniffXML 按根元素区分XHTML、Word 2003 XML、Flat OPC和其他XML
+func sniffXML(lower string) (Format, string) {
+	root := lower
+	// 跳过处理指令、注释和文档类型声明，找到根元素
+	for strings.HasPrefix(root, "<?") || strings.HasPrefix(root, "<!") {
+		end := strings.Index(root, ">")
+		if end < 0 {
+			break
+		}
+		root = strings.TrimLeft(root[end+1:], " \t\r\n")
+	}
+	switch {
+	case strings.HasPrefix(root, "<html"):
+		return HTML, "XHTML prologue"
+	case strings.HasPrefix(root, "<w:worddocument"):
+		return WordML, "Word 2003 XML"
+	case strings.HasPrefix(root, "<pkg:package"):
+		return FlatOPC, "Flat OPC"
+	}
+	return XML, "XML prologue"
+}
+
+// decodePrologue 将文件开头的字节转换为字符串，UTF-16文本按字节序标记解码
+func decodePrologue(head []byte) string {
+	var order func([]byte) uint16
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return string(head[len(utf8BOM):])
+	case bytes.HasPrefix(head, utf16LEBOM):
+		order = func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 }
+	case bytes.HasPrefix(head, utf16BEBOM):
+		order = func(b []byte) uint16 { return uint16(b[1]) | uint16(b[0])<<8 }
+	default:
+		return string(head)
+	}
+	head = head[2:]
+	units := make([]uint16, len(head)/2)
+	for i := range units {
+		units[i] = order(head[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,119 @@
+package sniff
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"docs-parser/internal/testutil"
+)
+
+// buildTestZip 构造包含[Content_Types].xml主文档部件声明的ZIP包，contentType为空时不写入[Content_Types].xml
+func buildTestZip(t *testing.T, contentType string) []byte {
+	t.Helper()
+	parts := map[string]string{"word/document.xml": `<w:document/>`}
+	if contentType != "" {
+		parts["[Content_Types].xml"] = `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/word/document.xml" ContentType="` + contentType + `"/></Types>`
+	}
+	return testutil.ZipBytes(t, parts)
+}
+
+// buildTestODF 构造mimetype为mimeType的ODF包，mimetype作为第一个不压缩的文件写入
+func buildTestODF(t *testing.T, mimeType string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(mimeType))
+	if w, err = archive.Create("content.xml"); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<office:document-content/>`))
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildTestCFB 构造根存储中只有一个流的复合文件
+func buildTestCFB(stream string) []byte {
+	return testutil.CFBBytes(3, testutil.CFBEntry{Name: stream})
+}
+
+// TestReader 测试按内容识别各种格式和扩展名不符时的警告
+func TestReader(t *testing.T) {
+	utf16LE := []byte{0xFF, 0xFE}
+	for _, c := range "<?xml version=\"1.0\"?><html xmlns=\"http://www.w3.org/1999/xhtml\">" {
+		utf16LE = append(utf16LE, byte(c), 0)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		ext     string
+		format  Format
+		detail  string
+		warning bool
+	}{
+		{"docx", buildTestZip(t, "application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"), ".docx", DOCX, "", false},
+		{"dotx", buildTestZip(t, "application/vnd.openxmlformats-officedocument.wordprocessingml.template.main+xml"), ".dotx", DOTX, "", false},
+		{"docm保存为docx", buildTestZip(t, "application/vnd.ms-word.document.macroEnabled.main+xml"), ".DOCX", DOCM, "", true},
+		{"dotm", buildTestZip(t, "application/vnd.ms-word.template.macroEnabledTemplate.main+xml"), ".dotm", DOTM, "", false},
+		{"没有内容类型声明", buildTestZip(t, ""), ".docx", DOCX, "word/document.xml", false},
+		{"ODT", buildTestODF(t, "application/vnd.oasis.opendocument.text"), ".odt", ODT, "application/vnd.oasis.opendocument.text", false},
+		{"OTT保存为odt", buildTestODF(t, "application/vnd.oasis.opendocument.text-template"), ".odt", OTT, "", true},
+		{"ODS", buildTestODF(t, "application/vnd.oasis.opendocument.spreadsheet"), ".ods", Unknown, "", false},
+		{"Word 97-2003", buildTestCFB("WordDocument"), ".doc", DOC, "WordDocument stream", false},
+		{"加密的OOXML", buildTestCFB("EncryptedPackage"), ".docx", EncryptedOOXML, "EncryptedPackage stream", false},
+		{"其他复合文件", buildTestCFB("Workbook"), ".doc", Unknown, "", false},
+		{"保存为doc的RTF", []byte("\xEF\xBB\xBF\r\n{\\rtf1\\ansi hello}"), ".doc", RTF, "", true},
+		{"WordPerfect", []byte("\xFFWPC\x10\x00\x00\x00\x01\x0A\x02\x01"), ".wpd", WPD, "", false},
+		{"Word 6.0", []byte{0x31, 0xBE, 0x00, 0x00, 0x00, 0xAB}, ".doc", DOC, "Word 6.0/95 header", false},
+		{"HTML", []byte("<!DOCTYPE html><html><body>x</body></html>"), ".htm", HTML, "", false},
+		{"UTF-16 XHTML", utf16LE, ".doc", HTML, "XHTML prologue", true},
+		{"MHTML", []byte("MIME-Version: 1.0\r\nContent-Type: multipart/related;\r\n\tboundary=\"----=_NextPart\"\r\n\r\n"), ".mht", MHTML, "MIME multipart/related", false},
+		{"保存为doc的MHTML", []byte("From: <Saved by Blink>\nSubject: x\nMIME-Version: 1.0\nContent-Type: multipart/related; boundary=b\n\n--b"), ".doc", MHTML, "", true},
+		{"邮件", []byte("MIME-Version: 1.0\r\nContent-Type: text/plain\r\n\r\nhello"), ".eml", Unknown, "", false},
+		{"Word 2003 XML", []byte(`<?xml version="1.0"?><?mso-application progid="Word.Document"?><w:wordDocument/>`), ".xml", WordML, "Word 2003 XML", false},
+		{"Flat OPC", []byte("<?xml version=\"1.0\"?>\n<pkg:package xmlns:pkg=\"http://schemas.microsoft.com/office/2006/xmlPackage\"/>"), ".xml", FlatOPC, "Flat OPC", false},
+		{"其他XML", []byte(`<?xml version="1.0"?><rss version="2.0"/>`), ".xml", XML, "XML prologue", false},
+		{"纯文本", []byte("plain text"), ".txt", Unknown, "", false},
+		{"空文件", nil, ".doc", Unknown, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Reader(bytes.NewReader(tt.data), int64(len(tt.data)), tt.ext)
+			if err != nil {
+				t.Fatalf("识别失败: %v", err)
+			}
+			if result.Format != tt.format {
+				t.Errorf("格式应为 %q，实际 %q (%s)", tt.format, result.Format, result.Detail)
+			}
+			if tt.detail != "" && result.Detail != tt.detail {
+				t.Errorf("识别依据应为 %q，实际 %q", tt.detail, result.Detail)
+			}
+			if (result.Warning != "") != tt.warning {
+				t.Errorf("警告不正确: %q", result.Warning)
+			}
+		})
+	}
+}
+
+// TestFormat_MatchesExtension 测试格式与扩展名的对应关系
+func TestFormat_MatchesExtension(t *testing.T) {
+	if !DOC.MatchesExtension(".DOT") || DOC.MatchesExtension(".docx") {
+		t.Error("DOC应对应.doc、.dot和.wbk")
+	}
+	if !EncryptedOOXML.MatchesExtension(".docm") || !DOTM.IsOOXML() || RTF.IsOOXML() {
+		t.Error("加密的OOXML应对应OOXML扩展名")
+	}
+	if exts := WPD.Extensions(); len(exts) == 0 || exts[0] != ".wpd" {
+		t.Errorf("WordPerfect的常用扩展名应为.wpd，实际 %v", exts)
+	}
+}
@@ -0,0 +1,695 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"docs-parser/internal/core/annotator"
+	"docs-parser/internal/core/cache"
+	"docs-parser/internal/core/comparator"
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+	"docs-parser/internal/core/validator"
+	"docs-parser/internal/formats"
+	"docs-parser/internal/templates"
+)
+
+// 任务类型
+const (
+	KindParse    = "parse"
+	KindCompare  = "compare"
+	KindValidate = "validate"
+	KindAnnotate = "annotate"
+)
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	StatusQueued    JobStatus = "queued"
+	StatusRunning   JobStatus = "running"
+	StatusSucceeded JobStatus = "succeeded"
+	StatusFailed    JobStatus = "failed"
+)
+
+// uploadExtensions 允许上传的文档扩展名
+var uploadExtensions = map[string]bool{
+	".docx":  true,
+	".doc":   true,
+	".rtf":   true,
+	".wpd":   true,
+	".dot":   true,
+	".dotx":  true,
+	".docm":  true,
+	".dotm":  true,
+	".xml":   true,
+	".odt":   true,
+	".ott":   true,
+	".html":  true,
+	".htm":   true,
+	".mht":   true,
+	".mhtml": true,
+}
+
+// templateExtensions 模板字段额外允许的规则文件扩展名
+var templateExtensions = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+	".json": true,
+}
+
+// Options 服务选项
+type Options struct {
+	Addr           string        // 监听地址，如":8080"
+	Workers        int           // 并发执行任务的工作协程数
+	QueueSize      int           // 等待执行的任务上限，队列已满时新请求返回503
+	MaxUploadBytes int64         // 单个请求的上传大小上限
+	JobTimeout     time.Duration // 单个任务的执行时限，0表示不限制
+	DataDir        string        // 上传文件和标注文档的存放目录
+	MaxJobs        int           // 保留的任务数，超出时删除最早完成的任务及其文件
+
+	Registry  *templates.Registry        // 模板注册表，template_id可以带版本（如gov-notice@2），可以为nil
+	Templates *templates.TemplateManager // 从模板目录加载的模板，可以为nil
+	Cache     *cache.ParseCache          // 解析结果缓存，可以为nil
+}
+
+// DefaultOptions 返回默认服务选项
+func DefaultOptions() Options {
+	return Options{
+		Addr:           ":8080",
+		Workers:        4,
+		QueueSize:      64,
+		MaxUploadBytes: 20 << 20,
+		JobTimeout:     2 * time.Minute,
+		DataDir:        filepath.Join(os.TempDir(), "docs-parser-serve"),
+		MaxJobs:        1000,
+	}
+}
+
+// Job 异步任务
+type Job struct {
+	ID           string        `json:"id"`
+	Kind         string        `json:"kind"`
+	Status       JobStatus     `json:"status"`
+	Error        string        `json:"error,omitempty"`
+	TimedOut     bool          `json:"timed_out,omitempty"` // 因超时或客户端断开而中断
+	CreatedAt    time.Time     `json:"created_at"`
+	FinishedAt   *time.Time    `json:"finished_at,omitempty"`
+	Duration     time.Duration `json:"duration,omitempty"`
+	StatusURL    string        `json:"status_url"`
+	AnnotatedURL string        `json:"annotated_url,omitempty"` // 标注文档下载地址
+	Result       any           `json:"result,omitempty"`
+
+	dir           string
+	documentPath  string
+	documentName  string
+	templatePath  string
+	templateName  string
+	annotatedPath string
+	done          chan struct{}
+}
+
+// Server 文档解析HTTP服务
+//
+// 每个请求创建一个任务，放入有界的ConcurrentProcessor队列执行。默认立即返回202和任务状态地址，
+// 请求带?wait=true时等待任务完成后返回结果，客户端断开连接会取消该任务。
+type Server struct {
+	options    Options
+	processor  *parser.ConcurrentProcessor
+	wordParser *formats.WordParser
+	comparator *comparator.DocumentComparator
+	validator  *validator.Validator
+	annotator  *annotator.Annotator
+	rules      *templates.TemplateManager // 解析规则文件模板的继承和组合
+
+	ctx     context.Context // 异步任务的ctx，服务关闭时取消
+	cancel  context.CancelFunc
+	drained chan struct{}
+	started time.Time
+
+	mu             sync.Mutex
+	jobs           map[string]*Job
+	order          []string // 按创建顺序排列的任务ID
+	nextID         uint64
+	rejectedFull   int64
+	rejectedUpload int64
+}
+
+// New 创建服务并启动工作协程
+func New(options Options) (*Server, error) {
+	defaults := DefaultOptions()
+	if options.Workers < 1 {
+		options.Workers = defaults.Workers
+	}
+	if options.QueueSize < 1 {
+		options.QueueSize = defaults.QueueSize
+	}
+	if options.MaxUploadBytes <= 0 {
+		options.MaxUploadBytes = defaults.MaxUploadBytes
+	}
+	if options.DataDir == "" {
+		options.DataDir = defaults.DataDir
+	}
+	if options.MaxJobs < 1 {
+		options.MaxJobs = defaults.MaxJobs
+	}
+	if err := os.MkdirAll(filepath.Join(options.DataDir, "jobs"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		options:    options,
+		processor:  parser.NewConcurrentProcessorWithQueue(options.Workers, options.QueueSize),
+		wordParser: formats.NewWordParser(),
+		comparator: comparator.NewDocumentComparator(),
+		validator:  validator.NewValidator(),
+		annotator:  annotator.NewAnnotator(),
+		ctx:        ctx,
+		cancel:     cancel,
+		drained:    make(chan struct{}),
+		started:    time.Now(),
+		jobs:       make(map[string]*Job),
+	}
+	s.rules = templates.NewTemplateManager("")
+	s.rules.SetLookup(s.lookupTemplate)
+	s.wordParser.SetCache(options.Cache)
+	s.comparator.SetParseCache(options.Cache)
+	s.processor.SetDocumentTimeout(options.JobTimeout)
+
+	s.processor.Start()
+	go s.collectResults()
+	return s, nil
+}
+
+// Handler 返回服务的HTTP路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, kind := range []string{KindParse, KindCompare, KindValidate, KindAnnotate} {
+		mux.HandleFunc("POST /api/v1/"+kind, s.handleSubmit(kind))
+	}
+	mux.HandleFunc("GET /api/v1/jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /api/v1/jobs/{id}/annotated", s.handleAnnotated)
+	mux.HandleFunc("GET /api/v1/templates", s.handleTemplates)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	return mux
+}
+
+// Run 在options.Addr上提供服务，ctx结束时停止接收请求、取消执行中的任务并返回
+func (s *Server) Run(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.options.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		s.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := httpServer.Shutdown(shutdownCtx)
+	s.Close()
+	return err
+}
+
+// Close 取消执行中的任务并停止工作协程，不再接受新任务
+func (s *Server) Close() {
+	s.cancel()
+	s.processor.Stop()
+	<-s.drained
+}
+
+// collectResults 读取处理器的结果，更新任务状态
+func (s *Server) collectResults() {
+	defer close(s.drained)
+	for result := range s.processor.GetResults() {
+		s.mu.Lock()
+		job, exists := s.jobs[result.JobID]
+		if exists {
+			finished := result.EndTime
+			job.FinishedAt = &finished
+			job.Duration = result.Duration
+			job.Status = StatusSucceeded
+			if result.Error != nil {
+				job.Status = StatusFailed
+				job.Error = result.Error.Error()
+				_, job.TimedOut = types.AsTimeoutError(result.Error)
+			}
+			close(job.done)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// handleSubmit 接收上传的文档并创建任务
+func (s *Server) handleSubmit(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.options.MaxUploadBytes)
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				s.mu.Lock()
+				s.rejectedUpload++
+				s.mu.Unlock()
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("上传内容超过 %d 字节的限制", s.options.MaxUploadBytes))
+				return
+			}
+			writeError(w, http.StatusBadRequest, "请求必须是multipart/form-data: "+err.Error())
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		job, status, err := s.createJob(kind, r)
+		if err != nil {
+			writeError(w, status, err.Error())
+			return
+		}
+
+		// 同步请求的任务随客户端断开而取消，异步任务只受服务关闭和任务时限控制
+		wait := r.URL.Query().Get("wait") == "true" || r.URL.Query().Get("wait") == "1"
+		ctx := s.ctx
+		if wait {
+			ctx = r.Context()
+		}
+
+		if err := s.processor.SubmitTask(ctx, job.ID, s.task(job)); err != nil {
+			s.removeJob(job.ID)
+			if errors.Is(err, parser.ErrQueueFull) {
+				s.mu.Lock()
+				s.rejectedFull++
+				s.mu.Unlock()
+				w.Header().Set("Retry-After", "5")
+				writeError(w, http.StatusServiceUnavailable, "任务队列已满，请稍后重试")
+				return
+			}
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+
+		if !wait {
+			w.Header().Set("Location", job.StatusURL)
+			writeJSON(w, http.StatusAccepted, s.snapshot(job.ID))
+			return
+		}
+
+		select {
+		case <-job.done:
+			writeJSON(w, http.StatusOK, s.snapshot(job.ID))
+		case <-r.Context().Done():
+			// 客户端已断开，任务的ctx随之取消
+		}
+	}
+}
+
+// createJob 保存上传的文件并登记任务，返回错误时同时返回HTTP状态码
+func (s *Server) createJob(kind string, r *http.Request) (*Job, int, error) {
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("%d-%d", time.Now().UnixNano(), s.nextID)
+	s.mu.Unlock()
+
+	job := &Job{
+		ID:        id,
+		Kind:      kind,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+		StatusURL: "/api/v1/jobs/" + id,
+		dir:       filepath.Join(s.options.DataDir, "jobs", id),
+		done:      make(chan struct{}),
+	}
+	if err := os.MkdirAll(job.dir, 0755); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("无法创建任务目录: %w", err)
+	}
+
+	var err error
+	job.documentPath, job.documentName, err = saveUpload(r, "document", job.dir)
+	if err != nil {
+		os.RemoveAll(job.dir)
+		return nil, http.StatusBadRequest, err
+	}
+
+	if kind == KindCompare || kind == KindAnnotate {
+		if err := s.resolveTemplate(r, job); err != nil {
+			os.RemoveAll(job.dir)
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.order = append(s.order, id)
+	s.pruneLocked()
+	s.mu.Unlock()
+	return job, http.StatusAccepted, nil
+}
+
+// resolveTemplate 使用上传的模板文件或已注册的模板ID，模板ID先在注册表中查找
+func (s *Server) resolveTemplate(r *http.Request, job *Job) error {
+	if templateID := r.FormValue("template_id"); templateID != "" {
+		if s.options.Registry != nil {
+			if entry, err := s.options.Registry.Resolve(templateID); err == nil {
+				job.templatePath = s.options.Registry.SourcePath(entry)
+				job.templateName = entry.Ref()
+				return nil
+			}
+		}
+		if s.options.Templates == nil {
+			return fmt.Errorf("服务未注册模板: %s", templateID)
+		}
+		template, err := s.options.Templates.GetTemplate(templateID)
+		if err != nil {
+			return err
+		}
+		job.templatePath = template.SourcePath
+		job.templateName = templateID
+		return nil
+	}
+
+	var err error
+	job.templatePath, job.templateName, err = saveUpload(r, "template", job.dir)
+	if err != nil {
+		return fmt.Errorf("需要上传template文件或指定template_id: %w", err)
+	}
+	return nil
+}
+
+// lookupTemplate 按ID查找规则文件继承或组合的模板，先在 --templates 目录的模板中查找，再在注册表中查找
+func (s *Server) lookupTemplate(templateID string) (*templates.Template, error) {
+	if s.options.Templates != nil {
+		if template, err := s.options.Templates.GetTemplate(templateID); err == nil {
+			return template, nil
+		}
+	}
+	if s.options.Registry != nil {
+		return s.options.Registry.Load(templateID)
+	}
+	return nil, fmt.Errorf("template not found: %s", templateID)
+}
+
+// saveUpload 将表单字段field中的文件保存为dir/field+扩展名，返回保存路径和原始文件名
+func saveUpload(r *http.Request, field, dir string) (string, string, error) {
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		return "", "", fmt.Errorf("缺少上传文件 %s", field)
+	}
+	defer file.Close()
+
+	name := filepath.Base(header.Filename)
+	ext := strings.ToLower(filepath.Ext(name))
+	if !uploadExtensions[ext] && !(field == "template" && templateExtensions[ext]) {
+		return "", "", fmt.Errorf("不支持的文件格式: %s", name)
+	}
+
+	path := filepath.Join(dir, field+ext)
+	if err := copyUpload(file, path); err != nil {
+		return "", "", fmt.Errorf("保存上传文件失败: %w", err)
+	}
+	return path, name, nil
+}
+
+// copyUpload 将上传的文件写入path
+func copyUpload(file multipart.File, path string) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(output, file)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// task 返回执行任务的函数，结果写入job.Result
+func (s *Server) task(job *Job) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if err := types.CheckContext(ctx, job.Kind, job.documentName); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		job.Status = StatusRunning
+		s.mu.Unlock()
+
+		result, annotatedPath, err := s.run(ctx, job)
+
+		s.mu.Lock()
+		job.Result = result
+		if annotatedPath != "" {
+			job.annotatedPath = annotatedPath
+			job.AnnotatedURL = job.StatusURL + "/annotated"
+		}
+		s.mu.Unlock()
+		return err
+	}
+}
+
+// compare 将任务文档与模板对比，模板为规则文件时解析其继承和组合后按段落角色对比
+//
+// 规则文件的错误信息以上传时的文件名或模板ID标识，不暴露服务器路径。
+func (s *Server) compare(ctx context.Context, job *Job) (*comparator.ComparisonReport, error) {
+	if !templates.IsRuleFile(job.templatePath) {
+		return s.comparator.CompareWithTemplateContext(ctx, job.documentPath, job.templatePath)
+	}
+	data, err := os.ReadFile(job.templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule file: %s", job.templateName)
+	}
+	template, err := templates.UnmarshalRuleFile(data, job.templateName)
+	if err != nil {
+		return nil, err
+	}
+	if template.HasInheritance() {
+		resolved, err := s.rules.ResolveTemplateRules(template)
+		if err != nil {
+			return nil, err
+		}
+		template = resolved.Template
+	}
+	return s.comparator.CompareWithRulesContext(ctx, job.documentPath, job.templatePath, &template.FormatRules)
+}
+
+// run 按任务类型执行，返回结果和标注文档路径
+func (s *Server) run(ctx context.Context, job *Job) (any, string, error) {
+	switch job.Kind {
+	case KindParse:
+		doc, err := s.wordParser.ParseDocumentContext(ctx, job.documentPath)
+		return doc, "", err
+
+	case KindValidate:
+		result, err := s.validator.ValidateDocumentContext(ctx, job.documentPath)
+		return result, "", err
+
+	default:
+		report, err := s.compare(ctx, job)
+		if report == nil {
+			return nil, "", err
+		}
+
+		// 报告中只保留上传时的文件名，不暴露服务器路径
+		annotatedPath := report.AnnotatedDocumentPath
+		report.DocumentPath = job.documentName
+		report.TemplatePath = job.templateName
+		report.AnnotatedDocumentPath = ""
+		if err != nil {
+			return report, "", err
+		}
+
+		// annotate总是生成标注文档，没有问题时为原文档的副本
+		outputExt := annotator.OutputExtension(job.documentPath)
+		if job.Kind == KindAnnotate && annotatedPath == "" && (strings.EqualFold(outputExt, ".docx") || outputExt == ".rtf") {
+			annotatedPath = filepath.Join(job.dir, "annotated"+outputExt)
+			if err := s.annotator.AnnotateDocumentContext(ctx, job.documentPath, annotatedPath, nil); err != nil {
+				return report, "", err
+			}
+		}
+		return report, annotatedPath, nil
+	}
+}
+
+// removeJob 删除任务及其文件
+func (s *Server) removeJob(id string) {
+	s.mu.Lock()
+	job, exists := s.jobs[id]
+	delete(s.jobs, id)
+	for i, jobID := range s.order {
+		if jobID == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if exists {
+		os.RemoveAll(job.dir)
+	}
+}
+
+// pruneLocked 任务数超过上限时删除最早完成的任务，调用方需持有锁
+func (s *Server) pruneLocked() {
+	for i := 0; len(s.jobs) > s.options.MaxJobs && i < len(s.order); {
+		job := s.jobs[s.order[i]]
+		if job.FinishedAt == nil {
+			i++
+			continue
+		}
+		delete(s.jobs, job.ID)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+		os.RemoveAll(job.dir)
+	}
+}
+
+// snapshot 返回任务的副本，用于在锁外编码JSON
+func (s *Server) snapshot(id string) *Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil
+	}
+	copied := *job
+	return &copied
+}
+
+// handleJob 查询任务状态和结果
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job := s.snapshot(r.PathValue("id"))
+	if job == nil {
+		writeError(w, http.StatusNotFound, "任务不存在")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleAnnotated 下载标注文档
+func (s *Server) handleAnnotated(w http.ResponseWriter, r *http.Request) {
+	job := s.snapshot(r.PathValue("id"))
+	if job == nil {
+		writeError(w, http.StatusNotFound, "任务不存在")
+		return
+	}
+	if job.annotatedPath == "" {
+		writeError(w, http.StatusNotFound, "该任务没有标注文档")
+		return
+	}
+
+	ext := filepath.Ext(job.annotatedPath)
+	name := strings.TrimSuffix(job.documentName, filepath.Ext(job.documentName)) + "_annotated" + ext
+	contentType := "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	if ext == ".rtf" {
+		contentType = "application/rtf"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", urlPathEscape(name)))
+	http.ServeFile(w, r, job.annotatedPath)
+}
+
+// handleTemplates 列出已注册的模板
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	response := struct {
+		Registry  []*templates.RegistryEntry `json:"registry"`
+		Templates []*templates.Template      `json:"templates"`
+	}{
+		Registry:  []*templates.RegistryEntry{},
+		Templates: []*templates.Template{},
+	}
+	if s.options.Registry != nil {
+		response.Registry = s.options.Registry.List()
+	}
+	if s.options.Templates != nil {
+		response.Templates = s.options.Templates.ListTemplates()
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleHealth 健康检查
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Metrics 服务运行指标
+type Metrics struct {
+	UptimeSeconds  float64           `json:"uptime_seconds"`
+	Jobs           map[JobStatus]int `json:"jobs"`
+	QueueLength    int               `json:"queue_length"`
+	QueueCapacity  int               `json:"queue_capacity"`
+	Processed      int64             `json:"processed"`
+	Failed         int64             `json:"failed"`
+	AverageSeconds float64           `json:"average_seconds"`
+	Rejected       map[string]int64  `json:"rejected"`
+	ParseCache     *cache.Stats      `json:"parse_cache,omitempty"`
+}
+
+// handleMetrics 输出运行指标
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := s.processor.GetStats()
+	metrics := Metrics{
+		UptimeSeconds:  time.Since(s.started).Seconds(),
+		Jobs:           map[JobStatus]int{StatusQueued: 0, StatusRunning: 0, StatusSucceeded: 0, StatusFailed: 0},
+		QueueLength:    s.processor.QueueLength(),
+		QueueCapacity:  s.processor.QueueCapacity(),
+		Processed:      stats.JobsProcessed,
+		Failed:         stats.JobsFailed,
+		AverageSeconds: stats.AverageTime.Seconds(),
+	}
+	if s.options.Cache != nil {
+		cacheStats := s.options.Cache.Stats()
+		metrics.ParseCache = &cacheStats
+	}
+
+	s.mu.Lock()
+	for _, job := range s.jobs {
+		metrics.Jobs[job.Status]++
+	}
+	metrics.Rejected = map[string]int64{"queue_full": s.rejectedFull, "upload_too_large": s.rejectedUpload}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+// writeJSON 以JSON输出响应
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// writeError 以JSON输出错误
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// urlPathEscape 按RFC 5987转义下载文件名
+func urlPathEscape(name string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(name) {
+		if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || strings.IndexByte("-._~", b) >= 0 {
+			escaped.WriteByte(b)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"docs-parser/internal/testutil"
+)
+
+// writeTestDocx 生成只含一个段落的最小DOCX文档
+func writeTestDocx(t *testing.T) []byte {
+	t.Helper()
+	return testutil.ZipBytes(t, testutil.DocxParts(`<w:p><w:r><w:t>测试段落</w:t></w:r></w:p>`))
+}
+
+// newUpload 构造multipart请求，files为字段名到文件名和内容的映射
+func newUpload(t *testing.T, url string, files map[string][]byte, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for field, content := range files {
+		part, err := writer.CreateFormFile(field, field+".docx")
+		if err != nil {
+			t.Fatalf("创建上传字段失败: %v", err)
+		}
+		part.Write(content)
+	}
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, url, &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+// newTestServer 创建使用临时数据目录的服务
+func newTestServer(t *testing.T, maxUpload int64) *Server {
+	t.Helper()
+
+	options := DefaultOptions()
+	options.Workers = 1
+	options.DataDir = t.TempDir()
+	options.MaxUploadBytes = maxUpload
+	s, err := New(options)
+	if err != nil {
+		t.Fatalf("创建服务失败: %v", err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// TestServer_Health 测试健康检查和运行指标
+func TestServer_Health(t *testing.T) {
+	handler := newTestServer(t, 1<<20).Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("健康检查应返回200，实际 %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var metrics Metrics
+	if err := json.Unmarshal(recorder.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("解析运行指标失败: %v", err)
+	}
+	if metrics.QueueCapacity != DefaultOptions().QueueSize {
+		t.Errorf("队列容量应为 %d，实际 %d", DefaultOptions().QueueSize, metrics.QueueCapacity)
+	}
+}
+
+// TestServer_RejectUpload 测试超出大小限制和缺少模板的请求被拒绝
+func TestServer_RejectUpload(t *testing.T) {
+	s := newTestServer(t, 1024)
+	handler := s.Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newUpload(t, "/api/v1/parse", map[string][]byte{"document": make([]byte, 4096)}, nil))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("超出上传限制应返回413，实际 %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newUpload(t, "/api/v1/compare", map[string][]byte{"document": []byte("x")}, map[string]string{"template_id": "missing"}))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("未注册的模板应返回400，实际 %d", recorder.Code)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(s.options.DataDir, "jobs"))
+	if len(entries) != 0 {
+		t.Errorf("被拒绝的请求不应保留任务目录: %d", len(entries))
+	}
+}
+
+// TestServer_ParseJob 测试异步解析任务的提交和状态查询
+func TestServer_ParseJob(t *testing.T) {
+	handler := newTestServer(t, 1<<20).Handler()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newUpload(t, "/api/v1/parse", map[string][]byte{"document": writeTestDocx(t)}, nil))
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("提交任务应返回202，实际 %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var job Job
+	json.Unmarshal(recorder.Body.Bytes(), &job)
+	if job.ID == "" || recorder.Header().Get("Location") != job.StatusURL {
+		t.Fatalf("响应应包含任务ID和状态地址: %s", recorder.Body.String())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != StatusSucceeded && job.Status != StatusFailed && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, job.StatusURL, nil))
+		job = Job{}
+		json.Unmarshal(recorder.Body.Bytes(), &job)
+	}
+	if job.Status != StatusSucceeded || job.Result == nil {
+		t.Fatalf("解析任务应成功完成: %s", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, job.StatusURL+"/annotated", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("解析任务没有标注文档，应返回404，实际 %d", recorder.Code)
+	}
+}
+
+// TestServer_SubmitAfterClose 测试服务关闭后提交的任务被拒绝而不是向已关闭的队列发送
+func TestServer_SubmitAfterClose(t *testing.T) {
+	s := newTestServer(t, 1<<20)
+	s.Close()
+
+	recorder := httptest.NewRecorder()
+	s.Handler().ServeHTTP(recorder, newUpload(t, "/api/v1/parse", map[string][]byte{"document": writeTestDocx(t)}, nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("服务关闭后提交任务应返回503，实际 %d: %s", recorder.Code, recorder.Body.String())
+	}
+}
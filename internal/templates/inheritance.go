@@ -0,0 +1,283 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"docs-parser/internal/core/types"
+)
+
+// 规则种类，用于说明规则来源
+const (
+	RuleKindRole      = "role"
+	RuleKindFont      = "font"
+	RuleKindParagraph = "paragraph"
+	RuleKindTable     = "table"
+	RuleKindPage      = "page"
+	RuleKindStyle     = "style"
+)
+
+// RuleOrigin 解析后的一条规则的来源
+type RuleOrigin struct {
+	Kind      string `json:"kind"`                 // 规则种类，如role、table
+	Key       string `json:"key"`                  // 角色名或规则ID
+	Source    string `json:"source"`               // 定义该规则的模板ID
+	Overrides string `json:"overrides,omitempty"`  // 被覆盖的同名规则所在的模板ID
+	RemovedBy string `json:"removed_by,omitempty"` // 删除该规则的模板ID
+}
+
+// ResolvedTemplate 解析继承和组合后的模板
+type ResolvedTemplate struct {
+	Template *Template    `json:"template"` // 合并后的模板，不再含有extends、includes和remove
+	Origins  []RuleOrigin `json:"origins"`  // 最终规则的来源，按种类和规则顺序排列
+	Removed  []RuleOrigin `json:"removed"`  // 被remove删除的规则
+	Chain    []string     `json:"chain"`    // 参与合并的模板ID，按首次应用的顺序排列
+}
+
+// ResolveTemplate 解析管理器中模板的继承和组合
+func (tm *TemplateManager) ResolveTemplate(templateID string) (*ResolvedTemplate, error) {
+	template, err := tm.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	return tm.ResolveTemplateRules(template)
+}
+
+// ResolveTemplateRules 解析模板的继承和组合，得到最终的规则集
+//
+// 依次应用extends指定的模板（递归解析）、includes中的规则片段、remove和模板自身的规则；
+// 角色规则按角色、其他规则按ID合并，后应用的同名规则覆盖先前的规则并保持其位置。
+// 引用的模板先在管理器中查找，再使用SetLookup设置的函数；继承或组合形成循环时返回错误。
+func (tm *TemplateManager) ResolveTemplateRules(template *Template) (*ResolvedTemplate, error) {
+	resolver := &ruleResolver{tm: tm, origins: make(map[string]*RuleOrigin)}
+	if err := resolver.apply(template, make(map[string]bool), nil); err != nil {
+		return nil, err
+	}
+
+	resolved := *template
+	resolved.Extends, resolved.Includes, resolved.Remove = "", nil, nil
+	resolved.FormatRules = resolver.rules
+	if err := tm.validateTemplate(&resolved); err != nil {
+		return nil, err
+	}
+
+	result := &ResolvedTemplate{Template: &resolved, Removed: resolver.removed, Chain: resolver.chain}
+	if result.Removed == nil {
+		result.Removed = []RuleOrigin{}
+	}
+	for _, kind := range []string{RuleKindRole, RuleKindFont, RuleKindParagraph, RuleKindTable, RuleKindPage, RuleKindStyle} {
+		for _, key := range resolver.ruleKeys(kind) {
+			result.Origins = append(result.Origins, *resolver.origins[kind+"/"+key])
+		}
+	}
+	return result, nil
+}
+
+// DirectoryLookup 返回在dir的规则文件中按模板ID查找模板的函数，可用于SetLookup
+//
+// 规则文件在第一次查找时读取，无法读取的文件被忽略；找不到时调用fallback，fallback为nil时返回错误。
+func DirectoryLookup(dir string, fallback func(templateID string) (*Template, error)) func(templateID string) (*Template, error) {
+	var templates map[string]*Template
+	return func(templateID string) (*Template, error) {
+		if templates == nil {
+			templates = make(map[string]*Template)
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				path := filepath.Join(dir, entry.Name())
+				if entry.IsDir() || !IsRuleFile(path) {
+					continue
+				}
+				if template, err := ReadRuleFile(path); err == nil {
+					template.SourcePath = path
+					templates[template.ID] = template
+				}
+			}
+		}
+
+		if template, exists := templates[templateID]; exists {
+			return template, nil
+		}
+		if fallback != nil {
+			return fallback(templateID)
+		}
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	}
+}
+
+// HasInheritance 判断模板是否需要解析继承或组合
+func (t *Template) HasInheritance() bool {
+	return t.Extends != "" || len(t.Includes) > 0 || len(t.Remove) > 0
+}
+
+// ruleResolver 按应用顺序合并模板规则并记录来源
+type ruleResolver struct {
+	tm      *TemplateManager
+	rules   types.FormatRules
+	origins map[string]*RuleOrigin // 键为"种类/角色名或规则ID"
+	removed []RuleOrigin
+	chain   []string
+}
+
+// apply 应用模板及其继承和组合的规则，visiting为正在解析的模板，path为引用路径
+func (r *ruleResolver) apply(template *Template, visiting map[string]bool, path []string) error {
+	path = append(path, template.ID)
+	if err := checkCircularReference(template.ID, visiting, path); err != nil {
+		return err
+	}
+	visiting[template.ID] = true
+	defer delete(visiting, template.ID)
+
+	references := make([]string, 0, len(template.Includes)+1)
+	if template.Extends != "" {
+		references = append(references, template.Extends)
+	}
+	references = append(references, template.Includes...)
+	for _, reference := range references {
+		if err := checkCircularReference(reference, visiting, append(path, reference)); err != nil {
+			return err
+		}
+		referenced, err := r.tm.findTemplate(reference)
+		if err != nil {
+			return fmt.Errorf("template %s references unknown template %s: %w", template.ID, reference, err)
+		}
+		if err := r.apply(referenced, visiting, path); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range template.Remove {
+		if !r.remove(key, template.ID) {
+			return fmt.Errorf("template %s removes unknown rule %s", template.ID, key)
+		}
+	}
+
+	rules := &template.FormatRules
+	r.rules.RoleRules = mergeRules(r, RuleKindRole, template.ID, r.rules.RoleRules, rules.RoleRules, func(rule types.RoleRule) string { return rule.Role })
+	r.rules.FontRules = mergeRules(r, RuleKindFont, template.ID, r.rules.FontRules, rules.FontRules, func(rule types.FontRule) string { return rule.ID })
+	r.rules.ParagraphRules = mergeRules(r, RuleKindParagraph, template.ID, r.rules.ParagraphRules, rules.ParagraphRules, func(rule types.ParagraphRule) string { return rule.ID })
+	r.rules.TableRules = mergeRules(r, RuleKindTable, template.ID, r.rules.TableRules, rules.TableRules, func(rule types.TableRule) string { return rule.ID })
+	r.rules.PageRules = mergeRules(r, RuleKindPage, template.ID, r.rules.PageRules, rules.PageRules, func(rule types.PageRule) string { return rule.ID })
+	r.rules.StyleRules = mergeRules(r, RuleKindStyle, template.ID, r.rules.StyleRules, rules.StyleRules, func(rule types.StyleRule) string { return rule.ID })
+	if !slices.Contains(r.chain, template.ID) {
+		r.chain = append(r.chain, template.ID)
+	}
+	return nil
+}
+
+// checkCircularReference 检查模板是否已在引用路径上，与样式继承的循环检查相同
+func checkCircularReference(templateID string, visiting map[string]bool, path []string) error {
+	if visiting[templateID] {
+		return fmt.Errorf("circular template reference: %s", strings.Join(path, " -> "))
+	}
+	return nil
+}
+
+// mergeRules 将layer中的规则合并到base：键相同的规则替换原规则并保持位置，新规则追加到末尾
+func mergeRules[T any](r *ruleResolver, kind, source string, base, layer []T, key func(T) string) []T {
+	if base == nil {
+		base = []T{}
+	}
+	for _, rule := range layer {
+		id := kind + "/" + key(rule)
+		origin := &RuleOrigin{Kind: kind, Key: key(rule), Source: source}
+		if previous, exists := r.origins[id]; exists {
+			if previous.Source != source {
+				origin.Overrides = previous.Source
+			}
+			for i := range base {
+				if key(base[i]) == key(rule) {
+					base[i] = rule
+					break
+				}
+			}
+		} else {
+			base = append(base, rule)
+		}
+		r.origins[id] = origin
+	}
+	return base
+}
+
+// remove 删除角色名或ID为key的规则，没有匹配的规则时返回false
+func (r *ruleResolver) remove(key, source string) bool {
+	removed := false
+	drop := func(kind string) bool {
+		origin, exists := r.origins[kind+"/"+key]
+		if !exists {
+			return false
+		}
+		removedOrigin := *origin
+		removedOrigin.RemovedBy = source
+		r.removed = append(r.removed, removedOrigin)
+		delete(r.origins, kind+"/"+key)
+		removed = true
+		return true
+	}
+
+	if drop(RuleKindRole) {
+		r.rules.RoleRules = removeRules(r.rules.RoleRules, func(rule types.RoleRule) bool { return rule.Role == key })
+	}
+	if drop(RuleKindFont) {
+		r.rules.FontRules = removeRules(r.rules.FontRules, func(rule types.FontRule) bool { return rule.ID == key })
+	}
+	if drop(RuleKindParagraph) {
+		r.rules.ParagraphRules = removeRules(r.rules.ParagraphRules, func(rule types.ParagraphRule) bool { return rule.ID == key })
+	}
+	if drop(RuleKindTable) {
+		r.rules.TableRules = removeRules(r.rules.TableRules, func(rule types.TableRule) bool { return rule.ID == key })
+	}
+	if drop(RuleKindPage) {
+		r.rules.PageRules = removeRules(r.rules.PageRules, func(rule types.PageRule) bool { return rule.ID == key })
+	}
+	if drop(RuleKindStyle) {
+		r.rules.StyleRules = removeRules(r.rules.StyleRules, func(rule types.StyleRule) bool { return rule.ID == key })
+	}
+	return removed
+}
+
+// removeRules 返回删除匹配规则后的列表
+func removeRules[T any](rules []T, match func(T) bool) []T {
+	kept := rules[:0]
+	for _, rule := range rules {
+		if !match(rule) {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
+
+// ruleKeys 按规则顺序返回某一种类的最终规则的键
+func (r *ruleResolver) ruleKeys(kind string) []string {
+	var keys []string
+	collect := func(key string) { keys = append(keys, key) }
+	switch kind {
+	case RuleKindRole:
+		for _, rule := range r.rules.RoleRules {
+			collect(rule.Role)
+		}
+	case RuleKindFont:
+		for _, rule := range r.rules.FontRules {
+			collect(rule.ID)
+		}
+	case RuleKindParagraph:
+		for _, rule := range r.rules.ParagraphRules {
+			collect(rule.ID)
+		}
+	case RuleKindTable:
+		for _, rule := range r.rules.TableRules {
+			collect(rule.ID)
+		}
+	case RuleKindPage:
+		for _, rule := range r.rules.PageRules {
+			collect(rule.ID)
+		}
+	case RuleKindStyle:
+		for _, rule := range r.rules.StyleRules {
+			collect(rule.ID)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,83 @@
+package templates
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+)
+
+// ruleTemplate 创建只有角色规则的模板
+func ruleTemplate(id string, roles ...types.RoleRule) *Template {
+	return &Template{ID: id, Name: id, Version: "1.0", FormatRules: types.FormatRules{RoleRules: roles}}
+}
+
+// TestResolveTemplate 测试继承、组合、覆盖、删除和规则来源
+func TestResolveTemplate(t *testing.T) {
+	tm := NewTemplateManager("")
+	tm.templates["institution"] = ruleTemplate("institution",
+		types.RoleRule{Role: "heading1", Font: types.Font{Name: "黑体"}},
+		types.RoleRule{Role: "body", Font: types.Font{Name: "宋体"}},
+		types.RoleRule{Role: "toc", Font: types.Font{Name: "宋体"}},
+	)
+	caption := ruleTemplate("caption-style", types.RoleRule{Role: "caption", Font: types.Font{Name: "楷体"}})
+	caption.FormatRules.TableRules = []types.TableRule{{ID: "standard-table", Name: "标准表格", Width: 100}}
+	tm.SetLookup(func(templateID string) (*Template, error) {
+		if templateID == "caption-style" {
+			return caption, nil
+		}
+		return nil, fmt.Errorf("template not found: %s", templateID)
+	})
+
+	dept := ruleTemplate("dept", types.RoleRule{Role: "body", Font: types.Font{Name: "仿宋"}})
+	dept.Extends = "institution"
+	dept.Includes = []string{"caption-style"}
+	dept.Remove = []string{"toc"}
+
+	resolved, err := tm.ResolveTemplateRules(dept)
+	if err != nil {
+		t.Fatalf("解析模板失败: %v", err)
+	}
+
+	var roles []string
+	for _, rule := range resolved.Template.FormatRules.RoleRules {
+		roles = append(roles, rule.Role+"="+rule.Font.Name)
+	}
+	if want := []string{"heading1=黑体", "body=仿宋", "caption=楷体"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("合并后的角色规则应为 %v，实际 %v", want, roles)
+	}
+	if resolved.Template.Extends != "" || len(resolved.Template.FormatRules.TableRules) != 1 {
+		t.Errorf("合并后的模板不应再有继承关系，并包含片段的表格规则: %+v", resolved.Template)
+	}
+
+	wantOrigins := []RuleOrigin{
+		{Kind: RuleKindRole, Key: "heading1", Source: "institution"},
+		{Kind: RuleKindRole, Key: "body", Source: "dept", Overrides: "institution"},
+		{Kind: RuleKindRole, Key: "caption", Source: "caption-style"},
+		{Kind: RuleKindTable, Key: "standard-table", Source: "caption-style"},
+	}
+	if !reflect.DeepEqual(resolved.Origins, wantOrigins) {
+		t.Errorf("规则来源不正确:\n%+v", resolved.Origins)
+	}
+	if len(resolved.Removed) != 1 || resolved.Removed[0].Key != "toc" || resolved.Removed[0].RemovedBy != "dept" {
+		t.Errorf("应记录被删除的toc规则: %+v", resolved.Removed)
+	}
+	if want := []string{"institution", "caption-style", "dept"}; !reflect.DeepEqual(resolved.Chain, want) {
+		t.Errorf("应用顺序应为 %v，实际 %v", want, resolved.Chain)
+	}
+
+	// 循环继承和删除不存在的规则
+	tm.templates["a"] = ruleTemplate("a")
+	tm.templates["a"].Extends = "b"
+	tm.templates["b"] = ruleTemplate("b")
+	tm.templates["b"].Includes = []string{"a"}
+	if _, err := tm.ResolveTemplate("a"); err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("应检测到循环引用: %v", err)
+	}
+	dept.Remove = []string{"footnote"}
+	if _, err := tm.ResolveTemplateRules(dept); err == nil {
+		t.Error("删除不存在的规则应返回错误")
+	}
+}
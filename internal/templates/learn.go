@@ -0,0 +1,463 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"docs-parser/internal/core/parser"
+	"docs-parser/internal/core/types"
+)
+
+// LearnOptions 从样本文档学习模板的选项
+type LearnOptions struct {
+	Workers          int           // 并发解析的工作协程数，小于1时为1
+	DocumentTimeout  time.Duration // 单个文档的解析时限，0表示不限制
+	MinSupport       float64       // 角色至少出现在该比例的样本文档中才生成规则，0表示0.5
+	VarianceShare    float64       // 其他格式的段落比例达到该值时，其取值作为允许的偏差，0表示0.1
+	OutlierThreshold float64       // 不符合学习结果的段落比例超过该值的文档为离群文档，0表示0.3
+}
+
+// LearnReport 从样本文档学习模板的结果
+type LearnReport struct {
+	Template  *Template         `json:"template"`
+	Roles     []RoleStatistics  `json:"roles"`
+	Documents []LearnedDocument `json:"documents"`
+	Outliers  []LearnedDocument `json:"outliers"` // 按偏差比例从高到低排列
+}
+
+// RoleStatistics 一个段落角色在样本文档中的格式分布
+type RoleStatistics struct {
+	Role       string          `json:"role"`
+	Documents  int             `json:"documents"`  // 出现该角色的文档数
+	Paragraphs int             `json:"paragraphs"` // 该角色的非空段落数
+	Clusters   []FormatCluster `json:"clusters"`   // 按段落数从多到少排列，第一个为主导格式
+	Confidence float64         `json:"confidence"` // 主导格式的段落比例
+	Learned    bool            `json:"learned"`    // 出现的文档比例达到MinSupport，已生成规则
+}
+
+// FormatCluster 角色中格式相同的一组段落
+type FormatCluster struct {
+	Format     types.RoleRule `json:"format"`
+	Paragraphs int            `json:"paragraphs"`
+	Documents  int            `json:"documents"`
+}
+
+// LearnedDocument 样本文档与学习结果的符合情况
+type LearnedDocument struct {
+	Path          string         `json:"path"`
+	Paragraphs    int            `json:"paragraphs"` // 有对应角色规则的非空段落数
+	Deviating     int            `json:"deviating"`  // 不符合角色规则的段落数
+	DeviationRate float64        `json:"deviation_rate"`
+	Deviations    map[string]int `json:"deviations,omitempty"` // 按"角色 属性"统计，如"heading1 font.size"
+	Outlier       bool           `json:"outlier"`
+	Error         string         `json:"error,omitempty"`
+}
+
+// learnSample 一个样本文档解析后的段落格式
+type learnSample struct {
+	formats  []types.RoleRule
+	pageRule *types.PageRule
+}
+
+// formatKey 聚类时比较的格式属性，与RoleRule.Deviations检查的属性相同
+type formatKey struct {
+	fontName  string
+	fontSize  float64
+	bold      bool
+	italic    bool
+	alignment types.Alignment
+	before    float64
+	after     float64
+	line      float64
+}
+
+// LearnTemplateContext 从一组符合规范的样本文档学习模板
+//
+// 文档由parser.BatchProcessor并发解析，段落先按角色分组，再按格式聚类：
+// 段落最多的格式作为角色规则，其段落比例为置信度，比例达到VarianceShare的其他字体、字号、
+// 对齐方式和行距（至少出现在两个文档中）记录为允许的偏差。不符合学习结果的段落比例超过OutlierThreshold的文档为离群文档，
+// 排除离群文档后重新学习一次，报告中的偏差比例按最终规则计算。学习得到的模板同时加入管理器。
+func (tm *TemplateManager) LearnTemplateContext(ctx context.Context, name string, files []string, options LearnOptions) (*LearnReport, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no documents to learn from")
+	}
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.MinSupport <= 0 {
+		options.MinSupport = 0.5
+	}
+	if options.VarianceShare <= 0 {
+		options.VarianceShare = 0.1
+	}
+	if options.OutlierThreshold <= 0 {
+		options.OutlierThreshold = 0.3
+	}
+
+	documents := make([]LearnedDocument, len(files))
+	samples := make([]*learnSample, len(files))
+	batch := parser.NewBatchProcessor(options.Workers)
+	batch.SetParser(tm.wordParser)
+	batch.SetDocumentTimeout(options.DocumentTimeout)
+	batchErr := batch.ProcessFilesWithCallbackContext(ctx, files, func(index int, doc *types.Document, err error) {
+		documents[index].Path = files[index]
+		if err != nil {
+			documents[index].Error = err.Error()
+			return
+		}
+		samples[index] = tm.learnSample(doc)
+	})
+	if batchErr != nil {
+		return nil, batchErr
+	}
+
+	included := make([]bool, len(files))
+	parsed := 0
+	for i, sample := range samples {
+		included[i] = sample != nil
+		if sample != nil {
+			parsed++
+		}
+	}
+	if parsed == 0 {
+		return nil, fmt.Errorf("none of the %d documents could be parsed", len(files))
+	}
+
+	// 第一次学习找出离群文档，排除后重新学习
+	rules, _ := learnRoleRules(samples, included, options)
+	for i, sample := range samples {
+		if sample == nil {
+			continue
+		}
+		documents[i] = evaluateSample(documents[i], sample, rules)
+		if documents[i].DeviationRate > options.OutlierThreshold {
+			documents[i].Outlier = true
+		}
+	}
+	remaining := 0
+	for i := range documents {
+		if documents[i].Outlier {
+			included[i] = false
+		}
+		if included[i] {
+			remaining++
+		}
+	}
+	if remaining == 0 {
+		// 所有文档都是离群文档时没有可以排除的依据，使用全部文档
+		for i, sample := range samples {
+			included[i] = sample != nil
+			documents[i].Outlier = false
+		}
+	}
+
+	rules, statistics := learnRoleRules(samples, included, options)
+	report := &LearnReport{Roles: statistics, Outliers: []LearnedDocument{}}
+	for i, sample := range samples {
+		if sample != nil {
+			documents[i] = evaluateSample(documents[i], sample, rules)
+		}
+		if documents[i].Outlier {
+			report.Outliers = append(report.Outliers, documents[i])
+		}
+	}
+	sort.SliceStable(report.Outliers, func(i, j int) bool {
+		return report.Outliers[i].DeviationRate > report.Outliers[j].DeviationRate
+	})
+	report.Documents = documents
+
+	now := time.Now().Format("2006-01-02")
+	template := &Template{
+		ID:          tm.generateLearnedTemplateID(name),
+		Name:        fmt.Sprintf("Learned Template: %s", name),
+		Description: fmt.Sprintf("Template learned from %d documents", countIncluded(included)),
+		Version:     "1.0",
+		FormatRules: types.FormatRules{
+			FontRules:      []types.FontRule{},
+			ParagraphRules: []types.ParagraphRule{},
+			TableRules:     []types.TableRule{},
+			PageRules:      dominantPageRules(samples, included),
+			StyleRules:     []types.StyleRule{},
+			RoleRules:      rules,
+		},
+		Metadata: TemplateMetadata{
+			Author:      "System",
+			Created:     now,
+			LastUpdated: now,
+			Category:    "Learned Template",
+			Tags:        []string{"learned", "template"},
+		},
+	}
+	if err := tm.validateTemplate(template); err != nil {
+		return nil, err
+	}
+	tm.templates[template.ID] = template
+	report.Template = template
+
+	return report, nil
+}
+
+// learnSample 提取文档中非空段落的格式和第一节的页面设置
+func (tm *TemplateManager) learnSample(doc *types.Document) *learnSample {
+	sample := &learnSample{}
+	for i := range doc.Content.Paragraphs {
+		paragraph := &doc.Content.Paragraphs[i]
+		if strings.TrimSpace(paragraph.Text) == "" {
+			continue
+		}
+		sample.formats = append(sample.formats, types.RoleRuleOf(paragraph))
+	}
+	if pageRules := tm.extractPageRules(doc); len(pageRules) > 0 {
+		sample.pageRule = &pageRules[0]
+	}
+	return sample
+}
+
+// generateLearnedTemplateID 生成学习模板的ID
+func (tm *TemplateManager) generateLearnedTemplateID(name string) string {
+	cleanName := strings.ReplaceAll(name, " ", "_")
+	cleanName = strings.ReplaceAll(cleanName, "-", "_")
+	return fmt.Sprintf("learned_template_%s", strings.ToLower(cleanName))
+}
+
+// roleCluster 聚类过程中的一组格式相同的段落
+type roleCluster struct {
+	key        formatKey
+	order      int // 格式首次出现的顺序，段落数和文档数相同时先出现的优先
+	formats    []types.RoleRule
+	documents  map[int]bool
+	paragraphs int
+}
+
+// learnRoleRules 对included中的样本按角色聚类，返回角色规则和各角色的统计，角色按首次出现的顺序排列
+func learnRoleRules(samples []*learnSample, included []bool, options LearnOptions) ([]types.RoleRule, []RoleStatistics) {
+	var roles []string
+	clusters := make(map[string]map[formatKey]*roleCluster)
+	roleDocuments := make(map[string]map[int]bool)
+	documents := 0
+
+	for index, sample := range samples {
+		if !included[index] {
+			continue
+		}
+		documents++
+		for _, format := range sample.formats {
+			if clusters[format.Role] == nil {
+				roles = append(roles, format.Role)
+				clusters[format.Role] = make(map[formatKey]*roleCluster)
+				roleDocuments[format.Role] = make(map[int]bool)
+			}
+			roleDocuments[format.Role][index] = true
+
+			key := keyOf(format)
+			cluster := clusters[format.Role][key]
+			if cluster == nil {
+				cluster = &roleCluster{key: key, order: len(clusters[format.Role]), documents: make(map[int]bool)}
+				clusters[format.Role][key] = cluster
+			}
+			cluster.formats = append(cluster.formats, format)
+			cluster.documents[index] = true
+			cluster.paragraphs++
+		}
+	}
+
+	rules := []types.RoleRule{}
+	statistics := make([]RoleStatistics, 0, len(roles))
+	for _, role := range roles {
+		ordered := make([]*roleCluster, 0, len(clusters[role]))
+		paragraphs := 0
+		for _, cluster := range clusters[role] {
+			ordered = append(ordered, cluster)
+			paragraphs += cluster.paragraphs
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			a, b := ordered[i], ordered[j]
+			if a.paragraphs != b.paragraphs {
+				return a.paragraphs > b.paragraphs
+			}
+			if len(a.documents) != len(b.documents) {
+				return len(a.documents) > len(b.documents)
+			}
+			return a.order < b.order
+		})
+
+		stats := RoleStatistics{
+			Role:       role,
+			Documents:  len(roleDocuments[role]),
+			Paragraphs: paragraphs,
+			Confidence: roundShare(ordered[0].paragraphs, paragraphs),
+			Learned:    float64(len(roleDocuments[role])) >= options.MinSupport*float64(documents),
+		}
+		for _, cluster := range ordered {
+			stats.Clusters = append(stats.Clusters, FormatCluster{
+				Format:     cluster.format(),
+				Paragraphs: cluster.paragraphs,
+				Documents:  len(cluster.documents),
+			})
+		}
+		statistics = append(statistics, stats)
+
+		if stats.Learned {
+			rule := ordered[0].format()
+			rule.Description = fmt.Sprintf("Learned from %d paragraphs in %d documents", paragraphs, stats.Documents)
+			rule.Confidence = stats.Confidence
+			rule.Variance = clusterVariance(rule, ordered[1:], paragraphs, options.VarianceShare)
+			rules = append(rules, rule)
+		}
+	}
+	return rules, statistics
+}
+
+// keyOf 返回段落格式的聚类键
+func keyOf(format types.RoleRule) formatKey {
+	return formatKey{
+		fontName:  format.Font.Name,
+		fontSize:  format.Font.Size,
+		bold:      format.Font.Bold,
+		italic:    format.Font.Italic,
+		alignment: types.NormalizeAlignment(format.Alignment),
+		before:    format.Spacing.Before,
+		after:     format.Spacing.After,
+		line:      format.Spacing.Line,
+	}
+}
+
+// format 返回聚类的格式，缩进取聚类中最常见的值
+func (c *roleCluster) format() types.RoleRule {
+	first := c.formats[0]
+	rule := types.RoleRule{
+		Role:      first.Role,
+		Font:      types.Font{Name: c.key.fontName, Size: c.key.fontSize, Bold: c.key.bold, Italic: c.key.italic},
+		Alignment: c.key.alignment,
+		Spacing:   types.Spacing{Before: c.key.before, After: c.key.after, Line: c.key.line},
+	}
+
+	counts := make(map[types.Indentation]int)
+	rule.Indentation = first.Indentation
+	for _, format := range c.formats {
+		counts[format.Indentation]++
+		if counts[format.Indentation] > counts[rule.Indentation] {
+			rule.Indentation = format.Indentation
+		}
+	}
+	return rule
+}
+
+// clusterVariance 收集段落比例达到share的其他聚类中与主导格式不同的字体、字号、对齐方式和行距
+//
+// 只出现在一个文档中的格式是该文档的个别情况，不作为允许的偏差；粗体、斜体和段前段后间距
+// 不同的聚类也不产生允许的偏差，这些段落仍视为不符合。
+func clusterVariance(rule types.RoleRule, others []*roleCluster, paragraphs int, share float64) *types.RoleVariance {
+	variance := &types.RoleVariance{}
+	for _, cluster := range others {
+		if float64(cluster.paragraphs) < share*float64(paragraphs) || len(cluster.documents) < 2 {
+			continue
+		}
+		key := cluster.key
+		if key.bold != rule.Font.Bold || key.italic != rule.Font.Italic ||
+			key.before != rule.Spacing.Before || key.after != rule.Spacing.After {
+			continue
+		}
+		if rule.Font.Name != "" && key.fontName != "" && key.fontName != rule.Font.Name {
+			variance.FontNames = appendUnique(variance.FontNames, key.fontName)
+		}
+		if rule.Font.Size > 0 && key.fontSize > 0 && key.fontSize != rule.Font.Size {
+			variance.FontSizes = appendUnique(variance.FontSizes, key.fontSize)
+		}
+		if key.alignment != rule.Alignment {
+			variance.Alignments = appendUnique(variance.Alignments, key.alignment)
+		}
+		if rule.Spacing.Line > 0 && key.line > 0 && key.line != rule.Spacing.Line {
+			variance.LineSpacings = appendUnique(variance.LineSpacings, key.line)
+		}
+	}
+
+	if len(variance.FontNames)+len(variance.FontSizes)+len(variance.Alignments)+len(variance.LineSpacings) == 0 {
+		return nil
+	}
+	return variance
+}
+
+// appendUnique 在values中没有value时追加
+func appendUnique[T comparable](values []T, value T) []T {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// evaluateSample 按角色规则统计样本文档中不符合的段落
+func evaluateSample(document LearnedDocument, sample *learnSample, rules []types.RoleRule) LearnedDocument {
+	byRole := make(map[string]*types.RoleRule, len(rules))
+	for i := range rules {
+		byRole[rules[i].Role] = &rules[i]
+	}
+
+	document.Paragraphs, document.Deviating, document.Deviations = 0, 0, nil
+	for _, format := range sample.formats {
+		rule, exists := byRole[format.Role]
+		if !exists {
+			continue
+		}
+		document.Paragraphs++
+		deviations := rule.Deviations(format)
+		if len(deviations) == 0 {
+			continue
+		}
+		document.Deviating++
+		if document.Deviations == nil {
+			document.Deviations = make(map[string]int)
+		}
+		for _, deviation := range deviations {
+			document.Deviations[format.Role+" "+deviation]++
+		}
+	}
+	document.DeviationRate = roundShare(document.Deviating, document.Paragraphs)
+	return document
+}
+
+// dominantPageRules 返回included中最常见的页面设置，没有页面设置时返回空列表
+func dominantPageRules(samples []*learnSample, included []bool) []types.PageRule {
+	counts := make(map[string]int)
+	var dominant *types.PageRule
+	var dominantKey string
+	for i, sample := range samples {
+		if !included[i] || sample.pageRule == nil {
+			continue
+		}
+		key := fmt.Sprintf("%+v", *sample.pageRule)
+		counts[key]++
+		if dominant == nil || counts[key] > counts[dominantKey] {
+			dominant, dominantKey = sample.pageRule, key
+		}
+	}
+
+	if dominant == nil {
+		return []types.PageRule{}
+	}
+	return []types.PageRule{*dominant}
+}
+
+// countIncluded 返回参与学习的文档数
+func countIncluded(included []bool) int {
+	count := 0
+	for _, ok := range included {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+// roundShare 返回part/total，保留三位小数，total为0时返回0
+func roundShare(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 1000
+}
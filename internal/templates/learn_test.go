@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/testutil"
+)
+
+// writeCorpusDocx 生成一个标题和若干正文段落的样本文档，bodyFonts为各正文段落的字体
+func writeCorpusDocx(t *testing.T, dir, name string, bodyFonts ...string) string {
+	t.Helper()
+
+	var body strings.Builder
+	body.WriteString(`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:rPr><w:rFonts w:val="黑体"/><w:sz w:val="32"/></w:rPr><w:t>总则</w:t></w:r></w:p>`)
+	for _, font := range bodyFonts {
+		body.WriteString(`<w:p><w:pPr><w:jc w:val="both"/></w:pPr><w:r><w:rPr><w:rFonts w:val="` + font + `"/><w:sz w:val="24"/></w:rPr><w:t>正文</w:t></w:r></w:p>`)
+	}
+
+	return testutil.WriteDocx(t, filepath.Join(dir, name), body.String())
+}
+
+// TestLearnTemplate 测试按角色学习主导格式、允许的偏差和离群文档
+func TestLearnTemplate(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeCorpusDocx(t, dir, "a.docx", "仿宋", "宋体", "宋体", "宋体"),
+		writeCorpusDocx(t, dir, "b.docx", "仿宋", "宋体", "宋体", "宋体"),
+		writeCorpusDocx(t, dir, "c.docx", "宋体", "宋体", "宋体", "宋体"),
+		writeCorpusDocx(t, dir, "d.docx", "宋体", "宋体", "宋体", "宋体"),
+		writeCorpusDocx(t, dir, "odd.docx", "Arial", "Arial", "Arial", "Arial"),
+	}
+
+	report, err := NewTemplateManager("").LearnTemplateContext(context.Background(), "公文", files, LearnOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("学习模板失败: %v", err)
+	}
+
+	rules := report.Template.FormatRules.RoleRules
+	if len(rules) != 2 || rules[0].Role != "heading1" || rules[1].Role != "body" {
+		t.Fatalf("应学习到heading1和body两个角色: %+v", rules)
+	}
+	body := rules[1]
+	if body.Font.Name != "宋体" || body.Font.Size != 12 || body.Alignment != "justify" || body.Confidence != 0.875 {
+		t.Errorf("正文规则不正确: %+v", body)
+	}
+	if body.Variance == nil || !reflect.DeepEqual(body.Variance.FontNames, []string{"仿宋"}) {
+		t.Errorf("出现在多个文档中的仿宋应作为允许的偏差: %+v", body.Variance)
+	}
+
+	if len(report.Outliers) != 1 || filepath.Base(report.Outliers[0].Path) != "odd.docx" || report.Outliers[0].DeviationRate != 0.8 {
+		t.Fatalf("odd.docx应为离群文档: %+v", report.Outliers)
+	}
+	for _, document := range report.Documents[:4] {
+		if document.Deviating != 0 || document.Outlier {
+			t.Errorf("%s 应符合学习结果: %+v", document.Path, document)
+		}
+	}
+
+	// 学习结果可以写成规则文件并读回
+	data, err := MarshalRuleFile(report.Template, RuleFormatYAML)
+	if err != nil {
+		t.Fatalf("导出规则文件失败: %v", err)
+	}
+	loaded, err := UnmarshalRuleFile(data, "learned.yaml")
+	if err != nil {
+		t.Fatalf("读取规则文件失败: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, report.Template) {
+		t.Errorf("规则文件往返后模板不同:\n%+v\n%+v", loaded, report.Template)
+	}
+}
@@ -0,0 +1,493 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RegistryIndexFile 注册表索引文件名
+const RegistryIndexFile = "index.json"
+
+// RegistryRulesFile 每个模板版本的规则快照文件名
+const RegistryRulesFile = "template.json"
+
+// RegistryEntry 注册表中的一个模板版本
+type RegistryEntry struct {
+	ID          string           `json:"id"`
+	Version     string           `json:"version"` // 语义化版本，如 2.1.0
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Metadata    TemplateMetadata `json:"metadata"`
+	SourceHash  string           `json:"source_hash"`           // 源Word文档或规则文件的SHA-256
+	SourceFile  string           `json:"source_file,omitempty"` // 源Word文档副本，相对注册表目录；从规则文件注册时为空
+	RulesFile   string           `json:"rules_file"`            // 模板规则快照，相对注册表目录
+	AddedAt     time.Time        `json:"added_at"`
+}
+
+// Ref 返回"ID@版本"形式的引用
+func (e *RegistryEntry) Ref() string {
+	return e.ID + "@" + e.Version
+}
+
+// registryIndex 索引文件内容
+type registryIndex struct {
+	Templates []*RegistryEntry `json:"templates"`
+}
+
+// RegisterOptions 注册模板的选项，为空的字段使用默认值或从文档提取的值
+type RegisterOptions struct {
+	ID          string
+	Version     string // 为空时在已有最高版本上递增主版本号，首个版本为1.0.0
+	Name        string
+	Description string
+	Author      string
+	Category    string
+	Tags        []string
+}
+
+// Registry 磁盘上的模板注册表
+//
+// 目录结构为 index.json 加上每个版本一个 <ID>/<版本>/ 子目录，其中保存源Word文档的副本和规则快照，
+// 模板因此有稳定的ID和版本，不必每次运行都重新扫描和解析模板目录。
+type Registry struct {
+	dir     string
+	manager *TemplateManager
+	entries []*RegistryEntry
+}
+
+// OpenRegistry 打开注册表目录，目录或索引文件不存在时返回空注册表
+func OpenRegistry(dir string) (*Registry, error) {
+	registry := &Registry{
+		dir:     dir,
+		manager: NewTemplateManager(dir),
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, RegistryIndexFile))
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry index: %w", err)
+	}
+
+	var index registryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse registry index: %w", err)
+	}
+	registry.entries = index.Templates
+	registry.sort()
+	return registry, nil
+}
+
+// Dir 返回注册表目录
+func (r *Registry) Dir() string {
+	return r.dir
+}
+
+// Add 解析Word模板或读取规则文件并注册为新版本
+//
+// 规则文件注册的模板没有Word文档副本，ID默认使用规则文件中的ID。
+func (r *Registry) Add(sourcePath string, options RegisterOptions) (*RegistryEntry, error) {
+	hash, err := fileHash(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template source: %w", err)
+	}
+
+	ruleFile := IsRuleFile(sourcePath)
+	var template *Template
+	if ruleFile {
+		template, err = ReadRuleFile(sourcePath)
+	} else {
+		template, err = r.manager.LoadTemplate(sourcePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id := options.ID
+	if id == "" && ruleFile {
+		id = template.ID
+	}
+	if id == "" {
+		id = registryID(sourcePath, hash)
+	}
+	if err := validateRegistryID(id); err != nil {
+		return nil, err
+	}
+
+	versions := r.versions(id)
+	for _, entry := range versions {
+		if entry.SourceHash == hash && options.Version == "" {
+			return nil, fmt.Errorf("template source is already registered as %s", entry.Ref())
+		}
+	}
+
+	version, err := r.nextVersion(versions, options.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 注册表中的模板使用稳定ID和语义化版本，元数据可由选项覆盖
+	template.ID = id
+	template.Version = version
+	template.SourceHash = hash
+	if options.Name != "" {
+		template.Name = options.Name
+	}
+	if options.Description != "" {
+		template.Description = options.Description
+	}
+	if options.Author != "" {
+		template.Metadata.Author = options.Author
+	}
+	if options.Category != "" {
+		template.Metadata.Category = options.Category
+	}
+	if len(options.Tags) > 0 {
+		template.Metadata.Tags = options.Tags
+	}
+
+	versionDir := filepath.Join(id, version)
+	entry := &RegistryEntry{
+		ID:          id,
+		Version:     version,
+		Name:        template.Name,
+		Description: template.Description,
+		Metadata:    template.Metadata,
+		SourceHash:  hash,
+		RulesFile:   filepath.ToSlash(filepath.Join(versionDir, RegistryRulesFile)),
+		AddedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+	if !ruleFile {
+		entry.SourceFile = filepath.ToSlash(filepath.Join(versionDir, filepath.Base(sourcePath)))
+	}
+
+	if err := os.MkdirAll(filepath.Join(r.dir, versionDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	if !ruleFile {
+		if err := copyFile(sourcePath, r.path(entry.SourceFile)); err != nil {
+			return nil, fmt.Errorf("failed to copy template source: %w", err)
+		}
+	}
+
+	// 快照中的源文档路径相对注册表目录，整个目录可以直接复制到其他机器
+	template.SourcePath = entry.SourceFile
+	if err := writeJSONFile(r.path(entry.RulesFile), template); err != nil {
+		os.RemoveAll(filepath.Join(r.dir, versionDir))
+		return nil, fmt.Errorf("failed to write template rules: %w", err)
+	}
+
+	r.entries = append(r.entries, entry)
+	r.sort()
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// List 返回所有模板版本，按ID和版本排序
+func (r *Registry) List() []*RegistryEntry {
+	entries := make([]*RegistryEntry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// Resolve 解析模板引用
+//
+// 支持 "id"、"id@latest"（最高版本）、"id@2"（最高的2.x.x）、"id@2.1"（最高的2.1.x）和 "id@2.1.0"。
+func (r *Registry) Resolve(ref string) (*RegistryEntry, error) {
+	id, constraint, _ := strings.Cut(ref, "@")
+	versions := r.versions(id)
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("template not found: %s", id)
+	}
+	if constraint == "" || constraint == "latest" {
+		return versions[len(versions)-1], nil
+	}
+
+	want, parts, err := parseVersion(constraint)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		have, _, _ := parseVersion(versions[i].Version)
+		if have.matches(want, parts) {
+			return versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("template version not found: %s", ref)
+}
+
+// Load 读取模板引用对应的规则快照，SourcePath为源文档副本的路径，没有源文档时为空
+func (r *Registry) Load(ref string) (*Template, error) {
+	entry, err := r.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(r.path(entry.RulesFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template rules: %w", err)
+	}
+	var template Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse template rules %s: %w", entry.RulesFile, err)
+	}
+	template.SourcePath = ""
+	if entry.SourceFile != "" {
+		template.SourcePath = r.path(entry.SourceFile)
+	}
+	return &template, nil
+}
+
+// SourcePath 返回对比时使用的模板文件：源Word文档副本，从规则文件注册的模板返回规则快照
+func (r *Registry) SourcePath(entry *RegistryEntry) string {
+	if entry.SourceFile == "" {
+		return r.path(entry.RulesFile)
+	}
+	return r.path(entry.SourceFile)
+}
+
+// Remove 删除模板，引用不带版本时删除该模板的所有版本
+func (r *Registry) Remove(ref string) ([]*RegistryEntry, error) {
+	var removed []*RegistryEntry
+	if id, constraint, _ := strings.Cut(ref, "@"); constraint == "" {
+		removed = r.versions(id)
+		if len(removed) == 0 {
+			return nil, fmt.Errorf("template not found: %s", id)
+		}
+	} else {
+		entry, err := r.Resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		removed = []*RegistryEntry{entry}
+	}
+
+	kept := r.entries[:0]
+	for _, entry := range r.entries {
+		if !containsEntry(removed, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	r.entries = kept
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range removed {
+		os.RemoveAll(filepath.Join(r.dir, entry.ID, entry.Version))
+	}
+	if len(r.versions(removed[0].ID)) == 0 {
+		os.Remove(filepath.Join(r.dir, removed[0].ID))
+	}
+	return removed, nil
+}
+
+// versions 返回指定ID的所有版本，按版本升序
+func (r *Registry) versions(id string) []*RegistryEntry {
+	var versions []*RegistryEntry
+	for _, entry := range r.entries {
+		if entry.ID == id {
+			versions = append(versions, entry)
+		}
+	}
+	return versions
+}
+
+// nextVersion 规范化指定的版本号，未指定时在最高版本上递增主版本号
+func (r *Registry) nextVersion(versions []*RegistryEntry, requested string) (string, error) {
+	if requested == "" {
+		if len(versions) == 0 {
+			return "1.0.0", nil
+		}
+		latest, _, _ := parseVersion(versions[len(versions)-1].Version)
+		return semver{latest[0] + 1, 0, 0}.String(), nil
+	}
+
+	version, _, err := parseVersion(requested)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range versions {
+		if entry.Version == version.String() {
+			return "", fmt.Errorf("template version already exists: %s", entry.Ref())
+		}
+	}
+	return version.String(), nil
+}
+
+// sort 按ID和版本排序
+func (r *Registry) sort() {
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		if r.entries[i].ID != r.entries[j].ID {
+			return r.entries[i].ID < r.entries[j].ID
+		}
+		a, _, _ := parseVersion(r.entries[i].Version)
+		b, _, _ := parseVersion(r.entries[j].Version)
+		return a.less(b)
+	})
+}
+
+// save 写入索引文件，先写临时文件再重命名，避免中断时留下不完整的索引
+func (r *Registry) save() error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	indexPath := filepath.Join(r.dir, RegistryIndexFile)
+	if err := writeJSONFile(indexPath+".tmp", registryIndex{Templates: r.entries}); err != nil {
+		return fmt.Errorf("failed to write registry index: %w", err)
+	}
+	if err := os.Rename(indexPath+".tmp", indexPath); err != nil {
+		return fmt.Errorf("failed to write registry index: %w", err)
+	}
+	return nil
+}
+
+// path 将注册表内的相对路径转换为文件路径
+func (r *Registry) path(relative string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(relative))
+}
+
+// semver 语义化版本号
+type semver [3]int
+
+// parseVersion 解析版本号，允许省略次版本号和修订号（如"2"、"2.1"），返回实际给出的段数
+func parseVersion(value string) (semver, int, error) {
+	var version semver
+	fields := strings.Split(strings.TrimPrefix(value, "v"), ".")
+	if len(fields) > 3 {
+		return version, 0, fmt.Errorf("invalid template version: %s", value)
+	}
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 0 {
+			return version, 0, fmt.Errorf("invalid template version: %s", value)
+		}
+		version[i] = number
+	}
+	return version, len(fields), nil
+}
+
+// String 返回 MAJOR.MINOR.PATCH 形式的版本号
+func (v semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// less 比较版本号大小
+func (v semver) less(other semver) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+	return false
+}
+
+// matches 检查版本号的前parts段是否与want相同
+func (v semver) matches(want semver, parts int) bool {
+	for i := 0; i < parts; i++ {
+		if v[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// registryID 从文件名生成模板ID，文件名中没有可用字符时使用内容哈希
+func registryID(sourcePath, hash string) string {
+	name := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+
+	var id strings.Builder
+	for _, char := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			id.WriteRune(char)
+		case char == ' ' || char == '_' || char == '-' || char == '.':
+			if id.Len() > 0 && !strings.HasSuffix(id.String(), "-") {
+				id.WriteByte('-')
+			}
+		}
+	}
+	if cleaned := strings.Trim(id.String(), "-"); cleaned != "" {
+		return cleaned
+	}
+	return "template-" + hash[:8]
+}
+
+// validateRegistryID 检查模板ID只包含字母、数字、"-"、"_"和"."，不能以"."开头
+func validateRegistryID(id string) error {
+	if id == "" || strings.HasPrefix(id, ".") {
+		return fmt.Errorf("invalid template ID: %q", id)
+	}
+	for _, char := range id {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && !strings.ContainsRune("-_.", char) {
+			return fmt.Errorf("invalid template ID: %q, only letters, digits, '-', '_' and '.' are allowed", id)
+		}
+	}
+	return nil
+}
+
+// containsEntry 检查列表中是否包含指定版本
+func containsEntry(entries []*RegistryEntry, target *RegistryEntry) bool {
+	for _, entry := range entries {
+		if entry == target {
+			return true
+		}
+	}
+	return false
+}
+
+// fileHash 计算文件的SHA-256
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// copyFile 复制文件
+func copyFile(source, target string) error {
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	output, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(output, input)
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeJSONFile 以缩进格式写入JSON文件
+func writeJSONFile(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,90 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docs-parser/internal/testutil"
+)
+
+// writeTemplateDocx 生成使用指定字体的最小Word模板
+func writeTemplateDocx(t *testing.T, name, font string) string {
+	t.Helper()
+
+	return testutil.WriteDocx(t, filepath.Join(t.TempDir(), name), `
+<w:p><w:r><w:rPr><w:rFonts w:val="`+font+`"/><w:sz w:val="32"/></w:rPr><w:t>标题</w:t></w:r></w:p>
+<w:p><w:r><w:t>正文</w:t></w:r></w:p>
+`)
+}
+
+// TestRegistry 测试模板注册、版本解析、持久化和删除
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	registry, err := OpenRegistry(dir)
+	if err != nil {
+		t.Fatalf("打开空注册表失败: %v", err)
+	}
+
+	first := writeTemplateDocx(t, "Gov Notice.docx", "黑体")
+	entry, err := registry.Add(first, RegisterOptions{Category: "公文", Tags: []string{"通知"}})
+	if err != nil {
+		t.Fatalf("注册模板失败: %v", err)
+	}
+	if entry.Ref() != "gov-notice@1.0.0" || entry.SourceHash == "" || entry.Metadata.Category != "公文" {
+		t.Errorf("注册结果不正确: %+v", entry)
+	}
+	if _, err := registry.Add(first, RegisterOptions{ID: "gov-notice"}); err == nil {
+		t.Error("相同的源文档不应重复注册")
+	}
+
+	second := writeTemplateDocx(t, "notice.docx", "宋体")
+	for _, version := range []string{"", "2.1"} {
+		if _, err := registry.Add(second, RegisterOptions{ID: "gov-notice", Version: version}); err != nil {
+			t.Fatalf("注册版本 %q 失败: %v", version, err)
+		}
+	}
+
+	// 重新打开后从索引文件恢复
+	registry, err = OpenRegistry(dir)
+	if err != nil {
+		t.Fatalf("重新打开注册表失败: %v", err)
+	}
+	for ref, want := range map[string]string{
+		"gov-notice":        "2.1.0",
+		"gov-notice@latest": "2.1.0",
+		"gov-notice@1":      "1.0.0",
+		"gov-notice@2.0":    "2.0.0",
+		"gov-notice@2.1.0":  "2.1.0",
+	} {
+		entry, err := registry.Resolve(ref)
+		if err != nil || entry.Version != want {
+			t.Errorf("%s 应解析为 %s，实际 %v %v", ref, want, entry, err)
+		}
+	}
+	if _, err := registry.Resolve("gov-notice@3"); err == nil {
+		t.Error("不存在的版本应返回错误")
+	}
+
+	template, err := registry.Load("gov-notice@1")
+	if err != nil {
+		t.Fatalf("读取规则快照失败: %v", err)
+	}
+	if len(template.FormatRules.FontRules) != 1 || template.FormatRules.FontRules[0].Name != "黑体" {
+		t.Errorf("规则快照应包含模板的字体规则: %+v", template.FormatRules.FontRules)
+	}
+	if _, err := os.Stat(template.SourcePath); err != nil {
+		t.Errorf("源文档副本应存在: %v", err)
+	}
+
+	removed, err := registry.Remove("gov-notice@2.0")
+	if err != nil || len(removed) != 1 || len(registry.List()) != 2 {
+		t.Fatalf("删除单个版本失败: %v %v", removed, err)
+	}
+	if removed, err := registry.Remove("gov-notice"); err != nil || len(removed) != 2 {
+		t.Fatalf("删除所有版本失败: %v %v", removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gov-notice")); !os.IsNotExist(err) {
+		t.Error("删除所有版本后应删除模板目录")
+	}
+}
@@ -0,0 +1,522 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"docs-parser/internal/core/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 规则文件格式
+const (
+	RuleFormatYAML = "yaml"
+	RuleFormatJSON = "json"
+)
+
+// RuleProblem 规则文件中的一个问题
+type RuleProblem struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Path    string `json:"path"` // 字段路径，如 format_rules.font_rules[0].size
+	Message string `json:"message"`
+}
+
+// RuleFileError 规则文件的校验错误，包含文件中发现的所有问题
+type RuleFileError struct {
+	File     string
+	Problems []RuleProblem
+}
+
+// Error 每个问题一行，格式为"文件:行:列: 字段路径: 问题"
+func (e *RuleFileError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		location := fmt.Sprintf("%s:%d:%d", e.File, problem.Line, problem.Column)
+		if problem.Path != "" {
+			location += ": " + problem.Path
+		}
+		lines[i] = location + ": " + problem.Message
+	}
+	return strings.Join(lines, "\n")
+}
+
+// add 记录node位置的问题
+func (e *RuleFileError) add(node *yaml.Node, path, message string) {
+	e.Problems = append(e.Problems, RuleProblem{Line: node.Line, Column: node.Column, Path: path, Message: message})
+}
+
+// IsRuleFile 按扩展名判断是否为规则文件（.yaml、.yml、.json）
+func IsRuleFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// RuleFormatOf 按扩展名返回规则文件格式，.json为JSON，其他为YAML
+func RuleFormatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return RuleFormatJSON
+	}
+	return RuleFormatYAML
+}
+
+// MarshalRuleFile 将模板序列化为可手工编辑的规则文件，字段名与JSON格式相同
+func MarshalRuleFile(template *Template, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal template: %w", err)
+	}
+
+	switch format {
+	case RuleFormatJSON:
+		return append(data, '\n'), nil
+	case RuleFormatYAML:
+	default:
+		return nil, fmt.Errorf("unsupported rule file format: %s", format)
+	}
+
+	// JSON是YAML的子集，解析为节点后改为块格式输出，字段顺序与JSON相同
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to convert template to YAML: %w", err)
+	}
+	setBlockStyle(&document)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to write YAML: %w", err)
+	}
+	encoder.Close()
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalRuleFile 解析YAML或JSON规则文件，name用于错误信息
+//
+// 除语法错误外，还检查未知字段、重复字段、字段类型和规则内容，所有问题以*RuleFileError一次返回。
+func UnmarshalRuleFile(data []byte, name string) (*Template, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, syntaxError(name, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, &RuleFileError{File: name, Problems: []RuleProblem{{Line: 1, Column: 1, Message: "rule file is empty"}}}
+	}
+	root := document.Content[0]
+
+	ruleErr := &RuleFileError{File: name}
+	value := convertNode(root, reflect.TypeOf(Template{}), "", ruleErr)
+	if len(ruleErr.Problems) > 0 {
+		return nil, ruleErr
+	}
+
+	// 字段类型已经检查过，经JSON转换到Template不会再出错
+	converted, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert rule file %s: %w", name, err)
+	}
+	var template Template
+	if err := json.Unmarshal(converted, &template); err != nil {
+		return nil, fmt.Errorf("failed to convert rule file %s: %w", name, err)
+	}
+
+	for _, problem := range templateProblems(&template) {
+		ruleErr.add(nodeAt(root, problem.path), problem.path, problem.message)
+	}
+	if len(ruleErr.Problems) > 0 {
+		return nil, ruleErr
+	}
+	return &template, nil
+}
+
+// ReadRuleFile 读取规则文件
+func ReadRuleFile(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule file: %w", err)
+	}
+	return UnmarshalRuleFile(data, path)
+}
+
+// WriteRuleFile 将模板写入规则文件，格式由扩展名决定
+func WriteRuleFile(path string, template *Template) error {
+	data, err := MarshalRuleFile(template, RuleFormatOf(path))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// setBlockStyle 清除JSON的流式和引号样式，由编码器按需要加引号
+func setBlockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		setBlockStyle(child)
+	}
+}
+
+// yamlLinePattern 匹配yaml错误信息中的行号
+var yamlLinePattern = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// syntaxError 将yaml的语法错误转换为带行号的RuleFileError
+//
+// yaml只给出行号，对未闭合的括号等错误给出的是所在结构开始的行。
+func syntaxError(name string, err error) error {
+	message := err.Error()
+	line := 1
+	if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+		line, _ = strconv.Atoi(match[1])
+		message = strings.TrimPrefix(message, match[0])
+	} else {
+		message = strings.TrimPrefix(message, "yaml: ")
+	}
+	return &RuleFileError{File: name, Problems: []RuleProblem{{Line: line, Column: 1, Message: message}}}
+}
+
+// timeType time.Time以字符串形式出现在规则文件中
+var timeType = reflect.TypeOf(time.Time{})
+
+// convertNode 按目标类型检查节点，并转换为可以编码为JSON的值
+//
+// 字符串字段接受任意标量，手工编辑时写成 version: 2 也能正确读取；其他类型不符时记录问题。
+func convertNode(node *yaml.Node, target reflect.Type, path string, ruleErr *RuleFileError) any {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+
+	switch {
+	case target == timeType || target.Kind() == reflect.String:
+		if node.Kind != yaml.ScalarNode {
+			ruleErr.add(node, path, "expected a string")
+			return nil
+		}
+		return node.Value
+
+	case target.Kind() == reflect.Bool:
+		var value bool
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" || node.Decode(&value) != nil {
+			ruleErr.add(node, path, fmt.Sprintf("expected true or false, got %q", node.Value))
+			return nil
+		}
+		return value
+
+	case target.Kind() >= reflect.Int && target.Kind() <= reflect.Uint64:
+		var value int64
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" || node.Decode(&value) != nil {
+			ruleErr.add(node, path, fmt.Sprintf("expected an integer, got %q", node.Value))
+			return nil
+		}
+		return value
+
+	case target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64:
+		var value float64
+		if node.Kind != yaml.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") || node.Decode(&value) != nil {
+			ruleErr.add(node, path, fmt.Sprintf("expected a number, got %q", node.Value))
+			return nil
+		}
+		return value
+
+	case target.Kind() == reflect.Slice || target.Kind() == reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			ruleErr.add(node, path, "expected a list")
+			return nil
+		}
+		values := make([]any, len(node.Content))
+		for i, child := range node.Content {
+			values[i] = convertNode(child, target.Elem(), fmt.Sprintf("%s[%d]", path, i), ruleErr)
+		}
+		return values
+
+	case target.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			ruleErr.add(node, path, "expected a mapping")
+			return nil
+		}
+		values := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			values[key] = convertNode(node.Content[i+1], target.Elem(), joinPath(path, key), ruleErr)
+		}
+		return values
+
+	case target.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			ruleErr.add(node, path, "expected a mapping")
+			return nil
+		}
+		fields := jsonFields(target)
+		values := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, key := node.Content[i], node.Content[i].Value
+			fieldPath := joinPath(path, key)
+			field, known := fields[key]
+			if !known {
+				ruleErr.add(keyNode, fieldPath, fmt.Sprintf("unknown field %q", key))
+				continue
+			}
+			if _, duplicate := values[key]; duplicate {
+				ruleErr.add(keyNode, fieldPath, fmt.Sprintf("duplicate field %q", key))
+				continue
+			}
+			values[key] = convertNode(node.Content[i+1], field, fieldPath, ruleErr)
+		}
+		return values
+	}
+
+	// 其他类型（如interface{}）按YAML本身的类型解码
+	var value any
+	if err := node.Decode(&value); err != nil {
+		ruleErr.add(node, path, err.Error())
+	}
+	return value
+}
+
+// jsonFields 返回结构体按JSON字段名索引的字段类型，包括嵌入结构体的字段
+func jsonFields(target reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range jsonFields(field.Type) {
+				fields[embeddedName] = embeddedType
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// 解析字段路径，pathSegmentPattern匹配其中的一段，如 font_rules[0]
+var (
+	pathSegmentPattern = regexp.MustCompile(`^([^\[]*)((?:\[\d+\])*)$`)
+	pathIndexPattern   = regexp.MustCompile(`\d+`)
+)
+
+// nodeAt 返回字段路径对应的节点，字段不存在时返回最近的上级节点
+func nodeAt(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	if path == "" {
+		return node
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		match := pathSegmentPattern.FindStringSubmatch(segment)
+		if match == nil {
+			return node
+		}
+
+		if match[1] != "" {
+			child := mappingValue(node, match[1])
+			if child == nil {
+				return node
+			}
+			node = child
+		}
+		for _, index := range pathIndexPattern.FindAllString(match[2], -1) {
+			i, _ := strconv.Atoi(index)
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return node
+			}
+			node = node.Content[i]
+		}
+	}
+	return node
+}
+
+// mappingValue 返回映射节点中key对应的值
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// ruleProblem 模板内容的问题，path为字段路径
+type ruleProblem struct {
+	path    string
+	message string
+}
+
+// templateProblems 检查模板的基本信息和格式规则
+func templateProblems(template *Template) []ruleProblem {
+	var problems []ruleProblem
+	if template.ID == "" {
+		problems = append(problems, ruleProblem{"id", "template ID is required"})
+	}
+	if template.Name == "" {
+		problems = append(problems, ruleProblem{"name", "template name is required"})
+	}
+	if template.Version == "" {
+		problems = append(problems, ruleProblem{"version", "template version is required"})
+	}
+	if template.Extends != "" && template.Extends == template.ID {
+		problems = append(problems, ruleProblem{"extends", "template cannot extend itself"})
+	}
+	for i, include := range template.Includes {
+		if include == "" {
+			problems = append(problems, ruleProblem{fmt.Sprintf("includes[%d]", i), "included template ID is required"})
+		}
+	}
+	for i, key := range template.Remove {
+		if key == "" {
+			problems = append(problems, ruleProblem{fmt.Sprintf("remove[%d]", i), "role or rule ID to remove is required"})
+		}
+	}
+
+	for _, problem := range formatRuleProblems(&template.FormatRules) {
+		problem.path = joinPath("format_rules", problem.path)
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// roleAlignments 角色规则允许的对齐方式，空值表示不作要求
+var roleAlignments = map[types.Alignment]bool{
+	"": true, "left": true, "center": true, "right": true, "justify": true,
+	"both": true, "distribute": true, "start": true, "end": true,
+}
+
+// formatRuleProblems 检查格式规则，返回所有问题
+func formatRuleProblems(rules *types.FormatRules) []ruleProblem {
+	var problems []ruleProblem
+	add := func(path, format string, args ...any) {
+		problems = append(problems, ruleProblem{path, fmt.Sprintf(format, args...)})
+	}
+
+	// 字体规则
+	for i, rule := range rules.FontRules {
+		path := fmt.Sprintf("font_rules[%d]", i)
+		if rule.ID == "" {
+			add(path+".id", "font rule %d: ID is required", i)
+		}
+		if rule.Name == "" {
+			add(path+".name", "font rule %d: name is required", i)
+		}
+		if rule.Size <= 0 {
+			add(path+".size", "font rule %d: size must be positive", i)
+		}
+	}
+
+	// 段落规则
+	for i, rule := range rules.ParagraphRules {
+		path := fmt.Sprintf("paragraph_rules[%d]", i)
+		if rule.ID == "" {
+			add(path+".id", "paragraph rule %d: ID is required", i)
+		}
+		if rule.Name == "" {
+			add(path+".name", "paragraph rule %d: name is required", i)
+		}
+	}
+
+	// 表格规则
+	for i, rule := range rules.TableRules {
+		path := fmt.Sprintf("table_rules[%d]", i)
+		if rule.ID == "" {
+			add(path+".id", "table rule %d: ID is required", i)
+		}
+		if rule.Name == "" {
+			add(path+".name", "table rule %d: name is required", i)
+		}
+		if rule.Width <= 0 {
+			add(path+".width", "table rule %d: width must be positive", i)
+		}
+	}
+
+	// 页面规则
+	for i, rule := range rules.PageRules {
+		path := fmt.Sprintf("page_rules[%d]", i)
+		if rule.ID == "" {
+			add(path+".id", "page rule %d: ID is required", i)
+		}
+		if rule.Name == "" {
+			add(path+".name", "page rule %d: name is required", i)
+		}
+		if rule.PageSize.Width <= 0 || rule.PageSize.Height <= 0 {
+			add(path+".page_size", "page rule %d: page size must be positive", i)
+		}
+	}
+
+	// 角色规则
+	roles := make(map[string]bool)
+	for i, rule := range rules.RoleRules {
+		path := fmt.Sprintf("role_rules[%d]", i)
+		if rule.Role == "" {
+			add(path+".role", "role rule %d: role is required", i)
+		} else if roles[rule.Role] {
+			add(path+".role", "role rule %d: duplicate role %q", i, rule.Role)
+		}
+		roles[rule.Role] = true
+		if rule.Font.Size < 0 {
+			add(path+".font.size", "role rule %d: font size must not be negative", i)
+		}
+		if !roleAlignments[rule.Alignment] {
+			add(path+".alignment", "role rule %d: unknown alignment %q", i, rule.Alignment)
+		}
+		if rule.Confidence < 0 || rule.Confidence > 1 {
+			add(path+".confidence", "role rule %d: confidence must be between 0 and 1", i)
+		}
+		if rule.Variance == nil {
+			continue
+		}
+		for j, size := range rule.Variance.FontSizes {
+			if size <= 0 {
+				add(fmt.Sprintf("%s.variance.font_sizes[%d]", path, j), "role rule %d: font size must be positive", i)
+			}
+		}
+		for j, alignment := range rule.Variance.Alignments {
+			if alignment == "" || !roleAlignments[alignment] {
+				add(fmt.Sprintf("%s.variance.alignments[%d]", path, j), "role rule %d: unknown alignment %q", i, alignment)
+			}
+		}
+		for j, line := range rule.Variance.LineSpacings {
+			if line <= 0 {
+				add(fmt.Sprintf("%s.variance.line_spacings[%d]", path, j), "role rule %d: line spacing must be positive", i)
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,106 @@
+package templates
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"docs-parser/internal/core/types"
+)
+
+// TestRuleFile_RoundTrip 测试从Word模板导出的规则文件以YAML和JSON读回后与原模板相同
+func TestRuleFile_RoundTrip(t *testing.T) {
+	template, err := NewTemplateManager("").LoadTemplate(writeTemplateDocx(t, "notice.docx", "黑体"))
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	template.FormatRules.RoleRules = append(template.FormatRules.RoleRules, types.RoleRule{
+		Role:        types.RoleCaption,
+		Description: "图表题注: 1.0",
+		Font:        types.Font{Name: "楷体", Size: 21, Italic: true},
+		Alignment:   types.AlignCenter,
+		Spacing:     types.Spacing{Before: 6, Line: 1.5},
+	})
+
+	for _, format := range []string{RuleFormatYAML, RuleFormatJSON} {
+		data, err := MarshalRuleFile(template, format)
+		if err != nil {
+			t.Fatalf("导出%s失败: %v", format, err)
+		}
+		loaded, err := UnmarshalRuleFile(data, "rules."+format)
+		if err != nil {
+			t.Fatalf("读取%s失败: %v\n%s", format, err, data)
+		}
+		if !reflect.DeepEqual(loaded, template) {
+			t.Errorf("%s往返后模板不同:\n%+v\n%+v", format, loaded, template)
+		}
+	}
+}
+
+// TestRuleFile_Problems 测试手工编辑错误按行号报告
+func TestRuleFile_Problems(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "未知字段和类型错误",
+			data: `id: notice
+name: 通知
+format_rules:
+  role_rules:
+    - role: body
+      font:
+        name: 宋体
+        size: large
+      colour: red
+`,
+			want: []string{
+				"rules.yaml:8:15: format_rules.role_rules[0].font.size: expected a number",
+				`rules.yaml:9:7: format_rules.role_rules[0].colour: unknown field "colour"`,
+			},
+		},
+		{
+			name: "规则内容无效",
+			data: `id: notice
+name: 通知
+version: "1.0"
+format_rules:
+  role_rules:
+    - role: body
+      alignment: middle
+    - role: body
+`,
+			want: []string{
+				`rules.yaml:7:18: format_rules.role_rules[0].alignment:`,
+				`rules.yaml:8:13: format_rules.role_rules[1].role:`,
+			},
+		},
+		{
+			name: "语法错误",
+			data: "id: notice\nname: \"通知\n",
+			want: []string{"rules.yaml:2:1: found unexpected end of stream"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalRuleFile([]byte(tt.data), "rules.yaml")
+			var ruleErr *RuleFileError
+			if !errors.As(err, &ruleErr) {
+				t.Fatalf("应返回*RuleFileError，实际: %v", err)
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("应报告 %d 个问题，实际:\n%v", len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("问题 %d 应以 %q 开头，实际 %q", i+1, want, lines[i])
+				}
+			}
+		})
+	}
+}
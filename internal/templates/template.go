@@ -19,7 +19,11 @@ type Template struct {
 	Version     string            `json:"version"`
 	FormatRules types.FormatRules `json:"format_rules"`
 	Metadata    TemplateMetadata  `json:"metadata"`
-	SourcePath  string            `json:"source_path"` // Word文档路径
+	SourcePath  string            `json:"source_path"`           // Word文档路径，从规则文件加载时为规则文件路径
+	SourceHash  string            `json:"source_hash,omitempty"` // Word文档的SHA-256，注册到注册表时记录
+	Extends     string            `json:"extends,omitempty"`     // 继承的模板ID，本模板的规则在其基础上增加或覆盖
+	Includes    []string          `json:"includes,omitempty"`    // 组合的规则片段（模板ID），在继承的规则之后按顺序应用
+	Remove      []string          `json:"remove,omitempty"`      // 从继承和组合的规则中删除的角色或规则ID
 }
 
 // TemplateMetadata 模板元数据
@@ -36,6 +40,7 @@ type TemplateManager struct {
 	templates  map[string]*Template
 	basePath   string
 	wordParser *formats.WordParser
+	lookup     func(templateID string) (*Template, error)
 }
 
 // NewTemplateManager 创建模板管理器
@@ -81,9 +86,27 @@ func (tm *TemplateManager) LoadTemplate(templatePath string) (*Template, error)
 	return template, nil
 }
 
+// LoadTemplateFile 从YAML或JSON规则文件加载模板，不需要Word文档
+//
+// 模板的SourcePath设为规则文件本身，对比时按段落角色使用其中的规则。
+func (tm *TemplateManager) LoadTemplateFile(path string) (*Template, error) {
+	template, err := ReadRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	template.SourcePath = path
+	tm.templates[template.ID] = template
+	return template, nil
+}
+
+// ExportTemplate 将模板写入规则文件，格式由扩展名决定（.json为JSON，其他为YAML）
+func (tm *TemplateManager) ExportTemplate(template *Template, path string) error {
+	return WriteRuleFile(path, template)
+}
+
 // isSupportedWordFormat 检查是否为支持的Word格式
 func (tm *TemplateManager) isSupportedWordFormat(ext string) bool {
-	supportedFormats := []string{".docx", ".doc", ".dot", ".dotx"}
+	supportedFormats := []string{".docx", ".doc", ".dot", ".dotx", ".docm", ".dotm", ".odt", ".ott"}
 	for _, format := range supportedFormats {
 		if ext == format {
 			return true
@@ -162,6 +185,9 @@ func (tm *TemplateManager) extractFormatRules(doc *types.Document) types.FormatR
 	// 提取页面规则
 	formatRules.PageRules = tm.extractPageRules(doc)
 
+	// 提取段落角色规则
+	formatRules.RoleRules = tm.extractRoleRules(doc)
+
 	return formatRules
 }
 
@@ -173,6 +199,10 @@ func (tm *TemplateManager) extractFontRules(doc *types.Document) []types.FontRul
 	// 从段落中提取字体信息
 	for _, paragraph := range doc.Content.Paragraphs {
 		for _, run := range paragraph.Runs {
+			// 继承样式字体的文本块没有显式的字体名称和字号，不构成规则
+			if run.Font.Name == "" || run.Font.Size <= 0 {
+				continue
+			}
 			fontKey := fmt.Sprintf("%s_%.1f", run.Font.Name, run.Font.Size)
 			if !fontMap[fontKey] {
 				fontRule := types.FontRule{
@@ -219,6 +249,44 @@ func (tm *TemplateManager) extractParagraphRules(doc *types.Document) []types.Pa
 	return paragraphRules
 }
 
+// extractRoleRules 按段落角色提取格式规则，同一角色的段落格式不一致时取出现次数最多的格式
+func (tm *TemplateManager) extractRoleRules(doc *types.Document) []types.RoleRule {
+	var roles []string
+	counts := make(map[string]map[string]int)
+	formats := make(map[string]map[string]types.RoleRule)
+
+	for i := range doc.Content.Paragraphs {
+		paragraph := &doc.Content.Paragraphs[i]
+		if strings.TrimSpace(paragraph.Text) == "" {
+			continue
+		}
+
+		rule := types.RoleRuleOf(paragraph)
+		if counts[rule.Role] == nil {
+			roles = append(roles, rule.Role)
+			counts[rule.Role] = make(map[string]int)
+			formats[rule.Role] = make(map[string]types.RoleRule)
+		}
+		key := fmt.Sprintf("%+v", rule)
+		if _, exists := formats[rule.Role][key]; !exists {
+			formats[rule.Role][key] = rule
+		}
+		counts[rule.Role][key]++
+	}
+
+	roleRules := make([]types.RoleRule, 0, len(roles))
+	for _, role := range roles {
+		var dominant string
+		for key, count := range counts[role] {
+			if count > counts[role][dominant] || (count == counts[role][dominant] && key < dominant) {
+				dominant = key
+			}
+		}
+		roleRules = append(roleRules, formats[role][dominant])
+	}
+	return roleRules
+}
+
 // extractTableRules 提取表格规则
 func (tm *TemplateManager) extractTableRules(doc *types.Document) []types.TableRule {
 	var tableRules []types.TableRule
@@ -307,7 +375,7 @@ func (tm *TemplateManager) createTemplateMetadata(templatePath string, doc *type
 	return metadata
 }
 
-// LoadTemplatesFromDirectory 从目录加载所有Word文档模板
+// LoadTemplatesFromDirectory 从目录加载所有Word文档模板和规则文件
 func (tm *TemplateManager) LoadTemplatesFromDirectory(dirPath string) error {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -319,13 +387,20 @@ func (tm *TemplateManager) LoadTemplatesFromDirectory(dirPath string) error {
 			continue
 		}
 
-		// 只加载Word文档格式
+		// 加载Word文档和规则文件
+		templatePath := filepath.Join(dirPath, entry.Name())
+		if IsRuleFile(templatePath) {
+			if _, err := tm.LoadTemplateFile(templatePath); err != nil {
+				return fmt.Errorf("failed to load template %s: %w", entry.Name(), err)
+			}
+			continue
+		}
+
 		ext := strings.ToLower(filepath.Ext(entry.Name()))
 		if !tm.isSupportedWordFormat(ext) {
 			continue
 		}
 
-		templatePath := filepath.Join(dirPath, entry.Name())
 		if _, err := tm.LoadTemplate(templatePath); err != nil {
 			return fmt.Errorf("failed to load template %s: %w", entry.Name(), err)
 		}
@@ -343,6 +418,22 @@ func (tm *TemplateManager) GetTemplate(templateID string) (*Template, error) {
 	return template, nil
 }
 
+// SetLookup 设置查找管理器中没有的模板的函数，解析extends和includes时使用，如从注册表读取
+func (tm *TemplateManager) SetLookup(lookup func(templateID string) (*Template, error)) {
+	tm.lookup = lookup
+}
+
+// findTemplate 按ID查找模板，管理器中没有时使用SetLookup设置的函数
+func (tm *TemplateManager) findTemplate(templateID string) (*Template, error) {
+	if template, exists := tm.templates[templateID]; exists {
+		return template, nil
+	}
+	if tm.lookup != nil {
+		return tm.lookup(templateID)
+	}
+	return nil, fmt.Errorf("template not found: %s", templateID)
+}
+
 // ListTemplates 列出所有可用模板
 func (tm *TemplateManager) ListTemplates() []*Template {
 	templates := make([]*Template, 0, len(tm.templates))
@@ -353,26 +444,17 @@ func (tm *TemplateManager) ListTemplates() []*Template {
 }
 
 // validateTemplate 验证模板格式
+//
+// 从规则文件加载的模板没有Word文档，因此不要求SourcePath。
 func (tm *TemplateManager) validateTemplate(template *Template) error {
-	if template.ID == "" {
-		return fmt.Errorf("template ID is required")
-	}
-	if template.Name == "" {
-		return fmt.Errorf("template name is required")
+	problems := templateProblems(template)
+	if len(problems) == 0 {
+		return nil
 	}
-	if template.Version == "" {
-		return fmt.Errorf("template version is required")
+	if strings.HasPrefix(problems[0].path, "format_rules.") {
+		return fmt.Errorf("format rules validation failed: %s", problems[0].message)
 	}
-	if template.SourcePath == "" {
-		return fmt.Errorf("template source path is required")
-	}
-
-	// 验证格式规则
-	if err := tm.validateFormatRules(&template.FormatRules); err != nil {
-		return fmt.Errorf("format rules validation failed: %w", err)
-	}
-
-	return nil
+	return fmt.Errorf("%s", problems[0].message)
 }
 
 // ValidateTemplate 验证模板（公共方法）
@@ -380,57 +462,11 @@ func (tm *TemplateManager) ValidateTemplate(template *Template) error {
 	return tm.validateTemplate(template)
 }
 
-// validateFormatRules 验证格式规则
+// validateFormatRules 验证格式规则，返回发现的第一个问题
 func (tm *TemplateManager) validateFormatRules(rules *types.FormatRules) error {
-	// 验证字体规则
-	for i, rule := range rules.FontRules {
-		if rule.ID == "" {
-			return fmt.Errorf("font rule %d: ID is required", i)
-		}
-		if rule.Name == "" {
-			return fmt.Errorf("font rule %d: name is required", i)
-		}
-		if rule.Size <= 0 {
-			return fmt.Errorf("font rule %d: size must be positive", i)
-		}
+	if problems := formatRuleProblems(rules); len(problems) > 0 {
+		return fmt.Errorf("%s", problems[0].message)
 	}
-
-	// 验证段落规则
-	for i, rule := range rules.ParagraphRules {
-		if rule.ID == "" {
-			return fmt.Errorf("paragraph rule %d: ID is required", i)
-		}
-		if rule.Name == "" {
-			return fmt.Errorf("paragraph rule %d: name is required", i)
-		}
-	}
-
-	// 验证表格规则
-	for i, rule := range rules.TableRules {
-		if rule.ID == "" {
-			return fmt.Errorf("table rule %d: ID is required", i)
-		}
-		if rule.Name == "" {
-			return fmt.Errorf("table rule %d: name is required", i)
-		}
-		if rule.Width <= 0 {
-			return fmt.Errorf("table rule %d: width must be positive", i)
-		}
-	}
-
-	// 验证页面规则
-	for i, rule := range rules.PageRules {
-		if rule.ID == "" {
-			return fmt.Errorf("page rule %d: ID is required", i)
-		}
-		if rule.Name == "" {
-			return fmt.Errorf("page rule %d: name is required", i)
-		}
-		if rule.PageSize.Width <= 0 || rule.PageSize.Height <= 0 {
-			return fmt.Errorf("page rule %d: page size must be positive", i)
-		}
-	}
-
 	return nil
 }
 
@@ -1,7 +1,12 @@
 package templates
 
 import (
+	"archive/zip"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"docs-parser/internal/packaging"
 )
 
 func TestNewTemplateManager(t *testing.T) {
@@ -27,6 +32,37 @@ func TestTemplateManager_LoadTemplate(t *testing.T) {
 	}
 }
 
+// TestTemplateManager_LoadTemplateOTT 测试OpenDocument文本模板可以作为对比模板加载
+func TestTemplateManager_LoadTemplateOTT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "公文.ott")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	writer := zip.NewWriter(file)
+	w, _ := writer.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	w.Write([]byte(packaging.MimeTypeTextTemplate))
+	w, _ = writer.Create("content.xml")
+	w.Write([]byte(`<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+		` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+		` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"><office:automatic-styles>` +
+		`<style:style style:name="P1" style:family="paragraph"><style:text-properties style:font-name="仿宋" fo:font-size="16pt"/></style:style>` +
+		`</office:automatic-styles><office:body><office:text><text:p text:style-name="P1">正文</text:p></office:text></office:body></office:document-content>`))
+	if err := writer.Close(); err != nil {
+		t.Fatalf("生成测试文档失败: %v", err)
+	}
+	file.Close()
+
+	template, err := NewTemplateManager("").LoadTemplate(path)
+	if err != nil {
+		t.Fatalf("加载OTT模板失败: %v", err)
+	}
+	rules := template.FormatRules.FontRules
+	if len(rules) != 1 || rules[0].Name != "仿宋" || rules[0].Size != 16 {
+		t.Errorf("应从OTT模板提取字体规则，实际 %+v", rules)
+	}
+}
+
 func TestTemplateManager_LoadTemplatesFromDirectory(t *testing.T) {
 	manager := NewTemplateManager("test_templates")
 
@@ -51,7 +87,7 @@ func TestTemplateManager_IsSupportedWordFormat(t *testing.T) {
 	manager := NewTemplateManager("test_templates")
 
 	// 测试支持的格式
-	supportedFormats := []string{".docx", ".doc", ".dot", ".dotx"}
+	supportedFormats := []string{".docx", ".doc", ".dot", ".dotx", ".docm", ".dotm", ".odt", ".ott"}
 	for _, format := range supportedFormats {
 		if !manager.isSupportedWordFormat(format) {
 			t.Errorf("期望 %s 是支持的格式", format)
@@ -0,0 +1,230 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// 复合文件格式中的常量，testutil不能引用cfb包（cfb包自己的测试也使用这里的构造函数）
+const (
+	cfbFreeSector       = 0xFFFFFFFF
+	cfbEndOfChain       = 0xFFFFFFFE
+	cfbFATSector        = 0xFFFFFFFD
+	cfbNoStream         = 0xFFFFFFFF
+	cfbDirectoryEntry   = 128
+	cfbMiniSectorSize   = 64
+	cfbMiniStreamCutoff = 4096
+	cfbHeaderDIFATCount = 109
+	cfbTypeStream       = 2
+	cfbTypeStorage      = 1
+	cfbTypeRoot         = 5
+)
+
+// CFBEntry 复合文件中的流或存储，Storage为true时Children为存储中的目录项
+type CFBEntry struct {
+	Name     string
+	Data     []byte
+	Storage  bool
+	Children []CFBEntry
+}
+
+// cfbNode 分配了目录项序号的目录项
+type cfbNode struct {
+	entry      CFBEntry
+	entryType  byte
+	right      uint32
+	child      uint32
+	start      uint32
+	size       int
+	miniStream bool
+}
+
+// CFBBytes 构造版本为version（3或4）的复合文件，根存储中为entries
+//
+// 目录项按层次依次编号（根存储为0），同一存储中的目录项按给出的顺序以右兄弟相连；
+// 小于4096字节的流存放在迷你流中。扇区布局依次为FAT、目录、MiniFAT、迷你流和其他流，
+// 版本3中只有一个FAT扇区时FAT位于扇区0、目录从扇区1开始。
+func CFBBytes(version int, entries ...CFBEntry) []byte {
+	sectorSize := 512
+	if version == 4 {
+		sectorSize = 4096
+	}
+	le := binary.LittleEndian
+	sectors := func(size, unit int) int { return (size + unit - 1) / unit }
+
+	// 按层次为目录项编号
+	nodes := []*cfbNode{{entry: CFBEntry{Name: "Root Entry", Storage: true, Children: entries}, entryType: cfbTypeRoot}}
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[i]
+		node.child = cfbNoStream
+		for j, child := range node.entry.Children {
+			if j == 0 {
+				node.child = uint32(len(nodes))
+			}
+			right := uint32(cfbNoStream)
+			if j+1 < len(node.entry.Children) {
+				right = uint32(len(nodes) + 1)
+			}
+			entryType := byte(cfbTypeStream)
+			if child.Storage {
+				entryType = cfbTypeStorage
+			}
+			nodes = append(nodes, &cfbNode{entry: child, entryType: entryType, right: right, size: len(child.Data)})
+		}
+	}
+
+	// 为流分配迷你扇区和普通扇区
+	var miniStream, streams []byte
+	var miniFAT []uint32
+	var streamChains [][2]int // 各普通流相对第一个流扇区的起始扇区和扇区数
+	chain := func(table []uint32, start, count int) []uint32 {
+		for i := start; i < start+count; i++ {
+			table = append(table, uint32(i+1))
+		}
+		if count > 0 {
+			table[len(table)-1] = cfbEndOfChain
+		}
+		return table
+	}
+	for _, node := range nodes[1:] {
+		if node.entryType != cfbTypeStream {
+			continue
+		}
+		data := node.entry.Data
+		if len(data) < cfbMiniStreamCutoff {
+			count := sectors(len(data), cfbMiniSectorSize)
+			node.miniStream = true
+			node.start = uint32(len(miniFAT))
+			if count == 0 {
+				node.start = cfbEndOfChain
+			}
+			miniFAT = chain(miniFAT, len(miniFAT), count)
+			miniStream = append(miniStream, pad(data, count*cfbMiniSectorSize)...)
+			continue
+		}
+		count := sectors(len(data), sectorSize)
+		streamChains = append(streamChains, [2]int{len(streams) / sectorSize, count})
+		streams = append(streams, pad(data, count*sectorSize)...)
+	}
+
+	directorySectors := sectors(len(nodes)*cfbDirectoryEntry, sectorSize)
+	miniFATSectors := sectors(4*len(miniFAT), sectorSize)
+	miniStreamSectors := sectors(len(miniStream), sectorSize)
+	streamSectors := len(streams) / sectorSize
+	fatSectors := 1
+	for {
+		total := fatSectors + directorySectors + miniFATSectors + miniStreamSectors + streamSectors
+		if needed := sectors(4*total, sectorSize); needed > fatSectors {
+			fatSectors = needed
+			continue
+		}
+		break
+	}
+
+	// 构造FAT
+	directoryStart := fatSectors
+	miniFATStart := directoryStart + directorySectors
+	miniStreamStart := miniFATStart + miniFATSectors
+	streamStart := miniStreamStart + miniStreamSectors
+	var fat []uint32
+	for i := 0; i < fatSectors; i++ {
+		fat = append(fat, cfbFATSector)
+	}
+	fat = chain(fat, directoryStart, directorySectors)
+	fat = chain(fat, miniFATStart, miniFATSectors)
+	fat = chain(fat, miniStreamStart, miniStreamSectors)
+	next := 0
+	for _, node := range nodes[1:] {
+		if node.entryType != cfbTypeStream || node.miniStream {
+			continue
+		}
+		start, count := streamStart+streamChains[next][0], streamChains[next][1]
+		node.start = uint32(start)
+		fat = chain(fat, start, count)
+		next++
+	}
+	for len(fat) < fatSectors*sectorSize/4 {
+		fat = append(fat, cfbFreeSector)
+	}
+	for len(miniFAT) < miniFATSectors*sectorSize/4 {
+		miniFAT = append(miniFAT, cfbFreeSector)
+	}
+
+	root := nodes[0]
+	root.start, root.size = cfbEndOfChain, len(miniStream)
+	if miniStreamSectors > 0 {
+		root.start = uint32(miniStreamStart)
+	}
+
+	// 构造目录
+	directory := make([]byte, directorySectors*sectorSize)
+	for i := 0; i < len(directory)/cfbDirectoryEntry; i++ {
+		data := directory[i*cfbDirectoryEntry:]
+		le.PutUint32(data[68:], cfbNoStream)
+		le.PutUint32(data[72:], cfbNoStream)
+		le.PutUint32(data[76:], cfbNoStream)
+	}
+	for i, node := range nodes {
+		data := directory[i*cfbDirectoryEntry:]
+		units := utf16.Encode([]rune(node.entry.Name))
+		for j, unit := range units {
+			le.PutUint16(data[2*j:], unit)
+		}
+		le.PutUint16(data[64:], uint16(2*len(units)+2))
+		data[66] = node.entryType
+		data[67] = 1
+		le.PutUint32(data[72:], node.right)
+		le.PutUint32(data[76:], node.child)
+		le.PutUint32(data[116:], node.start)
+		le.PutUint64(data[120:], uint64(node.size))
+	}
+
+	// 构造文件头
+	header := make([]byte, sectorSize)
+	copy(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	le.PutUint16(header[24:], 0x3E)
+	le.PutUint16(header[26:], uint16(version))
+	le.PutUint16(header[28:], 0xFFFE)
+	if version == 4 {
+		le.PutUint16(header[30:], 12)
+	} else {
+		le.PutUint16(header[30:], 9)
+	}
+	le.PutUint16(header[32:], 6)
+	le.PutUint32(header[44:], uint32(fatSectors))
+	le.PutUint32(header[48:], uint32(directoryStart))
+	le.PutUint32(header[56:], cfbMiniStreamCutoff)
+	le.PutUint32(header[60:], cfbEndOfChain)
+	if miniFATSectors > 0 {
+		le.PutUint32(header[60:], uint32(miniFATStart))
+	}
+	le.PutUint32(header[64:], uint32(miniFATSectors))
+	le.PutUint32(header[68:], cfbEndOfChain)
+	for i := 0; i < cfbHeaderDIFATCount; i++ {
+		le.PutUint32(header[76+4*i:], cfbFreeSector)
+	}
+	for i := 0; i < fatSectors; i++ {
+		le.PutUint32(header[76+4*i:], uint32(i))
+	}
+
+	var file bytes.Buffer
+	file.Write(header)
+	for _, value := range fat {
+		binary.Write(&file, le, value)
+	}
+	file.Write(directory)
+	for _, value := range miniFAT {
+		binary.Write(&file, le, value)
+	}
+	file.Write(pad(miniStream, miniStreamSectors*sectorSize))
+	file.Write(streams)
+	return file.Bytes()
+}
+
+// pad 将data用0补足到size字节
+func pad(data []byte, size int) []byte {
+	padded := make([]byte, size)
+	copy(padded, data)
+	return padded
+}
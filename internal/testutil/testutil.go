@@ -0,0 +1,73 @@
+// Package testutil 为各包的测试构造最小的OOXML包和复合文件
+package testutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"sort"
+	"testing"
+)
+
+const (
+	// ContentTypesXML 不声明任何部件的[Content_Types].xml
+	ContentTypesXML = `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`
+	// RelationshipsXML 不含任何关系的关系部件
+	RelationshipsXML = `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"/>`
+)
+
+// DocumentXML 用WordprocessingML命名空间包装正文，生成主文档部件
+func DocumentXML(body string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`
+}
+
+// DocxParts 只含正文body的最小DOCX包的部件，调用方可以继续添加其他部件
+func DocxParts(body string) map[string]string {
+	return map[string]string{
+		"[Content_Types].xml": ContentTypesXML,
+		"_rels/.rels":         RelationshipsXML,
+		"word/document.xml":   DocumentXML(body),
+	}
+}
+
+// ZipBytes 按部件名称顺序将部件写为ZIP包
+func ZipBytes(t testing.TB, parts map[string]string) []byte {
+	t.Helper()
+
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for _, name := range names {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("写入%s失败: %v", name, err)
+		}
+		w.Write([]byte(parts[name]))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("生成测试文档失败: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// WriteZip 将部件写为path处的ZIP文件，返回path
+func WriteZip(t testing.TB, path string, parts map[string]string) string {
+	t.Helper()
+
+	if err := os.WriteFile(path, ZipBytes(t, parts), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	return path
+}
+
+// WriteDocx 在path写入只含正文body的最小DOCX文件，返回path
+func WriteDocx(t testing.TB, path, body string) string {
+	t.Helper()
+	return WriteZip(t, path, DocxParts(body))
+}
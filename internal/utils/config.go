@@ -38,6 +38,7 @@ type Config struct {
 	PerformanceOptions struct {
 		EnableCaching     bool `json:"enable_caching"`
 		CacheSize         int  `json:"cache_size"`
+		EnableDiskCache   bool `json:"enable_disk_cache"` // 在输出目录下持久化解析结果
 		EnableConcurrency bool `json:"enable_concurrency"`
 		MaxWorkers        int  `json:"max_workers"`
 	} `json:"performance_options"`
@@ -69,6 +70,7 @@ func DefaultConfig() *Config {
 	// 性能选项默认值
 	config.PerformanceOptions.EnableCaching = true
 	config.PerformanceOptions.CacheSize = 100
+	config.PerformanceOptions.EnableDiskCache = false
 	config.PerformanceOptions.EnableConcurrency = false
 	config.PerformanceOptions.MaxWorkers = 4
 
@@ -150,4 +152,13 @@ func GetConfigPath() string {
 		return "./docs-parser-config.json"
 	}
 	return filepath.Join(homeDir, ".docs-parser", "config.json")
+}
+
+// GetTemplateRegistryPath 获取模板注册表目录
+func GetTemplateRegistryPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./docs-parser-templates"
+	}
+	return filepath.Join(homeDir, ".docs-parser", "templates")
 } 
\ No newline at end of file
@@ -81,7 +81,10 @@ func ValidateFile(filePath string) error {
 func GetSupportedExtensions() []string {
 	return []string{
 		".docx", ".doc", ".rtf", ".wpd",
-		".dot", ".dotx", // 模板格式
+		".dot", ".dotx", ".dotm", // 模板格式
+		".docm", // 启用宏的文档
+		".odt", ".ott", // OpenDocument文本和模板
+		".html", ".htm", ".mht", ".mhtml", // Word另存为的网页
 	}
 }
 
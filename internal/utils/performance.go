@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -9,6 +10,7 @@ import (
 type PerformanceMonitor struct {
 	startTime time.Time
 	steps     map[string]time.Duration
+	counters  map[string]int64
 }
 
 // NewPerformanceMonitor 创建新的性能监控器
@@ -16,6 +18,7 @@ func NewPerformanceMonitor() *PerformanceMonitor {
 	return &PerformanceMonitor{
 		startTime: time.Now(),
 		steps:     make(map[string]time.Duration),
+		counters:  make(map[string]int64),
 	}
 }
 
@@ -43,9 +46,31 @@ func (pm *PerformanceMonitor) PrintReport() {
 			fmt.Printf("  - %s: %v\n", step, duration)
 		}
 	}
+
+	if len(pm.counters) > 0 {
+		fmt.Printf("统计计数:\n")
+		names := make([]string, 0, len(pm.counters))
+		for name := range pm.counters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  - %s: %d\n", name, pm.counters[name])
+		}
+	}
 	fmt.Printf("==================\n")
 }
 
+// SetCounter 设置统计计数（如缓存命中次数），报告中按名称排序输出
+func (pm *PerformanceMonitor) SetCounter(name string, value int64) {
+	pm.counters[name] = value
+}
+
+// GetCounter 获取统计计数
+func (pm *PerformanceMonitor) GetCounter(name string) int64 {
+	return pm.counters[name]
+}
+
 // GetStepTime 获取特定步骤的耗时
 func (pm *PerformanceMonitor) GetStepTime(stepName string) time.Duration {
 	return pm.steps[stepName]
@@ -55,4 +80,5 @@ func (pm *PerformanceMonitor) GetStepTime(stepName string) time.Duration {
 func (pm *PerformanceMonitor) Reset() {
 	pm.startTime = time.Now()
 	pm.steps = make(map[string]time.Duration)
+	pm.counters = make(map[string]int64)
 } 
\ No newline at end of file
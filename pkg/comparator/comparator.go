@@ -1,8 +1,11 @@
 package comparator
 
 import (
+	"context"
 	"fmt"
+	"docs-parser/internal/core/cache"
 	"docs-parser/internal/core/comparator"
+	"docs-parser/internal/core/revisions"
 	"docs-parser/internal/core/types"
 )
 
@@ -23,6 +26,37 @@ func NewComparator() *Comparator {
 	}
 }
 
+// SetRevisionPolicy 设置修订处理策略："accepted"按接受全部修订评估，"original"按原始文档评估
+func (c *Comparator) SetRevisionPolicy(policy string) error {
+	parsed, err := revisions.ParsePolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	comparator, err := c.factory.GetComparator("default")
+	if err != nil {
+		return err
+	}
+
+	if configurable, ok := comparator.(interface{ SetRevisionPolicy(revisions.Policy) }); ok {
+		configurable.SetRevisionPolicy(parsed)
+	}
+	return nil
+}
+
+// SetParseCache 设置解析结果缓存，nil表示不缓存
+func (c *Comparator) SetParseCache(parseCache *cache.ParseCache) error {
+	comparator, err := c.factory.GetComparator("default")
+	if err != nil {
+		return err
+	}
+
+	if configurable, ok := comparator.(interface{ SetParseCache(*cache.ParseCache) }); ok {
+		configurable.SetParseCache(parseCache)
+	}
+	return nil
+}
+
 // CompareWithTemplate 与模板进行对比
 func (c *Comparator) CompareWithTemplate(docPath, templatePath string) (*comparator.ComparisonReport, error) {
 	fmt.Printf("DEBUG: pkg/comparator 开始比较文档\n")
@@ -43,6 +77,87 @@ func (c *Comparator) CompareWithTemplate(docPath, templatePath string) (*compara
 	return report, nil
 }
 
+// CompareWithTemplateContext 受ctx控制的CompareWithTemplate
+//
+// 超时或取消时返回*types.TimeoutError，标注阶段被中断时同时返回已完成的报告。
+func (c *Comparator) CompareWithTemplateContext(ctx context.Context, docPath, templatePath string) (*comparator.ComparisonReport, error) {
+	// 获取默认对比器
+	impl, err := c.factory.GetComparator("default")
+	if err != nil {
+		return nil, err
+	}
+
+	if contextual, ok := impl.(interface {
+		CompareWithTemplateContext(context.Context, string, string) (*comparator.ComparisonReport, error)
+	}); ok {
+		return contextual.CompareWithTemplateContext(ctx, docPath, templatePath)
+	}
+
+	if err := types.CheckContext(ctx, "compare with template", docPath); err != nil {
+		return nil, err
+	}
+	return impl.CompareWithTemplate(docPath, templatePath)
+}
+
+// CompareWithRulesContext 按段落角色将文档与模板规则对比，用于没有Word模板文档的规则文件
+func (c *Comparator) CompareWithRulesContext(ctx context.Context, docPath, rulesPath string, rules *types.FormatRules) (*comparator.ComparisonReport, error) {
+	impl, err := c.factory.GetComparator("default")
+	if err != nil {
+		return nil, err
+	}
+
+	withRules, ok := impl.(interface {
+		CompareWithRulesContext(context.Context, string, string, *types.FormatRules) (*comparator.ComparisonReport, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("comparator does not support rule comparison")
+	}
+	return withRules.CompareWithRulesContext(ctx, docPath, rulesPath, rules)
+}
+
+// CompareBatchContext 将pattern（目录或通配符）匹配的所有文档与模板对比
+//
+// 标注文档按输入目录结构写入options.OutputDirectory，汇总报告写入其中的batch_report.json。
+func (c *Comparator) CompareBatchContext(ctx context.Context, pattern, templatePath string, options comparator.BatchOptions) (*comparator.BatchReport, error) {
+	root, files, err := comparator.CollectBatchFiles(pattern, options.OutputDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	impl, err := c.factory.GetComparator("default")
+	if err != nil {
+		return nil, err
+	}
+
+	batch, ok := impl.(interface {
+		CompareBatchContext(context.Context, string, []string, string, comparator.BatchOptions) (*comparator.BatchReport, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("comparator does not support batch comparison")
+	}
+	return batch.CompareBatchContext(ctx, root, files, templatePath, options)
+}
+
+// CompareDocumentsContext 受ctx控制的CompareDocuments
+func (c *Comparator) CompareDocumentsContext(ctx context.Context, doc1Path, doc2Path string) (*comparator.ComparisonReport, error) {
+	// 获取默认对比器
+	impl, err := c.factory.GetComparator("default")
+	if err != nil {
+		return nil, err
+	}
+
+	if contextual, ok := impl.(interface {
+		CompareDocumentsContext(context.Context, string, string) (*comparator.ComparisonReport, error)
+	}); ok {
+		return contextual.CompareDocumentsContext(ctx, doc1Path, doc2Path)
+	}
+
+	if err := types.CheckContext(ctx, "compare documents", doc1Path); err != nil {
+		return nil, err
+	}
+	return impl.CompareDocuments(doc1Path, doc2Path)
+}
+
 // CompareDocuments 对比两个文档
 func (c *Comparator) CompareDocuments(doc1Path, doc2Path string) (*comparator.ComparisonReport, error) {
 	// 获取默认对比器